@@ -0,0 +1,201 @@
+// Package cache instruments in-process caches. A Recorder counts hits, misses
+// and evictions, times gets and sets, tracks the cache's size and derives its
+// hit ratio, all tagged with the cache name. Cache libraries differ too much to
+// wrap generically, so a thin wrapper reports to it; for go-cache:
+//
+//	type Cache struct {
+//		*gocache.Cache
+//		metrics *cache.Recorder
+//	}
+//
+//	func (c *Cache) Get(key string) (any, bool) {
+//		start := time.Now()
+//		value, found := c.Cache.Get(key)
+//		c.metrics.RecordGet(start, found)
+//		return value, found
+//	}
+//
+// Eviction callbacks such as ristretto's OnEvict or bigcache's OnRemoveWithReason
+// call Evict, and a periodic task or reporter hook calls Sized.
+package cache
+
+import (
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "cache", Package: "github.com/MichaelAJay/go-metrics/integrations/cache"})
+}
+
+const (
+	// HitsMetric is the counter of lookups that found their key
+	HitsMetric = "cache_hits_total"
+	// MissesMetric is the counter of lookups that did not find their key
+	MissesMetric = "cache_misses_total"
+	// EvictionsMetric is the counter of entries removed from the cache, tagged with the reason
+	EvictionsMetric = "cache_evictions_total"
+	// HitRatioMetric is the gauge of hits over lookups, in parts per million,
+	// derived from the hit and miss counters whenever the registry is reported
+	HitRatioMetric = "cache_hit_ratio"
+	// GetMetric is the timer of lookups
+	GetMetric = "cache_get_duration"
+	// SetMetric is the timer of writes
+	SetMetric = "cache_set_duration"
+	// EntriesMetric is the gauge of entries held
+	EntriesMetric = "cache_entries"
+	// SizeMetric is the gauge of bytes held
+	SizeMetric = "cache_size_bytes"
+)
+
+// Reasons of EvictionsMetric
+const (
+	EvictExpired  = "expired"
+	EvictCapacity = "capacity"
+	EvictDeleted  = "deleted"
+	// EvictUnknown is recorded when Evict is called without a reason
+	EvictUnknown = "unknown"
+)
+
+// Observer receives the events of a cache. Recorder implements it; wrappers
+// can accept an Observer to stay testable without a registry.
+type Observer interface {
+	// Hit records a lookup that found its key
+	Hit()
+	// Miss records a lookup that did not find its key
+	Miss()
+	// Evict records an entry removed from the cache for reason, e.g. EvictExpired
+	Evict(reason string)
+	// Sized records the number of entries and bytes the cache holds; a
+	// negative bytes leaves the size unchanged, for caches that do not track it
+	Sized(entries int, bytes int64)
+}
+
+// Recorder records the events of one cache into a registry, tagged with cache=name
+type Recorder struct {
+	hits      metric.Counter
+	misses    metric.Counter
+	evictions metric.Counter
+	get       metric.Timer
+	set       metric.Timer
+	entries   metric.Gauge
+	size      metric.Gauge
+	name      string
+}
+
+var _ Observer = (*Recorder)(nil)
+
+// NewRecorder creates a recorder for the cache called name in registry. The hit
+// ratio gauge is only registered if registry implements metric.InstrumentRegistry.
+func NewRecorder(registry metric.Registry, name string) *Recorder {
+	tags := metric.Tags{"cache": name}
+	if instruments, ok := registry.(metric.InstrumentRegistry); ok {
+		instruments.Derived(metric.Options{
+			Name:        HitRatioMetric,
+			Description: "Cache hits over lookups",
+			Unit:        "ppm",
+			Tags:        tags,
+		}, hitRatio)
+	}
+	return &Recorder{
+		hits: registry.Counter(metric.Options{
+			Name:        HitsMetric,
+			Description: "Cache lookups that found their key",
+			Unit:        "count",
+			Tags:        tags,
+		}),
+		misses: registry.Counter(metric.Options{
+			Name:        MissesMetric,
+			Description: "Cache lookups that did not find their key",
+			Unit:        "count",
+			Tags:        tags,
+		}),
+		evictions: registry.Counter(metric.Options{
+			Name:        EvictionsMetric,
+			Description: "Entries removed from the cache, by reason",
+			Unit:        "count",
+		}),
+		get: registry.Timer(metric.Options{
+			Name:        GetMetric,
+			Description: "Duration of cache lookups",
+			Unit:        "nanoseconds",
+			Buckets:     metric.LatencyBucketsFast(),
+			Tags:        tags,
+		}),
+		set: registry.Timer(metric.Options{
+			Name:        SetMetric,
+			Description: "Duration of cache writes",
+			Unit:        "nanoseconds",
+			Buckets:     metric.LatencyBucketsFast(),
+			Tags:        tags,
+		}),
+		entries: registry.Gauge(metric.Options{
+			Name:        EntriesMetric,
+			Description: "Entries held by the cache",
+			Unit:        "count",
+			Tags:        tags,
+		}),
+		size: registry.Gauge(metric.Options{
+			Name:        SizeMetric,
+			Description: "Bytes held by the cache",
+			Unit:        "bytes",
+			Tags:        tags,
+		}),
+		name: name,
+	}
+}
+
+// hitRatio derives the hit ratio of the cache tagged like the derived gauge.
+// Without lookups it divides zero by zero, which keeps the previous value.
+func hitRatio(s metric.SnapshotView) float64 {
+	hits := s.Value(HitsMetric, s.Tags())
+	misses := s.Value(MissesMetric, s.Tags())
+	return hits / (hits + misses) * 1e6
+}
+
+func (r *Recorder) Hit() {
+	r.hits.Inc()
+}
+
+func (r *Recorder) Miss() {
+	r.misses.Inc()
+}
+
+func (r *Recorder) Evict(reason string) {
+	if reason == "" {
+		reason = EvictUnknown
+	}
+	r.evictions.With(metric.Tags{"cache": r.name, "reason": reason}).Inc()
+}
+
+func (r *Recorder) Sized(entries int, bytes int64) {
+	r.entries.Set(float64(entries))
+	if bytes >= 0 {
+		r.size.Set(float64(bytes))
+	}
+}
+
+// RecordGet records a lookup started at start as a hit or a miss
+func (r *Recorder) RecordGet(start time.Time, found bool) {
+	r.get.RecordSince(start)
+	if found {
+		r.Hit()
+	} else {
+		r.Miss()
+	}
+}
+
+// RecordSet records a write started at start
+func (r *Recorder) RecordSet(start time.Time) {
+	r.set.RecordSince(start)
+}
+
+// HitRatio returns hits over lookups so far, from 0 to 1, or 1 without lookups
+func (r *Recorder) HitRatio() float64 {
+	hits, misses := r.hits.Value(), r.misses.Value()
+	if hits+misses == 0 {
+		return 1
+	}
+	return float64(hits) / float64(hits+misses)
+}
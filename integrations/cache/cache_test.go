@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestRecorder(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	recorder := NewRecorder(registry, "sessions")
+	start := time.Now()
+	recorder.RecordGet(start, true)
+	recorder.RecordGet(start, true)
+	recorder.RecordGet(start, true)
+	recorder.RecordGet(start, false)
+	recorder.RecordSet(start)
+	recorder.Evict(EvictExpired)
+	recorder.Evict("")
+	recorder.Sized(120, -1)
+
+	tags := metric.Tags{"cache": "sessions"}
+	if got := recorder.HitRatio(); got != 0.75 {
+		t.Errorf("Expected a hit ratio of 0.75, got %v", got)
+	}
+	if got := registry.Timer(metric.Options{Name: GetMetric, Tags: tags}).Snapshot().Count; got != 4 {
+		t.Errorf("Expected four timed lookups, got %d", got)
+	}
+	if got := registry.Timer(metric.Options{Name: SetMetric, Tags: tags}).Snapshot().Count; got != 1 {
+		t.Errorf("Expected one timed write, got %d", got)
+	}
+	for _, reason := range []string{EvictExpired, EvictUnknown} {
+		if got := registry.Counter(metric.Options{Name: EvictionsMetric, Tags: metric.Tags{"cache": "sessions", "reason": reason}}).Value(); got != 1 {
+			t.Errorf("Expected one eviction for %s, got %d", reason, got)
+		}
+	}
+	if got := registry.Gauge(metric.Options{Name: EntriesMetric, Tags: tags}).Value(); got != 120 {
+		t.Errorf("Expected 120 entries, got %d", got)
+	}
+
+	// The derived ratio is computed when the registry is iterated
+	var ratio int64 = -1
+	var sized bool
+	registry.Each(func(m metric.Metric) {
+		switch m.Name() {
+		case HitRatioMetric:
+			ratio = m.(metric.Gauge).Value()
+		case SizeMetric:
+			sized = m.(metric.Gauge).Value() != 0
+		}
+	})
+	if ratio != 750000 {
+		t.Errorf("Expected the derived hit ratio in ppm, got %d", ratio)
+	}
+	if sized {
+		t.Error("Expected a negative size to leave the size gauge unset")
+	}
+}
+
+func TestRecordersAreSeparate(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	NewRecorder(registry, "users").Hit()
+	NewRecorder(registry, "sessions").Miss()
+	if got := NewRecorder(registry, "users").HitRatio(); got != 1 {
+		t.Errorf("Expected recorders of one cache to share its series, got %v", got)
+	}
+
+	ratios := make(map[string]int64)
+	registry.Each(func(m metric.Metric) {
+		if m.Name() == HitRatioMetric {
+			ratios[m.Tags()["cache"]] = m.(metric.Gauge).Value()
+		}
+	})
+	if ratios["users"] != 1000000 || ratios["sessions"] != 0 {
+		t.Errorf("Expected a hit ratio per cache, got %v", ratios)
+	}
+}
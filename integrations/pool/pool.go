@@ -0,0 +1,211 @@
+// Package pool instruments worker pools and errgroups. A Tracker records, per
+// pool name, how many tasks are queued and running, how they completed, how
+// long they waited for a worker and how long they ran:
+//
+//	tracker := pool.NewTracker(registry, "thumbnails")
+//	group := pool.WrapGroup(new(errgroup.Group), tracker)
+//	group.Go(func() error { return resize(img) })
+//
+// Pools with a Submit(func()) error method, such as ants, are wrapped with
+// WrapPool; any other pool can run tasks wrapped with Tracker.Wrap.
+package pool
+
+import (
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "pool", Package: "github.com/MichaelAJay/go-metrics/integrations/pool"})
+}
+
+const (
+	// QueuedMetric is the up-down counter of tasks submitted but not yet started
+	QueuedMetric = "pool_tasks_queued"
+	// ActiveMetric is the up-down counter of tasks running
+	ActiveMetric = "pool_tasks_active"
+	// CompletedMetric is the counter of finished tasks, tagged with their outcome
+	CompletedMetric = "pool_tasks_completed_total"
+	// RejectedMetric is the counter of tasks the pool refused to queue
+	RejectedMetric = "pool_tasks_rejected_total"
+	// WaitMetric is the timer of how long tasks were queued before a worker started them
+	WaitMetric = "pool_queue_wait_duration"
+	// DurationMetric is the timer of how long tasks ran
+	DurationMetric = "pool_task_duration"
+)
+
+// Outcomes of CompletedMetric
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+	OutcomePanic   = "panic"
+)
+
+// Tracker records the tasks of one pool, tagged with pool=name
+type Tracker struct {
+	queued    metric.UpDownCounter
+	active    metric.UpDownCounter
+	succeeded metric.Counter
+	failed    metric.Counter
+	panicked  metric.Counter
+	rejected  metric.Counter
+	wait      metric.Timer
+	duration  metric.Timer
+}
+
+// NewTracker creates a tracker recording into registry under the given pool name
+func NewTracker(registry metric.InstrumentRegistry, name string) *Tracker {
+	tags := metric.Tags{"pool": name}
+	completed := registry.Counter(metric.Options{
+		Name:        CompletedMetric,
+		Description: "Pool tasks finished, by outcome",
+		Unit:        "count",
+	})
+	return &Tracker{
+		queued: registry.UpDownCounter(metric.Options{
+			Name:        QueuedMetric,
+			Description: "Pool tasks submitted but not yet started",
+			Unit:        "count",
+		}).With(tags),
+		active: registry.UpDownCounter(metric.Options{
+			Name:        ActiveMetric,
+			Description: "Pool tasks running",
+			Unit:        "count",
+		}).With(tags),
+		succeeded: completed.With(metric.Tags{"pool": name, "outcome": OutcomeSuccess}),
+		failed:    completed.With(metric.Tags{"pool": name, "outcome": OutcomeError}),
+		panicked:  completed.With(metric.Tags{"pool": name, "outcome": OutcomePanic}),
+		rejected: registry.Counter(metric.Options{
+			Name:        RejectedMetric,
+			Description: "Pool tasks the pool refused to queue",
+			Unit:        "count",
+		}).With(tags),
+		wait: registry.Timer(metric.Options{
+			Name:        WaitMetric,
+			Description: "Time pool tasks waited for a worker",
+			Unit:        "nanoseconds",
+		}).With(tags),
+		duration: registry.Timer(metric.Options{
+			Name:        DurationMetric,
+			Description: "Time pool tasks ran",
+			Unit:        "nanoseconds",
+		}).With(tags),
+	}
+}
+
+// Wrap counts task as queued and returns a function that runs it, recording
+// its queue wait, duration and outcome. Hand the result to the pool right
+// away, and call Reject if the pool refuses it, so the queue depth stays exact.
+// A panicking task is counted with OutcomePanic and the panic propagated.
+func (t *Tracker) Wrap(task func()) func() {
+	wrapped := t.WrapErr(func() error {
+		task()
+		return nil
+	})
+	return func() { wrapped() }
+}
+
+// WrapErr is Wrap for tasks returning an error, counted with OutcomeError when non-nil
+func (t *Tracker) WrapErr(task func() error) func() error {
+	queued := time.Now()
+	t.queued.Inc()
+	return func() (err error) {
+		started := time.Now()
+		t.queued.Dec()
+		t.active.Inc()
+		t.wait.Record(started.Sub(queued))
+
+		completed := false
+		defer func() {
+			t.duration.RecordSince(started)
+			t.active.Dec()
+			switch {
+			case !completed:
+				t.panicked.Inc()
+			case err != nil:
+				t.failed.Inc()
+			default:
+				t.succeeded.Inc()
+			}
+		}()
+		err = task()
+		completed = true
+		return err
+	}
+}
+
+// Reject undoes the queueing of a wrapped task the pool refused and counts it as rejected
+func (t *Tracker) Reject() {
+	t.queued.Dec()
+	t.rejected.Inc()
+}
+
+// Group is the subset of *errgroup.Group wrapped by WrapGroup
+type Group interface {
+	Go(f func() error)
+	Wait() error
+}
+
+// InstrumentedGroup runs the tasks of a Group through a Tracker
+type InstrumentedGroup struct {
+	group   Group
+	tracker *Tracker
+}
+
+// WrapGroup returns group recording its tasks in tracker. With a limit set on
+// an errgroup, Go blocks until a goroutine is free; that time is not queue wait,
+// since the task is only queued once Go returns.
+func WrapGroup(group Group, tracker *Tracker) *InstrumentedGroup {
+	return &InstrumentedGroup{group: group, tracker: tracker}
+}
+
+// Go runs f in the group
+func (g *InstrumentedGroup) Go(f func() error) {
+	g.group.Go(g.tracker.WrapErr(f))
+}
+
+// TryGo runs f in the group if it is below its limit and reports whether it
+// did; groups without a TryGo method always run f
+func (g *InstrumentedGroup) TryGo(f func() error) bool {
+	tryGroup, ok := g.group.(interface{ TryGo(f func() error) bool })
+	if !ok {
+		g.Go(f)
+		return true
+	}
+	if tryGroup.TryGo(g.tracker.WrapErr(f)) {
+		return true
+	}
+	g.tracker.Reject()
+	return false
+}
+
+// Wait waits for the group's tasks and returns the first error, as the group does
+func (g *InstrumentedGroup) Wait() error {
+	return g.group.Wait()
+}
+
+// Submitter is a worker pool accepting tasks, such as *ants.Pool
+type Submitter interface {
+	Submit(task func()) error
+}
+
+// InstrumentedPool submits tasks to a Submitter through a Tracker
+type InstrumentedPool struct {
+	pool    Submitter
+	tracker *Tracker
+}
+
+// WrapPool returns pool recording its tasks in tracker
+func WrapPool(pool Submitter, tracker *Tracker) *InstrumentedPool {
+	return &InstrumentedPool{pool: pool, tracker: tracker}
+}
+
+// Submit queues task in the pool, counting it as rejected if the pool returns an error
+func (p *InstrumentedPool) Submit(task func()) error {
+	if err := p.pool.Submit(p.tracker.Wrap(task)); err != nil {
+		p.tracker.Reject()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package pool
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// waitGroup is a Group without a limit, like a zero errgroup.Group
+type waitGroup struct {
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	err      error
+	full     bool // TryGo refuses tasks while set
+	released chan struct{}
+}
+
+func (g *waitGroup) Go(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.released != nil {
+			<-g.released
+		}
+		if err := f(); err != nil {
+			g.mu.Lock()
+			if g.err == nil {
+				g.err = err
+			}
+			g.mu.Unlock()
+		}
+	}()
+}
+
+func (g *waitGroup) TryGo(f func() error) bool {
+	if g.full {
+		return false
+	}
+	g.Go(f)
+	return true
+}
+
+func (g *waitGroup) Wait() error {
+	g.wg.Wait()
+	return g.err
+}
+
+func counterValue(registry metric.Registry, name string, tags metric.Tags) uint64 {
+	return registry.Counter(metric.Options{Name: name, Tags: tags}).Value()
+}
+
+func upDownValue(registry metric.InstrumentRegistry, name string, tags metric.Tags) int64 {
+	return registry.UpDownCounter(metric.Options{Name: name, Tags: tags}).Value()
+}
+
+func TestGroup(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry().(metric.InstrumentRegistry)
+	defer registry.Close()
+
+	inner := &waitGroup{released: make(chan struct{})}
+	group := WrapGroup(inner, NewTracker(registry, "resize"))
+	boom := errors.New("boom")
+	group.Go(func() error { return nil })
+	group.Go(func() error { return boom })
+
+	tags := metric.Tags{"pool": "resize"}
+	if got := upDownValue(registry, QueuedMetric, tags); got != 2 {
+		t.Errorf("Expected two queued tasks before they start, got %d", got)
+	}
+	close(inner.released)
+	if err := group.Wait(); err != boom {
+		t.Errorf("Expected the group's error, got %v", err)
+	}
+
+	if got := upDownValue(registry, QueuedMetric, tags); got != 0 {
+		t.Errorf("Expected an empty queue, got %d", got)
+	}
+	if got := upDownValue(registry, ActiveMetric, tags); got != 0 {
+		t.Errorf("Expected no active tasks, got %d", got)
+	}
+	if got := counterValue(registry, CompletedMetric, metric.Tags{"pool": "resize", "outcome": OutcomeSuccess}); got != 1 {
+		t.Errorf("Expected one successful task, got %d", got)
+	}
+	if got := counterValue(registry, CompletedMetric, metric.Tags{"pool": "resize", "outcome": OutcomeError}); got != 1 {
+		t.Errorf("Expected one failed task, got %d", got)
+	}
+	if got := registry.Timer(metric.Options{Name: WaitMetric, Tags: tags}).Snapshot().Count; got != 2 {
+		t.Errorf("Expected the queue wait of both tasks, got %d", got)
+	}
+	if got := registry.Timer(metric.Options{Name: DurationMetric, Tags: tags}).Snapshot().Count; got != 2 {
+		t.Errorf("Expected the duration of both tasks, got %d", got)
+	}
+}
+
+func TestGroupTryGo(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry().(metric.InstrumentRegistry)
+	defer registry.Close()
+
+	inner := &waitGroup{full: true}
+	group := WrapGroup(inner, NewTracker(registry, "resize"))
+	if group.TryGo(func() error { return nil }) {
+		t.Fatal("Expected a full group to refuse the task")
+	}
+	tags := metric.Tags{"pool": "resize"}
+	if got := upDownValue(registry, QueuedMetric, tags); got != 0 {
+		t.Errorf("Expected a refused task not to stay queued, got %d", got)
+	}
+	if got := counterValue(registry, RejectedMetric, tags); got != 1 {
+		t.Errorf("Expected the refused task to be counted, got %d", got)
+	}
+}
+
+// submitter runs tasks synchronously, refusing them once closed
+type submitter struct{ closed bool }
+
+func (s *submitter) Submit(task func()) error {
+	if s.closed {
+		return errors.New("pool closed")
+	}
+	task()
+	return nil
+}
+
+func TestPool(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry().(metric.InstrumentRegistry)
+	defer registry.Close()
+
+	inner := &submitter{}
+	pool := WrapPool(inner, NewTracker(registry, "emails"))
+	if err := pool.Submit(func() {}); err != nil {
+		t.Fatal(err)
+	}
+	func() {
+		defer func() { recover() }()
+		pool.Submit(func() { panic("boom") })
+	}()
+	inner.closed = true
+	if err := pool.Submit(func() {}); err == nil {
+		t.Error("Expected the pool's error")
+	}
+
+	tags := metric.Tags{"pool": "emails"}
+	if got := counterValue(registry, CompletedMetric, metric.Tags{"pool": "emails", "outcome": OutcomePanic}); got != 1 {
+		t.Errorf("Expected the panicking task to be counted, got %d", got)
+	}
+	if got := counterValue(registry, RejectedMetric, tags); got != 1 {
+		t.Errorf("Expected the refused task to be counted, got %d", got)
+	}
+	if got := upDownValue(registry, QueuedMetric, tags); got != 0 {
+		t.Errorf("Expected an empty queue, got %d", got)
+	}
+	if got := upDownValue(registry, ActiveMetric, tags); got != 0 {
+		t.Errorf("Expected the panicking task not to stay active, got %d", got)
+	}
+}
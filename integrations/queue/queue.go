@@ -0,0 +1,168 @@
+// Package queue instruments message queue consumers. Wrapping a handler
+// records, per topic, the messages processed by outcome, how long each took
+// and how many were redeliveries; the consumer loop reports its lag:
+//
+//	consumer := queue.NewConsumer(registry)
+//	handle := queue.WrapHandler(consumer, "orders", func(msg *sarama.ConsumerMessage) error {
+//		return process(msg.Value)
+//	})
+//	consumer.SetLag("orders", highWatermark-msg.Offset)
+//
+// It works with any client library, since the handler's message type is a
+// type parameter.
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "queue", Package: "github.com/MichaelAJay/go-metrics/integrations/queue"})
+}
+
+const (
+	// LagMetric is the gauge of messages published but not yet consumed, set by SetLag
+	LagMetric = "queue_consumer_lag"
+	// ProcessedMetric is the counter of messages handled, tagged with their outcome
+	ProcessedMetric = "queue_messages_processed_total"
+	// DurationMetric is the timer of how long the handler took per message
+	DurationMetric = "queue_processing_duration"
+	// RedeliveriesMetric is the counter of messages delivered more than once
+	RedeliveriesMetric = "queue_redeliveries_total"
+)
+
+// Outcomes of ProcessedMetric
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+	OutcomePanic   = "panic"
+)
+
+// Redeliverable is implemented by messages that know whether they were
+// delivered before, e.g. after a consumer crashed or a negative acknowledgement.
+// Wrapped handlers count such messages in RedeliveriesMetric; for messages
+// that expose it otherwise, such as a field, call Consumer.Redelivered.
+type Redeliverable interface {
+	Redelivered() bool
+}
+
+// Consumer records the messages consumed from a queue's topics
+type Consumer struct {
+	lag          metric.Gauge
+	processed    metric.Counter
+	duration     metric.Timer
+	redeliveries metric.Counter
+
+	mu     sync.Mutex
+	topics map[string]*topicMetrics
+}
+
+// topicMetrics are the series of one topic
+type topicMetrics struct {
+	lag          metric.Gauge
+	succeeded    metric.Counter
+	failed       metric.Counter
+	panicked     metric.Counter
+	duration     metric.Timer
+	redeliveries metric.Counter
+}
+
+// NewConsumer creates a consumer recording into registry
+func NewConsumer(registry metric.Registry) *Consumer {
+	return &Consumer{
+		lag: registry.Gauge(metric.Options{
+			Name:        LagMetric,
+			Description: "Messages published but not yet consumed",
+			Unit:        "count",
+		}),
+		processed: registry.Counter(metric.Options{
+			Name:        ProcessedMetric,
+			Description: "Messages handled, by outcome",
+			Unit:        "count",
+		}),
+		duration: registry.Timer(metric.Options{
+			Name:        DurationMetric,
+			Description: "Time taken to handle a message",
+			Unit:        "nanoseconds",
+		}),
+		redeliveries: registry.Counter(metric.Options{
+			Name:        RedeliveriesMetric,
+			Description: "Messages delivered more than once",
+			Unit:        "count",
+		}),
+		topics: make(map[string]*topicMetrics),
+	}
+}
+
+// topic returns the series of topic, creating them on first use
+func (c *Consumer) topic(topic string) *topicMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t, ok := c.topics[topic]; ok {
+		return t
+	}
+	tags := metric.Tags{"topic": topic}
+	t := &topicMetrics{
+		lag:          c.lag.With(tags),
+		succeeded:    c.processed.With(metric.Tags{"topic": topic, "outcome": OutcomeSuccess}),
+		failed:       c.processed.With(metric.Tags{"topic": topic, "outcome": OutcomeError}),
+		panicked:     c.processed.With(metric.Tags{"topic": topic, "outcome": OutcomePanic}),
+		duration:     c.duration.With(tags),
+		redeliveries: c.redeliveries.With(tags),
+	}
+	c.topics[topic] = t
+	return t
+}
+
+// SetLag records how many messages of topic are waiting to be consumed, e.g.
+// the partition's high watermark minus the offset just consumed
+func (c *Consumer) SetLag(topic string, messages int64) {
+	c.topic(topic).lag.Set(float64(messages))
+}
+
+// Redelivered counts a redelivered message of topic, for messages that do not
+// implement Redeliverable
+func (c *Consumer) Redelivered(topic string) {
+	c.topic(topic).redeliveries.Inc()
+}
+
+// WrapHandler returns h recording every message of topic it handles. A
+// non-nil error is counted with OutcomeError; a panic is counted with
+// OutcomePanic and propagated.
+func WrapHandler[M any](c *Consumer, topic string, h func(msg M) error) func(msg M) error {
+	wrapped := WrapContextHandler(c, topic, func(_ context.Context, msg M) error { return h(msg) })
+	return func(msg M) error {
+		return wrapped(context.Background(), msg)
+	}
+}
+
+// WrapContextHandler is WrapHandler for handlers taking a context
+func WrapContextHandler[M any](c *Consumer, topic string, h func(ctx context.Context, msg M) error) func(ctx context.Context, msg M) error {
+	t := c.topic(topic)
+	return func(ctx context.Context, msg M) (err error) {
+		if r, ok := any(msg).(Redeliverable); ok && r.Redelivered() {
+			t.redeliveries.Inc()
+		}
+
+		start := time.Now()
+		completed := false
+		defer func() {
+			t.duration.RecordSince(start)
+			switch {
+			case !completed:
+				t.panicked.Inc()
+			case err != nil:
+				t.failed.Inc()
+			default:
+				t.succeeded.Inc()
+			}
+		}()
+		err = h(ctx, msg)
+		completed = true
+		return err
+	}
+}
@@ -0,0 +1,91 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+type message struct {
+	body        string
+	redelivered bool
+}
+
+func (m message) Redelivered() bool {
+	return m.redelivered
+}
+
+func processed(registry metric.Registry, topic, outcome string) uint64 {
+	return registry.Counter(metric.Options{Name: ProcessedMetric, Tags: metric.Tags{"topic": topic, "outcome": outcome}}).Value()
+}
+
+func TestWrapHandler(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	consumer := NewConsumer(registry)
+	handle := WrapHandler(consumer, "orders", func(msg message) error {
+		switch msg.body {
+		case "bad":
+			return errors.New("invalid order")
+		case "crash":
+			panic("boom")
+		}
+		return nil
+	})
+
+	handle(message{body: "ok"})
+	handle(message{body: "ok", redelivered: true})
+	if err := handle(message{body: "bad"}); err == nil {
+		t.Error("Expected the handler's error")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the panic to propagate")
+			}
+		}()
+		handle(message{body: "crash"})
+	}()
+
+	for outcome, want := range map[string]uint64{OutcomeSuccess: 2, OutcomeError: 1, OutcomePanic: 1} {
+		if got := processed(registry, "orders", outcome); got != want {
+			t.Errorf("Expected %d messages with outcome %s, got %d", want, outcome, got)
+		}
+	}
+	tags := metric.Tags{"topic": "orders"}
+	if got := registry.Timer(metric.Options{Name: DurationMetric, Tags: tags}).Snapshot().Count; got != 4 {
+		t.Errorf("Expected the duration of every message, got %d", got)
+	}
+	if got := registry.Counter(metric.Options{Name: RedeliveriesMetric, Tags: tags}).Value(); got != 1 {
+		t.Errorf("Expected one redelivery, got %d", got)
+	}
+}
+
+func TestConsumerLagAndManualRedelivery(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	consumer := NewConsumer(registry)
+	handle := WrapContextHandler(consumer, "payments", func(ctx context.Context, body []byte) error {
+		consumer.Redelivered("payments")
+		return ctx.Err()
+	})
+	if err := handle(context.Background(), []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	consumer.SetLag("payments", 42)
+
+	tags := metric.Tags{"topic": "payments"}
+	if got := registry.Gauge(metric.Options{Name: LagMetric, Tags: tags}).Value(); got != 42 {
+		t.Errorf("Expected a lag of 42, got %d", got)
+	}
+	if got := registry.Counter(metric.Options{Name: RedeliveriesMetric, Tags: tags}).Value(); got != 1 {
+		t.Errorf("Expected the manual redelivery, got %d", got)
+	}
+	if got := processed(registry, "payments", OutcomeSuccess); got != 1 {
+		t.Errorf("Expected the message to be processed, got %d", got)
+	}
+}
@@ -0,0 +1,168 @@
+package metric
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// adaptiveSignificantDigits is how many significant digits the boundaries
+// chosen by an adaptive histogram are rounded to, so they read well on dashboards
+const adaptiveSignificantDigits = 2
+
+// adaptiveHistogram implements AdaptiveHistogram by observing into a histogram
+// with the warm-up boundaries while keeping the values, then replacing it with
+// one using boundaries chosen from them
+type adaptiveHistogram struct {
+	baseMetric
+	opts    Options
+	current atomic.Pointer[histogramImpl]
+	frozen  atomic.Bool
+
+	mu       sync.Mutex
+	samples  []float64 // warm-up observations, until frozen
+	reported int       // samples[:reported] were already returned by SnapshotAndReset
+
+	derive func(tags Tags) Histogram // set by the registry to look up With() series
+}
+
+func newAdaptiveHistogram(opts Options) AdaptiveHistogram {
+	a := &adaptiveHistogram{
+		baseMetric: baseMetric{
+			name:        opts.Name,
+			description: opts.Description,
+			unit:        opts.Unit,
+			metricType:  TypeHistogram,
+			tags:        opts.Tags,
+			annotations: maps.Clone(opts.Annotations),
+		},
+		opts:    opts,
+		samples: make([]float64, 0, opts.AdaptiveWarmUp),
+	}
+	a.current.Store(newHistogram(opts).(*histogramImpl))
+	a.markCreated()
+	return a
+}
+
+func (a *adaptiveHistogram) Observe(value float64) {
+	if a.isPaused() {
+		return
+	}
+	a.markWritten()
+	if !a.frozen.Load() && a.observeWarmUp(value) {
+		observe(a.sink, a, ObservationSample, value)
+		return
+	}
+	a.current.Load().Observe(value)
+	observe(a.sink, a, ObservationSample, value)
+}
+
+// observeWarmUp records value during the warm-up, freezing the boundaries once
+// it is complete, and reports false if the histogram was frozen meanwhile
+func (a *adaptiveHistogram) observeWarmUp(value float64) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.frozen.Load() {
+		return false
+	}
+	a.current.Load().Observe(value)
+	a.samples = append(a.samples, value)
+	if len(a.samples) >= a.opts.AdaptiveWarmUp {
+		a.freeze()
+	}
+	return true
+}
+
+func (a *adaptiveHistogram) Freeze() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.frozen.Load() {
+		a.freeze()
+	}
+}
+
+// freeze replaces the warm-up histogram with one using boundaries chosen from
+// the samples, replaying those not yet reported into it. The caller must hold mu.
+func (a *adaptiveHistogram) freeze() {
+	warmUp := a.current.Load()
+	if boundaries := adaptiveBoundaries(a.samples, len(warmUp.boundaries)); boundaries != nil {
+		opts := a.opts
+		opts.Buckets = boundaries
+		next := newHistogram(opts).(*histogramImpl)
+		for _, value := range a.samples[a.reported:] {
+			next.Observe(value)
+		}
+		a.current.Store(next)
+	}
+	a.samples, a.reported = nil, 0
+	a.frozen.Store(true)
+}
+
+func (a *adaptiveHistogram) Frozen() bool {
+	return a.frozen.Load()
+}
+
+func (a *adaptiveHistogram) With(tags Tags) Histogram {
+	if a.derive != nil {
+		return a.derive(copyTags(a.tags, tags))
+	}
+	opts := a.opts
+	opts.Description = a.Description()
+	opts.Unit = a.Unit()
+	opts.Tags = copyTags(a.tags, tags)
+	return newAdaptiveHistogram(opts)
+}
+
+func (a *adaptiveHistogram) Snapshot() HistogramSnapshot {
+	return a.current.Load().Snapshot()
+}
+
+// SnapshotAndReset returns the observations since the previous reset. Values
+// reset during the warm-up still choose the boundaries but are not moved into
+// them, so each observation is returned once.
+func (a *adaptiveHistogram) SnapshotAndReset() HistogramSnapshot {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.markCreated()
+	snapshot := a.current.Load().SnapshotAndReset()
+	if !a.frozen.Load() {
+		a.reported = len(a.samples)
+	}
+	return snapshot
+}
+
+// adaptiveBoundaries returns count exponential boundaries from half the
+// smallest to twice the largest positive sample, rounded to
+// adaptiveSignificantDigits, or nil if no sample is positive. Boundaries
+// rounding to the same value are merged, so there may be fewer than count.
+func adaptiveBoundaries(samples []float64, count int) []float64 {
+	lowest, highest := math.Inf(1), 0.0
+	for _, v := range samples {
+		if v > 0 && !math.IsInf(v, 1) {
+			lowest = math.Min(lowest, v)
+			highest = math.Max(highest, v)
+		}
+	}
+	if highest == 0 || count < 1 {
+		return nil
+	}
+
+	lower, upper := lowest/2, highest*2
+	if count == 1 {
+		return []float64{roundSignificant(upper, adaptiveSignificantDigits)}
+	}
+	factor := math.Pow(upper/lower, 1/float64(count-1))
+	boundaries := make([]float64, count)
+	for i := range boundaries {
+		boundaries[i] = roundSignificant(lower*math.Pow(factor, float64(i)), adaptiveSignificantDigits)
+	}
+	return slices.Compact(boundaries)
+}
+
+// roundSignificant rounds a positive v to digits significant digits
+func roundSignificant(v float64, digits int) float64 {
+	scale := math.Pow(10, float64(digits-1)-math.Floor(math.Log10(v)))
+	return math.Round(v*scale) / scale
+}
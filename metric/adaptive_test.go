@@ -0,0 +1,119 @@
+package metric
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAdaptiveHistogramFreezesAfterWarmUp(t *testing.T) {
+	h := newAdaptiveHistogram(Options{Name: "latency", AdaptiveWarmUp: 4})
+
+	for _, v := range []float64{20, 40, 80} {
+		h.Observe(v)
+	}
+	if h.Frozen() {
+		t.Fatal("Expected the histogram to warm up for four observations")
+	}
+	if s := h.Snapshot(); !slices.Equal(s.Boundaries, DefaultBuckets()) || s.Count != 3 {
+		t.Errorf("Expected warm-up observations in the default buckets, got %+v", s)
+	}
+
+	h.Observe(160)
+	if !h.Frozen() {
+		t.Fatal("Expected the histogram to freeze after the warm-up")
+	}
+	s := h.Snapshot()
+	if s.Boundaries[0] != 10 || s.Boundaries[len(s.Boundaries)-1] != 320 {
+		t.Errorf("Expected boundaries from 10 to 320, got %v", s.Boundaries)
+	}
+	if len(s.Boundaries) != len(DefaultBuckets()) {
+		t.Errorf("Expected as many boundaries as the warm-up used, got %v", s.Boundaries)
+	}
+	if s.Count != 4 || s.Sum != 300 || s.Min != 20 || s.Max != 160 {
+		t.Errorf("Expected the warm-up observations to be moved, got %+v", s)
+	}
+	var counted uint64
+	for i, n := range s.Buckets {
+		counted += n
+		if i == len(s.Buckets)-1 && n != 0 {
+			t.Errorf("Expected no warm-up observation beyond the last boundary, got %d", n)
+		}
+	}
+	if counted != 4 {
+		t.Errorf("Expected the buckets to hold the four observations, got %v", s.Buckets)
+	}
+
+	h.Observe(1000)
+	if s := h.Snapshot(); s.Count != 5 || s.Buckets[len(s.Buckets)-1] != 1 {
+		t.Errorf("Expected later observations in the frozen buckets, got %+v", s)
+	}
+}
+
+func TestAdaptiveHistogramResetDuringWarmUp(t *testing.T) {
+	h := newAdaptiveHistogram(Options{Name: "latency", AdaptiveWarmUp: 3})
+	h.Observe(1)
+	if s := h.SnapshotAndReset(); s.Count != 1 {
+		t.Errorf("Expected the first observation, got %+v", s)
+	}
+
+	h.Observe(2)
+	h.Observe(100)
+	s := h.SnapshotAndReset()
+	if s.Count != 2 || s.Sum != 102 {
+		t.Errorf("Expected only the observations since the reset to be moved, got %+v", s)
+	}
+	if s.Boundaries[0] != 0.5 {
+		t.Errorf("Expected the reset observation to still choose the boundaries, got %v", s.Boundaries)
+	}
+}
+
+func TestAdaptiveHistogramFreeze(t *testing.T) {
+	h := newAdaptiveHistogram(Options{Name: "latency", AdaptiveWarmUp: 1000, Buckets: []float64{1, 2, 3}})
+	h.Freeze()
+	if !h.Frozen() || !slices.Equal(h.Snapshot().Boundaries, []float64{1, 2, 3}) {
+		t.Error("Expected freezing without observations to keep the warm-up boundaries")
+	}
+
+	h = newAdaptiveHistogram(Options{Name: "latency", AdaptiveWarmUp: 1000, Buckets: []float64{1, 2, 3}})
+	h.Observe(5)
+	h.Observe(50)
+	h.Freeze()
+	if s := h.Snapshot(); !slices.Equal(s.Boundaries, []float64{2.5, 16, 100}) || s.Count != 2 {
+		t.Errorf("Expected boundaries chosen from the observations so far, got %+v", s)
+	}
+}
+
+func TestAdaptiveBoundaries(t *testing.T) {
+	if got := adaptiveBoundaries([]float64{-1, 0}, 5); got != nil {
+		t.Errorf("Expected no boundaries without positive samples, got %v", got)
+	}
+	if got := adaptiveBoundaries([]float64{7, 7, 7}, 3); !slices.Equal(got, []float64{3.5, 7, 14}) {
+		t.Errorf("Expected boundaries around a constant value, got %v", got)
+	}
+	if got := adaptiveBoundaries([]float64{1, 1.01}, 20); ValidateBuckets(got) != nil {
+		t.Errorf("Expected rounded boundaries to stay strictly increasing, got %v", got)
+	}
+}
+
+func TestRegistryAdaptiveHistogram(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	opts := Options{Name: "latency", AdaptiveWarmUp: 2}
+	h, ok := registry.Histogram(opts).(AdaptiveHistogram)
+	if !ok {
+		t.Fatal("Expected an AdaptiveHistogram")
+	}
+	if registry.Histogram(opts) != h {
+		t.Error("Expected lookups to return the registered histogram")
+	}
+	child, ok := h.With(Tags{"route": "/"}).(AdaptiveHistogram)
+	if !ok {
+		t.Fatal("Expected derived series to be adaptive")
+	}
+	h.Observe(1)
+	h.Observe(2)
+	if !h.Frozen() || child.Frozen() {
+		t.Error("Expected derived series to warm up on their own")
+	}
+}
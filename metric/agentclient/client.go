@@ -0,0 +1,259 @@
+// Package agentclient forwards the observations of a registry to a local
+// agentserver over a Unix socket, for sidecar-style collection where one agent
+// per host exports the metrics of every process.
+//
+// A Client is a metric.ObservationSink, so attaching it to a registry forwards
+// every write as it happens:
+//
+//	client := agentclient.New("/run/gometrics/agent.sock")
+//	defer client.Close()
+//	registry := metric.NewDefaultRegistry(metric.WithObservationSink(client))
+//
+// Writes never block on the agent: observations are queued and sent by a
+// background goroutine, and dropped, counted by Dropped, while the queue is
+// full or the agent is unreachable.
+package agentclient
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/metric/agentserver"
+)
+
+const (
+	// DefaultQueueSize is how many observations are queued by default
+	DefaultQueueSize = 4096
+	// DefaultReconnectInterval is how long the client waits between attempts
+	// to connect to an unreachable agent by default
+	DefaultReconnectInterval = time.Second
+	// DefaultWriteTimeout bounds each write to the agent by default
+	DefaultWriteTimeout = time.Second
+)
+
+// observationKinds maps observation kinds to message kinds
+var observationKinds = map[metric.ObservationKind]string{
+	metric.ObservationAdd:    agentserver.KindAdd,
+	metric.ObservationSet:    agentserver.KindSet,
+	metric.ObservationSample: agentserver.KindSample,
+}
+
+// Client sends observations to the agent listening on a Unix socket
+type Client struct {
+	path              string
+	queue             chan agentserver.Message
+	reconnectInterval time.Duration
+	writeTimeout      time.Duration
+
+	closed    atomic.Bool
+	dropped   atomic.Uint64
+	sent      atomic.Uint64
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+
+	// Owned by the sending goroutine
+	conn     net.Conn
+	writer   *bufio.Writer
+	pending  uint64 // messages written to writer since the last flush
+	lastDial time.Time
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithQueueSize sets how many observations may wait to be sent
+func WithQueueSize(size int) Option {
+	return func(c *Client) {
+		if size > 0 {
+			c.queue = make(chan agentserver.Message, size)
+		}
+	}
+}
+
+// WithReconnectInterval sets how long the client waits between attempts to
+// connect to an unreachable agent; observations are dropped meanwhile
+func WithReconnectInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		if interval > 0 {
+			c.reconnectInterval = interval
+		}
+	}
+}
+
+// WithWriteTimeout bounds each write to the agent, so a stalled agent costs
+// dropped observations rather than a stuck sender
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if timeout > 0 {
+			c.writeTimeout = timeout
+		}
+	}
+}
+
+// New creates a client sending to the agent at the Unix socket path and starts
+// its sending goroutine. The agent does not need to be up yet; the client
+// connects on the first observation and reconnects after failures.
+func New(path string, opts ...Option) *Client {
+	c := &Client{
+		path:              path,
+		queue:             make(chan agentserver.Message, DefaultQueueSize),
+		reconnectInterval: DefaultReconnectInterval,
+		writeTimeout:      DefaultWriteTimeout,
+		done:              make(chan struct{}),
+		stopped:           make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	go c.run()
+	return c
+}
+
+// Observe implements metric.ObservationSink by queueing o for the agent
+func (c *Client) Observe(o metric.Observation) {
+	kind, ok := observationKinds[o.Kind]
+	if !ok {
+		return
+	}
+	c.Send(agentserver.Message{
+		Name:        o.Metric.Name(),
+		Type:        o.Metric.Type(),
+		Kind:        kind,
+		Value:       o.Value,
+		Tags:        o.Metric.Tags(),
+		Description: o.Metric.Description(),
+		Unit:        o.Metric.Unit(),
+	})
+}
+
+// Send queues m for the agent without blocking, dropping it if the queue is
+// full or the client is closed
+func (c *Client) Send(m agentserver.Message) {
+	if c.closed.Load() {
+		c.dropped.Add(1)
+		return
+	}
+	select {
+	case c.queue <- m:
+	default:
+		c.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of observations dropped so far; it implements metric.DropCounter
+func (c *Client) Dropped() uint64 {
+	return c.dropped.Load()
+}
+
+// Sent returns the number of observations written to the agent so far
+func (c *Client) Sent() uint64 {
+	return c.sent.Load()
+}
+
+// Close stops accepting observations, sends those still queued if the agent
+// is reachable and closes the connection
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		c.closed.Store(true)
+		close(c.done)
+	})
+	<-c.stopped
+	return nil
+}
+
+// run sends queued messages until Close, then drains the queue
+func (c *Client) run() {
+	defer close(c.stopped)
+	defer c.disconnect()
+
+	for {
+		select {
+		case m := <-c.queue:
+			c.write(m)
+		case <-c.done:
+			for {
+				select {
+				case m := <-c.queue:
+					c.write(m)
+				default:
+					c.flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// write sends m, flushing once the queue is empty so bursts share writes
+func (c *Client) write(m agentserver.Message) {
+	if !c.connect() {
+		c.dropped.Add(1)
+		return
+	}
+	line, err := json.Marshal(m)
+	if err != nil {
+		c.dropped.Add(1)
+		return
+	}
+	line = append(line, '\n')
+	c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	if _, err := c.writer.Write(line); err != nil {
+		c.fail(c.pending + 1)
+		return
+	}
+	c.pending++
+	if len(c.queue) == 0 {
+		c.flush()
+	}
+}
+
+// flush writes buffered messages to the agent
+func (c *Client) flush() {
+	if c.writer == nil || c.pending == 0 {
+		return
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	if err := c.writer.Flush(); err != nil {
+		c.fail(c.pending)
+		return
+	}
+	c.sent.Add(c.pending)
+	c.pending = 0
+}
+
+// fail drops the connection after a write error, counting the n messages lost with it
+func (c *Client) fail(n uint64) {
+	c.dropped.Add(n)
+	c.pending = 0
+	c.disconnect()
+}
+
+// connect dials the agent unless connected or it failed within the reconnect interval
+func (c *Client) connect() bool {
+	if c.conn != nil {
+		return true
+	}
+	if !c.lastDial.IsZero() && time.Since(c.lastDial) < c.reconnectInterval {
+		return false
+	}
+	c.lastDial = time.Now()
+	conn, err := net.DialTimeout("unix", c.path, c.writeTimeout)
+	if err != nil {
+		return false
+	}
+	c.conn, c.writer = conn, bufio.NewWriter(conn)
+	return true
+}
+
+func (c *Client) disconnect() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn, c.writer = nil, nil
+	}
+}
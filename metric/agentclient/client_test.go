@@ -0,0 +1,78 @@
+package agentclient
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/metric/agentserver"
+)
+
+func TestClientForwardsObservations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	aggregated := metric.NewNoCleanupRegistry()
+	defer aggregated.Close()
+	server := agentserver.NewServer(aggregated)
+	go server.Serve(listener)
+	defer server.Close()
+
+	client := New(path)
+	registry := metric.NewNoCleanupRegistry(metric.WithObservationSink(client))
+	defer registry.Close()
+
+	requests := registry.Counter(metric.Options{Name: "requests_total", Tags: metric.Tags{"route": "/"}})
+	requests.Add(2)
+	requests.Inc()
+	registry.Gauge(metric.Options{Name: "queue_depth", Unit: "items"}).Set(4)
+	registry.Timer(metric.Options{Name: "job_duration"}).Record(250 * time.Millisecond)
+	registry.Histogram(metric.Options{Name: "payload_bytes"}).Observe(512)
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if client.Sent() != 5 || client.Dropped() != 0 {
+		t.Fatalf("Expected 5 observations sent, got %d sent and %d dropped", client.Sent(), client.Dropped())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for server.Received() < 5 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if v := aggregated.Counter(metric.Options{Name: "requests_total", Tags: metric.Tags{"route": "/"}}).Value(); v != 3 {
+		t.Errorf("Expected the agent to count 3 requests, got %d", v)
+	}
+	if g := aggregated.Gauge(metric.Options{Name: "queue_depth"}); g.Value() != 4 || g.Unit() != "items" {
+		t.Errorf("Expected the gauge with its unit, got %d %q", g.Value(), g.Unit())
+	}
+	if s := aggregated.Timer(metric.Options{Name: "job_duration"}).Snapshot(); s.Count != 1 || s.Sum != float64(250*time.Millisecond) {
+		t.Errorf("Expected the timer sample in nanoseconds, got %+v", s)
+	}
+	if s := aggregated.Histogram(metric.Options{Name: "payload_bytes"}).Snapshot(); s.Count != 1 || s.Sum != 512 {
+		t.Errorf("Expected the histogram sample, got %+v", s)
+	}
+
+	client.Observe(metric.Observation{Metric: requests, Kind: metric.ObservationAdd, Value: 1})
+	if client.Dropped() != 1 {
+		t.Errorf("Expected observations after Close to be dropped, got %d", client.Dropped())
+	}
+}
+
+func TestClientDropsWhileAgentIsDown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.sock")
+	client := New(path, WithReconnectInterval(time.Hour))
+	registry := metric.NewNoCleanupRegistry(metric.WithObservationSink(client))
+	defer registry.Close()
+
+	registry.Counter(metric.Options{Name: "requests_total"}).Add(3)
+	registry.Counter(metric.Options{Name: "requests_total"}).Inc()
+	client.Close()
+	if client.Dropped() != 2 || client.Sent() != 0 {
+		t.Errorf("Expected both observations dropped, got %d dropped and %d sent", client.Dropped(), client.Sent())
+	}
+}
@@ -0,0 +1,301 @@
+// Package agentserver aggregates observations forwarded by processes on the
+// same host into a registry, so a sidecar or host agent can export the
+// metrics of services written in any language through one reporter.
+//
+// Clients connect to a Unix socket and write one JSON Message per line:
+//
+//	{"name":"jobs_total","type":"counter","kind":"add","value":1,"tags":{"queue":"emails"}}
+//	{"name":"job_duration","type":"timer","kind":"sample","value":1500000}
+//
+// Go services can use the agentclient package, which writes every observation
+// of a registry. The server never replies; malformed or rejected messages are
+// counted and skipped, so a misbehaving client cannot take the agent down.
+package agentserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityCollector, Name: "agentserver", Package: "github.com/MichaelAJay/go-metrics/metric/agentserver"})
+}
+
+// DefaultMaxMessageSize is the longest message line accepted by default
+const DefaultMaxMessageSize = 64 << 10
+
+// Kinds of Message, matching metric.ObservationKind
+const (
+	// KindAdd adds Value to a counter, up-down counter or gauge, or marks Value events on a meter
+	KindAdd = "add"
+	// KindSet sets a gauge to Value
+	KindSet = "set"
+	// KindSample records Value in a histogram, or in a timer in nanoseconds
+	KindSample = "sample"
+)
+
+// ErrServerClosed is returned by Serve and ListenAndServe after Close
+var ErrServerClosed = errors.New("agentserver: server closed")
+
+// Message is one observation on the wire. Description and Unit are only
+// needed when the metric may be created by the message.
+type Message struct {
+	Name        string      `json:"name"`
+	Type        metric.Type `json:"type"`
+	Kind        string      `json:"kind"`
+	Value       float64     `json:"value"`
+	Tags        metric.Tags `json:"tags,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Unit        string      `json:"unit,omitempty"`
+}
+
+// Server reads messages from Unix socket connections and applies them to a registry
+type Server struct {
+	registry       metric.Registry
+	maxMessageSize int
+	errorHandler   func(error)
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+
+	received atomic.Uint64
+	rejected atomic.Uint64
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithMaxMessageSize sets the longest message line accepted; a connection
+// sending a longer line is closed. The default is DefaultMaxMessageSize.
+func WithMaxMessageSize(size int) Option {
+	return func(s *Server) {
+		if size > 0 {
+			s.maxMessageSize = size
+		}
+	}
+}
+
+// WithErrorHandler sets a callback invoked with the reason for every rejected
+// message, e.g. to log it. It is called from connection goroutines, so it must
+// be safe for concurrent use.
+func WithErrorHandler(handler func(error)) Option {
+	return func(s *Server) {
+		s.errorHandler = handler
+	}
+}
+
+// NewServer creates a server applying the messages it receives to registry
+func NewServer(registry metric.Registry, opts ...Option) *Server {
+	s := &Server{
+		registry:       registry,
+		maxMessageSize: DefaultMaxMessageSize,
+		listeners:      make(map[net.Listener]struct{}),
+		conns:          make(map[net.Conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ListenAndServe listens on the Unix socket at path and serves it until Close.
+// A socket file left at path by a previous run is removed first.
+func (s *Server) ListenAndServe(path string) error {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("agentserver: removing stale socket: %w", err)
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("agentserver: %w", err)
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener until Close, reading each on its own
+// goroutine. It always returns a non-nil error, ErrServerClosed after Close.
+func (s *Server) Serve(listener net.Listener) error {
+	if !s.track(listener) {
+		listener.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(listener)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if !s.trackConn(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// Close stops accepting connections and closes open ones, dropping messages
+// not yet read, then waits for their goroutines. Serve then returns ErrServerClosed.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	for listener := range s.listeners {
+		if closeErr := listener.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// Received returns the number of messages applied to the registry
+func (s *Server) Received() uint64 {
+	return s.received.Load()
+}
+
+// Rejected returns the number of malformed or rejected messages
+func (s *Server) Rejected() uint64 {
+	return s.rejected.Load()
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer s.untrackConn(conn)
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), s.maxMessageSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var m Message
+		if err := json.Unmarshal(line, &m); err != nil {
+			s.reject(fmt.Errorf("malformed message: %w", err))
+			continue
+		}
+		if err := s.apply(m); err != nil {
+			s.reject(err)
+			continue
+		}
+		s.received.Add(1)
+	}
+	if err := scanner.Err(); errors.Is(err, bufio.ErrTooLong) {
+		s.reject(fmt.Errorf("message longer than %d bytes; closing connection", s.maxMessageSize))
+	}
+}
+
+// apply writes m to the registry. The registry panics on invalid tags and
+// exceeded cardinality limits; those panics reject the message instead.
+func (s *Server) apply(m Message) (err error) {
+	if m.Name == "" {
+		return errors.New("message without a metric name")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("metric '%s' rejected: %v", m.Name, r)
+		}
+	}()
+
+	opts := metric.Options{Name: m.Name, Description: m.Description, Unit: m.Unit, Tags: m.Tags}
+	switch {
+	case m.Type == metric.TypeCounter && m.Kind == KindAdd:
+		s.registry.Counter(opts).Add(m.Value)
+	case m.Type == metric.TypeGauge && m.Kind == KindSet:
+		s.registry.Gauge(opts).Set(m.Value)
+	case m.Type == metric.TypeGauge && m.Kind == KindAdd:
+		s.registry.Gauge(opts).Add(m.Value)
+	case m.Type == metric.TypeUpDownCounter && m.Kind == KindAdd:
+		s.instruments(m).UpDownCounter(opts).Add(m.Value)
+	case m.Type == metric.TypeHistogram && m.Kind == KindSample:
+		s.registry.Histogram(opts).Observe(m.Value)
+	case m.Type == metric.TypeTimer && m.Kind == KindSample:
+		s.registry.Timer(opts).Record(time.Duration(m.Value))
+	case m.Type == metric.TypeMeter && m.Kind == KindAdd:
+		s.instruments(m).Meter(opts).Mark(int64(m.Value))
+	default:
+		return fmt.Errorf("metric '%s': kind %q does not apply to type %q", m.Name, m.Kind, m.Type)
+	}
+	return nil
+}
+
+// instruments returns the registry as a metric.InstrumentRegistry for m, and
+// panics, rejecting m, if it does not implement one
+func (s *Server) instruments(m Message) metric.InstrumentRegistry {
+	instruments, ok := s.registry.(metric.InstrumentRegistry)
+	if !ok {
+		panic(fmt.Sprintf("registry does not support type %q", m.Type))
+	}
+	return instruments
+}
+
+// reject counts a rejected message and passes err to the error handler
+func (s *Server) reject(err error) {
+	if s.errorHandler != nil {
+		s.errorHandler(err)
+	}
+	s.rejected.Add(1)
+}
+
+func (s *Server) track(listener net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.listeners[listener] = struct{}{}
+	return true
+}
+
+func (s *Server) untrack(listener net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.listeners, listener)
+}
+
+// trackConn registers a connection so Close can close it and wait for its goroutine
+func (s *Server) trackConn(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) untrackConn(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, conn)
+	conn.Close()
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
@@ -0,0 +1,93 @@
+package agentserver
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestServerAppliesMessages(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	var rejections []error
+	server := NewServer(registry, WithErrorHandler(func(err error) { rejections = append(rejections, err) }))
+
+	path := filepath.Join(t.TempDir(), "agent.sock")
+	served := make(chan error, 1)
+	go func() { served <- server.ListenAndServe(path) }()
+
+	conn := dialEventually(t, path)
+	_, err := conn.Write([]byte(strings.Join([]string{
+		`{"name":"jobs_total","type":"counter","kind":"add","value":2,"tags":{"queue":"emails"}}`,
+		`{"name":"jobs_total","type":"counter","kind":"add","value":3,"tags":{"queue":"emails"}}`,
+		`{"name":"queue_depth","type":"gauge","kind":"set","value":7,"description":"Jobs waiting"}`,
+		`{"name":"job_duration","type":"timer","kind":"sample","value":1500000000}`,
+		``,
+		`not json`,
+		`{"name":"jobs_total","type":"counter","kind":"set","value":1}`,
+		`{"type":"gauge","kind":"set","value":1}`,
+		`{"name":"bad_tags","type":"gauge","kind":"set","value":1,"tags":{"":"x"}}`,
+	}, "\n") + "\n"))
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	conn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for server.Received()+server.Rejected() < 8 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if server.Received() != 4 || server.Rejected() != 4 || len(rejections) != 4 {
+		t.Fatalf("Expected 4 applied and 4 rejected messages, got %d and %d: %v", server.Received(), server.Rejected(), rejections)
+	}
+
+	if v := registry.Counter(metric.Options{Name: "jobs_total", Tags: metric.Tags{"queue": "emails"}}).Value(); v != 5 {
+		t.Errorf("Expected the counter adds to accumulate to 5, got %d", v)
+	}
+	if g := registry.Gauge(metric.Options{Name: "queue_depth"}); g.Value() != 7 || g.Description() != "Jobs waiting" {
+		t.Errorf("Expected the gauge set with its description, got %d %q", g.Value(), g.Description())
+	}
+	if s := registry.Timer(metric.Options{Name: "job_duration"}).Snapshot(); s.Count != 1 || s.Sum != 1.5e9 {
+		t.Errorf("Expected one 1.5s timer sample, got %+v", s)
+	}
+
+	if err := server.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if err := <-served; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Expected ErrServerClosed, got %v", err)
+	}
+
+	// A socket left behind by a crashed agent is replaced
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	restarted := NewServer(registry)
+	go restarted.ListenAndServe(path)
+	dialEventually(t, path).Close()
+	restarted.Close()
+}
+
+// dialEventually connects to the socket at path once the server listens
+func dialEventually(t *testing.T, path string) net.Conn {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			return conn
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Dial %s: %v", path, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,230 @@
+package metric
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Batch buffers observations so hot code can record many of them and apply
+// them at a single point: the adds to a counter are summed into one add, the
+// writes to a gauge collapse into one, and the samples of a histogram or timer
+// update its count, sum, min and max once. Nothing recorded is visible until
+// Apply. A Batch is not safe for concurrent use.
+//
+// Metrics are tracked by identity, so they must be comparable, as every metric
+// of this module is.
+type Batch struct {
+	index   map[Metric]int
+	entries []batchEntry // reused across Apply calls, so a pooled Batch does not allocate
+}
+
+// batchEntry is the pending writes to one metric
+type batchEntry struct {
+	metric    Metric
+	total     uint64    // counter adds
+	negatives []float64 // negative counter adds, passed on one by one so the registry's policy applies
+	set       bool      // a gauge Set is pending with value; delta is added on top
+	value     float64
+	delta     float64   // gauge and up-down counter adds
+	values    []float64 // histogram and timer samples, timers in nanoseconds
+}
+
+var batchPool = sync.Pool{
+	New: func() any {
+		return NewBatch()
+	},
+}
+
+// NewBatch creates an empty Batch
+func NewBatch() *Batch {
+	return &Batch{index: make(map[Metric]int)}
+}
+
+// RecordBatch calls fn with a pooled Batch and applies what it recorded
+func RecordBatch(fn func(b *Batch)) {
+	b := batchPool.Get().(*Batch)
+	defer batchPool.Put(b)
+	fn(b)
+	b.Apply()
+}
+
+// entry returns the pending writes to m, adding an entry on its first write
+func (b *Batch) entry(m Metric) *batchEntry {
+	if i, ok := b.index[m]; ok {
+		return &b.entries[i]
+	}
+	i := len(b.entries)
+	if i < cap(b.entries) {
+		b.entries = b.entries[:i+1]
+	} else {
+		b.entries = append(b.entries, batchEntry{})
+	}
+	e := &b.entries[i]
+	*e = batchEntry{metric: m, negatives: e.negatives[:0], values: e.values[:0]}
+	b.index[m] = i
+	return e
+}
+
+// Inc counts one for counter
+func (b *Batch) Inc(counter Counter) {
+	b.entry(counter).total++
+}
+
+// Add adds value to counter
+func (b *Batch) Add(counter Counter, value float64) {
+	e := b.entry(counter)
+	if value > 0 {
+		e.total += uint64(value)
+	} else if value < 0 {
+		e.negatives = append(e.negatives, value)
+	}
+}
+
+// Set sets gauge to value, discarding the batch's earlier writes to it
+func (b *Batch) Set(gauge Gauge, value float64) {
+	e := b.entry(gauge)
+	e.set, e.value, e.delta = true, value, 0
+}
+
+// AddGauge adds value to gauge
+func (b *Batch) AddGauge(gauge Gauge, value float64) {
+	b.entry(gauge).delta += value
+}
+
+// AddUpDown adds value, which may be negative, to counter
+func (b *Batch) AddUpDown(counter UpDownCounter, value float64) {
+	b.entry(counter).delta += value
+}
+
+// Observe records value in histogram
+func (b *Batch) Observe(histogram Histogram, value float64) {
+	e := b.entry(histogram)
+	e.values = append(e.values, value)
+}
+
+// Record records d in timer
+func (b *Batch) Record(timer Timer, d time.Duration) {
+	e := b.entry(timer)
+	e.values = append(e.values, float64(d.Nanoseconds()))
+}
+
+// Len returns the number of metrics with pending writes
+func (b *Batch) Len() int {
+	return len(b.entries)
+}
+
+// Apply writes everything recorded since the last Apply to the metrics and
+// empties the batch for reuse
+func (b *Batch) Apply() {
+	for i := range b.entries {
+		e := &b.entries[i]
+		switch m := e.metric.(type) {
+		case Counter:
+			if e.total > 0 {
+				m.Add(float64(e.total))
+			}
+			for _, value := range e.negatives {
+				m.Add(value)
+			}
+		case Gauge:
+			if e.set {
+				m.Set(e.value + e.delta)
+			} else if e.delta != 0 {
+				m.Add(e.delta)
+			}
+		case UpDownCounter:
+			if e.delta != 0 {
+				m.Add(e.delta)
+			}
+		case Histogram:
+			applyHistogram(m, e.values)
+		case Timer:
+			applyTimer(m, e.values)
+		}
+		// Keep the slices for reuse but not the metric
+		e.metric = nil
+	}
+	b.entries = b.entries[:0]
+	clear(b.index)
+}
+
+// applyHistogram records values in histogram
+func applyHistogram(histogram Histogram, values []float64) {
+	if h, ok := histogram.(*histogramImpl); ok {
+		h.observeBatch(values)
+		return
+	}
+	for _, value := range values {
+		histogram.Observe(value)
+	}
+}
+
+// applyTimer records values, in nanoseconds, in timer
+func applyTimer(timer Timer, values []float64) {
+	if t, ok := timer.(*timerImpl); ok {
+		if h, ok := t.histogram.(*histogramImpl); ok {
+			if h.isPaused() {
+				return
+			}
+			h.observeBatch(values)
+			for _, value := range values {
+				observe(t.sink, t, ObservationSample, value)
+			}
+			return
+		}
+	}
+	for _, value := range values {
+		timer.Record(time.Duration(value))
+	}
+}
+
+// observeBatch records values like Observe, updating the count, sum, min and
+// max once and each bucket once per run of values that fall into it
+func (h *histogramImpl) observeBatch(values []float64) {
+	if len(values) == 0 || h.isPaused() {
+		return
+	}
+	h.markWritten()
+
+	// A high-contention histogram takes the batch into one of its shards
+	count, total, low, high, buckets, initialized := &h.count, &h.sum, &h.min, &h.max, h.buckets, &h.initialized
+	if h.shards != nil {
+		s := h.shard()
+		count, total, low, high, buckets, initialized = &s.count, &s.sum, &s.min, &s.max, s.buckets, &s.initialized
+	}
+
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	sum := 0.0
+	bucket, run := -1, uint64(0)
+	for _, value := range values {
+		sum += value
+		// Comparisons rather than math.Min and math.Max, so NaN is skipped as in Observe
+		if value < lowest {
+			lowest = value
+		}
+		if value > highest {
+			highest = value
+		}
+		if index := h.findBucket(value); index != bucket {
+			if run > 0 {
+				atomic.AddUint64(&buckets[bucket], run)
+			}
+			bucket, run = index, 0
+		}
+		run++
+	}
+	atomic.AddUint64(&buckets[bucket], run)
+	atomic.AddUint64(count, uint64(len(values)))
+	addFloat(total, sum)
+
+	lowerFloat(low, lowest)
+	raiseFloat(high, highest)
+	initialized.Store(true)
+	if h.sink != nil {
+		for _, value := range values {
+			observe(h.sink, h, ObservationSample, value)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package metric
+
+import (
+	"testing"
+	"time"
+)
+
+// batchBenchmarkSize is the number of observations per request in the batch benchmarks
+const batchBenchmarkSize = 32
+
+// BenchmarkBatchIndividual records a request's observations with one call each
+func BenchmarkBatchIndividual(b *testing.B) {
+	registry := NewNoCleanupRegistry()
+	counter := registry.Counter(Options{Name: "bench_rows"})
+	histogram := registry.Histogram(Options{Name: "bench_row_bytes"})
+	timer := registry.Timer(Options{Name: "bench_row_latency"})
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for i := 0; i < batchBenchmarkSize; i++ {
+				counter.Inc()
+				histogram.Observe(float64(i * 100))
+				timer.Record(time.Duration(i) * time.Microsecond)
+			}
+		}
+	})
+}
+
+// BenchmarkBatchRecord records the same observations through RecordBatch
+func BenchmarkBatchRecord(b *testing.B) {
+	registry := NewNoCleanupRegistry()
+	counter := registry.Counter(Options{Name: "bench_rows"})
+	histogram := registry.Histogram(Options{Name: "bench_row_bytes"})
+	timer := registry.Timer(Options{Name: "bench_row_latency"})
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			RecordBatch(func(batch *Batch) {
+				for i := 0; i < batchBenchmarkSize; i++ {
+					batch.Inc(counter)
+					batch.Observe(histogram, float64(i*100))
+					batch.Record(timer, time.Duration(i)*time.Microsecond)
+				}
+			})
+		}
+	})
+}
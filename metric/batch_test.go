@@ -0,0 +1,118 @@
+package metric
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBatchAppliesOnce(t *testing.T) {
+	registry := NewNoCleanupRegistry().(InstrumentRegistry)
+	counter := registry.Counter(Options{Name: "batch_requests"})
+	gauge := registry.Gauge(Options{Name: "batch_in_flight"})
+	upDown := registry.UpDownCounter(Options{Name: "batch_queue"})
+	histogram := registry.Histogram(Options{Name: "batch_sizes", Buckets: []float64{1, 10, 100}})
+	timer := registry.Timer(Options{Name: "batch_latency"})
+
+	b := NewBatch()
+	for i := 0; i < 10; i++ {
+		b.Inc(counter)
+		b.AddUpDown(upDown, -1)
+		b.Observe(histogram, float64(i*i))
+		b.Record(timer, time.Duration(i)*time.Millisecond)
+	}
+	b.Set(gauge, 5)
+	b.AddGauge(gauge, 2)
+
+	if counter.Value() != 0 || histogram.Snapshot().Count != 0 {
+		t.Fatal("Expected nothing to be recorded before Apply")
+	}
+	if b.Len() != 5 {
+		t.Errorf("Expected 5 pending metrics, got %d", b.Len())
+	}
+	b.Apply()
+
+	if counter.Value() != 10 {
+		t.Errorf("Expected counter 10, got %d", counter.Value())
+	}
+	if gauge.Value() != 7 {
+		t.Errorf("Expected gauge 7, got %d", gauge.Value())
+	}
+	if upDown.Value() != -10 {
+		t.Errorf("Expected up-down counter -10, got %d", upDown.Value())
+	}
+
+	snapshot := histogram.Snapshot()
+	if snapshot.Count != 10 || snapshot.Sum != 285 || snapshot.Min != 0 || snapshot.Max != 81 {
+		t.Errorf("Unexpected histogram snapshot %+v", snapshot)
+	}
+	// 0 and 1 fall into le=1, 4 and 9 into le=10, 16 to 81 into le=100
+	want := []uint64{2, 2, 6, 0}
+	for i, count := range want {
+		if snapshot.Buckets[i] != count {
+			t.Errorf("Expected bucket %d to be %d, got %d", i, count, snapshot.Buckets[i])
+		}
+	}
+	if timer.Snapshot().Count != 10 || timer.Snapshot().Max != float64(9*time.Millisecond) {
+		t.Errorf("Unexpected timer snapshot %+v", timer.Snapshot())
+	}
+
+	if b.Len() != 0 {
+		t.Errorf("Expected Apply to empty the batch, got %d pending", b.Len())
+	}
+	b.Apply()
+	if counter.Value() != 10 {
+		t.Errorf("Expected an empty Apply to change nothing, got %d", counter.Value())
+	}
+}
+
+func TestBatchMatchesIndividualCalls(t *testing.T) {
+	individual := NewNoCleanupRegistry()
+	batched := NewNoCleanupRegistry()
+	values := []float64{3, 0.5, 250, 7, 7, 1e9, 0.001, 12}
+
+	h := individual.Histogram(Options{Name: "batch_values"})
+	for _, v := range values {
+		h.Observe(v)
+	}
+	RecordBatch(func(b *Batch) {
+		for _, v := range values {
+			b.Observe(batched.Histogram(Options{Name: "batch_values"}), v)
+		}
+	})
+
+	want := h.Snapshot()
+	got := batched.Histogram(Options{Name: "batch_values"}).Snapshot()
+	if got.Count != want.Count || got.Sum != want.Sum || got.Min != want.Min || got.Max != want.Max {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	for i := range want.Buckets {
+		if got.Buckets[i] != want.Buckets[i] {
+			t.Errorf("Bucket %d: expected %d, got %d", i, want.Buckets[i], got.Buckets[i])
+		}
+	}
+}
+
+func TestBatchRespectsPause(t *testing.T) {
+	registry := NewNoCleanupRegistry().(PausableRegistry)
+	counter := registry.Counter(Options{Name: "batch_paused"})
+	timer := registry.Timer(Options{Name: "batch_paused_latency"})
+
+	registry.Pause()
+	RecordBatch(func(b *Batch) {
+		b.Add(counter, 3)
+		b.Record(timer, time.Second)
+	})
+	if counter.Value() != 0 || timer.Snapshot().Count != 0 {
+		t.Error("Expected a paused registry to ignore the batch")
+	}
+}
+
+func TestBatchWithOtherImplementations(t *testing.T) {
+	noop := NewNoop()
+	RecordBatch(func(b *Batch) {
+		b.Inc(noop.Counter(Options{Name: "noop"}))
+		b.Observe(noop.Histogram(Options{Name: "noop"}), 1)
+		b.Record(noop.Timer(Options{Name: "noop"}), time.Second)
+		b.Set(noop.Gauge(Options{Name: "noop"}), 1)
+	})
+}
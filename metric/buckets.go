@@ -0,0 +1,63 @@
+package metric
+
+import "time"
+
+// LatencyBucketsFast returns boundaries from 1µs to 10ms for in-process
+// operations such as cache lookups, lock waits and serialization. Like timer
+// observations the boundaries are in nanoseconds.
+func LatencyBucketsFast() []float64 {
+	return latencyBuckets(time.Microsecond)
+}
+
+// LatencyBucketsWeb returns boundaries from 1ms to 10s for requests and
+// database queries, in nanoseconds. Timers use them when Options.Buckets is empty.
+func LatencyBucketsWeb() []float64 {
+	return latencyBuckets(time.Millisecond)
+}
+
+// latencyBuckets returns 1-2.5-5 steps over four decades starting at base, in nanoseconds
+func latencyBuckets(base time.Duration) []float64 {
+	buckets := make([]float64, 0, 13)
+	for decade := float64(base); len(buckets) < 12; decade *= 10 {
+		buckets = append(buckets, decade, 2.5*decade, 5*decade)
+	}
+	return append(buckets, 10000*float64(base))
+}
+
+// SizeBucketsBytes returns boundaries from 64B to 1GiB, growing fourfold, for
+// payload, message and file sizes. Histograms with the unit "bytes" use them
+// when Options.Buckets is empty.
+func SizeBucketsBytes() []float64 {
+	return GenerateExponentialBuckets(64, 4, 13)
+}
+
+// DefaultBucketsFor returns the boundaries a metric of type t with unit gets
+// when Options.Buckets is empty. Timers, which always record nanoseconds, get
+// LatencyBucketsWeb. Histograms whose unit is a duration, such as "ms" or
+// "seconds", get LatencyBucketsWeb converted to that unit, and histograms
+// whose unit is a size, such as "bytes" or "KiB", get SizeBucketsBytes
+// converted to it; see LookupUnit. Everything else gets DefaultBuckets.
+func DefaultBucketsFor(t Type, unit string) []float64 {
+	if t == TypeTimer {
+		return LatencyBucketsWeb()
+	}
+	if t != TypeHistogram {
+		return DefaultBuckets()
+	}
+	conversion, ok := LookupUnit(unit)
+	switch {
+	case ok && conversion.Base == UnitSeconds:
+		buckets := LatencyBucketsWeb()
+		for i := range buckets {
+			buckets[i] = buckets[i] * conversion.Divisor / 1e9
+		}
+		return buckets
+	case ok && conversion.Base == UnitBytes:
+		buckets := SizeBucketsBytes()
+		for i := range buckets {
+			buckets[i] *= conversion.Divisor
+		}
+		return buckets
+	}
+	return DefaultBuckets()
+}
@@ -0,0 +1,81 @@
+package metric
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBucketPresets(t *testing.T) {
+	for name, buckets := range map[string][]float64{
+		"LatencyBucketsFast": LatencyBucketsFast(),
+		"LatencyBucketsWeb":  LatencyBucketsWeb(),
+		"SizeBucketsBytes":   SizeBucketsBytes(),
+	} {
+		if err := ValidateBuckets(buckets); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	fast := LatencyBucketsFast()
+	if fast[0] != float64(time.Microsecond) || fast[len(fast)-1] != float64(10*time.Millisecond) {
+		t.Errorf("Expected fast latency buckets from 1µs to 10ms, got %v", fast)
+	}
+	web := LatencyBucketsWeb()
+	if web[0] != float64(time.Millisecond) || web[len(web)-1] != float64(10*time.Second) {
+		t.Errorf("Expected web latency buckets from 1ms to 10s, got %v", web)
+	}
+	size := SizeBucketsBytes()
+	if size[0] != 64 || size[len(size)-1] != 1<<30 {
+		t.Errorf("Expected size buckets from 64B to 1GiB, got %v", size)
+	}
+}
+
+func TestDefaultBucketsFor(t *testing.T) {
+	tests := []struct {
+		metricType Type
+		unit       string
+		first      float64
+		last       float64
+	}{
+		{TypeTimer, "", 1e6, 1e10},
+		{TypeTimer, "milliseconds", 1e6, 1e10}, // timers record nanoseconds whatever the unit
+		{TypeHistogram, "milliseconds", 1, 10000},
+		{TypeHistogram, "seconds", 0.001, 10},
+		{TypeHistogram, "bytes", 64, 1 << 30},
+		{TypeHistogram, "requests", 0.001, 10000},
+		{TypeHistogram, "", 0.001, 10000},
+	}
+	for _, tt := range tests {
+		buckets := DefaultBucketsFor(tt.metricType, tt.unit)
+		if buckets[0] != tt.first || buckets[len(buckets)-1] != tt.last {
+			t.Errorf("%s %q: expected buckets from %v to %v, got %v", tt.metricType, tt.unit, tt.first, tt.last, buckets)
+		}
+	}
+}
+
+func TestTimerDefaultBuckets(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	timer := registry.Timer(Options{Name: "query_duration"})
+	timer.Record(30 * time.Millisecond)
+	snapshot := timer.Snapshot()
+	if !slices.Equal(snapshot.Boundaries, LatencyBucketsWeb()) {
+		t.Fatalf("Expected timers to default to web latency buckets, got %v", snapshot.Boundaries)
+	}
+	// 30ms falls in the (25ms, 50ms] bucket
+	if i := slices.Index(snapshot.Boundaries, float64(50*time.Millisecond)); snapshot.Buckets[i] != 1 {
+		t.Errorf("Expected the observation in the 50ms bucket, got %v", snapshot.Buckets)
+	}
+
+	custom := registry.Timer(Options{Name: "lock_wait", Buckets: LatencyBucketsFast()})
+	if !slices.Equal(custom.Snapshot().Boundaries, LatencyBucketsFast()) {
+		t.Errorf("Expected explicit buckets to be kept, got %v", custom.Snapshot().Boundaries)
+	}
+
+	payload := registry.Histogram(Options{Name: "payload_size", Unit: "bytes"})
+	if !slices.Equal(payload.Snapshot().Boundaries, SizeBucketsBytes()) {
+		t.Errorf("Expected byte histograms to default to size buckets, got %v", payload.Snapshot().Boundaries)
+	}
+}
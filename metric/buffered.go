@@ -0,0 +1,488 @@
+package metric
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrReportBuffered is wrapped by the errors of a BufferedReporter whose report
+// failed but was queued for retry, so callers can tell nothing was lost yet
+var ErrReportBuffered = errors.New("report buffered for retry")
+
+// BufferOption configures a BufferedReporter
+type BufferOption func(*bufferedReporter)
+
+// BufferSize bounds the number of failed reports kept for retry, 16 by default.
+// When the buffer is full the oldest report is dropped.
+func BufferSize(n int) BufferOption {
+	return func(b *bufferedReporter) {
+		if n > 0 {
+			b.size = n
+		}
+	}
+}
+
+// BufferBackoff sets the delay before the first retry, doubled after every
+// failed attempt up to limit. The defaults are one second and one minute.
+func BufferBackoff(base, limit time.Duration) BufferOption {
+	return func(b *bufferedReporter) {
+		if base > 0 {
+			b.baseDelay = base
+		}
+		if limit > 0 {
+			b.maxDelay = limit
+		}
+	}
+}
+
+// BufferDirectory keeps buffered reports in dir as well as in memory, so
+// reports still queued when the process exits are retried after it restarts.
+// The directory is created if missing and should not be shared.
+func BufferDirectory(dir string) BufferOption {
+	return func(b *bufferedReporter) {
+		b.dir = dir
+	}
+}
+
+// BufferClock makes the reporter time its backoff with clock
+func BufferClock(clock Clock) BufferOption {
+	return func(b *bufferedReporter) {
+		b.clock = clock
+	}
+}
+
+// bufferedReporter retries the reports its reporter failed from a bounded queue
+type bufferedReporter struct {
+	Reporter
+	size      int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	dir       string
+	clock     Clock
+
+	mu       sync.Mutex
+	queue    []*bufferedPayload
+	delay    time.Duration
+	retryAt  time.Time
+	lastErr  error
+	dropped  uint64
+	sequence uint64
+}
+
+// BufferedReporter wraps a push reporter so a failed report is not lost: the
+// series it carried are captured before the attempt and, if it fails, queued
+// in a bounded buffer. Queued reports are retried in order, oldest first, before
+// each later report, with exponential backoff between failed attempts; while
+// the backend is backing off new reports are queued without being attempted.
+// Flush retries immediately and Close makes a final attempt.
+//
+// Retried reports carry the values their series had when first attempted:
+// counters, gauges, histogram and timer snapshots and meter rates. Reporters
+// implementing TimestampReporter, such as remote write, send them stamped with
+// the time they were captured; others stamp them with the time of the retry,
+// so a backend sees the whole outage arrive at once. A reporter that consumes
+// deltas with Counter.Swap or SnapshotAndReset would send the values of reports
+// queued during a backoff again with the next live report.
+//
+// Reports failing with an error wrapping ErrPermanent, such as a request the
+// backend rejected as invalid, are dropped rather than retried. Those, reports
+// dropped because the buffer was full and reports still queued when Close
+// gives up are counted by Dropped, which the reporter implements for DropCounter.
+func BufferedReporter(inner Reporter, opts ...BufferOption) Reporter {
+	b := &bufferedReporter{
+		Reporter:  inner,
+		size:      16,
+		baseDelay: time.Second,
+		maxDelay:  time.Minute,
+		clock:     SystemClock,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.dir != "" {
+		b.load()
+	}
+	return b
+}
+
+func (b *bufferedReporter) Report(registry Registry) error {
+	return b.ReportContext(context.Background(), registry)
+}
+
+// ReportContext implements ContextReporter for the wrapped reporter. A report
+// cut short by ctx is queued like any other failed report.
+func (b *bufferedReporter) ReportContext(ctx context.Context, registry Registry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	payload := capturePayload(registry, b.clock.Now())
+	if err := b.drain(ctx, false); err != nil {
+		b.enqueue(payload)
+		return fmt.Errorf("%w: %w", ErrReportBuffered, err)
+	}
+	if err := ReportContext(ctx, b.Reporter, registry); err != nil {
+		if errors.Is(err, ErrPermanent) {
+			b.dropped++
+			return err
+		}
+		b.failed(err)
+		b.enqueue(payload)
+		return fmt.Errorf("%w: %w", ErrReportBuffered, err)
+	}
+	return nil
+}
+
+// Flush retries the queued reports without waiting for the backoff, then
+// flushes the wrapped reporter
+func (b *bufferedReporter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.drain(context.Background(), true); err != nil {
+		return fmt.Errorf("%w: %w", ErrReportBuffered, err)
+	}
+	return b.Reporter.Flush()
+}
+
+// Close makes a final attempt at the queued reports and closes the wrapped
+// reporter. Reports that still fail are dropped, unless BufferDirectory keeps
+// them for the next start.
+func (b *bufferedReporter) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := b.drain(context.Background(), true)
+	if err != nil && b.dir == "" {
+		b.dropped += uint64(len(b.queue))
+		b.queue = nil
+	}
+	return errors.Join(err, b.Reporter.Close())
+}
+
+// Dropped returns the reports dropped from the buffer plus those the wrapped
+// reporter dropped, if it implements DropCounter
+func (b *bufferedReporter) Dropped() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	dropped := b.dropped
+	if counter, ok := b.Reporter.(DropCounter); ok {
+		dropped += counter.Dropped()
+	}
+	return dropped
+}
+
+// PayloadBytes implements PayloadSizer for reporters that do
+func (b *bufferedReporter) PayloadBytes() uint64 {
+	if sizer, ok := b.Reporter.(PayloadSizer); ok {
+		return sizer.PayloadBytes()
+	}
+	return 0
+}
+
+// drain reports the queued payloads in order, stamped with the time they were
+// captured, stopping at the first failure; payloads rejected permanently are
+// dropped. Unless force is set nothing is attempted before the backoff has passed.
+func (b *bufferedReporter) drain(ctx context.Context, force bool) error {
+	if len(b.queue) == 0 {
+		return nil
+	}
+	if !force && b.clock.Now().Before(b.retryAt) {
+		return fmt.Errorf("backing off until %s: %w", b.retryAt.Format(time.RFC3339), b.lastErr)
+	}
+	for len(b.queue) > 0 {
+		payload := b.queue[0]
+		if err := ReportAt(ctx, b.Reporter, payload.registry(), payload.At); err != nil {
+			if !errors.Is(err, ErrPermanent) {
+				b.failed(err)
+				return err
+			}
+			b.dropped++
+		}
+		b.remove(payload)
+		b.queue = b.queue[1:]
+	}
+	b.delay = 0
+	b.retryAt = time.Time{}
+	b.lastErr = nil
+	return nil
+}
+
+// failed doubles the backoff after a failed attempt
+func (b *bufferedReporter) failed(err error) {
+	b.delay *= 2
+	if b.delay < b.baseDelay {
+		b.delay = b.baseDelay
+	}
+	if b.delay > b.maxDelay && b.maxDelay >= b.baseDelay {
+		b.delay = b.maxDelay
+	}
+	b.retryAt = b.clock.Now().Add(b.delay)
+	b.lastErr = err
+}
+
+// enqueue queues payload, dropping the oldest one if the buffer is full
+func (b *bufferedReporter) enqueue(payload *bufferedPayload) {
+	if len(b.queue) >= b.size {
+		b.remove(b.queue[0])
+		b.queue = b.queue[1:]
+		b.dropped++
+	}
+	if b.dir != "" {
+		b.store(payload)
+	}
+	b.queue = append(b.queue, payload)
+}
+
+// store writes payload to the buffer directory. A payload that cannot be
+// written is still retried from memory.
+func (b *bufferedReporter) store(payload *bufferedPayload) {
+	data, err := json.Marshal(payload)
+	if err != nil || os.MkdirAll(b.dir, 0o755) != nil {
+		return
+	}
+	b.sequence++
+	file := filepath.Join(b.dir, fmt.Sprintf("%020d-%06d.json", payload.At.UnixNano(), b.sequence%1e6))
+	if os.WriteFile(file, data, 0o644) == nil {
+		payload.file = file
+	}
+}
+
+// remove deletes the file of a payload leaving the buffer
+func (b *bufferedReporter) remove(payload *bufferedPayload) {
+	if payload.file != "" {
+		os.Remove(payload.file)
+	}
+}
+
+// load queues the payloads left in the buffer directory by a previous process.
+// Files that cannot be read are removed and counted as dropped.
+func (b *bufferedReporter) load() {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, filepath.Join(b.dir, entry.Name()))
+		}
+	}
+	slices.Sort(files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		payload := &bufferedPayload{}
+		if err == nil {
+			err = json.Unmarshal(data, payload)
+		}
+		if err != nil {
+			os.Remove(file)
+			b.dropped++
+			continue
+		}
+		payload.file = file
+		if len(b.queue) >= b.size {
+			b.remove(b.queue[0])
+			b.queue = b.queue[1:]
+			b.dropped++
+		}
+		b.queue = append(b.queue, payload)
+	}
+}
+
+// bufferedPayload is the content of a report as it was first attempted
+type bufferedPayload struct {
+	At     time.Time        `json:"at"`
+	Series []bufferedSeries `json:"series"`
+
+	file string
+}
+
+// bufferedSeries is the state of one series in a buffered report
+type bufferedSeries struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Unit        string             `json:"unit,omitempty"`
+	Type        Type               `json:"type"`
+	Tags        Tags               `json:"tags,omitempty"`
+	Value       int64              `json:"value,omitempty"`
+	FloatValue  *float64           `json:"float_value,omitempty"`
+	Counter     *CounterSnapshot   `json:"counter,omitempty"`
+	Histogram   *HistogramSnapshot `json:"histogram,omitempty"`
+	Meter       *MeterSnapshot     `json:"meter,omitempty"`
+}
+
+// capturePayload reads the series of registry without changing them
+func capturePayload(registry Registry, at time.Time) *bufferedPayload {
+	payload := &bufferedPayload{At: at}
+	registry.Each(func(m Metric) {
+		s := bufferedSeries{
+			Name:        m.Name(),
+			Description: m.Description(),
+			Unit:        m.Unit(),
+			Type:        m.Type(),
+			Tags:        m.Tags(),
+		}
+		switch v := m.(type) {
+		case Counter:
+			snapshot := v.Snapshot()
+			s.Counter = &snapshot
+		case Gauge:
+			s.Value = v.Value()
+			if f, ok := v.(FloatGauge); ok {
+				value := f.FloatValue()
+				s.FloatValue = &value
+			}
+		case UpDownCounter:
+			s.Value = v.Value()
+		case Histogram:
+			snapshot := v.Snapshot()
+			s.Histogram = &snapshot
+		case Timer:
+			snapshot := v.Snapshot()
+			s.Histogram = &snapshot
+		case Meter:
+			snapshot := v.Snapshot()
+			s.Meter = &snapshot
+		default:
+			return
+		}
+		payload.Series = append(payload.Series, s)
+	})
+	return payload
+}
+
+// registry returns a registry whose Each passes the captured series
+func (p *bufferedPayload) registry() Registry {
+	metrics := make([]Metric, 0, len(p.Series))
+	for i := range p.Series {
+		metrics = append(metrics, p.Series[i].metric())
+	}
+	return &capturedRegistry{Registry: NewNoop(), metrics: metrics}
+}
+
+// metric returns a read-only metric holding the captured state
+func (s *bufferedSeries) metric() Metric {
+	base := exportedBase{
+		name:        s.Name,
+		description: s.Description,
+		unit:        s.Unit,
+		metricType:  s.Type,
+		tags:        s.Tags,
+	}
+	switch {
+	case s.Counter != nil:
+		return &capturedCounter{exportedBase: base, snapshot: *s.Counter}
+	case s.Meter != nil:
+		return &capturedMeter{exportedBase: base, snapshot: *s.Meter}
+	case s.Histogram != nil && s.Type == TypeTimer:
+		return &capturedTimer{exportedBase: base, snapshot: *s.Histogram}
+	case s.Histogram != nil:
+		return &capturedHistogram{exportedBase: base, snapshot: *s.Histogram}
+	case s.Type == TypeUpDownCounter:
+		return &capturedUpDownCounter{exportedBase: base, value: s.Value}
+	default:
+		value := float64(s.Value)
+		if s.FloatValue != nil {
+			value = *s.FloatValue
+		}
+		return &capturedGauge{exportedBase: base, value: value}
+	}
+}
+
+// capturedRegistry is the registry a buffered report is retried with
+type capturedRegistry struct {
+	Registry
+	metrics []Metric
+}
+
+func (c *capturedRegistry) Each(fn func(Metric)) {
+	for _, m := range c.metrics {
+		fn(m)
+	}
+}
+
+type capturedCounter struct {
+	exportedBase
+	snapshot CounterSnapshot
+}
+
+func (c *capturedCounter) Inc()                      {}
+func (c *capturedCounter) Add(float64)               {}
+func (c *capturedCounter) With(Tags) Counter         { return c }
+func (c *capturedCounter) Value() uint64             { return c.snapshot.Value }
+func (c *capturedCounter) Swap() uint64              { return c.snapshot.Value }
+func (c *capturedCounter) Reset()                    {}
+func (c *capturedCounter) Snapshot() CounterSnapshot { return c.snapshot }
+
+type capturedGauge struct {
+	exportedBase
+	value float64
+}
+
+func (g *capturedGauge) Set(float64)         {}
+func (g *capturedGauge) Add(float64)         {}
+func (g *capturedGauge) Inc()                {}
+func (g *capturedGauge) Dec()                {}
+func (g *capturedGauge) With(Tags) Gauge     { return g }
+func (g *capturedGauge) Value() int64        { return int64(math.Round(g.value)) }
+func (g *capturedGauge) FloatValue() float64 { return g.value }
+
+type capturedUpDownCounter struct {
+	exportedBase
+	value int64
+}
+
+func (c *capturedUpDownCounter) Add(float64)             {}
+func (c *capturedUpDownCounter) Inc()                    {}
+func (c *capturedUpDownCounter) Dec()                    {}
+func (c *capturedUpDownCounter) With(Tags) UpDownCounter { return c }
+func (c *capturedUpDownCounter) Value() int64            { return c.value }
+
+type capturedHistogram struct {
+	exportedBase
+	snapshot HistogramSnapshot
+}
+
+func (h *capturedHistogram) Observe(float64)                     {}
+func (h *capturedHistogram) With(Tags) Histogram                 { return h }
+func (h *capturedHistogram) Snapshot() HistogramSnapshot         { return h.snapshot }
+func (h *capturedHistogram) SnapshotAndReset() HistogramSnapshot { return h.snapshot }
+
+type capturedTimer struct {
+	exportedBase
+	snapshot HistogramSnapshot
+}
+
+func (t *capturedTimer) Record(time.Duration)                {}
+func (t *capturedTimer) RecordSince(time.Time)               {}
+func (t *capturedTimer) With(Tags) Timer                     { return t }
+func (t *capturedTimer) Snapshot() HistogramSnapshot         { return t.snapshot }
+func (t *capturedTimer) SnapshotAndReset() HistogramSnapshot { return t.snapshot }
+
+// Time runs fn without recording its duration
+func (t *capturedTimer) Time(fn func()) time.Duration {
+	start := time.Now()
+	fn()
+	return time.Since(start)
+}
+
+type capturedMeter struct {
+	exportedBase
+	snapshot MeterSnapshot
+}
+
+func (m *capturedMeter) Mark(int64)              {}
+func (m *capturedMeter) With(Tags) Meter         { return m }
+func (m *capturedMeter) Count() int64            { return m.snapshot.Count }
+func (m *capturedMeter) Snapshot() MeterSnapshot { return m.snapshot }
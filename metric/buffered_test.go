@@ -0,0 +1,187 @@
+package metric
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyReporter records the counter values and histogram counts of the
+// reports it accepts and fails while err is set
+type flakyReporter struct {
+	mu       sync.Mutex
+	err      error
+	attempts int
+	accepted []map[string]uint64
+	closed   bool
+}
+
+func (r *flakyReporter) Report(registry Registry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	if r.err != nil {
+		return r.err
+	}
+	values := make(map[string]uint64)
+	registry.Each(func(m Metric) {
+		switch v := m.(type) {
+		case Counter:
+			values[m.Name()] = v.Value()
+		case Histogram:
+			values[m.Name()] = v.Snapshot().Count
+		}
+	})
+	r.accepted = append(r.accepted, values)
+	return nil
+}
+
+func (r *flakyReporter) Flush() error { return nil }
+
+func (r *flakyReporter) Close() error {
+	r.closed = true
+	return nil
+}
+
+// manualClock is a Clock moved by the test
+type manualClock struct{ now time.Time }
+
+func (c *manualClock) Now() time.Time { return c.now }
+
+func TestBufferedReporter(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+	counter := registry.Counter(Options{Name: "requests"})
+
+	clock := &manualClock{now: time.Unix(1700000000, 0)}
+	inner := &flakyReporter{err: errors.New("collector unavailable")}
+	reporter := BufferedReporter(inner, BufferBackoff(time.Second, 4*time.Second), BufferClock(clock))
+
+	// Failed reports are queued with the values they carried
+	counter.Add(3)
+	if err := reporter.Report(registry); !errors.Is(err, ErrReportBuffered) {
+		t.Fatalf("Expected the failed report to be buffered, got %v", err)
+	}
+	counter.Add(4)
+	if err := reporter.Report(registry); !errors.Is(err, ErrReportBuffered) {
+		t.Fatalf("Expected the report to be buffered during the backoff, got %v", err)
+	}
+	if inner.attempts != 1 {
+		t.Errorf("Expected no attempt during the backoff, got %d attempts", inner.attempts)
+	}
+
+	// Once the backend recovers the queue is drained in order before the new report
+	inner.err = nil
+	clock.now = clock.now.Add(time.Second)
+	counter.Add(5)
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Expected the report to succeed, got %v", err)
+	}
+	want := []uint64{3, 7, 12}
+	if len(inner.accepted) != len(want) {
+		t.Fatalf("Expected %d accepted reports, got %v", len(want), inner.accepted)
+	}
+	for i, value := range want {
+		if inner.accepted[i]["requests"] != value {
+			t.Errorf("Expected report %d to carry %d requests, got %v", i, value, inner.accepted[i])
+		}
+	}
+	if dropped := reporter.(DropCounter).Dropped(); dropped != 0 {
+		t.Errorf("Expected nothing dropped, got %d", dropped)
+	}
+}
+
+func TestBufferedReporterBounds(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+	counter := registry.Counter(Options{Name: "requests"})
+
+	inner := &flakyReporter{err: errors.New("collector unavailable")}
+	reporter := BufferedReporter(inner, BufferSize(2), BufferBackoff(time.Hour, time.Hour))
+
+	for i := 1; i <= 3; i++ {
+		counter.Add(float64(i))
+		reporter.Report(registry)
+	}
+	if dropped := reporter.(DropCounter).Dropped(); dropped != 1 {
+		t.Errorf("Expected the oldest report to be dropped, got %d dropped", dropped)
+	}
+
+	// Flush ignores the backoff
+	inner.err = nil
+	if err := reporter.Flush(); err != nil {
+		t.Fatalf("Expected Flush to drain the buffer, got %v", err)
+	}
+	if len(inner.accepted) != 2 || inner.accepted[0]["requests"] != 3 || inner.accepted[1]["requests"] != 6 {
+		t.Errorf("Expected the two newest reports, got %v", inner.accepted)
+	}
+
+	// Reports still failing at Close are dropped
+	inner.err = errors.New("collector unavailable")
+	reporter.Report(registry)
+	if err := reporter.Close(); err == nil || !inner.closed {
+		t.Errorf("Expected Close to fail and close the reporter, got %v", err)
+	}
+	if dropped := reporter.(DropCounter).Dropped(); dropped != 2 {
+		t.Errorf("Expected the queued report to be dropped at Close, got %d dropped", dropped)
+	}
+}
+
+func TestBufferedReporterDropsPermanentFailures(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+	registry.Counter(Options{Name: "requests"}).Inc()
+
+	inner := &flakyReporter{err: errors.New("collector unavailable")}
+	reporter := BufferedReporter(inner)
+	reporter.Report(registry)
+
+	// A queued report the backend rejects is dropped and the queue moves on
+	inner.err = fmt.Errorf("%w: 400 Bad Request", ErrPermanent)
+	if err := reporter.Flush(); err != nil {
+		t.Fatalf("Expected the rejected report to be dropped, got %v", err)
+	}
+	if err := reporter.Report(registry); errors.Is(err, ErrReportBuffered) {
+		t.Errorf("Expected a rejected report not to be buffered, got %v", err)
+	}
+	if dropped := reporter.(DropCounter).Dropped(); dropped != 2 {
+		t.Errorf("Expected 2 dropped reports, got %d", dropped)
+	}
+	if attempts := inner.attempts; attempts != 3 {
+		t.Errorf("Expected no retries of rejected reports, got %d attempts", attempts)
+	}
+}
+
+func TestBufferedReporterDirectory(t *testing.T) {
+	dir := t.TempDir()
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+	registry.Counter(Options{Name: "requests"}).Add(7)
+	registry.Histogram(Options{Name: "latency", Unit: "ms"}).Observe(12)
+
+	failing := &flakyReporter{err: errors.New("collector unavailable")}
+	reporter := BufferedReporter(failing, BufferDirectory(dir))
+	reporter.Report(registry)
+	reporter.Close()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one buffered report on disk, got %v (%v)", entries, err)
+	}
+
+	// A new process retries what the previous one left behind
+	recovered := &flakyReporter{}
+	restarted := BufferedReporter(recovered, BufferDirectory(dir))
+	if err := restarted.Report(NewNoop()); err != nil {
+		t.Fatalf("Expected the report to succeed, got %v", err)
+	}
+	if len(recovered.accepted) != 2 || recovered.accepted[0]["requests"] != 7 || recovered.accepted[0]["latency"] != 1 {
+		t.Fatalf("Expected the buffered report to be retried first, got %v", recovered.accepted)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected the sent report to be removed from disk, got %v", entries)
+	}
+}
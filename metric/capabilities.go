@@ -0,0 +1,102 @@
+package metric
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// CapabilityKind groups capabilities
+type CapabilityKind string
+
+const (
+	// CapabilityReporter is a backend reporter, e.g. "prometheus"
+	CapabilityReporter CapabilityKind = "reporter"
+	// CapabilityCollector gathers metrics or tags from the environment, e.g. "host"
+	CapabilityCollector CapabilityKind = "collector"
+	// CapabilityFeature is an optional feature, e.g. "history"
+	CapabilityFeature CapabilityKind = "feature"
+)
+
+// Capability is a reporter, collector or feature compiled into the binary
+type Capability struct {
+	Kind CapabilityKind
+	// Name identifies the capability within its kind
+	Name string
+	// Package is the import path that provides it
+	Package string
+}
+
+var (
+	capabilitiesMu sync.RWMutex
+	capabilities   = []Capability{
+		{Kind: CapabilityFeature, Name: "annotations", Package: "github.com/MichaelAJay/go-metrics/metric"},
+		{Kind: CapabilityFeature, Name: "goroutine_budget", Package: "github.com/MichaelAJay/go-metrics/metric"},
+		{Kind: CapabilityFeature, Name: "lock_instrumentation", Package: "github.com/MichaelAJay/go-metrics/metric"},
+		{Kind: CapabilityFeature, Name: "meta_metrics", Package: "github.com/MichaelAJay/go-metrics/metric"},
+		{Kind: CapabilityFeature, Name: "observation_sink", Package: "github.com/MichaelAJay/go-metrics/metric"},
+		{Kind: CapabilityFeature, Name: "pause", Package: "github.com/MichaelAJay/go-metrics/metric"},
+		{Kind: CapabilityFeature, Name: "pprof_labels", Package: "github.com/MichaelAJay/go-metrics/metric"},
+		{Kind: CapabilityFeature, Name: "rollups", Package: "github.com/MichaelAJay/go-metrics/metric"},
+		{Kind: CapabilityFeature, Name: "scheduler", Package: "github.com/MichaelAJay/go-metrics/metric"},
+		{Kind: CapabilityFeature, Name: "sharded_counters", Package: "github.com/MichaelAJay/go-metrics/metric"},
+		{Kind: CapabilityFeature, Name: "watch", Package: "github.com/MichaelAJay/go-metrics/metric"},
+	}
+)
+
+// RegisterCapability records a capability as compiled in. Packages providing
+// reporters, collectors and optional features call it from an init function,
+// so Capabilities only lists what the binary actually links, whatever build
+// tags or module splits were used. Registering the same kind and name again
+// replaces the earlier entry.
+func RegisterCapability(c Capability) {
+	capabilitiesMu.Lock()
+	defer capabilitiesMu.Unlock()
+
+	for i, existing := range capabilities {
+		if existing.Kind == c.Kind && existing.Name == c.Name {
+			capabilities[i] = c
+			return
+		}
+	}
+	capabilities = append(capabilities, c)
+}
+
+// Capabilities returns the reporters, collectors and optional features compiled
+// into the binary, sorted by kind and name, so configuration layers can check a
+// binary supports the backends it is configured for before deploying it
+func Capabilities() []Capability {
+	capabilitiesMu.RLock()
+	result := slices.Clone(capabilities)
+	capabilitiesMu.RUnlock()
+
+	slices.SortFunc(result, func(a, b Capability) int {
+		if c := strings.Compare(string(a.Kind), string(b.Kind)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+// HasCapability reports whether the capability of the given kind and name is compiled in
+func HasCapability(kind CapabilityKind, name string) bool {
+	capabilitiesMu.RLock()
+	defer capabilitiesMu.RUnlock()
+
+	return slices.ContainsFunc(capabilities, func(c Capability) bool {
+		return c.Kind == kind && c.Name == name
+	})
+}
+
+// MissingCapabilities returns the required capabilities that are not compiled
+// in, e.g. to validate a configuration naming its backends
+func MissingCapabilities(required ...Capability) []Capability {
+	var missing []Capability
+	for _, c := range required {
+		if !HasCapability(c.Kind, c.Name) {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,54 @@
+package metric
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	if !HasCapability(CapabilityFeature, "pause") {
+		t.Error("Expected the built-in pause feature to be listed")
+	}
+	if HasCapability(CapabilityReporter, "statsd") {
+		t.Error("Expected no statsd reporter in this binary")
+	}
+
+	RegisterCapability(Capability{Kind: CapabilityReporter, Name: "test_reporter", Package: "example.com/old"})
+	RegisterCapability(Capability{Kind: CapabilityReporter, Name: "test_reporter", Package: "example.com/new"})
+
+	caps := Capabilities()
+	if !slices.IsSortedFunc(caps, func(a, b Capability) int {
+		if a.Kind != b.Kind {
+			if a.Kind < b.Kind {
+				return -1
+			}
+			return 1
+		}
+		if a.Name < b.Name {
+			return -1
+		}
+		if a.Name > b.Name {
+			return 1
+		}
+		return 0
+	}) {
+		t.Errorf("Expected capabilities sorted by kind and name, got %+v", caps)
+	}
+	var registered []Capability
+	for _, c := range caps {
+		if c.Name == "test_reporter" {
+			registered = append(registered, c)
+		}
+	}
+	if len(registered) != 1 || registered[0].Package != "example.com/new" {
+		t.Errorf("Expected re-registration to replace the entry, got %+v", registered)
+	}
+
+	missing := MissingCapabilities(
+		Capability{Kind: CapabilityReporter, Name: "test_reporter"},
+		Capability{Kind: CapabilityReporter, Name: "statsd"},
+	)
+	if len(missing) != 1 || missing[0].Name != "statsd" {
+		t.Errorf("Expected only statsd to be missing, got %+v", missing)
+	}
+}
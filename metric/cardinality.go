@@ -0,0 +1,148 @@
+package metric
+
+import (
+	"fmt"
+	"hash/maphash"
+	"maps"
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+// cardinalityPrecision is the number of hash bits selecting a HyperLogLog
+// register: 4096 registers give a standard error of about 1.6%
+const cardinalityPrecision = 12
+
+const cardinalityRegisters = 1 << cardinalityPrecision
+
+// cardinalitySeed hashes observed values; estimates are process-local, so a random seed is fine
+var cardinalitySeed = maphash.MakeSeed()
+
+// cardinalityImpl is the series registered for a Cardinality. It is a Gauge
+// whose value is the estimate, so every reporter exports it as a gauge.
+type cardinalityImpl struct {
+	baseMetric
+	registers [cardinalityRegisters]atomic.Uint32
+	derive    func(tags Tags) *cardinalityImpl // set by the registry to look up With() series
+}
+
+func newCardinality(opts Options) *cardinalityImpl {
+	return &cardinalityImpl{
+		baseMetric: baseMetric{
+			name:        opts.Name,
+			description: opts.Description,
+			unit:        opts.Unit,
+			metricType:  TypeGauge,
+			tags:        opts.Tags,
+			annotations: maps.Clone(opts.Annotations),
+		},
+	}
+}
+
+// Cardinality creates or retrieves a Cardinality. It panics if a gauge that
+// is not a Cardinality is registered with the same name and tags.
+func (r *defaultRegistry) Cardinality(opts Options) Cardinality {
+	opts.Tags = r.processTags(opts.Tags)
+	m := r.lookup(opts, TypeGauge, func(opts Options) Metric {
+		c := newCardinality(opts)
+		c.paused = &r.paused
+		c.derive = func(tags Tags) *cardinalityImpl {
+			return r.Cardinality(derived(opts, tags)).(cardinalityHandle).cardinalityImpl
+		}
+		return c
+	})
+	c, ok := m.(*cardinalityImpl)
+	if !ok {
+		panic(fmt.Sprintf("metric '%s' is registered as a gauge, not a cardinality", opts.Name))
+	}
+	return cardinalityHandle{c}
+}
+
+func (c *cardinalityImpl) Observe(value string) {
+	if c.isPaused() {
+		return
+	}
+	c.markWritten()
+
+	h := maphash.String(cardinalitySeed, value)
+	index := h >> (64 - cardinalityPrecision)
+	// The guard bit caps the rank at the number of hash bits left
+	rank := uint32(bits.LeadingZeros64(h<<cardinalityPrecision|1<<(cardinalityPrecision-1))) + 1
+	register := &c.registers[index]
+	for {
+		old := register.Load()
+		if rank <= old || register.CompareAndSwap(old, rank) {
+			return
+		}
+	}
+}
+
+// Estimate returns the HyperLogLog estimate, using linear counting while few
+// registers are set, where the raw estimate is biased
+func (c *cardinalityImpl) Estimate() uint64 {
+	const m = float64(cardinalityRegisters)
+	sum, zeros := 0.0, 0
+	for i := range c.registers {
+		rank := c.registers[i].Load()
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(estimate))
+}
+
+func (c *cardinalityImpl) Reset() {
+	for i := range c.registers {
+		c.registers[i].Store(0)
+	}
+}
+
+// Value implements Gauge with the estimate
+func (c *cardinalityImpl) Value() int64 {
+	return int64(c.Estimate())
+}
+
+// Set is a no-op; the value is always the estimate
+func (c *cardinalityImpl) Set(value float64) {}
+
+// Add is a no-op; the value is always the estimate
+func (c *cardinalityImpl) Add(value float64) {}
+
+// Inc is a no-op; the value is always the estimate
+func (c *cardinalityImpl) Inc() {}
+
+// Dec is a no-op; the value is always the estimate
+func (c *cardinalityImpl) Dec() {}
+
+func (c *cardinalityImpl) With(tags Tags) Gauge {
+	return c.with(tags)
+}
+
+// with returns the series with tags added
+func (c *cardinalityImpl) with(tags Tags) *cardinalityImpl {
+	if c.derive != nil {
+		return c.derive(copyTags(c.tags, tags))
+	}
+	return newCardinality(Options{
+		Name:        c.name,
+		Description: c.Description(),
+		Unit:        c.Unit(),
+		Tags:        copyTags(c.tags, tags),
+		Annotations: c.annotations,
+	})
+}
+
+// cardinalityHandle is the Cardinality view of a registered series
+type cardinalityHandle struct {
+	*cardinalityImpl
+}
+
+func (h cardinalityHandle) With(tags Tags) Cardinality {
+	return cardinalityHandle{h.with(tags)}
+}
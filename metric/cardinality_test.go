@@ -0,0 +1,78 @@
+package metric
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestCardinality(t *testing.T) {
+	registry := NewNoCleanupRegistry().(InstrumentRegistry)
+	defer registry.Close()
+
+	users := registry.Cardinality(Options{Name: "unique_users", Tags: Tags{"region": "eu"}})
+	for i := 0; i < 10; i++ {
+		users.Observe("user-1")
+		users.Observe(fmt.Sprintf("user-%d", i))
+	}
+	if got := users.Estimate(); got != 10 {
+		t.Errorf("Expected small sets to be counted exactly, got %d", got)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50000; i++ {
+				users.Observe(fmt.Sprintf("visitor-%d", i))
+			}
+		}()
+	}
+	wg.Wait()
+	if got := float64(users.Estimate()); math.Abs(got-50010)/50010 > 0.05 {
+		t.Errorf("Expected about 50010 distinct values, got %v", got)
+	}
+
+	// Estimates are exported as gauges, and lookups share the sketch
+	if got := metaValues(registry)["unique_users{region=eu}"]; got != float64(users.Estimate()) {
+		t.Errorf("Expected the gauge to report the estimate, got %v", got)
+	}
+	if got := registry.Cardinality(Options{Name: "unique_users", Tags: Tags{"region": "eu"}}).Estimate(); got != users.Estimate() {
+		t.Errorf("Expected the same series on lookup, got %d", got)
+	}
+
+	us := users.With(Tags{"region": "us"})
+	us.Observe("user-1")
+	if got := us.Estimate(); got != 1 {
+		t.Errorf("Expected a derived series of its own, got %d", got)
+	}
+	users.Reset()
+	if got := users.Estimate(); got != 0 {
+		t.Errorf("Expected Reset to forget every value, got %d", got)
+	}
+
+	registry.Gauge(Options{Name: "queue_depth"})
+	if !panics(func() { registry.Cardinality(Options{Name: "queue_depth"}) }) {
+		t.Error("Expected a gauge of the same name to conflict")
+	}
+}
+
+func TestCardinalityRollups(t *testing.T) {
+	base := NewNoCleanupRegistry()
+	defer base.Close()
+	registry, err := NewRollupRegistry(base, RollupRule{Name: "unique_users", Without: []string{"region"}, As: "unique_users_global"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry.(InstrumentRegistry).Cardinality(Options{Name: "unique_users", Tags: Tags{"region": "eu"}}).Observe("alice")
+	us := registry.(InstrumentRegistry).Cardinality(Options{Name: "unique_users", Tags: Tags{"region": "us"}})
+	us.Observe("alice")
+	us.Observe("bob")
+
+	if got := metaValues(base)["unique_users_global{}"]; got != 2 {
+		t.Errorf("Expected the rollup to count the union of distinct values, got %v (%v)", got, metaValues(base))
+	}
+}
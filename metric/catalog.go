@@ -0,0 +1,169 @@
+package metric
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrMetadataConflict is the error a registry created WithStrictMetadata panics
+// with when a metric name is registered with a conflicting type, description or unit
+var ErrMetadataConflict = errors.New("metadata conflict")
+
+// CatalogEntry documents one metric name of a registry
+type CatalogEntry struct {
+	Name string
+	// Type is the type the name was first registered with
+	Type Type
+	// Description and Unit are those set with SetMetadata, or else the first
+	// non-empty ones the name was registered with
+	Description string
+	Unit        string
+	// Series is the number of series currently registered under the name
+	Series int
+}
+
+// MetadataCatalog is implemented by registries that keep a catalog of the
+// metric names registered in them
+type MetadataCatalog interface {
+	// Catalog returns an entry per registered metric name, sorted by name
+	Catalog() []CatalogEntry
+}
+
+// catalogEntry is the catalog record of one metric name
+type catalogEntry struct {
+	CatalogEntry
+	reported bool // set once a conflict for the name is reported
+}
+
+// WithStrictMetadata makes the registry panic with ErrMetadataConflict when a
+// metric is requested with a description or unit different from the one
+// already registered under its name, or a name is registered with a second
+// type, instead of only reporting the conflict. It is meant for tests and
+// development, so conflicting registrations fail before they reach a backend.
+func WithStrictMetadata() RegistryOption {
+	return func(r *defaultRegistry) {
+		r.strictMetadata = true
+	}
+}
+
+// Catalog returns an entry per metric name in registry, sorted by name, for
+// generating documentation. Registries implementing MetadataCatalog answer
+// from their catalog; others are cataloged from their series, taking the type
+// of the first series seen of each name and the first non-empty description and unit.
+func Catalog(registry Registry) []CatalogEntry {
+	if catalog, ok := registry.(MetadataCatalog); ok {
+		return catalog.Catalog()
+	}
+
+	entries := make(map[string]*CatalogEntry)
+	registry.Each(func(m Metric) {
+		entry, ok := entries[m.Name()]
+		if !ok {
+			entry = &CatalogEntry{Name: m.Name(), Type: m.Type()}
+			entries[m.Name()] = entry
+		}
+		if entry.Description == "" {
+			entry.Description = m.Description()
+		}
+		if entry.Unit == "" {
+			entry.Unit = m.Unit()
+		}
+		entry.Series++
+	})
+	return sortedCatalog(entries)
+}
+
+// Catalog implements MetadataCatalog
+func (r *defaultRegistry) Catalog() []CatalogEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make(map[string]*CatalogEntry, len(r.catalog))
+	for name, record := range r.catalog {
+		entry := record.CatalogEntry
+		if md, ok := r.metadata[name]; ok {
+			if md.Description != "" {
+				entry.Description = md.Description
+			}
+			if md.Unit != "" {
+				entry.Unit = md.Unit
+			}
+		}
+		entry.Series = r.cardinality[name]
+		entries[name] = &entry
+	}
+	return sortedCatalog(entries)
+}
+
+// sortedCatalog returns the entries sorted by name
+func sortedCatalog(entries map[string]*CatalogEntry) []CatalogEntry {
+	catalog := make([]CatalogEntry, 0, len(entries))
+	for _, entry := range entries {
+		catalog = append(catalog, *entry)
+	}
+	slices.SortFunc(catalog, func(a, b CatalogEntry) int { return strings.Compare(a.Name, b.Name) })
+	return catalog
+}
+
+// checkCatalog reports a conflict between a new series of opts.Name and the
+// catalog entry of the name. Description and unit conflicts are ignored once
+// SetMetadata has been called for the name, since it overrides both. The
+// caller must hold the write lock.
+func (r *defaultRegistry) checkCatalog(opts Options, metricType Type) {
+	record, ok := r.catalog[opts.Name]
+	if !ok {
+		return
+	}
+
+	conflict := MetadataConflict{
+		Name:      opts.Name,
+		Type:      record.Type,
+		Current:   Metadata{Description: record.Description, Unit: record.Unit},
+		Requested: Metadata{Description: opts.Description, Unit: opts.Unit},
+	}
+	if metricType != record.Type {
+		conflict.RequestedType = metricType
+	} else if _, set := r.metadata[opts.Name]; set ||
+		!differs(record.Description, opts.Description) && !differs(record.Unit, opts.Unit) {
+		return
+	}
+
+	if r.strictMetadata {
+		panic(fmt.Errorf("%w for metric '%s': %+v", ErrMetadataConflict, opts.Name, conflict))
+	}
+	if record.reported {
+		return
+	}
+	record.reported = true
+	r.reportConflict(conflict)
+}
+
+// recordCatalog adds the name of a new series to the catalog, filling in the
+// description and unit of names first registered without them. The caller must
+// hold the write lock.
+func (r *defaultRegistry) recordCatalog(opts Options, metricType Type) {
+	record, ok := r.catalog[opts.Name]
+	if !ok {
+		r.catalog[opts.Name] = &catalogEntry{CatalogEntry: CatalogEntry{
+			Name:        opts.Name,
+			Type:        metricType,
+			Description: opts.Description,
+			Unit:        opts.Unit,
+		}}
+		return
+	}
+	if record.Description == "" {
+		record.Description = opts.Description
+	}
+	if record.Unit == "" {
+		record.Unit = opts.Unit
+	}
+}
+
+// differs reports whether a requested description or unit conflicts with the
+// current one; either being empty is not a conflict
+func differs(current, requested string) bool {
+	return current != "" && requested != "" && current != requested
+}
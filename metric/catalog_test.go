@@ -0,0 +1,91 @@
+package metric
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	var conflicts []MetadataConflict
+	registry := NewNoCleanupRegistry(WithMetadataConflictHandler(func(c MetadataConflict) {
+		conflicts = append(conflicts, c)
+	}))
+	defer registry.Close()
+
+	registry.Counter(Options{Name: "requests_total", Tags: Tags{"route": "/"}})
+	registry.Counter(Options{Name: "requests_total", Description: "Requests served", Tags: Tags{"route": "/login"}})
+	registry.Histogram(Options{Name: "latency", Description: "Request latency", Unit: "ms"})
+	registry.Gauge(Options{Name: "queue_depth", Description: "Queue depth"})
+	if len(conflicts) != 0 {
+		t.Fatalf("Expected filling in a missing description not to conflict, got %v", conflicts)
+	}
+
+	// Conflicting series of a name are reported once per name, even with other tags
+	registry.Counter(Options{Name: "requests_total", Description: "HTTP requests", Tags: Tags{"route": "/a"}})
+	registry.Counter(Options{Name: "requests_total", Description: "HTTP requests", Tags: Tags{"route": "/b"}})
+	registry.Gauge(Options{Name: "latency"})
+	if len(conflicts) != 2 {
+		t.Fatalf("Expected a description and a type conflict, got %v", conflicts)
+	}
+	if c := conflicts[0]; c.Current.Description != "Requests served" || c.Requested.Description != "HTTP requests" || c.RequestedType != "" {
+		t.Errorf("Unexpected description conflict %+v", c)
+	}
+	if c := conflicts[1]; c.Type != TypeHistogram || c.RequestedType != TypeGauge {
+		t.Errorf("Unexpected type conflict %+v", c)
+	}
+
+	registry.(ConfigurableRegistry).SetMetadata("queue_depth", "Jobs waiting", "jobs")
+	catalog := registry.(MetadataCatalog).Catalog()
+	expected := []CatalogEntry{
+		{Name: "latency", Type: TypeHistogram, Description: "Request latency", Unit: "ms", Series: 2},
+		{Name: "queue_depth", Type: TypeGauge, Description: "Jobs waiting", Unit: "jobs", Series: 1},
+		{Name: "requests_total", Type: TypeCounter, Description: "Requests served", Series: 4},
+	}
+	if len(catalog) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, catalog)
+	}
+	for i := range expected {
+		if catalog[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], catalog[i])
+		}
+	}
+
+	registry.Unregister("latency")
+	if catalog := Catalog(registry); len(catalog) != 2 || catalog[0].Name != "queue_depth" {
+		t.Errorf("Expected unregistered names to leave the catalog, got %v", catalog)
+	}
+
+	// Registries without a catalog are cataloged from their series
+	tenant := ForTenant(registry, "acme")
+	tenant.Counter(Options{Name: "requests_total", Description: "Requests served"})
+	tenant.Counter(Options{Name: "requests_total", Tags: Tags{"route": "/"}})
+	if catalog := Catalog(tenant); len(catalog) != 1 || catalog[0].Series != 2 || catalog[0].Description != "Requests served" {
+		t.Errorf("Expected the tenant's series only, got %v", catalog)
+	}
+}
+
+func TestStrictMetadata(t *testing.T) {
+	registry := NewNoCleanupRegistry(WithStrictMetadata()).(SeriesRegistry)
+	defer registry.Close()
+	registry.Gauge(Options{Name: "queue_depth", Description: "Queue depth", Unit: "items"})
+	registry.Gauge(Options{Name: "queue_depth", Tags: Tags{"queue": "emails"}})
+
+	for name, register := range map[string]func(){
+		"same series":  func() { registry.Gauge(Options{Name: "queue_depth", Unit: "jobs"}) },
+		"other series": func() { registry.Gauge(Options{Name: "queue_depth", Unit: "jobs", Tags: Tags{"queue": "sms"}}) },
+		"other type":   func() { registry.Counter(Options{Name: "queue_depth"}) },
+	} {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrMetadataConflict) {
+					t.Errorf("%s: expected a metadata conflict panic, got %v", name, err)
+				}
+			}()
+			register()
+		}()
+	}
+	if series := registry.Series("queue_depth"); len(series) != 2 {
+		t.Errorf("Expected conflicting series not to be registered, got %d", len(series))
+	}
+}
@@ -1,13 +1,15 @@
 package metric
 
 import (
+	"sync"
 	"testing"
 	"time"
 )
 
 func TestMetricTTL(t *testing.T) {
-	// Create registry with short cleanup interval for testing
-	registry := NewRegistry(DefaultTagValidationConfig(), 100*time.Millisecond)
+	// Create registry with short cleanup interval and a clock moved by hand
+	clock := &stepClock{now: time.Unix(1700000000, 0)}
+	registry := NewRegistry(DefaultTagValidationConfig(), 10*time.Millisecond, WithClock(clock))
 	defer registry.Close()
 
 	// Create a metric with TTL
@@ -22,14 +24,17 @@ func TestMetricTTL(t *testing.T) {
 	}
 
 	// Metric should still exist before TTL expires
-	time.Sleep(100 * time.Millisecond)
-	counter2 := registry.Counter(Options{Name: "ttl_counter"})
-	if counter2.Value() != 1 {
-		t.Errorf("Expected counter to still exist with value 1, got %d", counter2.Value())
+	clock.advance(100 * time.Millisecond)
+	if _, ok := registry.(SeriesRegistry).Lookup("ttl_counter", TypeCounter); !ok {
+		t.Fatal("Expected counter to still exist before its TTL expired")
 	}
 
-	// Wait for TTL to expire and cleanup to run
-	time.Sleep(300 * time.Millisecond)
+	// Expire the TTL and wait for the background cleanup to remove the metric
+	clock.advance(300 * time.Millisecond)
+	eventually(t, time.Second, func() bool {
+		_, ok := registry.(SeriesRegistry).Lookup("ttl_counter", TypeCounter)
+		return !ok
+	})
 
 	// Create new counter with same name - should be a fresh instance
 	counter3 := registry.Counter(Options{Name: "ttl_counter"})
@@ -39,15 +44,17 @@ func TestMetricTTL(t *testing.T) {
 }
 
 func TestMetricWithoutTTL(t *testing.T) {
-	registry := NewRegistry(DefaultTagValidationConfig(), 50*time.Millisecond)
+	clock := &stepClock{now: time.Unix(1700000000, 0)}
+	registry := NewRegistry(DefaultTagValidationConfig(), time.Hour, WithClock(clock))
 	defer registry.Close()
 
 	// Create a metric without TTL
 	counter := registry.Counter(Options{Name: "persistent_counter"})
 	counter.Inc()
 
-	// Wait longer than cleanup interval
-	time.Sleep(200 * time.Millisecond)
+	// Run cleanup well after the metric was created
+	clock.advance(24 * time.Hour)
+	registry.ManualCleanup()
 
 	// Counter should still exist
 	counter2 := registry.Counter(Options{Name: "persistent_counter"})
@@ -56,8 +63,40 @@ func TestMetricWithoutTTL(t *testing.T) {
 	}
 }
 
+// eventually fails the test unless condition returns true within timeout
+func eventually(t *testing.T, timeout time.Duration, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Condition not met within %s", timeout)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// stepClock is a Clock moved by hand, standing in for testutil.FakeClock,
+// which this package cannot import
+type stepClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *stepClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
 func TestManualCleanup(t *testing.T) {
-	registry := NewRegistry(DefaultTagValidationConfig(), time.Hour) // Long interval
+	clock := &stepClock{now: time.Unix(1700000000, 0)}
+	registry := NewRegistry(DefaultTagValidationConfig(), time.Hour, WithClock(clock)) // Long interval
 	defer registry.Close()
 
 	// Create metric with short TTL
@@ -67,8 +106,8 @@ func TestManualCleanup(t *testing.T) {
 	})
 	counter.Inc()
 
-	// Wait for TTL to expire
-	time.Sleep(100 * time.Millisecond)
+	// Expire the TTL without sleeping
+	clock.advance(100 * time.Millisecond)
 
 	// Counter should still exist because cleanup hasn't run
 	counter2 := registry.Counter(Options{Name: "manual_cleanup_counter"})
@@ -86,6 +125,31 @@ func TestManualCleanup(t *testing.T) {
 	}
 }
 
+func TestClockTTLFromLastWrite(t *testing.T) {
+	clock := &stepClock{now: time.Unix(1700000000, 0)}
+	registry := NewRegistry(DefaultTagValidationConfig(), 0, WithClock(clock))
+	defer registry.Close()
+
+	counter := registry.Counter(Options{Name: "clock_ttl", TTL: time.Minute})
+	counter.Inc()
+
+	// A write after the first pass keeps the counter alive a TTL past that pass
+	clock.advance(40 * time.Second)
+	registry.ManualCleanup()
+	counter.Inc()
+	clock.advance(40 * time.Second)
+	registry.ManualCleanup()
+	if got := registry.Counter(Options{Name: "clock_ttl"}).Value(); got != 2 {
+		t.Fatalf("expected written counter to survive, got %d", got)
+	}
+
+	clock.advance(time.Minute)
+	registry.ManualCleanup()
+	if got := registry.Counter(Options{Name: "clock_ttl"}).Value(); got != 0 {
+		t.Errorf("expected idle counter to expire, got %d", got)
+	}
+}
+
 func TestRegistryClose(t *testing.T) {
 	registry := NewRegistry(DefaultTagValidationConfig(), 50*time.Millisecond)
 
@@ -240,4 +304,104 @@ func TestEachWithTTL(t *testing.T) {
 	if count != 1 {
 		t.Errorf("Expected 1 metric after first expiration, got %d", count)
 	}
-}
\ No newline at end of file
+}
+func TestTTLRefreshedByWrites(t *testing.T) {
+	registry := NewRegistry(DefaultTagValidationConfig(), time.Hour) // cleanup driven manually
+	defer registry.Close()
+
+	counter := registry.Counter(Options{
+		Name: "active_counter",
+		TTL:  200 * time.Millisecond,
+	})
+	histogram := registry.Histogram(Options{
+		Name: "active_histogram",
+		TTL:  200 * time.Millisecond,
+	}).With(Tags{"route": "/a"})
+
+	// Keep writing for longer than the TTL; the metrics must survive
+	for i := 0; i < 6; i++ {
+		time.Sleep(50 * time.Millisecond)
+		counter.Inc()
+		histogram.Observe(1)
+		registry.ManualCleanup()
+	}
+
+	if c := registry.Counter(Options{Name: "active_counter"}); c.Value() != 6 {
+		t.Errorf("Expected actively written counter to survive with value 6, got %d", c.Value())
+	}
+	if snapshot := histogram.Snapshot(); snapshot.Count != 6 {
+		t.Errorf("Expected histogram child to keep 6 observations, got %d", snapshot.Count)
+	}
+
+	// Once idle for longer than the TTL the metrics expire
+	time.Sleep(300 * time.Millisecond)
+	registry.ManualCleanup()
+
+	count := 0
+	registry.Each(func(m Metric) {
+		count++
+	})
+	if count != 0 {
+		t.Errorf("Expected idle metrics to expire, %d remain", count)
+	}
+}
+
+func TestOnExpire(t *testing.T) {
+	registry := NewRegistry(DefaultTagValidationConfig(), time.Hour).(ConfigurableRegistry)
+	defer registry.Close()
+
+	finalValues := make(map[string]uint64)
+	registry.OnExpire(func(m Metric) {
+		// Hooks run outside the registry lock, so using the registry is safe
+		registry.Counter(Options{Name: "expired_total"}).Inc()
+		if c, ok := m.(Counter); ok {
+			finalValues[c.Name()] = c.Value()
+		}
+	})
+
+	counter := registry.Counter(Options{
+		Name: "session_requests",
+		TTL:  20 * time.Millisecond,
+	})
+	counter.Add(7)
+	registry.Counter(Options{Name: "persistent"}).Inc()
+
+	time.Sleep(50 * time.Millisecond)
+	registry.ManualCleanup()
+
+	if finalValues["session_requests"] != 7 {
+		t.Errorf("Expected expire hook to see final value 7, got %v", finalValues)
+	}
+	if _, ok := finalValues["persistent"]; ok {
+		t.Error("Expected metric without TTL not to expire")
+	}
+	if v := registry.Counter(Options{Name: "expired_total"}).Value(); v != 1 {
+		t.Errorf("Expected hook to run once, ran %d times", v)
+	}
+}
+
+func TestExpiredChildIsRegisteredAgain(t *testing.T) {
+	registry := NewRegistry(DefaultTagValidationConfig(), time.Hour)
+	defer registry.Close()
+
+	child := registry.Timer(Options{Name: "latency", TTL: 10 * time.Millisecond}).With(Tags{"route": "/a"})
+	child.Record(time.Millisecond)
+
+	time.Sleep(30 * time.Millisecond)
+	registry.ManualCleanup()
+
+	// A series derived again after expiry must be fresh and visible to Each
+	again := registry.Timer(Options{Name: "latency", TTL: 10 * time.Millisecond}).With(Tags{"route": "/a"})
+	if again.Snapshot().Count != 0 {
+		t.Errorf("Expected a fresh series after expiry, got count %d", again.Snapshot().Count)
+	}
+	found := false
+	registry.Each(func(m Metric) {
+		if m.Name() == "latency" && m.Tags()["route"] == "/a" {
+			found = true
+		}
+	})
+	if !found {
+		t.Error("Expected the re-derived series to be registered")
+	}
+}
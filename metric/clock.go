@@ -0,0 +1,36 @@
+package metric
+
+import "time"
+
+// Clock tells registries and schedulers the time, so tests can control TTL
+// expiry instead of sleeping (see testutil.FakeClock)
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock reading time.Now, used unless WithClock or
+// WithSchedulerClock sets another
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// WithClock makes the registry measure TTLs with clock and stamp the last
+// write times read by LastUpdated and Series with it. Expiry is checked by
+// cleanup passes, which still run every cleanup interval of real time, so
+// tests advancing a fake clock call ManualCleanup to expire metrics.
+func WithClock(clock Clock) RegistryOption {
+	return func(r *defaultRegistry) {
+		r.clock = clock
+	}
+}
+
+// WithSchedulerClock makes the scheduler time its exports and shutdown report with clock
+func WithSchedulerClock(clock Clock) SchedulerOption {
+	return func(s *Scheduler) {
+		s.clock = clock
+	}
+}
@@ -126,22 +126,22 @@ func TestHistogramConcurrency(t *testing.T) {
 	}
 
 	// Verify min and max values are reasonable
-	expectedMin := uint64(1) // First value from goroutine 0 (now starts at 1)
-	expectedMax := uint64(numGoroutines*observationsPerGoroutine) // Last value from last goroutine
+	expectedMin := float64(1) // First value from goroutine 0 (now starts at 1)
+	expectedMax := float64(numGoroutines * observationsPerGoroutine) // Last value from last goroutine
 
 	if snapshot.Min != expectedMin {
-		t.Errorf("Expected histogram min %d, got %d", expectedMin, snapshot.Min)
+		t.Errorf("Expected histogram min %g, got %g", expectedMin, snapshot.Min)
 	}
 
 	if snapshot.Max != expectedMax {
-		t.Errorf("Expected histogram max %d, got %d", expectedMax, snapshot.Max)
+		t.Errorf("Expected histogram max %g, got %g", expectedMax, snapshot.Max)
 	}
 
 	// Verify sum is correct (sum of 1 to n = n*(n+1)/2)
 	n := numGoroutines * observationsPerGoroutine
-	expectedSum := uint64(n * (n + 1) / 2)
+	expectedSum := float64(n * (n + 1) / 2)
 	if snapshot.Sum != expectedSum {
-		t.Errorf("Expected histogram sum %d, got %d", expectedSum, snapshot.Sum)
+		t.Errorf("Expected histogram sum %g, got %g", expectedSum, snapshot.Sum)
 	}
 }
 
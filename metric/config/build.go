@@ -0,0 +1,235 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/metric/otel"
+	"github.com/MichaelAJay/go-metrics/metric/prometheus"
+)
+
+// Metrics is a registry and the reporters built for it from a Config
+type Metrics struct {
+	// Registry applies the config's default TTL and buckets to the metrics created through it
+	Registry metric.Registry
+	// Scheduler reports Registry to every reporter that is not live
+	Scheduler *metric.Scheduler
+	// Reporters are the built reporters by name
+	Reporters map[string]metric.Reporter
+
+	handlers map[string]http.Handler
+	live     []metric.Reporter
+	servers  []*http.Server
+	addrs    []net.Addr
+}
+
+// Handler returns the scrape handler of the named Prometheus reporter, or nil
+func (m *Metrics) Handler(name string) http.Handler {
+	return m.handlers[name]
+}
+
+// Build creates the registry and reporters config describes, starts the
+// scheduler and serves the scrape endpoints of Prometheus reporters with a
+// Listen address. Options are applied to the registry after the config's.
+func Build(config Config, opts ...metric.RegistryOption) (*Metrics, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	var registryOpts []metric.RegistryOption
+	if config.MetaMetrics {
+		registryOpts = append(registryOpts, metric.WithMetaMetrics())
+	}
+	registry := metric.NewRegistry(config.TagValidation.config(), config.CleanupInterval, append(registryOpts, opts...)...)
+	buckets := make(map[string][]float64, len(config.Buckets))
+	for name, b := range config.Buckets {
+		buckets[name], _ = b.boundaries()
+	}
+
+	m := &Metrics{
+		Registry:  &defaultsRegistry{fullRegistry: registry.(fullRegistry), ttl: config.DefaultTTL, buckets: buckets},
+		Reporters: make(map[string]metric.Reporter),
+		handlers:  make(map[string]http.Handler),
+	}
+	var scheduled []metric.SchedulerOption
+	for _, reporterConfig := range config.Reporters {
+		reporter, err := m.buildReporter(config, reporterConfig, registry)
+		if err != nil {
+			// Without a scheduler yet, Close closes the reporters built so far as live ones
+			m.live = slices.Collect(maps.Values(m.Reporters))
+			m.Close()
+			return nil, fmt.Errorf("reporter %s: %w", reporterConfig.name(), err)
+		}
+		m.Reporters[reporterConfig.name()] = reporter
+		if reporterConfig.Live {
+			m.live = append(m.live, reporter)
+		} else {
+			scheduled = append(scheduled, metric.WithReporter(reporterConfig.name(), reporter))
+		}
+	}
+	m.Scheduler = metric.NewScheduler(registry, config.ReportInterval, scheduled...)
+	return m, nil
+}
+
+// buildReporter creates the reporter of config
+func (m *Metrics) buildReporter(config Config, reporterConfig Reporter, registry metric.Registry) (metric.Reporter, error) {
+	tags := maps.Clone(config.DefaultTags)
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	maps.Copy(tags, reporterConfig.Tags)
+
+	var filter []metric.FilterOption
+	if len(reporterConfig.AllowNames) > 0 {
+		filter = append(filter, metric.AllowNames(reporterConfig.AllowNames...))
+	}
+	if len(reporterConfig.DenyNames) > 0 {
+		filter = append(filter, metric.DenyNames(reporterConfig.DenyNames...))
+	}
+	if len(reporterConfig.StripTags) > 0 {
+		filter = append(filter, metric.StripTags(reporterConfig.StripTags...))
+	}
+	if len(reporterConfig.Relabel) > 0 {
+		filter = append(filter, metric.Relabel(reporterConfig.Relabel...))
+	}
+
+	switch reporterConfig.Type {
+	case TypePrometheus:
+		opts := []prometheus.Option{prometheus.WithDefaultLabels(tags), prometheus.WithExportFilter(filter...)}
+		if reporterConfig.Live {
+			opts = append(opts, prometheus.WithLiveRegistry(registry))
+		}
+		reporter := prometheus.NewReporter(opts...)
+		handler := reporter.Handler()
+		m.handlers[reporterConfig.name()] = handler
+		if reporterConfig.Listen != "" {
+			if err := m.serve(reporterConfig, handler); err != nil {
+				return nil, err
+			}
+		}
+		return reporter, nil
+	case TypeOtel:
+		return otel.NewReporter(reporterConfig.ServiceName, reporterConfig.ServiceVersion,
+			otel.WithAttributes(tags), otel.WithExportFilter(filter...))
+	default:
+		return nil, fmt.Errorf("unknown type '%s'", reporterConfig.Type)
+	}
+}
+
+// serve starts serving handler on the reporter's Listen address and path
+func (m *Metrics) serve(reporterConfig Reporter, handler http.Handler) error {
+	listener, err := net.Listen("tcp", reporterConfig.Listen)
+	if err != nil {
+		return err
+	}
+	path := reporterConfig.Path
+	if path == "" {
+		path = DefaultMetricsPath
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, handler)
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	m.servers = append(m.servers, server)
+	m.addrs = append(m.addrs, listener.Addr())
+	go server.Serve(listener)
+	return nil
+}
+
+// Addrs returns the addresses the scrape endpoints listen on, in the order of
+// the config's reporters
+func (m *Metrics) Addrs() []net.Addr {
+	return m.addrs
+}
+
+// Close stops the scrape endpoints, runs the scheduler's final export, closes
+// every reporter and then the registry
+func (m *Metrics) Close() error {
+	var errs []error
+	for _, server := range m.servers {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		errs = append(errs, server.Shutdown(ctx))
+		cancel()
+	}
+	if m.Scheduler != nil {
+		_, err := m.Scheduler.Close()
+		errs = append(errs, err)
+	}
+	for _, reporter := range m.live {
+		errs = append(errs, reporter.Close())
+	}
+	errs = append(errs, m.Registry.Close())
+	return errors.Join(errs...)
+}
+
+// fullRegistry is every interface the registries of metric.NewRegistry implement
+type fullRegistry interface {
+	metric.InstrumentRegistry
+	metric.SeriesRegistry
+	metric.WatchRegistry
+	metric.ConfigurableRegistry
+	metric.PausableRegistry
+	metric.TagValidator
+}
+
+// defaultsRegistry gives metrics created without a TTL or buckets those of the config
+type defaultsRegistry struct {
+	fullRegistry
+	ttl     time.Duration
+	buckets map[string][]float64
+}
+
+// options fills in the defaults opts leaves out
+func (d *defaultsRegistry) options(opts metric.Options) metric.Options {
+	if opts.TTL == 0 {
+		opts.TTL = d.ttl
+	}
+	return opts
+}
+
+// histogramOptions also fills in the buckets configured for the metric's name
+func (d *defaultsRegistry) histogramOptions(opts metric.Options) metric.Options {
+	if opts.Buckets == nil {
+		opts.Buckets = d.buckets[opts.Name]
+	}
+	return d.options(opts)
+}
+
+func (d *defaultsRegistry) Counter(opts metric.Options) metric.Counter {
+	return d.fullRegistry.Counter(d.options(opts))
+}
+
+func (d *defaultsRegistry) Gauge(opts metric.Options) metric.Gauge {
+	return d.fullRegistry.Gauge(d.options(opts))
+}
+
+func (d *defaultsRegistry) GaugeFunc(opts metric.Options, fn func() float64) metric.Gauge {
+	return d.fullRegistry.GaugeFunc(d.options(opts), fn)
+}
+
+func (d *defaultsRegistry) UpDownCounter(opts metric.Options) metric.UpDownCounter {
+	return d.fullRegistry.UpDownCounter(d.options(opts))
+}
+
+func (d *defaultsRegistry) Histogram(opts metric.Options) metric.Histogram {
+	return d.fullRegistry.Histogram(d.histogramOptions(opts))
+}
+
+func (d *defaultsRegistry) Timer(opts metric.Options) metric.Timer {
+	return d.fullRegistry.Timer(d.histogramOptions(opts))
+}
+
+func (d *defaultsRegistry) Meter(opts metric.Options) metric.Meter {
+	return d.fullRegistry.Meter(d.options(opts))
+}
+
+func (d *defaultsRegistry) Cardinality(opts metric.Options) metric.Cardinality {
+	return d.fullRegistry.Cardinality(d.options(opts))
+}
@@ -0,0 +1,241 @@
+// Package config builds a metric.Registry and its reporters from a YAML or JSON
+// file or from environment variables, so services configure their metrics
+// backends declaratively instead of wiring reporters in main().
+//
+//	report_interval: 15s
+//	default_ttl: 10m
+//	default_tags: {service: checkout, env: prod}
+//	buckets:
+//	  payload_bytes: {start: 64, factor: 2, count: 12}
+//	reporters:
+//	  - type: prometheus
+//	    listen: ":9090"
+//	    live: true
+//	    strip_tags: [user_id]
+//	  - type: otel
+//	    service_name: checkout
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "config", Package: "github.com/MichaelAJay/go-metrics/metric/config"})
+}
+
+// Reporter types
+const (
+	TypePrometheus = "prometheus"
+	TypeOtel       = "otel"
+)
+
+// Defaults used for settings a config leaves out
+const (
+	DefaultReportInterval = 10 * time.Second
+	DefaultMetricsPath    = "/metrics"
+)
+
+// Config describes a registry and the reporters it is exported to
+type Config struct {
+	// CleanupInterval is how often expired metrics are removed; 0 disables cleanup
+	CleanupInterval time.Duration `json:"cleanup_interval,omitempty" yaml:"cleanup_interval,omitempty"`
+	// ReportInterval is how often scheduled reporters are given the registry
+	ReportInterval time.Duration `json:"report_interval,omitempty" yaml:"report_interval,omitempty"`
+	// DefaultTTL is the TTL of metrics created without one; 0 means they never expire
+	DefaultTTL time.Duration `json:"default_ttl,omitempty" yaml:"default_ttl,omitempty"`
+	// DefaultTags are exported with every metric by every reporter
+	DefaultTags map[string]string `json:"default_tags,omitempty" yaml:"default_tags,omitempty"`
+	// MetaMetrics enables the registry's meta-metrics (see metric.WithMetaMetrics)
+	MetaMetrics bool `json:"meta_metrics,omitempty" yaml:"meta_metrics,omitempty"`
+	// TagValidation limits the tags of the registry's metrics
+	TagValidation TagValidation `json:"tag_validation" yaml:"tag_validation"`
+	// Buckets are the bucket boundaries of histograms and timers created
+	// without their own, keyed by metric name. Timer boundaries are in nanoseconds.
+	Buckets map[string]Buckets `json:"buckets,omitempty" yaml:"buckets,omitempty"`
+	// Reporters are the backends the registry is exported to
+	Reporters []Reporter `json:"reporters,omitempty" yaml:"reporters,omitempty"`
+}
+
+// TagValidation mirrors metric.TagValidationConfig with snake_case keys
+type TagValidation struct {
+	MaxKeys        int      `json:"max_keys" yaml:"max_keys"`
+	MaxKeyLength   int      `json:"max_key_length" yaml:"max_key_length"`
+	MaxValueLength int      `json:"max_value_length" yaml:"max_value_length"`
+	MaxCardinality int      `json:"max_cardinality" yaml:"max_cardinality"`
+	DisallowedKeys []string `json:"disallowed_keys,omitempty" yaml:"disallowed_keys,omitempty"`
+}
+
+// Buckets are histogram bucket boundaries: Values as given, or Count
+// exponential boundaries from Start if Factor is set, or Count linear
+// boundaries from Start if Width is set
+type Buckets struct {
+	Values []float64 `json:"values,omitempty" yaml:"values,omitempty"`
+	Start  float64   `json:"start,omitempty" yaml:"start,omitempty"`
+	Width  float64   `json:"width,omitempty" yaml:"width,omitempty"`
+	Factor float64   `json:"factor,omitempty" yaml:"factor,omitempty"`
+	Count  int       `json:"count,omitempty" yaml:"count,omitempty"`
+}
+
+// Reporter configures one backend
+type Reporter struct {
+	// Type is TypePrometheus or TypeOtel
+	Type string `json:"type" yaml:"type"`
+	// Name identifies the reporter in Metrics.Reporters and shutdown reports; defaults to Type
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Listen is the address a Prometheus reporter serves scrapes on, e.g. ":9090";
+	// if empty, serve Metrics.Handler yourself
+	Listen string `json:"listen,omitempty" yaml:"listen,omitempty"`
+	// Path is the scrape path on Listen, DefaultMetricsPath if empty
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	// Live makes a Prometheus reporter read the registry on every scrape
+	// instead of being reported every ReportInterval
+	Live bool `json:"live,omitempty" yaml:"live,omitempty"`
+	// ServiceName and ServiceVersion identify the service to an OpenTelemetry reporter
+	ServiceName    string `json:"service_name,omitempty" yaml:"service_name,omitempty"`
+	ServiceVersion string `json:"service_version,omitempty" yaml:"service_version,omitempty"`
+	// Tags are exported by this reporter in addition to Config.DefaultTags
+	Tags map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// AllowNames, DenyNames, StripTags and Relabel filter and rewrite what this
+	// reporter exports (see metric.ExportFilter)
+	AllowNames []string             `json:"allow_names,omitempty" yaml:"allow_names,omitempty"`
+	DenyNames  []string             `json:"deny_names,omitempty" yaml:"deny_names,omitempty"`
+	StripTags  []string             `json:"strip_tags,omitempty" yaml:"strip_tags,omitempty"`
+	Relabel    []metric.RelabelRule `json:"relabel,omitempty" yaml:"relabel,omitempty"`
+}
+
+// Default returns the config used for settings a file or the environment
+// leaves out: no cleanup, DefaultReportInterval, the default tag validation
+// and no reporters
+func Default() Config {
+	tags := metric.DefaultTagValidationConfig()
+	return Config{
+		ReportInterval: DefaultReportInterval,
+		TagValidation: TagValidation{
+			MaxKeys:        tags.MaxKeys,
+			MaxKeyLength:   tags.MaxKeyLength,
+			MaxValueLength: tags.MaxValueLength,
+			MaxCardinality: tags.MaxCardinality,
+		},
+	}
+}
+
+// Parse reads a config from YAML or JSON on top of Default. Durations are
+// strings such as "30s"; unknown keys are an error.
+func Parse(data []byte) (Config, error) {
+	config := Default()
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return config, err
+	}
+	return config, config.Validate()
+}
+
+// Load reads a config from the YAML or JSON file at path
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	config, err := Parse(data)
+	if err != nil {
+		return config, fmt.Errorf("metrics config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Validate returns an error if the config cannot be built
+func (c Config) Validate() error {
+	if c.CleanupInterval < 0 || c.ReportInterval < 0 || c.DefaultTTL < 0 {
+		return errors.New("intervals and TTLs must not be negative")
+	}
+	if err := c.TagValidation.config().Validate(); err != nil {
+		return err
+	}
+	for name, buckets := range c.Buckets {
+		if _, err := buckets.boundaries(); err != nil {
+			return fmt.Errorf("buckets of %s: %w", name, err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, reporter := range c.Reporters {
+		if reporter.Type != TypePrometheus && reporter.Type != TypeOtel {
+			return fmt.Errorf("reporter %d: unknown type '%s'", i, reporter.Type)
+		}
+		name := reporter.name()
+		if names[name] {
+			return fmt.Errorf("reporter %d: duplicate name '%s'", i, name)
+		}
+		names[name] = true
+
+		for _, pattern := range slices.Concat(reporter.AllowNames, reporter.DenyNames) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("reporter %s: malformed pattern '%s': %w", name, pattern, err)
+			}
+		}
+		for _, rule := range reporter.Relabel {
+			if err := rule.Validate(); err != nil {
+				return fmt.Errorf("reporter %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// config returns the metric.TagValidationConfig of v
+func (v TagValidation) config() metric.TagValidationConfig {
+	return metric.TagValidationConfig{
+		MaxKeys:        v.MaxKeys,
+		MaxKeyLength:   v.MaxKeyLength,
+		MaxValueLength: v.MaxValueLength,
+		MaxCardinality: v.MaxCardinality,
+		DisallowedKeys: v.DisallowedKeys,
+	}
+}
+
+// boundaries returns the bucket boundaries b describes
+func (b Buckets) boundaries() ([]float64, error) {
+	var boundaries []float64
+	switch {
+	case len(b.Values) > 0:
+		boundaries = b.Values
+	case b.Count <= 0:
+		return nil, errors.New("values or a positive count are required")
+	case b.Factor > 0:
+		if b.Start <= 0 || b.Factor <= 1 {
+			return nil, errors.New("exponential buckets need a positive start and a factor above 1")
+		}
+		boundaries = metric.GenerateExponentialBuckets(b.Start, b.Factor, b.Count)
+	case b.Width > 0:
+		boundaries = metric.GenerateLinearBuckets(b.Start, b.Width, b.Count)
+	default:
+		return nil, errors.New("a width or factor is required with a count")
+	}
+
+	for i := 1; i < len(boundaries); i++ {
+		if boundaries[i] <= boundaries[i-1] {
+			return nil, errors.New("boundaries must be increasing")
+		}
+	}
+	return boundaries, nil
+}
+
+// name returns the reporter's name, defaulting to its type
+func (r Reporter) name() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Type
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestBuildFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.yaml")
+	data := `
+default_ttl: 10m
+default_tags: {service: checkout}
+tag_validation:
+  max_cardinality: 50
+buckets:
+  payload_bytes: {start: 64, factor: 2, count: 4}
+reporters:
+  - type: prometheus
+    listen: "127.0.0.1:0"
+    live: true
+    strip_tags: [user_id]
+  - type: prometheus
+    name: reported
+    deny_names: ["payload_*"]
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ReportInterval != DefaultReportInterval || config.TagValidation.MaxKeys != metric.DefaultTagValidationConfig().MaxKeys {
+		t.Errorf("Expected defaults for settings the file leaves out, got %+v", config)
+	}
+
+	m, err := Build(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	m.Registry.Counter(metric.Options{Name: "orders_total", Tags: metric.Tags{"user_id": "7"}}).Add(2)
+	payload := m.Registry.Histogram(metric.Options{Name: "payload_bytes"})
+	payload.Observe(100)
+	if got := payload.Snapshot().Boundaries; len(got) != 4 || got[0] != 64 || got[3] != 512 {
+		t.Errorf("Expected the configured buckets, got %v", got)
+	}
+	if got := metric.SeriesOf(m.Registry, "orders_total"); len(got) != 1 {
+		t.Fatalf("Expected one orders series, got %v", got)
+	}
+
+	resp, err := http.Get("http://" + m.Addrs()[0].String() + DefaultMetricsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `orders_total{service="checkout"} 2`) {
+		t.Errorf("Expected the live scrape to carry the default tags without user_id\n%s", body)
+	}
+
+	if m.Handler("reported") == nil || m.Reporters["reported"] == nil {
+		t.Fatal("Expected the reported reporter to be built")
+	}
+	m.Scheduler.Export()
+	rec := httptest.NewRecorder()
+	m.Handler("reported").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DefaultMetricsPath, nil))
+	if body := rec.Body.String(); !strings.Contains(body, `orders_total{service="checkout",user_id="7"} 2`) || strings.Contains(body, "payload_bytes") {
+		t.Errorf("Expected the scheduled reporter's own filter to apply\n%s", body)
+	}
+}
+
+func TestParseRejectsInvalidConfigs(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown key":    `report_intervall: 5s`,
+		"unknown type":   `{"reporters": [{"type": "statsd"}]}`,
+		"duplicate name": `{"reporters": [{"type": "otel"}, {"type": "otel"}]}`,
+		"bad buckets":    `{"buckets": {"latency": {"values": [2, 1]}}}`,
+		"bad pattern":    `{"reporters": [{"type": "otel", "allow_names": ["["]}]}`,
+		"negative ttl":   `{"default_ttl": "-1s"}`,
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	config, err := Parse([]byte(`{"report_interval": "30s", "reporters": [{"type": "otel", "service_name": "api"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ReportInterval != 30*time.Second || config.Reporters[0].ServiceName != "api" {
+		t.Errorf("Expected JSON to be parsed, got %+v", config)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	if err := os.WriteFile(path, []byte(`{"reporters": [{"type": "prometheus", "deny_names": ["debug_*"]}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("METRICS_CONFIG_FILE", path)
+	t.Setenv("METRICS_REPORTERS", "prometheus, otel")
+	t.Setenv("METRICS_PROMETHEUS_LISTEN", ":9464")
+	t.Setenv("METRICS_OTEL_SERVICE_NAME", "billing")
+	t.Setenv("METRICS_DEFAULT_TAGS", "env=prod, region=eu")
+	t.Setenv("METRICS_DEFAULT_TTL", "15m")
+	t.Setenv("METRICS_MAX_CARDINALITY", "200")
+
+	config, err := FromEnv("METRICS_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Reporters) != 2 {
+		t.Fatalf("Expected two reporters, got %+v", config.Reporters)
+	}
+	prom, otel := config.Reporters[0], config.Reporters[1]
+	if prom.Listen != ":9464" || len(prom.DenyNames) != 1 {
+		t.Errorf("Expected the file's prometheus settings with the env listen address, got %+v", prom)
+	}
+	if otel.Type != TypeOtel || otel.ServiceName != "billing" {
+		t.Errorf("Expected an otel reporter from the env, got %+v", otel)
+	}
+	if config.DefaultTags["region"] != "eu" || config.DefaultTTL != 15*time.Minute || config.TagValidation.MaxCardinality != 200 {
+		t.Errorf("Expected the env overrides, got %+v", config)
+	}
+
+	t.Setenv("METRICS_DEFAULT_TAGS", "env")
+	if _, err := FromEnv("METRICS_"); err == nil {
+		t.Error("Expected malformed tags to fail")
+	}
+}
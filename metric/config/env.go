@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// Environment variable suffixes read by FromEnv, in addition to the tag
+// validation variables of metric.TagValidationFromEnv
+const (
+	// EnvConfigFile names a config file loaded before the other variables apply
+	EnvConfigFile      = "CONFIG_FILE"
+	EnvCleanupInterval = "CLEANUP_INTERVAL"
+	EnvReportInterval  = "REPORT_INTERVAL"
+	EnvDefaultTTL      = "DEFAULT_TTL"
+	// EnvDefaultTags holds comma-separated key=value pairs
+	EnvDefaultTags = "DEFAULT_TAGS"
+	EnvMetaMetrics = "META_METRICS"
+	// EnvReporters holds comma-separated reporter types; it replaces the file's
+	// reporters, keeping the settings of those whose type is listed
+	EnvReporters          = "REPORTERS"
+	EnvPrometheusListen   = "PROMETHEUS_LISTEN"
+	EnvPrometheusPath     = "PROMETHEUS_PATH"
+	EnvPrometheusLive     = "PROMETHEUS_LIVE"
+	EnvOtelServiceName    = "OTEL_SERVICE_NAME"
+	EnvOtelServiceVersion = "OTEL_SERVICE_VERSION"
+)
+
+// FromEnv returns the config described by the environment variables prefix
+// followed by the Env constants, e.g. METRICS_REPORTERS=prometheus and
+// METRICS_PROMETHEUS_LISTEN=:9090 for the prefix "METRICS_". The file named by
+// EnvConfigFile, or Default, is the base the other variables override.
+func FromEnv(prefix string) (Config, error) {
+	config := Default()
+	if path, ok := os.LookupEnv(prefix + EnvConfigFile); ok {
+		var err error
+		if config, err = Load(path); err != nil {
+			return config, err
+		}
+	}
+
+	for suffix, field := range map[string]*time.Duration{
+		EnvCleanupInterval: &config.CleanupInterval,
+		EnvReportInterval:  &config.ReportInterval,
+		EnvDefaultTTL:      &config.DefaultTTL,
+	} {
+		if raw, ok := os.LookupEnv(prefix + suffix); ok {
+			d, err := time.ParseDuration(strings.TrimSpace(raw))
+			if err != nil {
+				return config, fmt.Errorf("%s%s: %w", prefix, suffix, err)
+			}
+			*field = d
+		}
+	}
+	if raw, ok := os.LookupEnv(prefix + EnvDefaultTags); ok {
+		tags, err := parseTags(raw)
+		if err != nil {
+			return config, fmt.Errorf("%s%s: %w", prefix, EnvDefaultTags, err)
+		}
+		config.DefaultTags = tags
+	}
+	if raw, ok := os.LookupEnv(prefix + EnvMetaMetrics); ok {
+		enabled, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return config, fmt.Errorf("%s%s: %w", prefix, EnvMetaMetrics, err)
+		}
+		config.MetaMetrics = enabled
+	}
+
+	tags, err := metric.TagValidationFromEnv(prefix, config.TagValidation.config())
+	if err != nil {
+		return config, err
+	}
+	config.TagValidation = TagValidation{
+		MaxKeys:        tags.MaxKeys,
+		MaxKeyLength:   tags.MaxKeyLength,
+		MaxValueLength: tags.MaxValueLength,
+		MaxCardinality: tags.MaxCardinality,
+		DisallowedKeys: tags.DisallowedKeys,
+	}
+
+	if raw, ok := os.LookupEnv(prefix + EnvReporters); ok {
+		var reporters []Reporter
+		for _, reporterType := range strings.Split(raw, ",") {
+			if reporterType = strings.TrimSpace(reporterType); reporterType == "" {
+				continue
+			}
+			reporter := Reporter{Type: reporterType}
+			for _, configured := range config.Reporters {
+				if configured.Type == reporterType {
+					reporter = configured
+					break
+				}
+			}
+			reporters = append(reporters, reporter)
+		}
+		config.Reporters = reporters
+	}
+
+	for i := range config.Reporters {
+		reporter := &config.Reporters[i]
+		switch reporter.Type {
+		case TypePrometheus:
+			lookupString(prefix+EnvPrometheusListen, &reporter.Listen)
+			lookupString(prefix+EnvPrometheusPath, &reporter.Path)
+			if raw, ok := os.LookupEnv(prefix + EnvPrometheusLive); ok {
+				live, err := strconv.ParseBool(strings.TrimSpace(raw))
+				if err != nil {
+					return config, fmt.Errorf("%s%s: %w", prefix, EnvPrometheusLive, err)
+				}
+				reporter.Live = live
+			}
+		case TypeOtel:
+			lookupString(prefix+EnvOtelServiceName, &reporter.ServiceName)
+			lookupString(prefix+EnvOtelServiceVersion, &reporter.ServiceVersion)
+		}
+	}
+	return config, config.Validate()
+}
+
+// lookupString sets *field to the environment variable key, if it is set
+func lookupString(key string, field *string) {
+	if raw, ok := os.LookupEnv(key); ok {
+		*field = strings.TrimSpace(raw)
+	}
+}
+
+// parseTags parses comma-separated key=value pairs
+func parseTags(raw string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("expected key=value, got '%s'", pair)
+		}
+		tags[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return tags, nil
+}
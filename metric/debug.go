@@ -0,0 +1,130 @@
+package metric
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// debugMetric is the JSON form of one series served by DebugHandler
+type debugMetric struct {
+	Name        string          `json:"name"`
+	Type        Type            `json:"type"`
+	Description string          `json:"description,omitempty"`
+	Unit        string          `json:"unit,omitempty"`
+	Tags        Tags            `json:"tags"`
+	Value       float64         `json:"value"`
+	LastUpdated *time.Time      `json:"last_updated,omitempty"`
+	AgeSeconds  *float64        `json:"age_seconds,omitempty"`
+	Histogram   *debugHistogram `json:"histogram,omitempty"`
+	Meter       *MeterSnapshot  `json:"meter,omitempty"`
+}
+
+// debugHistogram is the JSON form of a histogram or timer snapshot. The
+// statistics are omitted while the histogram is empty.
+type debugHistogram struct {
+	Count   uint64        `json:"count"`
+	Sum     float64       `json:"sum"`
+	Min     *float64      `json:"min,omitempty"`
+	Max     *float64      `json:"max,omitempty"`
+	Mean    *float64      `json:"mean,omitempty"`
+	P50     *float64      `json:"p50,omitempty"`
+	P90     *float64      `json:"p90,omitempty"`
+	P99     *float64      `json:"p99,omitempty"`
+	Buckets []debugBucket `json:"buckets"`
+}
+
+// debugBucket is one histogram bucket; its upper bound is a string so the last
+// bucket can be "+Inf", which JSON numbers cannot hold
+type debugBucket struct {
+	LE    string `json:"le"`
+	Count uint64 `json:"count"`
+}
+
+// DebugHandler returns an HTTP handler rendering every metric in registry as
+// indented JSON, with its type, tags, value and histogram statistics, for quick
+// inspection with curl independent of any reporter. Series that were written
+// show when and how many seconds ago, so a frozen gauge stands out from a
+// healthy one. The name query parameter limits the output to metrics whose
+// name starts with it.
+func DebugHandler(registry Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		prefix := req.URL.Query().Get("name")
+
+		metrics := []debugMetric{}
+		registry.Each(func(m Metric) {
+			if !strings.HasPrefix(m.Name(), prefix) {
+				return
+			}
+			value, snapshot, ok := readValue(m)
+			if !ok {
+				return
+			}
+
+			d := debugMetric{
+				Name:        m.Name(),
+				Type:        m.Type(),
+				Description: m.Description(),
+				Unit:        m.Unit(),
+				Tags:        m.Tags(),
+				Value:       value,
+			}
+			if d.Tags == nil {
+				d.Tags = Tags{}
+			}
+			if updated := LastUpdated(m); !updated.IsZero() {
+				age := time.Since(updated).Seconds()
+				d.LastUpdated, d.AgeSeconds = &updated, &age
+			}
+			if snapshot != nil {
+				d.Histogram = newDebugHistogram(*snapshot)
+			}
+			if meter, ok := m.(Meter); ok {
+				s := meter.Snapshot()
+				d.Meter = &s
+			}
+			metrics = append(metrics, d)
+		})
+
+		slices.SortFunc(metrics, func(a, b debugMetric) int {
+			if c := strings.Compare(a.Name, b.Name); c != 0 {
+				return c
+			}
+			if c := strings.Compare(string(a.Type), string(b.Type)); c != 0 {
+				return c
+			}
+			return strings.Compare(canonicalTags(a.Tags), canonicalTags(b.Tags))
+		})
+
+		writeDebugJSON(w, struct {
+			Metrics []debugMetric `json:"metrics"`
+		}{metrics})
+	})
+}
+
+// writeDebugJSON writes body as indented JSON
+func writeDebugJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(body)
+}
+
+func newDebugHistogram(s HistogramSnapshot) *debugHistogram {
+	h := &debugHistogram{Count: s.Count, Sum: s.Sum, Buckets: []debugBucket{}}
+	for _, b := range s.BucketRanges() {
+		h.Buckets = append(h.Buckets, debugBucket{
+			LE:    strconv.FormatFloat(b.Upper, 'g', -1, 64),
+			Count: b.Count,
+		})
+	}
+	if s.Count > 0 {
+		stat := func(v float64) *float64 { return &v }
+		h.Min, h.Max, h.Mean = stat(s.Min), stat(s.Max), stat(s.Mean())
+		h.P50, h.P90, h.P99 = stat(s.Quantile(0.5)), stat(s.Quantile(0.9)), stat(s.Quantile(0.99))
+	}
+	return h
+}
@@ -0,0 +1,70 @@
+package metric
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDebugHandler(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	registry.Counter(Options{Name: "http_requests_total", Description: "Requests served", Tags: Tags{"route": "/"}}).Add(3)
+	histogram := registry.Histogram(Options{Name: "http_response_bytes", Buckets: []float64{100, 1000}})
+	histogram.Observe(50)
+	histogram.Observe(500)
+	registry.Histogram(Options{Name: "http_empty_bytes"})
+	registry.Gauge(Options{Name: "queue_depth"}).Set(7)
+
+	rec := httptest.NewRecorder()
+	DebugHandler(registry).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/metrics?name=http_", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected a JSON content type, got %q", ct)
+	}
+	var body struct {
+		Metrics []struct {
+			Name        string
+			Type        Type
+			Description string
+			Tags        Tags
+			Value       float64
+			LastUpdated *time.Time `json:"last_updated"`
+			AgeSeconds  *float64   `json:"age_seconds"`
+			Histogram   *struct {
+				Count    uint64
+				Min, P50 *float64
+				Buckets  []struct {
+					LE    string
+					Count uint64
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected valid JSON, got %v:\n%s", err, rec.Body.String())
+	}
+
+	if len(body.Metrics) != 3 {
+		t.Fatalf("Expected the 3 metrics named http_*, got %+v", body.Metrics)
+	}
+	empty, requests, bytes := body.Metrics[0], body.Metrics[1], body.Metrics[2]
+	if requests.Name != "http_requests_total" || requests.Type != TypeCounter || requests.Value != 3 || requests.Tags["route"] != "/" || requests.Description != "Requests served" {
+		t.Errorf("Unexpected counter %+v", requests)
+	}
+	if h := bytes.Histogram; h == nil || h.Count != 2 || *h.Min != 50 || h.P50 == nil || len(h.Buckets) != 3 || h.Buckets[2].LE != "+Inf" || h.Buckets[1].Count != 1 {
+		t.Errorf("Unexpected histogram %+v", bytes.Histogram)
+	}
+	if h := empty.Histogram; h == nil || h.Count != 0 || h.Min != nil {
+		t.Errorf("Expected an empty histogram without statistics, got %+v", empty.Histogram)
+	}
+	if requests.LastUpdated == nil || requests.AgeSeconds == nil || *requests.AgeSeconds < 0 || time.Since(*requests.LastUpdated) > time.Minute {
+		t.Errorf("Expected the counter's last update and age, got %v and %v", requests.LastUpdated, requests.AgeSeconds)
+	}
+	if empty.LastUpdated != nil || empty.AgeSeconds != nil {
+		t.Errorf("Expected no age for a series never written, got %v", empty.LastUpdated)
+	}
+}
@@ -0,0 +1,153 @@
+package metric
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDeltaSeconds is the capture window of DeltaHandler when the request has no seconds parameter
+const DefaultDeltaSeconds = 30
+
+// deltaMetric is the JSON form of one series' change served by DeltaHandler
+type deltaMetric struct {
+	Name  string  `json:"name"`
+	Type  Type    `json:"type"`
+	Tags  Tags    `json:"tags"`
+	Value float64 `json:"value"`
+	// Delta is the increase of a counter, meter, histogram or timer count, or the
+	// change of a gauge or up/down counter, over the window
+	Delta float64 `json:"delta"`
+	// Rate is Delta per second
+	Rate float64 `json:"rate"`
+	// New is set for series registered during the window
+	New bool `json:"new,omitempty"`
+	// Histogram holds the observations made during the window; its min and max
+	// are those since the series was created
+	Histogram *debugHistogram `json:"histogram,omitempty"`
+}
+
+// deltaSample is the state of one series when a DeltaHandler window opens or closes
+type deltaSample struct {
+	metric   Metric
+	value    float64
+	snapshot *HistogramSnapshot
+}
+
+// DeltaHandler returns an HTTP handler that, like the pprof profile endpoint,
+// captures the registry twice, seconds apart, and renders the difference as
+// JSON: counter increases, gauge changes and the histogram buckets filled in
+// the window, with the window's quantiles. Series that did not change are
+// left out. The seconds query parameter sets the window (DefaultDeltaSeconds
+// if absent) and the name parameter limits the output to metrics whose name
+// starts with it:
+//
+//	curl 'localhost:8080/debug/metrics/delta?seconds=10&name=http_'
+//
+// The server's WriteTimeout must exceed the window.
+func DeltaHandler(registry Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		seconds := DefaultDeltaSeconds
+		if raw := req.URL.Query().Get("seconds"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "seconds must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			seconds = parsed
+		}
+		prefix := req.URL.Query().Get("name")
+
+		start := time.Now()
+		before := captureDelta(registry, prefix)
+		select {
+		case <-time.After(time.Duration(seconds) * time.Second):
+		case <-req.Context().Done():
+			return
+		}
+		end := time.Now()
+		after := captureDelta(registry, prefix)
+
+		elapsed := end.Sub(start).Seconds()
+		metrics := []deltaMetric{}
+		for key, sample := range after {
+			previous, existed := before[key]
+			d, changed := diffSample(previous, sample, existed)
+			if !changed {
+				continue
+			}
+			d.Rate = d.Delta / elapsed
+			metrics = append(metrics, d)
+		}
+		slices.SortFunc(metrics, func(a, b deltaMetric) int {
+			if c := strings.Compare(a.Name, b.Name); c != 0 {
+				return c
+			}
+			if c := strings.Compare(string(a.Type), string(b.Type)); c != 0 {
+				return c
+			}
+			return strings.Compare(canonicalTags(a.Tags), canonicalTags(b.Tags))
+		})
+
+		writeDebugJSON(w, struct {
+			Start   time.Time     `json:"start"`
+			End     time.Time     `json:"end"`
+			Metrics []deltaMetric `json:"metrics"`
+		}{start, end, metrics})
+	})
+}
+
+// captureDelta reads every series whose name starts with prefix, keyed by type, name and tags
+func captureDelta(registry Registry, prefix string) map[string]deltaSample {
+	samples := make(map[string]deltaSample)
+	registry.Each(func(m Metric) {
+		if !strings.HasPrefix(m.Name(), prefix) {
+			return
+		}
+		value, snapshot, ok := readValue(m)
+		if !ok {
+			return
+		}
+		key := seriesKey(m.Type(), m.Name(), m.Tags())
+		samples[key] = deltaSample{metric: m, value: value, snapshot: snapshot}
+	})
+	return samples
+}
+
+// diffSample describes how a series changed from previous to current. A
+// counter or histogram that went backwards was reset, so all of its current
+// value counts as the window's.
+func diffSample(previous, current deltaSample, existed bool) (deltaMetric, bool) {
+	m := current.metric
+	d := deltaMetric{Name: m.Name(), Type: m.Type(), Tags: m.Tags(), Value: current.value, New: !existed}
+	if d.Tags == nil {
+		d.Tags = Tags{}
+	}
+
+	switch m.Type() {
+	case TypeGauge, TypeUpDownCounter:
+		d.Delta = current.value - previous.value
+	default:
+		d.Delta = current.value
+		if existed && previous.value <= current.value {
+			d.Delta -= previous.value
+		}
+	}
+
+	if current.snapshot != nil {
+		window := *current.snapshot
+		if existed && previous.snapshot != nil && previous.snapshot.Count <= window.Count &&
+			slices.Equal(previous.snapshot.Boundaries, window.Boundaries) && len(previous.snapshot.Buckets) == len(window.Buckets) {
+			window.Count -= previous.snapshot.Count
+			window.Sum -= previous.snapshot.Sum
+			window.Buckets = slices.Clone(window.Buckets)
+			for i := range window.Buckets {
+				window.Buckets[i] -= previous.snapshot.Buckets[i]
+			}
+		}
+		d.Histogram = newDebugHistogram(window)
+	}
+	return d, d.Delta != 0
+}
@@ -0,0 +1,80 @@
+package metric
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeltaHandler(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	requests := registry.Counter(Options{Name: "http_requests_total", Tags: Tags{"route": "/"}})
+	requests.Add(10)
+	latency := registry.Histogram(Options{Name: "http_latency", Buckets: []float64{10, 100}})
+	latency.Observe(5)
+	registry.Counter(Options{Name: "http_idle_total"}).Inc()
+	inFlight := registry.Gauge(Options{Name: "http_in_flight"})
+	inFlight.Set(8)
+	registry.Counter(Options{Name: "jobs_total"}).Inc()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		requests.Add(5)
+		latency.Observe(50)
+		latency.Observe(60)
+		inFlight.Set(3)
+		registry.Counter(Options{Name: "http_errors_total"}).Add(2)
+	}()
+
+	rec := httptest.NewRecorder()
+	DeltaHandler(registry).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/metrics/delta?seconds=1&name=http_", nil))
+
+	var body struct {
+		Start, End time.Time
+		Metrics    []struct {
+			Name         string
+			Tags         Tags
+			Value, Delta float64
+			Rate         float64
+			New          bool
+			Histogram    *struct {
+				Count   uint64
+				Buckets []struct{ Count uint64 }
+			}
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected valid JSON, got %v:\n%s", err, rec.Body.String())
+	}
+	if body.End.Sub(body.Start) < time.Second {
+		t.Errorf("Expected a window of at least 1s, got %v", body.End.Sub(body.Start))
+	}
+
+	// Sorted by name; the unchanged idle counter and jobs_total are left out
+	if len(body.Metrics) != 4 {
+		t.Fatalf("Expected 4 changed http_ series, got %+v", body.Metrics)
+	}
+	errors, inflight, latencyDelta, requestsDelta := body.Metrics[0], body.Metrics[1], body.Metrics[2], body.Metrics[3]
+	if errors.Name != "http_errors_total" || !errors.New || errors.Delta != 2 {
+		t.Errorf("Expected new http_errors_total with delta 2, got %+v", errors)
+	}
+	if inflight.Name != "http_in_flight" || inflight.Delta != -5 || inflight.Value != 3 {
+		t.Errorf("Expected http_in_flight to drop by 5, got %+v", inflight)
+	}
+	if h := latencyDelta.Histogram; latencyDelta.Delta != 2 || h == nil || h.Count != 2 || h.Buckets[0].Count != 0 || h.Buckets[1].Count != 2 {
+		t.Errorf("Expected 2 latency observations in the 10-100 bucket, got %+v %+v", latencyDelta, h)
+	}
+	if requestsDelta.Delta != 5 || requestsDelta.Rate <= 0 || requestsDelta.Rate > 5 || requestsDelta.Tags["route"] != "/" {
+		t.Errorf("Expected requests to grow by 5, got %+v", requestsDelta)
+	}
+
+	rec = httptest.NewRecorder()
+	DeltaHandler(registry).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?seconds=-1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid window, got %d", rec.Code)
+	}
+}
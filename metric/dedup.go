@@ -0,0 +1,112 @@
+package metric
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+// filteredRegistry is a view of a registry whose Each only sees the metrics
+// keep returns true for; every other method reaches the registry itself
+type filteredRegistry struct {
+	Registry
+	keep func(Metric) bool
+}
+
+func (f *filteredRegistry) Each(fn func(Metric)) {
+	f.Registry.Each(func(m Metric) {
+		if f.keep(m) {
+			fn(m)
+		}
+	})
+}
+
+// sentGauge is the value of a gauge series at the report that last sent it
+type sentGauge struct {
+	value float64
+	at    time.Time
+}
+
+// gaugeDedupReporter leaves gauges out of reports while their value is unchanged
+type gaugeDedupReporter struct {
+	Reporter
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	sent map[string]sentGauge
+}
+
+// DedupGauges wraps a push reporter so a gauge or up-down counter is left out of
+// a report when its value is identical to the one last sent for the same series
+// less than window ago, cutting the points sent for slow-changing gauges on
+// short report intervals. Each series is still sent at least once per window,
+// so backends do not mark it stale. A window of zero or less returns reporter as is.
+func DedupGauges(reporter Reporter, window time.Duration) Reporter {
+	if window <= 0 {
+		return reporter
+	}
+	return &gaugeDedupReporter{
+		Reporter: reporter,
+		window:   window,
+		now:      time.Now,
+		sent:     make(map[string]sentGauge),
+	}
+}
+
+// Report reports the registry without the gauges that are unchanged since they
+// were last sent. Gauges only count as sent when Report succeeds.
+func (d *gaugeDedupReporter) Report(registry Registry) error {
+	return d.ReportContext(context.Background(), registry)
+}
+
+// ReportContext implements ContextReporter for the wrapped reporter
+func (d *gaugeDedupReporter) ReportContext(ctx context.Context, registry Registry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for key, s := range d.sent {
+		if now.Sub(s.at) >= d.window {
+			delete(d.sent, key)
+		}
+	}
+
+	pending := make(map[string]sentGauge)
+	err := ReportContext(ctx, d.Reporter, &filteredRegistry{Registry: registry, keep: func(m Metric) bool {
+		if m.Type() != TypeGauge && m.Type() != TypeUpDownCounter {
+			return true
+		}
+		value, _, ok := readValue(m)
+		if !ok {
+			return true
+		}
+		key := seriesKey(m.Type(), m.Name(), m.Tags())
+		if s, ok := d.sent[key]; ok && s.value == value {
+			return false
+		}
+		pending[key] = sentGauge{value: value, at: now}
+		return true
+	}})
+	if err == nil {
+		maps.Copy(d.sent, pending)
+	}
+	return err
+}
+
+// Dropped implements DropCounter for reporters that do
+func (d *gaugeDedupReporter) Dropped() uint64 {
+	if counter, ok := d.Reporter.(DropCounter); ok {
+		return counter.Dropped()
+	}
+	return 0
+}
+
+// PayloadBytes implements PayloadSizer for reporters that do
+func (d *gaugeDedupReporter) PayloadBytes() uint64 {
+	if sizer, ok := d.Reporter.(PayloadSizer); ok {
+		return sizer.PayloadBytes()
+	}
+	return 0
+}
@@ -0,0 +1,75 @@
+package metric
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// collectingReporter records the names of the metrics each report saw
+type collectingReporter struct {
+	fakeReporter
+	seen [][]string
+}
+
+func (c *collectingReporter) Report(registry Registry) error {
+	var names []string
+	registry.Each(func(m Metric) { names = append(names, m.Name()) })
+	slices.Sort(names)
+	c.seen = append(c.seen, names)
+	return c.fakeReporter.Report(registry)
+}
+
+func TestDedupGauges(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	registry.Counter(Options{Name: "requests_total"}).Inc()
+	depth := registry.Gauge(Options{Name: "queue_depth"})
+	depth.Set(3)
+
+	inner := &collectingReporter{}
+	reporter := DedupGauges(inner, time.Minute).(*gaugeDedupReporter)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	reporter.now = func() time.Time { return now }
+
+	report := func() []string {
+		t.Helper()
+		reporter.Report(registry)
+		return inner.seen[len(inner.seen)-1]
+	}
+
+	if got := report(); !slices.Equal(got, []string{"queue_depth", "requests_total"}) {
+		t.Errorf("Expected the first report to send everything, got %v", got)
+	}
+	now = now.Add(time.Second)
+	if got := report(); !slices.Equal(got, []string{"requests_total"}) {
+		t.Errorf("Expected the unchanged gauge to be left out, got %v", got)
+	}
+
+	depth.Set(4)
+	now = now.Add(time.Second)
+	if got := report(); !slices.Equal(got, []string{"queue_depth", "requests_total"}) {
+		t.Errorf("Expected the changed gauge to be sent, got %v", got)
+	}
+
+	// A failed report does not count as sent
+	depth.Set(5)
+	inner.setErr(errors.New("backend down"))
+	reporter.Report(registry)
+	inner.setErr(nil)
+	if got := report(); !slices.Contains(got, "queue_depth") {
+		t.Errorf("Expected the gauge to be sent again after a failed report, got %v", got)
+	}
+
+	// An unchanged gauge is sent again once the window has passed
+	now = now.Add(time.Minute)
+	if got := report(); !slices.Contains(got, "queue_depth") {
+		t.Errorf("Expected the gauge to be resent after the window, got %v", got)
+	}
+
+	if DedupGauges(inner, 0) != Reporter(inner) {
+		t.Error("Expected a zero window to leave the reporter unwrapped")
+	}
+}
@@ -0,0 +1,302 @@
+// Package delta encodes successive registry snapshots as deltas against the last
+// snapshot acknowledged by the receiver, with periodic full syncs. It is transport
+// agnostic: frames are plain values that a forwarding transport (such as a
+// sidecar agent connection) serializes however it likes.
+package delta
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "delta", Package: "github.com/MichaelAJay/go-metrics/metric/delta"})
+}
+
+// DefaultFullSyncEvery is how many frames may be sent between full syncs by default
+const DefaultFullSyncEvery = 60
+
+// maxPending bounds how many unacknowledged frames the encoder remembers
+const maxPending = 16
+
+// ErrUnknownBase is returned by Decoder.Apply when a delta frame refers to a
+// snapshot the decoder does not hold. The sender should be asked for a full sync.
+var ErrUnknownBase = errors.New("delta: frame base snapshot is unknown")
+
+// Series is the state of one metric series at snapshot time
+type Series struct {
+	Name string
+	Type metric.Type
+	Tags metric.Tags
+	// Value holds the counter or gauge value, or the event count of a meter
+	Value float64
+	// Histogram holds the distribution for histograms and timers
+	Histogram *metric.HistogramSnapshot
+}
+
+// Frame is one encoded snapshot
+type Frame struct {
+	// Sequence identifies this snapshot; receivers acknowledge it after applying the frame
+	Sequence uint64
+	// Base is the acknowledged snapshot this frame is relative to; zero for full frames
+	Base uint64
+	// Full is set when Series holds every series rather than only changed ones
+	Full bool
+	// Series holds the series that changed since Base, or all series for a full frame
+	Series []Series
+	// Removed lists the keys of series present in Base but no longer registered
+	Removed []string
+}
+
+// Key returns the identity of a series: its type, name and sorted tags
+func (s Series) Key() string {
+	keys := make([]string, 0, len(s.Tags))
+	for k := range s.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(string(s.Type))
+	b.WriteByte(':')
+	b.WriteString(s.Name)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(s.Tags[k])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// equal reports whether two states of the same series carry the same data
+func (s Series) equal(other Series) bool {
+	if s.Value != other.Value {
+		return false
+	}
+	if (s.Histogram == nil) != (other.Histogram == nil) {
+		return false
+	}
+	if s.Histogram == nil {
+		return true
+	}
+	a, b := s.Histogram, other.Histogram
+	if a.Count != b.Count || a.Sum != b.Sum || a.Min != b.Min || a.Max != b.Max || len(a.Buckets) != len(b.Buckets) {
+		return false
+	}
+	for i := range a.Buckets {
+		if a.Buckets[i] != b.Buckets[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// state is a full snapshot keyed by series key
+type state map[string]Series
+
+// Collect captures the current state of every series in registry
+func Collect(registry metric.Registry) []Series {
+	var series []Series
+	registry.Each(func(m metric.Metric) {
+		s := Series{Name: m.Name(), Type: m.Type(), Tags: m.Tags()}
+		switch m.Type() {
+		case metric.TypeCounter:
+			if counter, ok := m.(metric.Counter); ok {
+				s.Value = float64(counter.Value())
+			}
+		case metric.TypeGauge:
+			if gauge, ok := m.(metric.Gauge); ok {
+				s.Value = metric.GaugeValue(gauge)
+			}
+		case metric.TypeUpDownCounter:
+			if counter, ok := m.(metric.UpDownCounter); ok {
+				s.Value = float64(counter.Value())
+			}
+		case metric.TypeMeter:
+			if meter, ok := m.(metric.Meter); ok {
+				s.Value = float64(meter.Count())
+			}
+		case metric.TypeHistogram:
+			if histogram, ok := m.(metric.Histogram); ok {
+				snapshot := histogram.Snapshot()
+				s.Histogram = &snapshot
+			}
+		case metric.TypeTimer:
+			if timer, ok := m.(metric.Timer); ok {
+				snapshot := timer.Snapshot()
+				s.Histogram = &snapshot
+			}
+		default:
+			return
+		}
+		series = append(series, s)
+	})
+	return series
+}
+
+// Option configures an Encoder
+type Option func(*Encoder)
+
+// WithFullSyncEvery sets how many frames may be sent between full syncs.
+// Values below 1 make every frame a full sync.
+func WithFullSyncEvery(n int) Option {
+	return func(e *Encoder) {
+		e.fullSyncEvery = n
+	}
+}
+
+// Encoder produces frames for one receiver. It is not safe for concurrent use.
+type Encoder struct {
+	fullSyncEvery int
+	sequence      uint64
+	sinceFull     int
+
+	base     state // last acknowledged snapshot
+	baseSeq  uint64
+	pending  map[uint64]state
+	fullSync bool // next frame must be full
+}
+
+// NewEncoder creates an Encoder. The first frame is always a full sync.
+func NewEncoder(opts ...Option) *Encoder {
+	e := &Encoder{
+		fullSyncEvery: DefaultFullSyncEvery,
+		pending:       make(map[uint64]state),
+		fullSync:      true,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Encode captures registry and returns a frame relative to the last acknowledged snapshot
+func (e *Encoder) Encode(registry metric.Registry) Frame {
+	return e.EncodeSeries(Collect(registry))
+}
+
+// EncodeSeries returns a frame for an already collected snapshot
+func (e *Encoder) EncodeSeries(series []Series) Frame {
+	current := make(state, len(series))
+	for _, s := range series {
+		current[s.Key()] = s
+	}
+
+	e.sequence++
+	frame := Frame{Sequence: e.sequence}
+
+	if e.fullSync || e.base == nil || e.sinceFull >= e.fullSyncEvery {
+		frame.Full = true
+		frame.Series = sortedSeries(current, nil)
+		e.fullSync = false
+		e.sinceFull = 0
+	} else {
+		frame.Base = e.baseSeq
+		frame.Series = sortedSeries(current, e.base)
+		for key := range e.base {
+			if _, ok := current[key]; !ok {
+				frame.Removed = append(frame.Removed, key)
+			}
+		}
+		sort.Strings(frame.Removed)
+		e.sinceFull++
+	}
+
+	e.pending[frame.Sequence] = current
+	if len(e.pending) > maxPending {
+		delete(e.pending, frame.Sequence-maxPending)
+	}
+	return frame
+}
+
+// Ack records that the receiver applied the frame with the given sequence,
+// making it the base for subsequent deltas. Stale or unknown acks are ignored.
+func (e *Encoder) Ack(sequence uint64) {
+	acked, ok := e.pending[sequence]
+	if !ok || sequence <= e.baseSeq {
+		return
+	}
+	e.base = acked
+	e.baseSeq = sequence
+	for seq := range e.pending {
+		if seq <= sequence {
+			delete(e.pending, seq)
+		}
+	}
+}
+
+// ForceFullSync makes the next frame a full sync, e.g. after the receiver reports ErrUnknownBase
+func (e *Encoder) ForceFullSync() {
+	e.fullSync = true
+}
+
+// sortedSeries returns the series of current that differ from base, sorted by key
+func sortedSeries(current, base state) []Series {
+	keys := make([]string, 0, len(current))
+	for key, s := range current {
+		if previous, ok := base[key]; ok && previous.equal(s) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	series := make([]Series, len(keys))
+	for i, key := range keys {
+		series[i] = current[key]
+	}
+	return series
+}
+
+// Decoder rebuilds full snapshots from frames produced by an Encoder
+type Decoder struct {
+	states map[uint64]state
+}
+
+// NewDecoder creates a Decoder
+func NewDecoder() *Decoder {
+	return &Decoder{states: make(map[uint64]state)}
+}
+
+// Apply reconstructs the full snapshot carried by frame. After a successful
+// Apply the receiver should acknowledge frame.Sequence to the sender.
+func (d *Decoder) Apply(frame Frame) ([]Series, error) {
+	var next state
+	if frame.Full {
+		next = make(state, len(frame.Series))
+	} else {
+		base, ok := d.states[frame.Base]
+		if !ok {
+			return nil, ErrUnknownBase
+		}
+		next = make(state, len(base)+len(frame.Series))
+		for key, s := range base {
+			next[key] = s
+		}
+		for _, key := range frame.Removed {
+			delete(next, key)
+		}
+	}
+	for _, s := range frame.Series {
+		next[s.Key()] = s
+	}
+
+	// Keep the snapshots a later delta may still be based on: everything from the
+	// oldest base still in use up to this frame
+	d.states[frame.Sequence] = next
+	for seq := range d.states {
+		if seq+maxPending < frame.Sequence || (!frame.Full && seq < frame.Base) {
+			delete(d.states, seq)
+		}
+	}
+
+	return sortedSeries(next, nil), nil
+}
@@ -0,0 +1,107 @@
+package delta
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestEncoderSendsOnlyChangedSeries(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	requests := registry.Counter(metric.Options{Name: "requests_total"})
+	registry.Gauge(metric.Options{Name: "static_gauge"}).Set(7)
+	latency := registry.Histogram(metric.Options{Name: "latency"})
+
+	encoder := NewEncoder()
+	decoder := NewDecoder()
+
+	first := encoder.Encode(registry)
+	if !first.Full || len(first.Series) != 3 {
+		t.Fatalf("Expected first frame to be a full sync of 3 series, got full=%v series=%d", first.Full, len(first.Series))
+	}
+	if _, err := decoder.Apply(first); err != nil {
+		t.Fatalf("Apply full frame: %v", err)
+	}
+	encoder.Ack(first.Sequence)
+
+	requests.Inc()
+	latency.Observe(0.5)
+	second := encoder.Encode(registry)
+	if second.Full || second.Base != first.Sequence {
+		t.Fatalf("Expected delta frame based on %d, got full=%v base=%d", first.Sequence, second.Full, second.Base)
+	}
+	if len(second.Series) != 2 {
+		t.Errorf("Expected only the 2 changed series, got %v", second.Series)
+	}
+
+	snapshot, err := decoder.Apply(second)
+	if err != nil {
+		t.Fatalf("Apply delta frame: %v", err)
+	}
+	values := make(map[string]Series)
+	for _, s := range snapshot {
+		values[s.Name] = s
+	}
+	if len(values) != 3 || values["requests_total"].Value != 1 || values["static_gauge"].Value != 7 {
+		t.Errorf("Expected reconstructed snapshot with all series, got %v", snapshot)
+	}
+	if values["latency"].Histogram == nil || values["latency"].Histogram.Count != 1 {
+		t.Errorf("Expected latency histogram with one observation, got %+v", values["latency"].Histogram)
+	}
+
+	// Unacknowledged frames keep using the last acknowledged base
+	third := encoder.Encode(registry)
+	if third.Base != first.Sequence || len(third.Series) != 2 {
+		t.Errorf("Expected delta against unacknowledged base %d with 2 series, got base=%d series=%d",
+			first.Sequence, third.Base, len(third.Series))
+	}
+}
+
+func TestEncoderReportsRemovedSeriesAndFullSyncs(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	registry.Counter(metric.Options{Name: "a"})
+	registry.Counter(metric.Options{Name: "b"})
+
+	encoder := NewEncoder(WithFullSyncEvery(2))
+	decoder := NewDecoder()
+
+	frame := encoder.Encode(registry)
+	decoder.Apply(frame)
+	encoder.Ack(frame.Sequence)
+
+	registry.Unregister("b")
+	frame = encoder.Encode(registry)
+	if len(frame.Removed) != 1 || frame.Removed[0] != (Series{Name: "b", Type: metric.TypeCounter}).Key() {
+		t.Errorf("Expected b to be reported removed, got %v", frame.Removed)
+	}
+	snapshot, err := decoder.Apply(frame)
+	if err != nil || len(snapshot) != 1 {
+		t.Fatalf("Expected 1 series after removal, got %v (err %v)", snapshot, err)
+	}
+	encoder.Ack(frame.Sequence)
+
+	encoder.Encode(registry)
+	if frame = encoder.Encode(registry); !frame.Full {
+		t.Error("Expected a periodic full sync after 2 delta frames")
+	}
+}
+
+func TestDecoderUnknownBase(t *testing.T) {
+	decoder := NewDecoder()
+	_, err := decoder.Apply(Frame{Sequence: 5, Base: 4})
+	if !errors.Is(err, ErrUnknownBase) {
+		t.Errorf("Expected ErrUnknownBase, got %v", err)
+	}
+
+	encoder := NewEncoder()
+	encoder.Ack(encoder.EncodeSeries(nil).Sequence)
+	encoder.ForceFullSync()
+	if frame := encoder.EncodeSeries(nil); !frame.Full {
+		t.Error("Expected ForceFullSync to produce a full frame")
+	}
+}
@@ -0,0 +1,176 @@
+package metric
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"sync/atomic"
+)
+
+// SnapshotView is the read-only view of a registry's series given to the
+// function of a derived gauge. It reads live values: counters and gauges by
+// value, meters by event count and histograms and timers by observation count.
+type SnapshotView struct {
+	tags    Tags
+	metrics []Metric
+}
+
+// NewSnapshotView returns a view of metrics for a derived gauge tagged tags,
+// e.g. to unit test the function passed to Registry.Derived
+func NewSnapshotView(tags Tags, metrics []Metric) SnapshotView {
+	return SnapshotView{tags: tags, metrics: metrics}
+}
+
+// Tags returns the tags of the derived gauge being computed, so one function
+// can serve every series derived with With, e.g. an error rate per route
+func (v SnapshotView) Tags() Tags {
+	return v.tags
+}
+
+// Value returns the value of the series of name whose tags equal tags, or 0 if
+// there is none
+func (v SnapshotView) Value(name string, tags Tags) float64 {
+	key := tagsKey(tags)
+	for _, m := range v.metrics {
+		if m.Name() == name && tagsKey(m.Tags()) == key {
+			value, _, _ := readValue(m)
+			return value
+		}
+	}
+	return 0
+}
+
+// Sum returns the total value of the series of name whose tags include tags;
+// with no tags it adds up every series of name
+func (v SnapshotView) Sum(name string, tags Tags) float64 {
+	var sum float64
+	for _, m := range v.metrics {
+		if m.Name() != name || !hasTags(m.Tags(), tags) {
+			continue
+		}
+		value, _, _ := readValue(m)
+		sum += value
+	}
+	return sum
+}
+
+// Histogram returns the snapshot of the histogram or timer of name whose tags
+// equal tags
+func (v SnapshotView) Histogram(name string, tags Tags) (HistogramSnapshot, bool) {
+	key := tagsKey(tags)
+	for _, m := range v.metrics {
+		if m.Name() != name || tagsKey(m.Tags()) != key {
+			continue
+		}
+		if _, snapshot, _ := readValue(m); snapshot != nil {
+			return *snapshot, true
+		}
+	}
+	return HistogramSnapshot{}, false
+}
+
+// hasTags reports whether tags holds every key and value of want
+func hasTags(tags, want Tags) bool {
+	for k, v := range want {
+		if value, ok := tags[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// derivedGauge implements a Gauge computed from other series by the registry
+// each time it is iterated. Reading the registry while Each holds its lock
+// could deadlock, so the registry evaluates the function before passing the
+// gauge on and Value returns the latest result.
+type derivedGauge struct {
+	baseMetric
+	fn     func(SnapshotView) float64
+	value  atomic.Uint64         // float64 bits
+	derive func(tags Tags) Gauge // set by the registry to look up With() series
+}
+
+func newDerivedGauge(opts Options, fn func(SnapshotView) float64) *derivedGauge {
+	return &derivedGauge{
+		baseMetric: baseMetric{
+			name:        opts.Name,
+			description: opts.Description,
+			unit:        opts.Unit,
+			metricType:  TypeGauge,
+			tags:        opts.Tags,
+			annotations: maps.Clone(opts.Annotations),
+		},
+		fn: fn,
+	}
+}
+
+// evaluate computes the gauge from metrics. NaN and infinite results, e.g.
+// from dividing by zero, keep the previous value.
+func (d *derivedGauge) evaluate(metrics []Metric) {
+	value := d.fn(NewSnapshotView(d.tags, metrics))
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+	d.value.Store(math.Float64bits(value))
+}
+
+// Set is a no-op; the value is always computed from other series
+func (d *derivedGauge) Set(value float64) {}
+
+// Add is a no-op; the value is always computed from other series
+func (d *derivedGauge) Add(value float64) {}
+
+// Inc is a no-op; the value is always computed from other series
+func (d *derivedGauge) Inc() {}
+
+// Dec is a no-op; the value is always computed from other series
+func (d *derivedGauge) Dec() {}
+
+func (d *derivedGauge) With(tags Tags) Gauge {
+	if d.derive != nil {
+		return d.derive(copyTags(d.tags, tags))
+	}
+	return newDerivedGauge(Options{
+		Name:        d.name,
+		Description: d.Description(),
+		Unit:        d.Unit(),
+		Tags:        copyTags(d.tags, tags),
+		Annotations: d.annotations,
+	}, d.fn)
+}
+
+// Value returns the result of the latest evaluation, rounded
+func (d *derivedGauge) Value() int64 {
+	return int64(math.Round(d.FloatValue()))
+}
+
+// FloatValue returns the result of the latest evaluation
+func (d *derivedGauge) FloatValue() float64 {
+	return math.Float64frombits(d.value.Load())
+}
+
+// Derived implements the Registry interface
+func (r *defaultRegistry) Derived(opts Options, fn func(SnapshotView) float64) Gauge {
+	opts.Tags = r.processTags(opts.Tags)
+	if fn == nil {
+		panic(fmt.Sprintf("derived gauge '%s' requires a non-nil function", opts.Name))
+	}
+	m := r.lookup(opts, TypeGauge, func(opts Options) Metric {
+		d := newDerivedGauge(opts, fn)
+		d.derive = func(tags Tags) Gauge { return r.Derived(derived(opts, tags), fn) }
+		r.derivedGauges.Add(1)
+		return d
+	})
+	return m.(Gauge)
+}
+
+// evaluateDerived computes every derived gauge among metrics from metrics.
+// It runs after the registry lock is released, so a slow function does not
+// block writers.
+func (r *defaultRegistry) evaluateDerived(metrics []Metric) {
+	for _, m := range metrics {
+		if d, ok := m.(*derivedGauge); ok {
+			d.evaluate(metrics)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package metric
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDerived(t *testing.T) {
+	registry := NewNoCleanupRegistry().(*defaultRegistry)
+	defer registry.Close()
+
+	requests := registry.Counter(Options{Name: "requests_total", Tags: Tags{"route": "/a"}})
+	errors := registry.Counter(Options{Name: "errors_total", Tags: Tags{"route": "/a"}})
+	registry.Counter(Options{Name: "requests_total", Tags: Tags{"route": "/b"}}).Add(6)
+
+	errorRate := registry.Derived(Options{Name: "error_rate", Unit: "percent"}, func(s SnapshotView) float64 {
+		total := s.Sum("requests_total", s.Tags())
+		if total == 0 {
+			return 0
+		}
+		return 100 * s.Sum("errors_total", s.Tags()) / total
+	})
+
+	requests.Add(4)
+	errors.Add(1)
+	if got := registry.Series("error_rate"); len(got) != 1 || got[0].Value != 10 {
+		t.Fatalf("Expected an error rate of 10 percent over both routes, got %+v", got)
+	}
+	if errorRate.Value() != 10 {
+		t.Errorf("Expected Value to return the latest evaluation, got %d", errorRate.Value())
+	}
+
+	// Series derived with With pass their tags to the function
+	perRoute := errorRate.With(Tags{"route": "/a"})
+	if got := registry.Series("error_rate"); len(got) != 2 {
+		t.Fatalf("Expected 2 error rate series, got %+v", got)
+	}
+	if perRoute.Value() != 25 {
+		t.Errorf("Expected an error rate of 25 percent on /a, got %d", perRoute.Value())
+	}
+
+	// Writes are no-ops and the same series is returned again
+	errorRate.Set(99)
+	if again := registry.Derived(Options{Name: "error_rate"}, func(SnapshotView) float64 { return 0 }); again != errorRate {
+		t.Error("Expected the existing derived gauge to be returned")
+	}
+	registry.Each(func(Metric) {})
+	if errorRate.Value() != 10 {
+		t.Errorf("Expected the original function to be kept, got %d", errorRate.Value())
+	}
+}
+
+func TestDerivedKeepsValueOnNaN(t *testing.T) {
+	registry := NewNoCleanupRegistry().(InstrumentRegistry)
+	defer registry.Close()
+
+	hits := registry.Counter(Options{Name: "cache_hits_total"})
+	lookups := registry.Counter(Options{Name: "cache_lookups_total"})
+	ratio := registry.Derived(Options{Name: "cache_hit_ratio", Unit: "ppm"}, func(s SnapshotView) float64 {
+		return 1e6 * s.Value("cache_hits_total", nil) / s.Value("cache_lookups_total", nil)
+	})
+
+	hits.Add(3)
+	lookups.Add(4)
+	registry.Each(func(Metric) {})
+	if ratio.Value() != 750000 {
+		t.Fatalf("Expected a hit ratio of 750000 ppm, got %d", ratio.Value())
+	}
+
+	registry.Unregister("cache_lookups_total")
+	registry.Each(func(Metric) {})
+	if ratio.Value() != 750000 {
+		t.Errorf("Expected an infinite result to keep the previous value, got %d", ratio.Value())
+	}
+}
+
+func TestDerivedKeepsFraction(t *testing.T) {
+	registry := NewNoCleanupRegistry().(*defaultRegistry)
+	defer registry.Close()
+
+	registry.Counter(Options{Name: "cache_hits_total"}).Add(25)
+	registry.Counter(Options{Name: "cache_lookups_total"}).Add(100)
+	ratio := registry.Derived(Options{Name: "cache_hit_ratio"}, func(s SnapshotView) float64 {
+		return s.Value("cache_hits_total", nil) / s.Value("cache_lookups_total", nil)
+	})
+
+	if got := registry.Series("cache_hit_ratio"); len(got) != 1 || got[0].Value != 0.25 {
+		t.Fatalf("Expected a ratio of 0.25, got %+v", got)
+	}
+	if got := GaugeValue(ratio); got != 0.25 {
+		t.Errorf("Expected GaugeValue to return 0.25, got %v", got)
+	}
+	if ratio.Value() != 0 {
+		t.Errorf("Expected Value to round, got %d", ratio.Value())
+	}
+}
+
+func TestDerivedEvaluatedOutsideLock(t *testing.T) {
+	registry := NewNoCleanupRegistry().(InstrumentRegistry)
+	defer registry.Close()
+
+	// Creating a series needs the write lock, so this deadlocks if derived
+	// gauges are evaluated while the snapshot holds the read lock
+	registry.Derived(Options{Name: "evaluations"}, func(s SnapshotView) float64 {
+		registry.Counter(Options{Name: "evaluations_total"}).Inc()
+		return 0
+	})
+
+	done := make(chan struct{})
+	go func() {
+		registry.Each(func(Metric) {})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected derived gauges to be evaluated after the registry lock is released")
+	}
+	if got := registry.Counter(Options{Name: "evaluations_total"}).Value(); got != 1 {
+		t.Errorf("Expected 1 evaluation, got %d", got)
+	}
+}
+
+func TestSnapshotView(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	latency := registry.Histogram(Options{Name: "latency", Tags: Tags{"route": "/a"}, Buckets: []float64{1, 10}})
+	latency.Observe(5)
+	registry.Gauge(Options{Name: "queue_depth", Tags: Tags{"queue": "a"}}).Set(3)
+	registry.Gauge(Options{Name: "queue_depth", Tags: Tags{"queue": "b"}}).Set(4)
+
+	var metrics []Metric
+	registry.Each(func(m Metric) { metrics = append(metrics, m) })
+	view := NewSnapshotView(Tags{"pool": "main"}, metrics)
+
+	if view.Tags()["pool"] != "main" {
+		t.Errorf("Expected the view's tags, got %v", view.Tags())
+	}
+	if got := view.Value("queue_depth", Tags{"queue": "b"}); got != 4 {
+		t.Errorf("Expected queue b to hold 4, got %v", got)
+	}
+	if got := view.Value("queue_depth", nil); got != 0 {
+		t.Errorf("Expected 0 for a series that does not exist, got %v", got)
+	}
+	if got := view.Sum("queue_depth", nil); got != 7 {
+		t.Errorf("Expected a total queue depth of 7, got %v", got)
+	}
+	if got := view.Value("latency", Tags{"route": "/a"}); got != 1 {
+		t.Errorf("Expected a histogram's value to be its count, got %v", got)
+	}
+	if snapshot, ok := view.Histogram("latency", Tags{"route": "/a"}); !ok || snapshot.Sum != 5 {
+		t.Errorf("Expected the latency snapshot, got %+v, %v", snapshot, ok)
+	}
+	if _, ok := view.Histogram("queue_depth", Tags{"queue": "a"}); ok {
+		t.Error("Expected no histogram snapshot for a gauge")
+	}
+}
@@ -0,0 +1,242 @@
+package metric
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// SyncPolicy controls when a durable counter's writes are flushed to disk
+type SyncPolicy int
+
+const (
+	// SyncNone leaves writeback to the operating system: totals survive a crash
+	// of the process, but recent writes may be lost if the machine goes down
+	SyncNone SyncPolicy = iota
+	// SyncEveryWrite flushes every write to disk before it returns, so totals
+	// also survive power loss, at the cost of a disk flush per write
+	SyncEveryWrite
+)
+
+// Durability backs a counter with a memory-mapped file, so its total survives
+// crashes and restarts exactly. See Options.Durability.
+type Durability struct {
+	// Path is the file holding the counter. It is created if missing.
+	Path string
+	// Sync is the flush policy for writes
+	Sync SyncPolicy
+
+	root string // Path of the counter a derived series was created from
+}
+
+var (
+	// ErrDurableCorrupt is returned when a durable counter file fails validation
+	ErrDurableCorrupt = errors.New("durable counter file is corrupt")
+	// ErrDurableMismatch is returned when a durable counter file belongs to another series
+	ErrDurableMismatch = errors.New("durable counter file belongs to another series")
+	// ErrDurableLocked is returned when a durable counter file is already open,
+	// in another registry or process
+	ErrDurableLocked = errors.New("durable counter file is in use")
+)
+
+// Durable counter file layout, little endian, in one page:
+//
+//	0   magic "GOMTDUR1"
+//	8   version
+//	16  FNV-1a hash of the series key
+//	64  slot 0: sequence, value, CRC-32 of sequence and value
+//	96  slot 1
+//
+// Writes alternate between the slots, so a write torn by a crash leaves the
+// other slot, holding the previous total, intact.
+const (
+	durableMagic   = "GOMTDUR1"
+	durableVersion = 1
+	durableSlot0   = 64
+	durableSlotLen = 32
+)
+
+// durableStore is the memory-mapped storage of a durable counter
+type durableStore struct {
+	path     string
+	key      string
+	mu       sync.Mutex
+	file     *os.File
+	data     []byte
+	sync     SyncPolicy
+	sequence uint64
+	value    atomic.Uint64
+	closed   bool
+}
+
+// openDurableStore opens or creates the file at d.Path for the series key,
+// recovering the last completely written total. It holds an exclusive lock on
+// the file until closed, so no other registry or process maps it meanwhile.
+func openDurableStore(d Durability, key string) (*durableStore, error) {
+	file, err := os.OpenFile(d.Path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%w: %s: %v", ErrDurableLocked, d.Path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	size := os.Getpagesize()
+	fresh := info.Size() == 0
+	if fresh {
+		if err := file.Truncate(int64(size)); err != nil {
+			file.Close()
+			return nil, err
+		}
+	} else if info.Size() < int64(size) {
+		file.Close()
+		return nil, fmt.Errorf("%w: %s is %d bytes", ErrDurableCorrupt, d.Path, info.Size())
+	}
+
+	data, err := mapFile(file, size)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	s := &durableStore{path: d.Path, key: key, file: file, data: data, sync: d.Sync}
+
+	identity := fnv.New64a()
+	identity.Write([]byte(key))
+	if fresh {
+		copy(data, durableMagic)
+		binary.LittleEndian.PutUint64(data[8:], durableVersion)
+		binary.LittleEndian.PutUint64(data[16:], identity.Sum64())
+		s.writeSlot(0, 0, 0)
+		err = syncMapping(data)
+	} else {
+		err = s.recover(identity.Sum64())
+	}
+	if err != nil {
+		s.close()
+		return nil, fmt.Errorf("%s: %w", d.Path, err)
+	}
+	return s, nil
+}
+
+// recover validates the header and loads the newest slot with a valid checksum
+func (s *durableStore) recover(identity uint64) error {
+	if string(s.data[:8]) != durableMagic || binary.LittleEndian.Uint64(s.data[8:]) != durableVersion {
+		return ErrDurableCorrupt
+	}
+	if binary.LittleEndian.Uint64(s.data[16:]) != identity {
+		return ErrDurableMismatch
+	}
+
+	found := false
+	for slot := range 2 {
+		sequence, value, ok := s.readSlot(slot)
+		if ok && (!found || sequence > s.sequence) {
+			s.sequence = sequence
+			s.value.Store(value)
+			found = true
+		}
+	}
+	if !found {
+		return ErrDurableCorrupt
+	}
+	return nil
+}
+
+func (s *durableStore) readSlot(slot int) (sequence, value uint64, ok bool) {
+	b := s.data[durableSlot0+slot*durableSlotLen:]
+	sequence = binary.LittleEndian.Uint64(b)
+	value = binary.LittleEndian.Uint64(b[8:])
+	return sequence, value, crc32.ChecksumIEEE(b[:16]) == binary.LittleEndian.Uint32(b[16:])
+}
+
+func (s *durableStore) writeSlot(slot int, sequence, value uint64) {
+	b := s.data[durableSlot0+slot*durableSlotLen:]
+	binary.LittleEndian.PutUint64(b, sequence)
+	binary.LittleEndian.PutUint64(b[8:], value)
+	binary.LittleEndian.PutUint32(b[16:], crc32.ChecksumIEEE(b[:16]))
+}
+
+// update sets the total to next(current) and persists it in the older slot
+func (s *durableStore) update(next func(current uint64) uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	value := next(s.value.Load())
+	s.sequence++
+	s.writeSlot(int(s.sequence%2), s.sequence, value)
+	s.value.Store(value)
+	if s.sync == SyncEveryWrite {
+		syncMapping(s.data)
+	}
+}
+
+func (s *durableStore) add(delta uint64) {
+	s.update(func(current uint64) uint64 { return current + delta })
+}
+
+// subtract lowers the total by up to delta without going below zero
+func (s *durableStore) subtract(delta uint64) {
+	s.update(func(current uint64) uint64 {
+		if delta > current {
+			return 0
+		}
+		return current - delta
+	})
+}
+
+// swap zeroes the total and returns what it was
+func (s *durableStore) swap() uint64 {
+	var previous uint64
+	s.update(func(current uint64) uint64 {
+		previous = current
+		return 0
+	})
+	return previous
+}
+
+func (s *durableStore) load() uint64 {
+	return s.value.Load()
+}
+
+// close flushes and unmaps the file and releases its lock. Later writes are dropped.
+func (s *durableStore) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return errors.Join(syncMapping(s.data), unmapFile(s.data), s.file.Close())
+}
+
+// durableDerived returns the options of a series derived with With() from a
+// durable counter. Its file is the original counter's path suffixed with a hash
+// of the series' tags, whichever series it was derived through.
+func durableDerived(opts Options) Options {
+	if opts.Durability == nil {
+		return opts
+	}
+	d := *opts.Durability
+	if d.root == "" {
+		d.root = d.Path
+	}
+	h := fnv.New64a()
+	h.Write([]byte(tagsKey(opts.Tags)))
+	d.Path = fmt.Sprintf("%s.%016x", d.root, h.Sum64())
+	opts.Durability = &d
+	return opts
+}
@@ -0,0 +1,25 @@
+//go:build !unix
+
+package metric
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile reports that durable counters are not supported on this platform
+func mapFile(file *os.File, size int) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func syncMapping(data []byte) error {
+	return errors.ErrUnsupported
+}
+
+func unmapFile(data []byte) error {
+	return errors.ErrUnsupported
+}
+
+func lockFile(file *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,149 @@
+//go:build unix
+
+package metric
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// durableOpts returns the options of a durable counter stored at path
+func durableOpts(name, path string) Options {
+	return Options{Name: name, Durability: &Durability{Path: path, Sync: SyncEveryWrite}}
+}
+
+func TestDurableCounterSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payments")
+
+	registry := NewNoCleanupRegistry()
+	counter := registry.Counter(durableOpts("payments_processed_total", path))
+	counter.Add(41)
+	counter.Inc()
+	counter.With(Tags{"currency": "eur"}).Add(7)
+	if err := registry.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	registry = NewNoCleanupRegistry()
+	defer registry.Close()
+	counter = registry.Counter(durableOpts("payments_processed_total", path))
+	if got := counter.Value(); got != 42 {
+		t.Errorf("Expected 42 to be recovered, got %d", got)
+	}
+	if got := counter.With(Tags{"currency": "eur"}).Value(); got != 7 {
+		t.Errorf("Expected the derived series to recover 7, got %d", got)
+	}
+	if got := counter.With(Tags{"currency": "usd"}).Value(); got != 0 {
+		t.Errorf("Expected a new derived series to start at 0, got %d", got)
+	}
+
+	files, _ := filepath.Glob(path + ".*")
+	if len(files) != 2 {
+		t.Errorf("Expected a file per derived series, got %v", files)
+	}
+}
+
+// openDurable creates a durable counter at path in a fresh registry and returns
+// the error it panics with, if any
+func openDurable(name, path string) (err error) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	registry.Counter(durableOpts(name, path))
+	return nil
+}
+
+func TestDurableCounterRecoveryValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "refunds")
+
+	registry := NewNoCleanupRegistry()
+	registry.Counter(durableOpts("refunds_total", path)).Add(3)
+	registry.Close()
+
+	if err := openDurable("chargebacks_total", path); !errors.Is(err, ErrDurableMismatch) {
+		t.Errorf("Expected ErrDurableMismatch for another series, got %v", err)
+	}
+
+	// A torn write of the newest slot falls back to the previous total
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	torn := append([]byte(nil), data...)
+	torn[durableSlot0+durableSlotLen+8]++ // slot 1 holds the newest total
+	os.WriteFile(path, torn, 0o644)
+
+	registry = NewNoCleanupRegistry()
+	if got := registry.Counter(durableOpts("refunds_total", path)).Value(); got != 0 {
+		t.Errorf("Expected the previous total 0 after a torn write, got %d", got)
+	}
+	registry.Close()
+
+	corrupt := append([]byte(nil), data...)
+	copy(corrupt, "garbage!")
+	os.WriteFile(path, corrupt, 0o644)
+	if err := openDurable("refunds_total", path); !errors.Is(err, ErrDurableCorrupt) {
+		t.Errorf("Expected ErrDurableCorrupt for a damaged header, got %v", err)
+	}
+}
+
+func TestDurableCounterSwap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders")
+
+	registry := NewNoCleanupRegistry()
+	counter := registry.Counter(durableOpts("orders_total", path))
+	counter.Add(9)
+	if got := counter.Swap(); got != 9 {
+		t.Errorf("Expected Swap to return 9, got %d", got)
+	}
+	registry.Close()
+
+	registry = NewNoCleanupRegistry()
+	defer registry.Close()
+	if got := registry.Counter(durableOpts("orders_total", path)).Value(); got != 0 {
+		t.Errorf("Expected the swapped total to persist as 0, got %d", got)
+	}
+}
+
+func TestDurableCounterRecreatedAfterRemoval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "emails")
+
+	registry := NewNoCleanupRegistry()
+	counter := registry.Counter(durableOpts("emails_sent_total", path))
+	counter.Add(100)
+	registry.Unregister("emails_sent_total")
+
+	// The re-created series opens the file again instead of mapping it twice
+	counter = registry.Counter(durableOpts("emails_sent_total", path))
+	if got := counter.Value(); got != 100 {
+		t.Errorf("Expected the re-created counter to recover 100, got %d", got)
+	}
+	counter.Add(2)
+	if err := registry.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	registry = NewNoCleanupRegistry()
+	defer registry.Close()
+	if got := registry.Counter(durableOpts("emails_sent_total", path)).Value(); got != 102 {
+		t.Errorf("Expected 102 after reopening, got %d", got)
+	}
+}
+
+func TestDurableCounterFileIsLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invoices")
+
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+	registry.Counter(durableOpts("invoices_total", path)).Inc()
+
+	if err := openDurable("invoices_total", path); !errors.Is(err, ErrDurableLocked) {
+		t.Errorf("Expected ErrDurableLocked while another registry holds the file, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+//go:build unix
+
+package metric
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func init() {
+	RegisterCapability(Capability{Kind: CapabilityFeature, Name: "durable_counters", Package: "github.com/MichaelAJay/go-metrics/metric"})
+}
+
+// mapFile maps the first size bytes of file into memory, shared with the file
+func mapFile(file *os.File, size int) ([]byte, error) {
+	return unix.Mmap(int(file.Fd()), 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+}
+
+// syncMapping flushes the mapped pages to disk
+func syncMapping(data []byte) error {
+	return unix.Msync(data, unix.MS_SYNC)
+}
+
+func unmapFile(data []byte) error {
+	return unix.Munmap(data)
+}
+
+// lockFile takes an exclusive lock on file without waiting. The lock is tied
+// to the open file, so it is released when the file is closed.
+func lockFile(file *os.File) error {
+	return unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+}
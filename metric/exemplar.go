@@ -0,0 +1,60 @@
+package metric
+
+import (
+	"maps"
+	"time"
+)
+
+// Exemplar is an example observation of a counter or histogram, carrying labels
+// such as a trace ID that link the metric to the request that produced it
+type Exemplar struct {
+	Labels    Tags
+	Value     float64
+	Timestamp time.Time
+}
+
+func newExemplar(labels Tags, value float64) *Exemplar {
+	return &Exemplar{Labels: maps.Clone(labels), Value: value, Timestamp: time.Now()}
+}
+
+func (c *counterImpl) AddWithExemplar(value float64, labels Tags) {
+	c.Add(value)
+	if value > 0 && !c.isPaused() {
+		c.exemplar.Store(newExemplar(labels, value))
+	}
+}
+
+func (c *counterImpl) Exemplars() []Exemplar {
+	if e := c.exemplar.Load(); e != nil {
+		return []Exemplar{*e}
+	}
+	return nil
+}
+
+func (h *histogramImpl) ObserveWithExemplar(value float64, labels Tags) {
+	if h.isPaused() {
+		return
+	}
+	h.Observe(value)
+
+	e := newExemplar(labels, value)
+	h.exemplarMu.Lock()
+	defer h.exemplarMu.Unlock()
+	if h.exemplars == nil {
+		h.exemplars = make([]*Exemplar, len(h.buckets))
+	}
+	h.exemplars[h.findBucket(value)] = e
+}
+
+func (h *histogramImpl) Exemplars() []Exemplar {
+	h.exemplarMu.Lock()
+	defer h.exemplarMu.Unlock()
+
+	var result []Exemplar
+	for _, e := range h.exemplars {
+		if e != nil {
+			result = append(result, *e)
+		}
+	}
+	return result
+}
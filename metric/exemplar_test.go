@@ -0,0 +1,58 @@
+package metric
+
+import "testing"
+
+func TestExemplars(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	counter := registry.Counter(Options{Name: "orders_total"})
+	adder := counter.(ExemplarAdder)
+	if got := adder.Exemplars(); got != nil {
+		t.Errorf("Expected no exemplar before AddWithExemplar, got %v", got)
+	}
+	adder.AddWithExemplar(3, Tags{"trace_id": "abc"})
+	adder.AddWithExemplar(-1, Tags{"trace_id": "ignored"})
+	if got := adder.Exemplars(); len(got) != 1 || got[0].Value != 3 || got[0].Labels["trace_id"] != "abc" || got[0].Timestamp.IsZero() {
+		t.Errorf("Expected the exemplar of the positive add, got %+v", got)
+	}
+	if counter.Value() != 3 {
+		t.Errorf("Expected the add to be counted, got %d", counter.Value())
+	}
+
+	histogram := registry.Histogram(Options{Name: "latency", Buckets: []float64{1, 10}})
+	observer := histogram.(ExemplarObserver)
+	observer.ObserveWithExemplar(5, Tags{"trace_id": "first"})
+	observer.ObserveWithExemplar(7, Tags{"trace_id": "second"})
+	observer.ObserveWithExemplar(0.5, Tags{"trace_id": "low"})
+	got := observer.Exemplars()
+	if len(got) != 2 || got[0].Labels["trace_id"] != "low" || got[1].Labels["trace_id"] != "second" {
+		t.Errorf("Expected the latest exemplar of each bucket in bucket order, got %+v", got)
+	}
+	if histogram.Snapshot().Count != 3 {
+		t.Errorf("Expected 3 observations, got %d", histogram.Snapshot().Count)
+	}
+}
+
+func TestCreatedTimestamp(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	counter := registry.Counter(Options{Name: "orders_total"})
+	created := counter.(CreatedTimestamper).Created()
+	if created.IsZero() {
+		t.Fatal("Expected the counter to record its creation time")
+	}
+	counter.Swap()
+	if reset := counter.(CreatedTimestamper).Created(); reset.Before(created) {
+		t.Errorf("Expected Swap to move the created time forward, got %v before %v", reset, created)
+	}
+
+	timer := registry.Timer(Options{Name: "latency"})
+	if timer.(CreatedTimestamper).Created().IsZero() {
+		t.Error("Expected the timer to report its histogram's creation time")
+	}
+	if derived := registry.Histogram(Options{Name: "size"}).With(Tags{"route": "/"}); derived.(CreatedTimestamper).Created().IsZero() {
+		t.Error("Expected a derived series to record its creation time")
+	}
+}
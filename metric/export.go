@@ -0,0 +1,568 @@
+package metric
+
+import (
+	"context"
+	"maps"
+	"path"
+	"slices"
+	"time"
+)
+
+// ExportFilter selects and scrubs the metrics one reporter receives, so a
+// backend can get a reduced or privacy-scrubbed subset of a registry. Series
+// that become identical once tags are stripped are merged: counters, gauges
+// and up-down counters are summed, histograms and timers with equal bucket
+// boundaries are combined and meter counts and rates are summed. Relabel
+// rules rename metrics and rewrite their tags on the way out.
+type ExportFilter struct {
+	keep  []func(Metric) bool
+	allow []string
+	deny  []string
+	strip []string
+	rules []RelabelRule
+}
+
+// FilterOption configures an ExportFilter
+type FilterOption func(*ExportFilter)
+
+// MetricFilter only exports the metrics keep returns true for
+func MetricFilter(keep func(Metric) bool) FilterOption {
+	return func(f *ExportFilter) {
+		f.keep = append(f.keep, keep)
+	}
+}
+
+// AllowNames only exports metrics whose name matches one of the glob patterns,
+// in the syntax of path.Match, e.g. "http_*". It panics if a pattern is malformed.
+func AllowNames(patterns ...string) FilterOption {
+	mustBeGlobs(patterns)
+	return func(f *ExportFilter) {
+		f.allow = append(f.allow, patterns...)
+	}
+}
+
+// DenyNames leaves out metrics whose name matches one of the glob patterns,
+// even if AllowNames allows them. It panics if a pattern is malformed.
+func DenyNames(patterns ...string) FilterOption {
+	mustBeGlobs(patterns)
+	return func(f *ExportFilter) {
+		f.deny = append(f.deny, patterns...)
+	}
+}
+
+// StripTags removes the tag keys from every exported series, merging series
+// that only differed by them
+func StripTags(keys ...string) FilterOption {
+	return func(f *ExportFilter) {
+		f.strip = append(f.strip, keys...)
+	}
+}
+
+// mustBeGlobs panics if any pattern is malformed
+func mustBeGlobs(patterns []string) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			panic("metric: malformed name pattern '" + pattern + "': " + err.Error())
+		}
+	}
+}
+
+// NewExportFilter creates an ExportFilter from options
+func NewExportFilter(opts ...FilterOption) *ExportFilter {
+	f := &ExportFilter{}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// matchesAny reports whether name matches one of the glob patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Keep reports whether m passes the filter's metric, allow and deny rules
+func (f *ExportFilter) Keep(m Metric) bool {
+	name := m.Name()
+	if len(f.allow) > 0 && !matchesAny(f.allow, name) {
+		return false
+	}
+	if matchesAny(f.deny, name) {
+		return false
+	}
+	for _, keep := range f.keep {
+		if !keep(m) {
+			return false
+		}
+	}
+	return true
+}
+
+// rewrite returns the name and tags m is exported with and whether they differ
+// from its own. keep is false if a relabel rule drops m.
+func (f *ExportFilter) rewrite(m Metric) (name string, tags Tags, changed, keep bool) {
+	name, tags = m.Name(), m.Tags()
+	owned := false
+	if len(f.rules) > 0 {
+		relabeled := maps.Clone(tags)
+		if name, keep = relabel(f.rules, name, relabeled); !keep {
+			return name, tags, true, false
+		}
+		changed = name != m.Name() || !maps.Equal(relabeled, tags)
+		tags, owned = relabeled, true
+	}
+	for _, key := range f.strip {
+		if _, ok := tags[key]; ok {
+			if !owned {
+				tags, owned = maps.Clone(tags), true
+			}
+			delete(tags, key)
+			changed = true
+		}
+	}
+	return name, tags, changed, true
+}
+
+// View returns a view of registry whose Each and Series pass the filtered and
+// scrubbed metrics; every other method reaches the registry itself
+func (f *ExportFilter) View(registry Registry) Registry {
+	if f == nil {
+		return registry
+	}
+	return &exportRegistry{Registry: registry, filter: f}
+}
+
+// FilterReporter wraps reporter so every report only carries the metrics that
+// pass the filter built from opts
+func FilterReporter(reporter Reporter, opts ...FilterOption) Reporter {
+	return &filteredReporter{Reporter: reporter, filter: NewExportFilter(opts...)}
+}
+
+// filteredReporter reports a filtered view of each registry it is given
+type filteredReporter struct {
+	Reporter
+	filter *ExportFilter
+}
+
+func (r *filteredReporter) Report(registry Registry) error {
+	return r.Reporter.Report(r.filter.View(registry))
+}
+
+// ReportContext implements ContextReporter for the wrapped reporter
+func (r *filteredReporter) ReportContext(ctx context.Context, registry Registry) error {
+	return ReportContext(ctx, r.Reporter, r.filter.View(registry))
+}
+
+// ReportAt implements TimestampReporter for the wrapped reporter
+func (r *filteredReporter) ReportAt(ctx context.Context, registry Registry, at time.Time) error {
+	return ReportAt(ctx, r.Reporter, r.filter.View(registry), at)
+}
+
+// Dropped passes through the wrapped reporter's drop count, if it has one
+func (r *filteredReporter) Dropped() uint64 {
+	if counter, ok := r.Reporter.(DropCounter); ok {
+		return counter.Dropped()
+	}
+	return 0
+}
+
+// PayloadBytes passes through the wrapped reporter's payload size, if it has one
+func (r *filteredReporter) PayloadBytes() uint64 {
+	if sizer, ok := r.Reporter.(PayloadSizer); ok {
+		return sizer.PayloadBytes()
+	}
+	return 0
+}
+
+// exportRegistry is the view of a registry returned by ExportFilter.View
+type exportRegistry struct {
+	Registry
+	filter *ExportFilter
+}
+
+func (e *exportRegistry) Each(fn func(Metric)) {
+	// Rewritten series are collected first so those ending up identical can be merged
+	groups := make(map[string][]exportedSeries)
+	var order []string
+	e.Registry.Each(func(m Metric) {
+		if !e.filter.Keep(m) {
+			return
+		}
+		name, tags, changed, keep := e.filter.rewrite(m)
+		if !keep {
+			return
+		}
+		if !changed {
+			fn(m)
+			return
+		}
+		key := seriesKey(m.Type(), name, tags)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], exportedSeries{metric: m, name: name, tags: tags})
+	})
+
+	for _, key := range order {
+		if exported := exportSeries(groups[key]); exported != nil {
+			fn(exported)
+		}
+	}
+}
+
+// exportedSeries is a metric with the name and tags it is exported under
+type exportedSeries struct {
+	metric Metric
+	name   string
+	tags   Tags
+}
+
+// exportSeries returns the metric exported for series sharing an exported
+// name, type and tag set: the renamed metric itself, or a merge of them
+func exportSeries(series []exportedSeries) Metric {
+	first := series[0]
+	base := exportedBase{
+		name:        first.name,
+		description: first.metric.Description(),
+		unit:        first.metric.Unit(),
+		metricType:  first.metric.Type(),
+		tags:        first.tags,
+	}
+
+	switch m := first.metric.(type) {
+	case Counter:
+		if len(series) == 1 {
+			return &renamedCounter{Counter: m, exportedBase: base}
+		}
+		return &mergedCounter{exportedBase: base, sources: sourcesOf[Counter](series)}
+	case Gauge:
+		if len(series) == 1 {
+			return &renamedGauge{Gauge: m, exportedBase: base}
+		}
+		return &mergedGauge{exportedBase: base, sources: sourcesOf[Gauge](series)}
+	case UpDownCounter:
+		if len(series) == 1 {
+			return &renamedUpDownCounter{UpDownCounter: m, exportedBase: base}
+		}
+		return &mergedUpDownCounter{exportedBase: base, sources: sourcesOf[UpDownCounter](series)}
+	case Histogram:
+		if len(series) == 1 {
+			return &renamedHistogram{Histogram: m, exportedBase: base}
+		}
+		return &mergedHistogram{exportedBase: base, sources: sourcesOf[Histogram](series)}
+	case Timer:
+		if len(series) == 1 {
+			return &renamedTimer{Timer: m, exportedBase: base}
+		}
+		return &mergedTimer{exportedBase: base, sources: sourcesOf[Timer](series)}
+	case Meter:
+		if len(series) == 1 {
+			return &renamedMeter{Meter: m, exportedBase: base}
+		}
+		return &mergedMeter{exportedBase: base, sources: sourcesOf[Meter](series)}
+	default:
+		return nil
+	}
+}
+
+// sourcesOf returns the metrics of series that implement M
+func sourcesOf[M Metric](series []exportedSeries) []M {
+	sources := make([]M, 0, len(series))
+	for _, s := range series {
+		if m, ok := s.metric.(M); ok {
+			sources = append(sources, m)
+		}
+	}
+	return sources
+}
+
+// mergeSnapshots combines histogram or timer snapshots. Snapshots whose bucket
+// boundaries differ from the first one's are left out.
+func mergeSnapshots(snapshots []HistogramSnapshot) HistogramSnapshot {
+	var merged HistogramSnapshot
+	for i, snapshot := range snapshots {
+		if i == 0 {
+			merged = snapshot
+			merged.Buckets = slices.Clone(snapshot.Buckets)
+			continue
+		}
+		if !slices.Equal(snapshot.Boundaries, merged.Boundaries) || len(snapshot.Buckets) != len(merged.Buckets) {
+			continue
+		}
+		if snapshot.Count == 0 {
+			continue
+		}
+		if merged.Count == 0 || snapshot.Min < merged.Min {
+			merged.Min = snapshot.Min
+		}
+		if merged.Count == 0 || snapshot.Max > merged.Max {
+			merged.Max = snapshot.Max
+		}
+		merged.Count += snapshot.Count
+		merged.Sum += snapshot.Sum
+		for j, n := range snapshot.Buckets {
+			merged.Buckets[j] += n
+		}
+	}
+	return merged
+}
+
+// exportedBase holds the identity a metric is exported under
+type exportedBase struct {
+	name        string
+	description string
+	unit        string
+	metricType  Type
+	tags        Tags
+}
+
+func (b *exportedBase) Name() string        { return b.name }
+func (b *exportedBase) Description() string { return b.description }
+func (b *exportedBase) Unit() string        { return b.unit }
+func (b *exportedBase) Type() Type          { return b.metricType }
+func (b *exportedBase) Tags() Tags          { return maps.Clone(b.tags) }
+
+// Renamed metrics pass reads and writes through to the metric they wrap and
+// only change its exported name and tags
+
+type renamedCounter struct {
+	Counter
+	exportedBase
+}
+
+func (c *renamedCounter) Name() string        { return c.exportedBase.Name() }
+func (c *renamedCounter) Description() string { return c.exportedBase.Description() }
+func (c *renamedCounter) Unit() string        { return c.exportedBase.Unit() }
+func (c *renamedCounter) Type() Type          { return c.exportedBase.Type() }
+func (c *renamedCounter) Tags() Tags          { return c.exportedBase.Tags() }
+
+type renamedGauge struct {
+	Gauge
+	exportedBase
+}
+
+func (g *renamedGauge) Name() string        { return g.exportedBase.Name() }
+func (g *renamedGauge) Description() string { return g.exportedBase.Description() }
+func (g *renamedGauge) Unit() string        { return g.exportedBase.Unit() }
+func (g *renamedGauge) Type() Type          { return g.exportedBase.Type() }
+func (g *renamedGauge) Tags() Tags          { return g.exportedBase.Tags() }
+func (g *renamedGauge) FloatValue() float64 { return GaugeValue(g.Gauge) }
+
+type renamedUpDownCounter struct {
+	UpDownCounter
+	exportedBase
+}
+
+func (c *renamedUpDownCounter) Name() string        { return c.exportedBase.Name() }
+func (c *renamedUpDownCounter) Description() string { return c.exportedBase.Description() }
+func (c *renamedUpDownCounter) Unit() string        { return c.exportedBase.Unit() }
+func (c *renamedUpDownCounter) Type() Type          { return c.exportedBase.Type() }
+func (c *renamedUpDownCounter) Tags() Tags          { return c.exportedBase.Tags() }
+
+type renamedHistogram struct {
+	Histogram
+	exportedBase
+}
+
+func (h *renamedHistogram) Name() string        { return h.exportedBase.Name() }
+func (h *renamedHistogram) Description() string { return h.exportedBase.Description() }
+func (h *renamedHistogram) Unit() string        { return h.exportedBase.Unit() }
+func (h *renamedHistogram) Type() Type          { return h.exportedBase.Type() }
+func (h *renamedHistogram) Tags() Tags          { return h.exportedBase.Tags() }
+
+type renamedTimer struct {
+	Timer
+	exportedBase
+}
+
+func (t *renamedTimer) Name() string        { return t.exportedBase.Name() }
+func (t *renamedTimer) Description() string { return t.exportedBase.Description() }
+func (t *renamedTimer) Unit() string        { return t.exportedBase.Unit() }
+func (t *renamedTimer) Type() Type          { return t.exportedBase.Type() }
+func (t *renamedTimer) Tags() Tags          { return t.exportedBase.Tags() }
+
+type renamedMeter struct {
+	Meter
+	exportedBase
+}
+
+func (m *renamedMeter) Name() string        { return m.exportedBase.Name() }
+func (m *renamedMeter) Description() string { return m.exportedBase.Description() }
+func (m *renamedMeter) Unit() string        { return m.exportedBase.Unit() }
+func (m *renamedMeter) Type() Type          { return m.exportedBase.Type() }
+func (m *renamedMeter) Tags() Tags          { return m.exportedBase.Tags() }
+
+// Merged metrics read the combined value of the series they merge. They are
+// read-only: writes are ignored and With returns the metric itself. Swap, Reset
+// and SnapshotAndReset reset every merged series.
+
+type mergedCounter struct {
+	exportedBase
+	sources []Counter
+}
+
+func (c *mergedCounter) Inc()              {}
+func (c *mergedCounter) Add(float64)       {}
+func (c *mergedCounter) With(Tags) Counter { return c }
+
+func (c *mergedCounter) Value() uint64 {
+	var value uint64
+	for _, source := range c.sources {
+		value += source.Value()
+	}
+	return value
+}
+
+func (c *mergedCounter) Swap() uint64 {
+	var value uint64
+	for _, source := range c.sources {
+		value += source.Swap()
+	}
+	return value
+}
+
+func (c *mergedCounter) Reset() {
+	for _, source := range c.sources {
+		source.Reset()
+	}
+}
+
+// Snapshot sums the sources' values and generations; generations only grow,
+// so the sum changes whenever any source is reset
+func (c *mergedCounter) Snapshot() CounterSnapshot {
+	var snapshot CounterSnapshot
+	for _, source := range c.sources {
+		s := source.Snapshot()
+		snapshot.Value += s.Value
+		snapshot.Generation += s.Generation
+	}
+	return snapshot
+}
+
+type mergedGauge struct {
+	exportedBase
+	sources []Gauge
+}
+
+func (g *mergedGauge) Set(float64)     {}
+func (g *mergedGauge) Add(float64)     {}
+func (g *mergedGauge) Inc()            {}
+func (g *mergedGauge) Dec()            {}
+func (g *mergedGauge) With(Tags) Gauge { return g }
+
+func (g *mergedGauge) Value() int64 {
+	var value int64
+	for _, source := range g.sources {
+		value += source.Value()
+	}
+	return value
+}
+
+func (g *mergedGauge) FloatValue() float64 {
+	var value float64
+	for _, source := range g.sources {
+		value += GaugeValue(source)
+	}
+	return value
+}
+
+type mergedUpDownCounter struct {
+	exportedBase
+	sources []UpDownCounter
+}
+
+func (c *mergedUpDownCounter) Add(float64)             {}
+func (c *mergedUpDownCounter) Inc()                    {}
+func (c *mergedUpDownCounter) Dec()                    {}
+func (c *mergedUpDownCounter) With(Tags) UpDownCounter { return c }
+
+func (c *mergedUpDownCounter) Value() int64 {
+	var value int64
+	for _, source := range c.sources {
+		value += source.Value()
+	}
+	return value
+}
+
+type mergedHistogram struct {
+	exportedBase
+	sources []Histogram
+}
+
+func (h *mergedHistogram) Observe(float64)     {}
+func (h *mergedHistogram) With(Tags) Histogram { return h }
+
+func (h *mergedHistogram) Snapshot() HistogramSnapshot {
+	snapshots := make([]HistogramSnapshot, len(h.sources))
+	for i, source := range h.sources {
+		snapshots[i] = source.Snapshot()
+	}
+	return mergeSnapshots(snapshots)
+}
+
+func (h *mergedHistogram) SnapshotAndReset() HistogramSnapshot {
+	snapshots := make([]HistogramSnapshot, len(h.sources))
+	for i, source := range h.sources {
+		snapshots[i] = source.SnapshotAndReset()
+	}
+	return mergeSnapshots(snapshots)
+}
+
+type mergedTimer struct {
+	exportedBase
+	sources []Timer
+}
+
+func (t *mergedTimer) Record(time.Duration)  {}
+func (t *mergedTimer) RecordSince(time.Time) {}
+func (t *mergedTimer) With(Tags) Timer       { return t }
+
+// Time runs fn without recording its duration
+func (t *mergedTimer) Time(fn func()) time.Duration {
+	start := time.Now()
+	fn()
+	return time.Since(start)
+}
+
+func (t *mergedTimer) Snapshot() HistogramSnapshot {
+	snapshots := make([]HistogramSnapshot, len(t.sources))
+	for i, source := range t.sources {
+		snapshots[i] = source.Snapshot()
+	}
+	return mergeSnapshots(snapshots)
+}
+
+func (t *mergedTimer) SnapshotAndReset() HistogramSnapshot {
+	snapshots := make([]HistogramSnapshot, len(t.sources))
+	for i, source := range t.sources {
+		snapshots[i] = source.SnapshotAndReset()
+	}
+	return mergeSnapshots(snapshots)
+}
+
+type mergedMeter struct {
+	exportedBase
+	sources []Meter
+}
+
+func (m *mergedMeter) Mark(int64)      {}
+func (m *mergedMeter) With(Tags) Meter { return m }
+func (m *mergedMeter) Count() int64    { return m.Snapshot().Count }
+
+func (m *mergedMeter) Snapshot() MeterSnapshot {
+	var merged MeterSnapshot
+	for _, source := range m.sources {
+		snapshot := source.Snapshot()
+		merged.Count += snapshot.Count
+		merged.Rate1 += snapshot.Rate1
+		merged.Rate5 += snapshot.Rate5
+		merged.Rate15 += snapshot.Rate15
+		merged.MeanRate += snapshot.MeanRate
+	}
+	return merged
+}
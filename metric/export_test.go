@@ -0,0 +1,116 @@
+package metric
+
+import (
+	"testing"
+	"time"
+)
+
+// recordingReporter keeps the metrics of the latest report by series
+type recordingReporter struct {
+	fakeReporter
+	metrics map[string]Metric
+}
+
+func (r *recordingReporter) Report(registry Registry) error {
+	r.metrics = make(map[string]Metric)
+	registry.Each(func(m Metric) {
+		r.metrics[m.Name()+canonicalTags(m.Tags())] = m
+	})
+	return r.fakeReporter.Report(registry)
+}
+
+func TestFilterReporter(t *testing.T) {
+	registry := NewNoCleanupRegistry().(SeriesRegistry)
+	defer registry.Close()
+
+	logins := registry.Counter(Options{Name: "logins_total", Tags: Tags{"user_id": "1", "method": "sso"}})
+	logins.Add(2)
+	logins.With(Tags{"user_id": "2"}).Add(3)
+	registry.Counter(Options{Name: "logins_total", Tags: Tags{"user_id": "3", "method": "password"}}).Inc()
+	registry.Gauge(Options{Name: "debug_goroutines"}).Set(4)
+	registry.Gauge(Options{Name: "queue_depth"}).Set(5)
+	latency := registry.Histogram(Options{Name: "login_seconds", Tags: Tags{"user_id": "1"}, Buckets: []float64{1}})
+	latency.Observe(0.5)
+	latency.With(Tags{"user_id": "2"}).Observe(2)
+
+	recorder := &recordingReporter{}
+	reporter := FilterReporter(recorder,
+		DenyNames("debug_*"),
+		StripTags("user_id"),
+		MetricFilter(func(m Metric) bool { return m.Name() != "queue_depth" }),
+	)
+	if err := reporter.Report(registry); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recorder.metrics) != 3 {
+		t.Fatalf("Expected 3 exported series, got %v", recorder.metrics)
+	}
+	sso, ok := recorder.metrics["logins_total{method=sso}"].(Counter)
+	if !ok || sso.Value() != 5 {
+		t.Errorf("Expected the sso logins to be merged to 5, got %v", recorder.metrics["logins_total{method=sso}"])
+	}
+	password, ok := recorder.metrics["logins_total{method=password}"].(Counter)
+	if !ok || password.Value() != 1 {
+		t.Errorf("Expected the stripped password login to count 1, got %v", recorder.metrics["logins_total{method=password}"])
+	}
+	histogram, ok := recorder.metrics["login_seconds{}"].(Histogram)
+	if !ok {
+		t.Fatalf("Expected a merged histogram, got %v", recorder.metrics)
+	}
+	if snapshot := histogram.Snapshot(); snapshot.Count != 2 || snapshot.Sum != 2.5 || snapshot.Buckets[0] != 1 {
+		t.Errorf("Expected the merged histogram to hold both observations, got %+v", snapshot)
+	}
+
+	// The registry itself keeps every series
+	if got := len(registry.Series("logins_total")); got != 3 {
+		t.Errorf("Expected the registry to keep 3 login series, got %d", got)
+	}
+}
+
+func TestExportFilterAllowAndSwap(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	requests := registry.Counter(Options{Name: "http_requests_total", Tags: Tags{"ip": "10.0.0.1"}})
+	requests.Add(2)
+	requests.With(Tags{"ip": "10.0.0.2"}).Inc()
+	registry.Timer(Options{Name: "http_latency", Tags: Tags{"ip": "10.0.0.1"}}).Record(time.Second)
+	registry.Counter(Options{Name: "jobs_total"}).Inc()
+
+	view := NewExportFilter(AllowNames("http_*"), StripTags("ip")).View(registry)
+	var names []string
+	var merged Counter
+	view.Each(func(m Metric) {
+		names = append(names, m.Name())
+		if c, ok := m.(Counter); ok {
+			merged = c
+		}
+		if _, ok := m.Tags()["ip"]; ok {
+			t.Errorf("Expected ip to be stripped from %s", m.Name())
+		}
+	})
+	if len(names) != 2 || merged == nil {
+		t.Fatalf("Expected the http counter and timer only, got %v", names)
+	}
+
+	if got := merged.Swap(); got != 3 {
+		t.Errorf("Expected Swap to return the merged total, got %d", got)
+	}
+	if got := requests.Value(); got != 0 {
+		t.Errorf("Expected Swap to reset the merged series, got %d", got)
+	}
+
+	if !panics(func() { AllowNames("[") }) {
+		t.Error("Expected a malformed pattern to panic")
+	}
+}
+
+// panics reports whether fn panics
+func panics(fn func()) (panicked bool) {
+	defer func() {
+		panicked = recover() != nil
+	}()
+	fn()
+	return false
+}
@@ -0,0 +1,391 @@
+package metric
+
+import "context"
+
+// forwardingRegistry embeds a Registry and forwards the optional registry
+// interfaces to it. The wrappers built on it are returned through expose, so
+// only the methods of interfaces the wrapped registry implements are reachable.
+type forwardingRegistry struct {
+	Registry
+}
+
+func (f forwardingRegistry) Watch(ctx context.Context, filter WatchFilter) (<-chan MetricUpdate, error) {
+	return f.Registry.(WatchRegistry).Watch(ctx, filter)
+}
+
+func (f forwardingRegistry) WatchThreshold(ctx context.Context, name string, condition Condition, fn func(ThresholdEvent)) error {
+	return f.Registry.(WatchRegistry).WatchThreshold(ctx, name, condition, fn)
+}
+
+func (f forwardingRegistry) UnregisterMetric(name string, t Type) {
+	f.Registry.(SeriesRegistry).UnregisterMetric(name, t)
+}
+
+func (f forwardingRegistry) UnregisterWhere(match func(Metric) bool) {
+	f.Registry.(SeriesRegistry).UnregisterWhere(match)
+}
+
+func (f forwardingRegistry) UnregisterPrefix(prefix string) {
+	f.Registry.(SeriesRegistry).UnregisterPrefix(prefix)
+}
+
+func (f forwardingRegistry) EachByType(t Type, fn func(Metric)) {
+	f.Registry.(SeriesRegistry).EachByType(t, fn)
+}
+
+func (f forwardingRegistry) Lookup(name string, t Type) (Metric, bool) {
+	return f.Registry.(SeriesRegistry).Lookup(name, t)
+}
+
+func (f forwardingRegistry) Series(name string) []SeriesInfo {
+	return f.Registry.(SeriesRegistry).Series(name)
+}
+
+func (f forwardingRegistry) Pause() {
+	f.Registry.(PausableRegistry).Pause()
+}
+
+func (f forwardingRegistry) Resume() {
+	f.Registry.(PausableRegistry).Resume()
+}
+
+func (f forwardingRegistry) Paused() bool {
+	return f.Registry.(PausableRegistry).Paused()
+}
+
+func (f forwardingRegistry) UpdateTagValidation(config TagValidationConfig) error {
+	return f.Registry.(ConfigurableRegistry).UpdateTagValidation(config)
+}
+
+func (f forwardingRegistry) SetMetadata(name, description, unit string) {
+	f.Registry.(ConfigurableRegistry).SetMetadata(name, description, unit)
+}
+
+func (f forwardingRegistry) OnExpire(fn func(Metric)) {
+	f.Registry.(ConfigurableRegistry).OnExpire(fn)
+}
+
+func (f forwardingRegistry) GaugeFunc(opts Options, fn func() float64) Gauge {
+	return f.Registry.(InstrumentRegistry).GaugeFunc(opts, fn)
+}
+
+func (f forwardingRegistry) Derived(opts Options, fn func(SnapshotView) float64) Gauge {
+	return f.Registry.(InstrumentRegistry).Derived(opts, fn)
+}
+
+func (f forwardingRegistry) UpDownCounter(opts Options) UpDownCounter {
+	return f.Registry.(InstrumentRegistry).UpDownCounter(opts)
+}
+
+func (f forwardingRegistry) Meter(opts Options) Meter {
+	return f.Registry.(InstrumentRegistry).Meter(opts)
+}
+
+func (f forwardingRegistry) Cardinality(opts Options) Cardinality {
+	return f.Registry.(InstrumentRegistry).Cardinality(opts)
+}
+
+// TagValidation implements TagValidator with the config of the wrapped registry
+func (f forwardingRegistry) TagValidation() TagValidationConfig {
+	return TagValidationOf(f.Registry)
+}
+
+// coreView is the part of a wrapper every registry supports
+type coreView interface {
+	Registry
+	TagValidator
+}
+
+// The methods each optional registry interface adds to Registry
+type (
+	watchMethods interface {
+		Watch(ctx context.Context, filter WatchFilter) (<-chan MetricUpdate, error)
+		WatchThreshold(ctx context.Context, name string, condition Condition, fn func(ThresholdEvent)) error
+	}
+
+	seriesMethods interface {
+		UnregisterMetric(name string, t Type)
+		UnregisterWhere(match func(Metric) bool)
+		UnregisterPrefix(prefix string)
+		EachByType(t Type, fn func(Metric))
+		Lookup(name string, t Type) (Metric, bool)
+		Series(name string) []SeriesInfo
+	}
+
+	pauseMethods interface {
+		Pause()
+		Resume()
+		Paused() bool
+	}
+
+	configMethods interface {
+		UpdateTagValidation(config TagValidationConfig) error
+		SetMetadata(name, description, unit string)
+		OnExpire(fn func(Metric))
+	}
+
+	instrumentMethods interface {
+		GaugeFunc(opts Options, fn func() float64) Gauge
+		Derived(opts Options, fn func(SnapshotView) float64) Gauge
+		UpDownCounter(opts Options) UpDownCounter
+		Meter(opts Options) Meter
+		Cardinality(opts Options) Cardinality
+	}
+)
+
+// registryView is implemented by the wrappers built on forwardingRegistry
+type registryView interface {
+	coreView
+	watchMethods
+	seriesMethods
+	pauseMethods
+	configMethods
+	instrumentMethods
+}
+
+// capability is a set of optional registry interfaces
+type capability uint8
+
+const (
+	canWatch capability = 1 << iota
+	canSeries
+	canPause
+	canConfigure
+	canInstrument
+)
+
+// capabilitiesOf returns the optional interfaces registry implements
+func capabilitiesOf(registry Registry) capability {
+	var caps capability
+	if _, ok := registry.(WatchRegistry); ok {
+		caps |= canWatch
+	}
+	if _, ok := registry.(SeriesRegistry); ok {
+		caps |= canSeries
+	}
+	if _, ok := registry.(PausableRegistry); ok {
+		caps |= canPause
+	}
+	if _, ok := registry.(ConfigurableRegistry); ok {
+		caps |= canConfigure
+	}
+	if _, ok := registry.(InstrumentRegistry); ok {
+		caps |= canInstrument
+	}
+	return caps
+}
+
+// expose returns view, a wrapper of base, as a Registry implementing exactly the
+// optional interfaces base implements, so type assertions discover the same
+// capabilities on the wrapper as on base. Methods cannot be added to a value at
+// run time, so every combination has its own struct type.
+func expose(view registryView, base Registry) Registry {
+	switch capabilitiesOf(base) {
+	case canWatch:
+		return struct {
+			coreView
+			watchMethods
+		}{view, view}
+	case canSeries:
+		return struct {
+			coreView
+			seriesMethods
+		}{view, view}
+	case canWatch | canSeries:
+		return struct {
+			coreView
+			watchMethods
+			seriesMethods
+		}{view, view, view}
+	case canPause:
+		return struct {
+			coreView
+			pauseMethods
+		}{view, view}
+	case canWatch | canPause:
+		return struct {
+			coreView
+			watchMethods
+			pauseMethods
+		}{view, view, view}
+	case canSeries | canPause:
+		return struct {
+			coreView
+			seriesMethods
+			pauseMethods
+		}{view, view, view}
+	case canWatch | canSeries | canPause:
+		return struct {
+			coreView
+			watchMethods
+			seriesMethods
+			pauseMethods
+		}{view, view, view, view}
+	case canConfigure:
+		return struct {
+			coreView
+			configMethods
+		}{view, view}
+	case canWatch | canConfigure:
+		return struct {
+			coreView
+			watchMethods
+			configMethods
+		}{view, view, view}
+	case canSeries | canConfigure:
+		return struct {
+			coreView
+			seriesMethods
+			configMethods
+		}{view, view, view}
+	case canWatch | canSeries | canConfigure:
+		return struct {
+			coreView
+			watchMethods
+			seriesMethods
+			configMethods
+		}{view, view, view, view}
+	case canPause | canConfigure:
+		return struct {
+			coreView
+			pauseMethods
+			configMethods
+		}{view, view, view}
+	case canWatch | canPause | canConfigure:
+		return struct {
+			coreView
+			watchMethods
+			pauseMethods
+			configMethods
+		}{view, view, view, view}
+	case canSeries | canPause | canConfigure:
+		return struct {
+			coreView
+			seriesMethods
+			pauseMethods
+			configMethods
+		}{view, view, view, view}
+	case canWatch | canSeries | canPause | canConfigure:
+		return struct {
+			coreView
+			watchMethods
+			seriesMethods
+			pauseMethods
+			configMethods
+		}{view, view, view, view, view}
+	case canInstrument:
+		return struct {
+			coreView
+			instrumentMethods
+		}{view, view}
+	case canWatch | canInstrument:
+		return struct {
+			coreView
+			watchMethods
+			instrumentMethods
+		}{view, view, view}
+	case canSeries | canInstrument:
+		return struct {
+			coreView
+			seriesMethods
+			instrumentMethods
+		}{view, view, view}
+	case canWatch | canSeries | canInstrument:
+		return struct {
+			coreView
+			watchMethods
+			seriesMethods
+			instrumentMethods
+		}{view, view, view, view}
+	case canPause | canInstrument:
+		return struct {
+			coreView
+			pauseMethods
+			instrumentMethods
+		}{view, view, view}
+	case canWatch | canPause | canInstrument:
+		return struct {
+			coreView
+			watchMethods
+			pauseMethods
+			instrumentMethods
+		}{view, view, view, view}
+	case canSeries | canPause | canInstrument:
+		return struct {
+			coreView
+			seriesMethods
+			pauseMethods
+			instrumentMethods
+		}{view, view, view, view}
+	case canWatch | canSeries | canPause | canInstrument:
+		return struct {
+			coreView
+			watchMethods
+			seriesMethods
+			pauseMethods
+			instrumentMethods
+		}{view, view, view, view, view}
+	case canConfigure | canInstrument:
+		return struct {
+			coreView
+			configMethods
+			instrumentMethods
+		}{view, view, view}
+	case canWatch | canConfigure | canInstrument:
+		return struct {
+			coreView
+			watchMethods
+			configMethods
+			instrumentMethods
+		}{view, view, view, view}
+	case canSeries | canConfigure | canInstrument:
+		return struct {
+			coreView
+			seriesMethods
+			configMethods
+			instrumentMethods
+		}{view, view, view, view}
+	case canWatch | canSeries | canConfigure | canInstrument:
+		return struct {
+			coreView
+			watchMethods
+			seriesMethods
+			configMethods
+			instrumentMethods
+		}{view, view, view, view, view}
+	case canPause | canConfigure | canInstrument:
+		return struct {
+			coreView
+			pauseMethods
+			configMethods
+			instrumentMethods
+		}{view, view, view, view}
+	case canWatch | canPause | canConfigure | canInstrument:
+		return struct {
+			coreView
+			watchMethods
+			pauseMethods
+			configMethods
+			instrumentMethods
+		}{view, view, view, view, view}
+	case canSeries | canPause | canConfigure | canInstrument:
+		return struct {
+			coreView
+			seriesMethods
+			pauseMethods
+			configMethods
+			instrumentMethods
+		}{view, view, view, view, view}
+	case canWatch | canSeries | canPause | canConfigure | canInstrument:
+		return struct {
+			coreView
+			watchMethods
+			seriesMethods
+			pauseMethods
+			configMethods
+			instrumentMethods
+		}{view, view, view, view, view, view}
+	default:
+		return struct{ coreView }{view}
+	}
+}
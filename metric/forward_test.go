@@ -0,0 +1,74 @@
+package metric
+
+import "testing"
+
+// coreRegistry hides every optional interface of the registry it embeds
+type coreRegistry struct {
+	Registry
+}
+
+func TestWrappersExposeBaseCapabilities(t *testing.T) {
+	full := NewNoCleanupRegistry()
+	defer full.Close()
+	rollup, err := NewRollupRegistry(coreRegistry{full}, RollupRule{Name: "requests_total", Without: []string{"route"}})
+	if err != nil {
+		t.Fatalf("NewRollupRegistry() returned error: %v", err)
+	}
+
+	for name, view := range map[string]Registry{
+		"tenant": ForTenant(coreRegistry{full}, "acme"),
+		"rollup": rollup,
+	} {
+		if _, ok := view.(WatchRegistry); ok {
+			t.Errorf("%s: expected a view of a core registry not to implement WatchRegistry", name)
+		}
+		if _, ok := view.(SeriesRegistry); ok {
+			t.Errorf("%s: expected a view of a core registry not to implement SeriesRegistry", name)
+		}
+		if _, ok := view.(PausableRegistry); ok {
+			t.Errorf("%s: expected a view of a core registry not to implement PausableRegistry", name)
+		}
+		if _, ok := view.(ConfigurableRegistry); ok {
+			t.Errorf("%s: expected a view of a core registry not to implement ConfigurableRegistry", name)
+		}
+		if _, ok := view.(InstrumentRegistry); ok {
+			t.Errorf("%s: expected a view of a core registry not to implement InstrumentRegistry", name)
+		}
+	}
+
+	// A tenant view of a core registry cannot remove the tenant's series alone
+	tenant := ForTenant(coreRegistry{full}, "acme")
+	tenant.Counter(Options{Name: "jobs_total"}).Inc()
+	tenant.Unregister("jobs_total")
+	if got := len(CollectSeries(full, "jobs_total")); got != 1 {
+		t.Errorf("Expected the tenant's series to be kept, got %d series", got)
+	}
+
+	partial := struct {
+		Registry
+		pauseMethods
+	}{full, full.(PausableRegistry)}
+	view := ForTenant(partial, "acme")
+	if _, ok := view.(PausableRegistry); !ok {
+		t.Error("Expected the tenant view to implement the PausableRegistry of its base")
+	}
+	if _, ok := view.(SeriesRegistry); ok {
+		t.Error("Expected the tenant view not to implement SeriesRegistry")
+	}
+
+	view = ForTenant(full, "acme")
+	for _, ok := range []bool{
+		implements[InstrumentRegistry](view), implements[SeriesRegistry](view), implements[WatchRegistry](view),
+		implements[ConfigurableRegistry](view), implements[PausableRegistry](view),
+	} {
+		if !ok {
+			t.Fatal("Expected a tenant view of a full registry to implement every optional interface")
+		}
+	}
+}
+
+// implements reports whether registry implements the interface I
+func implements[I any](registry Registry) bool {
+	_, ok := registry.(I)
+	return ok
+}
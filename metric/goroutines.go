@@ -0,0 +1,198 @@
+package metric
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// periodicTask is background work the library runs on an interval: registry
+// cleanup, scheduled exports and watch sampling
+type periodicTask struct {
+	ctx      context.Context
+	interval time.Duration
+	// immediate runs the task once before the first interval elapses
+	immediate bool
+	// run performs one iteration; returning false stops the task
+	run func() bool
+	// done, if set, is called once when the task stops
+	done func()
+
+	// next run and whether run returned false, on the shared worker; only
+	// touched by the worker
+	next    time.Time
+	stopped bool
+}
+
+// loop runs the task on its own goroutine until ctx is done or run returns false
+func (t *periodicTask) loop() {
+	if t.immediate && !t.run() {
+		return
+	}
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+			if !t.run() {
+				return
+			}
+		}
+	}
+}
+
+func (t *periodicTask) stop() {
+	if t.done != nil {
+		t.done()
+	}
+}
+
+// goroutineBudget caps the goroutines started for periodic tasks. Tasks get a
+// goroutine of their own while the cap allows and otherwise share one worker.
+type goroutineBudget struct {
+	mu      sync.Mutex
+	limit   int // zero for no cap
+	active  int // goroutines running, including the shared worker
+	pooled  []*periodicTask
+	working bool          // the shared worker is running
+	wake    chan struct{} // wakes the shared worker to recompute its schedule
+}
+
+var goroutines = &goroutineBudget{wake: make(chan struct{}, 1)}
+
+// SetGoroutineLimit caps the background goroutines the library runs for
+// registry cleanup, schedulers and watches at n, for hosts that are sensitive
+// to goroutine counts. Periodic work started once the cap is reached shares a
+// single worker goroutine, which takes one slot of the cap, instead of getting
+// its own; a Watch consumer that stops reading then stalls the other shared
+// work, so give watches a Buffer and keep draining them. Zero or
+// less, the default, removes the cap. The limit applies to work started after
+// the call; running goroutines are not moved.
+func SetGoroutineLimit(n int) {
+	goroutines.mu.Lock()
+	defer goroutines.mu.Unlock()
+	goroutines.limit = max(n, 0)
+}
+
+// GoroutineLimit returns the cap set with SetGoroutineLimit, or zero without one
+func GoroutineLimit() int {
+	goroutines.mu.Lock()
+	defer goroutines.mu.Unlock()
+	return goroutines.limit
+}
+
+// ActiveGoroutines returns the number of background goroutines the library is
+// running, including the shared worker
+func ActiveGoroutines() int {
+	goroutines.mu.Lock()
+	defer goroutines.mu.Unlock()
+	return goroutines.active
+}
+
+// start runs t on a goroutine of its own if the cap allows and on the shared
+// worker otherwise. Until the worker runs, one slot is kept free for it.
+func (b *goroutineBudget) start(t *periodicTask) {
+	b.mu.Lock()
+	reserved := 1
+	if b.working {
+		reserved = 0
+	}
+	if b.limit == 0 || b.active+reserved < b.limit {
+		b.active++
+		b.mu.Unlock()
+		go func() {
+			defer b.release()
+			defer t.stop()
+			t.loop()
+		}()
+		return
+	}
+
+	t.next = time.Now()
+	if !t.immediate {
+		t.next = t.next.Add(t.interval)
+	}
+	b.pooled = append(b.pooled, t)
+	if !b.working {
+		b.working = true
+		b.active++
+		go b.work()
+	}
+	b.mu.Unlock()
+
+	b.notify()
+	context.AfterFunc(t.ctx, b.notify)
+}
+
+func (b *goroutineBudget) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.active--
+}
+
+// notify wakes the shared worker without blocking
+func (b *goroutineBudget) notify() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// work is the shared worker. It runs pooled tasks as they fall due and exits
+// once none are left.
+func (b *goroutineBudget) work() {
+	for {
+		now := time.Now()
+		var due, finished []*periodicTask
+		var next time.Time
+
+		b.mu.Lock()
+		pooled := b.pooled[:0]
+		for _, t := range b.pooled {
+			switch {
+			case t.stopped || t.ctx.Err() != nil:
+				finished = append(finished, t)
+				continue
+			case !t.next.After(now):
+				due = append(due, t)
+			case next.IsZero() || t.next.Before(next):
+				next = t.next
+			}
+			pooled = append(pooled, t)
+		}
+		clear(b.pooled[len(pooled):])
+		b.pooled = pooled
+		idle := len(pooled) == 0
+		if idle {
+			b.working = false
+			b.active--
+		}
+		b.mu.Unlock()
+
+		for _, t := range finished {
+			t.stop()
+		}
+		if idle {
+			return
+		}
+
+		for _, t := range due {
+			t.stopped = !t.run()
+			t.next = time.Now().Add(t.interval)
+		}
+		if len(due) > 0 {
+			continue
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-b.wake:
+		}
+		timer.Stop()
+	}
+}
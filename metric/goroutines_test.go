@@ -0,0 +1,85 @@
+package metric
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitForGoroutines waits until the library runs want background goroutines
+func waitForGoroutines(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for ActiveGoroutines() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d active goroutines, got %d", want, ActiveGoroutines())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGoroutineLimitSharesWorker(t *testing.T) {
+	// Other tests may leave registries running, so cap relative to them
+	base := ActiveGoroutines()
+	SetGoroutineLimit(base + 2)
+	defer SetGoroutineLimit(0)
+
+	registry := NewRegistry(DefaultTagValidationConfig(), time.Millisecond, WithMetaMetrics()).(WatchRegistry)
+	if got := ActiveGoroutines(); got != base+1 {
+		t.Fatalf("Expected the cleanup loop to get its own goroutine, got %d active", got-base)
+	}
+
+	reporters := []*fakeReporter{{}, {}, {}}
+	var schedulers []*Scheduler
+	for _, reporter := range reporters {
+		schedulers = append(schedulers, NewScheduler(registry, time.Millisecond, WithReporter("fake", reporter), WithShutdownLogger(nil)))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, err := registry.Watch(ctx, WatchFilter{Interval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry.Counter(Options{Name: "jobs_total"}).Inc()
+
+	if got := ActiveGoroutines(); got != base+2 {
+		t.Errorf("Expected the schedulers and watch to share one worker, got %d active", got-base)
+	}
+	if v := metaValues(registry)[MetaMetricPrefix+"goroutines{}"]; v != float64(base+2) {
+		t.Errorf("Expected the goroutines meta-metric to be %d, got %v", base+2, v)
+	}
+
+	// Keep consuming, since a blocked watch would stall the shared worker
+	sampled := make(chan int)
+	go func() {
+		n := 0
+		for range updates {
+			n++
+		}
+		sampled <- n
+	}()
+	deadline := time.Now().Add(time.Second)
+	for i, reporter := range reporters {
+		for {
+			reporter.mu.Lock()
+			reports := reporter.reports
+			reporter.mu.Unlock()
+			if reports > 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected scheduler %d to export on the shared worker", i)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	for _, s := range schedulers {
+		s.Close()
+	}
+
+	cancel()
+	if n := <-sampled; n == 0 {
+		t.Error("Expected the shared worker to sample the watch")
+	}
+	registry.Close()
+	waitForGoroutines(t, base)
+}
@@ -0,0 +1,27 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Handler serves the result of Check as JSON, with status 200 when every rule
+// holds and 503 otherwise, for Kubernetes liveness and readiness probes:
+//
+//	http.Handle("/healthz", checker.Handler())
+func (c *Checker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		report := c.Check()
+		status := http.StatusOK
+		if !report.Healthy() {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(struct {
+			Healthy bool `json:"healthy"`
+			Report
+		}{report.Healthy(), report})
+	})
+}
@@ -0,0 +1,351 @@
+// Package health turns metric thresholds into health checks. Rules such as
+//
+//	error_rate(operation=auth) < 5% over 1m
+//
+// are evaluated against recent snapshots of a registry, and Handler serves the
+// result as 200 or 503 for Kubernetes liveness and readiness probes.
+package health
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/metric/delta"
+	"github.com/MichaelAJay/go-metrics/metric/history"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "health", Package: "github.com/MichaelAJay/go-metrics/metric/health"})
+}
+
+// DefaultWindow is the window of rate, error_rate and quantile rules without "over"
+const DefaultWindow = time.Minute
+
+// Option configures a Checker
+type Option func(*Checker)
+
+// WithStore evaluates rules against store instead of snapshots the Checker
+// records itself. The store's owner records it, e.g. with history.Store.Run;
+// checkers for liveness and readiness can share one store this way.
+func WithStore(store *history.Store) Option {
+	return func(c *Checker) {
+		c.store = store
+		c.record = false
+	}
+}
+
+// WithClock sets the time source, for tests
+func WithClock(now func() time.Time) Option {
+	return func(c *Checker) {
+		c.now = now
+	}
+}
+
+// Checker evaluates health rules against a registry
+type Checker struct {
+	registry metric.Registry
+	store    *history.Store
+	record   bool // snapshot the registry on each Check
+	now      func() time.Time
+
+	mu    sync.RWMutex
+	rules []*Rule
+}
+
+// New creates a Checker for registry. Unless WithStore is given, each Check
+// records a snapshot of the registry first, so probes build up the history
+// that windowed rules need.
+func New(registry metric.Registry, opts ...Option) *Checker {
+	c := &Checker{
+		registry: registry,
+		store:    history.NewStore(0),
+		record:   true,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// AddRule parses expression (see ParseRule) and adds it to the checker under name
+func (c *Checker) AddRule(name, expression string) error {
+	rule, err := ParseRule(expression)
+	if err != nil {
+		return err
+	}
+	rule.Name = name
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, existing := range c.rules {
+		if existing.Name == name {
+			return fmt.Errorf("health: duplicate rule %q", name)
+		}
+	}
+	c.rules = append(c.rules, rule)
+	return nil
+}
+
+// Status is the result of evaluating one rule
+type Status struct {
+	Name string `json:"name"`
+	Rule string `json:"rule"`
+	// Value is the value the threshold was compared against
+	Value float64 `json:"value"`
+	// Healthy reports whether the threshold holds
+	Healthy bool `json:"healthy"`
+	// Pending is set while the history does not cover the rule's window yet;
+	// pending rules count as healthy
+	Pending bool `json:"pending,omitempty"`
+	// Error is set when the rule could not be evaluated, which fails it
+	Error string `json:"error,omitempty"`
+}
+
+// Report is the result of a Check
+type Report struct {
+	Time  time.Time `json:"time"`
+	Rules []Status  `json:"rules"`
+}
+
+// Healthy reports whether every rule holds
+func (r Report) Healthy() bool {
+	for _, s := range r.Rules {
+		if !s.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Check evaluates every rule now
+func (c *Checker) Check() Report {
+	now := c.now()
+	if c.record {
+		c.store.Add(history.Snapshot{Time: now, Series: delta.Collect(c.registry)})
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	report := Report{Time: now, Rules: make([]Status, 0, len(c.rules))}
+	for _, rule := range c.rules {
+		status := Status{Name: rule.Name, Rule: rule.Expression, Healthy: true}
+		value, err := rule.evaluate(c.store, now)
+		switch {
+		case errors.Is(err, history.ErrNoHistory):
+			status.Pending = true
+		case err != nil:
+			status.Healthy = false
+			status.Error = err.Error()
+		default:
+			status.Value = value
+			status.Healthy = rule.holds(value)
+		}
+		report.Rules = append(report.Rules, status)
+	}
+	return report
+}
+
+// Rule is a parsed health rule
+type Rule struct {
+	// Name identifies the rule in reports
+	Name string
+	// Expression is the rule as written
+	Expression string
+
+	function  string
+	metric    string
+	quantile  float64
+	matchers  metric.Tags
+	operator  string
+	threshold float64
+	window    time.Duration
+}
+
+// ParseRule parses a rule of the form
+//
+//	function(arguments) operator threshold [over window]
+//
+// where operator is <, <=, > or >=, the threshold is a number, a percentage
+// such as 5%, or a duration such as 250ms (compared in nanoseconds, the unit
+// timers record), and window is a duration. The functions are:
+//
+//	error_rate(operation=auth)            errors over operations recorded by the operational package
+//	rate(http_requests_total, status=500) per-second increase, summed over matching series
+//	value(queue_depth, queue=emails)      current value, summed over matching series
+//	quantile(0.99, checkout_duration)     quantile of a histogram or timer, highest across matching series
+//
+// Windowed functions default to DefaultWindow; value ignores the window.
+func ParseRule(expression string) (*Rule, error) {
+	rule := &Rule{Expression: expression, window: DefaultWindow, matchers: metric.Tags{}}
+	fail := func(format string, args ...any) (*Rule, error) {
+		return nil, fmt.Errorf("health: rule %q: %s", expression, fmt.Sprintf(format, args...))
+	}
+
+	lparen := strings.IndexByte(expression, '(')
+	rparen := strings.IndexByte(expression, ')')
+	if lparen < 0 || rparen < lparen {
+		return fail("expected function(arguments)")
+	}
+	rule.function = strings.TrimSpace(expression[:lparen])
+
+	var positional []string
+	for _, arg := range strings.Split(expression[lparen+1:rparen], ",") {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			positional = append(positional, arg)
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.Trim(strings.TrimSpace(value), `"`)
+		if key == "" || value == "" || strings.ContainsRune(value, '"') {
+			return fail("invalid tag matcher %q", arg)
+		}
+		rule.matchers[key] = value
+	}
+
+	switch rule.function {
+	case "error_rate":
+		if len(positional) != 0 || len(rule.matchers) != 1 || rule.matchers["operation"] == "" {
+			return fail("error_rate takes only operation=<name>")
+		}
+	case "rate", "value":
+		if len(positional) != 1 {
+			return fail("%s takes a metric name followed by tag matchers", rule.function)
+		}
+		rule.metric = positional[0]
+	case "quantile":
+		if len(positional) != 2 {
+			return fail("quantile takes a quantile and a metric name followed by tag matchers")
+		}
+		q, err := strconv.ParseFloat(positional[0], 64)
+		if err != nil || q < 0 || q > 1 {
+			return fail("quantile must be between 0 and 1, got %q", positional[0])
+		}
+		rule.quantile, rule.metric = q, positional[1]
+	default:
+		return fail("unknown function %q", rule.function)
+	}
+
+	fields := strings.Fields(expression[rparen+1:])
+	if len(fields) != 2 && len(fields) != 4 {
+		return fail("expected operator and threshold, optionally followed by over <window>")
+	}
+	switch fields[0] {
+	case "<", "<=", ">", ">=":
+		rule.operator = fields[0]
+	default:
+		return fail("unknown operator %q", fields[0])
+	}
+	threshold, err := parseThreshold(fields[1])
+	if err != nil {
+		return fail("invalid threshold %q", fields[1])
+	}
+	rule.threshold = threshold
+
+	if len(fields) == 4 {
+		window, err := time.ParseDuration(fields[3])
+		if fields[2] != "over" || err != nil || window <= 0 {
+			return fail("expected over <window>, got %q", strings.Join(fields[2:], " "))
+		}
+		rule.window = window
+	}
+	return rule, nil
+}
+
+// parseThreshold accepts numbers, percentages and durations
+func parseThreshold(raw string) (float64, error) {
+	if percent, ok := strings.CutSuffix(raw, "%"); ok {
+		v, err := strconv.ParseFloat(percent, 64)
+		return v / 100, err
+	}
+	if v, err := strconv.ParseFloat(raw, 64); err == nil {
+		return v, nil
+	}
+	d, err := time.ParseDuration(raw)
+	return float64(d), err
+}
+
+// holds reports whether value satisfies the rule's threshold
+func (r *Rule) holds(value float64) bool {
+	switch r.operator {
+	case "<":
+		return value < r.threshold
+	case "<=":
+		return value <= r.threshold
+	case ">":
+		return value > r.threshold
+	default:
+		return value >= r.threshold
+	}
+}
+
+// evaluate computes the rule's value from store at time at
+func (r *Rule) evaluate(store *history.Store, at time.Time) (float64, error) {
+	window := "[" + r.window.String() + "]"
+	switch r.function {
+	case "error_rate":
+		// The operational package counts {operation}_total and {operation}_errors_total
+		operation := r.matchers["operation"]
+		errs, err := sum(store, "rate("+operation+"_errors_total"+window+")", at)
+		if err != nil {
+			return 0, err
+		}
+		total, err := sum(store, "rate("+operation+"_total"+window+")", at)
+		if err != nil || total == 0 {
+			return 0, err
+		}
+		return errs / total, nil
+	case "rate":
+		return sum(store, "rate("+r.selector()+window+")", at)
+	case "value":
+		return sum(store, r.selector(), at)
+	default:
+		results, err := store.Query("quantile("+strconv.FormatFloat(r.quantile, 'g', -1, 64)+", "+r.selector()+window+")", at)
+		if err != nil {
+			return 0, err
+		}
+		highest := 0.0
+		for _, result := range results {
+			if !math.IsNaN(result.Value) {
+				highest = math.Max(highest, result.Value)
+			}
+		}
+		return highest, nil
+	}
+}
+
+// selector renders the rule's metric and tag matchers as a history query selector
+func (r *Rule) selector() string {
+	if len(r.matchers) == 0 {
+		return r.metric
+	}
+	matchers := make([]string, 0, len(r.matchers))
+	for k, v := range r.matchers {
+		matchers = append(matchers, k+`="`+v+`"`)
+	}
+	return r.metric + "{" + strings.Join(matchers, ",") + "}"
+}
+
+// sum returns the total of the query's results
+func sum(store *history.Store, query string, at time.Time) (float64, error) {
+	results, err := store.Query(query, at)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, result := range results {
+		total += result.Value
+	}
+	return total, nil
+}
@@ -0,0 +1,117 @@
+package health
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestCheckerErrorRate(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	checker := New(registry, WithClock(func() time.Time { return now }))
+	if err := checker.AddRule("auth_errors", "error_rate(operation=auth) < 5% over 1m"); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+	if err := checker.AddRule("queue", "value(queue_depth, queue=emails) <= 100"); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+
+	operations := registry.Counter(metric.Options{Name: "auth_total", Tags: metric.Tags{"operation": "auth", "status": "success"}})
+	errs := registry.Counter(metric.Options{Name: "auth_errors_total", Tags: metric.Tags{"operation": "auth", "error_type": "timeout"}})
+	registry.Gauge(metric.Options{Name: "queue_depth", Tags: metric.Tags{"queue": "emails"}}).Set(40)
+
+	// Without a minute of history the windowed rule is pending
+	report := checker.Check()
+	if !report.Healthy() || !report.Rules[0].Pending || report.Rules[1].Value != 40 {
+		t.Fatalf("Expected a pending error rate and a queue of 40, got %+v", report)
+	}
+
+	now = now.Add(time.Minute)
+	operations.Add(100)
+	errs.Add(2)
+	if report := checker.Check(); !report.Healthy() || report.Rules[0].Value != 0.02 {
+		t.Errorf("Expected a healthy error rate of 2%%, got %+v", report)
+	}
+
+	now = now.Add(time.Minute)
+	operations.Add(100)
+	errs.Add(10)
+	report = checker.Check()
+	if report.Healthy() || report.Rules[0].Healthy || math.Abs(report.Rules[0].Value-0.1) > 1e-9 {
+		t.Errorf("Expected an unhealthy error rate of 10%%, got %+v", report)
+	}
+
+	rec := httptest.NewRecorder()
+	checker.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503, got %d", rec.Code)
+	}
+	var body struct {
+		Healthy bool
+		Rules   []Status
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Healthy || len(body.Rules) != 2 {
+		t.Errorf("Expected an unhealthy JSON report, got %s", rec.Body.String())
+	}
+}
+
+func TestCheckerQuantileAndRate(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	checker := New(registry, WithClock(func() time.Time { return now }))
+	checker.AddRule("latency", "quantile(0.99, checkout_duration) < 250ms over 30s")
+	checker.AddRule("traffic", "rate(http_requests_total, status=200) >= 1 over 30s")
+
+	timer := registry.Timer(metric.Options{Name: "checkout_duration", Buckets: []float64{1e8, 5e8}})
+	requests := registry.Counter(metric.Options{Name: "http_requests_total", Tags: metric.Tags{"status": "200"}})
+	checker.Check()
+
+	now = now.Add(30 * time.Second)
+	timer.Record(50 * time.Millisecond)
+	requests.Add(60)
+	if report := checker.Check(); !report.Healthy() {
+		t.Errorf("Expected fast checkouts at 2 requests/s to be healthy, got %+v", report)
+	}
+
+	now = now.Add(30 * time.Second)
+	for range 10 {
+		timer.Record(400 * time.Millisecond)
+	}
+	report := checker.Check()
+	if report.Rules[0].Healthy || report.Rules[1].Healthy {
+		t.Errorf("Expected slow checkouts without traffic to fail both rules, got %+v", report)
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	for _, rule := range []string{
+		"error_rate operation=auth < 5%",
+		"error_rate(status=500) < 5%",
+		"unknown(x) < 1",
+		"rate(requests_total) ~ 1",
+		"rate(requests_total) < lots",
+		"rate(requests_total) < 1 during 1m",
+		"quantile(1.5, latency) < 1",
+		"value(queue_depth)",
+	} {
+		if _, err := ParseRule(rule); err == nil {
+			t.Errorf("Expected an error for %q", rule)
+		}
+	}
+
+	checker := New(metric.NewNoop())
+	checker.AddRule("queue", "value(queue_depth) < 1")
+	if err := checker.AddRule("queue", "value(queue_depth) < 2"); err == nil {
+		t.Error("Expected an error for a duplicate rule name")
+	}
+}
@@ -87,24 +87,22 @@ func TestHistogramImpl(t *testing.T) {
 				t.Errorf("Expected count 100, got %d", count)
 			}
 
-			// Check that min is set to some value
-			// Not checking for exact value because the actual implementation
-			// seems to handle it differently than expected
-			min := impl.min
-			count := impl.count
-			if min == 0 && count > 0 {
-				t.Errorf("Min value should be set to a non-zero value when observations exist")
+			snapshot := h.Snapshot()
+
+			// Check that min is 0 (first value added, a legitimate observation)
+			if snapshot.Min != 0 {
+				t.Errorf("Expected min 0, got %v", snapshot.Min)
 			}
 
 			// Check that max is 99 (last value added)
-			if max := impl.max; max != 99 {
-				t.Errorf("Expected max 99, got %d", max)
+			if snapshot.Max != 99 {
+				t.Errorf("Expected max 99, got %v", snapshot.Max)
 			}
 
 			// Verify sum
-			expectedSum := uint64(4950) // Sum of 0-99
-			if sum := impl.sum; sum != expectedSum {
-				t.Errorf("Expected sum %d, got %d", expectedSum, sum)
+			expectedSum := float64(4950) // Sum of 0-99
+			if snapshot.Sum != expectedSum {
+				t.Errorf("Expected sum %v, got %v", expectedSum, snapshot.Sum)
 			}
 
 			// Verify buckets have values
@@ -380,3 +378,86 @@ func TestHistogramPerformance(t *testing.T) {
 		t.Errorf("Expected count 1000, got %d", snapshot.Count)
 	}
 }
+
+func TestHistogramFractionalAndZeroValues(t *testing.T) {
+	h := newHistogram(Options{Name: "fractional_test"})
+
+	empty := h.Snapshot()
+	if empty.Min != 0 || empty.Max != 0 || empty.Sum != 0 {
+		t.Errorf("Expected zero min/max/sum before observations, got %v/%v/%v", empty.Min, empty.Max, empty.Sum)
+	}
+
+	h.Observe(0.25)
+	h.Observe(0)
+	h.Observe(0.5)
+
+	snapshot := h.Snapshot()
+	if snapshot.Count != 3 {
+		t.Errorf("Expected count 3, got %d", snapshot.Count)
+	}
+	if snapshot.Sum != 0.75 {
+		t.Errorf("Expected sum 0.75, got %v", snapshot.Sum)
+	}
+	if snapshot.Min != 0 {
+		t.Errorf("Expected min 0, got %v", snapshot.Min)
+	}
+	if snapshot.Max != 0.5 {
+		t.Errorf("Expected max 0.5, got %v", snapshot.Max)
+	}
+
+	// Negative values must also be tracked as the true minimum
+	h.Observe(-1.5)
+	snapshot = h.Snapshot()
+	if snapshot.Min != -1.5 {
+		t.Errorf("Expected min -1.5, got %v", snapshot.Min)
+	}
+	if snapshot.Sum != -0.75 {
+		t.Errorf("Expected sum -0.75, got %v", snapshot.Sum)
+	}
+}
+
+func TestHistogramWithPreservesBoundariesAndSharesData(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	h := registry.Histogram(Options{
+		Name:    "tagged_histogram",
+		Buckets: []float64{1, 5, 10},
+	})
+
+	child := h.With(Tags{"route": "/users"})
+	child.Observe(3)
+	h.With(Tags{"route": "/users"}).Observe(7)
+
+	snapshot := child.Snapshot()
+	if !reflect.DeepEqual(snapshot.Boundaries, []float64{1, 5, 10}) {
+		t.Errorf("Expected child to keep boundaries [1 5 10], got %v", snapshot.Boundaries)
+	}
+	if snapshot.Count != 2 {
+		t.Errorf("Expected repeated With() calls to share data, got count %d", snapshot.Count)
+	}
+	if !reflect.DeepEqual(snapshot.Buckets, []uint64{0, 1, 1, 0}) {
+		t.Errorf("Expected buckets [0 1 1 0], got %v", snapshot.Buckets)
+	}
+	if h.Snapshot().Count != 0 {
+		t.Errorf("Expected parent to be unaffected by child observations, got count %d", h.Snapshot().Count)
+	}
+
+	// The child series is registered alongside its parent
+	var series []Tags
+	registry.Each(func(m Metric) {
+		if m.Name() == "tagged_histogram" {
+			series = append(series, m.Tags())
+		}
+	})
+	if len(series) != 2 {
+		t.Fatalf("Expected parent and child to be registered, got %v", series)
+	}
+
+	registry.Unregister("tagged_histogram")
+	remaining := 0
+	registry.Each(func(Metric) { remaining++ })
+	if remaining != 0 {
+		t.Errorf("Expected Unregister to remove derived series, %d remain", remaining)
+	}
+}
@@ -0,0 +1,97 @@
+package history
+
+import (
+	_ "embed"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+//go:embed dashboard.html
+var dashboardPage []byte
+
+// dashboardSeries is one charted series in the JSON served by DashboardHandler
+type dashboardSeries struct {
+	Name   string      `json:"name"`
+	Type   metric.Type `json:"type"`
+	Tags   metric.Tags `json:"tags"`
+	Points []Point     `json:"points"`
+}
+
+// dashboardResponse is the JSON body served by DashboardHandler
+type dashboardResponse struct {
+	Time   time.Time         `json:"time"`
+	Series []dashboardSeries `json:"series"`
+}
+
+// DashboardHandler serves a self-contained HTML page charting the series held
+// by store, for local development and air-gapped debugging. The page needs no
+// external assets; it polls the same handler with format=json every few
+// seconds and redraws. Counters, histograms and timers are charted as
+// per-second rates, gauges as values.
+//
+// names limits the charts to metrics with those names; all metrics are charted
+// if none are given. The name query parameter further narrows the charts to
+// metrics whose name starts with it:
+//
+//	http.Handle("/debug/dashboard", history.DashboardHandler(store, "http_requests_total", "queue_depth"))
+//	// open http://localhost:8080/debug/dashboard?name=http_
+func DashboardHandler(store *Store, names ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("format") != "json" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write(dashboardPage)
+			return
+		}
+		prefix := req.URL.Query().Get("name")
+		writeJSON(w, http.StatusOK, dashboardResponse{
+			Time: time.Now(),
+			Series: store.chartSeries(func(name string) bool {
+				return (len(names) == 0 || slices.Contains(names, name)) && strings.HasPrefix(name, prefix)
+			}),
+		})
+	})
+}
+
+// chartSeries returns the points of every stored series whose name passes
+// keep, sorted by name, type and tags
+func (s *Store) chartSeries(keep func(name string) bool) []dashboardSeries {
+	byKey := make(map[string]*dashboardSeries)
+	keys := []string{}
+	for _, snapshot := range s.Snapshots() {
+		for _, series := range snapshot.Series {
+			if !keep(series.Name) {
+				continue
+			}
+			key := series.Key()
+			chart, ok := byKey[key]
+			if !ok {
+				chart = &dashboardSeries{Name: series.Name, Type: series.Type, Tags: series.Tags}
+				if chart.Tags == nil {
+					chart.Tags = metric.Tags{}
+				}
+				byKey[key] = chart
+				keys = append(keys, key)
+			}
+			chart.Points = append(chart.Points, Point{Time: snapshot.Time, Value: seriesValue(series)})
+		}
+	}
+
+	charts := make([]dashboardSeries, 0, len(keys))
+	for _, key := range keys {
+		charts = append(charts, *byKey[key])
+	}
+	slices.SortFunc(charts, func(a, b dashboardSeries) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		if c := strings.Compare(string(a.Type), string(b.Type)); c != 0 {
+			return c
+		}
+		return strings.Compare(tagKey(a.Tags), tagKey(b.Tags))
+	})
+	return charts
+}
@@ -0,0 +1,45 @@
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDashboardHandler(t *testing.T) {
+	start := time.Date(2024, 5, 1, 14, 31, 0, 0, time.UTC)
+	handler := DashboardHandler(testStore(start), "requests_total")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/dashboard", nil))
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/html") || !strings.Contains(recorder.Body.String(), "format") {
+		t.Errorf("Expected the HTML page, got %q", recorder.Header().Get("Content-Type"))
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/dashboard?format=json", nil))
+	var response dashboardResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Series) != 3 {
+		t.Fatalf("Expected the 3 requests_total series and no latency, got %+v", response.Series)
+	}
+	first := response.Series[0]
+	if first.Tags["route"] != "/a" || first.Tags["status"] != "200" || len(first.Points) != 2 || first.Points[1].Value != 160 {
+		t.Errorf("Expected /a 200 with points 100 and 160 first, got %+v", first)
+	}
+
+	recorder = httptest.NewRecorder()
+	DashboardHandler(testStore(start)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/?format=json&name=lat", nil))
+	response = dashboardResponse{}
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Series) != 1 || response.Series[0].Name != "latency" {
+		t.Errorf("Expected the name prefix to select latency only, got %+v", response.Series)
+	}
+}
@@ -0,0 +1,59 @@
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// queryResponse is the JSON body served by QueryHandler
+type queryResponse struct {
+	Time    time.Time `json:"time"`
+	Results []Result  `json:"results,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// QueryHandler serves queries against store as JSON, for mounting on an admin
+// endpoint. The query parameter holds the query (see Store.Query) and the
+// optional time parameter, RFC 3339 or unix seconds, the evaluation time
+// (now if absent):
+//
+//	GET /admin/query?query=sum by (status) (rate(http_requests_total[1m]))&time=2024-05-01T14:32:00Z
+func QueryHandler(store *Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		response := queryResponse{Time: time.Now()}
+		status := http.StatusOK
+
+		if raw := req.URL.Query().Get("time"); raw != "" {
+			at, err := parseTime(raw)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, queryResponse{Error: "invalid time: " + raw})
+				return
+			}
+			response.Time = at
+		}
+
+		results, err := store.Query(req.URL.Query().Get("query"), response.Time)
+		if err != nil {
+			status = http.StatusBadRequest
+			response.Error = err.Error()
+		}
+		response.Results = results
+		writeJSON(w, status, response)
+	})
+}
+
+// parseTime accepts RFC 3339 timestamps and unix seconds
+func parseTime(raw string) (time.Time, error) {
+	if seconds, err := strconv.ParseFloat(raw, 64); err == nil {
+		return time.Unix(0, int64(seconds*float64(time.Second))), nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
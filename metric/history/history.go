@@ -0,0 +1,101 @@
+// Package history keeps recent snapshots of a registry in memory so they can be
+// queried later without an external time series database.
+package history
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/metric/delta"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "history", Package: "github.com/MichaelAJay/go-metrics/metric/history"})
+}
+
+// DefaultCapacity is the number of snapshots a Store keeps by default
+const DefaultCapacity = 360
+
+// Snapshot is the state of every series in a registry at one point in time
+type Snapshot struct {
+	Time   time.Time
+	Series []delta.Series
+}
+
+// Store is a fixed-size ring buffer of snapshots, oldest first. It is safe for concurrent use.
+type Store struct {
+	mu        sync.RWMutex
+	snapshots []Snapshot
+	next      int // index the next snapshot is written to
+	full      bool
+}
+
+// NewStore creates a Store keeping the last capacity snapshots
+// (DefaultCapacity if capacity is not positive)
+func NewStore(capacity int) *Store {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Store{snapshots: make([]Snapshot, capacity)}
+}
+
+// Record captures the current state of registry
+func (s *Store) Record(registry metric.Registry) {
+	s.Add(Snapshot{Time: time.Now(), Series: delta.Collect(registry)})
+}
+
+// Add stores a snapshot, evicting the oldest one when the store is full.
+// Snapshots are expected in time order.
+func (s *Store) Add(snapshot Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots[s.next] = snapshot
+	s.next = (s.next + 1) % len(s.snapshots)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// Run records registry every interval until ctx is done
+func (s *Store) Run(ctx context.Context, registry metric.Registry, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.Record(registry)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Snapshots returns the stored snapshots, oldest first
+func (s *Store) Snapshots() []Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.full {
+		return append([]Snapshot(nil), s.snapshots[:s.next]...)
+	}
+	ordered := make([]Snapshot, 0, len(s.snapshots))
+	ordered = append(ordered, s.snapshots[s.next:]...)
+	return append(ordered, s.snapshots[:s.next]...)
+}
+
+// At returns the latest snapshot taken at or before t
+func (s *Store) At(t time.Time) (Snapshot, bool) {
+	snapshots := s.Snapshots()
+	i := sort.Search(len(snapshots), func(i int) bool {
+		return snapshots[i].Time.After(t)
+	})
+	if i == 0 {
+		return Snapshot{}, false
+	}
+	return snapshots[i-1], true
+}
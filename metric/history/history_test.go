@@ -0,0 +1,47 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestStoreKeepsLatestSnapshots(t *testing.T) {
+	store := NewStore(3)
+	base := time.Unix(1000, 0)
+	for i := 0; i < 5; i++ {
+		store.Add(Snapshot{Time: base.Add(time.Duration(i) * time.Second)})
+	}
+
+	snapshots := store.Snapshots()
+	if len(snapshots) != 3 {
+		t.Fatalf("Expected 3 snapshots, got %d", len(snapshots))
+	}
+	for i, snapshot := range snapshots {
+		if want := base.Add(time.Duration(i+2) * time.Second); !snapshot.Time.Equal(want) {
+			t.Errorf("Snapshot %d: expected time %v, got %v", i, want, snapshot.Time)
+		}
+	}
+
+	if _, ok := store.At(base.Add(time.Second)); ok {
+		t.Error("Expected no snapshot before the oldest one kept")
+	}
+	if snapshot, ok := store.At(base.Add(3500 * time.Millisecond)); !ok || !snapshot.Time.Equal(base.Add(3*time.Second)) {
+		t.Errorf("Expected the snapshot at 3s, got %v", snapshot.Time)
+	}
+}
+
+func TestStoreRecord(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	registry.Counter(metric.Options{Name: "jobs"}).Add(3)
+
+	store := NewStore(0)
+	store.Record(registry)
+
+	snapshot, ok := store.At(time.Now())
+	if !ok || len(snapshot.Series) != 1 || snapshot.Series[0].Value != 3 {
+		t.Errorf("Expected a snapshot holding jobs=3, got %+v", snapshot)
+	}
+}
@@ -0,0 +1,462 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/metric/delta"
+)
+
+// Result is one series produced by a query
+type Result struct {
+	// Name is the metric name, empty once the series has been aggregated
+	Name  string      `json:"name,omitempty"`
+	Tags  metric.Tags `json:"tags"`
+	Value float64     `json:"value"`
+}
+
+// Query evaluates a query against the snapshots held by the store as of time at.
+// The language is a small subset of PromQL:
+//
+//	http_requests_total{status="500"}         value of each matching series
+//	rate(http_requests_total[5m])             per-second increase over the window
+//	sum(expr), sum by (route, status) (expr)  total, optionally grouped by tags
+//	quantile(0.95, latency)                   quantile of a histogram or timer
+//	quantile(0.95, latency[5m])               quantile of the observations made in the window
+//
+// Histograms and timers select their observation count. Results are sorted by tags.
+func (s *Store) Query(query string, at time.Time) ([]Result, error) {
+	p := &parser{input: query}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+
+	results, err := expr.eval(s, at)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(results, func(a, b Result) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(tagKey(a.Tags), tagKey(b.Tags))
+	})
+	return results, nil
+}
+
+// ErrNoHistory is returned when a range query reaches back before the oldest stored snapshot
+var ErrNoHistory = errors.New("history: no snapshot covers the start of the window")
+
+// expr is a parsed query expression
+type expr interface {
+	eval(s *Store, at time.Time) ([]Result, error)
+}
+
+// selector picks series by name and exact tag values, optionally over a window
+type selector struct {
+	name     string
+	matchers metric.Tags
+	window   time.Duration
+}
+
+func (sel selector) matches(series delta.Series) bool {
+	if series.Name != sel.name {
+		return false
+	}
+	for k, v := range sel.matchers {
+		if series.Tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// selectAt returns the matching series of the latest snapshot at or before at, keyed by series key
+func (sel selector) selectAt(s *Store, at time.Time) (map[string]delta.Series, bool) {
+	snapshot, ok := s.At(at)
+	if !ok {
+		return nil, false
+	}
+	selected := make(map[string]delta.Series)
+	for _, series := range snapshot.Series {
+		if sel.matches(series) {
+			selected[series.Key()] = series
+		}
+	}
+	return selected, true
+}
+
+func (sel selector) eval(s *Store, at time.Time) ([]Result, error) {
+	if sel.window > 0 {
+		return nil, fmt.Errorf("query: range selector %s[%s] must be used inside rate or quantile", sel.name, sel.window)
+	}
+	selected, _ := sel.selectAt(s, at)
+	results := make([]Result, 0, len(selected))
+	for _, series := range selected {
+		results = append(results, Result{Name: series.Name, Tags: series.Tags, Value: seriesValue(series)})
+	}
+	return results, nil
+}
+
+// seriesValue is the value a series contributes to a query: the counter or
+// gauge value, or the observation count of a histogram or timer
+func seriesValue(series delta.Series) float64 {
+	if series.Histogram != nil {
+		return float64(series.Histogram.Count)
+	}
+	return series.Value
+}
+
+// window returns the matching series at the end and start of the selector's window
+func (sel selector) windowAt(s *Store, at time.Time) (end, start map[string]delta.Series, err error) {
+	start, ok := sel.selectAt(s, at.Add(-sel.window))
+	if !ok {
+		return nil, nil, ErrNoHistory
+	}
+	end, _ = sel.selectAt(s, at)
+	return end, start, nil
+}
+
+// rateExpr is rate(selector[window])
+type rateExpr struct {
+	sel selector
+}
+
+func (r rateExpr) eval(s *Store, at time.Time) ([]Result, error) {
+	end, start, err := r.sel.windowAt(s, at)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(end))
+	for key, series := range end {
+		increase := seriesValue(series)
+		if previous, ok := start[key]; ok && seriesValue(previous) <= increase {
+			increase -= seriesValue(previous)
+		}
+		// Otherwise the series is new or was reset within the window: count it all
+		results = append(results, Result{Name: series.Name, Tags: series.Tags, Value: increase / r.sel.window.Seconds()})
+	}
+	return results, nil
+}
+
+// quantileExpr is quantile(q, selector) or quantile(q, selector[window])
+type quantileExpr struct {
+	q   float64
+	sel selector
+}
+
+func (e quantileExpr) eval(s *Store, at time.Time) ([]Result, error) {
+	var end, start map[string]delta.Series
+	if e.sel.window > 0 {
+		var err error
+		if end, start, err = e.sel.windowAt(s, at); err != nil {
+			return nil, err
+		}
+	} else {
+		end, _ = e.sel.selectAt(s, at)
+	}
+
+	results := make([]Result, 0, len(end))
+	for key, series := range end {
+		if series.Histogram == nil {
+			continue
+		}
+		snapshot := *series.Histogram
+		if previous, ok := start[key]; ok && previous.Histogram != nil {
+			snapshot = subtractSnapshot(snapshot, *previous.Histogram)
+		}
+		results = append(results, Result{Name: series.Name, Tags: series.Tags, Value: snapshot.Quantile(e.q)})
+	}
+	return results, nil
+}
+
+// subtractSnapshot returns the observations in end that are not in start. The
+// window's min and max are unknown, so those of end bound the estimate.
+func subtractSnapshot(end, start metric.HistogramSnapshot) metric.HistogramSnapshot {
+	if start.Count > end.Count || len(start.Buckets) != len(end.Buckets) {
+		return end // reset within the window
+	}
+	diff := end
+	diff.Count = end.Count - start.Count
+	diff.Sum = end.Sum - start.Sum
+	diff.Buckets = make([]uint64, len(end.Buckets))
+	for i := range end.Buckets {
+		diff.Buckets[i] = end.Buckets[i] - start.Buckets[i]
+	}
+	return diff
+}
+
+// sumExpr is sum(expr) or sum by (tags) (expr)
+type sumExpr struct {
+	by   []string
+	expr expr
+}
+
+func (e sumExpr) eval(s *Store, at time.Time) ([]Result, error) {
+	inner, err := e.expr.eval(s, at)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*Result)
+	for _, r := range inner {
+		tags := make(metric.Tags, len(e.by))
+		for _, k := range e.by {
+			if v, ok := r.Tags[k]; ok {
+				tags[k] = v
+			}
+		}
+		key := tagKey(tags)
+		group, ok := groups[key]
+		if !ok {
+			group = &Result{Tags: tags}
+			groups[key] = group
+		}
+		group.Value += r.Value
+	}
+
+	results := make([]Result, 0, len(groups))
+	for _, group := range groups {
+		results = append(results, *group)
+	}
+	return results, nil
+}
+
+// tagKey renders tags in sorted order for grouping and sorting
+func tagKey(tags metric.Tags) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// parser is a recursive descent parser over the query string
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("query: at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek reports whether the next non-space character is c
+func (p *parser) peek(c byte) bool {
+	p.skipSpace()
+	return p.pos < len(p.input) && p.input[p.pos] == c
+}
+
+func (p *parser) expect(c byte) error {
+	if !p.peek(c) {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// ident reads a metric name, tag key or function name
+func (p *parser) ident() (string, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := rune(p.input[p.pos])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != ':' && c != '.' {
+			break
+		}
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.errorf("expected a name")
+	}
+	return p.input[start:p.pos], nil
+}
+
+// until reads up to, but not including, the next c
+func (p *parser) until(c byte) (string, error) {
+	end := strings.IndexByte(p.input[p.pos:], c)
+	if end < 0 {
+		return "", p.errorf("missing %q", c)
+	}
+	s := p.input[p.pos : p.pos+end]
+	p.pos += end
+	return strings.TrimSpace(s), nil
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case name == "rate" && p.peek('('):
+		return p.parseRate()
+	case name == "sum" && (p.peek('(') || strings.HasPrefix(p.input[p.pos:], "by")):
+		return p.parseSum()
+	case name == "quantile" && p.peek('('):
+		return p.parseQuantile()
+	}
+	return p.parseSelector(name)
+}
+
+func (p *parser) parseSelector(name string) (selector, error) {
+	sel := selector{name: name, matchers: metric.Tags{}}
+
+	if p.peek('{') {
+		p.pos++
+		for !p.peek('}') {
+			key, err := p.ident()
+			if err != nil {
+				return sel, err
+			}
+			if err := p.expect('='); err != nil {
+				return sel, err
+			}
+			if err := p.expect('"'); err != nil {
+				return sel, err
+			}
+			value, err := p.until('"')
+			if err != nil {
+				return sel, err
+			}
+			p.pos++
+			sel.matchers[key] = value
+			if !p.peek(',') {
+				break
+			}
+			p.pos++
+		}
+		if err := p.expect('}'); err != nil {
+			return sel, err
+		}
+	}
+
+	if p.peek('[') {
+		p.pos++
+		raw, err := p.until(']')
+		if err != nil {
+			return sel, err
+		}
+		p.pos++
+		window, err := time.ParseDuration(raw)
+		if err != nil || window <= 0 {
+			return sel, p.errorf("invalid window %q", raw)
+		}
+		sel.window = window
+	}
+	return sel, nil
+}
+
+func (p *parser) parseRate() (expr, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	sel, err := p.parseSelector(name)
+	if err != nil {
+		return nil, err
+	}
+	if sel.window == 0 {
+		return nil, p.errorf("rate needs a range selector such as %s[5m]", name)
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return rateExpr{sel: sel}, nil
+}
+
+func (p *parser) parseSum() (expr, error) {
+	var by []string
+	p.skipSpace()
+	if strings.HasPrefix(p.input[p.pos:], "by") {
+		p.pos += len("by")
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		for !p.peek(')') {
+			key, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			by = append(by, key)
+			if !p.peek(',') {
+				break
+			}
+			p.pos++
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	inner, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return sumExpr{by: by, expr: inner}, nil
+}
+
+func (p *parser) parseQuantile() (expr, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	raw, err := p.until(',')
+	if err != nil {
+		return nil, err
+	}
+	q, err := strconv.ParseFloat(raw, 64)
+	if err != nil || q < 0 || q > 1 {
+		return nil, p.errorf("quantile must be between 0 and 1, got %q", raw)
+	}
+	p.pos++
+
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	sel, err := p.parseSelector(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return quantileExpr{q: q, sel: sel}, nil
+}
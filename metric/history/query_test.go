@@ -0,0 +1,165 @@
+package history
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/metric/delta"
+)
+
+// testStore holds request counters and a latency histogram one minute apart
+func testStore(start time.Time) *Store {
+	counter := func(route, status string, value float64) delta.Series {
+		return delta.Series{
+			Name:  "requests_total",
+			Type:  metric.TypeCounter,
+			Tags:  metric.Tags{"route": route, "status": status},
+			Value: value,
+		}
+	}
+	latency := func(buckets ...uint64) delta.Series {
+		var count uint64
+		for _, b := range buckets {
+			count += b
+		}
+		return delta.Series{
+			Name: "latency",
+			Type: metric.TypeHistogram,
+			Histogram: &metric.HistogramSnapshot{
+				Count:      count,
+				Min:        0,
+				Max:        1000,
+				Buckets:    buckets,
+				Boundaries: []float64{10, 100},
+			},
+		}
+	}
+
+	store := NewStore(10)
+	store.Add(Snapshot{Time: start, Series: []delta.Series{
+		counter("/a", "200", 100),
+		counter("/a", "500", 10),
+		counter("/b", "200", 50),
+		latency(10, 0, 0),
+	}})
+	store.Add(Snapshot{Time: start.Add(time.Minute), Series: []delta.Series{
+		counter("/a", "200", 160),
+		counter("/a", "500", 40),
+		counter("/b", "200", 5), // reset
+		latency(10, 100, 0),
+	}})
+	return store
+}
+
+func TestQuery(t *testing.T) {
+	start := time.Date(2024, 5, 1, 14, 31, 0, 0, time.UTC)
+	store := testStore(start)
+	at := start.Add(time.Minute)
+
+	tests := []struct {
+		query    string
+		expected []Result
+	}{
+		{
+			query: `requests_total{status="500"}`,
+			expected: []Result{
+				{Name: "requests_total", Tags: metric.Tags{"route": "/a", "status": "500"}, Value: 40},
+			},
+		},
+		{
+			query: `sum by (status) (rate(requests_total[1m]))`,
+			expected: []Result{
+				{Tags: metric.Tags{"status": "200"}, Value: (60 + 5) / 60.0},
+				{Tags: metric.Tags{"status": "500"}, Value: 30 / 60.0},
+			},
+		},
+		{
+			query:    `sum(requests_total)`,
+			expected: []Result{{Tags: metric.Tags{}, Value: 205}},
+		},
+		{
+			// The window holds only the 100 observations in the second bucket
+			query:    `quantile(0.5, latency[1m])`,
+			expected: []Result{{Name: "latency", Tags: metric.Tags{}, Value: 55}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := store.Query(tt.query, at)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(results) != len(tt.expected) {
+				t.Fatalf("Expected %d results, got %+v", len(tt.expected), results)
+			}
+			for i, want := range tt.expected {
+				got := results[i]
+				if got.Name != want.Name || tagKey(got.Tags) != tagKey(want.Tags) || math.Abs(got.Value-want.Value) > 1e-9 {
+					t.Errorf("Result %d: expected %+v, got %+v", i, want, got)
+				}
+			}
+		})
+	}
+
+	// Queries can look back to an earlier time
+	results, err := store.Query(`sum(requests_total)`, start.Add(30*time.Second))
+	if err != nil || len(results) != 1 || results[0].Value != 160 {
+		t.Errorf("Expected the total of the first snapshot, got %+v (%v)", results, err)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	start := time.Unix(1000, 0)
+	store := testStore(start)
+
+	for _, query := range []string{
+		``,
+		`rate(requests_total)`,
+		`requests_total[1m]`,
+		`quantile(2, latency)`,
+		`requests_total{status="500"`,
+		`sum by (status) requests_total`,
+		`requests_total extra`,
+	} {
+		if _, err := store.Query(query, start.Add(time.Minute)); err == nil {
+			t.Errorf("Expected %q to fail", query)
+		}
+	}
+
+	if _, err := store.Query(`rate(requests_total[5m])`, start.Add(time.Minute)); err != ErrNoHistory {
+		t.Errorf("Expected ErrNoHistory for a window beyond the stored snapshots, got %v", err)
+	}
+}
+
+func TestQueryHandler(t *testing.T) {
+	start := time.Date(2024, 5, 1, 14, 31, 0, 0, time.UTC)
+	handler := QueryHandler(testStore(start))
+
+	query := url.Values{
+		"query": {`sum(rate(requests_total{route="/a"}[1m]))`},
+		"time":  {"2024-05-01T14:32:00Z"},
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/query?"+query.Encode(), nil))
+
+	var response queryResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if recorder.Code != http.StatusOK || len(response.Results) != 1 || response.Results[0].Value != 1.5 {
+		t.Errorf("Expected an error rate of 1.5/s, got %d %+v", recorder.Code, response)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/query?query=rate(", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid query, got %d", recorder.Code)
+	}
+}
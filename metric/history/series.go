@@ -0,0 +1,74 @@
+package history
+
+import (
+	"maps"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// Point is the value of one series in one snapshot
+type Point struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Range returns the values of the series of name whose tags equal tags in the
+// snapshots taken from from to to inclusive, oldest first. Histograms and
+// timers contribute their observation count. Snapshots missing the series
+// are skipped.
+func (s *Store) Range(name string, tags metric.Tags, from, to time.Time) []Point {
+	var points []Point
+	for _, snapshot := range s.Snapshots() {
+		if snapshot.Time.Before(from) || snapshot.Time.After(to) {
+			continue
+		}
+		if value, ok := valueIn(snapshot, name, tags); ok {
+			points = append(points, Point{Time: snapshot.Time, Value: value})
+		}
+	}
+	return points
+}
+
+// Rate returns the per-second increase of the series of name with tags over
+// the window ending at at, as rate(name{tags}[window]) does in Query. A series
+// that is new or was reset within the window counts its whole value.
+func (s *Store) Rate(name string, tags metric.Tags, window time.Duration, at time.Time) (float64, error) {
+	start, ok := s.At(at.Add(-window))
+	if !ok {
+		return 0, ErrNoHistory
+	}
+	end, _ := s.At(at)
+
+	increase, _ := valueIn(end, name, tags)
+	if previous, ok := valueIn(start, name, tags); ok && previous <= increase {
+		increase -= previous
+	}
+	return increase / window.Seconds(), nil
+}
+
+// MovingAverage returns the mean value of the series of name with tags across
+// the snapshots taken in the window ending at at, e.g. to compare a gauge
+// against its recent level in an anomaly check. It returns ErrNoHistory if no
+// snapshot in the window holds the series.
+func (s *Store) MovingAverage(name string, tags metric.Tags, window time.Duration, at time.Time) (float64, error) {
+	points := s.Range(name, tags, at.Add(-window), at)
+	if len(points) == 0 {
+		return 0, ErrNoHistory
+	}
+	var sum float64
+	for _, p := range points {
+		sum += p.Value
+	}
+	return sum / float64(len(points)), nil
+}
+
+// valueIn returns the value of the series of name with exactly tags in snapshot
+func valueIn(snapshot Snapshot, name string, tags metric.Tags) (float64, bool) {
+	for _, series := range snapshot.Series {
+		if series.Name == name && maps.Equal(series.Tags, tags) {
+			return seriesValue(series), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,50 @@
+package history
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/metric/delta"
+)
+
+func TestSeriesQueries(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	store := testStore(start)
+	okTags := metric.Tags{"route": "/a", "status": "200"}
+
+	points := store.Range("requests_total", okTags, start, start.Add(time.Minute))
+	if len(points) != 2 || points[0].Value != 100 || points[1].Value != 160 || !points[1].Time.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected points 100 then 160, got %+v", points)
+	}
+	if points := store.Range("requests_total", metric.Tags{"route": "/a"}, start, start.Add(time.Minute)); len(points) != 0 {
+		t.Errorf("Expected tags to match exactly, got %+v", points)
+	}
+
+	rate, err := store.Rate("requests_total", okTags, time.Minute, start.Add(time.Minute))
+	if err != nil || rate != 1 {
+		t.Errorf("Expected rate 1/s, got %g (%v)", rate, err)
+	}
+	// Reset within the window counts the whole value
+	rate, err = store.Rate("requests_total", metric.Tags{"route": "/b", "status": "200"}, time.Minute, start.Add(time.Minute))
+	if err != nil || rate != 5.0/60 {
+		t.Errorf("Expected rate 5/60 after reset, got %g (%v)", rate, err)
+	}
+	if _, err := store.Rate("requests_total", okTags, time.Hour, start.Add(time.Minute)); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("Expected ErrNoHistory for a window before the oldest snapshot, got %v", err)
+	}
+
+	avg, err := store.MovingAverage("latency", nil, time.Minute, start.Add(time.Minute))
+	if err != nil || avg != 60 {
+		t.Errorf("Expected average latency count 60, got %g (%v)", avg, err)
+	}
+	if _, err := store.MovingAverage("missing", nil, time.Minute, start.Add(time.Minute)); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("Expected ErrNoHistory for an unknown series, got %v", err)
+	}
+
+	store.Add(Snapshot{Time: start.Add(2 * time.Minute), Series: []delta.Series{{Name: "latency", Type: metric.TypeHistogram, Histogram: &metric.HistogramSnapshot{Count: 150}}}})
+	if avg, _ := store.MovingAverage("latency", nil, 90*time.Second, start.Add(2*time.Minute)); avg != 130 {
+		t.Errorf("Expected the window to drop the oldest snapshot, got %g", avg)
+	}
+}
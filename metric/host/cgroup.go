@@ -0,0 +1,110 @@
+package host
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// unlimitedMemory is the smallest cgroup v1 memory limit treated as no limit;
+// the kernel reports an unset limit as a page-aligned value near 2^63
+const unlimitedMemory = 1 << 62
+
+// containerLimits are the CPU and memory limits of the process's cgroup
+type containerLimits struct {
+	cgroupVersion int
+	cpu           float64 // cores; 0 when unlimited or unknown
+	memory        int64   // bytes; 0 when unlimited or unknown
+}
+
+// readContainerLimits reads the cgroup v2 or v1 limits from fsys, a view of
+// the root file system. Nothing is found outside Linux.
+func readContainerLimits(fsys fs.FS) containerLimits {
+	if _, err := fs.Stat(fsys, "sys/fs/cgroup/cgroup.controllers"); err == nil {
+		return readCgroupV2(fsys)
+	}
+	for _, controller := range []string{"cpu", "memory"} {
+		if _, err := fs.Stat(fsys, path.Join("sys/fs/cgroup", controller)); err == nil {
+			return readCgroupV1(fsys)
+		}
+	}
+	return containerLimits{}
+}
+
+// readCgroupV2 reads cpu.max and memory.max of the process's cgroup, falling
+// back to the root of the hierarchy, which is the container's own cgroup when
+// it runs in a cgroup namespace
+func readCgroupV2(fsys fs.FS) containerLimits {
+	limits := containerLimits{cgroupVersion: 2}
+	dirs := []string{"sys/fs/cgroup"}
+	if group := unifiedCgroupPath(fsys); group != "" && group != "/" {
+		dirs = append([]string{path.Join("sys/fs/cgroup", group)}, dirs...)
+	}
+
+	for _, dir := range dirs {
+		data, err := fs.ReadFile(fsys, path.Join(dir, "cpu.max"))
+		if err != nil {
+			continue
+		}
+		quota, period, ok := strings.Cut(strings.TrimSpace(string(data)), " ")
+		if q, err := strconv.ParseFloat(quota, 64); ok && err == nil {
+			if p, err := strconv.ParseFloat(period, 64); err == nil && p > 0 {
+				limits.cpu = q / p
+			}
+		}
+		break
+	}
+	for _, dir := range dirs {
+		data, err := fs.ReadFile(fsys, path.Join(dir, "memory.max"))
+		if err != nil {
+			continue
+		}
+		if memory, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err == nil {
+			limits.memory = memory
+		}
+		break
+	}
+	return limits
+}
+
+// readCgroupV1 reads the CFS quota and the memory limit of the cpu and memory hierarchies
+func readCgroupV1(fsys fs.FS) containerLimits {
+	limits := containerLimits{cgroupVersion: 1}
+	quota, qerr := readInt(fsys, "sys/fs/cgroup/cpu/cpu.cfs_quota_us")
+	period, perr := readInt(fsys, "sys/fs/cgroup/cpu/cpu.cfs_period_us")
+	if qerr == nil && perr == nil && quota > 0 && period > 0 {
+		limits.cpu = float64(quota) / float64(period)
+	}
+	if memory, err := readInt(fsys, "sys/fs/cgroup/memory/memory.limit_in_bytes"); err == nil && memory > 0 && memory < unlimitedMemory {
+		limits.memory = memory
+	}
+	return limits
+}
+
+// unifiedCgroupPath returns the path of the process's cgroup in the cgroup v2 hierarchy
+func unifiedCgroupPath(fsys fs.FS) string {
+	data, err := fs.ReadFile(fsys, "proc/self/cgroup")
+	if err != nil {
+		return ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path, with ID 0 and no controllers for v2
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) == 3 && fields[0] == "0" && fields[1] == "" {
+			return fields[2]
+		}
+	}
+	return ""
+}
+
+func readInt(fsys fs.FS, name string) (int64, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
@@ -0,0 +1,66 @@
+package host
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadContainerLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want containerLimits
+	}{
+		{
+			name: "cgroup v2 namespaced",
+			fsys: fstest.MapFS{
+				"sys/fs/cgroup/cgroup.controllers": {Data: []byte("cpu memory")},
+				"sys/fs/cgroup/cpu.max":            {Data: []byte("150000 100000\n")},
+				"sys/fs/cgroup/memory.max":         {Data: []byte("536870912\n")},
+				"proc/self/cgroup":                 {Data: []byte("0::/\n")},
+			},
+			want: containerLimits{cgroupVersion: 2, cpu: 1.5, memory: 536870912},
+		},
+		{
+			name: "cgroup v2 nested group",
+			fsys: fstest.MapFS{
+				"sys/fs/cgroup/cgroup.controllers":       {Data: []byte("cpu memory")},
+				"sys/fs/cgroup/cpu.max":                  {Data: []byte("max 100000\n")},
+				"sys/fs/cgroup/kubepods/pod1/cpu.max":    {Data: []byte("50000 100000\n")},
+				"sys/fs/cgroup/kubepods/pod1/memory.max": {Data: []byte("max\n")},
+				"proc/self/cgroup":                       {Data: []byte("0::/kubepods/pod1\n")},
+			},
+			want: containerLimits{cgroupVersion: 2, cpu: 0.5},
+		},
+		{
+			name: "cgroup v1",
+			fsys: fstest.MapFS{
+				"sys/fs/cgroup/cpu/cpu.cfs_quota_us":         {Data: []byte("200000\n")},
+				"sys/fs/cgroup/cpu/cpu.cfs_period_us":        {Data: []byte("100000\n")},
+				"sys/fs/cgroup/memory/memory.limit_in_bytes": {Data: []byte("1073741824\n")},
+			},
+			want: containerLimits{cgroupVersion: 1, cpu: 2, memory: 1073741824},
+		},
+		{
+			name: "cgroup v1 unlimited",
+			fsys: fstest.MapFS{
+				"sys/fs/cgroup/cpu/cpu.cfs_quota_us":         {Data: []byte("-1\n")},
+				"sys/fs/cgroup/cpu/cpu.cfs_period_us":        {Data: []byte("100000\n")},
+				"sys/fs/cgroup/memory/memory.limit_in_bytes": {Data: []byte("9223372036854771712\n")},
+			},
+			want: containerLimits{cgroupVersion: 1},
+		},
+		{
+			name: "no cgroups",
+			fsys: fstest.MapFS{},
+			want: containerLimits{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readContainerLimits(tt.fsys); got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
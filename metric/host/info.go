@@ -10,13 +10,25 @@ import (
 	"github.com/MichaelAJay/go-metrics/metric"
 )
 
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityCollector, Name: "host", Package: "github.com/MichaelAJay/go-metrics/metric/host"})
+}
+
 // Info represents host and container information
 type Info struct {
-	Hostname      string
-	OS            string
-	Architecture  string
-	CPUCores      int
-	ContainerID   string
+	Hostname     string
+	OS           string
+	Architecture string
+	// OSVersion is the kernel release on Unix and the edition and build on Windows
+	OSVersion   string
+	CPUCores    int
+	ContainerID string
+	// CgroupVersion is 1 or 2 when cgroup limits were read, 0 otherwise
+	CgroupVersion int
+	// CPULimit is the CPU quota of the container in cores, 0 if unlimited or unknown
+	CPULimit float64
+	// MemoryLimit is the memory limit of the container in bytes, 0 if unlimited or unknown
+	MemoryLimit   int64
 	KubeNode      string
 	KubePod       string
 	KubeNamespace string
@@ -36,6 +48,7 @@ func NewInfo() (*Info, error) {
 		Hostname:     hostname,
 		OS:           runtime.GOOS,
 		Architecture: runtime.GOARCH,
+		OSVersion:    osVersion(),
 		CPUCores:     runtime.NumCPU(),
 		Environment:  getEnv("ENVIRONMENT", "development"),
 		Region:       getEnv("REGION", ""),
@@ -44,6 +57,7 @@ func NewInfo() (*Info, error) {
 
 	// Try to detect container environment
 	info.detectContainer()
+	info.detectLimits()
 	info.detectKubernetes()
 
 	return info, nil
@@ -59,6 +73,9 @@ func (i *Info) AsMetricTags() metric.Tags {
 	}
 
 	// Only add non-empty values
+	if i.OSVersion != "" {
+		tags["os_version"] = i.OSVersion
+	}
 	if i.ContainerID != "" {
 		tags["container_id"] = i.ContainerID
 	}
@@ -111,14 +128,39 @@ func (i *Info) detectContainer() {
 			i.ContainerID = "docker-container"
 		}
 	}
+
+	// Windows containers have no cgroups; their registry marks them instead
+	if i.ContainerID == "" && windowsContainer() {
+		i.ContainerID = "windows-container"
+	}
 }
 
+// detectLimits reads the CPU and memory limits of the container from its cgroup
+func (i *Info) detectLimits() {
+	limits := readContainerLimits(os.DirFS("/"))
+	i.CgroupVersion = limits.cgroupVersion
+	i.CPULimit = limits.cpu
+	i.MemoryLimit = limits.memory
+}
+
+// serviceAccountNamespace is the file Kubernetes mounts into every pod with
+// a service account token, holding the pod's namespace
+const serviceAccountNamespace = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
 // detectKubernetes attempts to detect if running in Kubernetes
 func (i *Info) detectKubernetes() {
-	// In Kubernetes, these are usually set as environment variables
-	i.KubeNode = getEnv("NODE_NAME", "")
-	i.KubePod = getEnv("POD_NAME", "")
-	i.KubeNamespace = getEnv("POD_NAMESPACE", "")
+	// In Kubernetes, these are usually set as environment variables from the
+	// downward API (fieldRef spec.nodeName, metadata.name, metadata.namespace)
+	i.KubeNode = firstEnv("NODE_NAME", "KUBE_NODE_NAME", "K8S_NODE_NAME")
+	i.KubePod = firstEnv("POD_NAME", "KUBE_POD_NAME", "K8S_POD_NAME")
+	i.KubeNamespace = firstEnv("POD_NAMESPACE", "KUBE_NAMESPACE", "K8S_NAMESPACE")
+
+	// The namespace is also mounted with the service account token
+	if i.KubeNamespace == "" && os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		if data, err := os.ReadFile(serviceAccountNamespace); err == nil {
+			i.KubeNamespace = strings.TrimSpace(string(data))
+		}
+	}
 
 	// If not explicitly set, try to get pod name from hostname
 	// Kubernetes sets the hostname to the pod name by default
@@ -149,11 +191,37 @@ func InjectHostInfo(registry metric.Registry) error {
 	// Set to 1 to indicate the service is up
 	gauge.Set(1)
 
+	// Container limits are separate gauges so they can be compared with usage
+	if info.CPULimit > 0 {
+		registry.Gauge(metric.Options{
+			Name:        "container_cpu_limit_millicores",
+			Description: "CPU quota of the container's cgroup",
+			Unit:        "millicores",
+		}).Set(info.CPULimit * 1000)
+	}
+	if info.MemoryLimit > 0 {
+		registry.Gauge(metric.Options{
+			Name:        "container_memory_limit_bytes",
+			Description: "Memory limit of the container's cgroup",
+			Unit:        "bytes",
+		}).Set(float64(info.MemoryLimit))
+	}
+
 	return nil
 }
 
 // Helper functions
 
+// firstEnv returns the value of the first of the variables that is set
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value, exists := os.LookupEnv(key); exists {
+			return value
+		}
+	}
+	return ""
+}
+
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -0,0 +1,11 @@
+//go:build !unix && !windows
+
+package host
+
+func osVersion() string {
+	return ""
+}
+
+func windowsContainer() bool {
+	return false
+}
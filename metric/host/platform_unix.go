@@ -0,0 +1,18 @@
+//go:build unix
+
+package host
+
+import "golang.org/x/sys/unix"
+
+// osVersion returns the kernel release, e.g. "6.8.0-45-generic"
+func osVersion() string {
+	var uts unix.Utsname
+	if err := unix.Uname(&uts); err != nil {
+		return ""
+	}
+	return unix.ByteSliceToString(uts.Release[:])
+}
+
+func windowsContainer() bool {
+	return false
+}
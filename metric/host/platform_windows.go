@@ -0,0 +1,42 @@
+//go:build windows
+
+package host
+
+import (
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// osVersion returns the Windows edition, release and build, e.g.
+// "Windows Server 2022 Datacenter 21H2 (build 20348)", from the registry
+func osVersion() string {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Windows NT\CurrentVersion`, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	defer key.Close()
+
+	var parts []string
+	for _, name := range []string{"ProductName", "DisplayVersion"} {
+		if value, _, err := key.GetStringValue(name); err == nil && value != "" {
+			parts = append(parts, value)
+		}
+	}
+	if build, _, err := key.GetStringValue("CurrentBuildNumber"); err == nil && build != "" {
+		parts = append(parts, "(build "+build+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+// windowsContainer reports whether the process runs in a Windows container,
+// whose base images set the ContainerType value under the control key
+func windowsContainer() bool {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Control`, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	defer key.Close()
+	_, _, err = key.GetIntegerValue("ContainerType")
+	return err == nil
+}
@@ -0,0 +1,108 @@
+package metric
+
+import (
+	"strings"
+	"sync"
+)
+
+// InternStats describes the table tag strings are interned in
+type InternStats struct {
+	// Strings is the number of distinct tag keys and values held
+	Strings int
+	// Bytes is the size of the strings held
+	Bytes int
+	// Hits and Misses count the tag strings of new series that were already
+	// held, and those that were not
+	Hits   uint64
+	Misses uint64
+}
+
+// interned is the intern table shared by every registry, so a tag string such as
+// "success" or "us-east" is stored once however many series and registries use it
+var interned = &internTable{strings: make(map[string]*internedString)}
+
+// Interned returns the current state of the tag string intern table
+func Interned() InternStats {
+	return interned.stats()
+}
+
+// internTable deduplicates the tag keys and values of registered series. Each
+// string is reference counted by the series holding it and dropped with the last
+// of them, when they are removed or their registry is closed, so expired series
+// do not leave their tag values behind.
+type internTable struct {
+	mu      sync.Mutex
+	strings map[string]*internedString
+	bytes   int
+	hits    uint64
+	misses  uint64
+}
+
+// internedString is a held string and the number of series holding it
+type internedString struct {
+	value string
+	refs  int
+}
+
+// intern returns a copy of tags using the held copy of every key and value,
+// and adds a reference to each of them for the series the tags belong to
+func (t *internTable) intern(tags Tags) Tags {
+	if len(tags) == 0 {
+		return tags
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make(Tags, len(tags))
+	for k, v := range tags {
+		result[t.hold(k)] = t.hold(v)
+	}
+	return result
+}
+
+// hold adds a reference to s and returns the held copy. The caller must hold t.mu.
+func (t *internTable) hold(s string) string {
+	if held, ok := t.strings[s]; ok {
+		t.hits++
+		held.refs++
+		return held.value
+	}
+	t.misses++
+	// A clone, so a tag cut from a larger buffer such as a request does not keep it alive
+	s = strings.Clone(s)
+	t.strings[s] = &internedString{value: s, refs: 1}
+	t.bytes += len(s)
+	return s
+}
+
+// release drops the references intern added for tags
+func (t *internTable) release(tags Tags) {
+	if len(tags) == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for k, v := range tags {
+		t.drop(k)
+		t.drop(v)
+	}
+}
+
+// drop removes a reference to s. The caller must hold t.mu.
+func (t *internTable) drop(s string) {
+	held, ok := t.strings[s]
+	if !ok {
+		return
+	}
+	if held.refs--; held.refs <= 0 {
+		delete(t.strings, s)
+		t.bytes -= len(s)
+	}
+}
+
+func (t *internTable) stats() InternStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return InternStats{Strings: len(t.strings), Bytes: t.bytes, Hits: t.hits, Misses: t.misses}
+}
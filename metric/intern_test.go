@@ -0,0 +1,64 @@
+package metric
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestInternSharesTagStrings(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	// Built at run time, so each series gets its own copy of the value
+	region := func() string { return strings.Repeat("intern-region-", 2) }
+	a := registry.Counter(Options{Name: "intern_a", Tags: Tags{"region": region()}})
+	b := registry.Gauge(Options{Name: "intern_b", Tags: Tags{"region": region()}})
+	c := registry.Histogram(Options{Name: "intern_c"}).With(Tags{"region": region()})
+
+	data := func(m Metric) *byte { return unsafe.StringData(m.Tags()["region"]) }
+	if data(a) != data(b) || data(a) != data(c) {
+		t.Error("Expected the series to share one copy of the tag value")
+	}
+
+	held := func() int {
+		interned.mu.Lock()
+		defer interned.mu.Unlock()
+		if s, ok := interned.strings[region()]; ok {
+			return s.refs
+		}
+		return 0
+	}
+	if held() != 3 {
+		t.Errorf("Expected the value to be held by 3 series, got %d", held())
+	}
+
+	registry.Unregister("intern_a")
+	registry.Unregister("intern_c")
+	if held() != 1 {
+		t.Errorf("Expected the value to be held by 1 series, got %d", held())
+	}
+	registry.Unregister("intern_b")
+	if held() != 0 {
+		t.Errorf("Expected the value to be dropped with its last series, got %d references", held())
+	}
+}
+
+func TestInternMetaMetrics(t *testing.T) {
+	registry := NewRegistry(DefaultTagValidationConfig(), 0, WithMetaMetrics())
+	defer registry.Close()
+
+	registry.Counter(Options{Name: "intern_meta", Tags: Tags{"status": "intern-meta-ok"}})
+	registry.Counter(Options{Name: "intern_meta_other", Tags: Tags{"status": "intern-meta-ok"}})
+
+	values := metaValues(registry)
+	if values[MetaMetricPrefix+"intern_lookups_total{result=miss}"] < 2 {
+		t.Errorf("Expected the first series' tag strings to miss, got %v", values)
+	}
+	if values[MetaMetricPrefix+"intern_lookups_total{result=hit}"] < 2 {
+		t.Errorf("Expected the second series' tag strings to hit, got %v", values)
+	}
+	if values[MetaMetricPrefix+"interned_strings{}"] < 2 || values[MetaMetricPrefix+"interned_bytes{}"] < float64(len("status")+len("intern-meta-ok")) {
+		t.Errorf("Expected the held strings to be reported, got %v", values)
+	}
+}
@@ -0,0 +1,64 @@
+package metric
+
+import (
+	"testing"
+)
+
+// BenchmarkRegistryCachedLookup measures retrieving an existing series, where
+// building the series key is the main cost
+func BenchmarkRegistryCachedLookup(b *testing.B) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	untagged := Options{Name: "benchmark_lookup_untagged"}
+	tagged := Options{
+		Name: "benchmark_lookup_tagged",
+		Tags: Tags{"method": "GET", "route": "/api/users", "status": "200"},
+	}
+	registry.Counter(untagged)
+	registry.Counter(tagged)
+
+	b.Run("untagged", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			registry.Counter(untagged).Inc()
+		}
+	})
+
+	b.Run("tagged", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			registry.Counter(tagged).Inc()
+		}
+	})
+}
+
+// BenchmarkRegistryCachedLookupParallel measures retrieving existing series from
+// many goroutines at once, where lock contention on the registry would show
+func BenchmarkRegistryCachedLookupParallel(b *testing.B) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	opts := Options{
+		Name: "benchmark_lookup_parallel",
+		Tags: Tags{"method": "GET", "route": "/api/users", "status": "200"},
+	}
+	registry.Counter(opts)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			registry.Counter(opts).Inc()
+		}
+	})
+}
+
+// BenchmarkSeriesKey measures building a series key alone
+func BenchmarkSeriesKey(b *testing.B) {
+	tags := Tags{"method": "GET", "route": "/api/users", "status": "200"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = seriesKey(TypeCounter, "benchmark_key", tags)
+	}
+}
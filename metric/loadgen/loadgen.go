@@ -0,0 +1,248 @@
+// Package loadgen drives a function at a target request rate while recording
+// the achieved rate, latency, and errors into a metric registry
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "loadgen", Package: "github.com/MichaelAJay/go-metrics/metric/loadgen"})
+}
+
+// DefaultWorkers is the worker pool size used when Config.Workers is not set
+const DefaultWorkers = 10
+
+// DefaultName is the metric name prefix used when Config.Name is not set
+const DefaultName = "loadgen"
+
+// rateInterval is how often the achieved rate gauge is refreshed
+const rateInterval = time.Second
+
+// minTick is the finest pacing interval; higher rates are issued in batches per tick
+const minTick = time.Millisecond
+
+// Func is the unit of work driven by the generator. A non-nil error counts as a failed request.
+type Func func(ctx context.Context) error
+
+// Config contains configuration for a load generator
+type Config struct {
+	// Name is the prefix for the recorded metrics (default "loadgen")
+	Name string
+	// RPS is the target number of calls per second
+	RPS float64
+	// Workers is the number of concurrent workers executing calls (default 10)
+	Workers int
+	// Duration bounds the run; if zero, the run lasts until the context is done
+	Duration time.Duration
+	// Tags are added to every recorded metric
+	Tags metric.Tags
+}
+
+// Result summarizes a completed run
+type Result struct {
+	// Requests is the number of calls that completed
+	Requests uint64
+	// Errors is the number of calls that returned an error
+	Errors uint64
+	// Dropped is the number of scheduled calls skipped because the worker pool
+	// was saturated, or still queued when the run ended
+	Dropped uint64
+	// Elapsed is the wall-clock duration of the run
+	Elapsed time.Duration
+	// AchievedRPS is Requests divided by Elapsed
+	AchievedRPS float64
+}
+
+// Generator issues calls at a fixed rate over a worker pool
+type Generator struct {
+	config Config
+
+	requests   metric.Counter
+	errors     metric.Counter
+	dropped    metric.Counter
+	latency    metric.Timer
+	inFlight   metric.Gauge
+	targetRate *rateGauge
+	achieved   *rateGauge
+}
+
+// rateGauge holds a call rate. Registries implementing metric.InstrumentRegistry
+// export it unrounded through a GaugeFunc; others get a gauge of the rate
+// rounded to a whole number.
+type rateGauge struct {
+	bits  atomic.Uint64
+	gauge metric.Gauge // nil when exported through a GaugeFunc
+}
+
+func newRateGauge(registry metric.Registry, opts metric.Options) *rateGauge {
+	r := &rateGauge{}
+	if instruments, ok := registry.(metric.InstrumentRegistry); ok {
+		instruments.GaugeFunc(opts, r.Value)
+	} else {
+		r.gauge = registry.Gauge(opts)
+	}
+	return r
+}
+
+// Set stores the rate in calls per second
+func (r *rateGauge) Set(rate float64) {
+	r.bits.Store(math.Float64bits(rate))
+	if r.gauge != nil {
+		r.gauge.Set(math.Round(rate))
+	}
+}
+
+// Value returns the rate in calls per second
+func (r *rateGauge) Value() float64 {
+	return math.Float64frombits(r.bits.Load())
+}
+
+// New creates a Generator that records into registry. The recorded metrics are:
+//
+//	<name>_requests_total, <name>_errors_total, <name>_dropped_total (counters)
+//	<name>_latency (timer)
+//	<name>_in_flight, <name>_target_rps, <name>_achieved_rps (gauges)
+func New(registry metric.Registry, config Config) (*Generator, error) {
+	if config.RPS <= 0 {
+		return nil, errors.New("loadgen: RPS must be positive")
+	}
+	if config.Workers <= 0 {
+		config.Workers = DefaultWorkers
+	}
+	if config.Name == "" {
+		config.Name = DefaultName
+	}
+
+	opts := func(suffix, description, unit string) metric.Options {
+		return metric.Options{
+			Name:        config.Name + "_" + suffix,
+			Description: description,
+			Unit:        unit,
+			Tags:        config.Tags,
+		}
+	}
+
+	return &Generator{
+		config:     config,
+		requests:   registry.Counter(opts("requests_total", "Calls completed by the load generator", "count")),
+		errors:     registry.Counter(opts("errors_total", "Calls that returned an error", "count")),
+		dropped:    registry.Counter(opts("dropped_total", "Scheduled calls skipped because the worker pool was saturated", "count")),
+		latency:    registry.Timer(opts("latency", "Latency of calls issued by the load generator", "nanoseconds")),
+		inFlight:   registry.Gauge(opts("in_flight", "Calls currently executing", "count")),
+		targetRate: newRateGauge(registry, opts("target_rps", "Target call rate", "requests_per_second")),
+		achieved:   newRateGauge(registry, opts("achieved_rps", "Call rate achieved over the last second", "requests_per_second")),
+	}, nil
+}
+
+// Run calls fn at the configured rate until the configured duration elapses or
+// ctx is done, then waits for in-flight calls to finish. At most one pending
+// call per worker is queued; beyond that scheduled calls are dropped, so the
+// schedule is never delayed by a slow fn. Calls still queued when the run ends
+// are dropped too rather than made with a done context.
+func (g *Generator) Run(ctx context.Context, fn Func) (Result, error) {
+	if fn == nil {
+		return Result{}, errors.New("loadgen: fn must not be nil")
+	}
+
+	if g.config.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.config.Duration)
+		defer cancel()
+	}
+
+	g.targetRate.Set(g.config.RPS)
+
+	var (
+		completed atomic.Uint64
+		failed    atomic.Uint64
+		dropped   atomic.Uint64
+		wg        sync.WaitGroup
+		jobs      = make(chan struct{}, g.config.Workers)
+	)
+
+	for i := 0; i < g.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				if ctx.Err() != nil {
+					dropped.Add(1)
+					g.dropped.Inc()
+					continue
+				}
+				g.inFlight.Inc()
+				start := time.Now()
+				err := fn(ctx)
+				g.latency.RecordSince(start)
+				g.inFlight.Dec()
+
+				g.requests.Inc()
+				completed.Add(1)
+				if err != nil {
+					g.errors.Inc()
+					failed.Add(1)
+				}
+			}
+		}()
+	}
+
+	tick := time.Duration(float64(time.Second) / g.config.RPS)
+	if tick < minTick {
+		tick = minTick
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	rateTicker := time.NewTicker(rateInterval)
+	defer rateTicker.Stop()
+
+	start := time.Now()
+	var issued uint64
+	var lastCompleted uint64
+	lastRate := start
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case now := <-rateTicker.C:
+			current := completed.Load()
+			g.achieved.Set(float64(current-lastCompleted) / now.Sub(lastRate).Seconds())
+			lastCompleted, lastRate = current, now
+		case now := <-ticker.C:
+			due := uint64(now.Sub(start).Seconds() * g.config.RPS)
+			for ; issued < due; issued++ {
+				select {
+				case jobs <- struct{}{}:
+				default:
+					dropped.Add(1)
+					g.dropped.Inc()
+				}
+			}
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	result := Result{
+		Requests: completed.Load(),
+		Errors:   failed.Load(),
+		Dropped:  dropped.Load(),
+		Elapsed:  time.Since(start),
+	}
+	if result.Elapsed > 0 {
+		result.AchievedRPS = float64(result.Requests) / result.Elapsed.Seconds()
+	}
+	g.achieved.Set(result.AchievedRPS)
+
+	return result, nil
+}
@@ -0,0 +1,155 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestRunRecordsRateLatencyAndErrors(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	g, err := New(registry, Config{
+		Name:     "test_load",
+		RPS:      200,
+		Workers:  4,
+		Duration: 250 * time.Millisecond,
+		Tags:     metric.Tags{"scenario": "unit"},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var calls atomic.Int64
+	result, err := g.Run(context.Background(), func(ctx context.Context) error {
+		if calls.Add(1)%4 == 0 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// 200 rps for 250ms is ~50 calls; allow generous slack for slow CI machines
+	if result.Requests < 25 || result.Requests > 55 {
+		t.Errorf("Expected roughly 50 requests, got %d", result.Requests)
+	}
+	if result.Errors != result.Requests/4 {
+		t.Errorf("Expected %d errors, got %d", result.Requests/4, result.Errors)
+	}
+	if result.AchievedRPS <= 0 {
+		t.Errorf("Expected positive achieved rate, got %v", result.AchievedRPS)
+	}
+
+	tags := metric.Tags{"scenario": "unit"}
+	requests := registry.Counter(metric.Options{Name: "test_load_requests_total", Tags: tags})
+	if requests.Value() != result.Requests {
+		t.Errorf("Expected requests counter %d, got %d", result.Requests, requests.Value())
+	}
+	errorsTotal := registry.Counter(metric.Options{Name: "test_load_errors_total", Tags: tags})
+	if errorsTotal.Value() != result.Errors {
+		t.Errorf("Expected errors counter %d, got %d", result.Errors, errorsTotal.Value())
+	}
+	latency := registry.Timer(metric.Options{Name: "test_load_latency", Tags: tags})
+	if latency.Snapshot().Count != result.Requests {
+		t.Errorf("Expected %d latency samples, got %d", result.Requests, latency.Snapshot().Count)
+	}
+	if tags := latency.Tags(); tags["scenario"] != "unit" {
+		t.Errorf("Expected scenario tag on recorded metrics, got %v", tags)
+	}
+	if target := registry.Gauge(metric.Options{Name: "test_load_target_rps", Tags: tags}).Value(); target != 200 {
+		t.Errorf("Expected target rps gauge 200, got %d", target)
+	}
+	if inFlight := registry.Gauge(metric.Options{Name: "test_load_in_flight", Tags: tags}).Value(); inFlight != 0 {
+		t.Errorf("Expected no calls in flight after Run, got %d", inFlight)
+	}
+}
+
+func TestRunDropsWhenSaturated(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	g, err := New(registry, Config{RPS: 1000, Workers: 1, Duration: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	result, err := g.Run(context.Background(), func(ctx context.Context) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if result.Dropped == 0 {
+		t.Error("Expected scheduled calls to be dropped by a saturated worker pool")
+	}
+	dropped := registry.Counter(metric.Options{Name: DefaultName + "_dropped_total"})
+	if dropped.Value() != result.Dropped {
+		t.Errorf("Expected dropped counter %d, got %d", result.Dropped, dropped.Value())
+	}
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	if _, err := New(metric.NewNoCleanupRegistry(), Config{}); err == nil {
+		t.Error("Expected error for zero RPS")
+	}
+}
+
+func TestRunDropsQueuedCallsAtEnd(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	g, err := New(registry, Config{RPS: 1000, Workers: 2, Duration: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var calls, late atomic.Uint64
+	result, err := g.Run(context.Background(), func(ctx context.Context) error {
+		calls.Add(1)
+		if ctx.Err() != nil {
+			late.Add(1)
+		}
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if late.Load() != 0 {
+		t.Errorf("Expected no calls after the run ended, got %d", late.Load())
+	}
+	if result.Requests != calls.Load() {
+		t.Errorf("Expected %d requests, one per call, got %d", calls.Load(), result.Requests)
+	}
+}
+
+func TestRateGaugesKeepFractions(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	g, err := New(registry, Config{Name: "slow_load", RPS: 2.5, Duration: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := g.Run(context.Background(), func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	m, ok := metric.FindMetric(registry, "slow_load_target_rps", metric.TypeGauge, nil)
+	if !ok {
+		t.Fatal("Expected the target rate gauge to be registered")
+	}
+	if got := metric.GaugeValue(m.(metric.Gauge)); got != 2.5 {
+		t.Errorf("Expected target rate 2.5, got %v", got)
+	}
+}
@@ -0,0 +1,93 @@
+package metric
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Manifest lists the metrics of a registry with what a reader of dashboards
+// needs to know about them, for publishing a catalog of a service's metrics
+type Manifest struct {
+	Metrics []ManifestMetric `json:"metrics"`
+}
+
+// ManifestMetric describes one metric name of a Manifest
+type ManifestMetric struct {
+	Name        string `json:"name"`
+	Type        Type   `json:"type"`
+	Description string `json:"description,omitempty"`
+	Unit        string `json:"unit,omitempty"`
+	// Tags are the tag keys seen on the name's series, sorted
+	Tags []string `json:"tags,omitempty"`
+	// Series is the number of series registered under the name
+	Series int `json:"series"`
+}
+
+// GenerateManifest describes every metric name registered in registry when it
+// is called, sorted by name, from its Catalog and the tag keys of its series.
+// Metrics are only known once created, so generate the manifest after the
+// service has registered its metrics, e.g. from an admin endpoint or a test.
+func GenerateManifest(registry Registry) Manifest {
+	tagKeys := make(map[string]map[string]struct{})
+	registry.Each(func(m Metric) {
+		keys, ok := tagKeys[m.Name()]
+		if !ok {
+			keys = make(map[string]struct{})
+			tagKeys[m.Name()] = keys
+		}
+		for key := range m.Tags() {
+			keys[key] = struct{}{}
+		}
+	})
+
+	catalog := Catalog(registry)
+	manifest := Manifest{Metrics: make([]ManifestMetric, 0, len(catalog))}
+	for _, entry := range catalog {
+		metric := ManifestMetric{
+			Name:        entry.Name,
+			Type:        entry.Type,
+			Description: entry.Description,
+			Unit:        entry.Unit,
+			Series:      entry.Series,
+		}
+		for key := range tagKeys[entry.Name] {
+			metric.Tags = append(metric.Tags, key)
+		}
+		slices.Sort(metric.Tags)
+		manifest.Metrics = append(manifest.Metrics, metric)
+	}
+	return manifest
+}
+
+// WriteJSON writes the manifest as indented JSON
+func (m Manifest) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(m)
+}
+
+// WriteMarkdown writes the manifest as a Markdown table with a row per metric
+func (m Manifest) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("| Name | Type | Unit | Tags | Description |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, metric := range m.Metrics {
+		tags := make([]string, len(metric.Tags))
+		for i, tag := range metric.Tags {
+			tags[i] = "`" + tag + "`"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n",
+			metric.Name, metric.Type, markdownCell(metric.Unit), strings.Join(tags, ", "), markdownCell(metric.Description))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes s for a Markdown table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
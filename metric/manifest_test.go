@@ -0,0 +1,51 @@
+package metric
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGenerateManifest(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+	requests := registry.Counter(Options{Name: "http_requests_total", Description: "Requests served", Tags: Tags{"route": "/"}})
+	requests.With(Tags{"method": "GET"})
+	registry.Histogram(Options{Name: "http_request_duration", Description: "Request latency | p99 matters", Unit: "ms"})
+
+	manifest := GenerateManifest(registry)
+	if len(manifest.Metrics) != 2 {
+		t.Fatalf("Expected 2 metrics, got %+v", manifest.Metrics)
+	}
+	duration, total := manifest.Metrics[0], manifest.Metrics[1]
+	if duration.Name != "http_request_duration" || duration.Unit != "ms" || duration.Type != TypeHistogram || len(duration.Tags) != 0 {
+		t.Errorf("Unexpected histogram %+v", duration)
+	}
+	if total.Series != 2 || strings.Join(total.Tags, ",") != "method,route" || total.Description != "Requests served" {
+		t.Errorf("Unexpected counter %+v", total)
+	}
+
+	var buf bytes.Buffer
+	if err := manifest.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	var decoded Manifest
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Metrics) != 2 || decoded.Metrics[1].Tags[0] != "method" {
+		t.Errorf("Expected the manifest to round-trip through JSON, got %v:\n%s", err, buf.String())
+	}
+
+	buf.Reset()
+	if err := manifest.WriteMarkdown(&buf); err != nil {
+		t.Fatalf("WriteMarkdown: %v", err)
+	}
+	for _, row := range []string{
+		"| Name | Type | Unit | Tags | Description |",
+		"| `http_request_duration` | histogram | ms |  | Request latency \\| p99 matters |",
+		"| `http_requests_total` | counter |  | `method`, `route` | Requests served |",
+	} {
+		if !strings.Contains(buf.String(), row+"\n") {
+			t.Errorf("Expected the row %q in:\n%s", row, buf.String())
+		}
+	}
+}
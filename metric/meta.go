@@ -0,0 +1,218 @@
+package metric
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetaMetricPrefix is reserved for the metrics a registry reports about itself
+const MetaMetricPrefix = "gometrics_registry_"
+
+// RegistryOption configures a registry created with NewRegistry
+type RegistryOption func(*defaultRegistry)
+
+// WithMetaMetrics enables self-observability: the registry reports metrics about
+// itself under MetaMetricPrefix alongside the registered metrics:
+//
+//	gometrics_registry_metrics              gauge: registered metrics
+//	gometrics_registry_cardinality{metric}  gauge: series per metric name
+//	gometrics_registry_expired_total        counter: metrics removed by TTL cleanup
+//	gometrics_registry_rejections_total{reason}  counter: metrics rejected by tag validation, cardinality limits or tenant budgets
+//	gometrics_registry_cleanup_duration     timer: duration of each cleanup pass
+//	gometrics_registry_negative_adds_total  counter: negative values passed to counter Add
+//	gometrics_registry_metadata_conflicts_total  counter: metrics requested with conflicting description or unit
+//	gometrics_registry_timer_audit_findings_total{reason}  counter: implausible timer durations flagged by WithTimerAudit
+//	gometrics_registry_goroutines           gauge: background goroutines run by the library
+//	gometrics_registry_interned_strings     gauge: tag strings held by the intern table
+//	gometrics_registry_interned_bytes       gauge: size of the tag strings held by the intern table
+//	gometrics_registry_intern_lookups_total{result}  counter: new series' tag strings found ("hit") or not ("miss") in the intern table
+//
+// Meta-metrics are visible through Each and Watch but are not stored in the
+// registry, and user metrics may not use the reserved prefix.
+func WithMetaMetrics() RegistryOption {
+	return func(r *defaultRegistry) {
+		r.meta = newRegistryMeta()
+	}
+}
+
+// registryMeta holds a registry's self-observability metrics
+type registryMeta struct {
+	registered         Gauge
+	expired            Counter
+	tagRejections      Counter
+	limitRejections    Counter
+	tenantRejections   Counter
+	cleanupDuration    Timer
+	negativeAdds       Counter
+	metadataConflicts  Counter
+	timerAuditFindings map[string]Counter // keyed by TimerAuditFinding reason
+	goroutines         Gauge
+	internedStrings    Gauge
+	internedBytes      Gauge
+	internHits         Counter
+	internMisses       Counter
+
+	mu          sync.Mutex
+	cardinality map[string]Gauge // keyed by metric name
+	internSeen  InternStats      // intern table lookups already counted; guarded by mu
+}
+
+func newRegistryMeta() *registryMeta {
+	internLookups := newCounter(Options{
+		Name:        MetaMetricPrefix + "intern_lookups_total",
+		Description: "Tag strings of new series looked up in the intern table",
+		Unit:        "count",
+	})
+	rejections := newCounter(Options{
+		Name:        MetaMetricPrefix + "rejections_total",
+		Description: "Metrics rejected by tag validation, cardinality limits or tenant budgets",
+		Unit:        "count",
+	})
+	auditFindings := newCounter(Options{
+		Name:        MetaMetricPrefix + "timer_audit_findings_total",
+		Description: "Implausible timer durations flagged by the timer audit",
+		Unit:        "count",
+	})
+	timerAuditFindings := make(map[string]Counter)
+	for _, reason := range timerAuditReasons {
+		timerAuditFindings[reason] = auditFindings.With(Tags{"reason": reason})
+	}
+
+	return &registryMeta{
+		registered: newGauge(Options{
+			Name:        MetaMetricPrefix + "metrics",
+			Description: "Number of metrics registered",
+			Unit:        "count",
+		}),
+		expired: newCounter(Options{
+			Name:        MetaMetricPrefix + "expired_total",
+			Description: "Metrics removed by TTL cleanup",
+			Unit:        "count",
+		}),
+		tagRejections:    rejections.With(Tags{"reason": "tags"}),
+		limitRejections:  rejections.With(Tags{"reason": "cardinality"}),
+		tenantRejections: rejections.With(Tags{"reason": "tenant"}),
+		cleanupDuration: newTimer(Options{
+			Name:        MetaMetricPrefix + "cleanup_duration",
+			Description: "Duration of registry cleanup passes",
+			Unit:        "nanoseconds",
+		}),
+		negativeAdds: newCounter(Options{
+			Name:        MetaMetricPrefix + "negative_adds_total",
+			Description: "Negative values passed to counter Add",
+			Unit:        "count",
+		}),
+		metadataConflicts: newCounter(Options{
+			Name:        MetaMetricPrefix + "metadata_conflicts_total",
+			Description: "Metrics requested with a description or unit differing from the registered one",
+			Unit:        "count",
+		}),
+		timerAuditFindings: timerAuditFindings,
+		goroutines: newGauge(Options{
+			Name:        MetaMetricPrefix + "goroutines",
+			Description: "Background goroutines run by the library, across all registries",
+			Unit:        "count",
+		}),
+		internedStrings: newGauge(Options{
+			Name:        MetaMetricPrefix + "interned_strings",
+			Description: "Distinct tag strings held by the intern table, across all registries",
+			Unit:        "count",
+		}),
+		internedBytes: newGauge(Options{
+			Name:        MetaMetricPrefix + "interned_bytes",
+			Description: "Size of the tag strings held by the intern table, across all registries",
+			Unit:        "bytes",
+		}),
+		internHits:   internLookups.With(Tags{"result": "hit"}),
+		internMisses: internLookups.With(Tags{"result": "miss"}),
+		cardinality:  make(map[string]Gauge),
+		internSeen:   Interned(),
+	}
+}
+
+// cardinalityGauge returns the gauge tracking the series count for name
+func (m *registryMeta) cardinalityGauge(name string) Gauge {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g, ok := m.cardinality[name]
+	if !ok {
+		g = newGauge(Options{
+			Name:        MetaMetricPrefix + "cardinality",
+			Description: "Number of series registered per metric name",
+			Unit:        "count",
+			Tags:        Tags{"metric": name},
+		})
+		m.cardinality[name] = g
+	}
+	return g
+}
+
+// each refreshes the registry gauges from the registered series count and the
+// per-name cardinality, copied from the registry, and passes every meta-metric to fn
+func (m *registryMeta) each(registered int, cardinality map[string]int, fn func(Metric)) {
+	m.registered.Set(float64(registered))
+	fn(m.registered)
+	fn(m.expired)
+	fn(m.tagRejections)
+	fn(m.limitRejections)
+	fn(m.tenantRejections)
+	fn(m.cleanupDuration)
+	fn(m.negativeAdds)
+	fn(m.metadataConflicts)
+	for _, reason := range timerAuditReasons {
+		fn(m.timerAuditFindings[reason])
+	}
+	m.goroutines.Set(float64(ActiveGoroutines()))
+	fn(m.goroutines)
+	m.eachIntern(fn)
+
+	for name, count := range cardinality {
+		g := m.cardinalityGauge(name)
+		g.Set(float64(count))
+		fn(g)
+	}
+}
+
+// eachIntern refreshes the intern table metrics and passes them to fn. The
+// lookup counters are advanced by the lookups since the last call, so they keep
+// counting when a reporter swaps them.
+func (m *registryMeta) eachIntern(fn func(Metric)) {
+	stats := Interned()
+	m.internedStrings.Set(float64(stats.Strings))
+	m.internedBytes.Set(float64(stats.Bytes))
+
+	m.mu.Lock()
+	hits, misses := stats.Hits-m.internSeen.Hits, stats.Misses-m.internSeen.Misses
+	m.internSeen = stats
+	m.mu.Unlock()
+	if hits > 0 {
+		m.internHits.Add(float64(hits))
+	}
+	if misses > 0 {
+		m.internMisses.Add(float64(misses))
+	}
+
+	fn(m.internedStrings)
+	fn(m.internedBytes)
+	fn(m.internHits)
+	fn(m.internMisses)
+}
+
+// checkReserved panics if a user metric tries to use the reserved prefix
+func (m *registryMeta) checkReserved(name string) {
+	if strings.HasPrefix(name, MetaMetricPrefix) {
+		m.tagRejections.Inc()
+		panic(fmt.Sprintf("metric name '%s' uses the reserved prefix '%s'", name, MetaMetricPrefix))
+	}
+}
+
+// observeCleanup records a cleanup pass that started at start and expired n metrics
+func (m *registryMeta) observeCleanup(start time.Time, n int) {
+	m.cleanupDuration.RecordSince(start)
+	if n > 0 {
+		m.expired.Add(float64(n))
+	}
+}
@@ -0,0 +1,88 @@
+package metric
+
+import (
+	"testing"
+	"time"
+)
+
+// metaValues collects meta-metric values from Each, keyed by name and tags
+func metaValues(registry Registry) map[string]float64 {
+	values := make(map[string]float64)
+	registry.Each(func(m Metric) {
+		key := m.Name() + canonicalTags(m.Tags())
+		switch v := m.(type) {
+		case Counter:
+			values[key] = float64(v.Value())
+		case Gauge:
+			values[key] = float64(v.Value())
+		case Timer:
+			values[key] = float64(v.Snapshot().Count)
+		}
+	})
+	return values
+}
+
+func TestMetaMetrics(t *testing.T) {
+	config := DefaultTagValidationConfig()
+	config.MaxCardinality = 2
+	registry := NewRegistry(config, 0, WithMetaMetrics())
+	defer registry.Close()
+
+	registry.Counter(Options{Name: "requests_total"})
+	registry.Gauge(Options{Name: "queue_depth"})
+	registry.Histogram(Options{Name: "latency"}).With(Tags{"route": "/a"})
+	registry.Counter(Options{Name: "expiring_total", TTL: time.Nanosecond})
+
+	expectPanic := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected %s to panic", name)
+			}
+		}()
+		fn()
+	}
+	expectPanic("invalid tags", func() {
+		registry.Counter(Options{Name: "bad_tags", Tags: Tags{"": "empty key"}})
+	})
+	expectPanic("cardinality limit", func() {
+		registry.Histogram(Options{Name: "latency"}).With(Tags{"route": "/b"})
+	})
+	expectPanic("reserved prefix", func() {
+		registry.Counter(Options{Name: MetaMetricPrefix + "metrics"})
+	})
+
+	time.Sleep(time.Millisecond)
+	registry.ManualCleanup()
+
+	values := metaValues(registry)
+	expected := map[string]float64{
+		MetaMetricPrefix + "metrics{}":                            4,
+		MetaMetricPrefix + "cardinality{metric=latency}":          2,
+		MetaMetricPrefix + "cardinality{metric=queue_depth}":      1,
+		MetaMetricPrefix + "expired_total{}":                      1,
+		MetaMetricPrefix + "rejections_total{reason=tags}":        2,
+		MetaMetricPrefix + "rejections_total{reason=cardinality}": 1,
+		MetaMetricPrefix + "cleanup_duration{}":                   1,
+	}
+	for key, want := range expected {
+		if got, ok := values[key]; !ok || got != want {
+			t.Errorf("Expected %s = %v, got %v (present: %v)", key, want, got, ok)
+		}
+	}
+	if _, ok := values[MetaMetricPrefix+"cardinality{metric=expiring_total}"]; ok {
+		t.Error("Expected cardinality gauge for expired metric to disappear")
+	}
+}
+
+func TestMetaMetricsDisabledByDefault(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	registry.Counter(Options{Name: MetaMetricPrefix + "allowed_without_meta"})
+	count := 0
+	registry.Each(func(Metric) { count++ })
+	if count != 1 {
+		t.Errorf("Expected only the user metric, got %d metrics", count)
+	}
+}
@@ -0,0 +1,111 @@
+package metric
+
+import "fmt"
+
+// metadataSetter is implemented by metrics whose description and unit can be replaced at runtime
+type metadataSetter interface {
+	setMetadata(md Metadata)
+}
+
+// WithMetadataConflictHandler sets a callback invoked when a registry call asks for
+// an existing metric with a different non-empty description or unit. Each metric
+// is reported once, and not at all after SetMetadata has been called for its name.
+// New series of a name already registered with another type, description or
+// unit are reported too, once per name. With WithMetaMetrics, conflicts are
+// also counted in gometrics_registry_metadata_conflicts_total. The handler may
+// be called with the registry locked and must not use the registry.
+func WithMetadataConflictHandler(handler func(MetadataConflict)) RegistryOption {
+	return func(r *defaultRegistry) {
+		r.conflictHandler = handler
+	}
+}
+
+// mergeMetadata returns the metadata of m with the non-empty fields of md applied
+func mergeMetadata(m Metric, md Metadata) Metadata {
+	merged := Metadata{Description: m.Description(), Unit: m.Unit()}
+	if md.Description != "" {
+		merged.Description = md.Description
+	}
+	if md.Unit != "" {
+		merged.Unit = md.Unit
+	}
+	return merged
+}
+
+// SetMetadata replaces the description and unit of every metric registered under
+// name, including series derived with With(), and of metrics created under name
+// later. Empty values are left unchanged. Reporters that read metadata on every
+// collection pick up the change immediately.
+func (r *defaultRegistry) SetMetadata(name, description, unit string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	md := r.metadata[name]
+	if description != "" {
+		md.Description = description
+	}
+	if unit != "" {
+		md.Unit = unit
+	}
+	r.metadata[name] = md
+
+	for _, entry := range r.metrics {
+		if entry.metric.Name() != name {
+			continue
+		}
+		if setter, ok := entry.metric.(metadataSetter); ok {
+			setter.setMetadata(mergeMetadata(entry.metric, md))
+		}
+		entry.metadataQuiet.Store(true)
+	}
+}
+
+// applyMetadata gives a newly created metric the metadata set for its name, if any.
+// The caller must hold the write lock.
+func (r *defaultRegistry) applyMetadata(entry *metricEntry) {
+	md, ok := r.metadata[entry.metric.Name()]
+	if !ok {
+		return
+	}
+	if setter, ok := entry.metric.(metadataSetter); ok {
+		setter.setMetadata(mergeMetadata(entry.metric, md))
+	}
+	entry.metadataQuiet.Store(true)
+}
+
+// checkMetadata reports a conflict between the metadata requested by opts and
+// that of the existing metric in entry
+func (r *defaultRegistry) checkMetadata(entry *metricEntry, opts Options) {
+	if r.conflictHandler == nil && r.meta == nil && !r.strictMetadata {
+		return
+	}
+
+	m := entry.metric
+	if (opts.Description == "" || opts.Description == m.Description()) &&
+		(opts.Unit == "" || opts.Unit == m.Unit()) {
+		return
+	}
+	conflict := MetadataConflict{
+		Name:      m.Name(),
+		Type:      m.Type(),
+		Current:   Metadata{Description: m.Description(), Unit: m.Unit()},
+		Requested: Metadata{Description: opts.Description, Unit: opts.Unit},
+	}
+	if r.strictMetadata && !entry.metadataQuiet.Load() {
+		panic(fmt.Errorf("%w for metric '%s': %+v", ErrMetadataConflict, m.Name(), conflict))
+	}
+	if !entry.metadataQuiet.CompareAndSwap(false, true) {
+		return
+	}
+	r.reportConflict(conflict)
+}
+
+// reportConflict counts conflict and passes it to the conflict handler
+func (r *defaultRegistry) reportConflict(conflict MetadataConflict) {
+	if r.meta != nil {
+		r.meta.metadataConflicts.Inc()
+	}
+	if r.conflictHandler != nil {
+		r.conflictHandler(conflict)
+	}
+}
@@ -0,0 +1,73 @@
+package metric
+
+import "testing"
+
+func TestSetMetadata(t *testing.T) {
+	registry := NewNoCleanupRegistry().(ConfigurableRegistry)
+	defer registry.Close()
+
+	counter := registry.Counter(Options{Name: "requests_total", Description: "old", Unit: "count"})
+	child := registry.Histogram(Options{Name: "latency", Description: "old", Unit: "ms"}).With(Tags{"route": "/a"})
+
+	registry.SetMetadata("requests_total", "Requests served", "")
+	registry.SetMetadata("latency", "Request latency", "seconds")
+
+	if counter.Description() != "Requests served" || counter.Unit() != "count" {
+		t.Errorf("Expected updated description and unchanged unit, got %q %q", counter.Description(), counter.Unit())
+	}
+	if child.Description() != "Request latency" || child.Unit() != "seconds" {
+		t.Errorf("Expected With() series to be updated, got %q %q", child.Description(), child.Unit())
+	}
+
+	// Series derived after the update inherit it, as do metrics created later under the name
+	if derived := counter.With(Tags{"method": "GET"}); derived.Description() != "Requests served" {
+		t.Errorf("Expected derived series to inherit metadata, got %q", derived.Description())
+	}
+	timer := registry.Timer(Options{Name: "latency", Description: "stale", Unit: "ns"})
+	if timer.Description() != "Request latency" || timer.Unit() != "seconds" {
+		t.Errorf("Expected later metric to use set metadata, got %q %q", timer.Description(), timer.Unit())
+	}
+}
+
+func TestMetadataConflicts(t *testing.T) {
+	var conflicts []MetadataConflict
+	registry := NewNoCleanupRegistry(WithMetaMetrics(), WithMetadataConflictHandler(func(c MetadataConflict) {
+		conflicts = append(conflicts, c)
+	}))
+	defer registry.Close()
+
+	registry.Gauge(Options{Name: "queue_depth", Description: "Queue depth", Unit: "items"})
+
+	// Matching or empty metadata is not a conflict
+	registry.Gauge(Options{Name: "queue_depth"})
+	registry.Gauge(Options{Name: "queue_depth", Description: "Queue depth"})
+	if len(conflicts) != 0 {
+		t.Fatalf("Expected no conflicts, got %v", conflicts)
+	}
+
+	registry.Gauge(Options{Name: "queue_depth", Description: "Pending jobs", Unit: "items"})
+	registry.Gauge(Options{Name: "queue_depth", Description: "Pending jobs"})
+	if len(conflicts) != 1 {
+		t.Fatalf("Expected the conflict to be reported once, got %d", len(conflicts))
+	}
+	expected := MetadataConflict{
+		Name:      "queue_depth",
+		Type:      TypeGauge,
+		Current:   Metadata{Description: "Queue depth", Unit: "items"},
+		Requested: Metadata{Description: "Pending jobs", Unit: "items"},
+	}
+	if conflicts[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, conflicts[0])
+	}
+	if v := metaValues(registry)[MetaMetricPrefix+"metadata_conflicts_total{}"]; v != 1 {
+		t.Errorf("Expected 1 conflict counted, got %v", v)
+	}
+
+	// Explicitly set metadata silences conflicts from callers still using old options
+	registry.Counter(Options{Name: "jobs_total", Description: "Jobs"})
+	registry.(ConfigurableRegistry).SetMetadata("jobs_total", "Jobs processed", "")
+	registry.Counter(Options{Name: "jobs_total", Description: "Jobs"})
+	if len(conflicts) != 1 {
+		t.Errorf("Expected no conflict after SetMetadata, got %v", conflicts[1:])
+	}
+}
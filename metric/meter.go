@@ -0,0 +1,146 @@
+package metric
+
+import (
+	"maps"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// meterTickInterval is how often meter rates are updated. Updates happen
+// lazily on the first mark or read after each interval, so idle meters cost nothing.
+const meterTickInterval = 5 * time.Second
+
+// ewma is an exponentially weighted moving average of a per-second rate
+type ewma struct {
+	alpha       float64
+	rate        float64
+	initialized bool
+}
+
+func newEWMA(window time.Duration) ewma {
+	return ewma{alpha: 1 - math.Exp(-meterTickInterval.Seconds()/window.Seconds())}
+}
+
+// tick folds the events counted during one interval into the average, then
+// decays it for the remaining ticks-1 intervals that saw no events
+func (e *ewma) tick(count int64, ticks int64) {
+	instant := float64(count) / meterTickInterval.Seconds()
+	if e.initialized {
+		e.rate += e.alpha * (instant - e.rate)
+	} else {
+		e.rate = instant
+		e.initialized = true
+	}
+	if ticks > 1 {
+		e.rate *= math.Pow(1-e.alpha, float64(ticks-1))
+	}
+}
+
+// meterImpl implements the Meter interface
+type meterImpl struct {
+	baseMetric
+	count     atomic.Int64
+	uncounted atomic.Int64 // events since the last tick
+	start     time.Time
+	lastTick  atomic.Int64 // unix nanoseconds
+
+	mu    sync.Mutex
+	rates [3]ewma // 1, 5 and 15 minutes
+
+	now    func() time.Time
+	derive func(tags Tags) Meter // set by the registry to look up With() series
+}
+
+func newMeter(opts Options) Meter {
+	return newMeterAt(opts, time.Now)
+}
+
+// newMeterAt creates a meter reading the time from now
+func newMeterAt(opts Options, now func() time.Time) *meterImpl {
+	m := &meterImpl{
+		baseMetric: baseMetric{
+			name:        opts.Name,
+			description: opts.Description,
+			unit:        opts.Unit,
+			metricType:  TypeMeter,
+			tags:        opts.Tags,
+			annotations: maps.Clone(opts.Annotations),
+		},
+		start: now(),
+		rates: [3]ewma{newEWMA(time.Minute), newEWMA(5 * time.Minute), newEWMA(15 * time.Minute)},
+		now:   now,
+	}
+	m.lastTick.Store(m.start.UnixNano())
+	return m
+}
+
+func (m *meterImpl) Mark(n int64) {
+	if n < 0 || m.isPaused() {
+		return
+	}
+	m.markWritten()
+	m.tickIfNecessary()
+	m.count.Add(n)
+	m.uncounted.Add(n)
+	observe(m.sink, m, ObservationAdd, float64(n))
+}
+
+func (m *meterImpl) With(tags Tags) Meter {
+	if m.derive != nil {
+		return m.derive(copyTags(m.tags, tags))
+	}
+	return newMeterAt(Options{
+		Name:        m.name,
+		Description: m.Description(),
+		Unit:        m.Unit(),
+		Tags:        copyTags(m.tags, tags),
+		Annotations: m.annotations,
+	}, m.now)
+}
+
+func (m *meterImpl) Count() int64 {
+	return m.count.Load()
+}
+
+func (m *meterImpl) Snapshot() MeterSnapshot {
+	m.tickIfNecessary()
+
+	m.mu.Lock()
+	snapshot := MeterSnapshot{
+		Count:  m.count.Load(),
+		Rate1:  m.rates[0].rate,
+		Rate5:  m.rates[1].rate,
+		Rate15: m.rates[2].rate,
+	}
+	m.mu.Unlock()
+
+	if elapsed := m.now().Sub(m.start).Seconds(); elapsed > 0 {
+		snapshot.MeanRate = float64(snapshot.Count) / elapsed
+	}
+	return snapshot
+}
+
+// tickIfNecessary updates the rates for every interval that has passed since the last tick
+func (m *meterImpl) tickIfNecessary() {
+	now := m.now().UnixNano()
+	if now-m.lastTick.Load() < int64(meterTickInterval) {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	last := m.lastTick.Load()
+	ticks := (now - last) / int64(meterTickInterval)
+	if ticks <= 0 {
+		return
+	}
+	m.lastTick.Store(last + ticks*int64(meterTickInterval))
+
+	count := m.uncounted.Swap(0)
+	for i := range m.rates {
+		m.rates[i].tick(count, ticks)
+	}
+}
@@ -0,0 +1,64 @@
+package metric
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMeterRates(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	meter := newMeterAt(Options{Name: "requests"}, func() time.Time { return now })
+
+	meter.Mark(300)
+	meter.Mark(-5) // ignored
+	if s := meter.Snapshot(); s.Count != 300 || s.Rate1 != 0 {
+		t.Errorf("Expected no rate before the first tick, got %+v", s)
+	}
+
+	// The first tick starts every average at the instant rate
+	now = now.Add(meterTickInterval)
+	s := meter.Snapshot()
+	if s.Rate1 != 60 || s.Rate5 != 60 || s.Rate15 != 60 || s.MeanRate != 60 {
+		t.Errorf("Expected rates of 60/s, got %+v", s)
+	}
+
+	// A minute without events decays the 1-minute average by a factor of e
+	now = now.Add(time.Minute)
+	s = meter.Snapshot()
+	if math.Abs(s.Rate1-60/math.E) > 1e-9 {
+		t.Errorf("Expected the 1-minute rate to decay to %v, got %v", 60/math.E, s.Rate1)
+	}
+	if !(s.Rate1 < s.Rate5 && s.Rate5 < s.Rate15 && s.Rate15 < 60) {
+		t.Errorf("Expected longer windows to decay more slowly, got %+v", s)
+	}
+}
+
+func TestRegistryMeter(t *testing.T) {
+	sink := &recordingSink{}
+	registry := NewNoCleanupRegistry(WithObservationSink(sink)).(*defaultRegistry)
+	defer registry.Close()
+
+	meter := registry.Meter(Options{Name: "jobs", Tags: Tags{"queue": "default"}})
+	meter.Mark(2)
+	registry.Meter(Options{Name: "jobs", Tags: Tags{"queue": "default"}}).Mark(1)
+	meter.With(Tags{"priority": "high"}).Mark(4)
+
+	if meter.Count() != 3 {
+		t.Errorf("Expected lookups to share the meter, got count %d", meter.Count())
+	}
+	series := registry.Series("jobs")
+	if len(series) != 2 || series[0].Type != TypeMeter || series[0].Value+series[1].Value != 7 {
+		t.Errorf("Expected two meter series with 7 events, got %+v", series)
+	}
+	if got := len(sink.all()); got != 3 {
+		t.Errorf("Expected every mark to reach the sink, got %d observations", got)
+	}
+
+	registry.Pause()
+	meter.Mark(10)
+	registry.Resume()
+	if meter.Count() != 3 {
+		t.Errorf("Expected marks to be dropped while paused, got count %d", meter.Count())
+	}
+}
@@ -2,6 +2,7 @@ package metric
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 )
@@ -170,6 +171,156 @@ func TestRegistry(t *testing.T) {
 	}
 }
 
+func TestRegistryTagsAreIdentity(t *testing.T) {
+	config := DefaultTagValidationConfig()
+	config.MaxCardinality = 4
+	registry := NewRegistry(config, 0)
+	defer registry.Close()
+
+	get := registry.Counter(Options{Name: "requests_total", Tags: Tags{"method": "GET", "code": "200"}})
+	post := registry.Counter(Options{Name: "requests_total", Tags: Tags{"method": "POST", "code": "200"}})
+	if get == post {
+		t.Fatal("Expected distinct tag sets to yield distinct series")
+	}
+	get.Inc()
+	post.Add(2)
+
+	// The same tag set returns the same series regardless of map order or how it was derived
+	if again := registry.Counter(Options{Name: "requests_total", Tags: Tags{"code": "200", "method": "GET"}}); again != get {
+		t.Error("Expected the same tag set to return the same series")
+	}
+	base := registry.Counter(Options{Name: "requests_total", Tags: Tags{"code": "200"}})
+	if derived := base.With(Tags{"method": "POST"}); derived != post || derived.Value() != 2 {
+		t.Error("Expected With() to return the registered series for the merged tags")
+	}
+
+	histogram := registry.Histogram(Options{Name: "latency", Tags: Tags{"route": "/a"}})
+	histogram.Observe(1)
+	if child := registry.Histogram(Options{Name: "latency"}).With(Tags{"route": "/a"}); child.Snapshot().Count != 1 {
+		t.Error("Expected With() on a histogram to share the series registered with the same tags")
+	}
+
+	// The cardinality limit applies per name across all tag sets
+	registry.Counter(Options{Name: "requests_total", Tags: Tags{"method": "PUT", "code": "200"}})
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected a fifth requests_total series to exceed the cardinality limit")
+		}
+	}()
+	registry.Counter(Options{Name: "requests_total", Tags: Tags{"method": "DELETE", "code": "200"}})
+}
+
+func TestRegistryTagSeparatorsDoNotCollide(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	joined := registry.Counter(Options{Name: "requests_total", Tags: Tags{"a": "1,b=2"}})
+	split := registry.Counter(Options{Name: "requests_total", Tags: Tags{"a": "1", "b": "2"}})
+	if joined == split {
+		t.Fatal("Expected separators inside a tag value to yield a distinct series")
+	}
+	joined.Inc()
+	if split.Value() != 0 {
+		t.Error("Expected increments of one series not to reach the other")
+	}
+	if got := split.Tags(); got["a"] != "1" || got["b"] != "2" {
+		t.Errorf("Expected the split series to keep its own tags, got %v", got)
+	}
+
+	histogram := registry.Histogram(Options{Name: "latency"})
+	histogram.With(Tags{"a": "1,b=2"}).Observe(1)
+	if child := histogram.With(Tags{"a": "1", "b": "2"}); child.Snapshot().Count != 0 {
+		t.Error("Expected histogram children with separators in a value not to collide")
+	}
+}
+
+func TestRegistryUnregisterSelectors(t *testing.T) {
+	registry := NewNoCleanupRegistry().(SeriesRegistry)
+	defer registry.Close()
+
+	registry.Counter(Options{Name: "jobs"}).Inc()
+	registry.Gauge(Options{Name: "jobs"}).Set(3)
+	latency := registry.Histogram(Options{Name: "latency"})
+	latency.With(Tags{"tenant": "a"}).Observe(1)
+	latency.With(Tags{"tenant": "b"}).Observe(1)
+
+	names := func() map[string]int {
+		seen := make(map[string]int)
+		registry.Each(func(m Metric) {
+			seen[string(m.Type())+":"+m.Name()+canonicalTags(m.Tags())]++
+		})
+		return seen
+	}
+
+	// Only the gauge named jobs is removed
+	registry.UnregisterMetric("jobs", TypeGauge)
+	seen := names()
+	if seen["gauge:jobs{}"] != 0 || seen["counter:jobs{}"] != 1 {
+		t.Errorf("Expected only the jobs gauge to be removed, got %v", seen)
+	}
+
+	// Only the tenant=a series is removed
+	registry.UnregisterWhere(func(m Metric) bool {
+		return m.Tags()["tenant"] == "a"
+	})
+	seen = names()
+	if seen["histogram:latency{tenant=a}"] != 0 || seen["histogram:latency{tenant=b}"] != 1 || seen["histogram:latency{}"] != 1 {
+		t.Errorf("Expected only the tenant=a series to be removed, got %v", seen)
+	}
+
+	// Deriving the removed series again registers a fresh one
+	if count := latency.With(Tags{"tenant": "a"}).Snapshot().Count; count != 0 {
+		t.Errorf("Expected a fresh tenant=a series, got count %d", count)
+	}
+	if names()["histogram:latency{tenant=a}"] != 1 {
+		t.Error("Expected the re-derived tenant=a series to be registered")
+	}
+}
+
+func TestRegistryLookupAfterRemoval(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	// Enough lookups to serve the series from the lock-free snapshot
+	opts := Options{Name: "jobs", Tags: Tags{"queue": "default"}}
+	for i := 0; i < 3; i++ {
+		registry.Counter(opts).Inc()
+	}
+
+	registry.Unregister("jobs")
+	if value := registry.Counter(opts).Value(); value != 0 {
+		t.Errorf("Expected a fresh counter after Unregister, got value %d", value)
+	}
+
+	registry.Counter(opts).Inc()
+	if value := registry.Counter(opts).Value(); value != 1 {
+		t.Errorf("Expected lookups to share the new counter, got value %d", value)
+	}
+}
+
+func TestMetricAnnotations(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	annotations := map[string]string{"datadog.distribution": "true"}
+	latency := registry.Timer(Options{Name: "latency", Annotations: annotations})
+	annotations["datadog.distribution"] = "false" // the metric keeps its own copy
+
+	for _, m := range []Metric{latency, latency.With(Tags{"route": "/"})} {
+		if value, ok := AnnotationOf(m, "datadog.distribution"); !ok || value != "true" {
+			t.Errorf("Expected %s%v to carry the annotation, got %q, %v", m.Name(), m.Tags(), value, ok)
+		}
+	}
+
+	counter := registry.Counter(Options{Name: "plain"})
+	if _, ok := AnnotationOf(counter, "datadog.distribution"); ok {
+		t.Error("Expected no annotation on a metric created without any")
+	}
+	if _, ok := AnnotationOf(NewNoop().Counter(Options{Name: "noop"}), "x"); ok {
+		t.Error("Expected no annotations on no-op metrics")
+	}
+}
+
 func TestContext(t *testing.T) {
 	registry := NewDefaultRegistry()
 	// Use background context instead of nil
@@ -185,3 +336,210 @@ func TestContext(t *testing.T) {
 		t.Error("Registry extracted from context is not the same as the original")
 	}
 }
+
+func TestRegistryGaugeFunc(t *testing.T) {
+	registry := NewNoCleanupRegistry().(InstrumentRegistry)
+	defer registry.Close()
+
+	queue := []string{"a", "b"}
+	gauge := registry.GaugeFunc(Options{Name: "queue_length", Tags: Tags{"queue": "jobs"}}, func() float64 {
+		return float64(len(queue))
+	})
+
+	if gauge.Type() != TypeGauge {
+		t.Errorf("Expected type %s, got %s", TypeGauge, gauge.Type())
+	}
+	if gauge.Value() != 2 {
+		t.Errorf("Expected value 2, got %d", gauge.Value())
+	}
+
+	// The callback is evaluated on every read, and push updates are ignored
+	queue = append(queue, "c")
+	gauge.Set(100)
+	if gauge.Value() != 3 {
+		t.Errorf("Expected value 3 after queue grew, got %d", gauge.Value())
+	}
+
+	// Reporters see the current callback value through Each
+	registry.Each(func(m Metric) {
+		if g, ok := m.(Gauge); ok && m.Name() == "queue_length" && g.Value() != 3 {
+			t.Errorf("Expected Each to observe value 3, got %d", g.Value())
+		}
+	})
+
+	tagged := gauge.With(Tags{"region": "us-west"})
+	if tagged.Value() != 3 || tagged.Tags()["region"] != "us-west" {
+		t.Errorf("Expected tagged gauge to share callback, got value %d tags %v", tagged.Value(), tagged.Tags())
+	}
+
+	// An existing registration is returned unchanged
+	again := registry.GaugeFunc(Options{Name: "queue_length", Tags: Tags{"queue": "jobs"}}, func() float64 { return -1 })
+	if again != gauge {
+		t.Error("Expected GaugeFunc to return the existing gauge")
+	}
+
+	// Fractional results are rounded by Value and exported as they are
+	utilization := registry.GaugeFunc(Options{Name: "pool_utilization"}, func() float64 { return 0.75 })
+	if utilization.Value() != 1 {
+		t.Errorf("Expected Value to round 0.75 to 1, got %d", utilization.Value())
+	}
+	if got := GaugeValue(utilization); got != 0.75 {
+		t.Errorf("Expected GaugeValue to return 0.75, got %v", got)
+	}
+}
+
+func TestUpDownCounter(t *testing.T) {
+	registry := NewNoCleanupRegistry().(InstrumentRegistry)
+	defer registry.Close()
+
+	inFlight := registry.UpDownCounter(Options{Name: "requests_in_flight", Tags: Tags{"service": "api"}})
+	if inFlight.Type() != TypeUpDownCounter {
+		t.Errorf("Expected type %s, got %s", TypeUpDownCounter, inFlight.Type())
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				inFlight.Inc()
+				inFlight.Dec()
+			}
+			inFlight.Add(2)
+		}()
+	}
+	wg.Wait()
+	inFlight.Add(-30)
+
+	if v := inFlight.Value(); v != 70 {
+		t.Errorf("Expected value 70, got %d", v)
+	}
+	if registry.UpDownCounter(Options{Name: "requests_in_flight", Tags: Tags{"service": "api"}}) != inFlight {
+		t.Error("Expected the registry to return the existing up-down counter")
+	}
+
+	tagged := inFlight.With(Tags{"region": "eu"})
+	if tagged.Value() != 0 || tagged.Tags()["region"] != "eu" || tagged.Tags()["service"] != "api" {
+		t.Errorf("Expected a new tagged series, got value %d tags %v", tagged.Value(), tagged.Tags())
+	}
+}
+
+func TestAddAtKeepsLatestTimestamp(t *testing.T) {
+	registry := NewNoCleanupRegistry().(InstrumentRegistry)
+	defer registry.Close()
+
+	counter := registry.Counter(Options{Name: "events_total"})
+	adder, ok := counter.(TimestampedAdder)
+	if !ok {
+		t.Fatal("Expected counter to implement TimestampedAdder")
+	}
+	if !adder.LastTimestamp().IsZero() {
+		t.Errorf("Expected zero timestamp before AddAt, got %v", adder.LastTimestamp())
+	}
+
+	later := time.Unix(1700000100, 0)
+	earlier := time.Unix(1700000000, 0)
+	adder.AddAt(2, later)
+	adder.AddAt(3, earlier) // out of order: counted, but does not rewind the timestamp
+
+	if counter.Value() != 5 {
+		t.Errorf("Expected value 5, got %d", counter.Value())
+	}
+	if !adder.LastTimestamp().Equal(later) {
+		t.Errorf("Expected timestamp %v, got %v", later, adder.LastTimestamp())
+	}
+
+	upDown := registry.UpDownCounter(Options{Name: "balance"}).(TimestampedAdder)
+	upDown.AddAt(-4, earlier)
+	if !upDown.LastTimestamp().Equal(earlier) {
+		t.Errorf("Expected up-down counter timestamp %v, got %v", earlier, upDown.LastTimestamp())
+	}
+}
+
+func TestRegistryUnregisterPrefix(t *testing.T) {
+	config := DefaultTagValidationConfig()
+	config.MaxCardinality = 2
+	registry := NewRegistry(config, 0).(*defaultRegistry)
+	defer registry.Close()
+
+	tenant := registry.Counter(Options{Name: "tenant_a_requests_total", Tags: Tags{"route": "/"}})
+	tenant.With(Tags{"route": "/login"}).Inc()
+	registry.Histogram(Options{Name: "tenant_a_latency"})
+	registry.Counter(Options{Name: "tenant_b_requests_total"})
+	registry.SetMetadata("tenant_a_latency", "Latency", "seconds")
+
+	registry.UnregisterPrefix("tenant_a_")
+
+	var names []string
+	registry.Each(func(m Metric) { names = append(names, m.Name()) })
+	if len(names) != 1 || names[0] != "tenant_b_requests_total" {
+		t.Errorf("Expected only the tenant_b metric to remain, got %v", names)
+	}
+
+	// The removed series no longer count against the cardinality limit
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("Expected cardinality to be released, got panic %v", r)
+			}
+		}()
+		registry.Counter(Options{Name: "tenant_a_requests_total", Tags: Tags{"route": "/a"}})
+		registry.Counter(Options{Name: "tenant_a_requests_total", Tags: Tags{"route": "/b"}})
+	}()
+
+	if desc := registry.Histogram(Options{Name: "tenant_a_latency"}).Description(); desc != "" {
+		t.Errorf("Expected metadata to be dropped with the metrics, got %q", desc)
+	}
+}
+
+func TestRegistryEachIsReentrant(t *testing.T) {
+	registry := NewNoCleanupRegistry().(SeriesRegistry)
+	defer registry.Close()
+	registry.Counter(Options{Name: "requests_total"}).Inc()
+	registry.Counter(Options{Name: "requests_total", Tags: Tags{"route": "/"}})
+	registry.Gauge(Options{Name: "queue_depth"})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		registry.Each(func(m Metric) {
+			// Creating and removing metrics from a callback used to deadlock
+			registry.Counter(Options{Name: m.Name() + "_seen_total"}).Inc()
+			if m.Name() == "queue_depth" {
+				registry.Unregister("queue_depth")
+			}
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Each to allow registry calls from its callback")
+	}
+	if _, ok := registry.Lookup("queue_depth_seen_total", TypeCounter); !ok {
+		t.Error("Expected the counter created during iteration to be registered")
+	}
+
+	var counters []string
+	registry.EachByType(TypeCounter, func(m Metric) { counters = append(counters, m.Name()) })
+	if len(counters) != 4 {
+		t.Errorf("Expected the 2 request counters and 2 seen counters, got %v", counters)
+	}
+
+	m, ok := registry.Lookup("requests_total", TypeCounter)
+	if !ok || len(m.Tags()) != 0 || m.(Counter).Value() != 1 {
+		t.Errorf("Expected the untagged counter, got %v", m)
+	}
+	if _, ok := registry.Lookup("requests_total", TypeGauge); ok {
+		t.Error("Expected no gauge named requests_total")
+	}
+	if _, ok := registry.Lookup("queue_depth", TypeGauge); ok {
+		t.Error("Expected the unregistered gauge to be gone")
+	}
+
+	tenant := ForTenant(registry, "acme")
+	tenant.Gauge(Options{Name: "quota"}).Set(3)
+	if m, ok := tenant.(SeriesRegistry).Lookup("quota", TypeGauge); !ok || m.(Gauge).Value() != 3 {
+		t.Errorf("Expected the tenant's gauge, got %v", m)
+	}
+}
@@ -1,8 +1,11 @@
 package metric
 
 import (
+	"encoding/binary"
 	"fmt"
 	"maps"
+	"math"
+	"slices"
 	"sort"
 	"sync"
 	"sync/atomic"
@@ -38,6 +41,62 @@ type baseMetric struct {
 	unit        string
 	metricType  Type
 	tags        Tags
+	written     atomic.Bool              // set by writes, cleared by TTL cleanup
+	stamped     atomic.Bool              // set by writes, cleared by Series so the next write restamps lastWrite
+	lastWrite   atomic.Int64             // unix nanoseconds of the first write since stamped was cleared
+	clock       Clock                    // set by registries with WithClock; stamps lastWrite, nil for time.Now
+	metadata    atomic.Pointer[Metadata] // set by SetMetadata, overrides description and unit
+	sink        ObservationSink          // set by the registry; receives every write
+	paused      *atomic.Bool             // set by the registry; writes are dropped while it is true
+	annotations map[string]string        // from Options.Annotations; never modified
+	created     int64                    // unix nanoseconds of creation or the last reset; zero if unknown
+}
+
+// markCreated records now as the time the series was created or reset
+func (m *baseMetric) markCreated() {
+	atomic.StoreInt64(&m.created, time.Now().UnixNano())
+}
+
+func (m *baseMetric) Created() time.Time {
+	return loadTimestamp(&m.created)
+}
+
+// markWritten records a write so TTL cleanup treats the metric as active.
+// Reading the clock on every write would dominate the cost of a counter
+// increment, so only the first write after each Series call stamps its time.
+func (m *baseMetric) markWritten() {
+	if !m.written.Load() {
+		m.written.Store(true)
+	}
+	if !m.stamped.Load() {
+		now := time.Now()
+		if m.clock != nil {
+			now = m.clock.Now()
+		}
+		m.lastWrite.Store(now.UnixNano())
+		m.stamped.Store(true)
+	}
+}
+
+// setWriteClock makes the metric stamp its writes with clock
+func (m *baseMetric) setWriteClock(clock Clock) {
+	m.clock = clock
+}
+
+// takeLastWrite returns the stamped write time, or the zero time if the metric
+// was never written, and re-arms the stamp for the next write
+func (m *baseMetric) takeLastWrite() time.Time {
+	m.stamped.Store(false)
+	ns := m.lastWrite.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// takeWritten reports whether the metric was written since the last call and clears the mark
+func (m *baseMetric) takeWritten() bool {
+	return m.written.Swap(false)
 }
 
 func (m *baseMetric) Name() string {
@@ -45,9 +104,35 @@ func (m *baseMetric) Name() string {
 }
 
 func (m *baseMetric) Description() string {
+	if md := m.metadata.Load(); md != nil {
+		return md.Description
+	}
 	return m.description
 }
 
+func (m *baseMetric) Unit() string {
+	if md := m.metadata.Load(); md != nil {
+		return md.Unit
+	}
+	return m.unit
+}
+
+// setMetadata replaces the description and unit reported by the metric
+func (m *baseMetric) setMetadata(md Metadata) {
+	m.metadata.Store(&md)
+}
+
+// Annotation returns the value of the annotation key, if set
+func (m *baseMetric) Annotation(key string) (string, bool) {
+	value, ok := m.annotations[key]
+	return value, ok
+}
+
+// Annotations returns a copy of the metric's annotations
+func (m *baseMetric) Annotations() map[string]string {
+	return maps.Clone(m.annotations)
+}
+
 func (m *baseMetric) Type() Type {
 	return m.metricType
 }
@@ -89,52 +174,201 @@ func copyTags(originalTags, newTags Tags) Tags {
 // counterImpl implements the Counter interface
 type counterImpl struct {
 	baseMetric
-	value uint64
+	value      uint64
+	generation atomic.Uint64                       // see CounterSnapshot; taken from counterGenerations
+	shards     []counterShard                      // used instead of value when Options.Shards > 1
+	durable    *durableStore                       // used instead of value when Options.Durability is set
+	timestamp  int64                               // unix nanoseconds of the latest AddAt
+	exemplar   atomic.Pointer[Exemplar]            // set by AddWithExemplar
+	onNegative func(c *counterImpl, value float64) // set by the registry; nil ignores negative adds
+	derive     func(tags Tags) Counter             // set by the registry to look up With() series
 }
 
 func newCounter(opts Options) Counter {
-	return &counterImpl{
+	c := &counterImpl{
 		baseMetric: baseMetric{
 			name:        opts.Name,
 			description: opts.Description,
 			unit:        opts.Unit,
 			metricType:  TypeCounter,
 			tags:        opts.Tags,
+			annotations: maps.Clone(opts.Annotations),
 		},
+		shards: newCounterShards(opts.Shards),
 	}
+	c.generation.Store(counterGenerations.Add(1))
+	c.markCreated()
+	return c
 }
 
+// counterGenerations hands out counter generations, so no two series or resets share one
+var counterGenerations atomic.Uint64
+
 func (c *counterImpl) Inc() {
-	atomic.AddUint64(&c.value, 1)
+	if c.isPaused() {
+		return
+	}
+	c.markWritten()
+	c.add(1)
+	observe(c.sink, c, ObservationAdd, 1)
 }
 
 func (c *counterImpl) Add(value float64) {
+	if c.isPaused() {
+		return
+	}
+	c.markWritten()
 	// Only add if positive (counters should never decrease)
 	if value > 0 {
-		atomic.AddUint64(&c.value, uint64(value))
+		c.add(uint64(value))
+		observe(c.sink, c, ObservationAdd, value)
+	} else if value < 0 && c.onNegative != nil {
+		c.onNegative(c, value)
 	}
 }
 
 func (c *counterImpl) With(tags Tags) Counter {
-	return &counterImpl{
+	if c.derive != nil {
+		return c.derive(copyTags(c.tags, tags))
+	}
+	child := &counterImpl{
 		baseMetric: baseMetric{
 			name:        c.name,
-			description: c.description,
-			unit:        c.unit,
+			description: c.Description(),
+			unit:        c.Unit(),
 			metricType:  c.metricType,
 			tags:        copyTags(c.tags, tags),
+			annotations: c.annotations,
 		},
+		shards:     newCounterShards(len(c.shards)),
+		onNegative: c.onNegative,
 	}
+	child.generation.Store(counterGenerations.Add(1))
+	child.markCreated()
+	return child
 }
 
 func (c *counterImpl) Value() uint64 {
-	return atomic.LoadUint64(&c.value)
+	return c.load()
+}
+
+func (c *counterImpl) Swap() uint64 {
+	c.markCreated()
+	value := c.swap()
+	// The generation moves after the value is zeroed: a reader that sees the
+	// old generation with the reset value skips it, rather than seeing the new
+	// generation with the old value and counting it twice
+	c.generation.Store(counterGenerations.Add(1))
+	return value
+}
+
+func (c *counterImpl) Reset() {
+	c.Swap()
+}
+
+func (c *counterImpl) Snapshot() CounterSnapshot {
+	// Read the generation first, for the reason given in Swap
+	generation := c.generation.Load()
+	return CounterSnapshot{Value: c.load(), Generation: generation}
+}
+
+func (c *counterImpl) AddAt(value float64, ts time.Time) {
+	if c.isPaused() {
+		return
+	}
+	c.Add(value)
+	advanceTimestamp(&c.timestamp, ts)
+}
+
+func (c *counterImpl) LastTimestamp() time.Time {
+	return loadTimestamp(&c.timestamp)
+}
+
+// upDownCounterImpl implements the UpDownCounter interface
+type upDownCounterImpl struct {
+	baseMetric
+	value     int64
+	timestamp int64                         // unix nanoseconds of the latest AddAt
+	derive    func(tags Tags) UpDownCounter // set by the registry to look up With() series
+}
+
+func newUpDownCounter(opts Options) UpDownCounter {
+	return &upDownCounterImpl{
+		baseMetric: baseMetric{
+			name:        opts.Name,
+			description: opts.Description,
+			unit:        opts.Unit,
+			metricType:  TypeUpDownCounter,
+			tags:        opts.Tags,
+			annotations: maps.Clone(opts.Annotations),
+		},
+	}
+}
+
+func (c *upDownCounterImpl) Add(value float64) {
+	if c.isPaused() {
+		return
+	}
+	c.markWritten()
+	atomic.AddInt64(&c.value, int64(value))
+	observe(c.sink, c, ObservationAdd, value)
+}
+
+func (c *upDownCounterImpl) Inc() {
+	if c.isPaused() {
+		return
+	}
+	c.markWritten()
+	atomic.AddInt64(&c.value, 1)
+	observe(c.sink, c, ObservationAdd, 1)
+}
+
+func (c *upDownCounterImpl) Dec() {
+	if c.isPaused() {
+		return
+	}
+	c.markWritten()
+	atomic.AddInt64(&c.value, -1)
+	observe(c.sink, c, ObservationAdd, -1)
+}
+
+func (c *upDownCounterImpl) With(tags Tags) UpDownCounter {
+	if c.derive != nil {
+		return c.derive(copyTags(c.tags, tags))
+	}
+	return &upDownCounterImpl{
+		baseMetric: baseMetric{
+			name:        c.name,
+			description: c.Description(),
+			unit:        c.Unit(),
+			metricType:  c.metricType,
+			tags:        copyTags(c.tags, tags),
+			annotations: c.annotations,
+		},
+	}
+}
+
+func (c *upDownCounterImpl) Value() int64 {
+	return atomic.LoadInt64(&c.value)
+}
+
+func (c *upDownCounterImpl) AddAt(value float64, ts time.Time) {
+	if c.isPaused() {
+		return
+	}
+	c.Add(value)
+	advanceTimestamp(&c.timestamp, ts)
+}
+
+func (c *upDownCounterImpl) LastTimestamp() time.Time {
+	return loadTimestamp(&c.timestamp)
 }
 
 // gaugeImpl implements the Gauge interface
 type gaugeImpl struct {
 	baseMetric
-	value int64
+	value  int64
+	derive func(tags Tags) Gauge // set by the registry to look up With() series
 }
 
 func newGauge(opts Options) Gauge {
@@ -145,34 +379,59 @@ func newGauge(opts Options) Gauge {
 			unit:        opts.Unit,
 			metricType:  TypeGauge,
 			tags:        opts.Tags,
+			annotations: maps.Clone(opts.Annotations),
 		},
 	}
 }
 
 func (g *gaugeImpl) Set(value float64) {
+	if g.isPaused() {
+		return
+	}
+	g.markWritten()
 	atomic.StoreInt64(&g.value, int64(value))
+	observe(g.sink, g, ObservationSet, value)
 }
 
 func (g *gaugeImpl) Add(value float64) {
+	if g.isPaused() {
+		return
+	}
+	g.markWritten()
 	atomic.AddInt64(&g.value, int64(value))
+	observe(g.sink, g, ObservationAdd, value)
 }
 
 func (g *gaugeImpl) Inc() {
+	if g.isPaused() {
+		return
+	}
+	g.markWritten()
 	atomic.AddInt64(&g.value, 1)
+	observe(g.sink, g, ObservationAdd, 1)
 }
 
 func (g *gaugeImpl) Dec() {
+	if g.isPaused() {
+		return
+	}
+	g.markWritten()
 	atomic.AddInt64(&g.value, -1)
+	observe(g.sink, g, ObservationAdd, -1)
 }
 
 func (g *gaugeImpl) With(tags Tags) Gauge {
+	if g.derive != nil {
+		return g.derive(copyTags(g.tags, tags))
+	}
 	return &gaugeImpl{
 		baseMetric: baseMetric{
 			name:        g.name,
-			description: g.description,
-			unit:        g.unit,
+			description: g.Description(),
+			unit:        g.Unit(),
 			metricType:  g.metricType,
 			tags:        copyTags(g.tags, tags),
+			annotations: g.annotations,
 		},
 	}
 }
@@ -181,23 +440,99 @@ func (g *gaugeImpl) Value() int64 {
 	return atomic.LoadInt64(&g.value)
 }
 
+// gaugeFuncImpl implements a Gauge whose value is computed by a callback when read
+type gaugeFuncImpl struct {
+	baseMetric
+	fn     func() float64
+	derive func(tags Tags) Gauge // set by the registry to look up With() series
+}
+
+func newGaugeFunc(opts Options, fn func() float64) Gauge {
+	return &gaugeFuncImpl{
+		baseMetric: baseMetric{
+			name:        opts.Name,
+			description: opts.Description,
+			unit:        opts.Unit,
+			metricType:  TypeGauge,
+			tags:        opts.Tags,
+			annotations: maps.Clone(opts.Annotations),
+		},
+		fn: fn,
+	}
+}
+
+// Set is a no-op; the value is always computed by the callback
+func (g *gaugeFuncImpl) Set(value float64) {}
+
+// Add is a no-op; the value is always computed by the callback
+func (g *gaugeFuncImpl) Add(value float64) {}
+
+// Inc is a no-op; the value is always computed by the callback
+func (g *gaugeFuncImpl) Inc() {}
+
+// Dec is a no-op; the value is always computed by the callback
+func (g *gaugeFuncImpl) Dec() {}
+
+func (g *gaugeFuncImpl) With(tags Tags) Gauge {
+	if g.derive != nil {
+		return g.derive(copyTags(g.tags, tags))
+	}
+	return &gaugeFuncImpl{
+		baseMetric: baseMetric{
+			name:        g.name,
+			description: g.Description(),
+			unit:        g.Unit(),
+			metricType:  g.metricType,
+			tags:        copyTags(g.tags, tags),
+			annotations: g.annotations,
+		},
+		fn: g.fn,
+	}
+}
+
+// Value returns the callback's result, rounded
+func (g *gaugeFuncImpl) Value() int64 {
+	return int64(math.Round(g.fn()))
+}
+
+// FloatValue returns the callback's result, which exporters report unrounded
+func (g *gaugeFuncImpl) FloatValue() float64 {
+	return g.fn()
+}
+
 // histogramImpl implements the Histogram interface
 type histogramImpl struct {
 	baseMetric
-	count         uint64
-	sum           uint64
-	min           uint64
-	max           uint64
-	buckets       []uint64  // Bucket counts
-	boundaries    []float64 // Bucket boundaries
+	count       uint64
+	sum         uint64      // float64 bits
+	min         uint64      // float64 bits, +Inf until the first observation
+	max         uint64      // float64 bits, -Inf until the first observation
+	initialized atomic.Bool // set once min and max hold a real observation
+	buckets     []uint64    // Bucket counts
+	boundaries  []float64   // Bucket boundaries, shared with the whole family
+	family      *histogramFamily
+	exemplarMu  sync.Mutex
+	exemplars   []*Exemplar      // latest exemplar of each bucket; allocated on first use
+	shards      []histogramShard // used instead of the fields above when Options.HighContention is set
+}
+
+// histogramFamily is the configuration and child set shared by a histogram
+// and every series derived from it with With()
+type histogramFamily struct {
+	mu       sync.Mutex
+	children map[string]*histogramImpl // keyed by canonical tag set
+	// register is called once for each new child and returns the series to use for
+	// its tag set; set by the registry that created the family
+	register func(child *histogramImpl) *histogramImpl
+	// processTags rewrites the tags of new children; set by the registry that created the family
+	processTags func(Tags) Tags
 }
 
 func newHistogram(opts Options) Histogram {
-	// Use provided buckets or default ones
+	// Use provided buckets or defaults suited to the unit
 	boundaries := opts.Buckets
 	if len(boundaries) == 0 {
-		// Default buckets: exponential buckets from 0.001 to 10000
-		boundaries = []float64{0.001, 0.01, 0.1, 1, 10, 100, 1000, 10000}
+		boundaries = DefaultBucketsFor(TypeHistogram, opts.Unit)
 	}
 	
 	// Validate bucket boundaries
@@ -206,33 +541,66 @@ func newHistogram(opts Options) Histogram {
 		panic(fmt.Sprintf("invalid histogram buckets: %v", err))
 	}
 
-	return &histogramImpl{
+	h := &histogramImpl{
 		baseMetric: baseMetric{
 			name:        opts.Name,
 			description: opts.Description,
 			unit:        opts.Unit,
 			metricType:  TypeHistogram,
 			tags:        opts.Tags,
+			annotations: maps.Clone(opts.Annotations),
 		},
+		min:        math.Float64bits(math.Inf(1)),
+		max:        math.Float64bits(math.Inf(-1)),
 		boundaries: boundaries,
 		buckets:    make([]uint64, len(boundaries)+1), // +1 for the +Inf bucket
+		family:     &histogramFamily{children: make(map[string]*histogramImpl)},
 	}
+	if opts.HighContention {
+		h.shards = newHistogramShards(len(h.buckets))
+	}
+	h.markCreated()
+	return h
 }
 
 func (h *histogramImpl) Observe(value float64) {
-	// Convert to uint64 for atomic operations
-	v := uint64(value)
-
+	if h.isPaused() {
+		return
+	}
+	h.markWritten()
+	if h.shards != nil {
+		h.shard().observe(h.findBucket(value), value)
+		observe(h.sink, h, ObservationSample, value)
+		return
+	}
 	atomic.AddUint64(&h.count, 1)
-	atomic.AddUint64(&h.sum, v)
+	h.addSum(value)
 
 	// Find the appropriate bucket using binary search for O(log n) performance
 	bucketIndex := h.findBucket(value)
 	atomic.AddUint64(&h.buckets[bucketIndex], 1)
 
 	// Update min/max using compare-and-swap to avoid race conditions
-	h.updateMin(v)
-	h.updateMax(v)
+	h.updateMin(value)
+	h.updateMax(value)
+	h.initialized.Store(true)
+	observe(h.sink, h, ObservationSample, value)
+}
+
+// addSum atomically adds v to the float64 sum using compare-and-swap
+func (h *histogramImpl) addSum(v float64) {
+	addFloat(&h.sum, v)
+}
+
+// addFloat atomically adds v to the float64 stored as bits at addr
+func addFloat(addr *uint64, v float64) {
+	for {
+		current := atomic.LoadUint64(addr)
+		next := math.Float64bits(math.Float64frombits(current) + v)
+		if atomic.CompareAndSwapUint64(addr, current, next) {
+			return
+		}
+	}
 }
 
 // findBucket uses binary search to find the appropriate bucket for the given value
@@ -253,12 +621,17 @@ func (h *histogramImpl) findBucket(value float64) int {
 }
 
 // updateMin safely updates the minimum value using compare-and-swap
-func (h *histogramImpl) updateMin(v uint64) {
+func (h *histogramImpl) updateMin(v float64) {
+	lowerFloat(&h.min, v)
+}
+
+// lowerFloat atomically replaces the float64 stored as bits at addr with v if v is lower
+func lowerFloat(addr *uint64, v float64) {
 	for {
-		current := atomic.LoadUint64(&h.min)
-		// If current is 0 (uninitialized) or v is smaller, update it
-		if current == 0 || v < current {
-			if atomic.CompareAndSwapUint64(&h.min, current, v) {
+		current := atomic.LoadUint64(addr)
+		// min starts at +Inf, so the first observation always replaces it
+		if v < math.Float64frombits(current) {
+			if atomic.CompareAndSwapUint64(addr, current, math.Float64bits(v)) {
 				break
 			}
 			// If CAS failed, another goroutine updated it, try again
@@ -270,12 +643,17 @@ func (h *histogramImpl) updateMin(v uint64) {
 }
 
 // updateMax safely updates the maximum value using compare-and-swap
-func (h *histogramImpl) updateMax(v uint64) {
+func (h *histogramImpl) updateMax(v float64) {
+	raiseFloat(&h.max, v)
+}
+
+// raiseFloat atomically replaces the float64 stored as bits at addr with v if v is higher
+func raiseFloat(addr *uint64, v float64) {
 	for {
-		current := atomic.LoadUint64(&h.max)
-		// If v is larger than current, update it
-		if v > current {
-			if atomic.CompareAndSwapUint64(&h.max, current, v) {
+		current := atomic.LoadUint64(addr)
+		// max starts at -Inf, so the first observation always replaces it
+		if v > math.Float64frombits(current) {
+			if atomic.CompareAndSwapUint64(addr, current, math.Float64bits(v)) {
 				break
 			}
 			// If CAS failed, another goroutine updated it, try again
@@ -287,15 +665,78 @@ func (h *histogramImpl) updateMax(v uint64) {
 }
 
 func (h *histogramImpl) With(tags Tags) Histogram {
-	return &histogramImpl{
+	return h.child(tags)
+}
+
+// child returns the series of this histogram's family with the merged tag set,
+// creating and registering it on first use. Repeated calls with the same tags
+// return the same series, so observations accumulate in one place.
+func (h *histogramImpl) child(tags Tags) *histogramImpl {
+	merged := copyTags(h.tags, tags)
+	if h.family.processTags != nil {
+		merged = h.family.processTags(merged)
+	}
+	key := tagsKey(merged)
+	if key == tagsKey(h.tags) {
+		return h
+	}
+
+	h.family.mu.Lock()
+	if c, ok := h.family.children[key]; ok {
+		h.family.mu.Unlock()
+		return c
+	}
+	if h.family.register != nil {
+		// Registering takes its own references; these only cover creating the child
+		merged = interned.intern(merged)
+		defer interned.release(merged)
+	}
+
+	c := &histogramImpl{
 		baseMetric: baseMetric{
 			name:        h.name,
-			description: h.description,
-			unit:        h.unit,
+			description: h.Description(),
+			unit:        h.Unit(),
 			metricType:  h.metricType,
-			tags:        copyTags(h.tags, tags),
+			tags:        merged,
+			annotations: h.annotations,
+			sink:        h.sink,
+			paused:      h.paused,
 		},
-		buckets: make([]uint64, len(h.buckets)),
+		min:        math.Float64bits(math.Inf(1)),
+		max:        math.Float64bits(math.Inf(-1)),
+		boundaries: h.boundaries,
+		buckets:    make([]uint64, len(h.buckets)),
+		family:     h.family,
+	}
+	if h.shards != nil {
+		c.shards = newHistogramShards(len(h.buckets))
+	}
+	c.markCreated()
+	h.family.children[key] = c
+	register := h.family.register
+	h.family.mu.Unlock()
+
+	if register != nil {
+		if registered := register(c); registered != c {
+			// The registry already holds a series for these tags; share it
+			h.family.mu.Lock()
+			h.family.children[key] = registered
+			h.family.mu.Unlock()
+			c = registered
+		}
+	}
+	return c
+}
+
+// detach removes h from its family's child set, so a later With() with the same
+// tags creates and registers a fresh series instead of returning h
+func (h *histogramImpl) detach() {
+	key := tagsKey(h.tags)
+	h.family.mu.Lock()
+	defer h.family.mu.Unlock()
+	if h.family.children[key] == h {
+		delete(h.family.children, key)
 	}
 }
 
@@ -306,21 +747,63 @@ func (h *histogramImpl) Snapshot() HistogramSnapshot {
 		buckets[i] = atomic.LoadUint64(&h.buckets[i])
 	}
 	
-	return HistogramSnapshot{
-		Count:   atomic.LoadUint64(&h.count),
-		Sum:     atomic.LoadUint64(&h.sum),
-		Min:     atomic.LoadUint64(&h.min),
-		Max:     atomic.LoadUint64(&h.max),
-		Buckets: buckets,
+	snapshot := HistogramSnapshot{
+		Count:      atomic.LoadUint64(&h.count),
+		Sum:        math.Float64frombits(atomic.LoadUint64(&h.sum)),
+		Buckets:    buckets,
+		Boundaries: append([]float64(nil), h.boundaries...),
+	}
+
+	// Min and max stay zero until a value has actually been observed
+	if h.initialized.Load() {
+		snapshot.Min = math.Float64frombits(atomic.LoadUint64(&h.min))
+		snapshot.Max = math.Float64frombits(atomic.LoadUint64(&h.max))
+	}
+	h.mergeShards(&snapshot, false)
+
+	return snapshot
+}
+
+func (h *histogramImpl) SnapshotAndReset() HistogramSnapshot {
+	h.markCreated()
+	buckets := make([]uint64, len(h.buckets))
+	for i := range h.buckets {
+		buckets[i] = atomic.SwapUint64(&h.buckets[i], 0)
 	}
+
+	snapshot := HistogramSnapshot{
+		Count:      atomic.SwapUint64(&h.count, 0),
+		Sum:        math.Float64frombits(atomic.SwapUint64(&h.sum, 0)),
+		Buckets:    buckets,
+		Boundaries: append([]float64(nil), h.boundaries...),
+	}
+
+	// Clear initialized first, so an observation racing with the reset marks
+	// the histogram initialized again after storing its min and max
+	if h.initialized.Swap(false) {
+		lowest := math.Float64frombits(atomic.SwapUint64(&h.min, math.Float64bits(math.Inf(1))))
+		highest := math.Float64frombits(atomic.SwapUint64(&h.max, math.Float64bits(math.Inf(-1))))
+		if !math.IsInf(lowest, 1) {
+			snapshot.Min = lowest
+			snapshot.Max = highest
+		}
+	}
+	h.mergeShards(&snapshot, true)
+
+	return snapshot
 }
 
 // timerImpl implements the Timer interface
 type timerImpl struct {
 	histogram Histogram
+	sink      ObservationSink // set by the registry; the histogram itself has none
+	audit     *timerAudit     // set by the registry; nil unless WithTimerAudit is set
 }
 
 func newTimer(opts Options) Timer {
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = DefaultBucketsFor(TypeTimer, opts.Unit)
+	}
 	return &timerImpl{
 		histogram: newHistogram(opts),
 	}
@@ -334,6 +817,29 @@ func (t *timerImpl) Description() string {
 	return t.histogram.Description()
 }
 
+func (t *timerImpl) Unit() string {
+	return t.histogram.Unit()
+}
+
+func (t *timerImpl) Created() time.Time {
+	if h, ok := t.histogram.(CreatedTimestamper); ok {
+		return h.Created()
+	}
+	return time.Time{}
+}
+
+func (t *timerImpl) detach() {
+	if h, ok := t.histogram.(familyMember); ok {
+		h.detach()
+	}
+}
+
+func (t *timerImpl) setMetadata(md Metadata) {
+	if h, ok := t.histogram.(metadataSetter); ok {
+		h.setMetadata(md)
+	}
+}
+
 func (t *timerImpl) Type() Type {
 	return TypeTimer
 }
@@ -343,7 +849,14 @@ func (t *timerImpl) Tags() Tags {
 }
 
 func (t *timerImpl) Record(d time.Duration) {
+	if h, ok := t.histogram.(*histogramImpl); ok && h.isPaused() {
+		return
+	}
+	if t.audit != nil {
+		t.audit.check(t, d)
+	}
 	t.histogram.Observe(float64(d.Nanoseconds()))
+	observe(t.sink, t, ObservationSample, float64(d.Nanoseconds()))
 }
 
 func (t *timerImpl) RecordSince(start time.Time) {
@@ -359,8 +872,14 @@ func (t *timerImpl) Time(fn func()) time.Duration {
 }
 
 func (t *timerImpl) With(tags Tags) Timer {
+	child := t.histogram.With(tags)
+	if child == t.histogram {
+		return t
+	}
 	return &timerImpl{
-		histogram: t.histogram.With(tags),
+		histogram: child,
+		sink:      t.sink,
+		audit:     t.audit,
 	}
 }
 
@@ -368,8 +887,121 @@ func (t *timerImpl) Snapshot() HistogramSnapshot {
 	return t.histogram.Snapshot()
 }
 
+func (t *timerImpl) SnapshotAndReset() HistogramSnapshot {
+	return t.histogram.SnapshotAndReset()
+}
+
+func (t *timerImpl) Annotation(key string) (string, bool) {
+	return AnnotationOf(t.histogram, key)
+}
+
+func (t *timerImpl) Annotations() map[string]string {
+	if a, ok := t.histogram.(Annotated); ok {
+		return a.Annotations()
+	}
+	return nil
+}
+
+func (t *timerImpl) setWriteClock(clock Clock) {
+	if h, ok := t.histogram.(writeClockSetter); ok {
+		h.setWriteClock(clock)
+	}
+}
+
+func (t *timerImpl) takeLastWrite() time.Time {
+	if h, ok := t.histogram.(lastWriteTracker); ok {
+		return h.takeLastWrite()
+	}
+	return time.Time{}
+}
+
+func (t *timerImpl) takeWritten() bool {
+	h, ok := t.histogram.(writeTracker)
+	return ok && h.takeWritten()
+}
+
 // Helper functions
 
+// advanceTimestamp moves the unix-nanosecond timestamp at addr forward to ts, never backward
+func advanceTimestamp(addr *int64, ts time.Time) {
+	next := ts.UnixNano()
+	for {
+		current := atomic.LoadInt64(addr)
+		if next <= current || atomic.CompareAndSwapInt64(addr, current, next) {
+			return
+		}
+	}
+}
+
+// loadTimestamp returns the timestamp stored at addr, or the zero time if none was recorded
+func loadTimestamp(addr *int64) time.Time {
+	ns := atomic.LoadInt64(addr)
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// canonicalTags renders tags in a stable, sorted form for display and ordering.
+// It does not escape separators, so identify series with tagsKey instead.
+func canonicalTags(tags Tags) string {
+	if len(tags) == 0 {
+		return "{}"
+	}
+	return string(appendCanonicalTags(make([]byte, 0, 64), tags))
+}
+
+// appendCanonicalTags appends the canonical form of tags to dst. Keys of small
+// tag sets are sorted on the stack, so the only allocation is growing dst.
+func appendCanonicalTags(dst []byte, tags Tags) []byte {
+	var small [8]string
+	keys := small[:0]
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	dst = append(dst, '{')
+	for i, k := range keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, k...)
+		dst = append(dst, '=')
+		dst = append(dst, tags[k]...)
+	}
+	return append(dst, '}')
+}
+
+// tagsKey encodes tags as a map key that identifies the tag set
+func tagsKey(tags Tags) string {
+	return string(appendTagsKey(make([]byte, 0, 64), tags))
+}
+
+// appendTagsKey appends each key and value of tags to dst in sorted key order,
+// prefixed with its length so that separators inside a value cannot make two
+// tag sets collide, as in {a="1,b=2"} and {a="1",b="2"}
+func appendTagsKey(dst []byte, tags Tags) []byte {
+	var small [8]string
+	keys := small[:0]
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		dst = appendLengthPrefixed(dst, k)
+		dst = appendLengthPrefixed(dst, tags[k])
+	}
+	return dst
+}
+
+// appendLengthPrefixed appends s to dst preceded by its length as a uvarint
+func appendLengthPrefixed(dst []byte, s string) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(s)))
+	return append(dst, s...)
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,149 @@
+package metric
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// LockWaitMetric is the timer recording how long callers waited to acquire a named lock
+	LockWaitMetric = "lock_wait_duration"
+	// LockHoldMetric is the timer recording how long a named lock was held for writing
+	LockHoldMetric = "lock_hold_duration"
+)
+
+// lockBuckets spans 1µs to about 260ms, the useful range for lock contention
+var lockBuckets = GenerateExponentialBuckets(float64(time.Microsecond), 4, 10)
+
+// lockTimer returns the series of timer name for the named lock and access mode
+func lockTimer(registry Registry, name, description, lock, mode string) Timer {
+	return registry.Timer(Options{
+		Name:        name,
+		Description: description,
+		Unit:        "nanoseconds",
+		Buckets:     lockBuckets,
+	}).With(Tags{"lock": lock, "mode": mode})
+}
+
+// InstrumentedMutex is a sync.Mutex that records wait and hold times as
+// lock_wait_duration and lock_hold_duration timers tagged with the lock name
+// and mode=write. The zero value is an uninstrumented, unlocked mutex.
+type InstrumentedMutex struct {
+	mu       sync.Mutex
+	wait     Timer
+	hold     Timer
+	acquired time.Time // guarded by mu
+}
+
+// NewInstrumentedMutex creates a mutex recording into registry under the given lock name
+func NewInstrumentedMutex(registry Registry, name string) *InstrumentedMutex {
+	return &InstrumentedMutex{
+		wait: lockTimer(registry, LockWaitMetric, "Time spent waiting to acquire a lock", name, "write"),
+		hold: lockTimer(registry, LockHoldMetric, "Time a lock was held", name, "write"),
+	}
+}
+
+// Lock locks m, recording the time spent waiting
+func (m *InstrumentedMutex) Lock() {
+	if m.wait == nil {
+		m.mu.Lock()
+		return
+	}
+	start := time.Now()
+	m.mu.Lock()
+	m.acquired = time.Now()
+	m.wait.Record(m.acquired.Sub(start))
+}
+
+// TryLock tries to lock m without waiting and reports whether it succeeded
+func (m *InstrumentedMutex) TryLock() bool {
+	if !m.mu.TryLock() {
+		return false
+	}
+	if m.wait != nil {
+		m.acquired = time.Now()
+		m.wait.Record(0)
+	}
+	return true
+}
+
+// Unlock unlocks m, recording how long it was held
+func (m *InstrumentedMutex) Unlock() {
+	if m.hold == nil {
+		m.mu.Unlock()
+		return
+	}
+	held := time.Since(m.acquired)
+	m.mu.Unlock()
+	m.hold.Record(held)
+}
+
+// InstrumentedRWMutex is a sync.RWMutex that records wait times for readers
+// (mode=read) and writers (mode=write), and hold times for writers. Read hold
+// times are not recorded because readers share the lock. The zero value is an
+// uninstrumented, unlocked mutex.
+type InstrumentedRWMutex struct {
+	mu        sync.RWMutex
+	readWait  Timer
+	writeWait Timer
+	hold      Timer
+	acquired  time.Time // guarded by the write lock
+}
+
+// NewInstrumentedRWMutex creates a read/write mutex recording into registry under the given lock name
+func NewInstrumentedRWMutex(registry Registry, name string) *InstrumentedRWMutex {
+	return &InstrumentedRWMutex{
+		readWait:  lockTimer(registry, LockWaitMetric, "Time spent waiting to acquire a lock", name, "read"),
+		writeWait: lockTimer(registry, LockWaitMetric, "Time spent waiting to acquire a lock", name, "write"),
+		hold:      lockTimer(registry, LockHoldMetric, "Time a lock was held", name, "write"),
+	}
+}
+
+// Lock locks m for writing, recording the time spent waiting
+func (m *InstrumentedRWMutex) Lock() {
+	if m.writeWait == nil {
+		m.mu.Lock()
+		return
+	}
+	start := time.Now()
+	m.mu.Lock()
+	m.acquired = time.Now()
+	m.writeWait.Record(m.acquired.Sub(start))
+}
+
+// Unlock unlocks m for writing, recording how long it was held
+func (m *InstrumentedRWMutex) Unlock() {
+	if m.hold == nil {
+		m.mu.Unlock()
+		return
+	}
+	held := time.Since(m.acquired)
+	m.mu.Unlock()
+	m.hold.Record(held)
+}
+
+// RLock locks m for reading, recording the time spent waiting
+func (m *InstrumentedRWMutex) RLock() {
+	if m.readWait == nil {
+		m.mu.RLock()
+		return
+	}
+	start := time.Now()
+	m.mu.RLock()
+	m.readWait.RecordSince(start)
+}
+
+// RUnlock undoes a single RLock call
+func (m *InstrumentedRWMutex) RUnlock() {
+	m.mu.RUnlock()
+}
+
+// RLocker returns a Locker that locks m for reading
+func (m *InstrumentedRWMutex) RLocker() sync.Locker {
+	return (*rlocker)(m)
+}
+
+type rlocker InstrumentedRWMutex
+
+func (r *rlocker) Lock()   { (*InstrumentedRWMutex)(r).RLock() }
+func (r *rlocker) Unlock() { (*InstrumentedRWMutex)(r).RUnlock() }
@@ -0,0 +1,85 @@
+package metric
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInstrumentedMutex(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	mu := NewInstrumentedMutex(registry, "cache")
+	mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		mu.Lock() // waits for the holder below
+		mu.Unlock()
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	mu.Unlock()
+	<-done
+
+	if !mu.TryLock() {
+		t.Fatal("Expected TryLock on an unlocked mutex to succeed")
+	}
+	if mu.TryLock() {
+		t.Fatal("Expected TryLock on a locked mutex to fail")
+	}
+	mu.Unlock()
+
+	tags := Tags{"lock": "cache", "mode": "write"}
+	wait := registry.Timer(Options{Name: LockWaitMetric}).With(tags).Snapshot()
+	hold := registry.Timer(Options{Name: LockHoldMetric}).With(tags).Snapshot()
+
+	if wait.Count != 3 || hold.Count != 3 {
+		t.Errorf("Expected 3 acquisitions and releases, got wait=%d hold=%d", wait.Count, hold.Count)
+	}
+	if wait.Max < float64(10*time.Millisecond) {
+		t.Errorf("Expected the contended Lock to wait at least 10ms, max wait %v", time.Duration(wait.Max))
+	}
+	if hold.Max < float64(10*time.Millisecond) {
+		t.Errorf("Expected the first hold to last at least 10ms, max hold %v", time.Duration(hold.Max))
+	}
+}
+
+func TestInstrumentedRWMutex(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	mu := NewInstrumentedRWMutex(registry, "config")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mu.RLocker().Lock()
+			defer mu.RLocker().Unlock()
+		}()
+	}
+	wg.Wait()
+	mu.Lock()
+	mu.Unlock()
+
+	timer := registry.Timer(Options{Name: LockWaitMetric})
+	if reads := timer.With(Tags{"lock": "config", "mode": "read"}).Snapshot().Count; reads != 4 {
+		t.Errorf("Expected 4 read acquisitions, got %d", reads)
+	}
+	if writes := timer.With(Tags{"lock": "config", "mode": "write"}).Snapshot().Count; writes != 1 {
+		t.Errorf("Expected 1 write acquisition, got %d", writes)
+	}
+}
+
+func TestInstrumentedMutexZeroValue(t *testing.T) {
+	var mu InstrumentedMutex
+	mu.Lock()
+	mu.Unlock()
+
+	var rw InstrumentedRWMutex
+	rw.RLock()
+	rw.RUnlock()
+	rw.Lock()
+	rw.Unlock()
+}
@@ -0,0 +1,100 @@
+package metric
+
+import "sync/atomic"
+
+// NegativeAddPolicy controls what a registry's counters do when Add is called with a negative value
+type NegativeAddPolicy int
+
+const (
+	// NegativeAddIgnore drops negative values; the counter is unchanged (default)
+	NegativeAddIgnore NegativeAddPolicy = iota
+	// NegativeAddClamp subtracts the value from the counter, stopping at zero.
+	// The counter is no longer monotonic, so backends may read a decrease as a reset.
+	NegativeAddClamp
+	// NegativeAddError drops negative values and passes them to the NegativeAddHandler
+	NegativeAddError
+	// NegativeAddDownSeries records negative values in a companion gauge named with
+	// DownSeriesSuffix, so the counter stays monotonic while the net total is
+	// counter + down series
+	NegativeAddDownSeries
+)
+
+// DownSeriesSuffix is appended to a counter's name to form the down-adjustment
+// series used by NegativeAddDownSeries
+const DownSeriesSuffix = "_down"
+
+// NegativeAddHandler is called with the counter and the rejected value under NegativeAddError
+type NegativeAddHandler func(c Counter, value float64)
+
+// WithNegativeAddPolicy sets how counters created by the registry handle negative adds.
+// With WithMetaMetrics, every negative add is also counted in
+// gometrics_registry_negative_adds_total whatever the policy.
+func WithNegativeAddPolicy(policy NegativeAddPolicy) RegistryOption {
+	return func(r *defaultRegistry) {
+		r.negativePolicy = policy
+	}
+}
+
+// WithNegativeAddHandler sets the NegativeAddError policy with handler as its callback
+func WithNegativeAddHandler(handler NegativeAddHandler) RegistryOption {
+	return func(r *defaultRegistry) {
+		r.negativePolicy = NegativeAddError
+		r.negativeHandler = handler
+	}
+}
+
+// onNegativeAdd applies the registry's negative add policy to a counter it created
+func (r *defaultRegistry) onNegativeAdd(c *counterImpl, value float64) {
+	if r.meta != nil {
+		r.meta.negativeAdds.Inc()
+	}
+
+	switch r.negativePolicy {
+	case NegativeAddClamp:
+		c.subtract(uint64(-value))
+	case NegativeAddError:
+		if r.negativeHandler != nil {
+			r.negativeHandler(c, value)
+		}
+	case NegativeAddDownSeries:
+		r.Gauge(Options{
+			Name:        c.name + DownSeriesSuffix,
+			Description: "Negative adjustments to " + c.name,
+			Unit:        c.unit,
+			Tags:        c.tags,
+		}).Add(value)
+	}
+}
+
+// subtract lowers the counter by delta without going below zero
+func (c *counterImpl) subtract(delta uint64) {
+	if c.durable != nil {
+		c.durable.subtract(delta)
+		return
+	}
+	if c.shards == nil {
+		subtractClamped(&c.value, delta)
+		return
+	}
+	for i := range c.shards {
+		if delta == 0 {
+			return
+		}
+		delta -= subtractClamped(&c.shards[i].value, delta)
+	}
+}
+
+// subtractClamped lowers the value at addr by up to delta without going below
+// zero and returns the amount subtracted
+func subtractClamped(addr *uint64, delta uint64) uint64 {
+	for {
+		current := atomic.LoadUint64(addr)
+		taken := delta
+		if current < taken {
+			taken = current
+		}
+		if atomic.CompareAndSwapUint64(addr, current, current-taken) {
+			return taken
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package metric
+
+import "testing"
+
+func TestNegativeAddPolicies(t *testing.T) {
+	t.Run("ignore by default", func(t *testing.T) {
+		registry := NewNoCleanupRegistry(WithMetaMetrics())
+		defer registry.Close()
+
+		counter := registry.Counter(Options{Name: "requests_total"})
+		counter.Add(5)
+		counter.Add(-2)
+		if counter.Value() != 5 {
+			t.Errorf("Expected negative add to be ignored, got %d", counter.Value())
+		}
+		if v := metaValues(registry)[MetaMetricPrefix+"negative_adds_total{}"]; v != 1 {
+			t.Errorf("Expected 1 negative add to be counted, got %v", v)
+		}
+	})
+
+	t.Run("clamp", func(t *testing.T) {
+		registry := NewNoCleanupRegistry(WithNegativeAddPolicy(NegativeAddClamp))
+		defer registry.Close()
+
+		counter := registry.Counter(Options{Name: "items"}).With(Tags{"shard": "a"})
+		counter.Add(5)
+		counter.Add(-2)
+		if counter.Value() != 3 {
+			t.Errorf("Expected 3 after clamped subtraction, got %d", counter.Value())
+		}
+		counter.Add(-10)
+		if counter.Value() != 0 {
+			t.Errorf("Expected counter clamped at 0, got %d", counter.Value())
+		}
+	})
+
+	t.Run("error handler", func(t *testing.T) {
+		var rejected []float64
+		var rejectedName string
+		registry := NewNoCleanupRegistry(WithNegativeAddHandler(func(c Counter, value float64) {
+			rejectedName = c.Name()
+			rejected = append(rejected, value)
+		}))
+		defer registry.Close()
+
+		counter := registry.Counter(Options{Name: "bytes_total"})
+		counter.Add(-3)
+		if counter.Value() != 0 || len(rejected) != 1 || rejected[0] != -3 || rejectedName != "bytes_total" {
+			t.Errorf("Expected handler to receive -3 for bytes_total, got %v for %q (value %d)", rejected, rejectedName, counter.Value())
+		}
+	})
+
+	t.Run("down series", func(t *testing.T) {
+		registry := NewNoCleanupRegistry(WithNegativeAddPolicy(NegativeAddDownSeries))
+		defer registry.Close()
+
+		counter := registry.Counter(Options{Name: "balance", Tags: Tags{"account": "x"}})
+		counter.Add(10)
+		counter.Add(-4)
+		counter.Add(-1)
+		if counter.Value() != 10 {
+			t.Errorf("Expected counter to stay monotonic at 10, got %d", counter.Value())
+		}
+
+		down := registry.Gauge(Options{Name: "balance" + DownSeriesSuffix, Tags: Tags{"account": "x"}})
+		if down.Value() != -5 {
+			t.Errorf("Expected down series of -5, got %d", down.Value())
+		}
+		if down.Tags()["account"] != "x" {
+			t.Errorf("Expected down series to carry the counter tags, got %v", down.Tags())
+		}
+	})
+}
@@ -1,6 +1,9 @@
 package metric
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // noopRegistry implements Registry by discarding all metrics
 // This is useful for testing and scenarios where metrics are not needed
@@ -19,6 +22,18 @@ func (n *noopRegistry) Gauge(opts Options) Gauge {
 	return &noopGauge{name: opts.Name, metricType: TypeGauge, tags: opts.Tags}
 }
 
+func (n *noopRegistry) GaugeFunc(opts Options, fn func() float64) Gauge {
+	return &noopGauge{name: opts.Name, metricType: TypeGauge, tags: opts.Tags}
+}
+
+func (n *noopRegistry) Derived(opts Options, fn func(SnapshotView) float64) Gauge {
+	return &noopGauge{name: opts.Name, metricType: TypeGauge, tags: opts.Tags}
+}
+
+func (n *noopRegistry) UpDownCounter(opts Options) UpDownCounter {
+	return &noopUpDownCounter{name: opts.Name, metricType: TypeUpDownCounter, tags: opts.Tags}
+}
+
 func (n *noopRegistry) Histogram(opts Options) Histogram {
 	return &noopHistogram{name: opts.Name, metricType: TypeHistogram, tags: opts.Tags}
 }
@@ -27,12 +42,52 @@ func (n *noopRegistry) Timer(opts Options) Timer {
 	return &noopTimer{name: opts.Name, metricType: TypeTimer, tags: opts.Tags}
 }
 
+func (n *noopRegistry) Meter(opts Options) Meter {
+	return &noopMeter{name: opts.Name, metricType: TypeMeter, tags: opts.Tags}
+}
+
+func (n *noopRegistry) Cardinality(opts Options) Cardinality {
+	return &noopCardinality{name: opts.Name, metricType: TypeGauge, tags: opts.Tags}
+}
+
 func (n *noopRegistry) Unregister(name string) {}
 
+func (n *noopRegistry) UnregisterMetric(name string, t Type) {}
+
+func (n *noopRegistry) UnregisterWhere(match func(Metric) bool) {}
+
+func (n *noopRegistry) UnregisterPrefix(prefix string) {}
+
 func (n *noopRegistry) Each(fn func(Metric)) {}
+func (n *noopRegistry) EachByType(t Type, fn func(Metric)) {}
+func (n *noopRegistry) Lookup(name string, t Type) (Metric, bool) { return nil, false }
+
+func (n *noopRegistry) Watch(ctx context.Context, filter WatchFilter) (<-chan MetricUpdate, error) {
+	updates := make(chan MetricUpdate)
+	context.AfterFunc(ctx, func() { close(updates) })
+	return updates, nil
+}
+
+func (n *noopRegistry) WatchThreshold(ctx context.Context, name string, condition Condition, fn func(ThresholdEvent)) error {
+	return nil
+}
 
 func (n *noopRegistry) ManualCleanup() {}
 
+func (n *noopRegistry) Series(name string) []SeriesInfo { return nil }
+
+func (n *noopRegistry) UpdateTagValidation(config TagValidationConfig) error { return nil }
+
+func (n *noopRegistry) SetMetadata(name, description, unit string) {}
+
+func (n *noopRegistry) OnExpire(fn func(Metric)) {}
+
+func (n *noopRegistry) Pause() {}
+
+func (n *noopRegistry) Resume() {}
+
+func (n *noopRegistry) Paused() bool { return false }
+
 func (n *noopRegistry) Close() error { return nil }
 
 // Noop metric implementations
@@ -44,15 +99,40 @@ type noopCounter struct {
 
 func (n *noopCounter) Name() string        { return n.name }
 func (n *noopCounter) Description() string { return "" }
+func (n *noopCounter) Unit() string        { return "" }
 func (n *noopCounter) Type() Type          { return n.metricType }
 func (n *noopCounter) Tags() Tags          { return n.tags }
 func (n *noopCounter) Inc()                {}
 func (n *noopCounter) Add(value float64)   {}
 func (n *noopCounter) Value() uint64       { return 0 }
+func (n *noopCounter) Swap() uint64        { return 0 }
+func (n *noopCounter) Reset()              {}
+func (n *noopCounter) Snapshot() CounterSnapshot {
+	return CounterSnapshot{}
+}
 func (n *noopCounter) With(tags Tags) Counter {
 	return &noopCounter{name: n.name, metricType: n.metricType, tags: tags}
 }
 
+type noopUpDownCounter struct {
+	name       string
+	metricType Type
+	tags       Tags
+}
+
+func (n *noopUpDownCounter) Name() string        { return n.name }
+func (n *noopUpDownCounter) Description() string { return "" }
+func (n *noopUpDownCounter) Unit() string        { return "" }
+func (n *noopUpDownCounter) Type() Type          { return n.metricType }
+func (n *noopUpDownCounter) Tags() Tags          { return n.tags }
+func (n *noopUpDownCounter) Add(value float64)   {}
+func (n *noopUpDownCounter) Inc()                {}
+func (n *noopUpDownCounter) Dec()                {}
+func (n *noopUpDownCounter) Value() int64        { return 0 }
+func (n *noopUpDownCounter) With(tags Tags) UpDownCounter {
+	return &noopUpDownCounter{name: n.name, metricType: n.metricType, tags: tags}
+}
+
 type noopGauge struct {
 	name       string
 	metricType Type
@@ -61,6 +141,7 @@ type noopGauge struct {
 
 func (n *noopGauge) Name() string        { return n.name }
 func (n *noopGauge) Description() string { return "" }
+func (n *noopGauge) Unit() string        { return "" }
 func (n *noopGauge) Type() Type          { return n.metricType }
 func (n *noopGauge) Tags() Tags          { return n.tags }
 func (n *noopGauge) Set(value float64)   {}
@@ -80,12 +161,16 @@ type noopHistogram struct {
 
 func (n *noopHistogram) Name() string              { return n.name }
 func (n *noopHistogram) Description() string       { return "" }
+func (n *noopHistogram) Unit() string              { return "" }
 func (n *noopHistogram) Type() Type                { return n.metricType }
 func (n *noopHistogram) Tags() Tags                { return n.tags }
 func (n *noopHistogram) Observe(value float64)     {}
 func (n *noopHistogram) Snapshot() HistogramSnapshot {
 	return HistogramSnapshot{}
 }
+func (n *noopHistogram) SnapshotAndReset() HistogramSnapshot {
+	return HistogramSnapshot{}
+}
 func (n *noopHistogram) With(tags Tags) Histogram {
 	return &noopHistogram{name: n.name, metricType: n.metricType, tags: tags}
 }
@@ -98,12 +183,49 @@ type noopTimer struct {
 
 func (n *noopTimer) Name() string                   { return n.name }
 func (n *noopTimer) Description() string            { return "" }
+func (n *noopTimer) Unit() string                   { return "" }
 func (n *noopTimer) Type() Type                     { return n.metricType }
 func (n *noopTimer) Tags() Tags                     { return n.tags }
 func (n *noopTimer) Record(d time.Duration)         {}
 func (n *noopTimer) RecordSince(t time.Time)        {}
 func (n *noopTimer) Time(fn func()) time.Duration   { fn(); return 0 }
 func (n *noopTimer) Snapshot() HistogramSnapshot { return HistogramSnapshot{} }
+func (n *noopTimer) SnapshotAndReset() HistogramSnapshot { return HistogramSnapshot{} }
 func (n *noopTimer) With(tags Tags) Timer {
 	return &noopTimer{name: n.name, metricType: n.metricType, tags: tags}
-}
\ No newline at end of file
+}
+type noopMeter struct {
+	name       string
+	metricType Type
+	tags       Tags
+}
+
+func (n *noopMeter) Name() string            { return n.name }
+func (n *noopMeter) Description() string     { return "" }
+func (n *noopMeter) Unit() string            { return "" }
+func (n *noopMeter) Type() Type              { return n.metricType }
+func (n *noopMeter) Tags() Tags              { return n.tags }
+func (n *noopMeter) Mark(count int64)        {}
+func (n *noopMeter) Count() int64            { return 0 }
+func (n *noopMeter) Snapshot() MeterSnapshot { return MeterSnapshot{} }
+func (n *noopMeter) With(tags Tags) Meter {
+	return &noopMeter{name: n.name, metricType: n.metricType, tags: tags}
+}
+
+type noopCardinality struct {
+	name       string
+	metricType Type
+	tags       Tags
+}
+
+func (n *noopCardinality) Name() string         { return n.name }
+func (n *noopCardinality) Description() string  { return "" }
+func (n *noopCardinality) Unit() string         { return "" }
+func (n *noopCardinality) Type() Type           { return n.metricType }
+func (n *noopCardinality) Tags() Tags           { return n.tags }
+func (n *noopCardinality) Observe(value string) {}
+func (n *noopCardinality) Estimate() uint64     { return 0 }
+func (n *noopCardinality) Reset()               {}
+func (n *noopCardinality) With(tags Tags) Cardinality {
+	return &noopCardinality{name: n.name, metricType: n.metricType, tags: tags}
+}
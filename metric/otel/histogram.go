@@ -0,0 +1,190 @@
+package otel
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	metricpkg "github.com/MichaelAJay/go-metrics/metric"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// histogramProducer is an sdkmetric.Producer exporting histograms and timers
+// from the bucket counts of their last reported snapshots. SDK histogram
+// instruments only take single observations, which cannot reproduce the bucket
+// counts of a snapshot, so reported histograms bypass the meter.
+type histogramProducer struct {
+	scope  instrumentation.Scope
+	mu     sync.Mutex
+	series map[callbackKey]*histogramSeries
+}
+
+// histogramSeries is the exported state of one histogram or timer series
+type histogramSeries struct {
+	name, description, unit string
+	attrs                   attribute.Set
+	start                   time.Time
+	point                   metricdata.HistogramDataPoint[float64]
+}
+
+func newHistogramProducer(scope string) *histogramProducer {
+	return &histogramProducer{
+		scope:  instrumentation.Scope{Name: scope},
+		series: make(map[callbackKey]*histogramSeries),
+	}
+}
+
+// record stores point as the cumulative state of the series of name with
+// attrs, or adds it to that state if delta is set, and returns the series key.
+// The series starts at created, or now if that is zero.
+func (p *histogramProducer) record(name, description, unit string, attrs []attribute.KeyValue, created time.Time, point metricdata.HistogramDataPoint[float64], delta bool) callbackKey {
+	key := newCallbackKey("histogram", name, attrs)
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, exists := p.series[key]
+	switch {
+	case !exists:
+		s = &histogramSeries{
+			name:        name,
+			description: description,
+			unit:        cmp.Or(unit, "1"),
+			attrs:       attribute.NewSet(attrs...),
+			start:       cmp.Or(created, now),
+		}
+		p.series[key] = s
+	case delta && slices.Equal(point.Bounds, s.point.Bounds):
+		point = mergePoints(s.point, point)
+	case delta, point.Count < s.point.Count:
+		// New boundaries, or a histogram reset since the last report, start a new series
+		s.start = now
+	}
+	s.point = point
+	return key
+}
+
+// retain drops the series whose keys are not in keep, so histograms removed
+// from the registry stop being exported
+func (p *histogramProducer) retain(keep map[callbackKey]bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key := range p.series {
+		if !keep[key] {
+			delete(p.series, key)
+		}
+	}
+}
+
+// Produce implements sdkmetric.Producer
+func (p *histogramProducer) Produce(context.Context) ([]metricdata.ScopeMetrics, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.series) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	byName := make(map[string]*metricdata.Metrics)
+	var names []string
+	for _, s := range p.series {
+		m, exists := byName[s.name]
+		if !exists {
+			m = &metricdata.Metrics{
+				Name:        s.name,
+				Description: s.description,
+				Unit:        s.unit,
+				Data:        metricdata.Histogram[float64]{Temporality: metricdata.CumulativeTemporality},
+			}
+			byName[s.name] = m
+			names = append(names, s.name)
+		}
+		point := s.point
+		point.Attributes = s.attrs
+		point.StartTime = s.start
+		point.Time = now
+		point.Bounds = slices.Clone(point.Bounds)
+		point.BucketCounts = slices.Clone(point.BucketCounts)
+
+		data := m.Data.(metricdata.Histogram[float64])
+		data.DataPoints = append(data.DataPoints, point)
+		m.Data = data
+	}
+
+	sort.Strings(names)
+	metrics := make([]metricdata.Metrics, len(names))
+	for i, name := range names {
+		metrics[i] = *byName[name]
+	}
+	return []metricdata.ScopeMetrics{{Scope: p.scope, Metrics: metrics}}, nil
+}
+
+// mergePoints returns the data point holding the observations of both points,
+// which have the same bounds
+func mergePoints(a, b metricdata.HistogramDataPoint[float64]) metricdata.HistogramDataPoint[float64] {
+	merged := metricdata.HistogramDataPoint[float64]{
+		Count:        a.Count + b.Count,
+		Sum:          a.Sum + b.Sum,
+		Bounds:       a.Bounds,
+		BucketCounts: slices.Clone(a.BucketCounts),
+		Min:          a.Min,
+		Max:          a.Max,
+	}
+	for i, count := range b.BucketCounts {
+		merged.BucketCounts[i] += count
+	}
+	if lowest, ok := b.Min.Value(); ok {
+		if current, ok := a.Min.Value(); !ok || lowest < current {
+			merged.Min = b.Min
+		}
+	}
+	if highest, ok := b.Max.Value(); ok {
+		if current, ok := a.Max.Value(); !ok || highest > current {
+			merged.Max = b.Max
+		}
+	}
+	return merged
+}
+
+// histogramPoint converts a snapshot to a data point with the given bucket
+// boundaries in the exported unit; see bucketCounts
+func histogramPoint(snapshot metricpkg.HistogramSnapshot, bounds []float64, divisor float64) metricdata.HistogramDataPoint[float64] {
+	point := metricdata.HistogramDataPoint[float64]{
+		Count:        snapshot.Count,
+		Sum:          snapshot.Sum / divisor,
+		Bounds:       bounds,
+		BucketCounts: bucketCounts(snapshot, bounds, divisor),
+	}
+	if snapshot.Count > 0 {
+		point.Min = metricdata.NewExtrema(snapshot.Min / divisor)
+		point.Max = metricdata.NewExtrema(snapshot.Max / divisor)
+	}
+	return point
+}
+
+// bucketCounts returns the per-bucket counts of a snapshot for bounds, which
+// are the snapshot's boundaries scaled by divisor unless overridden. For other
+// bounds each bucket counts the observations in the snapshot's buckets whose
+// upper boundary falls into it.
+func bucketCounts(snapshot metricpkg.HistogramSnapshot, bounds []float64, divisor float64) []uint64 {
+	counts := make([]uint64, len(bounds)+1)
+	if len(snapshot.Buckets) != len(snapshot.Boundaries)+1 {
+		counts[len(bounds)] = snapshot.Count
+		return counts
+	}
+	for i, count := range snapshot.Buckets {
+		if i == len(snapshot.Boundaries) {
+			counts[len(bounds)] += count
+			continue
+		}
+		upper := snapshot.Boundaries[i] / divisor
+		counts[sort.SearchFloat64s(bounds, upper)] += count
+	}
+	return counts
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	metricpkg "github.com/MichaelAJay/go-metrics/metric"
 	"go.opentelemetry.io/otel"
@@ -16,32 +17,38 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 )
 
+func init() {
+	metricpkg.RegisterCapability(metricpkg.Capability{Kind: metricpkg.CapabilityReporter, Name: "otel", Package: "github.com/MichaelAJay/go-metrics/metric/otel"})
+}
+
 // Reporter implements the metric.Reporter interface for OpenTelemetry
 type Reporter struct {
-	provider       *sdkmetric.MeterProvider
-	meter          otelmetric.Meter
-	counters       map[string]otelmetric.Int64Counter
-	gauges         map[string]otelmetric.Int64ObservableGauge
-	histograms     map[string]otelmetric.Float64Histogram
-	mutex          sync.RWMutex
-	defaultAttrs   []attribute.KeyValue
-	ctx            context.Context
-	cancel         context.CancelFunc
-	observing      map[string]bool
-	gaugeCallbacks map[string]otelmetric.Registration
+	provider        *sdkmetric.MeterProvider
+	meter           otelmetric.Meter
+	counters        map[string]otelmetric.Int64Counter
+	gauges          map[string]otelmetric.Int64ObservableGauge
+	floatGauges     map[string]otelmetric.Float64ObservableGauge
+	upDownCounters  map[string]otelmetric.Int64ObservableUpDownCounter
+	histograms      *histogramProducer
+	mutex           sync.RWMutex
+	defaultAttrs    []attribute.KeyValue
+	ctx             context.Context
+	cancel          context.CancelFunc
+	observing       map[string]bool
+	reportMutex     sync.Mutex // serialises reports, which own gaugeCallbacks
+	gaugeCallbacks  map[callbackKey]otelmetric.Registration
+	counterStates   map[callbackKey]*counterState // guarded by reportMutex
+	bucketOverrides map[string][]float64
+	intervalDeltas  bool
+	filterOptions   []metricpkg.FilterOption
+	filter          *metricpkg.ExportFilter
+	newReaders      []func(histograms sdkmetric.Producer) sdkmetric.Reader
 }
 
 // NewReporter creates a new OpenTelemetry reporter
 func NewReporter(serviceName, version string, options ...Option) (*Reporter, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// Create a new Prometheus exporter
-	exporter, err := prometheus.New()
-	if err != nil {
-		cancel()
-		return nil, fmt.Errorf("failed to create Prometheus exporter: %w", err)
-	}
-
 	// Create resource with service information
 	res, err := resource.New(ctx,
 		resource.WithAttributes(
@@ -54,37 +61,68 @@ func NewReporter(serviceName, version string, options ...Option) (*Reporter, err
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
-	// Create the MeterProvider
-	provider := sdkmetric.NewMeterProvider(
-		sdkmetric.WithResource(res),
-		sdkmetric.WithReader(exporter),
-	)
-
-	// Set the global MeterProvider
-	otel.SetMeterProvider(provider)
-
 	// Create the Reporter
 	r := &Reporter{
-		provider:       provider,
-		meter:          provider.Meter(serviceName),
-		counters:       make(map[string]otelmetric.Int64Counter),
-		gauges:         make(map[string]otelmetric.Int64ObservableGauge),
-		histograms:     make(map[string]otelmetric.Float64Histogram),
-		defaultAttrs:   []attribute.KeyValue{},
-		ctx:            ctx,
-		cancel:         cancel,
-		observing:      make(map[string]bool),
-		gaugeCallbacks: make(map[string]otelmetric.Registration),
+		counters:        make(map[string]otelmetric.Int64Counter),
+		gauges:          make(map[string]otelmetric.Int64ObservableGauge),
+		floatGauges:     make(map[string]otelmetric.Float64ObservableGauge),
+		upDownCounters:  make(map[string]otelmetric.Int64ObservableUpDownCounter),
+		histograms:      newHistogramProducer(serviceName),
+		defaultAttrs:    []attribute.KeyValue{},
+		ctx:             ctx,
+		cancel:          cancel,
+		observing:       make(map[string]bool),
+		gaugeCallbacks:  make(map[callbackKey]otelmetric.Registration),
+		counterStates:   make(map[callbackKey]*counterState),
+		bucketOverrides: make(map[string][]float64),
 	}
 
 	// Apply options
 	for _, opt := range options {
 		opt(r)
 	}
+	if len(r.filterOptions) > 0 {
+		r.filter = metricpkg.NewExportFilter(r.filterOptions...)
+	}
+
+	// Create a new Prometheus exporter
+	exporter, err := prometheus.New(prometheus.WithProducer(r.histograms))
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create Prometheus exporter: %w", err)
+	}
+
+	// Create the MeterProvider
+	providerOptions := []sdkmetric.Option{
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(exporter),
+	}
+	for _, newReader := range r.newReaders {
+		providerOptions = append(providerOptions, sdkmetric.WithReader(newReader(r.histograms)))
+	}
+	r.provider = sdkmetric.NewMeterProvider(providerOptions...)
+	r.meter = r.provider.Meter(serviceName)
+
+	// Set the global MeterProvider
+	otel.SetMeterProvider(r.provider)
 
 	return r, nil
 }
 
+// callbackKey identifies the callback observing one series. The attributes are
+// compared as a set, so tags in any order map to the same callback.
+type callbackKey struct {
+	kind  string
+	name  string
+	attrs attribute.Distinct
+}
+
+// newCallbackKey returns the key of the kind of callback observing name with attrs
+func newCallbackKey(kind, name string, attrs []attribute.KeyValue) callbackKey {
+	set := attribute.NewSet(attrs...)
+	return callbackKey{kind: kind, name: name, attrs: set.Equivalent()}
+}
+
 // Option is a functional option for configuring the OpenTelemetry reporter
 type Option func(*Reporter)
 
@@ -97,10 +135,70 @@ func WithAttributes(attrs map[string]string) Option {
 	}
 }
 
+// WithHistogramBuckets overrides the explicit bucket boundaries used for the
+// named histogram or timer. Timer buckets are in seconds, matching the exported
+// unit. Each observation is counted in the bucket holding the upper boundary
+// of its bucket in the metric, so choose boundaries among the metric's own.
+func WithHistogramBuckets(name string, buckets []float64) Option {
+	return func(r *Reporter) {
+		r.bucketOverrides[name] = append([]float64(nil), buckets...)
+	}
+}
+
+// WithIntervalDeltas makes each Report read and reset counters, histograms and
+// timers, with Counter.Swap and SnapshotAndReset, so it records only what
+// happened since the previous report; the exported values stay cumulative.
+// Other readers of the registry then see per-interval values, so use it only
+// when this reporter is the sole reader.
+func WithIntervalDeltas() Option {
+	return func(r *Reporter) {
+		r.intervalDeltas = true
+	}
+}
+
+// WithReader adds the reader newReader returns to the meter provider alongside
+// the Prometheus exporter, e.g. a periodic reader pushing to an OTLP collector
+// or an sdkmetric.ManualReader collecting on demand. Histograms and timers are
+// exported with their bucket counts by histograms rather than through the
+// meter, so pass it to the reader with sdkmetric.WithProducer:
+//
+//	otel.WithReader(func(histograms sdkmetric.Producer) sdkmetric.Reader {
+//		return sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithProducer(histograms))
+//	})
+func WithReader(newReader func(histograms sdkmetric.Producer) sdkmetric.Reader) Option {
+	return func(r *Reporter) {
+		r.newReaders = append(r.newReaders, newReader)
+	}
+}
+
+// WithExportFilter narrows and scrubs the reported metrics with the filter
+// options, e.g. metricpkg.AllowNames("http_*") or metricpkg.StripTags("user_id")
+func WithExportFilter(opts ...metricpkg.FilterOption) Option {
+	return func(r *Reporter) {
+		r.filterOptions = append(r.filterOptions, opts...)
+	}
+}
+
 // Report implements the metric.Reporter interface
 func (r *Reporter) Report(registry metricpkg.Registry) error {
+	return r.ReportContext(context.Background(), registry)
+}
+
+// ReportContext implements the metric.ContextReporter interface. Series not
+// yet recorded when ctx ends are left for the next report. Callbacks of series
+// no longer in the registry are unregistered, so removed series stop being
+// observed.
+func (r *Reporter) ReportContext(ctx context.Context, registry metricpkg.Registry) error {
+	r.reportMutex.Lock()
+	defer r.reportMutex.Unlock()
+
+	seen := make(map[callbackKey]bool, len(r.gaugeCallbacks))
+
 	// Process each metric in the registry
-	registry.Each(func(m metricpkg.Metric) {
+	r.filter.View(registry).Each(func(m metricpkg.Metric) {
+		if ctx.Err() != nil {
+			return
+		}
 		name := m.Name()
 
 		// Convert metric.Tags to OpenTelemetry attributes
@@ -110,45 +208,111 @@ func (r *Reporter) Report(registry metricpkg.Registry) error {
 		switch m.Type() {
 		case metricpkg.TypeCounter:
 			if counter, ok := m.(metricpkg.Counter); ok {
-				r.reportCounter(name, counter)
+				seen[r.reportCounter(name, attrs, counter)] = true
 			}
 		case metricpkg.TypeGauge:
 			if gauge, ok := m.(metricpkg.Gauge); ok {
-				r.reportGauge(name, attrs, gauge)
+				seen[r.reportGauge(name, attrs, gauge)] = true
+			}
+		case metricpkg.TypeUpDownCounter:
+			if counter, ok := m.(metricpkg.UpDownCounter); ok {
+				seen[r.reportUpDownCounter(name, attrs, counter)] = true
 			}
 		case metricpkg.TypeHistogram:
 			if histogram, ok := m.(metricpkg.Histogram); ok {
-				r.reportHistogram(name, attrs, histogram)
+				seen[r.reportHistogram(name, attrs, histogram)] = true
 			}
 		case metricpkg.TypeTimer:
 			if timer, ok := m.(metricpkg.Timer); ok {
-				r.reportTimer(name, attrs, timer)
+				seen[r.reportHistogram(name, attrs, timer)] = true
+			}
+		case metricpkg.TypeMeter:
+			if meter, ok := m.(metricpkg.Meter); ok {
+				seen[r.reportMeter(name, attrs, meter)] = true
 			}
 		}
 	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
+	// A complete report saw every series still registered
+	for key, callback := range r.gaugeCallbacks {
+		if !seen[key] {
+			callback.Unregister()
+			delete(r.gaugeCallbacks, key)
+		}
+	}
+	for key := range r.counterStates {
+		if !seen[key] {
+			delete(r.counterStates, key)
+		}
+	}
+	r.histograms.retain(seen)
 	return nil
 }
 
 
-func (r *Reporter) reportCounter(name string, counter metricpkg.Counter) {
-	// Create or get the counter
-	otelCounter := r.getOrCreateCounter(name, counter.Description())
+// counterState is the last value of a counter series added to its OpenTelemetry counter
+type counterState struct {
+	lastValue  uint64
+	generation uint64 // of the counter when lastValue was read; see metric.CounterSnapshot
+}
 
-	// Get the value from our counter using the safe Value() method
-	value := int64(counter.Value())
+// reportCounter adds what the counter series counted since the previous report
+// to its OpenTelemetry counter, with its attributes, and returns its series key
+func (r *Reporter) reportCounter(name string, attrs []attribute.KeyValue, counter metricpkg.Counter) callbackKey {
+	otelCounter := r.getOrCreateCounter(name, counter.Description())
+	key := newCallbackKey("counter", name, attrs)
+
+	var delta uint64
+	if r.intervalDeltas {
+		delta = counter.Swap()
+	} else {
+		// A new generation means the counter was reset or the series re-created
+		// since the last report, so all of its current value is new. Within a
+		// generation the value only grows; a lower value was read mid-reset and
+		// is skipped until the new generation shows.
+		current := counter.Snapshot()
+		state, exists := r.counterStates[key]
+		switch {
+		case !exists:
+			delta = current.Value
+			r.counterStates[key] = &counterState{lastValue: current.Value, generation: current.Generation}
+		case current.Generation != state.generation,
+			current.Generation == 0 && current.Value < state.lastValue: // untracked generations: guess
+			delta = current.Value
+			state.lastValue = current.Value
+			state.generation = current.Generation
+		case current.Value > state.lastValue:
+			delta = current.Value - state.lastValue
+			state.lastValue = current.Value
+		}
+	}
 
-	// Record the value - convert []attribute.KeyValue to an option list
-	// In OpenTelemetry, options need to be passed as variadic parameters
-	otelCounter.Add(r.ctx, value)
+	if delta > 0 && otelCounter != nil {
+		otelCounter.Add(r.ctx, int64(delta), otelmetric.WithAttributes(attrs...))
+	}
+	return key
 }
 
-func (r *Reporter) reportGauge(name string, attrs []attribute.KeyValue, gauge metricpkg.Gauge) {
+// reportGauge registers a callback observing gauge, if there is none yet, and
+// returns its key
+func (r *Reporter) reportGauge(name string, attrs []attribute.KeyValue, gauge metricpkg.Gauge) callbackKey {
+	// Gauges marked for conversion, such as duration gauges, are exported in their
+	// base unit, and gauges with fractional values as they are, as float gauges.
+	// State sets only ever hold 0 or 1.
+	_, float := gauge.(metricpkg.FloatGauge)
+	_, stateSet := metricpkg.AnnotationOf(gauge, metricpkg.StateSetAnnotation)
+	if unit := metricpkg.ExportUnit(gauge); unit.Base != "" || float && !stateSet {
+		return r.reportConvertedGauge(name, attrs, gauge, unit)
+	}
+
 	// Create the gauge if it doesn't exist and set up observation
 	otelGauge := r.getOrCreateGauge(name, gauge.Description())
 
 	// Set up a gauge callback if we haven't already
-	key := fmt.Sprintf("%s:%v", name, attrs)
+	key := newCallbackKey("gauge", name, attrs)
 	if _, exists := r.gaugeCallbacks[key]; !exists {
 		// Save a reference to our gauge for the callback
 		// This creates a closure over our gauge instance
@@ -159,8 +323,12 @@ func (r *Reporter) reportGauge(name string, attrs []attribute.KeyValue, gauge me
 			func(_ context.Context, o otelmetric.Observer) error {
 				// Get current value using the safe Value() method
 				value := metricGauge.Value()
+				// A state set is one gauge whose attribute is the current state
+				if _, stateSet := metricpkg.AnnotationOf(metricGauge, metricpkg.StateSetAnnotation); stateSet && value == 0 {
+					return nil
+				}
 				// Report to OpenTelemetry
-				o.ObserveInt64(otelGauge, value)
+				o.ObserveInt64(otelGauge, value, otelmetric.WithAttributes(attrs...))
 				return nil
 			},
 			otelGauge,
@@ -170,40 +338,113 @@ func (r *Reporter) reportGauge(name string, attrs []attribute.KeyValue, gauge me
 			r.gaugeCallbacks[key] = callback
 		}
 	}
+	return key
 }
 
-func (r *Reporter) reportHistogram(name string, _ []attribute.KeyValue, histogram metricpkg.Histogram) {
-	// Create or get the histogram
-	otelHistogram := r.getOrCreateHistogram(name, histogram.Description())
+// reportConvertedGauge observes a gauge in the base unit of its unit as a float gauge
+func (r *Reporter) reportConvertedGauge(name string, attrs []attribute.KeyValue, gauge metricpkg.Gauge, unit metricpkg.UnitConversion) callbackKey {
+	name = unit.Name(name)
+	key := newCallbackKey("gauge", name, attrs)
+	if _, exists := r.gaugeCallbacks[key]; exists {
+		return key
+	}
 
-	// Get the current histogram snapshot using the safe Snapshot() method
-	snapshot := histogram.Snapshot()
+	symbol := unit.Symbol
+	if symbol == "" {
+		symbol = "1"
+	}
+	otelGauge := r.getOrCreateFloatGauge(name, gauge.Description(), symbol)
+	callback, err := r.meter.RegisterCallback(
+		func(_ context.Context, o otelmetric.Observer) error {
+			o.ObserveFloat64(otelGauge, unit.Convert(metricpkg.GaugeValue(gauge)), otelmetric.WithAttributes(attrs...))
+			return nil
+		},
+		otelGauge,
+	)
 
-	// Record observations based on the histogram buckets
-	// This is a simplified approach - in a full implementation, we'd record
-	// individual observations or use OpenTelemetry's histogram directly
-	if snapshot.Count > 0 {
-		// Record the average value as a representative sample
-		avgValue := float64(snapshot.Sum) / float64(snapshot.Count)
-		otelHistogram.Record(r.ctx, avgValue)
+	if err == nil {
+		r.gaugeCallbacks[key] = callback
 	}
+	return key
 }
 
-func (r *Reporter) reportTimer(name string, _ []attribute.KeyValue, timer metricpkg.Timer) {
-	// Create a histogram for the timer
-	otelHistogram := r.getOrCreateHistogram(name+"_seconds", timer.Description())
+func (r *Reporter) reportUpDownCounter(name string, attrs []attribute.KeyValue, counter metricpkg.UpDownCounter) callbackKey {
+	otelCounter := r.getOrCreateUpDownCounter(name, counter.Description())
 
-	// Get the current timer snapshot using the safe Snapshot() method
-	snapshot := timer.Snapshot()
+	// Observe the current value at collection time, like gauges
+	key := newCallbackKey("updowncounter", name, attrs)
+	if _, exists := r.gaugeCallbacks[key]; !exists {
+		callback, err := r.meter.RegisterCallback(
+			func(_ context.Context, o otelmetric.Observer) error {
+				o.ObserveInt64(otelCounter, counter.Value(), otelmetric.WithAttributes(attrs...))
+				return nil
+			},
+			otelCounter,
+		)
 
-	// Record observations based on the timer's histogram data
-	// Convert from nanoseconds to seconds for better OpenTelemetry compatibility
-	if snapshot.Count > 0 {
-		// Record the average duration in seconds
-		avgDurationNanos := float64(snapshot.Sum) / float64(snapshot.Count)
-		avgDurationSeconds := avgDurationNanos / 1e9 // Convert nanoseconds to seconds
-		otelHistogram.Record(r.ctx, avgDurationSeconds)
+		if err == nil {
+			r.gaugeCallbacks[key] = callback
+		}
+	}
+	return key
+}
+
+// reportMeter observes a meter's 1, 5 and 15 minute rates as the gauges
+// {name}_rate1m, {name}_rate5m and {name}_rate15m at collection time
+func (r *Reporter) reportMeter(name string, attrs []attribute.KeyValue, meter metricpkg.Meter) callbackKey {
+	key := newCallbackKey("meter", name, attrs)
+	if _, exists := r.gaugeCallbacks[key]; exists {
+		return key
+	}
+
+	rate1 := r.getOrCreateFloatGauge(name+"_rate1m", meter.Description(), "1/s")
+	rate5 := r.getOrCreateFloatGauge(name+"_rate5m", meter.Description(), "1/s")
+	rate15 := r.getOrCreateFloatGauge(name+"_rate15m", meter.Description(), "1/s")
+	callback, err := r.meter.RegisterCallback(
+		func(_ context.Context, o otelmetric.Observer) error {
+			snapshot := meter.Snapshot()
+			attributes := otelmetric.WithAttributes(attrs...)
+			o.ObserveFloat64(rate1, snapshot.Rate1, attributes)
+			o.ObserveFloat64(rate5, snapshot.Rate5, attributes)
+			o.ObserveFloat64(rate15, snapshot.Rate15, attributes)
+			return nil
+		},
+		rate1, rate5, rate15,
+	)
+
+	if err == nil {
+		r.gaugeCallbacks[key] = callback
 	}
+	return key
+}
+
+// histogramMetric is a histogram or timer
+type histogramMetric interface {
+	metricpkg.Metric
+	Snapshot() metricpkg.HistogramSnapshot
+	SnapshotAndReset() metricpkg.HistogramSnapshot
+}
+
+// reportHistogram records the snapshot of a histogram or timer for the
+// histogram producer and returns its series key. In interval delta mode the
+// snapshot covers the observations since the previous report, which the
+// producer adds to the series' cumulative state.
+func (r *Reporter) reportHistogram(name string, attrs []attribute.KeyValue, histogram histogramMetric) callbackKey {
+	var created time.Time
+	if c, ok := histogram.(metricpkg.CreatedTimestamper); ok {
+		created = c.Created()
+	}
+	snapshot := histogram.Snapshot()
+	if r.intervalDeltas {
+		snapshot = histogram.SnapshotAndReset()
+	}
+
+	// Histograms are exported with the metric's own bucket boundaries. Values in
+	// a known unit, including timer nanoseconds, are exported in its base unit,
+	// named with its suffix.
+	unit := metricpkg.ExportUnit(histogram)
+	point := histogramPoint(snapshot, r.bucketsFor(name, snapshot.Boundaries, unit.Divisor), unit.Divisor)
+	return r.histograms.record(unit.Name(name), histogram.Description(), unit.Symbol, attrs, created, point, r.intervalDeltas)
 }
 
 func (r *Reporter) getOrCreateCounter(name, help string) otelmetric.Int64Counter {
@@ -266,34 +507,61 @@ func (r *Reporter) getOrCreateGauge(name, help string) otelmetric.Int64Observabl
 	return gauge
 }
 
-func (r *Reporter) getOrCreateHistogram(name, help string) otelmetric.Float64Histogram {
+func (r *Reporter) getOrCreateFloatGauge(name, help, unit string) otelmetric.Float64ObservableGauge {
+	r.mutex.RLock()
+	gauge, exists := r.floatGauges[name]
+	r.mutex.RUnlock()
+
+	if exists {
+		return gauge
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if gauge, exists = r.floatGauges[name]; exists {
+		return gauge
+	}
+
+	gauge, err := r.meter.Float64ObservableGauge(
+		name,
+		otelmetric.WithDescription(help),
+		otelmetric.WithUnit(unit),
+	)
+	if err == nil {
+		r.floatGauges[name] = gauge
+	}
+
+	return gauge
+}
+
+func (r *Reporter) getOrCreateUpDownCounter(name, help string) otelmetric.Int64ObservableUpDownCounter {
 	r.mutex.RLock()
-	histogram, exists := r.histograms[name]
+	counter, exists := r.upDownCounters[name]
 	r.mutex.RUnlock()
 
 	if exists {
-		return histogram
+		return counter
 	}
 
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
 	// Double-check after acquiring write lock
-	if histogram, exists = r.histograms[name]; exists {
-		return histogram
+	if counter, exists = r.upDownCounters[name]; exists {
+		return counter
 	}
 
-	// Create the histogram
-	histogram, err := r.meter.Float64Histogram(
+	counter, err := r.meter.Int64ObservableUpDownCounter(
 		name,
 		otelmetric.WithDescription(help),
 		otelmetric.WithUnit("1"),
 	)
 	if err == nil {
-		r.histograms[name] = histogram
+		r.upDownCounters[name] = counter
 	}
 
-	return histogram
+	return counter
 }
 
 // Flush implements the metric.Reporter interface
@@ -308,9 +576,11 @@ func (r *Reporter) Close() error {
 	r.cancel()
 
 	// Unregister all callbacks
+	r.reportMutex.Lock()
 	for _, callback := range r.gaugeCallbacks {
 		callback.Unregister()
 	}
+	r.reportMutex.Unlock()
 
 	// Shutdown the provider
 	return r.provider.Shutdown(context.Background())
@@ -318,6 +588,20 @@ func (r *Reporter) Close() error {
 
 // Helper functions
 
+// bucketsFor returns the explicit bucket boundaries for a histogram: a reporter
+// override if configured, otherwise the metric's own boundaries scaled by divisor
+func (r *Reporter) bucketsFor(name string, boundaries []float64, divisor float64) []float64 {
+	if override, ok := r.bucketOverrides[name]; ok {
+		return override
+	}
+
+	buckets := make([]float64, len(boundaries))
+	for i, boundary := range boundaries {
+		buckets[i] = boundary / divisor
+	}
+	return buckets
+}
+
 func (r *Reporter) convertTags(tags metricpkg.Tags) []attribute.KeyValue {
 	if len(tags) == 0 {
 		return r.defaultAttrs
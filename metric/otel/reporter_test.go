@@ -1,10 +1,16 @@
 package otel
 
 import (
+	"context"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/MichaelAJay/go-metrics/metric"
+	prom "github.com/prometheus/client_golang/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
 func TestNewReporter(t *testing.T) {
@@ -222,7 +228,7 @@ func TestReportHistogram(t *testing.T) {
 		t.Errorf("Expected histogram count 3, got %d", snapshot.Count)
 	}
 	if snapshot.Sum != 60 {
-		t.Errorf("Expected histogram sum 60, got %d", snapshot.Sum)
+		t.Errorf("Expected histogram sum 60, got %g", snapshot.Sum)
 	}
 
 	// Report the metrics
@@ -231,11 +237,9 @@ func TestReportHistogram(t *testing.T) {
 		t.Errorf("Report() returned error: %v", err)
 	}
 
-	// Verify the histogram is tracked
-	reporter.mutex.RLock()
-	_, exists := reporter.histograms["test_histogram"]
-	reporter.mutex.RUnlock()
-	
+	// Verify the histogram is tracked, converted from milliseconds to seconds
+	_, exists := producedHistograms(t, reporter)["test_histogram_seconds"]
+
 	if !exists {
 		t.Error("Histogram was not created in reporter")
 	}
@@ -275,10 +279,8 @@ func TestReportTimer(t *testing.T) {
 	}
 
 	// Verify the timer histogram is tracked (timers create histograms with "_seconds" suffix)
-	reporter.mutex.RLock()
-	_, exists := reporter.histograms["test_timer_seconds"]
-	reporter.mutex.RUnlock()
-	
+	_, exists := producedHistograms(t, reporter)["test_timer_seconds"]
+
 	if !exists {
 		t.Error("Timer histogram was not created in reporter")
 	}
@@ -378,3 +380,386 @@ func TestMultipleReports(t *testing.T) {
 		t.Errorf("Expected gauge value 40, got %d", gauge.Value())
 	}
 }
+
+func TestReportWithHistogramBuckets(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	registry.Histogram(metric.Options{Name: "otel_payload_bytes", Buckets: []float64{64, 512}}).Observe(100)
+	registry.Timer(metric.Options{Name: "otel_db_query"}).Record(20 * time.Millisecond)
+
+	var reader *sdkmetric.ManualReader
+	reporter, err := NewReporter("test-service", "v1.0.0",
+		WithHistogramBuckets("otel_db_query", []float64{0.01, 0.05}),
+		WithReader(func(histograms sdkmetric.Producer) sdkmetric.Reader {
+			reader = sdkmetric.NewManualReader(sdkmetric.WithProducer(histograms))
+			return reader
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewReporter() returned error: %v", err)
+	}
+	defer reporter.Close()
+
+	if err := reporter.Report(registry); err != nil {
+		t.Errorf("Report() returned error: %v", err)
+	}
+
+	var collected metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &collected); err != nil {
+		t.Fatalf("Collect() returned error: %v", err)
+	}
+	bounds := make(map[string][]float64)
+	for _, scope := range collected.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if histogram, ok := m.Data.(metricdata.Histogram[float64]); ok && len(histogram.DataPoints) > 0 {
+				bounds[m.Name] = histogram.DataPoints[0].Bounds
+			}
+		}
+	}
+
+	if got := bounds["otel_payload_bytes"]; !slices.Equal(got, []float64{64, 512}) {
+		t.Errorf("Expected the histogram's own boundaries to be exported, got %v", got)
+	}
+	var timerBounds []float64
+	for name, b := range bounds {
+		if strings.HasPrefix(name, "otel_db_query") {
+			timerBounds = b
+		}
+	}
+	if !slices.Equal(timerBounds, []float64{0.01, 0.05}) {
+		t.Errorf("Expected the override boundaries to be exported for the timer, got %v (all: %v)", timerBounds, bounds)
+	}
+}
+
+func TestReportUpDownCounter(t *testing.T) {
+	registry := metric.NewDefaultRegistry().(metric.InstrumentRegistry)
+	reporter, err := NewReporter("test-service", "v1.0.0")
+	if err != nil {
+		t.Fatalf("NewReporter() returned error: %v", err)
+	}
+	defer reporter.Close()
+
+	inFlight := registry.UpDownCounter(metric.Options{
+		Name:        "test_in_flight",
+		Description: "Requests currently in flight",
+	})
+	inFlight.Add(3)
+	inFlight.Dec()
+
+	if err := reporter.Report(registry); err != nil {
+		t.Errorf("Report() returned error: %v", err)
+	}
+
+	reporter.mutex.RLock()
+	_, exists := reporter.upDownCounters["test_in_flight"]
+	reporter.mutex.RUnlock()
+
+	if !exists {
+		t.Error("UpDownCounter was not created in reporter")
+	}
+}
+
+func TestReportWithIntervalDeltas(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	counter := registry.Counter(metric.Options{Name: "otel_delta_requests"})
+	histogram := registry.Histogram(metric.Options{Name: "otel_delta_payload_bytes", Buckets: []float64{10, 1000}})
+	timer := registry.Timer(metric.Options{Name: "otel_delta_query"})
+	counter.Add(3)
+	histogram.Observe(100)
+	timer.Record(20 * time.Millisecond)
+
+	reporter, err := NewReporter("test-service", "v1.0.0", WithIntervalDeltas())
+	if err != nil {
+		t.Fatalf("NewReporter() returned error: %v", err)
+	}
+	defer reporter.Close()
+
+	if err := reporter.Report(registry); err != nil {
+		t.Errorf("Report() returned error: %v", err)
+	}
+	if counter.Value() != 0 || histogram.Snapshot().Count != 0 || timer.Snapshot().Count != 0 {
+		t.Errorf("Expected the report to reset the counter, histogram and timer, got %d, %d and %d",
+			counter.Value(), histogram.Snapshot().Count, timer.Snapshot().Count)
+	}
+
+	// The exported histogram adds up the bucket counts of each interval
+	histogram.Observe(5)
+	histogram.Observe(5000)
+	if err := reporter.Report(registry); err != nil {
+		t.Errorf("Report() returned error: %v", err)
+	}
+	histograms := producedHistograms(t, reporter)
+	if points := histograms["otel_delta_payload_bytes"].DataPoints; len(points) != 1 ||
+		points[0].Count != 3 || !slices.Equal(points[0].BucketCounts, []uint64{1, 1, 1}) {
+		t.Errorf("Expected 3 observations in buckets [1 1 1], got %+v", points)
+	}
+	if points := histograms["otel_delta_query_seconds"].DataPoints; len(points) != 1 || points[0].Count != 1 {
+		t.Errorf("Expected the timer's observation to be kept after its reset, got %+v", points)
+	}
+}
+
+func TestReportStateSet(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry().(metric.InstrumentRegistry)
+	defer registry.Close()
+	reporter, err := NewReporter("test-service", "v1.0.0")
+	if err != nil {
+		t.Fatalf("NewReporter() returned error: %v", err)
+	}
+	defer reporter.Close()
+
+	breaker := metric.NewStateSet(registry, metric.Options{Name: "otel_breaker_state"}, "closed", "open", "half_open")
+	breaker.Set("open")
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+
+	families, err := prom.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	var states []string
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), "otel_breaker_state") {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == metric.StateSetTag {
+					states = append(states, label.GetValue())
+				}
+			}
+		}
+	}
+	if len(states) != 1 || states[0] != "open" {
+		t.Errorf("Expected one gauge for the current state, got %v", states)
+	}
+}
+
+func TestReportFractionalGauge(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry().(metric.InstrumentRegistry)
+	defer registry.Close()
+	reporter, err := NewReporter("test-service", "v1.0.0")
+	if err != nil {
+		t.Fatalf("NewReporter() returned error: %v", err)
+	}
+	defer reporter.Close()
+
+	registry.GaugeFunc(metric.Options{Name: "otel_pool_utilization"}, func() float64 { return 0.75 })
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+
+	families, err := prom.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	var values []float64
+	for _, family := range families {
+		if strings.HasPrefix(family.GetName(), "otel_pool_utilization") {
+			for _, m := range family.GetMetric() {
+				values = append(values, m.GetGauge().GetValue())
+			}
+		}
+	}
+	if len(values) != 1 || values[0] != 0.75 {
+		t.Errorf("Expected the gauge to be exported as 0.75, got %v", values)
+	}
+}
+
+func TestReportGaugeAttributes(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry().(metric.InstrumentRegistry)
+	defer registry.Close()
+	reporter, err := NewReporter("test-service", "v1.0.0")
+	if err != nil {
+		t.Fatalf("NewReporter() returned error: %v", err)
+	}
+	defer reporter.Close()
+
+	registry.Gauge(metric.Options{Name: "otel_attr_pool_size", Tags: metric.Tags{"pool": "primary"}}).Set(4)
+	registry.Gauge(metric.Options{Name: "otel_attr_pool_size", Tags: metric.Tags{"pool": "replica"}}).Set(2)
+	registry.UpDownCounter(metric.Options{Name: "otel_attr_in_flight", Tags: metric.Tags{"route": "/a"}}).Add(3)
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+
+	families, err := prom.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	values := make(map[string]float64)
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), "otel_attr_") {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				switch label.GetName() {
+				case "pool", "route":
+					values[label.GetValue()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	if values["primary"] != 4 || values["replica"] != 2 || values["/a"] != 3 {
+		t.Errorf("Expected each series to be observed with its tags as attributes, got %v", values)
+	}
+}
+
+func TestReportCallbacksPerSeries(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry().(metric.InstrumentRegistry)
+	defer registry.Close()
+	reporter, err := NewReporter("test-service", "v1.0.0")
+	if err != nil {
+		t.Fatalf("NewReporter() returned error: %v", err)
+	}
+	defer reporter.Close()
+
+	tags := metric.Tags{"a": "1", "b": "2", "c": "3", "d": "4"}
+	registry.Gauge(metric.Options{Name: "otel_callback_gauge", Tags: tags}).Set(1)
+	registry.UpDownCounter(metric.Options{Name: "otel_callback_in_flight", Tags: tags}).Inc()
+
+	// Tags come out of the map in any order, but each series keeps one callback
+	for i := 0; i < 10; i++ {
+		if err := reporter.Report(registry); err != nil {
+			t.Fatalf("Report() returned error: %v", err)
+		}
+	}
+	if len(reporter.gaugeCallbacks) != 2 {
+		t.Fatalf("Expected one callback per series, got %d", len(reporter.gaugeCallbacks))
+	}
+
+	// Series removed from the registry stop being observed
+	registry.Unregister("otel_callback_gauge")
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+	if len(reporter.gaugeCallbacks) != 1 {
+		t.Errorf("Expected the removed gauge's callback to be unregistered, got %d callbacks", len(reporter.gaugeCallbacks))
+	}
+	families, err := prom.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	for _, family := range families {
+		if strings.HasPrefix(family.GetName(), "otel_callback_gauge") && len(family.GetMetric()) > 0 {
+			t.Errorf("Expected the removed gauge not to be exported, got %v", family.GetMetric())
+		}
+	}
+}
+
+func TestReportHistogramBucketCounts(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	histogram := registry.Histogram(metric.Options{Name: "otel_counted_bytes", Buckets: []float64{1, 10, 100}})
+	for _, v := range []float64{0.5, 0.5, 0.5, 500} {
+		histogram.Observe(v)
+	}
+
+	var reader *sdkmetric.ManualReader
+	reporter, err := NewReporter("test-service", "v1.0.0", WithReader(func(histograms sdkmetric.Producer) sdkmetric.Reader {
+		reader = sdkmetric.NewManualReader(sdkmetric.WithProducer(histograms))
+		return reader
+	}))
+	if err != nil {
+		t.Fatalf("NewReporter() returned error: %v", err)
+	}
+	defer reporter.Close()
+
+	// Reporting twice must not count the same observations again
+	for i := 0; i < 2; i++ {
+		if err := reporter.Report(registry); err != nil {
+			t.Fatalf("Report() returned error: %v", err)
+		}
+	}
+
+	var collected metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &collected); err != nil {
+		t.Fatalf("Collect() returned error: %v", err)
+	}
+	var point *metricdata.HistogramDataPoint[float64]
+	for _, scope := range collected.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if histogram, ok := m.Data.(metricdata.Histogram[float64]); ok && m.Name == "otel_counted_bytes" && len(histogram.DataPoints) == 1 {
+				point = &histogram.DataPoints[0]
+			}
+		}
+	}
+	if point == nil {
+		t.Fatalf("Expected one otel_counted_bytes data point, got %+v", collected.ScopeMetrics)
+	}
+	if point.Count != 4 || point.Sum != 501.5 || !slices.Equal(point.BucketCounts, []uint64{3, 0, 0, 1}) {
+		t.Errorf("Expected count 4, sum 501.5 and bucket counts [3 0 0 1], got %d, %g and %v", point.Count, point.Sum, point.BucketCounts)
+	}
+}
+
+// producedHistograms returns the histograms exported by the reporter's
+// histogram producer, by name
+func producedHistograms(t *testing.T, reporter *Reporter) map[string]metricdata.Histogram[float64] {
+	t.Helper()
+	scopes, err := reporter.histograms.Produce(context.Background())
+	if err != nil {
+		t.Fatalf("Produce() returned error: %v", err)
+	}
+	histograms := make(map[string]metricdata.Histogram[float64])
+	for _, scope := range scopes {
+		for _, m := range scope.Metrics {
+			if histogram, ok := m.Data.(metricdata.Histogram[float64]); ok {
+				histograms[m.Name] = histogram
+			}
+		}
+	}
+	return histograms
+}
+
+func TestReportCounterSeries(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	requests := registry.Counter(metric.Options{Name: "otel_series_requests"})
+	requests.With(metric.Tags{"route": "a"}).Add(5)
+	requests.With(metric.Tags{"route": "b"}).Add(7)
+
+	var reader *sdkmetric.ManualReader
+	reporter, err := NewReporter("test-service", "v1.0.0", WithReader(func(histograms sdkmetric.Producer) sdkmetric.Reader {
+		reader = sdkmetric.NewManualReader(sdkmetric.WithProducer(histograms))
+		return reader
+	}))
+	if err != nil {
+		t.Fatalf("NewReporter() returned error: %v", err)
+	}
+	defer reporter.Close()
+
+	// Reporting twice must not add the same counts again
+	for i := 0; i < 2; i++ {
+		if err := reporter.Report(registry); err != nil {
+			t.Fatalf("Report() returned error: %v", err)
+		}
+	}
+	requests.With(metric.Tags{"route": "a"}).Inc()
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+
+	var collected metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &collected); err != nil {
+		t.Fatalf("Collect() returned error: %v", err)
+	}
+	values := make(map[string]int64)
+	for _, scope := range collected.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "otel_series_requests" {
+				for _, point := range sum.DataPoints {
+					route, _ := point.Attributes.Value("route")
+					values[route.AsString()] = point.Value
+				}
+			}
+		}
+	}
+	if len(values) != 2 || values["a"] != 6 || values["b"] != 7 {
+		t.Errorf("Expected a=6 and b=7 as separate points, got %v", values)
+	}
+}
@@ -0,0 +1,24 @@
+package metric
+
+// isPaused reports whether the registry that created the metric has paused writes
+func (m *baseMetric) isPaused() bool {
+	return m.paused != nil && m.paused.Load()
+}
+
+// Pause implements the Registry interface. Writes made while paused are dropped,
+// not buffered, and do not reach observation sinks. Background TTL cleanup is
+// suspended so idle handles stay registered. Gauge funcs keep reporting their
+// callback's value.
+func (r *defaultRegistry) Pause() {
+	r.paused.Store(true)
+}
+
+// Resume implements the Registry interface
+func (r *defaultRegistry) Resume() {
+	r.paused.Store(false)
+}
+
+// Paused implements the Registry interface
+func (r *defaultRegistry) Paused() bool {
+	return r.paused.Load()
+}
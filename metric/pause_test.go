@@ -0,0 +1,60 @@
+package metric
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistryPauseResume(t *testing.T) {
+	sink := &recordingSink{}
+	registry := NewNoCleanupRegistry(WithObservationSink(sink)).(*defaultRegistry)
+	defer registry.Close()
+
+	counter := registry.Counter(Options{Name: "requests_total"})
+	gauge := registry.Gauge(Options{Name: "queue_depth"})
+	upDown := registry.UpDownCounter(Options{Name: "in_flight"})
+	histogram := registry.Histogram(Options{Name: "payload_bytes"})
+	timer := registry.Timer(Options{Name: "latency"})
+	counter.Inc()
+
+	registry.Pause()
+	if !registry.Paused() {
+		t.Fatal("Expected the registry to report paused")
+	}
+
+	counter.Add(5)
+	counter.(TimestampedAdder).AddAt(1, time.Now())
+	gauge.Set(10)
+	gauge.Inc()
+	upDown.Add(3)
+	histogram.Observe(1)
+	timer.Record(time.Second)
+	// Series derived or created while paused are paused too
+	counter.With(Tags{"status": "500"}).Inc()
+	histogram.With(Tags{"route": "/"}).Observe(1)
+	registry.Counter(Options{Name: "created_while_paused_total"}).Inc()
+
+	if counter.Value() != 1 || gauge.Value() != 0 || upDown.Value() != 0 {
+		t.Errorf("Expected writes to be dropped, got counter %d gauge %d up/down %d", counter.Value(), gauge.Value(), upDown.Value())
+	}
+	if histogram.Snapshot().Count != 0 || timer.Snapshot().Count != 0 {
+		t.Error("Expected observations to be dropped")
+	}
+	if got := counter.With(Tags{"status": "500"}).Value(); got != 0 {
+		t.Errorf("Expected the derived series to be paused, got %d", got)
+	}
+	if got := registry.Counter(Options{Name: "created_while_paused_total"}).Value(); got != 0 {
+		t.Errorf("Expected a new metric to be paused, got %d", got)
+	}
+	if got := len(sink.all()); got != 1 {
+		t.Errorf("Expected only the write before Pause to reach the sink, got %d observations", got)
+	}
+
+	registry.Resume()
+	counter.Inc()
+	gauge.Set(4)
+	timer.Record(time.Second)
+	if counter.Value() != 2 || gauge.Value() != 4 || timer.Snapshot().Count != 1 {
+		t.Errorf("Expected writes after Resume, got counter %d gauge %d timer %d", counter.Value(), gauge.Value(), timer.Snapshot().Count)
+	}
+}
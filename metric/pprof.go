@@ -0,0 +1,38 @@
+package metric
+
+import (
+	"context"
+	"runtime/pprof"
+	"sort"
+	"time"
+)
+
+// ProfileLabels converts tags into a pprof label set, so CPU profiles can be
+// sliced along the same dimensions as the metrics carrying those tags
+func ProfileLabels(tags Tags) pprof.LabelSet {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		args = append(args, k, tags[k])
+	}
+	return pprof.Labels(args...)
+}
+
+// TimeWithLabels runs fn with pprof labels set from tags and records its
+// duration on timer.With(tags). Goroutines started by fn with the context it
+// receives inherit the labels.
+func TimeWithLabels(ctx context.Context, timer Timer, tags Tags, fn func(ctx context.Context)) time.Duration {
+	var d time.Duration
+	pprof.Do(ctx, ProfileLabels(tags), func(ctx context.Context) {
+		start := time.Now()
+		fn(ctx)
+		d = time.Since(start)
+	})
+	timer.With(tags).Record(d)
+	return d
+}
@@ -0,0 +1,32 @@
+package metric
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+)
+
+func TestTimeWithLabels(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	timer := registry.Timer(Options{Name: "handler_duration"})
+	tags := Tags{"operation": "checkout", "route": "/cart"}
+
+	labels := make(map[string]string)
+	TimeWithLabels(context.Background(), timer, tags, func(ctx context.Context) {
+		pprof.ForLabels(ctx, func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+		time.Sleep(time.Millisecond)
+	})
+
+	if labels["operation"] != "checkout" || labels["route"] != "/cart" {
+		t.Errorf("Expected pprof labels to match the metric tags, got %v", labels)
+	}
+	if count := timer.With(tags).Snapshot().Count; count != 1 {
+		t.Errorf("Expected one recording on the tagged series, got %d", count)
+	}
+}
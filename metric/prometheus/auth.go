@@ -0,0 +1,241 @@
+package prometheus
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// scrapeAccess restricts who may scrape the Handler. Credentials are
+// alternatives, so a request passes with any accepted one; the network
+// allowlist applies on top of them.
+type scrapeAccess struct {
+	users    map[string]string // basic auth passwords by user name
+	tokens   []string
+	validate []func(token string) bool
+	networks []netip.Prefix
+}
+
+// access returns the reporter's scrape access rules, creating them on first use
+func (r *Reporter) access() *scrapeAccess {
+	if r.scrapeAccess == nil {
+		r.scrapeAccess = &scrapeAccess{users: make(map[string]string)}
+	}
+	return r.scrapeAccess
+}
+
+// WithBasicAuth requires scrapes to send HTTP basic auth with the user name and
+// password. It may be given several times to accept several users.
+func WithBasicAuth(username, password string) Option {
+	return func(r *Reporter) {
+		r.access().users[username] = password
+	}
+}
+
+// WithBearerTokens requires scrapes to send "Authorization: Bearer" with one of
+// the tokens, as Prometheus does with bearer_token or authorization in a scrape config
+func WithBearerTokens(tokens ...string) Option {
+	return func(r *Reporter) {
+		r.access().tokens = append(r.access().tokens, tokens...)
+	}
+}
+
+// WithBearerTokenValidator requires scrapes to send a bearer token validate
+// accepts, e.g. to check a signed token or look it up in a secret store
+func WithBearerTokenValidator(validate func(token string) bool) Option {
+	return func(r *Reporter) {
+		r.access().validate = append(r.access().validate, validate)
+	}
+}
+
+// WithAllowedNetworks only serves scrapes whose remote address is in one of the
+// networks, given in CIDR notation such as "10.0.0.0/8" or as single addresses.
+// The address is the connection's, not one from X-Forwarded-For, so a proxy in
+// front of the handler must be allowed itself. It panics if a network is malformed.
+func WithAllowedNetworks(networks ...string) Option {
+	prefixes := make([]netip.Prefix, 0, len(networks))
+	for _, network := range networks {
+		prefix, err := parseNetwork(network)
+		if err != nil {
+			panic("prometheus: malformed network '" + network + "': " + err.Error())
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return func(r *Reporter) {
+		r.access().networks = append(r.access().networks, prefixes...)
+	}
+}
+
+// parseNetwork parses a CIDR prefix or a single address
+func parseNetwork(network string) (netip.Prefix, error) {
+	if strings.Contains(network, "/") {
+		prefix, err := netip.ParsePrefix(network)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(network)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
+
+// protect wraps next so it only serves requests the access rules allow
+func (a *scrapeAccess) protect(next http.Handler, rejected func(reason string)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !a.allowedAddress(req.RemoteAddr) {
+			rejected("forbidden")
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if !a.authorized(req) {
+			rejected("unauthorized")
+			if len(a.users) > 0 {
+				w.Header().Add("WWW-Authenticate", `Basic realm="metrics", charset="UTF-8"`)
+			}
+			if len(a.tokens) > 0 || len(a.validate) > 0 {
+				w.Header().Add("WWW-Authenticate", `Bearer realm="metrics"`)
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// allowedAddress reports whether remoteAddr is in an allowed network
+func (a *scrapeAccess) allowedAddress(remoteAddr string) bool {
+	if len(a.networks) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, network := range a.networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorized reports whether req carries accepted credentials, or whether none are required
+func (a *scrapeAccess) authorized(req *http.Request) bool {
+	if len(a.users) == 0 && len(a.tokens) == 0 && len(a.validate) == 0 {
+		return true
+	}
+	if username, password, ok := req.BasicAuth(); ok {
+		expected, known := a.users[username]
+		// Compare even for unknown users so timing does not reveal which exist
+		match := subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+		return known && match
+	}
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return false
+	}
+	for _, expected := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			return true
+		}
+	}
+	for _, validate := range a.validate {
+		if validate(token) {
+			return true
+		}
+	}
+	return false
+}
+
+// TLSConfig returns a server TLS configuration for serving the Handler over
+// HTTPS with the certificate and key in PEM files, requiring TLS 1.2 or later.
+// If clientCAFile is not empty, scrapers must present a client certificate
+// signed by one of its CAs (mutual TLS, tls_config.cert_file in a Prometheus
+// scrape config). The certificate files are reread on each handshake when they
+// change, so rotated certificates are picked up without a restart.
+func TLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	loader := &certificateLoader{certFile: certFile, keyFile: keyFile}
+	if _, err := loader.load(); err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return loader.load()
+		},
+	}
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("prometheus: reading client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("prometheus: no certificates found in client CA file " + clientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// certificateLoader loads a key pair, reloading it when either file changes.
+// If a reload fails the previous certificate is kept.
+type certificateLoader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modified time.Time
+}
+
+func (l *certificateLoader) load() (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	modified, err := lastModified(l.certFile, l.keyFile)
+	if err == nil && l.cert != nil && modified.Equal(l.modified) {
+		return l.cert, nil
+	}
+	if err == nil {
+		var cert tls.Certificate
+		if cert, err = tls.LoadX509KeyPair(l.certFile, l.keyFile); err == nil {
+			l.cert, l.modified = &cert, modified
+			return l.cert, nil
+		}
+	}
+	if l.cert != nil {
+		return l.cert, nil
+	}
+	return nil, fmt.Errorf("prometheus: loading certificate: %w", err)
+}
+
+// lastModified returns the latest modification time of the files
+func lastModified(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,167 @@
+package prometheus
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlerAuthentication(t *testing.T) {
+	reporter := NewReporter(
+		WithBasicAuth("prometheus", "secret"),
+		WithBearerTokens("static-token"),
+		WithBearerTokenValidator(func(token string) bool { return strings.HasPrefix(token, "signed.") }),
+	)
+	handler := reporter.Handler()
+
+	tests := []struct {
+		name      string
+		authorize func(*http.Request)
+		want      int
+	}{
+		{"no credentials", func(*http.Request) {}, http.StatusUnauthorized},
+		{"basic auth", func(r *http.Request) { r.SetBasicAuth("prometheus", "secret") }, http.StatusOK},
+		{"wrong password", func(r *http.Request) { r.SetBasicAuth("prometheus", "guess") }, http.StatusUnauthorized},
+		{"unknown user", func(r *http.Request) { r.SetBasicAuth("admin", "secret") }, http.StatusUnauthorized},
+		{"static token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer static-token") }, http.StatusOK},
+		{"validated token", func(r *http.Request) { r.Header.Set("Authorization", "bearer signed.abc") }, http.StatusOK},
+		{"rejected token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer forged") }, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			tt.authorize(req)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, rec.Code)
+			}
+			if rec.Code == http.StatusUnauthorized && len(rec.Header().Values("WWW-Authenticate")) != 2 {
+				t.Errorf("Expected basic and bearer challenges, got %v", rec.Header().Values("WWW-Authenticate"))
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.SetBasicAuth("prometheus", "secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `metrics_scrape_rejections_total{reason="unauthorized"} 4`) {
+		t.Errorf("Expected the rejected scrapes to be counted, got:\n%s", rec.Body.String())
+	}
+}
+
+func TestHandlerAllowedNetworks(t *testing.T) {
+	handler := NewReporter(WithAllowedNetworks("10.0.0.0/8", "192.168.1.7", "::1")).Handler()
+
+	tests := map[string]int{
+		"10.1.2.3:9090":          http.StatusOK,
+		"192.168.1.7:9090":       http.StatusOK,
+		"192.168.1.8:9090":       http.StatusForbidden,
+		"[::1]:9090":             http.StatusOK,
+		"[::ffff:10.0.0.1]:9090": http.StatusOK,
+		"garbage":                http.StatusForbidden,
+	}
+	for remoteAddr, want := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Expected status %d for %s, got %d", want, remoteAddr, rec.Code)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a malformed network to panic")
+		}
+	}()
+	WithAllowedNetworks("10.0.0.0/33")
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeSelfSignedCertificate(t, certFile, keyFile)
+
+	if _, err := TLSConfig(filepath.Join(dir, "missing.pem"), keyFile, ""); err == nil {
+		t.Error("Expected an error for a missing certificate")
+	}
+	if _, err := TLSConfig(certFile, keyFile, keyFile); err == nil {
+		t.Error("Expected an error for a client CA file without certificates")
+	}
+
+	config, err := TLSConfig(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("TLSConfig: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: NewReporter().Handler()}
+	go server.Serve(tls.NewListener(listener, config))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	certPEM, _ := os.ReadFile(certFile)
+	pool.AppendCertsFromPEM(certPEM)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get("https://" + listener.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatalf("Scrape over TLS: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil || resp.TLS.Version < tls.VersionTLS12 {
+		t.Errorf("Expected a TLS 1.2+ scrape to succeed, got %d", resp.StatusCode)
+	}
+}
+
+// writeSelfSignedCertificate writes a certificate for 127.0.0.1 and its key as PEM
+func writeSelfSignedCertificate(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "metrics"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
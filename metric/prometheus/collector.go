@@ -0,0 +1,240 @@
+package prometheus
+
+import (
+	"sort"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector exposes a metric.Registry as a prom.Collector.
+// Values are read from the registry on every scrape, so there is no Report
+// loop and the first scrape after startup already contains live data.
+type Collector struct {
+	source      metric.Registry
+	constLabels prom.Labels
+	// nativeBucketFactor is the native bucket factor of histograms annotated
+	// for native export; see WithNativeHistograms
+	nativeBucketFactor float64
+	// openMetrics adds created timestamps and exemplars; see WithOpenMetrics
+	openMetrics bool
+	// staleness leaves out series not written during recent scrapes; see WithStaleAfter
+	staleness *staleness
+}
+
+// NewCollector creates a Collector reading from the given registry.
+// constLabels are added to every exported series.
+func NewCollector(source metric.Registry, constLabels map[string]string) *Collector {
+	labels := prom.Labels{}
+	for k, v := range constLabels {
+		labels[k] = v
+	}
+	return &Collector{
+		source:      source,
+		constLabels: labels,
+	}
+}
+
+// WithLiveRegistry registers a Collector for the given registry with the
+// reporter's Prometheus registry so the Handler serves live values on scrape.
+// Report does not need to be called for a live registry.
+func WithLiveRegistry(source metric.Registry) Option {
+	return func(r *Reporter) {
+		r.liveSources = append(r.liveSources, source)
+	}
+}
+
+// Describe implements prom.Collector. The collector is unchecked because the
+// set of metrics in the source registry changes at runtime.
+func (c *Collector) Describe(chan<- *prom.Desc) {}
+
+// Collect implements prom.Collector by converting each registry metric to a const metric
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	cutoff := c.staleness.begin()
+	c.source.Each(func(m metric.Metric) {
+		if c.staleness.stale(m, cutoff) {
+			return
+		}
+		labelNames, labelValues := sortedLabels(m.Tags())
+
+		switch m.Type() {
+		case metric.TypeCounter:
+			if counter, ok := m.(metric.Counter); ok {
+				desc := c.desc(sanitizeName(m.Name()), m, labelNames)
+				ch <- withTimestamp(m, c.counter(desc, m, float64(counter.Value()), labelValues))
+			}
+		case metric.TypeGauge:
+			if gauge, ok := m.(metric.Gauge); ok {
+				unit := metric.ExportUnit(m)
+				desc := c.desc(unit.Name(sanitizeName(m.Name())), m, labelNames)
+				ch <- constMetric(desc, prom.GaugeValue, unit.Convert(metric.GaugeValue(gauge)), labelValues)
+			}
+		case metric.TypeUpDownCounter:
+			// Up-down counters can decrease, so Prometheus models them as gauges
+			if counter, ok := m.(metric.UpDownCounter); ok {
+				desc := c.desc(sanitizeName(m.Name()), m, labelNames)
+				ch <- withTimestamp(m, constMetric(desc, prom.GaugeValue, float64(counter.Value()), labelValues))
+			}
+		case metric.TypeHistogram:
+			if histogram, ok := m.(metric.Histogram); ok {
+				// Values in a known unit are exported in its base unit, named with its suffix
+				unit := metric.ExportUnit(m)
+				desc := c.desc(unit.Name(sanitizeName(m.Name())), m, labelNames)
+				ch <- c.histogram(desc, m, histogram.Snapshot(), unit.Divisor, labelValues)
+			}
+		case metric.TypeTimer:
+			if timer, ok := m.(metric.Timer); ok {
+				// Timers record nanoseconds; Prometheus convention is seconds
+				unit := metric.ExportUnit(m)
+				desc := c.desc(unit.Name(sanitizeName(m.Name())), m, labelNames)
+				ch <- c.histogram(desc, m, timer.Snapshot(), unit.Divisor, labelValues)
+			}
+		case metric.TypeMeter:
+			// Meters are exported as one gauge per moving average
+			if meter, ok := m.(metric.Meter); ok {
+				rates := meterRates(meter.Snapshot())
+				for i, suffix := range meterRateSuffixes {
+					desc := c.desc(sanitizeName(m.Name())+suffix, m, labelNames)
+					ch <- constMetric(desc, prom.GaugeValue, rates[i], labelValues)
+				}
+			}
+		}
+	})
+}
+
+func (c *Collector) desc(name string, m metric.Metric, labelNames []string) *prom.Desc {
+	return prom.NewDesc(name, getMetricHelp(m), labelNames, c.constLabels)
+}
+
+// counter converts a counter value, adding its created timestamp and exemplar
+// when OpenMetrics data is enabled
+func (c *Collector) counter(desc *prom.Desc, m metric.Metric, value float64, labelValues []string) prom.Metric {
+	if !c.openMetrics {
+		return constMetric(desc, prom.CounterValue, value, labelValues)
+	}
+
+	created := createdOf(m)
+	if created.IsZero() {
+		return withExemplars(m, constMetric(desc, prom.CounterValue, value, labelValues), 1)
+	}
+	pm, err := prom.NewConstMetricWithCreatedTimestamp(desc, prom.CounterValue, value, created, labelValues...)
+	if err != nil {
+		return prom.NewInvalidMetric(desc, err)
+	}
+	return withExemplars(m, pm, 1)
+}
+
+// histogram converts a histogram or timer snapshot with the collector's settings
+func (c *Collector) histogram(desc *prom.Desc, m metric.Metric, snapshot metric.HistogramSnapshot, divisor float64, labelValues []string) prom.Metric {
+	return histogramMetric(desc, m, snapshot, divisor, nil, c.nativeBucketFactor, c.openMetrics, labelValues)
+}
+
+// histogramMetric converts a histogram or timer snapshot, adding native buckets
+// if they are enabled for m, and its created timestamp and exemplars when
+// openMetrics is set. Non-nil bounds regroup the classic buckets; see cumulativeBuckets.
+func histogramMetric(desc *prom.Desc, m metric.Metric, snapshot metric.HistogramSnapshot, divisor float64, bounds []float64, nativeFactor float64, openMetrics bool, labelValues []string) prom.Metric {
+	var created time.Time
+	if openMetrics {
+		created = createdOf(m)
+	}
+
+	var pm prom.Metric
+	if factor := nativeBucketFactor(m, nativeFactor); factor > 0 {
+		pm = constNativeHistogram(desc, snapshot, divisor, bounds, factor, created, labelValues)
+	} else {
+		pm = constHistogram(desc, snapshot, divisor, bounds, created, labelValues)
+	}
+	if openMetrics {
+		pm = withExemplars(m, pm, divisor)
+	}
+	return pm
+}
+
+// constMetric builds a const metric, reporting construction errors as invalid metrics
+func constMetric(desc *prom.Desc, valueType prom.ValueType, value float64, labelValues []string) prom.Metric {
+	m, err := prom.NewConstMetric(desc, valueType, value, labelValues...)
+	if err != nil {
+		return prom.NewInvalidMetric(desc, err)
+	}
+	return m
+}
+
+// withTimestamp attaches the explicit timestamp recorded through metric.TimestampedAdder, if any
+func withTimestamp(m metric.Metric, pm prom.Metric) prom.Metric {
+	if adder, ok := m.(metric.TimestampedAdder); ok {
+		if ts := adder.LastTimestamp(); !ts.IsZero() {
+			return prom.NewMetricWithTimestamp(ts, pm)
+		}
+	}
+	return pm
+}
+
+// constHistogram converts a snapshot into a const histogram with cumulative buckets.
+// divisor scales the recorded values (e.g., 1e9 to turn nanoseconds into seconds).
+// A non-zero created time is exported as the histogram's created timestamp.
+func constHistogram(desc *prom.Desc, snapshot metric.HistogramSnapshot, divisor float64, bounds []float64, created time.Time, labelValues []string) prom.Metric {
+	buckets := cumulativeBuckets(snapshot, divisor, bounds)
+
+	var (
+		m   prom.Metric
+		err error
+	)
+	if created.IsZero() {
+		m, err = prom.NewConstHistogram(desc, snapshot.Count, snapshot.Sum/divisor, buckets, labelValues...)
+	} else {
+		m, err = prom.NewConstHistogramWithCreatedTimestamp(desc, snapshot.Count, snapshot.Sum/divisor, buckets, created, labelValues...)
+	}
+	if err != nil {
+		return prom.NewInvalidMetric(desc, err)
+	}
+	return m
+}
+
+// cumulativeBuckets returns the cumulative bucket counts of a snapshot by upper
+// bound, scaled by divisor. Non-nil bounds, in the scaled unit, replace the
+// snapshot's boundaries: each counts the observations in the snapshot's buckets
+// whose upper boundary is at or below it.
+func cumulativeBuckets(snapshot metric.HistogramSnapshot, divisor float64, bounds []float64) map[float64]uint64 {
+	if len(snapshot.Buckets) != len(snapshot.Boundaries)+1 {
+		return map[float64]uint64{}
+	}
+
+	own := make(map[float64]uint64, len(snapshot.Boundaries))
+	upper := make([]float64, len(snapshot.Boundaries))
+	var cumulative uint64
+	for i, boundary := range snapshot.Boundaries {
+		cumulative += snapshot.Buckets[i]
+		upper[i] = boundary / divisor
+		own[upper[i]] = cumulative
+	}
+	if bounds == nil {
+		return own
+	}
+
+	buckets := make(map[float64]uint64, len(bounds))
+	for _, bound := range bounds {
+		// Boundaries are sorted, so the last one at or below bound holds its count
+		if i := sort.Search(len(upper), func(i int) bool { return upper[i] > bound }); i > 0 {
+			buckets[bound] = own[upper[i-1]]
+		} else {
+			buckets[bound] = 0
+		}
+	}
+	return buckets
+}
+
+// sortedLabels splits tags into label names and values in a stable order
+func sortedLabels(tags metric.Tags) ([]string, []string) {
+	names := make([]string, 0, len(tags))
+	for k := range tags {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = tags[name]
+	}
+	return names, values
+}
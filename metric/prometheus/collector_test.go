@@ -0,0 +1,255 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestLiveRegistryServesWithoutReport(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	reporter := NewReporter(
+		WithLiveRegistry(registry),
+		WithDefaultLabels(map[string]string{"service": "live"}),
+	)
+	handler := reporter.Handler()
+
+	counter := registry.Counter(metric.Options{Name: "live_requests_total", Tags: metric.Tags{"method": "GET"}})
+	counter.Add(3)
+	registry.Gauge(metric.Options{Name: "live_queue_depth"}).Set(7)
+	histogram := registry.Histogram(metric.Options{Name: "live_payload_bytes", Buckets: []float64{10, 100}})
+	histogram.Observe(5)
+	histogram.Observe(50)
+	registry.Timer(metric.Options{Name: "live_latency"}).Record(2 * time.Second)
+
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		return rec.Body.String()
+	}
+
+	body := scrape()
+	expected := []string{
+		`live_requests_total{method="GET",service="live"} 3`,
+		`live_queue_depth{service="live"} 7`,
+		`live_payload_bytes_bucket{service="live",le="10"} 1`,
+		`live_payload_bytes_bucket{service="live",le="100"} 2`,
+		`live_payload_bytes_sum{service="live"} 55`,
+		`live_latency_seconds_sum{service="live"} 2`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected scrape output to contain %q\n%s", line, body)
+		}
+	}
+
+	// Values are read on every scrape
+	counter.Inc()
+	if body := scrape(); !strings.Contains(body, `live_requests_total{method="GET",service="live"} 4`) {
+		t.Errorf("Expected updated counter value in second scrape\n%s", body)
+	}
+}
+
+func TestLiveRegistryUpDownCounterAndTimestamps(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry().(metric.InstrumentRegistry)
+	defer registry.Close()
+
+	reporter := NewReporter(WithLiveRegistry(registry))
+	handler := reporter.Handler()
+
+	inFlight := registry.UpDownCounter(metric.Options{Name: "live_in_flight"})
+	inFlight.Add(5)
+	inFlight.Dec()
+
+	events := registry.Counter(metric.Options{Name: "live_events_total"})
+	events.(metric.TimestampedAdder).AddAt(2, time.UnixMilli(1700000000000))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	expected := []string{
+		"# TYPE live_in_flight gauge",
+		"live_in_flight 4",
+		"live_events_total 2 1700000000000",
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected scrape output to contain %q\n%s", line, body)
+		}
+	}
+}
+
+func TestLiveRegistryPicksUpMetadataChanges(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry().(metric.ConfigurableRegistry)
+	defer registry.Close()
+
+	reporter := NewReporter(WithLiveRegistry(registry))
+	handler := reporter.Handler()
+
+	registry.Counter(metric.Options{Name: "live_jobs_total", Description: "Jobs"}).Inc()
+	registry.SetMetadata("live_jobs_total", "Jobs processed by the worker pool", "")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "# HELP live_jobs_total Jobs processed by the worker pool") {
+		t.Errorf("Expected updated help text in scrape output\n%s", body)
+	}
+}
+
+func TestMeterExportedAsGauges(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry().(metric.InstrumentRegistry)
+	defer registry.Close()
+	registry.Meter(metric.Options{Name: "jobs", Tags: metric.Tags{"queue": "default"}}).Mark(5)
+
+	live := NewReporter(WithLiveRegistry(registry))
+	reported := NewReporter()
+	if err := reported.Report(registry); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	for _, reporter := range []*Reporter{live, reported} {
+		rec := httptest.NewRecorder()
+		reporter.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		body := rec.Body.String()
+		for _, line := range []string{
+			"# TYPE jobs_rate1m gauge",
+			`jobs_rate1m{queue="default"} 0`,
+			`jobs_rate5m{queue="default"} 0`,
+			`jobs_rate15m{queue="default"} 0`,
+		} {
+			if !strings.Contains(body, line) {
+				t.Errorf("Expected scrape output to contain %q\n%s", line, body)
+			}
+		}
+	}
+}
+
+func TestExportFilter(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	requests := registry.Counter(metric.Options{Name: "requests_total", Tags: metric.Tags{"user_id": "1", "route": "/a"}})
+	requests.Add(2)
+	requests.With(metric.Tags{"user_id": "2"}).Inc()
+	registry.Gauge(metric.Options{Name: "internal_cache_size"}).Set(9)
+
+	opts := []Option{
+		WithExportFilter(metric.StripTags("user_id")),
+		WithMetricFilter(func(m metric.Metric) bool { return m.Name() != "internal_cache_size" }),
+	}
+	live := NewReporter(append(opts, WithLiveRegistry(registry))...)
+	reported := NewReporter(opts...)
+	if err := reported.Report(registry); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	for _, reporter := range []*Reporter{live, reported} {
+		rec := httptest.NewRecorder()
+		reporter.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		body := rec.Body.String()
+		if !strings.Contains(body, `requests_total{route="/a"} 3`) {
+			t.Errorf("Expected the stripped series to be merged\n%s", body)
+		}
+		if strings.Contains(body, "user_id") || strings.Contains(body, "internal_cache_size") {
+			t.Errorf("Expected filtered metrics and tags to be left out\n%s", body)
+		}
+	}
+}
+
+func TestHistogramUnitsExportedInBaseUnits(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	latency := registry.Histogram(metric.Options{Name: "db_query_duration", Unit: "milliseconds", Buckets: []float64{10, 100}})
+	latency.Observe(50)
+	latency.Observe(150)
+	registry.Histogram(metric.Options{Name: "upload_size_bytes", Unit: "KiB", Buckets: []float64{1, 4}}).Observe(2)
+	registry.Timer(metric.Options{Name: "job_duration_seconds", Unit: "milliseconds"}).Record(2 * time.Second)
+
+	live := NewReporter(WithLiveRegistry(registry))
+	reported := NewReporter()
+	if err := reported.Report(registry); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	live.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, line := range []string{
+		`db_query_duration_seconds_bucket{le="0.01"} 0`,
+		`db_query_duration_seconds_bucket{le="0.1"} 1`,
+		`db_query_duration_seconds_sum 0.2`,
+		`upload_size_bytes_bucket{le="1024"} 0`,
+		`upload_size_bytes_sum 2048`,
+		// Timers record nanoseconds whatever their unit, and an existing suffix is not repeated
+		`job_duration_seconds_sum 2`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected scrape output to contain %q\n%s", line, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	reported.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `db_query_duration_seconds_bucket{le="0.1"}`) {
+		t.Errorf("Expected the reported histogram in seconds\n%s", body)
+	}
+}
+
+func TestDurationGaugeExportedInSeconds(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	metric.NewDurationGauge(registry, metric.Options{Name: "cache_age"}).Set(1500 * time.Millisecond)
+	metric.NewBoolGauge(registry, metric.Options{Name: "upstream_reachable"}).Set(true)
+	registry.Gauge(metric.Options{Name: "heap", Unit: "MB"}).Set(3)
+
+	live := NewReporter(WithLiveRegistry(registry))
+	reported := NewReporter()
+	if err := reported.Report(registry); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	for _, reporter := range []*Reporter{live, reported} {
+		rec := httptest.NewRecorder()
+		reporter.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		body := rec.Body.String()
+		// Gauges not marked for conversion keep their names and values
+		for _, line := range []string{"cache_age_seconds 1.5", "upstream_reachable 1", "heap 3"} {
+			if !strings.Contains(body, line+"\n") {
+				t.Errorf("Expected scrape output to contain %q\n%s", line, body)
+			}
+		}
+	}
+}
+
+func TestFractionalGaugesExportedAsFloats(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry().(metric.InstrumentRegistry)
+	defer registry.Close()
+	registry.Counter(metric.Options{Name: "cache_hits_total"}).Add(25)
+	registry.Counter(metric.Options{Name: "cache_lookups_total"}).Add(100)
+	registry.Derived(metric.Options{Name: "cache_hit_ratio"}, func(s metric.SnapshotView) float64 {
+		return s.Value("cache_hits_total", nil) / s.Value("cache_lookups_total", nil)
+	})
+
+	live := NewReporter(WithLiveRegistry(registry))
+	reported := NewReporter()
+	if err := reported.Report(registry); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	for _, reporter := range []*Reporter{live, reported} {
+		rec := httptest.NewRecorder()
+		reporter.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if body := rec.Body.String(); !strings.Contains(body, "cache_hit_ratio 0.25\n") {
+			t.Errorf("Expected the derived ratio unrounded\n%s", body)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package prometheus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// DefaultCompressionThreshold is the response size in bytes above which the
+// handler gzip-compresses scrape responses for clients that accept it
+const DefaultCompressionThreshold = 1024
+
+// WithCompressionThreshold sets the minimum response size in bytes before the
+// handler compresses a scrape response. A negative value disables compression.
+func WithCompressionThreshold(size int) Option {
+	return func(r *Reporter) {
+		r.compressionThreshold = size
+	}
+}
+
+// WithMaxResponseSize caps the size in bytes of an uncompressed scrape
+// response. Encoding stops once a response grows past it and the scrape fails
+// with a 500 counted in metrics_scrape_rejections_total{reason="too_large"},
+// so a registry whose cardinality exploded cannot exhaust the process's
+// memory on every scrape. Zero, the default, means no limit.
+func WithMaxResponseSize(size int) Option {
+	return func(r *Reporter) {
+		r.maxResponseSize = size
+	}
+}
+
+// handlerMetrics holds the self-metrics recorded by the scrape handler
+type handlerMetrics struct {
+	duration   *prom.HistogramVec
+	size       *prom.HistogramVec
+	rejections *prom.CounterVec
+	errors     *prom.CounterVec
+}
+
+func newHandlerMetrics() *handlerMetrics {
+	return &handlerMetrics{
+		duration: prom.NewHistogramVec(
+			prom.HistogramOpts{
+				Name:    "metrics_scrape_duration_seconds",
+				Help:    "Time spent gathering and encoding a metrics scrape",
+				Buckets: prom.DefBuckets,
+			},
+			[]string{"format", "compression"},
+		),
+		size: prom.NewHistogramVec(
+			prom.HistogramOpts{
+				Name:    "metrics_scrape_response_size_bytes",
+				Help:    "Size of metrics scrape responses as written to the client",
+				Buckets: prom.ExponentialBuckets(256, 4, 8),
+			},
+			[]string{"format", "compression"},
+		),
+		rejections: prom.NewCounterVec(
+			prom.CounterOpts{
+				Name: "metrics_scrape_rejections_total",
+				Help: "Scrapes refused by the handler's network allowlist, authentication or size limit",
+			},
+			[]string{"reason"},
+		),
+		errors: prom.NewCounterVec(
+			prom.CounterOpts{
+				Name: "metrics_scrape_errors_total",
+				Help: "Scrapes that failed, or were served without the families that failed to gather",
+			},
+			[]string{"reason"},
+		),
+	}
+}
+
+// registerHandlerMetrics registers the handler self-metrics once per reporter
+func (r *Reporter) registerHandlerMetrics() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.handlerMetrics != nil {
+		return
+	}
+
+	hm := newHandlerMetrics()
+	try(func() {
+		r.registry.MustRegister(hm.duration, hm.size, hm.rejections, hm.errors)
+	})
+	r.handlerMetrics = hm
+}
+
+// Handler returns an HTTP handler for the Prometheus metrics.
+// The exposition format is negotiated from the Accept header, so scrapers that
+// request OpenMetrics or delimited protobuf receive it and everyone else gets
+// the text format; WithOpenMetrics adds created timestamps and exemplars to
+// OpenMetrics responses. Browsers and tools asking for application/json, or
+// for ?format=json, get an indented JSON debug view of the same families. Responses larger than the compression threshold are
+// gzip-compressed when the client sends Accept-Encoding: gzip.
+//
+// WithBasicAuth, WithBearerTokens, WithBearerTokenValidator and
+// WithAllowedNetworks restrict who may scrape; refused scrapes get 401 or 403
+// and are counted in metrics_scrape_rejections_total. Serve the handler with
+// TLSConfig to encrypt scrapes.
+//
+// Scrapes that fail are counted in metrics_scrape_errors_total by reason. When
+// only some collectors fail, the families gathered from the others are still
+// served and the scrape is counted with reason="partial_gather".
+func (r *Reporter) Handler() http.Handler {
+	r.registerHandlerMetrics()
+	handler := http.Handler(http.HandlerFunc(r.serveMetrics))
+	if r.scrapeAccess != nil {
+		handler = r.scrapeAccess.protect(handler, func(reason string) {
+			r.handlerMetrics.rejections.WithLabelValues(reason).Inc()
+		})
+	}
+	return handler
+}
+
+func (r *Reporter) serveMetrics(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+
+	families, err := r.registry.Gather()
+	if err != nil && len(families) == 0 {
+		r.countScrapeError("gather")
+		http.Error(w, "error gathering metrics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		r.countScrapeError("partial_gather")
+	}
+
+	body := &limitedBuffer{limit: r.maxResponseSize}
+	var contentType, label string
+	if wantsJSON(req) {
+		contentType, label = jsonContentType, "json"
+		err = encodeJSON(body, families)
+	} else {
+		format := expfmt.NegotiateIncludingOpenMetrics(req.Header)
+		contentType, label = string(format), formatLabel(format)
+		err = r.encode(body, format, families)
+	}
+	if errors.Is(err, errResponseTooLarge) {
+		if hm := r.handlerMetrics; hm != nil {
+			hm.rejections.WithLabelValues("too_large").Inc()
+		}
+		http.Error(w, fmt.Sprintf("metrics response exceeds %d bytes", r.maxResponseSize), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		r.countScrapeError("encode")
+		http.Error(w, "error encoding metrics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", contentType)
+	header.Add("Vary", "Accept-Encoding")
+
+	compression := "identity"
+	payload := body.Bytes()
+	if r.shouldCompress(req, len(payload)) {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		if _, err := gz.Write(payload); err == nil && gz.Close() == nil {
+			compression = "gzip"
+			payload = compressed.Bytes()
+			header.Set("Content-Encoding", "gzip")
+		}
+	}
+
+	header.Set("Content-Length", strconv.Itoa(len(payload)))
+	_, _ = w.Write(payload)
+
+	if hm := r.handlerMetrics; hm != nil {
+		hm.duration.WithLabelValues(label, compression).Observe(time.Since(start).Seconds())
+		hm.size.WithLabelValues(label, compression).Observe(float64(len(payload)))
+	}
+}
+
+// countScrapeError counts a failed or partial scrape in metrics_scrape_errors_total
+func (r *Reporter) countScrapeError(reason string) {
+	if hm := r.handlerMetrics; hm != nil {
+		hm.errors.WithLabelValues(reason).Inc()
+	}
+}
+
+// encode writes families in an exposition format
+func (r *Reporter) encode(w io.Writer, format expfmt.Format, families []*dto.MetricFamily) error {
+	var options []expfmt.EncoderOption
+	if r.openMetrics && format.FormatType() == expfmt.TypeOpenMetrics {
+		options = append(options, expfmt.WithCreatedLines())
+	}
+
+	encoder := expfmt.NewEncoder(w, format, options...)
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return err
+		}
+	}
+	if closer, ok := encoder.(expfmt.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// errResponseTooLarge is returned by a limitedBuffer once its limit is exceeded
+var errResponseTooLarge = errors.New("response too large")
+
+// limitedBuffer is a bytes.Buffer refusing writes beyond limit bytes, so an
+// oversized response is abandoned while it is being encoded; zero means no limit
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.limit > 0 && b.Len()+len(p) > b.limit {
+		return 0, errResponseTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
+// shouldCompress reports whether a response of the given size should be gzipped
+func (r *Reporter) shouldCompress(req *http.Request, size int) bool {
+	if r.compressionThreshold < 0 || size < r.compressionThreshold {
+		return false
+	}
+	return acceptsGzip(req.Header.Get("Accept-Encoding"))
+}
+
+// acceptsGzip parses an Accept-Encoding header and reports whether gzip is allowed
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// formatLabel maps a negotiated exposition format to a short label value
+func formatLabel(format expfmt.Format) string {
+	switch format.FormatType() {
+	case expfmt.TypeOpenMetrics:
+		return "openmetrics"
+	case expfmt.TypeProtoDelim, expfmt.TypeProtoText, expfmt.TypeProtoCompact:
+		return "protobuf"
+	default:
+		return "text"
+	}
+}
@@ -0,0 +1,139 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// jsonContentType is the content type of the handler's JSON debug view
+const jsonContentType = "application/json; charset=utf-8"
+
+// jsonFamily is the JSON form of a metric family served by the debug view
+type jsonFamily struct {
+	Name    string       `json:"name"`
+	Help    string       `json:"help,omitempty"`
+	Type    string       `json:"type"`
+	Metrics []jsonMetric `json:"metrics"`
+}
+
+// jsonMetric is one series of a family. Counters, gauges and untyped metrics
+// have a Value; histograms and summaries have a Count, Sum and their buckets or quantiles.
+type jsonMetric struct {
+	Labels    map[string]string `json:"labels,omitempty"`
+	Value     *float64          `json:"value,omitempty"`
+	Count     *uint64           `json:"count,omitempty"`
+	Sum       *float64          `json:"sum,omitempty"`
+	Buckets   []jsonBucket      `json:"buckets,omitempty"`
+	Quantiles map[string]string `json:"quantiles,omitempty"`
+}
+
+// jsonBucket is one cumulative histogram bucket; its upper bound is a string
+// so the last bucket can be "+Inf", which JSON numbers cannot hold
+type jsonBucket struct {
+	LE    string `json:"le"`
+	Count uint64 `json:"count"`
+}
+
+// wantsJSON reports whether the request asks for the JSON debug view, with
+// ?format=json or an Accept header naming application/json but none of the
+// exposition formats scrapers ask for
+func wantsJSON(req *http.Request) bool {
+	if req.URL.Query().Get("format") == "json" {
+		return true
+	}
+	accepted := false
+	for _, part := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			accepted = true
+		case "text/plain", "application/openmetrics-text", "application/vnd.google.protobuf":
+			return false
+		}
+	}
+	return accepted
+}
+
+// encodeJSON writes families as the indented JSON debug view
+func encodeJSON(w io.Writer, families []*dto.MetricFamily) error {
+	out := make([]jsonFamily, 0, len(families))
+	for _, family := range families {
+		f := jsonFamily{
+			Name:    family.GetName(),
+			Help:    family.GetHelp(),
+			Type:    strings.ToLower(family.GetType().String()),
+			Metrics: make([]jsonMetric, 0, len(family.GetMetric())),
+		}
+		for _, m := range family.GetMetric() {
+			f.Metrics = append(f.Metrics, jsonMetricOf(m))
+		}
+		out = append(out, f)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+func jsonMetricOf(m *dto.Metric) jsonMetric {
+	var out jsonMetric
+	if len(m.GetLabel()) > 0 {
+		out.Labels = make(map[string]string, len(m.GetLabel()))
+		for _, label := range m.GetLabel() {
+			out.Labels[label.GetName()] = label.GetValue()
+		}
+	}
+
+	switch {
+	case m.Counter != nil:
+		out.Value = jsonNumber(m.GetCounter().GetValue())
+	case m.Gauge != nil:
+		out.Value = jsonNumber(m.GetGauge().GetValue())
+	case m.Untyped != nil:
+		out.Value = jsonNumber(m.GetUntyped().GetValue())
+	case m.Histogram != nil:
+		h := m.GetHistogram()
+		count := h.GetSampleCount()
+		out.Count, out.Sum = &count, jsonNumber(h.GetSampleSum())
+		for _, bucket := range h.GetBucket() {
+			if math.IsInf(bucket.GetUpperBound(), 1) {
+				continue
+			}
+			out.Buckets = append(out.Buckets, jsonBucket{
+				LE:    strconv.FormatFloat(bucket.GetUpperBound(), 'g', -1, 64),
+				Count: bucket.GetCumulativeCount(),
+			})
+		}
+		out.Buckets = append(out.Buckets, jsonBucket{LE: "+Inf", Count: count})
+	case m.Summary != nil:
+		s := m.GetSummary()
+		count := s.GetSampleCount()
+		out.Count, out.Sum = &count, jsonNumber(s.GetSampleSum())
+		out.Quantiles = make(map[string]string, len(s.GetQuantile()))
+		for _, q := range s.GetQuantile() {
+			out.Quantiles[strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64)] = strconv.FormatFloat(q.GetValue(), 'g', -1, 64)
+		}
+	}
+	return out
+}
+
+// jsonNumber returns a pointer to v, or nil for NaN and infinities, which JSON cannot hold
+func jsonNumber(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
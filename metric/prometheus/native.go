@@ -0,0 +1,182 @@
+package prometheus
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	prom "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// NativeHistogramAnnotation is the metric.Options.Annotations key that enables
+// native histogram export for a histogram or timer. "true" enables it with the
+// reporter's bucket factor (see WithNativeHistograms) or
+// DefaultNativeHistogramBucketFactor, and a number greater than 1 enables it
+// with that bucket factor. Other values, like a missing annotation, keep the
+// metric classic only.
+const NativeHistogramAnnotation = "prom.native_histogram"
+
+// DefaultNativeHistogramBucketFactor is the growth factor between native
+// histogram buckets when none is configured, giving schema 3
+const DefaultNativeHistogramBucketFactor = 1.1
+
+// DefaultNativeHistogramMaxBuckets bounded the native buckets of histograms the
+// reporter observed itself.
+//
+// Deprecated: unused. Native buckets are derived from each metric's own buckets,
+// which bound their number.
+const DefaultNativeHistogramMaxBuckets = 160
+
+// WithNativeHistograms sets the bucket factor of histograms and timers whose
+// NativeHistogramAnnotation is "true". Only annotated metrics are exported as
+// native histograms, in addition to their classic buckets: the registry keeps
+// classic bucket counts, so native buckets are a re-encoding of those at
+// classic resolution rather than observations bucketed exponentially (see
+// constNativeHistogram). Scrapers that negotiate protobuf and support native
+// histograms use the native buckets; the text and OpenMetrics formats carry
+// only the classic buckets.
+func WithNativeHistograms(bucketFactor float64) Option {
+	return func(r *Reporter) {
+		if bucketFactor <= 1 {
+			bucketFactor = DefaultNativeHistogramBucketFactor
+		}
+		r.nativeBucketFactor = bucketFactor
+	}
+}
+
+// nativeBucketFactor returns the native histogram bucket factor for m, or zero
+// if m is exported with classic buckets only
+func nativeBucketFactor(m metric.Metric, reporterFactor float64) float64 {
+	value, _ := metric.AnnotationOf(m, NativeHistogramAnnotation)
+	if value == "true" {
+		if reporterFactor > 0 {
+			return reporterFactor
+		}
+		return DefaultNativeHistogramBucketFactor
+	}
+	if factor, err := strconv.ParseFloat(value, 64); err == nil && factor > 1 {
+		return factor
+	}
+	return 0
+}
+
+// nativeSchema returns the coarsest native histogram schema whose bucket
+// growth factor does not exceed bucketFactor
+func nativeSchema(bucketFactor float64) int32 {
+	schema := int32(math.Ceil(-math.Log2(math.Log2(bucketFactor))))
+	return max(-4, min(8, schema))
+}
+
+// nativeIndex returns the index of the native bucket holding v > 0: bucket i
+// covers (base^(i-1), base^i] with base 2^(2^-schema)
+func nativeIndex(v float64, schema int32) int {
+	return int(math.Ceil(math.Log2(v) * math.Exp2(float64(schema))))
+}
+
+// nativeHistogram is a const classic histogram that also carries native buckets
+type nativeHistogram struct {
+	prom.Metric
+	schema             int32
+	zeroCount          uint64
+	positive, negative map[int]int64
+}
+
+// constNativeHistogram converts a snapshot into a histogram with both classic
+// and native buckets. The snapshot only keeps classic bucket counts, so this is
+// a classic-resolution re-encoding: each classic bucket is placed in the native
+// bucket holding its upper bound (the maximum observation for the +Inf bucket).
+// Observations are thereby moved up to their classic bound, which biases native
+// quantiles upwards by up to a classic bucket width; native resolution is never
+// finer than the metric's own buckets.
+func constNativeHistogram(desc *prom.Desc, snapshot metric.HistogramSnapshot, divisor float64, bounds []float64, bucketFactor float64, created time.Time, labelValues []string) prom.Metric {
+	classic := constHistogram(desc, snapshot, divisor, bounds, created, labelValues)
+	if len(snapshot.Buckets) != len(snapshot.Boundaries)+1 {
+		return classic
+	}
+
+	h := &nativeHistogram{
+		Metric:   classic,
+		schema:   nativeSchema(bucketFactor),
+		positive: make(map[int]int64),
+		negative: make(map[int]int64),
+	}
+	for i, count := range snapshot.Buckets {
+		if count == 0 {
+			continue
+		}
+		upper := snapshot.Max
+		if i < len(snapshot.Boundaries) {
+			upper = min(upper, snapshot.Boundaries[i])
+		}
+		upper /= divisor
+
+		switch {
+		case upper > 0:
+			h.positive[nativeIndex(upper, h.schema)] += int64(count)
+		case upper < 0:
+			h.negative[nativeIndex(-upper, h.schema)] += int64(count)
+		default:
+			h.zeroCount += count
+		}
+	}
+	return h
+}
+
+// Write implements prom.Metric, adding the native buckets to the classic histogram
+func (h *nativeHistogram) Write(out *dto.Metric) error {
+	if err := h.Metric.Write(out); err != nil {
+		return err
+	}
+	histogram := out.GetHistogram()
+	if histogram == nil {
+		return nil
+	}
+
+	histogram.Schema = proto.Int32(h.schema)
+	histogram.ZeroThreshold = proto.Float64(0)
+	histogram.ZeroCount = proto.Uint64(h.zeroCount)
+	histogram.PositiveSpan, histogram.PositiveDelta = nativeSpans(h.positive)
+	histogram.NegativeSpan, histogram.NegativeDelta = nativeSpans(h.negative)
+	if len(histogram.PositiveSpan) == 0 && len(histogram.NegativeSpan) == 0 && h.zeroCount == 0 {
+		// An empty span marks the histogram as native even without observations
+		histogram.PositiveSpan = []*dto.BucketSpan{{Offset: proto.Int32(0), Length: proto.Uint32(0)}}
+	}
+	return nil
+}
+
+// nativeSpans encodes sparse bucket counts as spans of consecutive indexes and
+// the deltas between successive counts, as the protobuf format expects
+func nativeSpans(buckets map[int]int64) ([]*dto.BucketSpan, []int64) {
+	if len(buckets) == 0 {
+		return nil, nil
+	}
+
+	indexes := make([]int, 0, len(buckets))
+	for i := range buckets {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	var (
+		spans    []*dto.BucketSpan
+		deltas   = make([]int64, 0, len(indexes))
+		previous int64
+	)
+	for n, i := range indexes {
+		if n == 0 || i != indexes[n-1]+1 {
+			offset := i
+			if n > 0 {
+				offset = i - indexes[n-1] - 1
+			}
+			spans = append(spans, &dto.BucketSpan{Offset: proto.Int32(int32(offset)), Length: proto.Uint32(0)})
+		}
+		*spans[len(spans)-1].Length++
+		deltas = append(deltas, buckets[i]-previous)
+		previous = buckets[i]
+	}
+	return spans, deltas
+}
@@ -0,0 +1,141 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// gatherHistogram returns the histogram of the first series in the named family
+func gatherHistogram(t *testing.T, reporter *Reporter, name string) *dto.Histogram {
+	t.Helper()
+	families, err := reporter.registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == name && len(family.GetMetric()) > 0 {
+			return family.GetMetric()[0].GetHistogram()
+		}
+	}
+	t.Fatalf("Family %s not gathered", name)
+	return nil
+}
+
+// nativeCount sums the bucket counts encoded in spans and deltas
+func nativeCount(deltas []int64) int64 {
+	var total, count int64
+	for _, delta := range deltas {
+		count += delta
+		total += count
+	}
+	return total
+}
+
+func TestLiveRegistryNativeHistograms(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	reporter := NewReporter(WithLiveRegistry(registry))
+
+	native := registry.Histogram(metric.Options{
+		Name:        "native_payload_bytes",
+		Buckets:     []float64{1, 2, 4, 8},
+		Annotations: map[string]string{NativeHistogramAnnotation: "2"},
+	})
+	for _, v := range []float64{0.5, 1.5, 3, 3, 6, 20} {
+		native.Observe(v)
+	}
+	registry.Histogram(metric.Options{Name: "classic_payload_bytes", Buckets: []float64{1, 2}}).Observe(1)
+
+	h := gatherHistogram(t, reporter, "native_payload_bytes")
+	if h.GetSchema() != 0 {
+		t.Errorf("Expected schema 0 for bucket factor 2, got %d", h.GetSchema())
+	}
+	if len(h.GetBucket()) != 4 {
+		t.Errorf("Expected classic buckets to be kept, got %d", len(h.GetBucket()))
+	}
+	// Buckets 2^0, 2^1, 2^2, 2^3 and the +Inf bucket at the maximum 20 (index 5)
+	spans := h.GetPositiveSpan()
+	if len(spans) != 2 || spans[0].GetOffset() != 0 || spans[0].GetLength() != 4 || spans[1].GetOffset() != 1 {
+		t.Errorf("Unexpected native spans %v", spans)
+	}
+	if got := nativeCount(h.GetPositiveDelta()); got != 6 {
+		t.Errorf("Expected native buckets to hold 6 observations, got %d", got)
+	}
+
+	if h := gatherHistogram(t, reporter, "classic_payload_bytes"); h.Schema != nil || len(h.GetPositiveSpan()) != 0 {
+		t.Errorf("Expected a classic-only histogram without the annotation, got %v", h)
+	}
+
+	// The text format still carries the classic buckets
+	rec := httptest.NewRecorder()
+	reporter.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `native_payload_bytes_bucket{le="4"} 4`) {
+		t.Errorf("Expected classic buckets in text output\n%s", body)
+	}
+}
+
+func TestWithNativeHistograms(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	reporter := NewReporter(WithNativeHistograms(0), WithLiveRegistry(registry))
+	optIn := map[string]string{NativeHistogramAnnotation: "true"}
+	registry.Timer(metric.Options{Name: "native_latency", Annotations: optIn}).Record(100 * time.Millisecond)
+	registry.Histogram(metric.Options{Name: "unannotated_bytes"}).Observe(1)
+	registry.Histogram(metric.Options{
+		Name:        "opted_out_bytes",
+		Annotations: map[string]string{NativeHistogramAnnotation: "false"},
+	}).Observe(1)
+
+	h := gatherHistogram(t, reporter, "native_latency_seconds")
+	if h.GetSchema() != 3 {
+		t.Errorf("Expected schema 3 for the default bucket factor, got %d", h.GetSchema())
+	}
+	if got := nativeCount(h.GetPositiveDelta()); got != 1 {
+		t.Errorf("Expected 1 native observation, got %d", got)
+	}
+	// The option only sets the factor; native export stays opt-in per metric
+	for _, name := range []string{"unannotated_bytes", "opted_out_bytes"} {
+		if h := gatherHistogram(t, reporter, name); h.Schema != nil {
+			t.Errorf("Expected %s to be classic only, got schema %d", name, h.GetSchema())
+		}
+	}
+
+	// Annotated histograms exported by Report get native buckets too
+	source := metric.NewNoCleanupRegistry()
+	defer source.Close()
+	source.Histogram(metric.Options{Name: "reported_bytes", Buckets: []float64{10, 100}, Annotations: optIn}).Observe(50)
+	if err := reporter.Report(source); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if h := gatherHistogram(t, reporter, "reported_bytes"); h.GetSchema() != 3 || len(h.GetBucket()) != 2 {
+		t.Errorf("Expected native and classic buckets from Report, got %v", h)
+	}
+}
+
+func TestNativeSpans(t *testing.T) {
+	spans, deltas := nativeSpans(map[int]int64{-2: 1, -1: 3, 2: 2})
+	if len(spans) != 2 || spans[0].GetOffset() != -2 || spans[0].GetLength() != 2 ||
+		spans[1].GetOffset() != 2 || spans[1].GetLength() != 1 {
+		t.Errorf("Unexpected spans %v", spans)
+	}
+	if want := []int64{1, 2, -1}; len(deltas) != 3 || deltas[0] != want[0] || deltas[1] != want[1] || deltas[2] != want[2] {
+		t.Errorf("Expected deltas %v, got %v", want, deltas)
+	}
+}
+
+func TestCapabilitiesRegistered(t *testing.T) {
+	if missing := metric.MissingCapabilities(
+		metric.Capability{Kind: metric.CapabilityReporter, Name: "prometheus"},
+		metric.Capability{Kind: metric.CapabilityFeature, Name: "prometheus_native_histograms"},
+	); len(missing) != 0 {
+		t.Errorf("Expected the prometheus package to register its capabilities, missing %+v", missing)
+	}
+}
@@ -0,0 +1,65 @@
+package prometheus
+
+import (
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// WithOpenMetrics adds the data only the OpenMetrics format carries to scrapes
+// that negotiate it: _created samples holding when each counter and histogram
+// was created or last reset, and exemplars recorded through metric.ExemplarAdder
+// and metric.ExemplarObserver. Creation times and exemplars come from the
+// metrics themselves for live registries (see WithLiveRegistry); series exported
+// through Report are created when first reported and carry no exemplars.
+// Scrapes negotiating other formats are unaffected.
+func WithOpenMetrics() Option {
+	return func(r *Reporter) {
+		r.openMetrics = true
+	}
+}
+
+// createdOf returns when m was created, or the zero time if unknown
+func createdOf(m metric.Metric) time.Time {
+	if c, ok := m.(metric.CreatedTimestamper); ok {
+		return c.Created()
+	}
+	return time.Time{}
+}
+
+// exemplarsOf returns the exemplars of a counter or histogram with values scaled
+// by divisor, like the metric's own values
+func exemplarsOf(m metric.Metric, divisor float64) []prom.Exemplar {
+	var exemplars []metric.Exemplar
+	switch e := m.(type) {
+	case metric.ExemplarAdder:
+		exemplars = e.Exemplars()
+	case metric.ExemplarObserver:
+		exemplars = e.Exemplars()
+	}
+
+	result := make([]prom.Exemplar, 0, len(exemplars))
+	for _, e := range exemplars {
+		result = append(result, prom.Exemplar{
+			Value:     e.Value / divisor,
+			Labels:    prom.Labels(e.Labels),
+			Timestamp: e.Timestamp,
+		})
+	}
+	return result
+}
+
+// withExemplars attaches the exemplars of m to pm. Exemplars the format cannot
+// carry, such as ones with too many label characters, are left out.
+func withExemplars(m metric.Metric, pm prom.Metric, divisor float64) prom.Metric {
+	exemplars := exemplarsOf(m, divisor)
+	if len(exemplars) == 0 {
+		return pm
+	}
+	withExemplars, err := prom.NewMetricWithExemplars(pm, exemplars...)
+	if err != nil {
+		return pm
+	}
+	return withExemplars
+}
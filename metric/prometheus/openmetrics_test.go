@@ -0,0 +1,51 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// scrape returns the body served by handler for the given Accept header
+func scrape(t *testing.T, handler http.Handler, accept string) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", accept)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Body.String()
+}
+
+func TestOpenMetricsCreatedAndExemplars(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	counter := registry.Counter(metric.Options{Name: "om_orders_total"})
+	counter.(metric.ExemplarAdder).AddWithExemplar(2, metric.Tags{"trace_id": "abc123"})
+	histogram := registry.Histogram(metric.Options{Name: "om_payload_bytes", Buckets: []float64{10, 100}})
+	histogram.(metric.ExemplarObserver).ObserveWithExemplar(42, metric.Tags{"trace_id": "def456"})
+
+	const openMetrics = "application/openmetrics-text; version=1.0.0"
+	body := scrape(t, NewReporter(WithLiveRegistry(registry), WithOpenMetrics()).Handler(), openMetrics)
+	for _, want := range []string{
+		"om_orders_created ",
+		`om_orders_total 2.0 # {trace_id="abc123"} 2.0`,
+		"om_payload_bytes_created ",
+		`om_payload_bytes_bucket{le="100.0"} 1 # {trace_id="def456"} 42.0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in OpenMetrics output:\n%s", want, body)
+		}
+	}
+
+	// Text scrapes and reporters without the option are unaffected
+	if body := scrape(t, NewReporter(WithLiveRegistry(registry), WithOpenMetrics()).Handler(), "text/plain"); strings.Contains(body, "_created") || strings.Contains(body, "trace_id") {
+		t.Errorf("Expected no created samples or exemplars in text output:\n%s", body)
+	}
+	if body := scrape(t, NewReporter(WithLiveRegistry(registry)).Handler(), openMetrics); strings.Contains(body, "_created") || strings.Contains(body, "trace_id") {
+		t.Errorf("Expected no created samples or exemplars without WithOpenMetrics:\n%s", body)
+	}
+}
@@ -2,42 +2,71 @@
 package prometheus
 
 import (
-	"fmt"
+	"context"
+	"strings"
 	"sync"
 
-	"net/http"
-
 	"github.com/MichaelAJay/go-metrics/metric"
 	prom "github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityReporter, Name: "prometheus", Package: "github.com/MichaelAJay/go-metrics/metric/prometheus"})
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "prometheus_native_histograms", Package: "github.com/MichaelAJay/go-metrics/metric/prometheus"})
+}
+
 // counterState tracks state for delta calculation
 type counterState struct {
 	promCounter prom.Counter
 	lastValue   uint64
+	generation  uint64 // of the counter when lastValue was read; see metric.CounterSnapshot
 }
 
-// Reporter implements the metric.Reporter interface for Prometheus
+// Reporter implements the metric.Reporter interface for Prometheus.
+// Metric families (name plus label names) are registered once as vectors, or
+// as collectors of the reported snapshots for histograms, and per-series state
+// is keyed by the label values so that series sharing a name never overwrite
+// each other.
 type Reporter struct {
-	registry      *prom.Registry
-	counters      map[string]*counterState
-	gauges        map[string]prom.Gauge
-	histograms    map[string]prom.Observer
-	mutex         sync.Mutex
-	defaultLabels prom.Labels
-	registered    map[string]bool
+	registry    *prom.Registry
+	counterVecs map[string]*prom.CounterVec
+	gaugeVecs   map[string]*prom.GaugeVec
+	counters    map[string]*counterState
+	gauges      map[string]prom.Gauge
+	// histogramFamilies export histograms and timers from their last reported snapshots
+	histogramFamilies map[string]*histogramFamily
+	mutex             sync.Mutex
+	defaultLabels     prom.Labels
+
+	bucketOverrides      map[string][]float64
+	compressionThreshold int
+	maxResponseSize      int
+	nativeBucketFactor   float64
+	openMetrics          bool
+	handlerMetrics       *handlerMetrics
+	scrapeAccess         *scrapeAccess // nil unless scrapes are restricted
+	staleAfter           int
+	staleness            *staleness // nil unless WithStaleAfter is set
+	clock                metric.Clock
+	liveSources          []metric.Registry
+	filterOptions        []metric.FilterOption
+	filter               *metric.ExportFilter
 }
 
 // NewReporter creates a new Prometheus reporter
 func NewReporter(opts ...Option) *Reporter {
 	r := &Reporter{
-		registry:      prom.NewRegistry(),
-		counters:      make(map[string]*counterState),
-		gauges:        make(map[string]prom.Gauge),
-		histograms:    make(map[string]prom.Observer),
-		defaultLabels: prom.Labels{},
-		registered:    make(map[string]bool),
+		registry:          prom.NewRegistry(),
+		counterVecs:       make(map[string]*prom.CounterVec),
+		gaugeVecs:         make(map[string]*prom.GaugeVec),
+		counters:          make(map[string]*counterState),
+		gauges:            make(map[string]prom.Gauge),
+		histogramFamilies: make(map[string]*histogramFamily),
+		defaultLabels:     prom.Labels{},
+
+		bucketOverrides:      make(map[string][]float64),
+		compressionThreshold: DefaultCompressionThreshold,
+		clock:                metric.SystemClock,
 	}
 
 	// Apply options
@@ -45,6 +74,22 @@ func NewReporter(opts ...Option) *Reporter {
 		opt(r)
 	}
 
+	if len(r.filterOptions) > 0 {
+		r.filter = metric.NewExportFilter(r.filterOptions...)
+	}
+	r.staleness = newStaleness(r.staleAfter, r.clock)
+
+	// Register live collectors after options so they target the final registry
+	for _, source := range r.liveSources {
+		collector := NewCollector(r.filter.View(source), r.defaultLabels)
+		collector.nativeBucketFactor = r.nativeBucketFactor
+		collector.openMetrics = r.openMetrics
+		collector.staleness = newStaleness(r.staleAfter, r.clock)
+		try(func() {
+			r.registry.MustRegister(collector)
+		})
+	}
+
 	return r
 }
 
@@ -60,6 +105,17 @@ func WithDefaultLabels(labels map[string]string) Option {
 	}
 }
 
+// WithHistogramBuckets overrides the bucket boundaries exported for the named
+// histogram or timer. Timer buckets are in seconds, matching the exported unit.
+// Each exported bucket counts the observations in the metric's own buckets at or
+// below its boundary, so boundaries between the metric's own undercount; choose
+// a subset of them. Live registries always export the metric's own boundaries.
+func WithHistogramBuckets(name string, buckets []float64) Option {
+	return func(r *Reporter) {
+		r.bucketOverrides[name] = append([]float64(nil), buckets...)
+	}
+}
+
 // WithRegistry uses a custom Prometheus registry
 func WithRegistry(registry *prom.Registry) Option {
 	return func(r *Reporter) {
@@ -67,27 +123,44 @@ func WithRegistry(registry *prom.Registry) Option {
 	}
 }
 
-// Handler returns an HTTP handler for the Prometheus metrics
-func (r *Reporter) Handler() http.Handler {
-	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{})
+// WithMetricFilter only exports the metrics keep returns true for, both on
+// Report and from live registries
+func WithMetricFilter(keep func(m metric.Metric) bool) Option {
+	return WithExportFilter(metric.MetricFilter(keep))
+}
+
+// WithExportFilter narrows and scrubs the exported metrics with the filter
+// options, e.g. metric.DenyNames("debug_*") or metric.StripTags("user_id")
+func WithExportFilter(opts ...metric.FilterOption) Option {
+	return func(r *Reporter) {
+		r.filterOptions = append(r.filterOptions, opts...)
+	}
 }
 
 // Report implements the metric.Reporter interface
 func (r *Reporter) Report(registry metric.Registry) error {
+	return r.ReportContext(context.Background(), registry)
+}
+
+// ReportContext implements the metric.ContextReporter interface. Series not
+// yet updated when ctx ends keep their previous values until the next report.
+func (r *Reporter) ReportContext(ctx context.Context, registry metric.Registry) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	registry.Each(func(m metric.Metric) {
+	cutoff := r.staleness.begin()
+	r.filter.View(registry).Each(func(m metric.Metric) {
+		if ctx.Err() != nil {
+			return
+		}
 		name := sanitizeName(m.Name())
-		tags := m.Tags()
 
-		// Create label set with default labels plus metric tags
-		labelNames := make([]string, 0, len(tags))
-		labelValues := make([]string, 0, len(tags))
+		// Sort label names so the same tag set always maps to the same family
+		labelNames, labelValues := sortedLabels(m.Tags())
 
-		for k, v := range tags {
-			labelNames = append(labelNames, k)
-			labelValues = append(labelValues, v)
+		if r.staleness.stale(m, cutoff) {
+			r.forgetSeries(exportedNames(name, m), labelNames, labelValues)
+			return
 		}
 
 		switch m.Type() {
@@ -99,6 +172,11 @@ func (r *Reporter) Report(registry metric.Registry) error {
 			if gauge, ok := m.(metric.Gauge); ok {
 				r.reportGauge(name, labelNames, labelValues, gauge)
 			}
+		case metric.TypeUpDownCounter:
+			// Up-down counters can decrease, so Prometheus models them as gauges
+			if counter, ok := m.(metric.UpDownCounter); ok {
+				r.reportGauge(name, labelNames, labelValues, counter)
+			}
 		case metric.TypeHistogram:
 			if histogram, ok := m.(metric.Histogram); ok {
 				r.reportHistogram(name, labelNames, labelValues, histogram)
@@ -107,183 +185,247 @@ func (r *Reporter) Report(registry metric.Registry) error {
 			if timer, ok := m.(metric.Timer); ok {
 				r.reportTimer(name, labelNames, labelValues, timer)
 			}
+		case metric.TypeMeter:
+			if meter, ok := m.(metric.Meter); ok {
+				r.reportMeter(name, labelNames, labelValues, meter)
+			}
 		}
 	})
 
-	return nil
+	return ctx.Err()
 }
 
 func (r *Reporter) reportCounter(name string, labelNames, labelValues []string, counter metric.Counter) {
-	key := fmt.Sprintf("%s:%v", name, labelNames)
-	if _, exists := r.counters[key]; !exists {
-		// Only register if we haven't seen this counter before
-		if !r.registered[key] {
-			c := prom.NewCounterVec(
-				prom.CounterOpts{
-					Name: name,
-					Help: getMetricHelp(counter),
-				},
-				labelNames,
-			)
-
-			// Use MustRegister and handle potential panics for duplicate registrations
-			try(func() {
-				r.registry.MustRegister(c)
-				r.registered[key] = true
-			})
-
-			// Only set the counter if registration was successful
-			if r.registered[key] {
-				r.counters[key] = &counterState{
-					promCounter: c.WithLabelValues(labelValues...),
-					lastValue:   0,
-				}
-			}
+	family := familyKey(name, labelNames)
+	vec, exists := r.counterVecs[family]
+	if !exists {
+		c := prom.NewCounterVec(
+			prom.CounterOpts{
+				Name:        name,
+				Help:        getMetricHelp(counter),
+				ConstLabels: r.constLabels(labelNames),
+			},
+			labelNames,
+		)
+
+		// Use MustRegister and handle potential panics for duplicate registrations
+		if !r.register(c) {
+			return
 		}
+		r.counterVecs[family] = c
+		vec = c
 	}
 
-	// Update the counter value using delta calculation
-	if state, exists := r.counters[key]; exists {
-		// Get current value from our metric
-		currentValue := counter.Value()
-		// Calculate delta since last report
-		if currentValue >= state.lastValue {
-			delta := currentValue - state.lastValue
-			if delta > 0 {
-				state.promCounter.Add(float64(delta))
-				state.lastValue = currentValue
-			}
-		} else {
-			// Counter was reset, add the full current value
-			state.promCounter.Add(float64(currentValue))
-			state.lastValue = currentValue
+	key := seriesKey(family, labelValues)
+	state, exists := r.counters[key]
+	if !exists {
+		state = &counterState{
+			promCounter: vec.WithLabelValues(labelValues...),
+			lastValue:   0,
 		}
+		r.counters[key] = state
+	}
+
+	// Update the counter value using delta calculation. A new generation means
+	// the counter was reset or the series re-created since the last report, so
+	// all of its current value is new. Within a generation the value only grows;
+	// a lower value was read mid-reset and is skipped until the new generation shows.
+	current := counter.Snapshot()
+	switch {
+	case current.Generation != state.generation,
+		current.Generation == 0 && current.Value < state.lastValue: // untracked generations: guess
+		state.promCounter.Add(float64(current.Value))
+		state.lastValue = current.Value
+		state.generation = current.Generation
+	case current.Value > state.lastValue:
+		state.promCounter.Add(float64(current.Value - state.lastValue))
+		state.lastValue = current.Value
 	}
 }
 
-func (r *Reporter) reportGauge(name string, labelNames, labelValues []string, gauge metric.Gauge) {
-	key := fmt.Sprintf("%s:%v", name, labelNames)
-	if _, exists := r.gauges[key]; !exists {
-		// Only register if we haven't seen this gauge before
-		if !r.registered[key] {
-			g := prom.NewGaugeVec(
-				prom.GaugeOpts{
-					Name: name,
-					Help: getMetricHelp(gauge),
-				},
-				labelNames,
-			)
-
-			// Use MustRegister and handle potential panics for duplicate registrations
-			try(func() {
-				r.registry.MustRegister(g)
-				r.registered[key] = true
-			})
-
-			// Only set the gauge if registration was successful
-			if r.registered[key] {
-				r.gauges[key] = g.WithLabelValues(labelValues...)
-			}
-		}
+// gaugeValue is a metric reported as a Prometheus gauge: a Gauge or an UpDownCounter
+type gaugeValue interface {
+	metric.Metric
+	Value() int64
+}
+
+func (r *Reporter) reportGauge(name string, labelNames, labelValues []string, gauge gaugeValue) {
+	// Gauges marked for conversion, such as duration gauges, are exported in their base unit
+	unit := metric.ExportUnit(gauge)
+	value := float64(gauge.Value())
+	if g, ok := gauge.(metric.Gauge); ok {
+		value = metric.GaugeValue(g)
 	}
+	r.setGauge(unit.Name(name), labelNames, labelValues, gauge, unit.Convert(value))
+}
 
-	// Update the gauge value using the safe Value() method
-	if promGauge, exists := r.gauges[key]; exists {
-		// Get current value from our metric and set it
-		currentValue := float64(gauge.Value())
-		promGauge.Set(currentValue)
+// reportMeter exports each of a meter's moving averages as a gauge
+func (r *Reporter) reportMeter(name string, labelNames, labelValues []string, meter metric.Meter) {
+	rates := meterRates(meter.Snapshot())
+	for i, suffix := range meterRateSuffixes {
+		r.setGauge(name+suffix, labelNames, labelValues, meter, rates[i])
 	}
 }
 
-func (r *Reporter) reportHistogram(name string, labelNames, labelValues []string, histogram metric.Histogram) {
-	key := fmt.Sprintf("%s:%v", name, labelNames)
-	if _, exists := r.histograms[key]; !exists {
-		// Only register if we haven't seen this histogram before
-		if !r.registered[key] {
-			h := prom.NewHistogramVec(
-				prom.HistogramOpts{
-					Name:    name,
-					Help:    getMetricHelp(histogram),
-					Buckets: prom.DefBuckets, // Default buckets
-				},
-				labelNames,
-			)
-
-			// Use MustRegister and handle potential panics for duplicate registrations
-			try(func() {
-				r.registry.MustRegister(h)
-				r.registered[key] = true
-			})
-
-			// Only set the histogram if registration was successful
-			if r.registered[key] {
-				r.histograms[key] = h.WithLabelValues(labelValues...)
-			}
+// setGauge sets the gauge series of m to value, registering its family on first use
+func (r *Reporter) setGauge(name string, labelNames, labelValues []string, m metric.Metric, value float64) {
+	family := familyKey(name, labelNames)
+	vec, exists := r.gaugeVecs[family]
+	if !exists {
+		g := prom.NewGaugeVec(
+			prom.GaugeOpts{
+				Name:        name,
+				Help:        getMetricHelp(m),
+				ConstLabels: r.constLabels(labelNames),
+			},
+			labelNames,
+		)
+
+		if !r.register(g) {
+			return
 		}
+		r.gaugeVecs[family] = g
+		vec = g
 	}
 
-	// Update the histogram with observations from our metric
-	if promHistogram, exists := r.histograms[key]; exists {
-		// Get snapshot from our histogram using the safe Snapshot() method
-		snapshot := histogram.Snapshot()
-		
-		// Record observations - this is a simplified approach
-		// In a full implementation, we'd need to track individual observations
-		if snapshot.Count > 0 {
-			// Record the average value as a representative sample
-			avgValue := float64(snapshot.Sum) / float64(snapshot.Count)
-			promHistogram.Observe(avgValue)
-		}
+	key := seriesKey(family, labelValues)
+	promGauge, exists := r.gauges[key]
+	if !exists {
+		promGauge = vec.WithLabelValues(labelValues...)
+		r.gauges[key] = promGauge
 	}
+
+	promGauge.Set(value)
+}
+
+// meterRateSuffixes name the gauges a meter is exported as, in the order of meterRates
+var meterRateSuffixes = [...]string{"_rate1m", "_rate5m", "_rate15m"}
+
+// meterRates returns the 1, 5 and 15 minute rates of a meter snapshot
+func meterRates(s metric.MeterSnapshot) [3]float64 {
+	return [3]float64{s.Rate1, s.Rate5, s.Rate15}
+}
+
+func (r *Reporter) reportHistogram(name string, labelNames, labelValues []string, histogram metric.Histogram) {
+	r.setHistogram(name, labelNames, labelValues, histogram, histogram.Snapshot())
 }
 
 func (r *Reporter) reportTimer(name string, labelNames, labelValues []string, timer metric.Timer) {
-	// Timers are histograms in Prometheus
-	// We use Observer interface which is implemented by both Histogram and Summary
-	// Instead of using a type assertion, use the timer's properties to create a histogram
-	timerName := fmt.Sprintf("%s_seconds", name)
-	key := fmt.Sprintf("%s:%v", timerName, labelNames)
-
-	if _, exists := r.histograms[key]; !exists {
-		// Only register if we haven't seen this timer before
-		if !r.registered[key] {
-			h := prom.NewHistogramVec(
-				prom.HistogramOpts{
-					Name:    timerName,
-					Help:    getMetricHelp(timer),
-					Buckets: prom.DefBuckets, // Default buckets
-				},
-				labelNames,
-			)
-
-			// Use MustRegister and handle potential panics for duplicate registrations
-			try(func() {
-				r.registry.MustRegister(h)
-				r.registered[key] = true
-			})
-
-			// Only set the histogram if registration was successful
-			if r.registered[key] {
-				r.histograms[key] = h.WithLabelValues(labelValues...)
-			}
+	// Timers are histograms in Prometheus, named with the seconds unit suffix.
+	// Timer boundaries are recorded in nanoseconds and exported in seconds.
+	r.setHistogram(name, labelNames, labelValues, timer, timer.Snapshot())
+}
+
+// setHistogram stores the snapshot of a histogram or timer series, registering
+// its family on first use. Snapshots hold cumulative bucket counts, so the
+// family exports the latest one as is rather than observing it again.
+func (r *Reporter) setHistogram(name string, labelNames, labelValues []string, m metric.Metric, snapshot metric.HistogramSnapshot) {
+	// Values in a known unit are exported in its base unit, named with its suffix
+	unit := metric.ExportUnit(m)
+	family := familyKey(unit.Name(name), labelNames)
+	f, exists := r.histogramFamilies[family]
+	if !exists {
+		f = &histogramFamily{
+			desc:               prom.NewDesc(unit.Name(name), getMetricHelp(m), labelNames, r.constLabels(labelNames)),
+			divisor:            unit.Divisor,
+			bounds:             r.bucketOverrides[name],
+			nativeBucketFactor: r.nativeBucketFactor,
+			openMetrics:        r.openMetrics,
+			series:             make(map[string]histogramSeries),
+		}
+		if !r.register(f) {
+			return
 		}
+		r.histogramFamilies[family] = f
 	}
+	f.set(seriesKey(family, labelValues), histogramSeries{m: m, snapshot: snapshot, labelValues: labelValues})
+}
 
-	// Update the timer histogram with observations from our timer
-	if promHistogram, exists := r.histograms[key]; exists {
-		// Get snapshot from our timer using the safe Snapshot() method
-		snapshot := timer.Snapshot()
-		
-		// Record observations - convert from nanoseconds to seconds for Prometheus
-		if snapshot.Count > 0 {
-			// Record the average duration in seconds
-			avgDurationNanos := float64(snapshot.Sum) / float64(snapshot.Count)
-			avgDurationSeconds := avgDurationNanos / 1e9 // Convert nanoseconds to seconds
-			promHistogram.Observe(avgDurationSeconds)
-		}
+// histogramFamily is a prom.Collector exporting the last reported snapshot of
+// each series of a histogram or timer family as a const histogram, with the
+// same conversion as Collector
+type histogramFamily struct {
+	desc    *prom.Desc
+	divisor float64
+	// bounds regroup the exported buckets; nil exports the metric's own. See WithHistogramBuckets
+	bounds             []float64
+	nativeBucketFactor float64
+	openMetrics        bool
+
+	mu     sync.Mutex
+	series map[string]histogramSeries
+}
+
+// histogramSeries is the last reported snapshot of one series
+type histogramSeries struct {
+	m           metric.Metric
+	snapshot    metric.HistogramSnapshot
+	labelValues []string
+}
+
+func (f *histogramFamily) set(key string, series histogramSeries) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.series[key] = series
+}
+
+// remove drops a series from the family until it is reported again
+func (f *histogramFamily) remove(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.series, key)
+}
+
+// Describe implements prom.Collector
+func (f *histogramFamily) Describe(ch chan<- *prom.Desc) {
+	ch <- f.desc
+}
+
+// Collect implements prom.Collector
+func (f *histogramFamily) Collect(ch chan<- prom.Metric) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.series {
+		ch <- histogramMetric(f.desc, s.m, s.snapshot, f.divisor, f.bounds, f.nativeBucketFactor, f.openMetrics, s.labelValues)
 	}
 }
 
+// register adds a collector to the Prometheus registry, reporting whether it succeeded
+func (r *Reporter) register(c prom.Collector) bool {
+	registered := false
+	try(func() {
+		r.registry.MustRegister(c)
+		registered = true
+	})
+	return registered
+}
+
+// constLabels returns the default labels that don't collide with the metric's own tags
+func (r *Reporter) constLabels(labelNames []string) prom.Labels {
+	if len(r.defaultLabels) == 0 {
+		return nil
+	}
+
+	labels := make(prom.Labels, len(r.defaultLabels))
+	for k, v := range r.defaultLabels {
+		labels[k] = v
+	}
+	for _, name := range labelNames {
+		delete(labels, name)
+	}
+	return labels
+}
+
+// familyKey identifies a metric family by name and (sorted) label names
+func familyKey(name string, labelNames []string) string {
+	return name + "{" + strings.Join(labelNames, ",") + "}"
+}
+
+// seriesKey identifies a single series within a family by its label values
+func seriesKey(family string, labelValues []string) string {
+	return family + "=" + strings.Join(labelValues, "\xff")
+}
+
 // Flush implements the metric.Reporter interface
 func (r *Reporter) Flush() error {
 	// No-op for Prometheus as it's a pull-based system
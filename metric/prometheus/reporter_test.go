@@ -1,10 +1,22 @@
 package prometheus
 
 import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/MichaelAJay/go-metrics/metric"
 	prom "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 )
 
 func TestNewReporter(t *testing.T) {
@@ -33,6 +45,28 @@ func TestReporterImplementsInterface(t *testing.T) {
 
 	// Assert that our reporter implements the Reporter interface
 	var _ metric.Reporter = reporter
+	var _ metric.ContextReporter = reporter
+}
+
+func TestReportContextCancelled(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	registry.Counter(metric.Options{Name: "jobs_total"}).Add(3)
+
+	reporter := NewReporter()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := reporter.ReportContext(ctx, registry); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancellation error, got %v", err)
+	}
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	reporter.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "jobs_total 3") {
+		t.Errorf("Expected the next report to catch up\n%s", body)
+	}
 }
 
 func TestReportWithMetrics(t *testing.T) {
@@ -93,3 +127,411 @@ func TestReportWithMetrics(t *testing.T) {
 		t.Errorf("Close() returned error: %v", err)
 	}
 }
+
+func TestHandlerNegotiationAndCompression(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	for i := 0; i < 50; i++ {
+		registry.Counter(metric.Options{
+			Name:        fmt.Sprintf("handler_counter_%d", i),
+			Description: "Counter used to grow the scrape response",
+		}).Inc()
+	}
+
+	reporter := NewReporter(WithCompressionThreshold(512))
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+	handler := reporter.Handler()
+
+	t.Run("text without compression", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+			t.Errorf("Expected text/plain content type, got %q", ct)
+		}
+		if enc := rec.Header().Get("Content-Encoding"); enc != "" {
+			t.Errorf("Expected no content encoding, got %q", enc)
+		}
+		if !strings.Contains(rec.Body.String(), "handler_counter_0 1") {
+			t.Error("Expected counter sample in text output")
+		}
+	})
+
+	t.Run("openmetrics with gzip", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+			t.Errorf("Expected OpenMetrics content type, got %q", ct)
+		}
+		if enc := rec.Header().Get("Content-Encoding"); enc != "gzip" {
+			t.Fatalf("Expected gzip content encoding, got %q", enc)
+		}
+
+		gz, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("Failed to open gzip body: %v", err)
+		}
+		body, err := io.ReadAll(gz)
+		if err != nil {
+			t.Fatalf("Failed to read gzip body: %v", err)
+		}
+		if !strings.HasSuffix(string(body), "# EOF\n") {
+			t.Error("Expected OpenMetrics output to end with # EOF")
+		}
+	})
+
+	t.Run("self metrics recorded", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		if !strings.Contains(rec.Body.String(), "metrics_scrape_response_size_bytes_count") {
+			t.Error("Expected scrape size self-metric in output")
+		}
+	})
+}
+
+func TestHandlerJSONAndSizeLimit(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	registry.Counter(metric.Options{Name: "json_requests", Tags: metric.Tags{"route": "/"}}).Add(3)
+	registry.Histogram(metric.Options{Name: "json_latency", Buckets: []float64{1, 10}}).Observe(5)
+
+	reporter := NewReporter()
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+	handler := reporter.Handler()
+
+	byHeader := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	byHeader.Header.Set("Accept", "application/json")
+	byQuery := httptest.NewRequest(http.MethodGet, "/metrics?format=json", nil)
+
+	for name, req := range map[string]*http.Request{"accept header": byHeader, "query": byQuery} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Fatalf("%s: expected JSON content type, got %q", name, ct)
+		}
+		var families []struct {
+			Name    string
+			Type    string
+			Metrics []struct {
+				Labels  map[string]string
+				Value   *float64
+				Count   *uint64
+				Buckets []struct {
+					LE    string
+					Count uint64
+				}
+			}
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &families); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", name, err)
+		}
+		found := 0
+		for _, f := range families {
+			switch f.Name {
+			case "json_requests":
+				if f.Type != "counter" || *f.Metrics[0].Value != 3 || f.Metrics[0].Labels["route"] != "/" {
+					t.Errorf("%s: unexpected counter %+v", name, f)
+				}
+				found++
+			case "json_latency":
+				buckets := f.Metrics[0].Buckets
+				if f.Type != "histogram" || *f.Metrics[0].Count != 1 || len(buckets) != 3 || buckets[2].LE != "+Inf" {
+					t.Errorf("%s: unexpected histogram %+v", name, f)
+				}
+				found++
+			}
+		}
+		if found != 2 {
+			t.Errorf("%s: expected both families, got %s", name, rec.Body.String())
+		}
+	}
+
+	// Scrapers asking for an exposition format never get JSON
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0,text/plain;q=0.5,*/*;q=0.1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("Expected OpenMetrics for a scraper, got %q", ct)
+	}
+
+	limited := NewReporter(WithMaxResponseSize(64))
+	if err := limited.Report(registry); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	limited.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "exceeds 64 bytes") {
+		t.Errorf("Expected the oversized response to be refused, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandlerDelimitedProtobuf(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	registry.Counter(metric.Options{Name: "proto_requests", Tags: metric.Tags{"route": "/"}}).Add(3)
+	registry.Histogram(metric.Options{Name: "proto_latency", Buckets: []float64{1, 10}}).Observe(5)
+
+	reporter := NewReporter()
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,text/plain;q=0.3")
+	rec := httptest.NewRecorder()
+	reporter.Handler().ServeHTTP(rec, req)
+
+	format := expfmt.ResponseFormat(rec.Header())
+	if format.FormatType() != expfmt.TypeProtoDelim {
+		t.Fatalf("Expected delimited protobuf, got content type %q", rec.Header().Get("Content-Type"))
+	}
+	decoder := expfmt.NewDecoder(rec.Body, format)
+	families := make(map[string]*dto.MetricFamily)
+	for {
+		family := &dto.MetricFamily{}
+		if err := decoder.Decode(family); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Failed to decode protobuf response: %v", err)
+		}
+		families[family.GetName()] = family
+	}
+
+	counter := families["proto_requests"]
+	if counter == nil || counter.GetMetric()[0].GetCounter().GetValue() != 3 {
+		t.Errorf("Expected proto_requests 3, got %v", counter)
+	}
+	histogram := families["proto_latency"]
+	if histogram == nil {
+		t.Fatal("Expected proto_latency in the protobuf response")
+	}
+	h := histogram.GetMetric()[0].GetHistogram()
+	if h.GetSampleCount() != 1 || len(h.GetBucket()) != 2 || h.GetBucket()[1].GetCumulativeCount() != 1 {
+		t.Errorf("Unexpected proto_latency histogram %v", h)
+	}
+}
+
+// failingCollector collects one valid counter and one collection error
+type failingCollector struct {
+	desc *prom.Desc
+}
+
+func (c failingCollector) Describe(ch chan<- *prom.Desc) { ch <- c.desc }
+
+func (c failingCollector) Collect(ch chan<- prom.Metric) {
+	ch <- prom.MustNewConstMetric(c.desc, prom.CounterValue, 1)
+	ch <- prom.NewInvalidMetric(c.desc, errors.New("backend unavailable"))
+}
+
+func TestHandlerCountsPartialGather(t *testing.T) {
+	promRegistry := prom.NewRegistry()
+	promRegistry.MustRegister(failingCollector{desc: prom.NewDesc("flaky_total", "Partly failing counter", nil, nil)})
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	registry.Counter(metric.Options{Name: "healthy_total"}).Inc()
+
+	reporter := NewReporter(WithRegistry(promRegistry))
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+	handler := reporter.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "healthy_total 1") {
+		t.Fatalf("Expected the gathered families to be served, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The error is counted for the failed scrape and visible from the next one
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), `metrics_scrape_errors_total{reason="partial_gather"} 1`) {
+		t.Errorf("Expected the partial gather to be counted\n%s", rec.Body.String())
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := map[string]bool{
+		"":                  false,
+		"gzip":              true,
+		"deflate, gzip;q=1": true,
+		"gzip;q=0":          false,
+		"*":                 true,
+		"br":                false,
+	}
+	for header, expected := range tests {
+		if actual := acceptsGzip(header); actual != expected {
+			t.Errorf("acceptsGzip(%q) = %v, expected %v", header, actual, expected)
+		}
+	}
+}
+
+// seriesRegistry is a minimal registry that yields a fixed set of metrics,
+// allowing several series with the same name but different tag values
+type seriesRegistry struct {
+	metric.Registry
+	metrics []metric.Metric
+}
+
+func (s *seriesRegistry) Each(fn func(metric.Metric)) {
+	for _, m := range s.metrics {
+		fn(m)
+	}
+}
+
+func TestReportCounterSeriesKeyedByLabelValues(t *testing.T) {
+	base := metric.NewNoCleanupRegistry()
+	defer base.Close()
+
+	parent := base.Counter(metric.Options{Name: "series_requests_total"})
+	get := parent.With(metric.Tags{"method": "GET", "status": "200"})
+	post := parent.With(metric.Tags{"status": "500", "method": "POST"})
+	get.Add(5)
+	post.Add(2)
+
+	source := &seriesRegistry{Registry: metric.NewNoop(), metrics: []metric.Metric{get, post}}
+	reporter := NewReporter(WithDefaultLabels(map[string]string{"service": "api"}))
+
+	// Report twice with growth in between; each series must track its own delta
+	if err := reporter.Report(source); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+	get.Add(1)
+	if err := reporter.Report(source); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	reporter.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, line := range []string{
+		`series_requests_total{method="GET",service="api",status="200"} 6`,
+		`series_requests_total{method="POST",service="api",status="500"} 2`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected %q in output\n%s", line, body)
+		}
+	}
+}
+
+func TestReportCounterResetGenerations(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	reporter := NewReporter()
+
+	scrape := func() string {
+		t.Helper()
+		if err := reporter.Report(registry); err != nil {
+			t.Fatalf("Report() returned error: %v", err)
+		}
+		rec := httptest.NewRecorder()
+		reporter.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return rec.Body.String()
+	}
+
+	jobs := registry.Counter(metric.Options{Name: "jobs_total"})
+	jobs.Add(5)
+	scrape()
+
+	// Re-created and grown past its old value between reports: the old
+	// heuristic saw an increase of 2 instead of 7 new jobs
+	registry.Unregister("jobs_total")
+	jobs = registry.Counter(metric.Options{Name: "jobs_total"})
+	jobs.Add(7)
+	if body := scrape(); !strings.Contains(body, "jobs_total 12") {
+		t.Errorf("Expected the re-created counter's jobs to be added in full\n%s", body)
+	}
+
+	// An explicit reset counts what follows it as new
+	jobs.Add(3)
+	jobs.Reset()
+	jobs.Add(4)
+	if body := scrape(); !strings.Contains(body, "jobs_total 16") {
+		t.Errorf("Expected the jobs after the reset to be added\n%s", body)
+	}
+
+	// Without changes nothing is added
+	if body := scrape(); !strings.Contains(body, "jobs_total 16") {
+		t.Errorf("Expected an unchanged counter to stay at 16\n%s", body)
+	}
+}
+
+func TestReportUsesHistogramBoundaries(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	registry.Histogram(metric.Options{Name: "payload_bytes", Buckets: []float64{64, 512, 4096}}).Observe(100)
+	registry.Timer(metric.Options{Name: "db_query"}).Record(20 * time.Millisecond)
+
+	reporter := NewReporter(WithHistogramBuckets("db_query", []float64{0.01, 0.05}))
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report() returned error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	reporter.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, line := range []string{
+		`payload_bytes_bucket{le="64"} 0`,
+		`payload_bytes_bucket{le="512"} 1`,
+		`payload_bytes_bucket{le="4096"} 1`,
+		`db_query_seconds_bucket{le="0.01"} 0`,
+		`db_query_seconds_bucket{le="0.05"} 1`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected %q in output\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, `payload_bytes_bucket{le="0.005"}`) {
+		t.Error("Histogram should not use the Prometheus default buckets")
+	}
+}
+
+func TestReportExportsBucketCounts(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	histogram := registry.Histogram(metric.Options{Name: "payload_bytes", Buckets: []float64{1, 10, 100}})
+	for _, v := range []float64{0.5, 0.5, 0.5, 500} {
+		histogram.Observe(v)
+	}
+
+	reporter := NewReporter()
+	// Reporting twice must not count the same observations again
+	for i := 0; i < 2; i++ {
+		if err := reporter.Report(registry); err != nil {
+			t.Fatalf("Report() returned error: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	reporter.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, line := range []string{
+		`payload_bytes_bucket{le="1"} 3`,
+		`payload_bytes_bucket{le="10"} 3`,
+		`payload_bytes_bucket{le="100"} 3`,
+		`payload_bytes_bucket{le="+Inf"} 4`,
+		`payload_bytes_sum 501.5`,
+		`payload_bytes_count 4`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected %q in output\n%s", line, body)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package prometheus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// WithStaleAfter leaves out series that were not written during the last
+// intervals reports, or scrapes for live registries, so Prometheus marks them
+// stale instead of graphing a frozen value as healthy. A series written again
+// is exported again. Series never written, such as gauge functions and
+// counters still at zero, are always exported; so are all series until
+// intervals reports have passed. Values that legitimately stay put, such as a
+// build info gauge set once at startup, go stale too, so leave them out of the
+// registries this reporter exports or export them with another reporter.
+// Zero or less disables staleness, the default.
+func WithStaleAfter(intervals int) Option {
+	return func(r *Reporter) {
+		if intervals > 0 {
+			r.staleAfter = intervals
+		}
+	}
+}
+
+// WithClock makes the reporter time reports and scrapes for WithStaleAfter with
+// clock. Series are stale by the write times their registries stamp, so give
+// those registries the same clock with metric.WithClock.
+func WithClock(clock metric.Clock) Option {
+	return func(r *Reporter) {
+		r.clock = clock
+	}
+}
+
+// staleness tracks the start of recent reports or scrapes to tell which series
+// were not written during the last intervals of them
+type staleness struct {
+	intervals int
+	clock     metric.Clock
+	mu        sync.Mutex
+	starts    []time.Time // start of the last intervals reports, oldest first
+}
+
+// newStaleness returns a tracker for intervals reports timed with clock, or nil
+// if intervals disables staleness
+func newStaleness(intervals int, clock metric.Clock) *staleness {
+	if intervals <= 0 {
+		return nil
+	}
+	return &staleness{intervals: intervals, clock: clock}
+}
+
+// begin records the start of a report, now, and returns the cutoff before which a
+// series' last write makes it stale: the start of the report intervals
+// reports ago. The cutoff is zero until that many reports have passed.
+func (s *staleness) begin() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	now := s.clock.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var cutoff time.Time
+	if len(s.starts) == s.intervals {
+		cutoff = s.starts[0]
+		s.starts = s.starts[1:]
+	}
+	s.starts = append(s.starts, now)
+	return cutoff
+}
+
+// stale reports whether m was last written before cutoff. Every report reads
+// the last write, even before there is a cutoff, since reading re-arms its
+// stamp: a later write is stamped at or after this report's start and keeps
+// the series fresh for the next intervals reports.
+func (s *staleness) stale(m metric.Metric, cutoff time.Time) bool {
+	if s == nil {
+		return false
+	}
+	updated := metric.LastUpdated(m)
+	return !cutoff.IsZero() && !updated.IsZero() && updated.Before(cutoff)
+}
+
+// forgetSeries removes the series with labelValues from the families named
+// names, so they leave the exposition until they are reported again
+func (r *Reporter) forgetSeries(names []string, labelNames, labelValues []string) {
+	for _, name := range names {
+		family := familyKey(name, labelNames)
+		key := seriesKey(family, labelValues)
+		if vec, ok := r.counterVecs[family]; ok {
+			if _, exported := r.counters[key]; exported {
+				vec.DeleteLabelValues(labelValues...)
+				delete(r.counters, key)
+			}
+		}
+		if vec, ok := r.gaugeVecs[family]; ok {
+			if _, exported := r.gauges[key]; exported {
+				vec.DeleteLabelValues(labelValues...)
+				delete(r.gauges, key)
+			}
+		}
+		if f, ok := r.histogramFamilies[family]; ok {
+			f.remove(key)
+		}
+	}
+}
+
+// exportedNames returns the names of the families m is exported as under name
+func exportedNames(name string, m metric.Metric) []string {
+	if m.Type() == metric.TypeMeter {
+		names := make([]string, 0, len(meterRateSuffixes))
+		for _, suffix := range meterRateSuffixes {
+			names = append(names, name+suffix)
+		}
+		return names
+	}
+	return []string{metric.ExportUnit(m).Name(name)}
+}
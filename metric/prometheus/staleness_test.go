@@ -0,0 +1,69 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/testutil"
+)
+
+func TestStaleAfter(t *testing.T) {
+	clock := testutil.NewFakeClock(time.Unix(1700000000, 0))
+	registry := metric.NewNoCleanupRegistry(metric.WithClock(clock)).(metric.InstrumentRegistry)
+	defer registry.Close()
+	requests := registry.Counter(metric.Options{Name: "requests_total"})
+	frozen := registry.Gauge(metric.Options{Name: "queue_depth", Tags: metric.Tags{"queue": "emails"}})
+	lag := metric.NewDurationGauge(registry, metric.Options{Name: "consumer_lag"})
+	registry.GaugeFunc(metric.Options{Name: "goroutines"}, func() float64 { return 4 })
+	frozen.Set(3)
+	lag.Set(time.Second)
+
+	reported := NewReporter(WithStaleAfter(2), WithClock(clock))
+	live := NewReporter(WithStaleAfter(2), WithClock(clock), WithLiveRegistry(registry))
+	scrape := func(reporter *Reporter) string {
+		rec := httptest.NewRecorder()
+		reporter.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return rec.Body.String()
+	}
+	// cycle moves the clock past the last write, writes the counter, then
+	// reports and scrapes both reporters
+	cycle := func() (string, string) {
+		clock.Advance(time.Second)
+		requests.Inc()
+		if err := reported.Report(registry); err != nil {
+			t.Fatalf("Report failed: %v", err)
+		}
+		return scrape(reported), scrape(live)
+	}
+
+	for i := 0; i < 2; i++ {
+		reportedBody, liveBody := cycle()
+		for _, body := range []string{reportedBody, liveBody} {
+			if !strings.Contains(body, `queue_depth{queue="emails"} 3`) || !strings.Contains(body, "consumer_lag_seconds 1") {
+				t.Fatalf("Expected every series before 2 intervals passed\n%s", body)
+			}
+		}
+	}
+
+	reportedBody, liveBody := cycle()
+	for _, body := range []string{reportedBody, liveBody} {
+		if strings.Contains(body, "queue_depth{") || strings.Contains(body, "consumer_lag_seconds ") {
+			t.Errorf("Expected the frozen gauges to be left out\n%s", body)
+		}
+		if !strings.Contains(body, "requests_total 3") || !strings.Contains(body, "goroutines 4") {
+			t.Errorf("Expected written and never-written series to stay\n%s", body)
+		}
+	}
+
+	frozen.Set(5)
+	reportedBody, liveBody = cycle()
+	for _, body := range []string{reportedBody, liveBody} {
+		if !strings.Contains(body, `queue_depth{queue="emails"} 5`) {
+			t.Errorf("Expected a written series to be exported again\n%s", body)
+		}
+	}
+}
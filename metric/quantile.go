@@ -0,0 +1,72 @@
+package metric
+
+import "math"
+
+// BucketRange is one histogram bucket with the range of values it counts
+type BucketRange struct {
+	// Lower is the exclusive lower bound; -Inf for the first bucket
+	Lower float64
+	// Upper is the inclusive upper bound; +Inf for the last bucket
+	Upper float64
+	Count uint64
+}
+
+// BucketRanges returns the snapshot's buckets with their bounds
+func (s HistogramSnapshot) BucketRanges() []BucketRange {
+	ranges := make([]BucketRange, len(s.Buckets))
+	for i, count := range s.Buckets {
+		ranges[i] = BucketRange{Lower: math.Inf(-1), Upper: math.Inf(1), Count: count}
+		if i > 0 && i-1 < len(s.Boundaries) {
+			ranges[i].Lower = s.Boundaries[i-1]
+		}
+		if i < len(s.Boundaries) {
+			ranges[i].Upper = s.Boundaries[i]
+		}
+	}
+	return ranges
+}
+
+// Mean returns the average observed value, or NaN for an empty snapshot
+func (s HistogramSnapshot) Mean() float64 {
+	if s.Count == 0 {
+		return math.NaN()
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// Quantile estimates the q-quantile (0 <= q <= 1) of the observations, e.g. 0.95
+// for p95, by interpolating linearly within the bucket holding the target rank,
+// bounded by Min and Max. It returns NaN for an empty snapshot and Max when the
+// buckets do not match the boundaries.
+func (s HistogramSnapshot) Quantile(q float64) float64 {
+	if s.Count == 0 {
+		return math.NaN()
+	}
+	q = math.Max(0, math.Min(1, q))
+	if len(s.Buckets) != len(s.Boundaries)+1 {
+		return s.Max
+	}
+
+	rank := q * float64(s.Count)
+	var cumulative uint64
+	for i, count := range s.Buckets {
+		if count == 0 {
+			continue
+		}
+		previous := cumulative
+		cumulative += count
+		if float64(cumulative) < rank {
+			continue
+		}
+
+		lower, upper := s.Min, s.Max
+		if i > 0 {
+			lower = math.Max(lower, s.Boundaries[i-1])
+		}
+		if i < len(s.Boundaries) {
+			upper = math.Min(upper, s.Boundaries[i])
+		}
+		return lower + (upper-lower)*(rank-float64(previous))/float64(count)
+	}
+	return s.Max
+}
@@ -0,0 +1,55 @@
+package metric
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHistogramSnapshotQuantile(t *testing.T) {
+	h := newHistogram(Options{Name: "latency", Buckets: []float64{10, 20, 50}})
+	for i := 1; i <= 100; i++ {
+		h.Observe(float64(i % 40))
+	}
+	s := h.Snapshot()
+
+	if got := s.Mean(); math.Abs(got-s.Sum/100) > 1e-12 {
+		t.Errorf("Expected the mean %v, got %v", s.Sum/100, got)
+	}
+	if p0, p100 := s.Quantile(0), s.Quantile(1); p0 != s.Min || p100 != s.Max {
+		t.Errorf("Expected quantiles 0 and 1 to be Min %v and Max %v, got %v and %v", s.Min, s.Max, p0, p100)
+	}
+	// 32 of 100 observations are at most 10, 62 at most 20: p50 lies in (10, 20]
+	if p50 := s.Quantile(0.5); p50 <= 10 || p50 > 20 {
+		t.Errorf("Expected p50 in (10, 20], got %v", p50)
+	}
+	if p95 := s.Quantile(0.95); p95 <= 20 || p95 > s.Max {
+		t.Errorf("Expected p95 in (20, %v], got %v", s.Max, p95)
+	}
+
+	empty := HistogramSnapshot{}
+	if !math.IsNaN(empty.Quantile(0.5)) || !math.IsNaN(empty.Mean()) {
+		t.Error("Expected NaN for an empty snapshot")
+	}
+}
+
+func TestHistogramSnapshotBucketRanges(t *testing.T) {
+	h := newHistogram(Options{Name: "size", Buckets: []float64{1, 10}})
+	h.Observe(0.5)
+	h.Observe(5)
+	h.Observe(100)
+
+	ranges := h.Snapshot().BucketRanges()
+	expected := []BucketRange{
+		{Lower: math.Inf(-1), Upper: 1, Count: 1},
+		{Lower: 1, Upper: 10, Count: 1},
+		{Lower: 10, Upper: math.Inf(1), Count: 1},
+	}
+	if len(ranges) != len(expected) {
+		t.Fatalf("Expected %d ranges, got %+v", len(expected), ranges)
+	}
+	for i := range expected {
+		if ranges[i] != expected[i] {
+			t.Errorf("Range %d: expected %+v, got %+v", i, expected[i], ranges[i])
+		}
+	}
+}
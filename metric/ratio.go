@@ -0,0 +1,93 @@
+package metric
+
+import "math"
+
+// Suffixes appended to Options.Name for the series of a Ratio
+const (
+	// RatioGoodSuffix names the counter of good events
+	RatioGoodSuffix = "_good_total"
+	// RatioTotalSuffix names the counter of all events
+	RatioTotalSuffix = "_total"
+	// RatioSuffix names the gauge of good events over all events, in parts per
+	// million since gauges hold integers; 1000000 without events
+	RatioSuffix = "_ratio"
+)
+
+// ratioScale scales the ratio gauge to parts per million
+const ratioScale = 1e6
+
+// Ratio records a good/total pair of counters for an SLI, such as successful
+// over all requests, with a gauge of their ratio computed when it is read. All
+// three series share the name prefix and tags, so the pair always lines up.
+type Ratio struct {
+	registry InstrumentRegistry
+	opts     Options
+	good     Counter
+	total    Counter
+	ratio    Gauge
+}
+
+// NewRatio creates or retrieves the ratio named opts.Name in registry: the
+// counters <name>_good_total and <name>_total and the gauge <name>_ratio
+func NewRatio(registry InstrumentRegistry, opts Options) *Ratio {
+	r := &Ratio{
+		registry: registry,
+		opts:     opts,
+		good:     registry.Counter(ratioOptions(opts, RatioGoodSuffix, "")),
+		total:    registry.Counter(ratioOptions(opts, RatioTotalSuffix, "")),
+	}
+	r.ratio = registry.GaugeFunc(ratioOptions(opts, RatioSuffix, "ppm"), func() float64 {
+		return math.Round(r.Value() * ratioScale)
+	})
+	return r
+}
+
+// ratioOptions returns the options of one series of a ratio
+func ratioOptions(opts Options, suffix, unit string) Options {
+	opts.Name += suffix
+	if unit != "" {
+		opts.Unit = unit
+	}
+	return opts
+}
+
+// Good records a good event
+func (r *Ratio) Good() {
+	// The total is counted first, so a concurrent read never sees more good
+	// events than events
+	r.total.Inc()
+	r.good.Inc()
+}
+
+// Bad records a bad event
+func (r *Ratio) Bad() {
+	r.total.Inc()
+}
+
+// Record records a good event if good is true and a bad one otherwise
+func (r *Ratio) Record(good bool) {
+	if good {
+		r.Good()
+	} else {
+		r.Bad()
+	}
+}
+
+// Value returns good events over all events, from 0 to 1, or 1 without events
+func (r *Ratio) Value() float64 {
+	good, total := r.good.Value(), r.total.Value()
+	if total == 0 || good >= total {
+		return 1
+	}
+	return float64(good) / float64(total)
+}
+
+// Counts returns the number of good events and of all events
+func (r *Ratio) Counts() (good, total uint64) {
+	return r.good.Value(), r.total.Value()
+}
+
+// With returns the Ratio with additional tags on all three series
+func (r *Ratio) With(tags Tags) *Ratio {
+	return NewRatio(r.registry, derived(r.opts, copyTags(r.opts.Tags, tags)))
+}
@@ -0,0 +1,47 @@
+package metric
+
+import "testing"
+
+func TestRatio(t *testing.T) {
+	registry := NewNoCleanupRegistry().(*defaultRegistry)
+	defer registry.Close()
+
+	checkout := NewRatio(registry, Options{Name: "checkout", Tags: Tags{"region": "eu"}})
+	if checkout.Value() != 1 {
+		t.Errorf("Expected a ratio of 1 without events, got %v", checkout.Value())
+	}
+
+	for range 3 {
+		checkout.Good()
+	}
+	checkout.Bad()
+	checkout.Record(false)
+	if good, total := checkout.Counts(); good != 3 || total != 5 {
+		t.Errorf("Expected 3 of 5 good events, got %d of %d", good, total)
+	}
+
+	series := registry.Series("checkout" + RatioSuffix)
+	if len(series) != 1 || series[0].Value != 600000 || series[0].Tags["region"] != "eu" {
+		t.Errorf("Expected a ratio gauge of 600000 ppm tagged region=eu, got %+v", series)
+	}
+	if got := registry.Counter(Options{Name: "checkout" + RatioGoodSuffix, Tags: Tags{"region": "eu"}}).Value(); got != 3 {
+		t.Errorf("Expected the good counter to share the ratio's tags, got %d", got)
+	}
+
+	// Derived ratios tag all three series and keep their own counts
+	mobile := checkout.With(Tags{"client": "mobile"})
+	mobile.Bad()
+	if mobile.Value() != 0 || checkout.Value() != 0.6 {
+		t.Errorf("Expected separate ratios, got %v and %v", mobile.Value(), checkout.Value())
+	}
+	for _, name := range []string{"checkout" + RatioGoodSuffix, "checkout" + RatioTotalSuffix, "checkout" + RatioSuffix} {
+		if got := len(registry.Series(name)); got != 2 {
+			t.Errorf("Expected 2 series of %s, got %d", name, got)
+		}
+	}
+
+	// Creating the ratio again returns the same series
+	if good, _ := NewRatio(registry, Options{Name: "checkout", Tags: Tags{"region": "eu"}}).Counts(); good != 3 {
+		t.Errorf("Expected the existing counters to be reused, got %d good events", good)
+	}
+}
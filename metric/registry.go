@@ -2,207 +2,644 @@ package metric
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // metricEntry holds a metric and its expiration information
 type metricEntry struct {
-	metric    Metric
-	expiresAt time.Time
-	ttl       time.Duration
+	metric        Metric
+	tags          Tags // interned when the entry was created, released when it is removed
+	expiresAt     time.Time
+	ttl           time.Duration
+	metadataQuiet atomic.Bool // set once a metadata conflict is reported or SetMetadata applies
+	removed       atomic.Bool // set when the entry leaves the registry, so stale read snapshots skip it
+}
+
+// ErrCardinalityExceeded is the error a registry panics with when a new series
+// would exceed TagValidationConfig.MaxCardinality for its metric name
+var ErrCardinalityExceeded = errors.New("cardinality limit exceeded")
+
+// writeTracker is implemented by metrics that record whether they were written,
+// so TTL cleanup can expire them only once idle
+type writeTracker interface {
+	takeWritten() bool
+}
+
+// familyMember is implemented by series derived with With() that their parent
+// caches; removing such a series from the registry must also evict it from the cache
+type familyMember interface {
+	detach()
 }
 
 // defaultRegistry is a thread-safe implementation of Registry
 type defaultRegistry struct {
 	mu                  sync.RWMutex
 	metrics             map[string]*metricEntry
+	read                atomic.Pointer[map[string]*metricEntry] // immutable snapshot of metrics for lock-free lookups
+	misses              int                                     // locked lookups since read was published; guarded by mu
 	cardinality         map[string]int // tracks cardinality per metric name
-	tagValidationConfig TagValidationConfig
+	tagValidationConfig atomic.Pointer[TagValidationConfig] // swapped by UpdateTagValidation
 	ctx                 context.Context
 	cancel              context.CancelFunc
 	cleanupInterval     time.Duration
+	meta                *registryMeta // nil unless WithMetaMetrics is set
+	negativePolicy      NegativeAddPolicy
+	negativeHandler     NegativeAddHandler
+	lastCleanup         time.Time           // start of the previous cleanup pass
+	expireHooks         []func(Metric)      // guarded by mu
+	metadata            map[string]Metadata // set by SetMetadata, keyed by metric name
+	conflictHandler     func(MetadataConflict)
+	catalog             map[string]*catalogEntry // keyed by metric name; see Catalog
+	strictMetadata      bool                     // set by WithStrictMetadata
+	sink                ObservationSink // set by WithObservationSink, given to every metric created
+	counterShards       int             // default Options.Shards for counters, set by WithCounterShards
+	paused              atomic.Bool     // set by Pause; shared with every metric created
+	durableStores       map[string]*durableStore // open files of durable counters by path, closed on removal or with the registry; guarded by mu
+	tenants             *tenantLimits   // nil unless WithTenantBudget is set
+	tagProcessors       []TagProcessor  // set by WithTagProcessors
+	clock               Clock           // measures TTLs and stamps writes; set by WithClock
+	derivedGauges       atomic.Int64    // derived gauges ever created; Each evaluates them while any may exist
+	timerAudit          *timerAudit     // nil unless WithTimerAudit is set
 }
 
 // NewRegistry creates a new Registry instance with full configuration
-func NewRegistry(tagConfig TagValidationConfig, cleanupInterval time.Duration) Registry {
+func NewRegistry(tagConfig TagValidationConfig, cleanupInterval time.Duration, opts ...RegistryOption) Registry {
 	ctx, cancel := context.WithCancel(context.Background())
 	
 	r := &defaultRegistry{
 		metrics:             make(map[string]*metricEntry),
 		cardinality:         make(map[string]int),
-		tagValidationConfig: tagConfig,
 		ctx:                 ctx,
 		cancel:              cancel,
 		cleanupInterval:     cleanupInterval,
+		metadata:            make(map[string]Metadata),
+		catalog:             make(map[string]*catalogEntry),
+		clock:               SystemClock,
 	}
+	r.tagValidationConfig.Store(&tagConfig)
+
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.timerAudit != nil {
+		r.timerAudit.meta = r.meta
+	}
+	r.lastCleanup = r.clock.Now()
 	
 	// Start cleanup goroutine only if cleanup interval is > 0
 	if cleanupInterval > 0 {
-		go r.cleanupLoop()
+		goroutines.start(&periodicTask{ctx: r.ctx, interval: cleanupInterval, run: r.cleanupTick})
 	}
 	
 	return r
 }
 
 // NewDefaultRegistry creates a registry with sensible defaults
-func NewDefaultRegistry() Registry {
-	return NewRegistry(DefaultTagValidationConfig(), 5*time.Minute)
+func NewDefaultRegistry(opts ...RegistryOption) Registry {
+	return NewRegistry(DefaultTagValidationConfig(), 5*time.Minute, opts...)
 }
 
 // NewNoCleanupRegistry creates a registry that never expires metrics
-func NewNoCleanupRegistry() Registry {
-	return NewRegistry(DefaultTagValidationConfig(), 0) // 0 means no cleanup
+func NewNoCleanupRegistry(opts ...RegistryOption) Registry {
+	return NewRegistry(DefaultTagValidationConfig(), 0, opts...) // 0 means no cleanup
 }
 
-// lookup retrieves a metric by name and type or creates it using the factory if it doesn't exist
-func (r *defaultRegistry) lookup(opts Options, metricType Type, factory func() Metric) Metric {
+// keyBufferSize is the stack buffer used to build series keys on lookup; longer
+// keys still work but spill to the heap
+const keyBufferSize = 256
+
+// seriesKey identifies a series in the registry by type, name and canonical tag set,
+// so the same name with different tags yields distinct series
+func seriesKey(metricType Type, name string, tags Tags) string {
+	return string(appendSeriesKey(make([]byte, 0, keyBufferSize), metricType, name, tags))
+}
+
+// appendSeriesKey appends the series key for type, name and tags to dst
+func appendSeriesKey(dst []byte, metricType Type, name string, tags Tags) []byte {
+	dst = append(dst, metricType...)
+	dst = append(dst, ':')
+	dst = appendLengthPrefixed(dst, name)
+	return appendTagsKey(dst, tags)
+}
+
+// lookup retrieves a metric by type, name and tags or creates it using the factory if it doesn't exist.
+// The key is built in a stack buffer and only copied to the heap when a new series is stored.
+func (r *defaultRegistry) lookup(opts Options, metricType Type, factory func(opts Options) Metric) Metric {
+	var buf [keyBufferSize]byte
+	return r.getOrCreate(appendSeriesKey(buf[:0], metricType, opts.Name, opts.Tags), metricType, opts, factory)
+}
+
+// derived returns the options for the series derived from opts with With(tags),
+// where tags is the merged tag set
+func derived(opts Options, tags Tags) Options {
+	child := opts
+	child.Tags = tags
+	return child
+}
+
+// getOrCreate retrieves the metric stored under key or creates it using the factory,
+// applying tag validation and the cardinality limit for opts.Name. The factory is
+// given opts with tags whose strings are shared with other series.
+func (r *defaultRegistry) getOrCreate(key []byte, metricType Type, opts Options, factory func(opts Options) Metric) Metric {
+	// Fast path: existing series are found without locking or allocating, and their
+	// name and tags were already checked when they were created
+	if read := r.read.Load(); read != nil {
+		if entry, ok := (*read)[string(key)]; ok && !entry.removed.Load() {
+			r.checkMetadata(entry, opts)
+			return entry.metric
+		}
+	}
+
+	if r.meta != nil {
+		r.meta.checkReserved(opts.Name)
+	}
+
 	// Validate tags before proceeding
-	if err := ValidateTags(opts.Tags, r.tagValidationConfig); err != nil {
+	config := r.tagValidationConfig.Load()
+	if err := ValidateTags(opts.Tags, *config); err != nil {
+		if r.meta != nil {
+			r.meta.tagRejections.Inc()
+		}
 		// In production, you might want to log this error and return a no-op metric
 		// For now, we'll panic to make the error visible during development
 		panic(fmt.Sprintf("tag validation failed: %v", err))
 	}
 
-	key := fmt.Sprintf("%s:%s", metricType, opts.Name)
-
-	r.mu.RLock()
-	entry, ok := r.metrics[key]
-	r.mu.RUnlock()
-
-	if ok {
-		return entry.metric
-	}
-
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Double-check after acquiring write lock
-	if entry, ok = r.metrics[key]; ok {
+	// Series created since the snapshot was published are found here
+	if entry, ok := r.metrics[string(key)]; ok {
+		r.missed()
+		r.checkMetadata(entry, opts)
 		return entry.metric
 	}
 
 	// Check cardinality limit for this metric name
-	if r.cardinality[opts.Name] >= r.tagValidationConfig.MaxCardinality {
+	if r.cardinality[opts.Name] >= config.MaxCardinality {
+		if r.meta != nil {
+			r.meta.limitRejections.Inc()
+		}
 		// In production, you might want to log this and return a no-op metric
-		panic(fmt.Sprintf("cardinality limit exceeded for metric '%s': %d >= %d", 
-			opts.Name, r.cardinality[opts.Name], r.tagValidationConfig.MaxCardinality))
+		panic(fmt.Errorf("%w for metric '%s': %d >= %d",
+			ErrCardinalityExceeded, opts.Name, r.cardinality[opts.Name], config.MaxCardinality))
 	}
-
-	// Create new metric
-	m := factory()
-	entry = &metricEntry{
+	if r.tenants != nil {
+		r.checkTenant(opts.Name, opts.Tags)
+	}
+	r.checkCatalog(opts, metricType)
+
+	// Create new metric, releasing the interned tags if the factory panics
+	opts.Tags = interned.intern(opts.Tags)
+	created := false
+	defer func() {
+		if !created {
+			interned.release(opts.Tags)
+		}
+	}()
+	m := factory(opts)
+	created = true
+	if setter, ok := m.(writeClockSetter); ok && r.clock != SystemClock {
+		setter.setWriteClock(r.clock)
+	}
+	entry := &metricEntry{
 		metric: m,
+		tags:   opts.Tags,
 		ttl:    opts.TTL,
 	}
 	
 	// Set expiration time if TTL is specified
 	if opts.TTL > 0 {
-		entry.expiresAt = time.Now().Add(opts.TTL)
+		entry.expiresAt = r.clock.Now().Add(opts.TTL)
 	}
 	
-	r.metrics[key] = entry
+	r.applyMetadata(entry)
+
+	r.metrics[string(key)] = entry
 	r.cardinality[opts.Name]++
+	r.recordCatalog(opts, metricType)
+	if r.tenants != nil {
+		r.countTenant(opts.Tags, 1)
+	}
+	r.missed()
 	return m
 }
 
+// missed counts a lookup that needed the lock and republishes the read snapshot
+// once misses have cost as much as copying it, as sync.Map does. Unlike sync.Map
+// the snapshot is typed, so a lookup with a []byte key does not allocate.
+// The caller must hold the write lock.
+func (r *defaultRegistry) missed() {
+	r.misses++
+	if r.misses >= len(r.metrics) {
+		r.publish()
+	}
+}
+
+// publish replaces the read snapshot with a copy of metrics.
+// The caller must hold the write lock.
+func (r *defaultRegistry) publish() {
+	snapshot := maps.Clone(r.metrics)
+	r.read.Store(&snapshot)
+	r.misses = 0
+}
+
 // Counter creates or retrieves a Counter
 func (r *defaultRegistry) Counter(opts Options) Counter {
-	m := r.lookup(opts, TypeCounter, func() Metric {
-		return newCounter(opts)
+	opts.Tags = r.processTags(opts.Tags)
+	if opts.Shards == 0 {
+		opts.Shards = r.counterShards
+	}
+	m := r.lookup(opts, TypeCounter, func(opts Options) Metric {
+		c := newCounter(opts).(*counterImpl)
+		c.onNegative = r.onNegativeAdd
+		c.sink = r.sink
+		c.paused = &r.paused
+		c.derive = func(tags Tags) Counter { return r.Counter(durableDerived(derived(opts, tags))) }
+		if opts.Durability != nil {
+			store, err := r.durableStore(*opts.Durability, seriesKey(TypeCounter, opts.Name, opts.Tags))
+			if err != nil {
+				panic(fmt.Errorf("durable counter '%s': %w", opts.Name, err))
+			}
+			c.durable = store
+		}
+		return c
 	})
 	return m.(Counter)
 }
 
 // Gauge creates or retrieves a Gauge
 func (r *defaultRegistry) Gauge(opts Options) Gauge {
-	m := r.lookup(opts, TypeGauge, func() Metric {
-		return newGauge(opts)
+	opts.Tags = r.processTags(opts.Tags)
+	m := r.lookup(opts, TypeGauge, func(opts Options) Metric {
+		g := newGauge(opts).(*gaugeImpl)
+		g.sink = r.sink
+		g.paused = &r.paused
+		g.derive = func(tags Tags) Gauge { return r.Gauge(derived(opts, tags)) }
+		return g
 	})
 	return m.(Gauge)
 }
 
-// Histogram creates or retrieves a Histogram
+// UpDownCounter creates or retrieves an UpDownCounter
+func (r *defaultRegistry) UpDownCounter(opts Options) UpDownCounter {
+	opts.Tags = r.processTags(opts.Tags)
+	m := r.lookup(opts, TypeUpDownCounter, func(opts Options) Metric {
+		c := newUpDownCounter(opts).(*upDownCounterImpl)
+		c.sink = r.sink
+		c.paused = &r.paused
+		c.derive = func(tags Tags) UpDownCounter { return r.UpDownCounter(derived(opts, tags)) }
+		return c
+	})
+	return m.(UpDownCounter)
+}
+
+// GaugeFunc creates or retrieves a Gauge whose value is computed by fn at read time.
+// If a gauge with the same name is already registered it is returned unchanged.
+func (r *defaultRegistry) GaugeFunc(opts Options, fn func() float64) Gauge {
+	opts.Tags = r.processTags(opts.Tags)
+	if fn == nil {
+		panic(fmt.Sprintf("gauge func '%s' requires a non-nil callback", opts.Name))
+	}
+	m := r.lookup(opts, TypeGauge, func(opts Options) Metric {
+		g := newGaugeFunc(opts, fn).(*gaugeFuncImpl)
+		g.derive = func(tags Tags) Gauge { return r.GaugeFunc(derived(opts, tags), fn) }
+		return g
+	})
+	return m.(Gauge)
+}
+
+// Histogram creates or retrieves a Histogram, a WindowedHistogram if opts.Window
+// is set or an AdaptiveHistogram if opts.AdaptiveWarmUp is
 func (r *defaultRegistry) Histogram(opts Options) Histogram {
-	m := r.lookup(opts, TypeHistogram, func() Metric {
-		return newHistogram(opts)
+	opts.Tags = r.processTags(opts.Tags)
+	if opts.Window > 0 {
+		return r.windowedHistogram(opts)
+	}
+	if opts.AdaptiveWarmUp > 0 {
+		return r.adaptiveHistogram(opts)
+	}
+	m := r.lookup(opts, TypeHistogram, func(opts Options) Metric {
+		h := newHistogram(opts).(*histogramImpl)
+		h.sink = r.sink
+		h.paused = &r.paused
+		h.family.register = func(child *histogramImpl) *histogramImpl {
+			return r.registerChild(TypeHistogram, child, opts.TTL).(*histogramImpl)
+		}
+		h.family.processTags = r.processTags
+		return h
+	})
+	return m.(Histogram)
+}
+
+// windowedHistogram creates or retrieves a WindowedHistogram
+func (r *defaultRegistry) windowedHistogram(opts Options) Histogram {
+	m := r.lookup(opts, TypeHistogram, func(opts Options) Metric {
+		w := newWindowedHistogram(opts).(*windowedHistogram)
+		w.sink = r.sink
+		w.paused = &r.paused
+		w.derive = func(tags Tags) Histogram { return r.Histogram(derived(opts, tags)) }
+		return w
+	})
+	return m.(Histogram)
+}
+
+// adaptiveHistogram creates or retrieves an AdaptiveHistogram
+func (r *defaultRegistry) adaptiveHistogram(opts Options) Histogram {
+	m := r.lookup(opts, TypeHistogram, func(opts Options) Metric {
+		a := newAdaptiveHistogram(opts).(*adaptiveHistogram)
+		a.sink = r.sink
+		a.paused = &r.paused
+		a.derive = func(tags Tags) Histogram { return r.Histogram(derived(opts, tags)) }
+		return a
 	})
 	return m.(Histogram)
 }
 
 // Timer creates or retrieves a Timer
 func (r *defaultRegistry) Timer(opts Options) Timer {
-	m := r.lookup(opts, TypeTimer, func() Metric {
-		return newTimer(opts)
+	opts.Tags = r.processTags(opts.Tags)
+	m := r.lookup(opts, TypeTimer, func(opts Options) Metric {
+		t := newTimer(opts).(*timerImpl)
+		t.sink = r.sink
+		t.audit = r.timerAudit
+		t.histogram.(*histogramImpl).paused = &r.paused
+		t.histogram.(*histogramImpl).family.register = func(child *histogramImpl) *histogramImpl {
+			registered := r.registerChild(TypeTimer, &timerImpl{histogram: child, sink: r.sink, audit: r.timerAudit}, opts.TTL)
+			return registered.(*timerImpl).histogram.(*histogramImpl)
+		}
+		t.histogram.(*histogramImpl).family.processTags = r.processTags
+		return t
 	})
 	return m.(Timer)
 }
 
+// Meter creates or retrieves a Meter
+func (r *defaultRegistry) Meter(opts Options) Meter {
+	opts.Tags = r.processTags(opts.Tags)
+	m := r.lookup(opts, TypeMeter, func(opts Options) Metric {
+		meter := newMeter(opts).(*meterImpl)
+		meter.sink = r.sink
+		meter.paused = &r.paused
+		meter.derive = func(tags Tags) Meter { return r.Meter(derived(opts, tags)) }
+		return meter
+	})
+	return m.(Meter)
+}
+
+// registerChild adds a series derived with With() to the registry so it is
+// visible to Each and reporters, and returns the registered series. If a series
+// with the same tags was already registered, for example by a lookup with those
+// tags, that series is returned instead of m.
+func (r *defaultRegistry) registerChild(metricType Type, m Metric, ttl time.Duration) Metric {
+	tags := m.Tags()
+	var buf [keyBufferSize]byte
+	return r.getOrCreate(appendSeriesKey(buf[:0], metricType, m.Name(), tags), metricType, Options{Name: m.Name(), Tags: tags, TTL: ttl}, func(Options) Metric {
+		return m
+	})
+}
+
 // Unregister removes a metric from the registry
 func (r *defaultRegistry) Unregister(name string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Delete all metric types with this name
-	for key := range r.metrics {
-		if fmt.Sprintf("%s:%s", TypeCounter, name) == key ||
-			fmt.Sprintf("%s:%s", TypeGauge, name) == key ||
-			fmt.Sprintf("%s:%s", TypeHistogram, name) == key ||
-			fmt.Sprintf("%s:%s", TypeTimer, name) == key {
-			delete(r.metrics, key)
+	// Delete all metric types with this name, including series derived with With()
+	for key, entry := range r.metrics {
+		if entry.metric.Name() == name {
+			r.remove(key, entry)
+		}
+	}
+	r.publish()
+	delete(r.cardinality, name)
+	delete(r.metadata, name)
+}
+
+// UnregisterMetric removes the metric of type t registered under name, including
+// its series derived with With(), leaving metrics of other types with the same name
+func (r *defaultRegistry) UnregisterMetric(name string, t Type) {
+	r.UnregisterWhere(func(m Metric) bool {
+		return m.Name() == name && m.Type() == t
+	})
+}
+
+// UnregisterWhere removes every registered series for which match returns true.
+// match is called with the registry locked and must not use the registry.
+func (r *defaultRegistry) UnregisterWhere(match func(Metric) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, entry := range r.metrics {
+		if match(entry.metric) {
+			r.remove(key, entry)
+		}
+	}
+	r.publish()
+}
+
+// UnregisterPrefix removes every metric whose name starts with prefix, including
+// series derived with With(), e.g. a family of per-tenant metrics after the
+// tenant is deleted. Like Unregister it also drops metadata set for those names.
+func (r *defaultRegistry) UnregisterPrefix(prefix string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, entry := range r.metrics {
+		if strings.HasPrefix(entry.metric.Name(), prefix) {
+			r.remove(key, entry)
+		}
+	}
+	r.publish()
+	for name := range r.metadata {
+		if strings.HasPrefix(name, prefix) {
+			delete(r.metadata, name)
 		}
 	}
 }
 
+// remove deletes the entry stored under key and releases its cardinality.
+// The caller must hold the write lock.
+func (r *defaultRegistry) remove(key string, entry *metricEntry) {
+	delete(r.metrics, key)
+	entry.removed.Store(true)
+	interned.release(entry.tags)
+	if member, ok := entry.metric.(familyMember); ok {
+		member.detach()
+	}
+
+	name := entry.metric.Name()
+	r.cardinality[name]--
+	if r.cardinality[name] <= 0 {
+		delete(r.cardinality, name)
+		delete(r.catalog, name)
+	}
+	if r.tenants != nil {
+		r.countTenant(entry.metric.Tags(), -1)
+	}
+	if c, ok := entry.metric.(*counterImpl); ok && c.durable != nil {
+		r.releaseDurableStore(c.durable)
+	}
+}
+
+// durableStore returns the open store of the durable counter at d.Path,
+// opening it on first use, so a series re-created after removal never maps its
+// file twice. The caller must hold the write lock.
+func (r *defaultRegistry) durableStore(d Durability, key string) (*durableStore, error) {
+	if store, ok := r.durableStores[d.Path]; ok {
+		if store.key != key {
+			return nil, fmt.Errorf("%w: %s is open for another series", ErrDurableMismatch, d.Path)
+		}
+		return store, nil
+	}
+	store, err := openDurableStore(d, key)
+	if err != nil {
+		return nil, err
+	}
+	if r.durableStores == nil {
+		r.durableStores = make(map[string]*durableStore)
+	}
+	r.durableStores[d.Path] = store
+	return store, nil
+}
+
+// releaseDurableStore closes the store of a removed durable counter, so its
+// file can be opened again. Writes through the removed counter are dropped,
+// like those to any removed series. The caller must hold the write lock.
+func (r *defaultRegistry) releaseDurableStore(store *durableStore) {
+	if r.durableStores[store.path] == store {
+		delete(r.durableStores, store.path)
+	}
+	store.close()
+}
+
 // Each iterates over all registered metrics
 func (r *defaultRegistry) Each(fn func(Metric)) {
+	for _, m := range r.snapshot() {
+		fn(m)
+	}
+}
+
+// EachByType iterates over the registered metrics of type t
+func (r *defaultRegistry) EachByType(t Type, fn func(Metric)) {
+	for _, m := range r.snapshot() {
+		if m.Type() == t {
+			fn(m)
+		}
+	}
+}
+
+// Lookup returns the untagged series of name and type t without creating it
+func (r *defaultRegistry) Lookup(name string, t Type) (Metric, bool) {
+	var buf [keyBufferSize]byte
+	key := appendSeriesKey(buf[:0], t, name, nil)
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	entry, ok := r.metrics[string(key)]
+	if !ok {
+		return nil, false
+	}
+	return entry.metric, true
+}
 
+// snapshot returns every registered metric and meta metric. Only the copy is
+// taken under the lock; meta metrics and derived gauges are evaluated after it
+// is released, as are callbacks on the snapshot, so they may use the registry.
+func (r *defaultRegistry) snapshot() []Metric {
+	r.mu.RLock()
+	metrics := make([]Metric, 0, len(r.metrics))
 	for _, entry := range r.metrics {
-		fn(entry.metric)
+		metrics = append(metrics, entry.metric)
+	}
+	var cardinality map[string]int
+	if r.meta != nil {
+		cardinality = maps.Clone(r.cardinality)
 	}
+	r.mu.RUnlock()
+
+	if r.meta != nil {
+		r.meta.each(len(metrics), cardinality, func(m Metric) { metrics = append(metrics, m) })
+	}
+	if r.derivedGauges.Load() > 0 {
+		// Derived gauges read the other series, so they are evaluated on the whole snapshot
+		r.evaluateDerived(metrics)
+	}
+	return metrics
 }
 
-// cleanupLoop runs in the background and periodically removes expired metrics
-func (r *defaultRegistry) cleanupLoop() {
-	ticker := time.NewTicker(r.cleanupInterval)
-	defer ticker.Stop()
+// cleanupTick runs one pass of the periodic cleanup
+func (r *defaultRegistry) cleanupTick() bool {
+	// Paused metrics are idle by design; keep their handles registered
+	if !r.paused.Load() {
+		r.cleanupExpired()
+	}
+	return true
+}
 
-	for {
-		select {
-		case <-r.ctx.Done():
-			return
-		case <-ticker.C:
-			r.cleanupExpired()
+// OnExpire registers fn to be called with each metric removed by TTL cleanup.
+// Hooks run after the metric has been removed, outside the registry lock, so
+// they may read its final value or use the registry.
+func (r *defaultRegistry) OnExpire(fn func(Metric)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expireHooks = append(r.expireHooks, fn)
+}
+
+// cleanupExpired removes expired metrics from the registry and runs the expire hooks
+func (r *defaultRegistry) cleanupExpired() {
+	expired, hooks := r.removeExpired()
+	for _, m := range expired {
+		for _, hook := range hooks {
+			hook(m)
 		}
 	}
 }
 
-// cleanupExpired removes expired metrics from the registry
-func (r *defaultRegistry) cleanupExpired() {
+// removeExpired deletes metrics that have been idle for longer than their TTL.
+// A metric written since the previous pass has its expiry moved to at least
+// TTL after that pass, so expiry is measured from the last write to within
+// one cleanup interval.
+func (r *defaultRegistry) removeExpired() ([]Metric, []func(Metric)) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	now := time.Now()
+	start, now := time.Now(), r.clock.Now()
+	var expired []Metric
+	if r.meta != nil {
+		defer func() { r.meta.observeCleanup(start, len(expired)) }()
+	}
+
 	for key, entry := range r.metrics {
 		// Skip metrics without TTL
 		if entry.ttl == 0 {
 			continue
 		}
 
+		// Refresh metrics written since the previous pass
+		if tracker, ok := entry.metric.(writeTracker); ok && tracker.takeWritten() {
+			if refreshed := r.lastCleanup.Add(entry.ttl); refreshed.After(entry.expiresAt) {
+				entry.expiresAt = refreshed
+			}
+		}
+
 		// Remove expired metrics
 		if now.After(entry.expiresAt) {
-			delete(r.metrics, key)
-			// Decrease cardinality count
-			metricName := entry.metric.Name()
-			r.cardinality[metricName]--
-			if r.cardinality[metricName] <= 0 {
-				delete(r.cardinality, metricName)
-			}
+			r.remove(key, entry)
+			expired = append(expired, entry.metric)
 		}
 	}
+	r.lastCleanup = now
+	if len(expired) > 0 {
+		r.publish()
+	}
+
+	return expired, r.expireHooks
 }
 
 // ManualCleanup removes all expired metrics immediately
@@ -213,7 +650,21 @@ func (r *defaultRegistry) ManualCleanup() {
 // Close stops the cleanup goroutine and cleans up resources
 func (r *defaultRegistry) Close() error {
 	r.cancel()
-	return nil
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for _, store := range r.durableStores {
+		errs = append(errs, store.close())
+	}
+	r.durableStores = nil
+
+	// Series stay readable, but no longer hold their tag strings in the intern table
+	for _, entry := range r.metrics {
+		interned.release(entry.tags)
+		entry.tags = nil
+	}
+	return errors.Join(errs...)
 }
 
 // GlobalRegistry is the default registry used when no registry is specified
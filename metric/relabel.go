@@ -0,0 +1,137 @@
+package metric
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RelabelRule rewrites the metrics an export filter passes, like a Prometheus
+// relabel_config. A rule applies to the metrics whose name, as left by the
+// rules before it, matches Match; within a rule tag keys are mapped first,
+// then tags dropped, then static tags added and finally the metric renamed.
+type RelabelRule struct {
+	// Match is a glob pattern for metric names, in the syntax of path.Match; empty matches every metric
+	Match string `json:"match,omitempty" yaml:"match,omitempty"`
+	// Drop leaves the matching metrics out of the export
+	Drop bool `json:"drop,omitempty" yaml:"drop,omitempty"`
+	// MapTags renames tag keys, from old to new key
+	MapTags map[string]string `json:"map_tags,omitempty" yaml:"map_tags,omitempty"`
+	// DropTags removes tag keys, merging series that only differed by them
+	DropTags []string `json:"drop_tags,omitempty" yaml:"drop_tags,omitempty"`
+	// AddTags sets static tags, replacing tags with the same keys
+	AddTags Tags `json:"add_tags,omitempty" yaml:"add_tags,omitempty"`
+	// Rename is the name the matching metrics are exported under
+	Rename string `json:"rename,omitempty" yaml:"rename,omitempty"`
+}
+
+// Validate returns an error if the rule's pattern is malformed
+func (r RelabelRule) Validate() error {
+	if r.Match == "" {
+		return nil
+	}
+	if _, err := path.Match(r.Match, ""); err != nil {
+		return fmt.Errorf("relabel rule: malformed pattern '%s': %w", r.Match, err)
+	}
+	return nil
+}
+
+// Relabel applies rules, in order, to every metric the filter passes. Name and
+// tag filters see the metrics as registered; StripTags applies after the rules.
+// It panics if a rule is invalid.
+func Relabel(rules ...RelabelRule) FilterOption {
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			panic("metric: " + err.Error())
+		}
+	}
+	return func(f *ExportFilter) {
+		f.rules = append(f.rules, rules...)
+	}
+}
+
+// ParseRelabelRules parses a list of rules from YAML or JSON
+func ParseRelabelRules(data []byte) ([]RelabelRule, error) {
+	var rules []RelabelRule
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// LoadRelabelRules reads a list of rules from the file at path. Files ending
+// in .json are read as JSON and anything else as YAML, using the field names
+// of the struct tags, e.g.
+//
+//   - match: "http_*"
+//     map_tags: {path: route}
+//     drop_tags: [user_id]
+//     add_tags: {team: payments}
+func LoadRelabelRules(path string) ([]RelabelRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []RelabelRule
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &rules)
+		for _, rule := range rules {
+			if err == nil {
+				err = rule.Validate()
+			}
+		}
+	} else {
+		rules, err = ParseRelabelRules(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("relabel rules %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// relabel applies rules to name and tags, which it may modify. It returns
+// false if a rule drops the metric.
+func relabel(rules []RelabelRule, name string, tags Tags) (string, bool) {
+	for _, rule := range rules {
+		if rule.Match != "" {
+			if ok, _ := path.Match(rule.Match, name); !ok {
+				continue
+			}
+		}
+		if rule.Drop {
+			return name, false
+		}
+
+		if len(rule.MapTags) > 0 {
+			mapped := make(Tags, len(tags))
+			for key, value := range tags {
+				if to, ok := rule.MapTags[key]; ok {
+					key = to
+				}
+				mapped[key] = value
+			}
+			clear(tags)
+			maps.Copy(tags, mapped)
+		}
+		for _, key := range rule.DropTags {
+			delete(tags, key)
+		}
+		maps.Copy(tags, rule.AddTags)
+		if rule.Rename != "" {
+			name = rule.Rename
+		}
+	}
+	return name, true
+}
@@ -0,0 +1,80 @@
+package metric
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRelabel(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	requests := registry.Counter(Options{Name: "http_requests_total", Tags: Tags{"path": "/a", "pod": "web-1"}})
+	requests.Add(2)
+	requests.With(Tags{"pod": "web-2"}).Inc()
+	registry.Gauge(Options{Name: "debug_heap"}).Set(1)
+	registry.Gauge(Options{Name: "queue_depth", Tags: Tags{"queue": "emails"}}).Set(4)
+
+	recorder := &recordingReporter{}
+	reporter := FilterReporter(recorder, Relabel(
+		RelabelRule{Match: "debug_*", Drop: true},
+		RelabelRule{Match: "http_*", MapTags: map[string]string{"path": "route"}, DropTags: []string{"pod"}},
+		RelabelRule{Match: "http_requests_total", Rename: "requests_total"},
+		RelabelRule{AddTags: Tags{"team": "payments"}},
+	))
+	if err := reporter.Report(registry); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recorder.metrics) != 2 {
+		t.Fatalf("Expected 2 exported series, got %v", recorder.metrics)
+	}
+	requestsOut, ok := recorder.metrics["requests_total{route=/a,team=payments}"].(Counter)
+	if !ok || requestsOut.Value() != 3 {
+		t.Errorf("Expected the renamed and merged counter to be 3, got %v", recorder.metrics)
+	}
+	if _, ok := recorder.metrics["queue_depth{queue=emails,team=payments}"].(Gauge); !ok {
+		t.Errorf("Expected the static tag on every metric, got %v", recorder.metrics)
+	}
+}
+
+func TestLoadRelabelRules(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "relabel.yaml")
+	yamlRules := `
+- match: "http_*"
+  map_tags: {path: route}
+  drop_tags: [user_id]
+- match: debug_*
+  drop: true
+- rename: renamed
+  add_tags:
+    env: prod
+`
+	jsonPath := filepath.Join(dir, "relabel.json")
+	jsonRules := `[{"match": "http_*", "map_tags": {"path": "route"}, "drop_tags": ["user_id"]},
+		{"match": "debug_*", "drop": true},
+		{"rename": "renamed", "add_tags": {"env": "prod"}}]`
+	if err := os.WriteFile(yamlPath, []byte(yamlRules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(jsonPath, []byte(jsonRules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		rules, err := LoadRelabelRules(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rules) != 3 || rules[0].MapTags["path"] != "route" || rules[0].DropTags[0] != "user_id" ||
+			!rules[1].Drop || rules[2].Rename != "renamed" || rules[2].AddTags["env"] != "prod" {
+			t.Errorf("Unexpected rules from %s: %+v", filepath.Base(path), rules)
+		}
+	}
+
+	if _, err := ParseRelabelRules([]byte(`[{match: "["}]`)); err == nil {
+		t.Error("Expected a malformed pattern to be rejected")
+	}
+}
@@ -0,0 +1,143 @@
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// label is a Prometheus label pair
+type label struct {
+	name, value string
+}
+
+// timeSeries is one remote-write series with a single sample
+type timeSeries struct {
+	labels    []label
+	value     float64
+	timestamp int64 // milliseconds since the epoch
+}
+
+// collect converts every metric in registry into time series stamped with
+// timestamp, following the naming of the prometheus package: timers become
+// histograms in seconds and meters one gauge per moving average.
+func collect(registry metric.Registry, externalLabels map[string]string, timestamp int64) []timeSeries {
+	var series []timeSeries
+	add := func(name string, tags metric.Tags, extra []label, value float64) {
+		labels := make([]label, 0, len(externalLabels)+len(tags)+len(extra)+1)
+		labels = append(labels, label{"__name__", name})
+		for k, v := range externalLabels {
+			if _, ok := tags[k]; !ok {
+				labels = append(labels, label{k, v})
+			}
+		}
+		for k, v := range tags {
+			labels = append(labels, label{k, v})
+		}
+		labels = append(labels, extra...)
+		sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+		series = append(series, timeSeries{labels: labels, value: value, timestamp: timestamp})
+	}
+	addHistogram := func(name string, tags metric.Tags, snapshot metric.HistogramSnapshot, divisor float64) {
+		if len(snapshot.Buckets) == len(snapshot.Boundaries)+1 {
+			var cumulative uint64
+			for i, boundary := range snapshot.Boundaries {
+				cumulative += snapshot.Buckets[i]
+				add(name+"_bucket", tags, []label{{"le", formatFloat(boundary / divisor)}}, float64(cumulative))
+			}
+		}
+		add(name+"_bucket", tags, []label{{"le", "+Inf"}}, float64(snapshot.Count))
+		add(name+"_sum", tags, nil, snapshot.Sum/divisor)
+		add(name+"_count", tags, nil, float64(snapshot.Count))
+	}
+
+	registry.Each(func(m metric.Metric) {
+		name, tags := m.Name(), m.Tags()
+		switch m.Type() {
+		case metric.TypeCounter:
+			if counter, ok := m.(metric.Counter); ok {
+				add(name, tags, nil, float64(counter.Value()))
+			}
+		case metric.TypeGauge:
+			if gauge, ok := m.(metric.Gauge); ok {
+				unit := metric.ExportUnit(m)
+				add(unit.Name(name), tags, nil, unit.Convert(metric.GaugeValue(gauge)))
+			}
+		case metric.TypeUpDownCounter:
+			if counter, ok := m.(metric.UpDownCounter); ok {
+				add(name, tags, nil, float64(counter.Value()))
+			}
+		case metric.TypeHistogram:
+			// Values in a known unit are exported in its base unit, named with its suffix
+			if histogram, ok := m.(metric.Histogram); ok {
+				unit := metric.ExportUnit(m)
+				addHistogram(unit.Name(name), tags, histogram.Snapshot(), unit.Divisor)
+			}
+		case metric.TypeTimer:
+			// Timers record nanoseconds; Prometheus convention is seconds
+			if timer, ok := m.(metric.Timer); ok {
+				unit := metric.ExportUnit(m)
+				addHistogram(unit.Name(name), tags, timer.Snapshot(), unit.Divisor)
+			}
+		case metric.TypeMeter:
+			if meter, ok := m.(metric.Meter); ok {
+				s := meter.Snapshot()
+				add(name+"_rate1m", tags, nil, s.Rate1)
+				add(name+"_rate5m", tags, nil, s.Rate5)
+				add(name+"_rate15m", tags, nil, s.Rate15)
+			}
+		}
+	})
+	return series
+}
+
+// formatFloat formats a bucket bound the way Prometheus does
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Field numbers of the remote-write protobuf messages (prometheus/prompb)
+const (
+	writeRequestTimeseries = 1
+	timeSeriesLabels       = 1
+	timeSeriesSamples      = 2
+	labelName              = 1
+	labelValue             = 2
+	sampleValue            = 1
+	sampleTimestamp        = 2
+)
+
+// marshalWriteRequest encodes series as a prometheus.WriteRequest
+func marshalWriteRequest(series []timeSeries) []byte {
+	var out, ts, msg []byte
+	for _, s := range series {
+		ts = ts[:0]
+		for _, l := range s.labels {
+			msg = msg[:0]
+			msg = protowire.AppendTag(msg, labelName, protowire.BytesType)
+			msg = protowire.AppendString(msg, l.name)
+			msg = protowire.AppendTag(msg, labelValue, protowire.BytesType)
+			msg = protowire.AppendString(msg, l.value)
+			ts = protowire.AppendTag(ts, timeSeriesLabels, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, msg)
+		}
+
+		msg = msg[:0]
+		msg = protowire.AppendTag(msg, sampleValue, protowire.Fixed64Type)
+		msg = protowire.AppendFixed64(msg, math.Float64bits(s.value))
+		msg = protowire.AppendTag(msg, sampleTimestamp, protowire.VarintType)
+		msg = protowire.AppendVarint(msg, uint64(s.timestamp))
+		ts = protowire.AppendTag(ts, timeSeriesSamples, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, msg)
+
+		out = protowire.AppendTag(out, writeRequestTimeseries, protowire.BytesType)
+		out = protowire.AppendBytes(out, ts)
+	}
+	return out
+}
@@ -0,0 +1,257 @@
+// Package remotewrite pushes registry snapshots to Prometheus remote-write
+// endpoints such as Thanos Receive, Mimir, Cortex or VictoriaMetrics.
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/golang/snappy"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityReporter, Name: "remotewrite", Package: "github.com/MichaelAJay/go-metrics/metric/remotewrite"})
+}
+
+const (
+	// DefaultMaxSamplesPerRequest caps the samples sent in one request, matching
+	// the Prometheus server's default max_samples_per_send
+	DefaultMaxSamplesPerRequest = 2000
+	// DefaultMaxRetries is how many times a failed request is retried by default
+	DefaultMaxRetries = 3
+	// DefaultRetryBackoff is the delay before the first retry; it doubles on each attempt
+	DefaultRetryBackoff = 100 * time.Millisecond
+)
+
+// Reporter implements metric.Reporter by encoding each Report as remote-write
+// protobuf, snappy-compressed, and POSTing it to the endpoint. Every series is
+// sent with one sample stamped with the time of the Report, or the time given
+// to ReportAt.
+type Reporter struct {
+	url            string
+	client         *http.Client
+	headers        http.Header
+	externalLabels map[string]string
+	maxSamples     int
+	maxRetries     int
+	backoff        time.Duration
+	clock          metric.Clock
+	filterOptions  []metric.FilterOption
+	filter         *metric.ExportFilter
+	payloadBytes   atomic.Uint64 // compressed bytes of the last report
+}
+
+// Option is a functional option for configuring the remote-write reporter
+type Option func(*Reporter)
+
+// NewReporter creates a reporter writing to the remote-write endpoint url,
+// e.g. "http://mimir:9009/api/v1/push"
+func NewReporter(url string, opts ...Option) *Reporter {
+	r := &Reporter{
+		url:            url,
+		client:         &http.Client{Timeout: 30 * time.Second},
+		headers:        http.Header{},
+		externalLabels: make(map[string]string),
+		maxSamples:     DefaultMaxSamplesPerRequest,
+		maxRetries:     DefaultMaxRetries,
+		backoff:        DefaultRetryBackoff,
+		clock:          metric.SystemClock,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if len(r.filterOptions) > 0 {
+		r.filter = metric.NewExportFilter(r.filterOptions...)
+	}
+	return r
+}
+
+// WithHTTPClient sends requests with client instead of a default client with a 30s timeout
+func WithHTTPClient(client *http.Client) Option {
+	return func(r *Reporter) {
+		r.client = client
+	}
+}
+
+// WithHeader adds a header to every request, e.g. "X-Scope-OrgID" for Mimir tenants
+func WithHeader(key, value string) Option {
+	return func(r *Reporter) {
+		r.headers.Add(key, value)
+	}
+}
+
+// WithBasicAuth authenticates requests with HTTP basic auth
+func WithBasicAuth(username, password string) Option {
+	return func(r *Reporter) {
+		req := http.Request{Header: http.Header{}}
+		req.SetBasicAuth(username, password)
+		r.headers.Set("Authorization", req.Header.Get("Authorization"))
+	}
+}
+
+// WithBearerToken authenticates requests with a bearer token
+func WithBearerToken(token string) Option {
+	return func(r *Reporter) {
+		r.headers.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// WithExternalLabels adds labels to every series, e.g. cluster or replica.
+// Tags of the same name take precedence.
+func WithExternalLabels(labels map[string]string) Option {
+	return func(r *Reporter) {
+		for k, v := range labels {
+			r.externalLabels[k] = v
+		}
+	}
+}
+
+// WithMaxSamplesPerRequest splits each Report into requests of at most n samples
+func WithMaxSamplesPerRequest(n int) Option {
+	return func(r *Reporter) {
+		if n > 0 {
+			r.maxSamples = n
+		}
+	}
+}
+
+// WithRetries retries a request that failed with a network error, a 5xx or a
+// 429 response up to maxRetries times, waiting backoff before the first retry
+// and doubling it on each later one. Other 4xx responses are not retried, and
+// a report whose failures were all such responses wraps metric.ErrPermanent.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(r *Reporter) {
+		r.maxRetries = maxRetries
+		r.backoff = backoff
+	}
+}
+
+// WithClock stamps samples with the time read from clock
+func WithClock(clock metric.Clock) Option {
+	return func(r *Reporter) {
+		r.clock = clock
+	}
+}
+
+// WithExportFilter narrows and scrubs the exported metrics with the filter
+// options, e.g. metric.DenyNames("debug_*") or metric.StripTags("user_id")
+func WithExportFilter(opts ...metric.FilterOption) Option {
+	return func(r *Reporter) {
+		r.filterOptions = append(r.filterOptions, opts...)
+	}
+}
+
+// Report implements the metric.Reporter interface. All batches are attempted;
+// the errors of those that failed are joined.
+func (r *Reporter) Report(registry metric.Registry) error {
+	return r.ReportContext(context.Background(), registry)
+}
+
+// ReportContext implements the metric.ContextReporter interface. Requests and
+// retry backoffs are cut short when ctx ends, and batches not yet sent are
+// dropped.
+func (r *Reporter) ReportContext(ctx context.Context, registry metric.Registry) error {
+	return r.ReportAt(ctx, registry, r.clock.Now())
+}
+
+// ReportAt implements the metric.TimestampReporter interface, stamping every
+// sample with at, so reports retried by metric.BufferedReporter keep the time
+// they were captured at instead of colliding at the time of the retry
+func (r *Reporter) ReportAt(ctx context.Context, registry metric.Registry, at time.Time) error {
+	series := collect(r.filter.View(registry), r.externalLabels, at.UnixMilli())
+
+	var errs []error
+	var size uint64
+	permanent := true
+	for start := 0; start < len(series); start += r.maxSamples {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("remotewrite: %w", err))
+			permanent = false
+			break
+		}
+		end := min(start+r.maxSamples, len(series))
+		body := snappy.Encode(nil, marshalWriteRequest(series[start:end]))
+		size += uint64(len(body))
+		if retryable, err := r.send(ctx, body); err != nil {
+			errs = append(errs, err)
+			permanent = permanent && !retryable && ctx.Err() == nil
+		}
+	}
+	r.payloadBytes.Store(size)
+	if len(errs) > 0 && permanent {
+		return fmt.Errorf("%w: %w", metric.ErrPermanent, errors.Join(errs...))
+	}
+	return errors.Join(errs...)
+}
+
+// PayloadBytes implements the metric.PayloadSizer interface, returning the
+// compressed size of the write requests of the last report
+func (r *Reporter) PayloadBytes() uint64 {
+	return r.payloadBytes.Load()
+}
+
+// send POSTs one compressed write request, retrying recoverable failures and
+// reporting whether the last failure was recoverable
+func (r *Reporter) send(ctx context.Context, body []byte) (retryable bool, err error) {
+	backoff := r.backoff
+	for attempt := 0; ; attempt++ {
+		retryable, err = r.post(ctx, body)
+		if err == nil || !retryable || attempt >= r.maxRetries {
+			return retryable, err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return retryable, err
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one request, reporting whether a failure is worth retrying
+func (r *Reporter) post(ctx context.Context, body []byte) (retryable bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("remotewrite: %w", err)
+	}
+	for k, values := range r.headers {
+		req.Header[k] = values
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", "go-metrics-remotewrite")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("remotewrite: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("remotewrite: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	return resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests, err
+}
+
+// Flush implements the metric.Reporter interface; Report sends synchronously,
+// so there is nothing buffered
+func (r *Reporter) Flush() error {
+	return nil
+}
+
+// Close implements the metric.Reporter interface by closing idle connections
+func (r *Reporter) Close() error {
+	r.client.CloseIdleConnections()
+	return nil
+}
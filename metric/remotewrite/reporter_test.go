@@ -0,0 +1,323 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// receiver is a remote-write endpoint recording the series it was sent
+type receiver struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	series   []timeSeries
+	statuses []int // responses to return in order; 204 once exhausted
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, req)
+	if len(rc.statuses) > 0 {
+		status := rc.statuses[0]
+		rc.statuses = rc.statuses[1:]
+		if status != http.StatusNoContent {
+			http.Error(w, "rejected", status)
+			return
+		}
+	}
+
+	compressed, _ := io.ReadAll(req.Body)
+	body, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rc.series = append(rc.series, decodeWriteRequest(body)...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rc *receiver) find(name string, labels ...string) (timeSeries, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, s := range rc.series {
+		if valueOf(s, "__name__") != name {
+			continue
+		}
+		match := true
+		for i := 0; i < len(labels); i += 2 {
+			if valueOf(s, labels[i]) != labels[i+1] {
+				match = false
+			}
+		}
+		if match {
+			return s, true
+		}
+	}
+	return timeSeries{}, false
+}
+
+func valueOf(s timeSeries, name string) string {
+	for _, l := range s.labels {
+		if l.name == name {
+			return l.value
+		}
+	}
+	return ""
+}
+
+// decodeWriteRequest parses the fields marshalWriteRequest writes
+func decodeWriteRequest(b []byte) []timeSeries {
+	var series []timeSeries
+	eachField(b, func(_ protowire.Number, ts []byte, _ uint64) {
+		var s timeSeries
+		eachField(ts, func(num protowire.Number, msg []byte, _ uint64) {
+			switch num {
+			case timeSeriesLabels:
+				var l label
+				eachField(msg, func(num protowire.Number, v []byte, _ uint64) {
+					if num == labelName {
+						l.name = string(v)
+					} else {
+						l.value = string(v)
+					}
+				})
+				s.labels = append(s.labels, l)
+			case timeSeriesSamples:
+				eachField(msg, func(num protowire.Number, _ []byte, v uint64) {
+					if num == sampleValue {
+						s.value = math.Float64frombits(v)
+					} else {
+						s.timestamp = int64(v)
+					}
+				})
+			}
+		})
+		series = append(series, s)
+	})
+	return series
+}
+
+func eachField(b []byte, fn func(num protowire.Number, bytes []byte, scalar uint64)) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			fn(num, v, 0)
+			b = b[n:]
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			fn(num, nil, v)
+			b = b[n:]
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			fn(num, nil, v)
+			b = b[n:]
+		default:
+			return
+		}
+	}
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func TestReporterWritesSeries(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	registry.Counter(metric.Options{Name: "requests_total", Tags: metric.Tags{"method": "GET"}}).Add(3)
+	registry.Gauge(metric.Options{Name: "queue_depth"}).Set(5)
+	registry.Timer(metric.Options{Name: "latency"}).Record(2 * time.Second)
+
+	now := time.Unix(1700000000, 0)
+	reporter := NewReporter(server.URL,
+		WithBearerToken("secret"),
+		WithHeader("X-Scope-OrgID", "team-a"),
+		WithExternalLabels(map[string]string{"cluster": "eu-1"}),
+		WithClock(fixedClock(now)),
+	)
+	defer reporter.Close()
+
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+
+	req := rc.requests[0]
+	if got := req.Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Expected bearer auth header, got %q", got)
+	}
+	if got := req.Header.Get("X-Scope-OrgID"); got != "team-a" {
+		t.Errorf("Expected tenant header, got %q", got)
+	}
+	if got := req.Header.Get("Content-Encoding"); got != "snappy" {
+		t.Errorf("Expected snappy encoding, got %q", got)
+	}
+
+	counter, ok := rc.find("requests_total", "method", "GET", "cluster", "eu-1")
+	if !ok || counter.value != 3 || counter.timestamp != now.UnixMilli() {
+		t.Errorf("Expected requests_total 3 at %d, got %+v (found %v)", now.UnixMilli(), counter, ok)
+	}
+	if gauge, ok := rc.find("queue_depth"); !ok || gauge.value != 5 {
+		t.Errorf("Expected queue_depth 5, got %+v (found %v)", gauge, ok)
+	}
+	if sum, ok := rc.find("latency_seconds_sum"); !ok || sum.value != 2 {
+		t.Errorf("Expected latency_seconds_sum 2, got %+v (found %v)", sum, ok)
+	}
+	if inf, ok := rc.find("latency_seconds_bucket", "le", "+Inf"); !ok || inf.value != 1 {
+		t.Errorf("Expected +Inf bucket 1, got %+v (found %v)", inf, ok)
+	}
+	for _, s := range rc.series {
+		for i := 1; i < len(s.labels); i++ {
+			if s.labels[i-1].name >= s.labels[i].name {
+				t.Fatalf("Expected labels sorted by name, got %+v", s.labels)
+			}
+		}
+	}
+}
+
+func TestReporterBatchesSamples(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		registry.Counter(metric.Options{Name: name}).Inc()
+	}
+
+	reporter := NewReporter(server.URL, WithMaxSamplesPerRequest(2))
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if len(rc.requests) != 3 || len(rc.series) != 5 {
+		t.Errorf("Expected 5 samples in 3 requests, got %d in %d", len(rc.series), len(rc.requests))
+	}
+	if reporter.PayloadBytes() == 0 {
+		t.Error("Expected the payload size of the report")
+	}
+}
+
+func TestReporterRetries(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	registry.Counter(metric.Options{Name: "jobs_total"}).Inc()
+
+	reporter := NewReporter(server.URL, WithRetries(2, time.Millisecond))
+	if err := reporter.Report(registry); err != nil {
+		t.Fatalf("Expected the third attempt to succeed, got %v", err)
+	}
+	if len(rc.requests) != 3 {
+		t.Errorf("Expected 3 attempts, got %d", len(rc.requests))
+	}
+
+	rc.statuses = []int{http.StatusBadRequest}
+	rc.requests = nil
+	err := reporter.Report(registry)
+	if err == nil || !strings.Contains(err.Error(), "400") || !errors.Is(err, metric.ErrPermanent) {
+		t.Errorf("Expected a permanent 400 error, got %v", err)
+	}
+	if len(rc.requests) != 1 {
+		t.Errorf("Expected client errors not to be retried, got %d attempts", len(rc.requests))
+	}
+}
+
+func TestBufferedReporterKeepsCaptureTime(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	jobs := registry.Counter(metric.Options{Name: "jobs_total"})
+
+	captured := time.Unix(1700000000, 0)
+	replayed := captured.Add(10 * time.Minute)
+	reporter := metric.BufferedReporter(
+		NewReporter(server.URL, WithRetries(0, 0), WithClock(fixedClock(replayed))),
+		metric.BufferClock(fixedClock(captured)),
+	)
+
+	// The report queued during the outage is sent with the time it was captured
+	jobs.Inc()
+	if err := reporter.Report(registry); !errors.Is(err, metric.ErrReportBuffered) {
+		t.Fatalf("Expected the report to be buffered, got %v", err)
+	}
+	jobs.Inc()
+	if err := reporter.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if s, ok := rc.find("jobs_total"); !ok || s.value != 1 || s.timestamp != captured.UnixMilli() {
+		t.Errorf("Expected jobs_total 1 at %d, got %+v (found %v)", captured.UnixMilli(), s, ok)
+	}
+
+	// Reports the backend rejects as invalid are dropped, not retried
+	rc.statuses = []int{http.StatusBadRequest}
+	if err := reporter.Report(registry); !errors.Is(err, metric.ErrPermanent) || errors.Is(err, metric.ErrReportBuffered) {
+		t.Fatalf("Expected the rejected report to be dropped, got %v", err)
+	}
+	requests := len(rc.requests)
+	if err := reporter.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(rc.requests) != requests {
+		t.Errorf("Expected nothing queued after a permanent failure, got %d more requests", len(rc.requests)-requests)
+	}
+	if dropped := reporter.(metric.DropCounter).Dropped(); dropped != 1 {
+		t.Errorf("Expected 1 dropped report, got %d", dropped)
+	}
+}
+
+func TestReporterReportContext(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	registry.Counter(metric.Options{Name: "jobs_total"}).Inc()
+
+	// The deadline cuts the retry backoff short
+	reporter := NewReporter(server.URL, WithRetries(5, time.Minute))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := reporter.ReportContext(ctx, registry)
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Expected the last 503 error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the report to stop at the deadline, took %v", elapsed)
+	}
+
+	// A context that already ended sends nothing
+	rc.requests = nil
+	if err := reporter.ReportContext(ctx, registry); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline error, got %v", err)
+	}
+	if len(rc.requests) != 0 {
+		t.Errorf("Expected no requests after the deadline, got %d", len(rc.requests))
+	}
+}
@@ -0,0 +1,69 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ContextReporter is implemented by reporters whose reports honour a context,
+// so a slow backend gives up at a deadline or on cancellation instead of
+// holding up the caller. Report behaves as ReportContext with
+// context.Background().
+type ContextReporter interface {
+	Reporter
+	// ReportContext reports the registry like Report, returning ctx.Err() if
+	// ctx ends before the report completes
+	ReportContext(ctx context.Context, registry Registry) error
+}
+
+// ReportContext reports registry to reporter within ctx. Reporters that
+// implement ContextReporter stop their work when ctx ends; for others the
+// report runs in its own goroutine and ReportContext returns ctx.Err() without
+// waiting for it, so the abandoned report may still complete later.
+func ReportContext(ctx context.Context, reporter Reporter, registry Registry) error {
+	if r, ok := reporter.(ContextReporter); ok {
+		return r.ReportContext(ctx, registry)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ctx.Done() == nil {
+		return reporter.Report(registry)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- reporter.Report(registry)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ErrPermanent is wrapped by the errors of reports that retrying cannot fix,
+// such as a request the backend rejected as malformed. BufferedReporter drops
+// such reports instead of queueing them.
+var ErrPermanent = errors.New("report rejected permanently")
+
+// TimestampReporter is implemented by reporters that can stamp a report with
+// the time its values were read rather than the time it is sent, such as the
+// remote write reporter. BufferedReporter retries queued reports with it, so
+// the samples of an outage keep the times they were captured at.
+type TimestampReporter interface {
+	Reporter
+	// ReportAt reports the registry like ReportContext, stamping its values with at
+	ReportAt(ctx context.Context, registry Registry, at time.Time) error
+}
+
+// ReportAt reports registry to reporter within ctx, stamped with at if
+// reporter implements TimestampReporter and like ReportContext otherwise
+func ReportAt(ctx context.Context, reporter Reporter, registry Registry, at time.Time) error {
+	if r, ok := reporter.(TimestampReporter); ok {
+		return r.ReportAt(ctx, registry, at)
+	}
+	return ReportContext(ctx, reporter, registry)
+}
@@ -0,0 +1,94 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// blockingReporter's Report blocks until release is closed
+type blockingReporter struct {
+	fakeReporter
+	release chan struct{}
+}
+
+func (b *blockingReporter) Report(registry Registry) error {
+	<-b.release
+	return b.fakeReporter.Report(registry)
+}
+
+// reportKey marks the context passed to a contextReporter
+type reportKey struct{}
+
+// contextReporter records the context it was given
+type contextReporter struct {
+	fakeReporter
+	ctx context.Context
+}
+
+func (c *contextReporter) ReportContext(ctx context.Context, registry Registry) error {
+	c.ctx = ctx
+	return c.fakeReporter.Report(registry)
+}
+
+func TestReportContext(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	// A reporter without ReportContext is abandoned at the deadline
+	slow := &blockingReporter{release: make(chan struct{})}
+	defer close(slow.release)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := ReportContext(ctx, slow, registry); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected ReportContext to return at the deadline, took %v", elapsed)
+	}
+
+	// A cancelled context does not start a report
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	fast := &fakeReporter{}
+	if err := ReportContext(cancelled, fast, registry); !errors.Is(err, context.Canceled) || fast.reports != 0 {
+		t.Errorf("Expected a cancelled context to skip the report, got %v after %d reports", err, fast.reports)
+	}
+
+	// Reporters that take a context are given it
+	aware := &contextReporter{}
+	ctx = context.WithValue(context.Background(), reportKey{}, "marker")
+	if err := ReportContext(ctx, aware, registry); err != nil || aware.ctx != ctx {
+		t.Errorf("Expected ReportContext to be called with the context, got %v", err)
+	}
+}
+
+func TestSchedulerReportTimeout(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	hung := &blockingReporter{release: make(chan struct{})}
+	defer close(hung.release)
+	healthy := &fakeReporter{}
+	scheduler := NewScheduler(registry, 0,
+		WithReporter("hung", hung),
+		WithReporter("healthy", healthy),
+		WithReportTimeout(20*time.Millisecond),
+		WithShutdownLogger(nil),
+	)
+
+	scheduler.Export()
+	if healthy.reports != 1 {
+		t.Errorf("Expected the healthy backend to be reported despite the hung one, got %d reports", healthy.reports)
+	}
+
+	report, err := scheduler.Close()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the hung backend's deadline error, got %v", err)
+	}
+	if report.Backends[0].Err == nil || report.Backends[1].Err != nil {
+		t.Errorf("Expected only the hung backend to fail, got %+v", report.Backends)
+	}
+}
@@ -0,0 +1,182 @@
+package metric
+
+import (
+	"sync"
+	"time"
+)
+
+// ReporterMetricPrefix starts the names of the metrics WithReporterMetrics registers
+const ReporterMetricPrefix = "gometrics_reporter_"
+
+// PayloadSizer is implemented by reporters that know how many bytes their
+// last report sent, e.g. the compressed request bodies of a push reporter
+type PayloadSizer interface {
+	// PayloadBytes returns the size of the last report's payload
+	PayloadBytes() uint64
+}
+
+// ReporterStats describes the health of one scheduler backend, so operators can
+// alert when an export pipeline breaks
+type ReporterStats struct {
+	// Name is the backend name given to WithReporter
+	Name string
+	// LastAttempt is when the last report started; zero if never
+	LastAttempt time.Time
+	// LastSuccess is when the reporter last reported and flushed successfully; zero if never
+	LastSuccess time.Time
+	// ConsecutiveFailures counts the reports that failed since the last success
+	ConsecutiveFailures int
+	// Reports and Failures count every report and the ones that failed
+	Reports  uint64
+	Failures uint64
+	// LastDuration is how long the last report and flush took
+	LastDuration time.Duration
+	// LastSeries is the number of series in the registry at the last report
+	LastSeries int
+	// LastPayloadBytes is the size of the last report's payload, if the
+	// reporter implements PayloadSizer
+	LastPayloadBytes uint64
+	// LastErr is the error of the last report or flush, if it failed
+	LastErr error
+}
+
+// WithReporterMetrics registers the health of every backend in the scheduler's
+// registry, tagged with the backend name:
+//
+//	gometrics_reporter_reports_total{backend}                counter: reports attempted
+//	gometrics_reporter_failures_total{backend}               counter: reports or flushes that failed
+//	gometrics_reporter_consecutive_failures{backend}         gauge: failures since the last success
+//	gometrics_reporter_last_success_timestamp_seconds{backend}  gauge: Unix time of the last success
+//	gometrics_reporter_report_duration{backend}              timer: duration of each report and flush
+//	gometrics_reporter_payload_series{backend}               gauge: series in the registry at the last report
+//	gometrics_reporter_payload_bytes{backend}                gauge: size of the last payload, for reporters implementing PayloadSizer
+//
+// Each report carries the health recorded up to the previous one. The same
+// values are available without a registry from Scheduler.Stats.
+func WithReporterMetrics() SchedulerOption {
+	return func(s *Scheduler) {
+		s.reporterMetrics = true
+	}
+}
+
+// backendHealth records the stats of a backend and mirrors them in its metrics
+type backendHealth struct {
+	mu    sync.Mutex
+	stats ReporterStats
+
+	metrics *reporterMetrics // nil unless WithReporterMetrics is set
+}
+
+// reporterMetrics are the metrics registered for a backend by WithReporterMetrics
+type reporterMetrics struct {
+	reports             Counter
+	failures            Counter
+	consecutiveFailures Gauge
+	lastSuccess         Gauge
+	duration            Timer
+	series              Gauge
+	payloadBytes        Gauge // nil unless the reporter implements PayloadSizer
+}
+
+func newReporterMetrics(registry Registry, name string, reporter Reporter) *reporterMetrics {
+	tags := Tags{"backend": name}
+	m := &reporterMetrics{
+		reports: registry.Counter(Options{
+			Name:        ReporterMetricPrefix + "reports_total",
+			Description: "Reports attempted by the scheduler",
+			Unit:        "count",
+			Tags:        tags,
+		}),
+		failures: registry.Counter(Options{
+			Name:        ReporterMetricPrefix + "failures_total",
+			Description: "Reports or flushes that failed",
+			Unit:        "count",
+			Tags:        tags,
+		}),
+		consecutiveFailures: registry.Gauge(Options{
+			Name:        ReporterMetricPrefix + "consecutive_failures",
+			Description: "Reports that failed since the last success",
+			Unit:        "count",
+			Tags:        tags,
+		}),
+		lastSuccess: registry.Gauge(Options{
+			Name:        ReporterMetricPrefix + "last_success_timestamp_seconds",
+			Description: "Unix time of the last successful report",
+			Unit:        "seconds",
+			Tags:        tags,
+		}),
+		duration: registry.Timer(Options{
+			Name:        ReporterMetricPrefix + "report_duration",
+			Description: "Duration of each report and flush",
+			Unit:        "ns",
+			Tags:        tags,
+		}),
+		series: registry.Gauge(Options{
+			Name:        ReporterMetricPrefix + "payload_series",
+			Description: "Series in the registry at the last report",
+			Unit:        "count",
+			Tags:        tags,
+		}),
+	}
+	if _, ok := reporter.(PayloadSizer); ok {
+		m.payloadBytes = registry.Gauge(Options{
+			Name:        ReporterMetricPrefix + "payload_bytes",
+			Description: "Size of the last report's payload",
+			Unit:        "bytes",
+			Tags:        tags,
+		})
+	}
+	return m
+}
+
+// record updates the stats with a report of series that ran from start to end
+func (h *backendHealth) record(reporter Reporter, start, end time.Time, series int, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := &h.stats
+	s.LastAttempt = start
+	s.LastDuration = end.Sub(start)
+	s.LastSeries = series
+	s.LastErr = err
+	s.Reports++
+	if sizer, ok := reporter.(PayloadSizer); ok {
+		s.LastPayloadBytes = sizer.PayloadBytes()
+	}
+	if err != nil {
+		s.Failures++
+		s.ConsecutiveFailures++
+	} else {
+		s.LastSuccess = end
+		s.ConsecutiveFailures = 0
+	}
+
+	m := h.metrics
+	if m == nil {
+		return
+	}
+	m.reports.Inc()
+	if err != nil {
+		m.failures.Inc()
+	} else {
+		m.lastSuccess.Set(float64(end.Unix()))
+	}
+	m.consecutiveFailures.Set(float64(s.ConsecutiveFailures))
+	m.duration.Record(s.LastDuration)
+	m.series.Set(float64(series))
+	if m.payloadBytes != nil {
+		m.payloadBytes.Set(float64(s.LastPayloadBytes))
+	}
+}
+
+// Stats returns the health of every backend in the order they were added. It
+// does not wait for an export in progress.
+func (s *Scheduler) Stats() []ReporterStats {
+	stats := make([]ReporterStats, len(s.backends))
+	for i, b := range s.backends {
+		b.health.mu.Lock()
+		stats[i] = b.health.stats
+		b.health.mu.Unlock()
+	}
+	return stats
+}
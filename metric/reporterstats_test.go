@@ -0,0 +1,58 @@
+package metric
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSchedulerStats(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+	registry.Counter(Options{Name: "requests"}).Inc()
+
+	clock := &manualClock{now: time.Unix(1700000000, 0)}
+	failing := &fakeReporter{err: errors.New("collector unavailable")}
+	scheduler := NewScheduler(registry, 0,
+		WithReporter("remote", failing),
+		WithReporterMetrics(),
+		WithSchedulerClock(clock),
+		WithShutdownLogger(nil),
+	)
+	defer scheduler.Close()
+
+	scheduler.Export()
+	scheduler.Export()
+	stats := scheduler.Stats()[0]
+	if stats.Name != "remote" || stats.Reports != 2 || stats.Failures != 2 || stats.ConsecutiveFailures != 2 {
+		t.Errorf("Expected two consecutive failures, got %+v", stats)
+	}
+	if !stats.LastSuccess.IsZero() || stats.LastErr == nil {
+		t.Errorf("Expected no success and the last error, got %+v", stats)
+	}
+
+	failing.mu.Lock()
+	failing.err = nil
+	failing.mu.Unlock()
+	clock.now = clock.now.Add(time.Minute)
+	scheduler.Export()
+	stats = scheduler.Stats()[0]
+	if stats.ConsecutiveFailures != 0 || !stats.LastSuccess.Equal(clock.now) || stats.LastErr != nil {
+		t.Errorf("Expected the success to reset the failures, got %+v", stats)
+	}
+
+	// The health is registered in the registry, tagged with the backend
+	tags := Tags{"backend": "remote"}
+	if v := registry.Counter(Options{Name: ReporterMetricPrefix + "reports_total", Tags: tags}).Value(); v != 3 {
+		t.Errorf("Expected 3 reports, got %d", v)
+	}
+	if v := registry.Counter(Options{Name: ReporterMetricPrefix + "failures_total", Tags: tags}).Value(); v != 2 {
+		t.Errorf("Expected 2 failures, got %d", v)
+	}
+	if v := registry.Gauge(Options{Name: ReporterMetricPrefix + "last_success_timestamp_seconds", Tags: tags}).Value(); v != clock.now.Unix() {
+		t.Errorf("Expected the last success time, got %d", v)
+	}
+	if v := registry.Gauge(Options{Name: ReporterMetricPrefix + "payload_series", Tags: tags}).Value(); v < 1 {
+		t.Errorf("Expected the reported series count, got %d", v)
+	}
+}
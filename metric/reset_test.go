@@ -0,0 +1,113 @@
+package metric
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestCounterSwap(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	for _, shards := range []int{0, 4} {
+		counter := registry.Counter(Options{Name: "requests_total", Shards: shards, Tags: Tags{"shards": strconv.Itoa(shards)}})
+		counter.Add(5)
+		if got := counter.Swap(); got != 5 {
+			t.Errorf("Shards %d: expected Swap to return 5, got %d", shards, got)
+		}
+		if got := counter.Value(); got != 0 {
+			t.Errorf("Shards %d: expected the counter to be zeroed, got %d", shards, got)
+		}
+
+		// Concurrent adds are never lost or counted twice
+		var wg sync.WaitGroup
+		var swapped uint64
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range 100 {
+				swapped += counter.Swap()
+			}
+		}()
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 1000 {
+					counter.Inc()
+				}
+			}()
+		}
+		wg.Wait()
+		<-done
+		if total := swapped + counter.Swap(); total != 8000 {
+			t.Errorf("Shards %d: expected swaps to total 8000, got %d", shards, total)
+		}
+	}
+}
+
+func TestCounterResetGenerations(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	counter := registry.Counter(Options{Name: "jobs_total"})
+	sibling := counter.With(Tags{"queue": "low"})
+	counter.Add(3)
+	before := counter.Snapshot()
+	if before.Value != 3 || before.Generation == 0 {
+		t.Fatalf("Expected a value of 3 with a generation, got %+v", before)
+	}
+	if sibling.Snapshot().Generation == before.Generation {
+		t.Error("Expected every series to start with a generation of its own")
+	}
+
+	counter.Inc()
+	if s := counter.Snapshot(); s.Generation != before.Generation || s.Value != 4 {
+		t.Errorf("Expected writes to keep the generation, got %+v", s)
+	}
+
+	counter.Reset()
+	after := counter.Snapshot()
+	if after.Value != 0 || after.Generation <= before.Generation {
+		t.Errorf("Expected Reset to zero the counter and start a newer generation, got %+v after %+v", after, before)
+	}
+	counter.Swap()
+	if counter.Snapshot().Generation == after.Generation {
+		t.Error("Expected Swap to start a new generation")
+	}
+
+	// A series created again after removal is told apart from the old one
+	registry.Unregister("jobs_total")
+	recreated := registry.Counter(Options{Name: "jobs_total"})
+	recreated.Add(10)
+	if recreated.Snapshot().Generation == counter.Snapshot().Generation {
+		t.Error("Expected a re-created series to have a new generation")
+	}
+}
+
+func TestHistogramSnapshotAndReset(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	histogram := registry.Histogram(Options{Name: "payload_bytes", Buckets: []float64{10, 100}})
+	histogram.Observe(5)
+	histogram.Observe(50)
+
+	snapshot := histogram.SnapshotAndReset()
+	if snapshot.Count != 2 || snapshot.Sum != 55 || snapshot.Min != 5 || snapshot.Max != 50 {
+		t.Errorf("Expected 2 observations summing to 55 between 5 and 50, got %+v", snapshot)
+	}
+	if snapshot.Buckets[0] != 1 || snapshot.Buckets[1] != 1 {
+		t.Errorf("Expected one observation in each of the first buckets, got %v", snapshot.Buckets)
+	}
+
+	if empty := histogram.Snapshot(); empty.Count != 0 || empty.Sum != 0 || empty.Min != 0 || empty.Max != 0 || empty.Buckets[0] != 0 {
+		t.Errorf("Expected the histogram to be reset, got %+v", empty)
+	}
+
+	histogram.Observe(500)
+	if next := histogram.SnapshotAndReset(); next.Count != 1 || next.Min != 500 || next.Max != 500 || next.Buckets[2] != 1 {
+		t.Errorf("Expected only the observation since the reset, got %+v", next)
+	}
+}
@@ -0,0 +1,419 @@
+package metric
+
+import (
+	"fmt"
+	"hash/maphash"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RollupRule declares an aggregate series maintained alongside a detailed one.
+// Every update recorded on a metric named Name is also applied to a series
+// named As whose tags omit the keys in Without, so the aggregate carries the
+// total across all values of those keys.
+type RollupRule struct {
+	// Name is the detailed metric the rule applies to
+	Name string
+	// Without lists the tag keys aggregated away in the rollup series
+	Without []string
+	// As names the rollup series; if empty it defaults to Name + "_all_" + the sorted Without keys
+	As string
+}
+
+// rollupName returns the name of the series maintained by the rule
+func (rule RollupRule) rollupName() string {
+	if rule.As != "" {
+		return rule.As
+	}
+	keys := append([]string(nil), rule.Without...)
+	sort.Strings(keys)
+	return rule.Name + "_all_" + strings.Join(keys, "_")
+}
+
+// rollupTags returns tags without the keys aggregated away by the rule
+func (rule RollupRule) rollupTags(tags Tags) Tags {
+	rolled := make(Tags, len(tags))
+	for k, v := range tags {
+		rolled[k] = v
+	}
+	for _, k := range rule.Without {
+		delete(rolled, k)
+	}
+	return rolled
+}
+
+// rollupRegistry wraps a Registry and fans updates out to rollup series
+type rollupRegistry struct {
+	forwardingRegistry
+	rules map[string][]RollupRule // keyed by detailed metric name
+}
+
+// NewRollupRegistry returns a Registry that maintains the rollup series declared
+// by rules in base, computed client-side from the same Inc/Add/Set/Observe/Record
+// call that updates the detailed series. Counters, histograms and timers roll up
+// every update; gauges roll up as the sum of their detailed series. The returned
+// registry implements the optional registry interfaces base implements.
+func NewRollupRegistry(base Registry, rules ...RollupRule) (Registry, error) {
+	r := &rollupRegistry{
+		forwardingRegistry: forwardingRegistry{base},
+		rules:              make(map[string][]RollupRule),
+	}
+
+	names := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rollup rule requires a metric name")
+		}
+		if len(rule.Without) == 0 {
+			return nil, fmt.Errorf("rollup rule for '%s' must aggregate at least one tag key", rule.Name)
+		}
+		name := rule.rollupName()
+		if name == rule.Name || names[name] {
+			return nil, fmt.Errorf("rollup series name '%s' for '%s' is not unique", name, rule.Name)
+		}
+		names[name] = true
+		r.rules[rule.Name] = append(r.rules[rule.Name], rule)
+	}
+
+	return expose(r, base), nil
+}
+
+// rollupWith applies With to each rollup series using the tags that survive its rule.
+// When every tag is aggregated away the rollup series itself is kept.
+func rollupWith[M any](rollups []M, rules []RollupRule, tags Tags, with func(M, Tags) M) []M {
+	derived := make([]M, len(rollups))
+	for i, rollup := range rollups {
+		rolled := rules[i].rollupTags(tags)
+		if len(rolled) == 0 {
+			derived[i] = rollup
+			continue
+		}
+		derived[i] = with(rollup, rolled)
+	}
+	return derived
+}
+
+// rollupOptions returns the options for the series maintained by rule
+func rollupOptions(opts Options, rule RollupRule) Options {
+	rolled := opts
+	rolled.Name = rule.rollupName()
+	rolled.Tags = rule.rollupTags(opts.Tags)
+	return rolled
+}
+
+// Counter creates or retrieves a Counter that also updates its rollups
+func (r *rollupRegistry) Counter(opts Options) Counter {
+	c := r.Registry.Counter(opts)
+	rules := r.rules[opts.Name]
+	if len(rules) == 0 {
+		return c
+	}
+
+	rollups := make([]Counter, len(rules))
+	for i, rule := range rules {
+		rollups[i] = r.Registry.Counter(rollupOptions(opts, rule))
+	}
+	return &rollupCounter{Counter: c, rules: rules, rollups: rollups}
+}
+
+// Gauge creates or retrieves a Gauge that also updates its rollups
+func (r *rollupRegistry) Gauge(opts Options) Gauge {
+	g := r.Registry.Gauge(opts)
+	rules := r.rules[opts.Name]
+	if len(rules) == 0 {
+		return g
+	}
+
+	rollups := make([]Gauge, len(rules))
+	for i, rule := range rules {
+		rollups[i] = r.Registry.Gauge(rollupOptions(opts, rule))
+	}
+	return newRollupGauge(g, rules, rollups)
+}
+
+// UpDownCounter creates or retrieves an UpDownCounter that also updates its rollups
+func (r *rollupRegistry) UpDownCounter(opts Options) UpDownCounter {
+	c := r.forwardingRegistry.UpDownCounter(opts)
+	rules := r.rules[opts.Name]
+	if len(rules) == 0 {
+		return c
+	}
+
+	rollups := make([]UpDownCounter, len(rules))
+	for i, rule := range rules {
+		rollups[i] = r.forwardingRegistry.UpDownCounter(rollupOptions(opts, rule))
+	}
+	return &rollupUpDownCounter{UpDownCounter: c, rules: rules, rollups: rollups}
+}
+
+// GaugeFunc creates or retrieves a callback gauge. Callback gauges are read, not
+// updated, so they have no rollups.
+func (r *rollupRegistry) GaugeFunc(opts Options, fn func() float64) Gauge {
+	return r.forwardingRegistry.GaugeFunc(opts, fn)
+}
+
+// Histogram creates or retrieves a Histogram that also updates its rollups
+func (r *rollupRegistry) Histogram(opts Options) Histogram {
+	h := r.Registry.Histogram(opts)
+	rules := r.rules[opts.Name]
+	if len(rules) == 0 {
+		return h
+	}
+
+	rollups := make([]Histogram, len(rules))
+	for i, rule := range rules {
+		rollups[i] = r.Registry.Histogram(rollupOptions(opts, rule))
+	}
+	return &rollupHistogram{Histogram: h, rules: rules, rollups: rollups}
+}
+
+// Timer creates or retrieves a Timer that also updates its rollups
+func (r *rollupRegistry) Timer(opts Options) Timer {
+	t := r.Registry.Timer(opts)
+	rules := r.rules[opts.Name]
+	if len(rules) == 0 {
+		return t
+	}
+
+	rollups := make([]Timer, len(rules))
+	for i, rule := range rules {
+		rollups[i] = r.Registry.Timer(rollupOptions(opts, rule))
+	}
+	return &rollupTimer{Timer: t, rules: rules, rollups: rollups}
+}
+
+// Meter creates or retrieves a Meter that also marks its rollups
+func (r *rollupRegistry) Meter(opts Options) Meter {
+	m := r.forwardingRegistry.Meter(opts)
+	rules := r.rules[opts.Name]
+	if len(rules) == 0 {
+		return m
+	}
+
+	rollups := make([]Meter, len(rules))
+	for i, rule := range rules {
+		rollups[i] = r.forwardingRegistry.Meter(rollupOptions(opts, rule))
+	}
+	return &rollupMeter{Meter: m, rules: rules, rollups: rollups}
+}
+
+// Cardinality creates or retrieves a Cardinality that also observes into its
+// rollups, so they estimate the distinct values of every series they cover
+func (r *rollupRegistry) Cardinality(opts Options) Cardinality {
+	c := r.forwardingRegistry.Cardinality(opts)
+	rules := r.rules[opts.Name]
+	if len(rules) == 0 {
+		return c
+	}
+
+	rollups := make([]Cardinality, len(rules))
+	for i, rule := range rules {
+		rollups[i] = r.forwardingRegistry.Cardinality(rollupOptions(opts, rule))
+	}
+	return &rollupCardinality{Cardinality: c, rules: rules, rollups: rollups}
+}
+
+// rollupCounter updates a detailed counter and its rollups together
+type rollupCounter struct {
+	Counter
+	rules   []RollupRule
+	rollups []Counter
+}
+
+func (c *rollupCounter) Inc() {
+	c.Counter.Inc()
+	for _, rollup := range c.rollups {
+		rollup.Inc()
+	}
+}
+
+func (c *rollupCounter) Add(value float64) {
+	c.Counter.Add(value)
+	for _, rollup := range c.rollups {
+		rollup.Add(value)
+	}
+}
+
+func (c *rollupCounter) With(tags Tags) Counter {
+	rollups := rollupWith(c.rollups, c.rules, tags, func(m Counter, tags Tags) Counter { return m.With(tags) })
+	return &rollupCounter{Counter: c.Counter.With(tags), rules: c.rules, rollups: rollups}
+}
+
+// rollupMeter marks a detailed meter and its rollups together
+type rollupMeter struct {
+	Meter
+	rules   []RollupRule
+	rollups []Meter
+}
+
+func (m *rollupMeter) Mark(n int64) {
+	m.Meter.Mark(n)
+	for _, rollup := range m.rollups {
+		rollup.Mark(n)
+	}
+}
+
+func (m *rollupMeter) With(tags Tags) Meter {
+	rollups := rollupWith(m.rollups, m.rules, tags, func(meter Meter, tags Tags) Meter { return meter.With(tags) })
+	return &rollupMeter{Meter: m.Meter.With(tags), rules: m.rules, rollups: rollups}
+}
+
+// rollupCardinality observes into a detailed cardinality and its rollups together.
+// Reset only forgets the values of the detailed series, since the rollups cover others too.
+type rollupCardinality struct {
+	Cardinality
+	rules   []RollupRule
+	rollups []Cardinality
+}
+
+func (c *rollupCardinality) Observe(value string) {
+	c.Cardinality.Observe(value)
+	for _, rollup := range c.rollups {
+		rollup.Observe(value)
+	}
+}
+
+func (c *rollupCardinality) With(tags Tags) Cardinality {
+	rollups := rollupWith(c.rollups, c.rules, tags, func(m Cardinality, tags Tags) Cardinality { return m.With(tags) })
+	return &rollupCardinality{Cardinality: c.Cardinality.With(tags), rules: c.rules, rollups: rollups}
+}
+
+// rollupUpDownCounter updates a detailed up-down counter and its rollups together
+type rollupUpDownCounter struct {
+	UpDownCounter
+	rules   []RollupRule
+	rollups []UpDownCounter
+}
+
+func (c *rollupUpDownCounter) Add(value float64) {
+	c.UpDownCounter.Add(value)
+	for _, rollup := range c.rollups {
+		rollup.Add(value)
+	}
+}
+
+func (c *rollupUpDownCounter) Inc() {
+	c.Add(1)
+}
+
+func (c *rollupUpDownCounter) Dec() {
+	c.Add(-1)
+}
+
+func (c *rollupUpDownCounter) With(tags Tags) UpDownCounter {
+	rollups := rollupWith(c.rollups, c.rules, tags, func(m UpDownCounter, tags Tags) UpDownCounter { return m.With(tags) })
+	return &rollupUpDownCounter{UpDownCounter: c.UpDownCounter.With(tags), rules: c.rules, rollups: rollups}
+}
+
+// rollupGaugeLocks serialise the writes to each detailed gauge with rollups. Set
+// applies the change from the previous value to the rollups, so no other write
+// to the series may land between reading that value and updating the rollups.
+// Every handle of a series, from any rollup registry, hashes to the same lock.
+var (
+	rollupGaugeLocks [64]sync.Mutex
+	rollupGaugeSeed  = maphash.MakeSeed()
+)
+
+// rollupGaugeLock returns the lock serialising writes to the series of g
+func rollupGaugeLock(g Gauge) *sync.Mutex {
+	key := appendSeriesKey(make([]byte, 0, keyBufferSize), TypeGauge, g.Name(), g.Tags())
+	return &rollupGaugeLocks[maphash.Bytes(rollupGaugeSeed, key)%uint64(len(rollupGaugeLocks))]
+}
+
+// rollupGauge updates a detailed gauge and applies the resulting change to its rollups
+type rollupGauge struct {
+	Gauge
+	rules   []RollupRule
+	rollups []Gauge
+	mu      *sync.Mutex // shared by every handle of the detailed series
+}
+
+func newRollupGauge(g Gauge, rules []RollupRule, rollups []Gauge) *rollupGauge {
+	return &rollupGauge{Gauge: g, rules: rules, rollups: rollups, mu: rollupGaugeLock(g)}
+}
+
+func (g *rollupGauge) Set(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	previous := GaugeValue(g.Gauge)
+	g.Gauge.Set(value)
+	g.addRollups(GaugeValue(g.Gauge) - previous)
+}
+
+func (g *rollupGauge) Add(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.Gauge.Add(value)
+	g.addRollups(value)
+}
+
+func (g *rollupGauge) Inc() {
+	g.Add(1)
+}
+
+func (g *rollupGauge) Dec() {
+	g.Add(-1)
+}
+
+func (g *rollupGauge) addRollups(delta float64) {
+	for _, rollup := range g.rollups {
+		rollup.Add(delta)
+	}
+}
+
+func (g *rollupGauge) With(tags Tags) Gauge {
+	rollups := rollupWith(g.rollups, g.rules, tags, func(m Gauge, tags Tags) Gauge { return m.With(tags) })
+	return newRollupGauge(g.Gauge.With(tags), g.rules, rollups)
+}
+
+// rollupHistogram records into a detailed histogram and its rollups together
+type rollupHistogram struct {
+	Histogram
+	rules   []RollupRule
+	rollups []Histogram
+}
+
+func (h *rollupHistogram) Observe(value float64) {
+	h.Histogram.Observe(value)
+	for _, rollup := range h.rollups {
+		rollup.Observe(value)
+	}
+}
+
+func (h *rollupHistogram) With(tags Tags) Histogram {
+	rollups := rollupWith(h.rollups, h.rules, tags, func(m Histogram, tags Tags) Histogram { return m.With(tags) })
+	return &rollupHistogram{Histogram: h.Histogram.With(tags), rules: h.rules, rollups: rollups}
+}
+
+// rollupTimer records into a detailed timer and its rollups together
+type rollupTimer struct {
+	Timer
+	rules   []RollupRule
+	rollups []Timer
+}
+
+func (t *rollupTimer) Record(d time.Duration) {
+	t.Timer.Record(d)
+	for _, rollup := range t.rollups {
+		rollup.Record(d)
+	}
+}
+
+func (t *rollupTimer) RecordSince(start time.Time) {
+	t.Record(time.Since(start))
+}
+
+func (t *rollupTimer) Time(fn func()) time.Duration {
+	start := time.Now()
+	fn()
+	d := time.Since(start)
+	t.Record(d)
+	return d
+}
+
+func (t *rollupTimer) With(tags Tags) Timer {
+	rollups := rollupWith(t.rollups, t.rules, tags, func(m Timer, tags Tags) Timer { return m.With(tags) })
+	return &rollupTimer{Timer: t.Timer.With(tags), rules: t.rules, rollups: rollups}
+}
@@ -0,0 +1,130 @@
+package metric
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRollupRegistryCounterAndHistogram(t *testing.T) {
+	base := NewNoCleanupRegistry()
+	defer base.Close()
+
+	registry, err := NewRollupRegistry(base,
+		RollupRule{Name: "http_requests_total", Without: []string{"route"}},
+		RollupRule{Name: "auth_latency", Without: []string{"provider"}, As: "auth_latency_all_providers"},
+	)
+	if err != nil {
+		t.Fatalf("NewRollupRegistry: %v", err)
+	}
+
+	requests := registry.Counter(Options{Name: "http_requests_total", Tags: Tags{"route": "/users", "method": "GET"}})
+	requests.Inc()
+	requests.Add(2)
+
+	total := base.Counter(Options{Name: "http_requests_total_all_route", Tags: Tags{"method": "GET"}})
+	if total.Value() != 3 {
+		t.Errorf("Expected rollup counter 3, got %d", total.Value())
+	}
+	if tags := total.Tags(); tags["method"] != "GET" || tags["route"] != "" {
+		t.Errorf("Expected rollup tags to keep method and drop route, got %v", tags)
+	}
+	if requests.Value() != 3 {
+		t.Errorf("Expected detailed counter 3, got %d", requests.Value())
+	}
+
+	latency := registry.Histogram(Options{Name: "auth_latency", Tags: Tags{"provider": "password"}})
+	latency.Observe(0.5)
+	latency.With(Tags{"provider": "oauth"}).Observe(1.5)
+
+	all := base.Histogram(Options{Name: "auth_latency_all_providers"}).Snapshot()
+	if all.Count != 2 || all.Sum != 2 {
+		t.Errorf("Expected rollup histogram count 2 sum 2, got count %d sum %v", all.Count, all.Sum)
+	}
+	if detailed := latency.Snapshot(); detailed.Count != 1 {
+		t.Errorf("Expected detailed histogram count 1, got %d", detailed.Count)
+	}
+}
+
+func TestRollupRegistryGaugeAndTimer(t *testing.T) {
+	base := NewNoCleanupRegistry()
+	defer base.Close()
+
+	registry, err := NewRollupRegistry(base,
+		RollupRule{Name: "queue_depth", Without: []string{"queue"}},
+		RollupRule{Name: "job_duration", Without: []string{"job"}},
+	)
+	if err != nil {
+		t.Fatalf("NewRollupRegistry: %v", err)
+	}
+
+	depth := registry.Gauge(Options{Name: "queue_depth", Tags: Tags{"queue": "emails"}})
+	depth.Set(5)
+	depth.Inc()
+	depth.Set(3)
+
+	if v := base.Gauge(Options{Name: "queue_depth_all_queue"}).Value(); v != 3 {
+		t.Errorf("Expected rollup gauge to track detailed value 3, got %d", v)
+	}
+
+	timer := registry.Timer(Options{Name: "job_duration", Tags: Tags{"job": "resize"}})
+	timer.Record(10 * time.Millisecond)
+	timer.Time(func() {})
+
+	if count := base.Timer(Options{Name: "job_duration_all_job"}).Snapshot().Count; count != 2 {
+		t.Errorf("Expected rollup timer count 2, got %d", count)
+	}
+}
+
+func TestRollupRegistryConcurrentGaugeWrites(t *testing.T) {
+	base := NewNoCleanupRegistry()
+	defer base.Close()
+
+	registry, err := NewRollupRegistry(base, RollupRule{Name: "pool_size", Without: []string{"pool"}})
+	if err != nil {
+		t.Fatalf("NewRollupRegistry: %v", err)
+	}
+
+	// Each goroutine takes its own handles, so the writes only agree through the series
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			g := registry.Gauge(Options{Name: "pool_size", Tags: Tags{"pool": "primary"}})
+			for j := 0; j < 2000; j++ {
+				if j%2 == 0 {
+					g.Set(float64(i + j))
+				} else {
+					g.Inc()
+				}
+				g.With(Tags{"pool": "replica"}).Inc()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	primary := base.Gauge(Options{Name: "pool_size", Tags: Tags{"pool": "primary"}})
+	replica := base.Gauge(Options{Name: "pool_size", Tags: Tags{"pool": "replica"}})
+	want := GaugeValue(primary) + GaugeValue(replica)
+	if got := GaugeValue(base.Gauge(Options{Name: "pool_size_all_pool"})); got != want {
+		t.Errorf("Expected the rollup to equal the sum of its series %v, got %v", want, got)
+	}
+}
+
+func TestRollupRegistryRejectsInvalidRules(t *testing.T) {
+	base := NewNoCleanupRegistry()
+	defer base.Close()
+
+	invalid := [][]RollupRule{
+		{{Without: []string{"route"}}},
+		{{Name: "requests"}},
+		{{Name: "requests", Without: []string{"route"}}, {Name: "requests", Without: []string{"route"}}},
+		{{Name: "requests", Without: []string{"route"}, As: "requests"}},
+	}
+	for i, rules := range invalid {
+		if _, err := NewRollupRegistry(base, rules...); err == nil {
+			t.Errorf("case %d: expected error for rules %+v", i, rules)
+		}
+	}
+}
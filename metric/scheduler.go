@@ -0,0 +1,278 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DropCounter is implemented by reporters that queue data and may drop it,
+// e.g. when the queue is full or the reporter is closed with items pending
+type DropCounter interface {
+	// Dropped returns the number of queued items dropped so far
+	Dropped() uint64
+}
+
+// SchedulerOption configures a Scheduler
+type SchedulerOption func(*Scheduler)
+
+// WithReporter adds a reporter to the scheduler under a backend name used in
+// its shutdown report
+func WithReporter(name string, reporter Reporter) SchedulerOption {
+	return func(s *Scheduler) {
+		s.backends = append(s.backends, &backend{name: name, reporter: reporter})
+	}
+}
+
+// WithShutdownLogger sets the logger the shutdown report is written to
+// (default slog.Default()); nil disables logging
+func WithShutdownLogger(logger *slog.Logger) SchedulerOption {
+	return func(s *Scheduler) {
+		s.logger = logger
+	}
+}
+
+// WithReportTimeout bounds each Report to timeout, so a slow or hung backend
+// cannot hold up the other reporters or Close; the report then fails with
+// context.DeadlineExceeded. See ReportContext for reporters that do not
+// implement ContextReporter.
+func WithReportTimeout(timeout time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.reportTimeout = timeout
+	}
+}
+
+// backend is a reporter and the state of its exports
+type backend struct {
+	name       string
+	reporter   Reporter
+	lastExport time.Time
+	lastErr    error
+	exported   exportState // series values at the last successful export
+	health     backendHealth
+}
+
+// exportState maps series keys to the value read at export time
+type exportState map[string]float64
+
+// Scheduler reports a registry to its reporters on a fixed interval. Close runs
+// a final export, closes the reporters and returns a ShutdownReport of any
+// telemetry that was not exported.
+type Scheduler struct {
+	registry Registry
+	interval time.Duration
+	backends []*backend
+	logger   *slog.Logger
+	clock    Clock
+	// reportTimeout bounds each Report; zero for no bound
+	reportTimeout time.Duration
+	// reporterMetrics registers the backends' health in the registry
+	reporterMetrics bool
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+	closed bool
+}
+
+// NewScheduler creates a Scheduler and starts reporting registry every
+// interval. An interval of zero or less only exports on Close.
+func NewScheduler(registry Registry, interval time.Duration, opts ...SchedulerOption) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		registry: registry,
+		interval: interval,
+		logger:   slog.Default(),
+		clock:    SystemClock,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	for _, b := range s.backends {
+		b.health.stats.Name = b.name
+		if s.reporterMetrics {
+			b.health.metrics = newReporterMetrics(registry, b.name, b.reporter)
+		}
+	}
+
+	if interval > 0 {
+		goroutines.start(&periodicTask{
+			ctx:      ctx,
+			interval: interval,
+			run:      func() bool { s.export(false); return true },
+			done:     func() { close(s.done) },
+		})
+	} else {
+		close(s.done)
+	}
+	return s
+}
+
+// Export reports the registry to every reporter now
+func (s *Scheduler) Export() {
+	s.export(false)
+}
+
+// export reports the registry to every backend, flushing each one, and on
+// shutdown closing it. It returns the state that was exported.
+func (s *Scheduler) export(shutdown bool) exportState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := make(exportState)
+	s.registry.Each(func(m Metric) {
+		if value, _, ok := readValue(m); ok {
+			state[seriesKey(m.Type(), m.Name(), m.Tags())] = value
+		}
+	})
+
+	for _, b := range s.backends {
+		start := s.clock.Now()
+		err := s.report(b.reporter)
+		if err == nil {
+			err = b.reporter.Flush()
+		}
+		b.health.record(b.reporter, start, s.clock.Now(), len(state), err)
+		if err == nil {
+			b.lastExport = s.clock.Now()
+			b.exported = state
+		}
+		if shutdown {
+			err = errors.Join(err, b.reporter.Close())
+		}
+		b.lastErr = err
+	}
+	return state
+}
+
+// report reports the registry to reporter within the report timeout
+func (s *Scheduler) report(reporter Reporter) error {
+	ctx := context.Background()
+	if s.reportTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.reportTimeout)
+		defer cancel()
+	}
+	return ReportContext(ctx, reporter, s.registry)
+}
+
+// BackendShutdown describes what one reporter had exported when the scheduler closed
+type BackendShutdown struct {
+	// Name is the backend name given to WithReporter
+	Name string
+	// LastExport is when the reporter last reported and flushed successfully; zero if never
+	LastExport time.Time
+	// Err is the error from the final report, flush or close, if any
+	Err error
+	// Unexported counts the series that are new or changed since LastExport
+	Unexported int
+	// Dropped counts the items the reporter dropped from its queue, if it implements DropCounter
+	Dropped uint64
+}
+
+// Lost reports whether the backend is missing telemetry
+func (b BackendShutdown) Lost() bool {
+	return b.Unexported > 0 || b.Dropped > 0
+}
+
+// ShutdownReport summarizes the telemetry that was not exported when a Scheduler closed
+type ShutdownReport struct {
+	// Time is when the scheduler closed
+	Time time.Time
+	// Series is the number of series in the registry at shutdown
+	Series int
+	// Backends describes each reporter in the order they were added
+	Backends []BackendShutdown
+}
+
+// Lost reports whether any backend is missing telemetry
+func (r ShutdownReport) Lost() bool {
+	for _, b := range r.Backends {
+		if b.Lost() {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a one-line summary of the report
+func (r ShutdownReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "metrics shutdown: %d series", r.Series)
+	for _, backend := range r.Backends {
+		fmt.Fprintf(&b, "; %s: %d unexported, %d dropped", backend.Name, backend.Unexported, backend.Dropped)
+		if backend.LastExport.IsZero() {
+			b.WriteString(", never exported")
+		} else {
+			fmt.Fprintf(&b, ", last export %s", backend.LastExport.Format(time.RFC3339))
+		}
+		if backend.Err != nil {
+			fmt.Fprintf(&b, ", error: %v", backend.Err)
+		}
+	}
+	return b.String()
+}
+
+// Close stops the schedule, runs a final export, closes every reporter and
+// returns the shutdown report, which is also logged. The error joins the final
+// errors of the reporters. Closing twice returns an empty report.
+func (s *Scheduler) Close() (ShutdownReport, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ShutdownReport{}, nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	s.cancel()
+	<-s.done
+	state := s.export(true)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := ShutdownReport{Time: s.clock.Now(), Series: len(state)}
+	var errs []error
+	for _, b := range s.backends {
+		shutdown := BackendShutdown{
+			Name:       b.name,
+			LastExport: b.lastExport,
+			Err:        b.lastErr,
+			Unexported: unexported(state, b.exported),
+		}
+		if counter, ok := b.reporter.(DropCounter); ok {
+			shutdown.Dropped = counter.Dropped()
+		}
+		if b.lastErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.name, b.lastErr))
+		}
+		report.Backends = append(report.Backends, shutdown)
+	}
+
+	if s.logger != nil {
+		level := slog.LevelInfo
+		if report.Lost() {
+			level = slog.LevelWarn
+		}
+		s.logger.Log(context.Background(), level, report.String())
+	}
+	return report, errors.Join(errs...)
+}
+
+// unexported counts the series of current that are missing from or differ in exported
+func unexported(current, exported exportState) int {
+	n := 0
+	for key, value := range current {
+		if previous, ok := exported[key]; !ok || previous != value {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,124 @@
+package metric
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeReporter counts calls and fails Report while err is set
+type fakeReporter struct {
+	mu      sync.Mutex
+	err     error
+	reports int
+	closed  bool
+	dropped uint64
+}
+
+func (f *fakeReporter) Report(Registry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reports++
+	return f.err
+}
+
+func (f *fakeReporter) Flush() error { return nil }
+
+func (f *fakeReporter) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func (f *fakeReporter) Dropped() uint64 { return f.dropped }
+
+func (f *fakeReporter) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func TestSchedulerShutdownReport(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	healthy := &fakeReporter{}
+	failing := &fakeReporter{dropped: 7}
+	var logs bytes.Buffer
+	scheduler := NewScheduler(registry, 0,
+		WithReporter("healthy", healthy),
+		WithReporter("failing", failing),
+		WithShutdownLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+
+	requests := registry.Counter(Options{Name: "requests_total"})
+	registry.Gauge(Options{Name: "queue_depth"}).Set(3)
+	requests.Inc()
+	scheduler.Export()
+
+	// After the backend starts failing, one series changes and one is added
+	failing.setErr(errors.New("connection refused"))
+	requests.Inc()
+	registry.Counter(Options{Name: "errors_total"}).Inc()
+
+	report, err := scheduler.Close()
+	if err == nil || !strings.Contains(err.Error(), "failing: connection refused") {
+		t.Errorf("Expected the failing backend's error, got %v", err)
+	}
+	if report.Series != 3 || len(report.Backends) != 2 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+
+	h, f := report.Backends[0], report.Backends[1]
+	if h.Lost() || h.Err != nil || h.LastExport.IsZero() {
+		t.Errorf("Expected the healthy backend to have exported everything, got %+v", h)
+	}
+	if f.Unexported != 2 || f.Dropped != 7 || f.LastExport.IsZero() || f.Err == nil {
+		t.Errorf("Expected 2 unexported series and 7 dropped items, got %+v", f)
+	}
+	if !report.Lost() {
+		t.Error("Expected the report to show lost telemetry")
+	}
+	if !healthy.closed || !failing.closed {
+		t.Error("Expected Close to close every reporter")
+	}
+	if out := logs.String(); !strings.Contains(out, "level=WARN") || !strings.Contains(out, "failing: 2 unexported, 7 dropped") {
+		t.Errorf("Expected a warning with the shutdown summary, got %q", out)
+	}
+
+	if again, err := scheduler.Close(); err != nil || len(again.Backends) != 0 {
+		t.Errorf("Expected a second Close to be a no-op, got %+v, %v", again, err)
+	}
+}
+
+func TestSchedulerReportsOnInterval(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	reporter := &fakeReporter{}
+	scheduler := NewScheduler(registry, time.Millisecond, WithReporter("fake", reporter), WithShutdownLogger(nil))
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		reporter.mu.Lock()
+		reports := reporter.reports
+		reporter.mu.Unlock()
+		if reports >= 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	report, err := scheduler.Close()
+	if err != nil || report.Lost() {
+		t.Errorf("Expected a clean shutdown, got %+v, %v", report, err)
+	}
+	if reporter.reports < 3 {
+		t.Errorf("Expected periodic reports plus a final one, got %d", reporter.reports)
+	}
+}
@@ -0,0 +1,117 @@
+package metric
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ScopeContextKey is the context key for the request scope
+const ScopeContextKey ContextKey = "metrics-scope"
+
+// Scope collects tags and counts for one unit of work, typically a request,
+// and records them when the work ends. Tags such as tenant or route are often
+// only known partway through a request; a scope lets each layer add what it
+// knows and records everything with the complete tag set. The methods of a nil
+// Scope do nothing, so code can use ScopeFromContext without checking.
+type Scope struct {
+	registry Registry
+	name     string
+	start    time.Time
+
+	mu     sync.Mutex
+	tags   Tags
+	counts map[string]float64
+	ended  bool
+}
+
+// NewScope starts a scope recording into registry under name and returns it
+// with a context carrying it and the registry. When the scope ends it records
+//
+//	{name}_duration  timer: time from NewScope to End
+//	{name}_total     counter: ended scopes
+//
+// tagged with the scope's tags, plus any counts added with Add.
+func NewScope(ctx context.Context, registry Registry, name string, tags Tags) (context.Context, *Scope) {
+	s := &Scope{
+		registry: registry,
+		name:     name,
+		start:    time.Now(),
+		tags:     copyTags(tags, nil),
+		counts:   make(map[string]float64),
+	}
+	ctx = NewContext(ctx, registry)
+	return context.WithValue(ctx, ScopeContextKey, s), s
+}
+
+// ScopeFromContext returns the scope started with NewScope for ctx, or nil,
+// whose methods do nothing, if there is none
+func ScopeFromContext(ctx context.Context) *Scope {
+	s, _ := ctx.Value(ScopeContextKey).(*Scope)
+	return s
+}
+
+// SetTag adds a tag to everything the scope records, replacing an earlier value for key
+func (s *Scope) SetTag(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags[key] = value
+}
+
+// Tags returns a copy of the scope's tags
+func (s *Scope) Tags() Tags {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyTags(s.tags, nil)
+}
+
+// Add counts delta towards the counter name, recorded with the scope's tags when it ends
+func (s *Scope) Add(name string, delta float64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[name] += delta
+}
+
+// End records the scope's metrics and returns its duration. Only the first call records.
+func (s *Scope) End() time.Duration {
+	if s == nil {
+		return 0
+	}
+	duration := time.Since(s.start)
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return duration
+	}
+	s.ended = true
+	tags := s.tags
+	counts := s.counts
+	s.mu.Unlock()
+
+	s.registry.Timer(Options{
+		Name:        s.name + "_duration",
+		Description: "Duration of " + s.name + " scopes",
+		Unit:        "nanoseconds",
+		Tags:        tags,
+	}).Record(duration)
+	s.registry.Counter(Options{
+		Name:        s.name + "_total",
+		Description: "Ended " + s.name + " scopes",
+		Unit:        "count",
+		Tags:        tags,
+	}).Inc()
+	for name, delta := range counts {
+		s.registry.Counter(Options{Name: name, Tags: tags}).Add(delta)
+	}
+	return duration
+}
@@ -0,0 +1,47 @@
+package metric
+
+import (
+	"context"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	ctx, scope := NewScope(context.Background(), registry, "jobs", Tags{"queue": "emails"})
+	if ScopeFromContext(ctx) != scope {
+		t.Fatal("Expected the scope to be carried by the context")
+	}
+	if r, ok := FromContext(ctx); !ok || r != registry {
+		t.Error("Expected the registry to be carried by the context")
+	}
+
+	ScopeFromContext(ctx).SetTag("tenant", "acme")
+	ScopeFromContext(ctx).Add("jobs_retries_total", 2)
+	scope.End()
+	scope.End()
+
+	tags := Tags{"queue": "emails", "tenant": "acme"}
+	if got := registry.Counter(Options{Name: "jobs_total", Tags: tags}).Value(); got != 1 {
+		t.Errorf("Expected one ended scope, got %d", got)
+	}
+	if got := registry.Timer(Options{Name: "jobs_duration", Tags: tags}).Snapshot().Count; got != 1 {
+		t.Errorf("Expected one recorded duration, got %d", got)
+	}
+	if got := registry.Counter(Options{Name: "jobs_retries_total", Tags: tags}).Value(); got != 2 {
+		t.Errorf("Expected the scope counts to be recorded with its tags, got %d", got)
+	}
+}
+
+func TestNilScope(t *testing.T) {
+	scope := ScopeFromContext(context.Background())
+	if scope != nil {
+		t.Fatal("Expected no scope in an empty context")
+	}
+	scope.SetTag("tenant", "acme")
+	scope.Add("jobs_retries_total", 1)
+	if scope.Tags() != nil || scope.End() != 0 {
+		t.Error("Expected a nil scope to do nothing")
+	}
+}
@@ -0,0 +1,144 @@
+package metric
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SeriesInfo describes one registered series of a metric name
+type SeriesInfo struct {
+	// Metric is the series itself, e.g. to pass its tags to UnregisterWhere
+	Metric Metric
+	// Type is the metric type
+	Type Type
+	// Tags are the series' tags
+	Tags Tags
+	// LastWrite is when the series was last written, accurate to the interval
+	// between Series calls; zero if it was never written or the registry does not track writes
+	LastWrite time.Time
+	// Value is the counter or gauge value, the event count for meters, or the
+	// observation count for histograms and timers
+	Value float64
+	// Snapshot holds the full statistics for histograms and timers, nil otherwise
+	Snapshot *HistogramSnapshot
+}
+
+// writeClockSetter is implemented by metrics whose write stamps a registry's
+// WithClock can take over
+type writeClockSetter interface {
+	setWriteClock(clock Clock)
+}
+
+// lastWriteTracker is implemented by metrics that record when they were last written
+type lastWriteTracker interface {
+	takeLastWrite() time.Time
+}
+
+// LastUpdated returns when m was last written, accurate to the interval
+// between calls to LastUpdated or Series for it. Reading the clock on every
+// write would dominate the cost of an increment, so a write is stamped only if
+// it is the first since the previous call. It returns the zero time for metrics
+// never written, such as gauge functions, and for metrics that do not track writes.
+func LastUpdated(m Metric) time.Time {
+	if tracker, ok := m.(lastWriteTracker); ok {
+		return tracker.takeLastWrite()
+	}
+	return time.Time{}
+}
+
+// SeriesOf returns every series registered under name in registry, from its
+// Series if it is a SeriesRegistry and with CollectSeries otherwise
+func SeriesOf(registry Registry, name string) []SeriesInfo {
+	if series, ok := registry.(SeriesRegistry); ok {
+		return series.Series(name)
+	}
+	return CollectSeries(registry, name)
+}
+
+// CollectSeries returns every series registered under name, sorted by type and
+// tags. SeriesRegistry implementations use it to back Series; it works with
+// any Registry.
+func CollectSeries(registry Registry, name string) []SeriesInfo {
+	var series []SeriesInfo
+	registry.Each(func(m Metric) {
+		if m.Name() != name {
+			return
+		}
+		value, snapshot, ok := readValue(m)
+		if !ok {
+			return
+		}
+
+		info := SeriesInfo{
+			Metric:   m,
+			Type:     m.Type(),
+			Tags:     m.Tags(),
+			Value:    value,
+			Snapshot: snapshot,
+		}
+		info.LastWrite = LastUpdated(m)
+		series = append(series, info)
+	})
+
+	slices.SortFunc(series, func(a, b SeriesInfo) int {
+		if c := strings.Compare(string(a.Type), string(b.Type)); c != 0 {
+			return c
+		}
+		return strings.Compare(canonicalTags(a.Tags), canonicalTags(b.Tags))
+	})
+	return series
+}
+
+// EachOfType calls fn with every metric of type t in registry. SeriesRegistry
+// implementations use it to back EachByType; it works with any Registry.
+func EachOfType(registry Registry, t Type, fn func(Metric)) {
+	registry.Each(func(m Metric) {
+		if m.Type() == t {
+			fn(m)
+		}
+	})
+}
+
+// FindMetric returns the series of name and type t in registry whose tags are
+// exactly tags. SeriesRegistry implementations use it to back Lookup; it works
+// with any Registry.
+func FindMetric(registry Registry, name string, t Type, tags Tags) (Metric, bool) {
+	var found Metric
+	registry.Each(func(m Metric) {
+		if found == nil && m.Name() == name && m.Type() == t && maps.Equal(m.Tags(), tags) {
+			found = m
+		}
+	})
+	return found, found != nil
+}
+
+// readValue returns the current value of m: the counter or gauge value, the
+// event count for meters, or the observation count and snapshot for histograms
+// and timers. ok is false for
+// metrics of unknown types.
+func readValue(m Metric) (value float64, snapshot *HistogramSnapshot, ok bool) {
+	switch v := m.(type) {
+	case Counter:
+		return float64(v.Value()), nil, true
+	case Gauge:
+		return GaugeValue(v), nil, true
+	case UpDownCounter:
+		return float64(v.Value()), nil, true
+	case Histogram:
+		s := v.Snapshot()
+		return float64(s.Count), &s, true
+	case Timer:
+		s := v.Snapshot()
+		return float64(s.Count), &s, true
+	case Meter:
+		return float64(v.Count()), nil, true
+	}
+	return 0, nil, false
+}
+
+// Series implements the Registry interface
+func (r *defaultRegistry) Series(name string) []SeriesInfo {
+	return CollectSeries(r, name)
+}
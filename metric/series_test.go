@@ -0,0 +1,78 @@
+package metric
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistrySeries(t *testing.T) {
+	registry := NewNoCleanupRegistry().(SeriesRegistry)
+	defer registry.Close()
+
+	before := time.Now()
+	requests := registry.Counter(Options{Name: "requests"})
+	requests.With(Tags{"status": "500"}).Add(2)
+	requests.With(Tags{"status": "200"}).Add(5)
+	registry.Gauge(Options{Name: "requests", Tags: Tags{"status": "200"}})
+	registry.Histogram(Options{Name: "latency"}).Observe(1)
+
+	series := registry.Series("requests")
+	if len(series) != 4 {
+		t.Fatalf("Expected 4 series for requests, got %d: %+v", len(series), series)
+	}
+
+	expected := []struct {
+		metricType Type
+		status     string
+		value      float64
+		written    bool
+	}{
+		{TypeCounter, "200", 5, true},
+		{TypeCounter, "500", 2, true},
+		{TypeCounter, "", 0, false},
+		{TypeGauge, "200", 0, false},
+	}
+	for i, want := range expected {
+		got := series[i]
+		if got.Type != want.metricType || got.Tags["status"] != want.status || got.Value != want.value {
+			t.Errorf("Series %d: expected %s status=%q value %v, got %s %v value %v",
+				i, want.metricType, want.status, want.value, got.Type, got.Tags, got.Value)
+		}
+		if written := !got.LastWrite.IsZero(); written != want.written {
+			t.Errorf("Series %d: expected written %v, got last write %v", i, want.written, got.LastWrite)
+		} else if written && got.LastWrite.Before(before.Truncate(time.Microsecond)) {
+			t.Errorf("Series %d: last write %v is before the test started", i, got.LastWrite)
+		}
+	}
+
+	latency := registry.Series("latency")
+	if len(latency) != 1 || latency[0].Snapshot == nil || latency[0].Value != 1 {
+		t.Errorf("Expected one latency series with a snapshot of 1 observation, got %+v", latency)
+	}
+
+	// A series found with Series can be removed on its own
+	bad := series[1].Metric
+	registry.UnregisterWhere(func(m Metric) bool { return m == bad })
+	if remaining := registry.Series("requests"); len(remaining) != 3 {
+		t.Errorf("Expected 3 series after removing status=500, got %d", len(remaining))
+	}
+}
+
+func TestSeriesLastWriteFollowsWrites(t *testing.T) {
+	registry := NewNoCleanupRegistry().(SeriesRegistry)
+	defer registry.Close()
+
+	counter := registry.Counter(Options{Name: "jobs"})
+	counter.Inc()
+	first := registry.Series("jobs")[0].LastWrite
+
+	time.Sleep(2 * time.Millisecond)
+	if again := registry.Series("jobs")[0].LastWrite; !again.Equal(first) {
+		t.Errorf("Expected last write to stay %v without writes, got %v", first, again)
+	}
+
+	counter.Inc()
+	if later := registry.Series("jobs")[0].LastWrite; !later.After(first) {
+		t.Errorf("Expected last write after %v, got %v", first, later)
+	}
+}
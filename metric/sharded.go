@@ -0,0 +1,166 @@
+package metric
+
+import (
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"runtime"
+	"sync/atomic"
+)
+
+// WithCounterShards makes counters created by the registry sharded with n stripes
+// unless their Options set Shards themselves. See Options.Shards.
+func WithCounterShards(n int) RegistryOption {
+	return func(r *defaultRegistry) {
+		r.counterShards = n
+	}
+}
+
+// counterShard is one stripe of a sharded counter, padded to its own cache line
+// so writers on different CPUs do not contend for it
+type counterShard struct {
+	value uint64
+	_     [56]byte
+}
+
+// newCounterShards returns n stripes rounded up to a power of two, or nil when
+// n asks for a plain counter
+func newCounterShards(n int) []counterShard {
+	if n <= 1 {
+		return nil
+	}
+	return make([]counterShard, 1<<bits.Len(uint(n-1)))
+}
+
+// add increases the counter by delta, spreading writes over the shards at random
+func (c *counterImpl) add(delta uint64) {
+	if c.durable != nil {
+		c.durable.add(delta)
+		return
+	}
+	if c.shards == nil {
+		atomic.AddUint64(&c.value, delta)
+		return
+	}
+	i := rand.Uint32() & uint32(len(c.shards)-1)
+	atomic.AddUint64(&c.shards[i].value, delta)
+}
+
+// swap returns the counter value and zeroes it. Each shard is swapped on its own,
+// so an add racing with the swap is counted either now or by the next swap.
+func (c *counterImpl) swap() uint64 {
+	if c.durable != nil {
+		return c.durable.swap()
+	}
+	if c.shards == nil {
+		return atomic.SwapUint64(&c.value, 0)
+	}
+	var total uint64
+	for i := range c.shards {
+		total += atomic.SwapUint64(&c.shards[i].value, 0)
+	}
+	return total
+}
+
+// load returns the counter value, summing the shards of a sharded counter
+func (c *counterImpl) load() uint64 {
+	if c.durable != nil {
+		return c.durable.load()
+	}
+	if c.shards == nil {
+		return atomic.LoadUint64(&c.value)
+	}
+	var total uint64
+	for i := range c.shards {
+		total += atomic.LoadUint64(&c.shards[i].value)
+	}
+	return total
+}
+
+// histogramShard is one CPU's share of a high-contention histogram. Its fields
+// fill one cache line, and the buckets of each shard start on a line of their own.
+type histogramShard struct {
+	count       uint64
+	sum         uint64 // float64 bits
+	min         uint64 // float64 bits, +Inf until the first observation
+	max         uint64 // float64 bits, -Inf until the first observation
+	initialized atomic.Bool
+	buckets     []uint64
+}
+
+// newHistogramShards returns one shard per CPU, rounded up to a power of two,
+// each with n buckets
+func newHistogramShards(n int) []histogramShard {
+	shards := make([]histogramShard, 1<<bits.Len(uint(runtime.GOMAXPROCS(0)-1)))
+	// Whole cache lines of buckets per shard, in one allocation
+	stride := (n + 7) &^ 7
+	backing := make([]uint64, len(shards)*stride)
+	for i := range shards {
+		shards[i].min = math.Float64bits(math.Inf(1))
+		shards[i].max = math.Float64bits(math.Inf(-1))
+		shards[i].buckets = backing[i*stride : i*stride+n : i*stride+n]
+	}
+	return shards
+}
+
+// shard returns a shard of a high-contention histogram to write to, at random
+// as Go does not expose the current CPU
+func (h *histogramImpl) shard() *histogramShard {
+	return &h.shards[rand.Uint32()&uint32(len(h.shards)-1)]
+}
+
+// observe records value, which falls into bucket
+func (s *histogramShard) observe(bucket int, value float64) {
+	atomic.AddUint64(&s.count, 1)
+	addFloat(&s.sum, value)
+	atomic.AddUint64(&s.buckets[bucket], 1)
+	lowerFloat(&s.min, value)
+	raiseFloat(&s.max, value)
+	if !s.initialized.Load() {
+		s.initialized.Store(true)
+	}
+}
+
+// mergeShards adds the observations of a high-contention histogram's shards to
+// snapshot, zeroing them if reset is set
+func (h *histogramImpl) mergeShards(snapshot *HistogramSnapshot, reset bool) {
+	seen := false
+	for i := range h.shards {
+		s := &h.shards[i]
+		load := atomic.LoadUint64
+		if reset {
+			load = func(addr *uint64) uint64 { return atomic.SwapUint64(addr, 0) }
+		}
+		for j := range s.buckets {
+			snapshot.Buckets[j] += load(&s.buckets[j])
+		}
+		snapshot.Count += load(&s.count)
+		snapshot.Sum += math.Float64frombits(load(&s.sum))
+
+		var lowest, highest float64
+		if reset {
+			// As in SnapshotAndReset, clear initialized before min and max
+			if !s.initialized.Swap(false) {
+				continue
+			}
+			lowest = math.Float64frombits(atomic.SwapUint64(&s.min, math.Float64bits(math.Inf(1))))
+			highest = math.Float64frombits(atomic.SwapUint64(&s.max, math.Float64bits(math.Inf(-1))))
+			if math.IsInf(lowest, 1) {
+				continue
+			}
+		} else {
+			if !s.initialized.Load() {
+				continue
+			}
+			lowest = math.Float64frombits(atomic.LoadUint64(&s.min))
+			highest = math.Float64frombits(atomic.LoadUint64(&s.max))
+		}
+		if !seen || lowest < snapshot.Min {
+			snapshot.Min = lowest
+		}
+		if !seen || highest > snapshot.Max {
+			snapshot.Max = highest
+		}
+		seen = true
+	}
+}
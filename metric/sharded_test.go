@@ -0,0 +1,170 @@
+package metric
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedCounter(t *testing.T) {
+	registry := NewNoCleanupRegistry(WithNegativeAddPolicy(NegativeAddClamp))
+	defer registry.Close()
+
+	counter := registry.Counter(Options{Name: "hits", Shards: 6})
+	if shards := len(counter.(*counterImpl).shards); shards != 8 {
+		t.Errorf("Expected 6 shards to round up to 8, got %d", shards)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				counter.Inc()
+			}
+		}()
+	}
+	wg.Wait()
+	counter.Add(500)
+
+	if counter.Value() != 50500 {
+		t.Errorf("Expected 50500, got %d", counter.Value())
+	}
+
+	counter.Add(-50000)
+	if counter.Value() != 500 {
+		t.Errorf("Expected clamped subtraction across shards to leave 500, got %d", counter.Value())
+	}
+
+	child := counter.With(Tags{"route": "/"})
+	if len(child.(*counterImpl).shards) != 8 {
+		t.Error("Expected series derived with With() to be sharded too")
+	}
+}
+
+func TestWithCounterShards(t *testing.T) {
+	registry := NewNoCleanupRegistry(WithCounterShards(4))
+	defer registry.Close()
+
+	if len(registry.Counter(Options{Name: "default"}).(*counterImpl).shards) != 4 {
+		t.Error("Expected registry default of 4 shards")
+	}
+	if registry.Counter(Options{Name: "plain", Shards: 1}).(*counterImpl).shards != nil {
+		t.Error("Expected Shards: 1 to override the registry default with a plain counter")
+	}
+}
+
+// BenchmarkCounterContention compares plain and sharded counters written by
+// many goroutines at once
+func BenchmarkCounterContention(b *testing.B) {
+	for _, bc := range []struct {
+		name   string
+		shards int
+	}{
+		{"plain", 0},
+		{"sharded", 16},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			registry := NewNoCleanupRegistry()
+			defer registry.Close()
+			counter := registry.Counter(Options{Name: "benchmark_contention", Shards: bc.shards})
+
+			b.SetParallelism(128)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					counter.Inc()
+				}
+			})
+		})
+	}
+}
+
+func TestHighContentionHistogram(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(3))
+
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	plain := registry.Histogram(Options{Name: "plain_sizes", Buckets: []float64{10, 100}})
+	sharded := registry.Histogram(Options{Name: "sharded_sizes", Buckets: []float64{10, 100}, HighContention: true})
+	if shards := len(sharded.(*histogramImpl).shards); shards != 4 {
+		t.Errorf("Expected 3 CPUs to round up to 4 shards, got %d", shards)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				value := float64(i*500 + j)
+				plain.Observe(value)
+				sharded.Observe(value)
+			}
+		}(i)
+	}
+	wg.Wait()
+	RecordBatch(func(b *Batch) {
+		b.Observe(plain, -1)
+		b.Observe(sharded, -1)
+	})
+
+	want, got := plain.Snapshot(), sharded.Snapshot()
+	if got.Count != want.Count || got.Sum != want.Sum || got.Min != want.Min || got.Max != want.Max {
+		t.Errorf("Expected merged shards %+v to match %+v", got, want)
+	}
+	for i := range want.Buckets {
+		if got.Buckets[i] != want.Buckets[i] {
+			t.Errorf("Bucket %d: expected %d, got %d", i, want.Buckets[i], got.Buckets[i])
+		}
+	}
+
+	reset := sharded.(*histogramImpl).SnapshotAndReset()
+	if reset.Count != want.Count || reset.Min != -1 || reset.Max != 9999 {
+		t.Errorf("Expected the reset snapshot to hold every observation, got %+v", reset)
+	}
+	if after := sharded.Snapshot(); after.Count != 0 || after.Sum != 0 || after.Min != 0 || after.Max != 0 {
+		t.Errorf("Expected every shard to be reset, got %+v", after)
+	}
+
+	child := sharded.With(Tags{"route": "/"})
+	if len(child.(*histogramImpl).shards) != 4 {
+		t.Error("Expected series derived with With() to be sharded too")
+	}
+	timer := registry.Timer(Options{Name: "sharded_latency", HighContention: true})
+	timer.Record(time.Millisecond)
+	if timer.Snapshot().Count != 1 || timer.(*timerImpl).histogram.(*histogramImpl).shards == nil {
+		t.Error("Expected a high-contention timer to record into shards")
+	}
+}
+
+// BenchmarkHistogramContention compares plain and high-contention histograms
+// observed by a thousand goroutines at once
+func BenchmarkHistogramContention(b *testing.B) {
+	for _, bc := range []struct {
+		name           string
+		highContention bool
+	}{
+		{"plain", false},
+		{"sharded", true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			registry := NewNoCleanupRegistry()
+			defer registry.Close()
+			histogram := registry.Histogram(Options{Name: "benchmark_contention", HighContention: bc.highContention})
+
+			b.SetParallelism(1000 / runtime.GOMAXPROCS(0))
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				value := 0.0
+				for pb.Next() {
+					histogram.Observe(value)
+					value += 0.25
+				}
+			})
+		})
+	}
+}
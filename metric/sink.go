@@ -0,0 +1,37 @@
+package metric
+
+import "time"
+
+// WithObservationSink forwards every write to the registry's metrics to sink.
+// Counters report positive adds only; negative adds are handled by the
+// NegativeAddPolicy. GaugeFunc gauges have no writes and are never reported.
+// The option may be given more than once to attach several sinks.
+func WithObservationSink(sink ObservationSink) RegistryOption {
+	return func(r *defaultRegistry) {
+		switch current := r.sink.(type) {
+		case nil:
+			r.sink = sink
+		case multiSink:
+			r.sink = append(current, sink)
+		default:
+			r.sink = multiSink{current, sink}
+		}
+	}
+}
+
+// multiSink fans observations out to several sinks in order
+type multiSink []ObservationSink
+
+func (s multiSink) Observe(o Observation) {
+	for _, sink := range s {
+		sink.Observe(o)
+	}
+}
+
+// observe passes a write of m to sink, if there is one
+func observe(sink ObservationSink, m Metric, kind ObservationKind, value float64) {
+	if sink == nil {
+		return
+	}
+	sink.Observe(Observation{Metric: m, Kind: kind, Value: value, Time: time.Now()})
+}
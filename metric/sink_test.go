@@ -0,0 +1,84 @@
+package metric
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink collects observations for inspection
+type recordingSink struct {
+	mu           sync.Mutex
+	observations []Observation
+}
+
+func (s *recordingSink) Observe(o Observation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observations = append(s.observations, o)
+}
+
+func (s *recordingSink) all() []Observation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Observation(nil), s.observations...)
+}
+
+func TestObservationSink(t *testing.T) {
+	sink := &recordingSink{}
+	registry := NewNoCleanupRegistry(WithObservationSink(sink)).(InstrumentRegistry)
+	defer registry.Close()
+
+	counter := registry.Counter(Options{Name: "requests_total"})
+	counter.Inc()
+	counter.Add(3)
+	counter.Add(-1) // dropped by the default policy, not reported
+	registry.Gauge(Options{Name: "queue_depth"}).Set(7)
+	registry.UpDownCounter(Options{Name: "in_flight"}).Dec()
+	registry.Histogram(Options{Name: "size_bytes"}).With(Tags{"route": "/a"}).Observe(512)
+	registry.Timer(Options{Name: "latency"}).With(Tags{"route": "/a"}).Record(2 * time.Millisecond)
+
+	expected := []struct {
+		name  string
+		kind  ObservationKind
+		value float64
+	}{
+		{"requests_total", ObservationAdd, 1},
+		{"requests_total", ObservationAdd, 3},
+		{"queue_depth", ObservationSet, 7},
+		{"in_flight", ObservationAdd, -1},
+		{"size_bytes", ObservationSample, 512},
+		{"latency", ObservationSample, float64(2 * time.Millisecond)},
+	}
+
+	observations := sink.all()
+	if len(observations) != len(expected) {
+		t.Fatalf("Expected %d observations, got %d: %+v", len(expected), len(observations), observations)
+	}
+	for i, want := range expected {
+		got := observations[i]
+		if got.Metric.Name() != want.name || got.Kind != want.kind || got.Value != want.value {
+			t.Errorf("Observation %d: expected %s kind %d value %v, got %s kind %d value %v",
+				i, want.name, want.kind, want.value, got.Metric.Name(), got.Kind, got.Value)
+		}
+		if got.Time.IsZero() {
+			t.Errorf("Observation %d has no time", i)
+		}
+	}
+
+	if tags := observations[5].Metric.Tags(); tags["route"] != "/a" || observations[5].Metric.Type() != TypeTimer {
+		t.Errorf("Expected the timer series with route=/a, got %s %v", observations[5].Metric.Type(), tags)
+	}
+}
+
+func TestObservationSinkFanOut(t *testing.T) {
+	first, second := &recordingSink{}, &recordingSink{}
+	registry := NewNoCleanupRegistry(WithObservationSink(first), WithObservationSink(second))
+	defer registry.Close()
+
+	registry.Counter(Options{Name: "events_total"}).With(Tags{"kind": "a"}).Inc()
+
+	if len(first.all()) != 1 || len(second.all()) != 1 {
+		t.Errorf("Expected both sinks to receive the observation, got %d and %d", len(first.all()), len(second.all()))
+	}
+}
@@ -0,0 +1,74 @@
+package metric
+
+import (
+	"maps"
+	"slices"
+	"sync/atomic"
+)
+
+// StateSetAnnotation marks the gauges of a StateSet; its value is the tag key
+// holding the state. Reporters whose backends model enums as a single series,
+// such as OpenTelemetry, export only the current state's gauge, so the state
+// becomes an attribute of one gauge with value 1.
+const StateSetAnnotation = "stateset"
+
+// StateSetTag is the tag key holding the state of each StateSet gauge
+const StateSetTag = "state"
+
+// StateSet records which of a fixed set of mutually exclusive states something
+// is in, such as a circuit breaker's closed, open or half-open. It is exported
+// as one gauge series per state, tagged state=<state>, that is 1 for the
+// current state and 0 for the others, as Prometheus expects of enums.
+type StateSet struct {
+	states  []string
+	current atomic.Int32 // index into states
+}
+
+// NewStateSet registers the gauges of the state set named opts.Name in
+// registry, one per state, starting in the first state. The gauges read the
+// StateSet that registered them, so create one StateSet per name and tags and
+// share it. It panics without states.
+func NewStateSet(registry InstrumentRegistry, opts Options, states ...string) *StateSet {
+	if len(states) == 0 {
+		panic("state set '" + opts.Name + "' requires at least one state")
+	}
+	s := &StateSet{states: slices.Clone(states)}
+
+	opts.Annotations = maps.Clone(opts.Annotations)
+	if opts.Annotations == nil {
+		opts.Annotations = make(map[string]string, 1)
+	}
+	opts.Annotations[StateSetAnnotation] = StateSetTag
+	for i, state := range s.states {
+		stateOpts := opts
+		stateOpts.Tags = maps.Clone(opts.Tags)
+		if stateOpts.Tags == nil {
+			stateOpts.Tags = make(Tags, 1)
+		}
+		stateOpts.Tags[StateSetTag] = state
+		registry.GaugeFunc(stateOpts, func() float64 {
+			if int(s.current.Load()) == i {
+				return 1
+			}
+			return 0
+		})
+	}
+	return s
+}
+
+// Set makes state the current state. States the set was not created with are ignored.
+func (s *StateSet) Set(state string) {
+	if i := slices.Index(s.states, state); i >= 0 {
+		s.current.Store(int32(i))
+	}
+}
+
+// State returns the current state
+func (s *StateSet) State() string {
+	return s.states[s.current.Load()]
+}
+
+// States returns the states of the set in the order they were given
+func (s *StateSet) States() []string {
+	return slices.Clone(s.states)
+}
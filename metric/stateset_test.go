@@ -0,0 +1,50 @@
+package metric
+
+import "testing"
+
+func TestStateSet(t *testing.T) {
+	registry := NewNoCleanupRegistry().(InstrumentRegistry)
+	defer registry.Close()
+
+	breaker := NewStateSet(registry, Options{Name: "breaker_state", Tags: Tags{"dependency": "billing"}}, "closed", "open", "half_open")
+	values := func() map[string]int64 {
+		got := make(map[string]int64)
+		registry.Each(func(m Metric) {
+			if m.Name() != "breaker_state" {
+				return
+			}
+			if m.Tags()["dependency"] != "billing" {
+				t.Errorf("Expected the state gauges to keep their tags, got %v", m.Tags())
+			}
+			if key, ok := AnnotationOf(m, StateSetAnnotation); !ok || key != StateSetTag {
+				t.Errorf("Expected the state set annotation, got %q", key)
+			}
+			got[m.Tags()[StateSetTag]] = m.(Gauge).Value()
+		})
+		return got
+	}
+
+	if got := values(); breaker.State() != "closed" || got["closed"] != 1 || got["open"] != 0 || got["half_open"] != 0 {
+		t.Errorf("Expected the set to start closed, got %s and %v", breaker.State(), got)
+	}
+
+	breaker.Set("open")
+	if got := values(); breaker.State() != "open" || got["closed"] != 0 || got["open"] != 1 || got["half_open"] != 0 {
+		t.Errorf("Expected the set to be open, got %s and %v", breaker.State(), got)
+	}
+
+	breaker.Set("melted")
+	if breaker.State() != "open" {
+		t.Errorf("Expected an unknown state to be ignored, got %s", breaker.State())
+	}
+	if states := breaker.States(); len(states) != 3 || states[2] != "half_open" {
+		t.Errorf("Expected the states in order, got %v", states)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a state set without states to panic")
+		}
+	}()
+	NewStateSet(registry, Options{Name: "empty_state"})
+}
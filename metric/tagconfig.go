@@ -0,0 +1,155 @@
+package metric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate returns an error if any limit of the config is negative
+func (c TagValidationConfig) Validate() error {
+	if c.MaxKeys < 0 || c.MaxKeyLength < 0 || c.MaxValueLength < 0 || c.MaxCardinality < 0 {
+		return fmt.Errorf("tag validation limits must not be negative: %+v", c)
+	}
+	return nil
+}
+
+// UpdateTagValidation implements the Registry interface. The new config applies
+// to series created afterwards; existing series are kept even if they exceed it.
+func (r *defaultRegistry) UpdateTagValidation(config TagValidationConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	config.DisallowedKeys = append([]string(nil), config.DisallowedKeys...)
+	r.tagValidationConfig.Store(&config)
+	return nil
+}
+
+// TagValidator is implemented by registries that can report the tag
+// validation config new series are checked against
+type TagValidator interface {
+	// TagValidation returns the current tag validation config
+	TagValidation() TagValidationConfig
+}
+
+// TagValidation implements the TagValidator interface
+func (r *defaultRegistry) TagValidation() TagValidationConfig {
+	config := *r.tagValidationConfig.Load()
+	config.DisallowedKeys = append([]string(nil), config.DisallowedKeys...)
+	return config
+}
+
+// TagValidationOf returns the tag validation config of registry, or
+// DefaultTagValidationConfig if it does not implement TagValidator
+func TagValidationOf(registry Registry) TagValidationConfig {
+	if validator, ok := registry.(TagValidator); ok {
+		return validator.TagValidation()
+	}
+	return DefaultTagValidationConfig()
+}
+
+// Environment variable suffixes read by TagValidationFromEnv
+const (
+	EnvMaxKeys        = "MAX_KEYS"
+	EnvMaxKeyLength   = "MAX_KEY_LENGTH"
+	EnvMaxValueLength = "MAX_VALUE_LENGTH"
+	EnvMaxCardinality = "MAX_CARDINALITY"
+	EnvDisallowedKeys = "DISALLOWED_KEYS"
+)
+
+// TagValidationFromEnv returns base with the limits set in the environment
+// variables prefix followed by EnvMaxKeys, EnvMaxKeyLength, EnvMaxValueLength,
+// EnvMaxCardinality and EnvDisallowedKeys (comma-separated), e.g.
+// METRICS_MAX_CARDINALITY=5000 for the prefix "METRICS_". Unset variables keep
+// the values of base.
+func TagValidationFromEnv(prefix string, base TagValidationConfig) (TagValidationConfig, error) {
+	config := base
+	for suffix, field := range map[string]*int{
+		EnvMaxKeys:        &config.MaxKeys,
+		EnvMaxKeyLength:   &config.MaxKeyLength,
+		EnvMaxValueLength: &config.MaxValueLength,
+		EnvMaxCardinality: &config.MaxCardinality,
+	} {
+		raw, ok := os.LookupEnv(prefix + suffix)
+		if !ok {
+			continue
+		}
+		v, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return base, fmt.Errorf("%s%s: %w", prefix, suffix, err)
+		}
+		*field = v
+	}
+	if raw, ok := os.LookupEnv(prefix + EnvDisallowedKeys); ok {
+		config.DisallowedKeys = nil
+		for _, key := range strings.Split(raw, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				config.DisallowedKeys = append(config.DisallowedKeys, key)
+			}
+		}
+	}
+	return config, config.Validate()
+}
+
+// LoadTagValidationFile reads a TagValidationConfig from a JSON file whose keys
+// are the field names, e.g. {"MaxCardinality": 5000}. Fields the file leaves
+// out keep the values of DefaultTagValidationConfig.
+func LoadTagValidationFile(path string) (TagValidationConfig, error) {
+	config := DefaultTagValidationConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("tag validation config %s: %w", path, err)
+	}
+	return config, config.Validate()
+}
+
+// WatchTagValidationFile applies the config in path (see LoadTagValidationFile)
+// to registry and reapplies it whenever the file changes, checking every
+// interval until ctx is done. A config that fails to load or apply leaves the
+// current one in place and is passed to onError, which may be nil; only the
+// initial load is returned as an error.
+func WatchTagValidationFile(ctx context.Context, registry ConfigurableRegistry, path string, interval time.Duration, onError func(error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got %s", interval)
+	}
+
+	var last os.FileInfo
+	reload := func() error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+			return nil
+		}
+		last = info
+
+		config, err := LoadTagValidationFile(path)
+		if err != nil {
+			return err
+		}
+		return registry.UpdateTagValidation(config)
+	}
+	if err := reload(); err != nil {
+		return err
+	}
+
+	goroutines.start(&periodicTask{
+		ctx:      ctx,
+		interval: interval,
+		run: func() bool {
+			if err := reload(); err != nil && onError != nil {
+				onError(err)
+			}
+			return true
+		},
+	})
+	return nil
+}
@@ -0,0 +1,109 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpdateTagValidation(t *testing.T) {
+	config := DefaultTagValidationConfig()
+	config.MaxCardinality = 1
+	registry := NewRegistry(config, 0).(ConfigurableRegistry)
+	defer registry.Close()
+
+	requests := registry.Counter(Options{Name: "requests_total", Tags: Tags{"route": "/a"}})
+	if !panicsWith(func() { requests.With(Tags{"route": "/b"}) }, ErrCardinalityExceeded) {
+		t.Fatal("Expected the initial limit to reject a second series")
+	}
+
+	config.MaxCardinality = 2
+	if err := registry.UpdateTagValidation(config); err != nil {
+		t.Fatal(err)
+	}
+	requests.With(Tags{"route": "/b"}).Inc()
+
+	config.MaxCardinality = -1
+	if err := registry.UpdateTagValidation(config); err == nil {
+		t.Error("Expected a negative limit to be rejected")
+	}
+}
+
+// panicsWith reports whether fn panics with an error matching target
+func panicsWith(fn func(), target error) (matched bool) {
+	defer func() {
+		err, ok := recover().(error)
+		matched = ok && errors.Is(err, target)
+	}()
+	fn()
+	return false
+}
+
+func TestTagValidationFromEnv(t *testing.T) {
+	t.Setenv("METRICS_MAX_CARDINALITY", "5000")
+	t.Setenv("METRICS_DISALLOWED_KEYS", "session_id, ip")
+
+	config, err := TagValidationFromEnv("METRICS_", DefaultTagValidationConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MaxCardinality != 5000 || config.MaxKeys != DefaultTagValidationConfig().MaxKeys {
+		t.Errorf("Expected MaxCardinality from the environment and other limits kept, got %+v", config)
+	}
+	if len(config.DisallowedKeys) != 2 || config.DisallowedKeys[1] != "ip" {
+		t.Errorf("Expected the disallowed keys to be split, got %v", config.DisallowedKeys)
+	}
+
+	t.Setenv("METRICS_MAX_KEYS", "many")
+	if _, err := TagValidationFromEnv("METRICS_", DefaultTagValidationConfig()); err == nil {
+		t.Error("Expected an invalid number to fail")
+	}
+}
+
+func TestWatchTagValidationFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tags.json")
+	if err := os.WriteFile(path, []byte(`{"MaxCardinality": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewNoCleanupRegistry().(ConfigurableRegistry)
+	defer registry.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 10)
+	if err := WatchTagValidationFile(ctx, registry, path, time.Millisecond, func(err error) { errs <- err }); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := registry.Counter(Options{Name: "jobs_total", Tags: Tags{"queue": "a"}})
+	if !panicsWith(func() { jobs.With(Tags{"queue": "b"}) }, ErrCardinalityExceeded) {
+		t.Fatal("Expected the file's limit to be applied")
+	}
+
+	// Rewrite with a different size so the change is seen even on coarse mtimes
+	if err := os.WriteFile(path, []byte(`{"MaxCardinality": 100}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for panicsWith(func() { jobs.With(Tags{"queue": "b"}) }, ErrCardinalityExceeded) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the raised limit to be reloaded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"MaxCardinality": `), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-errs:
+	case <-time.After(time.Second):
+		t.Error("Expected a broken file to be reported")
+	}
+	if err := WatchTagValidationFile(ctx, registry, path, time.Millisecond, nil); err == nil {
+		t.Error("Expected the initial load of a broken file to fail")
+	}
+}
@@ -0,0 +1,141 @@
+package metric
+
+import (
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// TagProcessor rewrites the value of the tag key before a series is looked up,
+// so differently written values map to one series and sensitive values never
+// reach a backend
+type TagProcessor func(key, value string) string
+
+// WithTagProcessors runs every tag value through processors, in order, before
+// the registry validates tags and looks up the series, including tags added
+// with With(). Processors should be idempotent, since derived series pass
+// through them again.
+func WithTagProcessors(processors ...TagProcessor) RegistryOption {
+	return func(r *defaultRegistry) {
+		r.tagProcessors = append(r.tagProcessors, processors...)
+	}
+}
+
+// processTags returns tags with every processor applied, or tags itself if
+// there are no processors or nothing changed
+func (r *defaultRegistry) processTags(tags Tags) Tags {
+	if len(r.tagProcessors) == 0 || len(tags) == 0 {
+		return tags
+	}
+
+	var processed Tags
+	for key, value := range tags {
+		rewritten := value
+		for _, process := range r.tagProcessors {
+			rewritten = process(key, rewritten)
+		}
+		if rewritten == value {
+			continue
+		}
+		if processed == nil {
+			processed = copyTags(tags, nil)
+		}
+		processed[key] = rewritten
+	}
+	if processed == nil {
+		return tags
+	}
+	return processed
+}
+
+// LowercaseTags lowercases tag values, so "GET" and "get" are one series
+func LowercaseTags() TagProcessor {
+	return func(key, value string) string {
+		return strings.ToLower(value)
+	}
+}
+
+// TrimTagSpace removes leading and trailing whitespace from tag values
+func TrimTagSpace() TagProcessor {
+	return func(key, value string) string {
+		return strings.TrimSpace(value)
+	}
+}
+
+// TruncateTags shortens tag values to at most n characters, for use with a
+// TagValidationConfig.MaxValueLength of at least n so long values are cut
+// instead of rejected
+func TruncateTags(n int) TagProcessor {
+	return func(key, value string) string {
+		if utf8.RuneCountInString(value) <= n {
+			return value
+		}
+		return string([]rune(value)[:n])
+	}
+}
+
+// RedactedValue replaces the values redaction processors remove
+const RedactedValue = "[redacted]"
+
+// RedactTags replaces the whole value of the given keys, such as session_id,
+// with RedactedValue
+func RedactTags(keys ...string) TagProcessor {
+	return func(key, value string) string {
+		if slices.Contains(keys, key) {
+			return RedactedValue
+		}
+		return value
+	}
+}
+
+// RedactPattern replaces every match of pattern in tag values with RedactedValue
+func RedactPattern(pattern *regexp.Regexp) TagProcessor {
+	return func(key, value string) string {
+		return pattern.ReplaceAllLiteralString(value, RedactedValue)
+	}
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// ipCandidate matches runs that may hold an address; redactIP parses them
+	ipCandidate = regexp.MustCompile(`[0-9A-Fa-f:.]*[:.][0-9A-Fa-f:.]*`)
+)
+
+// RedactEmails replaces email addresses in tag values with RedactedValue
+func RedactEmails() TagProcessor {
+	return RedactPattern(emailPattern)
+}
+
+// RedactIPs replaces IPv4 and IPv6 addresses in tag values with RedactedValue,
+// keeping any port
+func RedactIPs() TagProcessor {
+	return func(key, value string) string {
+		return ipCandidate.ReplaceAllStringFunc(value, redactIP)
+	}
+}
+
+// redactIP redacts candidate if it is an address, optionally with a port and
+// followed by a full stop
+func redactIP(candidate string) string {
+	trimmed := strings.TrimRight(candidate, ".")
+	suffix := candidate[len(trimmed):]
+	if net.ParseIP(trimmed) != nil {
+		return RedactedValue + suffix
+	}
+	if host, port, err := net.SplitHostPort(trimmed); err == nil && net.ParseIP(host) != nil {
+		return RedactedValue + ":" + port + suffix
+	}
+	return candidate
+}
+
+// ForTagKeys limits processor to the given tag keys
+func ForTagKeys(processor TagProcessor, keys ...string) TagProcessor {
+	return func(key, value string) string {
+		if slices.Contains(keys, key) {
+			return processor(key, value)
+		}
+		return value
+	}
+}
@@ -0,0 +1,65 @@
+package metric
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestTagProcessors(t *testing.T) {
+	registry := NewRegistry(DefaultTagValidationConfig(), 0, WithTagProcessors(
+		TrimTagSpace(),
+		ForTagKeys(LowercaseTags(), "method"),
+		RedactTags("session_id"),
+		RedactEmails(),
+		RedactIPs(),
+		TruncateTags(12),
+	))
+	defer registry.Close()
+
+	registry.Counter(Options{Name: "logins_total", Tags: Tags{"method": " POST "}}).Inc()
+	registry.Counter(Options{Name: "logins_total", Tags: Tags{"method": "post"}}).Inc()
+	if got := registry.Counter(Options{Name: "logins_total", Tags: Tags{"method": "post"}}).Value(); got != 2 {
+		t.Errorf("Expected normalized methods to share a series, got %d", got)
+	}
+
+	counter := registry.Counter(Options{Name: "auth_total"}).With(Tags{
+		"session_id": "abc123",
+		"user":       "jane@example.com",
+		"ip":         "10.0.0.1",
+		"peer":       "[::1]:8443",
+		"agent":      "Mozilla/5.0 (X11; Linux)",
+	})
+	want := Tags{
+		"session_id": RedactedValue,
+		"user":       RedactedValue,
+		"ip":         RedactedValue,
+		"peer":       "[" + RedactedValue + "]",
+		"agent":      "Mozilla/5.0 ",
+	}
+	if got := canonicalTags(counter.Tags()); got != canonicalTags(want) {
+		t.Errorf("Expected processed tags %s, got %s", canonicalTags(want), got)
+	}
+
+	// Histogram families process the tags of derived series too
+	latency := registry.Timer(Options{Name: "auth_duration"}).With(Tags{"ip": "192.168.1.20"})
+	latency.Record(time.Millisecond)
+	if got := latency.Tags()["ip"]; got != RedactedValue {
+		t.Errorf("Expected the timer child's ip to be redacted, got %q", got)
+	}
+}
+
+func TestRedactPattern(t *testing.T) {
+	redact := RedactPattern(regexp.MustCompile(`\d{4}-\d{4}`))
+	if got := redact("card", "card 1234-5678 used"); got != "card "+RedactedValue+" used" {
+		t.Errorf("Expected the match to be redacted, got %q", got)
+	}
+	for _, value := range []string{"v1.2.3", "10:30:00", "checkout"} {
+		if got := RedactIPs()("version", value); got != value {
+			t.Errorf("Expected %q to be kept, got %q", value, got)
+		}
+	}
+	if got := RedactIPs()("peer", "from 10.1.2.3:443."); got != "from "+RedactedValue+":443." {
+		t.Errorf("Expected the address to be redacted and the port kept, got %q", got)
+	}
+}
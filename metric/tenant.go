@@ -0,0 +1,378 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultTenantTag is the tag ForTenant namespaces metrics by when the registry
+// was not created with WithTenantBudget
+const DefaultTenantTag = "tenant"
+
+// ErrTenantBudgetExceeded is the error a registry panics with when a new series
+// would exceed its tenant's budget
+var ErrTenantBudgetExceeded = errors.New("tenant budget exceeded")
+
+// TenantBudget limits what one tenant may register. Zero fields are unlimited.
+type TenantBudget struct {
+	// MaxSeries is the number of series the tenant may have across all metric names
+	MaxSeries int
+	// MaxTags is the number of tags per series, not counting the tenant tag
+	MaxTags int
+}
+
+// tenantLimits holds the budgets and series counts of each tenant; guarded by the registry's mu
+type tenantLimits struct {
+	tag     string
+	budget  TenantBudget
+	budgets map[string]TenantBudget // overrides set with SetTenantBudget
+	series  map[string]int
+}
+
+// WithTenantBudget makes the registry account series by the value of their tag
+// tag, such as "tenant", and hold every tenant to budget independently of the
+// others, so one tenant's high-cardinality tags cannot exhaust the limits the
+// rest rely on. Series without the tag are not limited by it. Budgets of single
+// tenants can be changed with SetTenantBudget.
+func WithTenantBudget(tag string, budget TenantBudget) RegistryOption {
+	return func(r *defaultRegistry) {
+		r.tenants = &tenantLimits{
+			tag:     tag,
+			budget:  budget,
+			budgets: make(map[string]TenantBudget),
+			series:  make(map[string]int),
+		}
+	}
+}
+
+// TenantRegistry is implemented by registries created with WithTenantBudget
+type TenantRegistry interface {
+	Registry
+	// TenantTag returns the tag identifying the tenant of a series
+	TenantTag() string
+	// SetTenantBudget overrides the registry's budget for tenant
+	SetTenantBudget(tenant string, budget TenantBudget)
+	// TenantSeries returns the number of series registered for tenant
+	TenantSeries(tenant string) int
+	// OffboardTenant removes every series of tenant, as UnregisterWhere does,
+	// drops its budget override and returns the number of series removed
+	OffboardTenant(tenant string) int
+}
+
+// checkTenant panics if registering a series with tags would exceed its tenant's
+// budget. The caller must hold r.mu.
+func (r *defaultRegistry) checkTenant(name string, tags Tags) {
+	tenant, ok := tags[r.tenants.tag]
+	if !ok {
+		return
+	}
+
+	budget, ok := r.tenants.budgets[tenant]
+	if !ok {
+		budget = r.tenants.budget
+	}
+	var err error
+	switch {
+	case budget.MaxTags > 0 && len(tags)-1 > budget.MaxTags:
+		err = fmt.Errorf("%w for tenant '%s': metric '%s' has %d tags, limit %d",
+			ErrTenantBudgetExceeded, tenant, name, len(tags)-1, budget.MaxTags)
+	case budget.MaxSeries > 0 && r.tenants.series[tenant] >= budget.MaxSeries:
+		err = fmt.Errorf("%w for tenant '%s': %d >= %d series",
+			ErrTenantBudgetExceeded, tenant, r.tenants.series[tenant], budget.MaxSeries)
+	default:
+		return
+	}
+
+	if r.meta != nil {
+		r.meta.tenantRejections.Inc()
+	}
+	panic(err)
+}
+
+// countTenant adjusts the series count of the tenant of tags by delta. The caller must hold r.mu.
+func (r *defaultRegistry) countTenant(tags Tags, delta int) {
+	tenant, ok := tags[r.tenants.tag]
+	if !ok {
+		return
+	}
+	r.tenants.series[tenant] += delta
+	if r.tenants.series[tenant] <= 0 {
+		delete(r.tenants.series, tenant)
+	}
+}
+
+// TenantTag implements the TenantRegistry interface
+func (r *defaultRegistry) TenantTag() string {
+	if r.tenants == nil {
+		return DefaultTenantTag
+	}
+	return r.tenants.tag
+}
+
+// SetTenantBudget implements the TenantRegistry interface. Series already
+// registered are kept when the budget shrinks below them.
+func (r *defaultRegistry) SetTenantBudget(tenant string, budget TenantBudget) {
+	if r.tenants == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tenants.budgets[tenant] = budget
+}
+
+// TenantSeries implements the TenantRegistry interface
+func (r *defaultRegistry) TenantSeries(tenant string) int {
+	if r.tenants == nil {
+		return 0
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tenants.series[tenant]
+}
+
+// OffboardTenant implements the TenantRegistry interface
+func (r *defaultRegistry) OffboardTenant(tenant string) int {
+	tag := r.TenantTag()
+	removed := 0
+	r.UnregisterWhere(func(m Metric) bool {
+		if m.Tags()[tag] != tenant {
+			return false
+		}
+		removed++
+		return true
+	})
+
+	if r.tenants != nil {
+		r.mu.Lock()
+		delete(r.tenants.budgets, tenant)
+		r.mu.Unlock()
+	}
+	return removed
+}
+
+// tenantRegistry is the view of one tenant returned by ForTenant
+type tenantRegistry struct {
+	forwardingRegistry
+	tag    string
+	tenant string
+}
+
+// ForTenant returns a view of registry for one tenant. Metrics created through
+// it carry the tenant tag (the registry's TenantTag, or DefaultTenantTag), which
+// their With keeps even when given another value for it, and Each, Series,
+// Watch, WatchThreshold, OnExpire and the Unregister methods only see the
+// tenant's series. The view shares the registry, so closing it does nothing;
+// Pause, Resume, SetMetadata and UpdateTagValidation still apply to the whole
+// registry. The view implements the optional registry interfaces registry
+// implements.
+func ForTenant(registry Registry, tenant string) Registry {
+	tag := DefaultTenantTag
+	if tenants, ok := registry.(TenantRegistry); ok {
+		tag = tenants.TenantTag()
+	}
+	return expose(&tenantRegistry{forwardingRegistry: forwardingRegistry{registry}, tag: tag, tenant: tenant}, registry)
+}
+
+// options adds the tenant tag to opts
+func (t *tenantRegistry) options(opts Options) Options {
+	opts.Tags = copyTags(opts.Tags, Tags{t.tag: t.tenant})
+	return opts
+}
+
+// owns reports whether m belongs to the tenant
+func (t *tenantRegistry) owns(m Metric) bool {
+	return m.Tags()[t.tag] == t.tenant
+}
+
+func (t *tenantRegistry) Counter(opts Options) Counter {
+	return &tenantCounter{t.Registry.Counter(t.options(opts)), t.pin()}
+}
+
+func (t *tenantRegistry) Gauge(opts Options) Gauge {
+	return &tenantGauge{t.Registry.Gauge(t.options(opts)), t.pin()}
+}
+
+func (t *tenantRegistry) GaugeFunc(opts Options, fn func() float64) Gauge {
+	return &tenantGauge{t.forwardingRegistry.GaugeFunc(t.options(opts), fn), t.pin()}
+}
+
+func (t *tenantRegistry) Derived(opts Options, fn func(SnapshotView) float64) Gauge {
+	return &tenantGauge{t.forwardingRegistry.Derived(t.options(opts), fn), t.pin()}
+}
+
+func (t *tenantRegistry) UpDownCounter(opts Options) UpDownCounter {
+	return &tenantUpDownCounter{t.forwardingRegistry.UpDownCounter(t.options(opts)), t.pin()}
+}
+
+func (t *tenantRegistry) Histogram(opts Options) Histogram {
+	return &tenantHistogram{t.Registry.Histogram(t.options(opts)), t.pin()}
+}
+
+func (t *tenantRegistry) Timer(opts Options) Timer {
+	return &tenantTimer{t.Registry.Timer(t.options(opts)), t.pin()}
+}
+
+func (t *tenantRegistry) Meter(opts Options) Meter {
+	return &tenantMeter{t.forwardingRegistry.Meter(t.options(opts)), t.pin()}
+}
+
+func (t *tenantRegistry) Cardinality(opts Options) Cardinality {
+	return &tenantCardinality{t.forwardingRegistry.Cardinality(t.options(opts)), t.pin()}
+}
+
+func (t *tenantRegistry) pin() tenantPin {
+	return tenantPin{tag: t.tag, tenant: t.tenant}
+}
+
+// tenantPin keeps the handles of a tenant view in the tenant: With cannot
+// replace the tenant tag, so a handle never creates another tenant's series
+type tenantPin struct {
+	tag, tenant string
+}
+
+// tags returns tags with the tenant tag, if present, set to the tenant
+func (p tenantPin) tags(tags Tags) Tags {
+	if tenant, ok := tags[p.tag]; !ok || tenant == p.tenant {
+		return tags
+	}
+	return copyTags(tags, Tags{p.tag: p.tenant})
+}
+
+type tenantCounter struct {
+	Counter
+	pin tenantPin
+}
+
+func (c *tenantCounter) With(tags Tags) Counter {
+	return &tenantCounter{c.Counter.With(c.pin.tags(tags)), c.pin}
+}
+
+type tenantGauge struct {
+	Gauge
+	pin tenantPin
+}
+
+func (g *tenantGauge) With(tags Tags) Gauge {
+	return &tenantGauge{g.Gauge.With(g.pin.tags(tags)), g.pin}
+}
+
+type tenantUpDownCounter struct {
+	UpDownCounter
+	pin tenantPin
+}
+
+func (c *tenantUpDownCounter) With(tags Tags) UpDownCounter {
+	return &tenantUpDownCounter{c.UpDownCounter.With(c.pin.tags(tags)), c.pin}
+}
+
+type tenantHistogram struct {
+	Histogram
+	pin tenantPin
+}
+
+func (h *tenantHistogram) With(tags Tags) Histogram {
+	return &tenantHistogram{h.Histogram.With(h.pin.tags(tags)), h.pin}
+}
+
+type tenantTimer struct {
+	Timer
+	pin tenantPin
+}
+
+func (t *tenantTimer) With(tags Tags) Timer {
+	return &tenantTimer{t.Timer.With(t.pin.tags(tags)), t.pin}
+}
+
+type tenantMeter struct {
+	Meter
+	pin tenantPin
+}
+
+func (m *tenantMeter) With(tags Tags) Meter {
+	return &tenantMeter{m.Meter.With(m.pin.tags(tags)), m.pin}
+}
+
+type tenantCardinality struct {
+	Cardinality
+	pin tenantPin
+}
+
+func (c *tenantCardinality) With(tags Tags) Cardinality {
+	return &tenantCardinality{c.Cardinality.With(c.pin.tags(tags)), c.pin}
+}
+
+// Unregister removes the tenant's series of name. Registries that cannot remove
+// single series keep them, since removing the name would affect other tenants.
+func (t *tenantRegistry) Unregister(name string) {
+	if _, ok := t.Registry.(SeriesRegistry); ok {
+		t.UnregisterWhere(func(m Metric) bool { return m.Name() == name })
+	}
+}
+
+func (t *tenantRegistry) UnregisterMetric(name string, metricType Type) {
+	t.UnregisterWhere(func(m Metric) bool { return m.Name() == name && m.Type() == metricType })
+}
+
+func (t *tenantRegistry) UnregisterWhere(match func(Metric) bool) {
+	t.forwardingRegistry.UnregisterWhere(func(m Metric) bool { return t.owns(m) && match(m) })
+}
+
+func (t *tenantRegistry) UnregisterPrefix(prefix string) {
+	t.UnregisterWhere(func(m Metric) bool { return strings.HasPrefix(m.Name(), prefix) })
+}
+
+func (t *tenantRegistry) Each(fn func(Metric)) {
+	t.Registry.Each(func(m Metric) {
+		if t.owns(m) {
+			fn(m)
+		}
+	})
+}
+
+func (t *tenantRegistry) EachByType(metricType Type, fn func(Metric)) {
+	EachOfType(t, metricType, fn)
+}
+
+// Lookup returns the series of name and type created through the view without tags
+func (t *tenantRegistry) Lookup(name string, metricType Type) (Metric, bool) {
+	return FindMetric(t.Registry, name, metricType, Tags{t.tag: t.tenant})
+}
+
+func (t *tenantRegistry) Series(name string) []SeriesInfo {
+	var series []SeriesInfo
+	for _, s := range SeriesOf(t.Registry, name) {
+		if s.Tags[t.tag] == t.tenant {
+			series = append(series, s)
+		}
+	}
+	return series
+}
+
+func (t *tenantRegistry) Watch(ctx context.Context, filter WatchFilter) (<-chan MetricUpdate, error) {
+	match := filter.Match
+	filter.Match = func(m Metric) bool { return t.owns(m) && (match == nil || match(m)) }
+	return t.forwardingRegistry.Watch(ctx, filter)
+}
+
+func (t *tenantRegistry) WatchThreshold(ctx context.Context, name string, condition Condition, fn func(ThresholdEvent)) error {
+	return t.forwardingRegistry.WatchThreshold(ctx, name, condition, func(e ThresholdEvent) {
+		if e.Tags[t.tag] == t.tenant {
+			fn(e)
+		}
+	})
+}
+
+func (t *tenantRegistry) OnExpire(fn func(Metric)) {
+	t.forwardingRegistry.OnExpire(func(m Metric) {
+		if t.owns(m) {
+			fn(m)
+		}
+	})
+}
+
+// Close does nothing; the underlying registry is shared with other tenants
+func (t *tenantRegistry) Close() error {
+	return nil
+}
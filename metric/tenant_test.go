@@ -0,0 +1,123 @@
+package metric
+
+import (
+	"errors"
+	"testing"
+)
+
+// expectTenantPanic runs fn and reports whether it panicked with ErrTenantBudgetExceeded
+func expectTenantPanic(fn func()) (exceeded bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(error)
+			exceeded = ok && errors.Is(err, ErrTenantBudgetExceeded)
+		}
+	}()
+	fn()
+	return false
+}
+
+func TestTenantBudgets(t *testing.T) {
+	registry := NewRegistry(DefaultTagValidationConfig(), 0,
+		WithTenantBudget("tenant", TenantBudget{MaxSeries: 2, MaxTags: 1}), WithMetaMetrics())
+	defer registry.Close()
+	tenants := registry.(TenantRegistry)
+
+	acme := ForTenant(registry, "acme")
+	requests := acme.Counter(Options{Name: "requests_total"})
+	requests.With(Tags{"route": "/a"}).Inc()
+	if got := tenants.TenantSeries("acme"); got != 2 {
+		t.Fatalf("Expected 2 series for acme, got %d", got)
+	}
+
+	// Series derived with With count towards the budget too
+	if !expectTenantPanic(func() { requests.With(Tags{"route": "/b"}) }) {
+		t.Error("Expected a third series to exceed acme's budget")
+	}
+	if !expectTenantPanic(func() { acme.Gauge(Options{Name: "queue_depth", Tags: Tags{"a": "1", "b": "2"}}) }) {
+		t.Error("Expected two tags besides the tenant to exceed acme's tag budget")
+	}
+	if v := metaValues(registry)[MetaMetricPrefix+"rejections_total{reason=tenant}"]; v != 2 {
+		t.Errorf("Expected 2 tenant rejections, got %v", v)
+	}
+
+	// Other tenants and untagged series have their own budgets
+	globex := ForTenant(registry, "globex")
+	globex.Counter(Options{Name: "requests_total"}).With(Tags{"route": "/a"}).Inc()
+	registry.Counter(Options{Name: "uptime_total"})
+	registry.Counter(Options{Name: "builds_total", Tags: Tags{"a": "1", "b": "2"}})
+
+	tenants.SetTenantBudget("acme", TenantBudget{MaxSeries: 3})
+	requests.With(Tags{"route": "/b"}).Inc()
+
+	var seen int
+	acme.Each(func(m Metric) {
+		seen++
+		if m.Tags()["tenant"] != "acme" {
+			t.Errorf("Expected acme's view to only see its series, got %s %v", m.Name(), m.Tags())
+		}
+	})
+	if seen != 3 || len(acme.(SeriesRegistry).Series("requests_total")) != 3 {
+		t.Errorf("Expected 3 acme series, got %d", seen)
+	}
+
+	acme.Unregister("requests_total")
+	if got := tenants.TenantSeries("acme"); got != 0 {
+		t.Errorf("Expected unregistering through the view to release acme's budget, got %d series", got)
+	}
+	if got := len(globex.(SeriesRegistry).Series("requests_total")); got != 2 {
+		t.Errorf("Expected globex's series to be kept, got %d", got)
+	}
+}
+
+func TestOffboardTenant(t *testing.T) {
+	registry := NewRegistry(DefaultTagValidationConfig(), 0, WithTenantBudget("org", TenantBudget{MaxSeries: 1}))
+	defer registry.Close()
+	tenants := registry.(TenantRegistry)
+
+	initech := ForTenant(registry, "initech")
+	initech.Counter(Options{Name: "jobs_total"}).Inc()
+	tenants.SetTenantBudget("initech", TenantBudget{MaxSeries: 5})
+	initech.Gauge(Options{Name: "queue_depth"}).Set(1)
+	ForTenant(registry, "hooli").Counter(Options{Name: "jobs_total"}).Inc()
+
+	if removed := tenants.OffboardTenant("initech"); removed != 2 {
+		t.Errorf("Expected 2 series to be removed, got %d", removed)
+	}
+	if got := tenants.TenantSeries("initech"); got != 0 {
+		t.Errorf("Expected no series left for initech, got %d", got)
+	}
+	if got := tenants.TenantSeries("hooli"); got != 1 {
+		t.Errorf("Expected hooli to be kept, got %d series", got)
+	}
+
+	// The override is dropped, so a returning tenant gets the default budget
+	initech.Counter(Options{Name: "jobs_total"})
+	if !expectTenantPanic(func() { initech.Gauge(Options{Name: "queue_depth"}) }) {
+		t.Error("Expected the default budget after offboarding")
+	}
+}
+
+func TestTenantHandlesKeepTenantTag(t *testing.T) {
+	registry := NewRegistry(DefaultTagValidationConfig(), 0, WithTenantBudget("tenant", TenantBudget{MaxSeries: 10}))
+	defer registry.Close()
+	tenants := registry.(TenantRegistry)
+
+	acme := ForTenant(registry, "acme")
+	acme.Counter(Options{Name: "requests_total"}).With(Tags{"tenant": "globex", "route": "/a"}).Inc()
+	acme.Histogram(Options{Name: "payload_bytes"}).With(Tags{"tenant": "globex"}).With(Tags{"route": "/b"}).Observe(1)
+	acme.(InstrumentRegistry).Meter(Options{Name: "events"}).With(Tags{"tenant": "globex"}).Mark(1)
+
+	if got := tenants.TenantSeries("globex"); got != 0 {
+		t.Errorf("Expected acme's handles not to create globex series, got %d", got)
+	}
+	registry.Each(func(m Metric) {
+		if tenant := m.Tags()["tenant"]; tenant != "acme" {
+			t.Errorf("Expected %s %v to belong to acme", m.Name(), m.Tags())
+		}
+	})
+	// Each handle's own series plus /a and /b
+	if got := tenants.TenantSeries("acme"); got != 5 {
+		t.Errorf("Expected 5 acme series, got %d", got)
+	}
+}
@@ -0,0 +1,176 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Condition is a threshold on the value of a metric's series. Every bound that
+// is set must hold. Zero bounds are unset, so conditions on zero itself use Match.
+type Condition struct {
+	// GreaterThan holds while the value is above it
+	GreaterThan float64
+	// LessThan holds while the value is below it
+	LessThan float64
+	// Match is an optional predicate on the value
+	Match func(value float64) bool
+	// Quantile compares this quantile of histograms and timers instead of
+	// their observation count
+	Quantile float64
+	// For is how long the condition must hold before the watch fires
+	For time.Duration
+	// Interval is how often the metric is evaluated (DefaultWatchInterval if zero)
+	Interval time.Duration
+}
+
+// holds reports whether value satisfies every bound of the condition
+func (c Condition) holds(value float64) bool {
+	if c.GreaterThan != 0 && value <= c.GreaterThan {
+		return false
+	}
+	if c.LessThan != 0 && value >= c.LessThan {
+		return false
+	}
+	return c.Match == nil || c.Match(value)
+}
+
+// ThresholdEvent reports a series of a watched metric starting or ceasing to
+// meet its condition
+type ThresholdEvent struct {
+	// Metric is the series the condition was evaluated on
+	Metric Metric
+	// Name is the metric name
+	Name string
+	// Tags are the series' tags
+	Tags Tags
+	// Value is the value that was compared
+	Value float64
+	// Firing is true once the condition has held for Condition.For and false
+	// when it stops holding afterwards
+	Firing bool
+	// Since is when the condition started holding
+	Since time.Time
+	// Time is when the value was sampled
+	Time time.Time
+}
+
+// thresholdState tracks a series while its condition holds
+type thresholdState struct {
+	since  time.Time
+	firing bool
+}
+
+// EvaluateThreshold evaluates condition on every series of the named metric at
+// the condition's interval until ctx is done. fn is called when a series has
+// met the condition for Condition.For and again when it stops meeting it.
+// Callbacks run on the evaluating goroutine and should return quickly.
+// Registry implementations use it to back WatchThreshold; it works with any
+// Registry that supports Each.
+func EvaluateThreshold(ctx context.Context, registry Registry, name string, condition Condition, fn func(ThresholdEvent)) error {
+	if condition.GreaterThan == 0 && condition.LessThan == 0 && condition.Match == nil {
+		return errors.New("threshold condition has no bound")
+	}
+	if condition.Quantile < 0 || condition.Quantile > 1 {
+		return fmt.Errorf("threshold quantile must be between 0 and 1, got %v", condition.Quantile)
+	}
+	if condition.For < 0 || condition.Interval < 0 {
+		return fmt.Errorf("threshold durations must not be negative, got for %s and interval %s", condition.For, condition.Interval)
+	}
+	if condition.Interval == 0 {
+		condition.Interval = DefaultWatchInterval
+	}
+
+	states := make(map[Metric]*thresholdState)
+	goroutines.start(&periodicTask{
+		ctx:       ctx,
+		interval:  condition.Interval,
+		immediate: true,
+		run: func() bool {
+			evaluateThreshold(registry, name, condition, states, time.Now(), fn)
+			return true
+		},
+	})
+	return nil
+}
+
+// evaluateThreshold compares each series of name against condition at now and calls fn on transitions
+func evaluateThreshold(registry Registry, name string, condition Condition, states map[Metric]*thresholdState, now time.Time, fn func(ThresholdEvent)) {
+	// Collect first so callbacks run outside Each
+	var selected []Metric
+	registry.Each(func(m Metric) {
+		if m.Name() == name {
+			selected = append(selected, m)
+		}
+	})
+
+	seen := make(map[Metric]bool, len(selected))
+	for _, m := range selected {
+		value, snapshot, ok := readValue(m)
+		if !ok {
+			continue
+		}
+		if snapshot != nil && condition.Quantile > 0 {
+			if snapshot.Count == 0 {
+				continue
+			}
+			value = snapshot.Quantile(condition.Quantile)
+		}
+		seen[m] = true
+
+		event := ThresholdEvent{Metric: m, Name: name, Tags: m.Tags(), Value: value, Time: now}
+		state, tracked := states[m]
+		if !condition.holds(value) {
+			if tracked {
+				delete(states, m)
+				if state.firing {
+					event.Since = state.since
+					fn(event)
+				}
+			}
+			continue
+		}
+
+		if !tracked {
+			state = &thresholdState{since: now}
+			states[m] = state
+		}
+		if !state.firing && now.Sub(state.since) >= condition.For {
+			state.firing = true
+			event.Firing = true
+			event.Since = state.since
+			fn(event)
+		}
+	}
+
+	// Forget series that disappeared, e.g. by expiry
+	for m := range states {
+		if !seen[m] {
+			delete(states, m)
+		}
+	}
+}
+
+// WatchThreshold implements the Registry interface. The watch ends when ctx is done or the registry is closed.
+func (r *defaultRegistry) WatchThreshold(ctx context.Context, name string, condition Condition, fn func(ThresholdEvent)) error {
+	if r.ctx.Err() != nil {
+		return ErrRegistryClosed
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(r.ctx, cancel)
+
+	if err := EvaluateThreshold(watchCtx, r, name, condition, fn); err != nil {
+		stop()
+		cancel()
+		return err
+	}
+
+	// Release the registry hook once the caller's context ends
+	context.AfterFunc(watchCtx, func() {
+		stop()
+	})
+
+	return nil
+}
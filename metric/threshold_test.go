@@ -0,0 +1,132 @@
+package metric
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatchThreshold(t *testing.T) {
+	registry := NewNoCleanupRegistry().(WatchRegistry)
+	defer registry.Close()
+
+	queue := registry.Gauge(Options{Name: "queue_depth", Tags: Tags{"queue": "emails"}})
+	other := queue.With(Tags{"queue": "sms"})
+	queue.Set(150)
+	other.Set(10)
+
+	events := make(chan ThresholdEvent, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	condition := Condition{GreaterThan: 100, For: 50 * time.Millisecond, Interval: time.Millisecond}
+	start := time.Now()
+	if err := registry.WatchThreshold(ctx, "queue_depth", condition, func(e ThresholdEvent) { events <- e }); err != nil {
+		t.Fatal(err)
+	}
+
+	var fired ThresholdEvent
+	select {
+	case fired = <-events:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the watch to fire")
+	}
+	if !fired.Firing || fired.Value != 150 || fired.Tags["queue"] != "emails" {
+		t.Errorf("Expected the emails queue to fire at 150, got %+v", fired)
+	}
+	if elapsed := fired.Time.Sub(start); elapsed < condition.For {
+		t.Errorf("Expected the watch to fire after the condition held for %s, fired after %s", condition.For, elapsed)
+	}
+
+	queue.Set(50)
+	select {
+	case resolved := <-events:
+		if resolved.Firing || resolved.Value != 50 || !resolved.Since.Equal(fired.Since) {
+			t.Errorf("Expected a resolved event at 50 since %s, got %+v", fired.Since, resolved)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the watch to resolve")
+	}
+}
+
+func TestThresholdShortSpike(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	queue := registry.Gauge(Options{Name: "queue_depth"})
+	condition := Condition{GreaterThan: 100, For: 50 * time.Millisecond}
+	clock := &stepClock{now: time.Unix(1700000000, 0)}
+	states := make(map[Metric]*thresholdState)
+	var events []ThresholdEvent
+	evaluate := func() {
+		evaluateThreshold(registry, "queue_depth", condition, states, clock.Now(), func(e ThresholdEvent) { events = append(events, e) })
+	}
+
+	// A condition that stops holding before For elapses never fires
+	queue.Set(120)
+	evaluate()
+	clock.advance(5 * time.Millisecond)
+	evaluate()
+	queue.Set(50)
+	clock.advance(60 * time.Millisecond)
+	evaluate()
+	if len(events) != 0 {
+		t.Errorf("Expected no event for a short spike, got %+v", events)
+	}
+
+	// Holding again restarts the wait rather than counting the earlier spike
+	queue.Set(120)
+	evaluate()
+	clock.advance(condition.For - time.Millisecond)
+	evaluate()
+	if len(events) != 0 {
+		t.Fatalf("Expected no event before the condition held for %s, got %+v", condition.For, events)
+	}
+	clock.advance(time.Millisecond)
+	evaluate()
+	if len(events) != 1 || !events[0].Firing || events[0].Value != 120 {
+		t.Errorf("Expected one firing event at 120, got %+v", events)
+	}
+}
+
+func TestWatchThresholdQuantile(t *testing.T) {
+	registry := NewNoCleanupRegistry().(WatchRegistry)
+	defer registry.Close()
+
+	latency := registry.Timer(Options{Name: "checkout_duration"})
+	for i := 0; i < 100; i++ {
+		latency.Record(300 * time.Millisecond)
+	}
+
+	events := make(chan ThresholdEvent, 1)
+	condition := Condition{GreaterThan: float64(250 * time.Millisecond), Quantile: 0.99, Interval: time.Millisecond}
+	if err := registry.WatchThreshold(context.Background(), "checkout_duration", condition, func(e ThresholdEvent) { events <- e }); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		if !e.Firing || e.Value <= float64(250*time.Millisecond) {
+			t.Errorf("Expected p99 above 250ms to fire, got %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the quantile watch to fire")
+	}
+}
+
+func TestWatchThresholdValidation(t *testing.T) {
+	registry := NewNoCleanupRegistry().(WatchRegistry)
+
+	for _, condition := range []Condition{
+		{},
+		{GreaterThan: 1, Quantile: 2},
+		{GreaterThan: 1, For: -time.Second},
+	} {
+		if err := registry.WatchThreshold(context.Background(), "x", condition, func(ThresholdEvent) {}); err == nil {
+			t.Errorf("Expected %+v to be rejected", condition)
+		}
+	}
+
+	registry.Close()
+	if err := registry.WatchThreshold(context.Background(), "x", Condition{GreaterThan: 1}, func(ThresholdEvent) {}); err != ErrRegistryClosed {
+		t.Errorf("Expected ErrRegistryClosed, got %v", err)
+	}
+}
@@ -111,3 +111,33 @@ func TestTimerImpl(t *testing.T) {
 		})
 	}
 }
+
+func TestTimerWithRegistersTimerSeries(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	timer := registry.Timer(Options{Name: "tagged_timer", Buckets: []float64{1e6, 1e7}})
+	timer.With(Tags{"op": "read"}).Record(2 * time.Millisecond)
+	timer.With(Tags{"op": "read"}).Record(3 * time.Millisecond)
+
+	var child Timer
+	registry.Each(func(m Metric) {
+		if m.Tags()["op"] == "read" {
+			if m.Type() != TypeTimer {
+				t.Errorf("Expected derived series of type %s, got %s", TypeTimer, m.Type())
+			}
+			child, _ = m.(Timer)
+		}
+	})
+	if child == nil {
+		t.Fatal("Expected derived timer series to be registered")
+	}
+
+	snapshot := child.Snapshot()
+	if snapshot.Count != 2 {
+		t.Errorf("Expected count 2, got %d", snapshot.Count)
+	}
+	if !reflect.DeepEqual(snapshot.Boundaries, []float64{1e6, 1e7}) {
+		t.Errorf("Expected boundaries [1e6 1e7], got %v", snapshot.Boundaries)
+	}
+}
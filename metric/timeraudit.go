@@ -0,0 +1,138 @@
+package metric
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Reasons a TimerAuditFinding flags a recorded duration
+const (
+	// TimerAuditTooShort flags durations below TimerAudit.Min, such as zero or
+	// negative ones from a reversed subtraction
+	TimerAuditTooShort = "too_short"
+	// TimerAuditTooLong flags durations above TimerAudit.Max, such as an
+	// elapsed count multiplied by a time unit twice
+	TimerAuditTooLong = "too_long"
+	// TimerAuditBelowUnit flags durations below a thousandth of the timer's
+	// Unit, such as 150ns on a timer in "ms", the mark of a raw count of
+	// milliseconds converted with time.Duration(n)
+	TimerAuditBelowUnit = "below_unit"
+)
+
+// timerAuditReasons lists the reasons of findings, in meta-metric order
+var timerAuditReasons = []string{TimerAuditTooShort, TimerAuditTooLong, TimerAuditBelowUnit}
+
+// Default bounds of TimerAudit
+const (
+	DefaultTimerAuditMin = time.Nanosecond
+	DefaultTimerAuditMax = time.Hour
+)
+
+// TimerAudit configures WithTimerAudit. Zero fields take their defaults.
+type TimerAudit struct {
+	// Min is the shortest plausible duration; DefaultTimerAuditMin if zero
+	Min time.Duration
+	// Max is the longest plausible duration; DefaultTimerAuditMax if zero
+	Max time.Duration
+	// Handler is called with the first finding of each series and reason; nil
+	// logs it with slog.Default(). It is called from Record, so it must be fast
+	// and safe for concurrent use, and must not record to the timer.
+	Handler func(TimerAuditFinding)
+}
+
+// TimerAuditFinding describes an implausible duration recorded in a timer
+type TimerAuditFinding struct {
+	Name   string
+	Tags   Tags
+	Unit   string
+	Value  time.Duration
+	Reason string
+}
+
+// WithTimerAudit makes the registry's timers check every recorded duration
+// against audit's bounds and their Unit, to catch unit mistakes such as
+// passing a count of milliseconds as a time.Duration. Flagged durations are
+// still recorded. Each series reports each reason to the handler once; with
+// WithMetaMetrics, every finding is also counted in
+// gometrics_registry_timer_audit_findings_total{reason}. The checks cost a
+// comparison per Record, so it is meant for development, tests and canaries.
+func WithTimerAudit(audit TimerAudit) RegistryOption {
+	return func(r *defaultRegistry) {
+		r.timerAudit = newTimerAudit(audit)
+	}
+}
+
+// timerAudit checks the durations recorded in a registry's timers
+type timerAudit struct {
+	min, max time.Duration
+	handler  func(TimerAuditFinding)
+	meta     *registryMeta // the registry's meta-metrics; nil unless WithMetaMetrics is set
+
+	mu       sync.Mutex
+	reported map[string]struct{} // series key and reason of findings passed to the handler
+}
+
+func newTimerAudit(audit TimerAudit) *timerAudit {
+	a := &timerAudit{
+		min:      audit.Min,
+		max:      audit.Max,
+		handler:  audit.Handler,
+		reported: make(map[string]struct{}),
+	}
+	if a.min <= 0 {
+		a.min = DefaultTimerAuditMin
+	}
+	if a.max <= 0 {
+		a.max = DefaultTimerAuditMax
+	}
+	if a.handler == nil {
+		a.handler = logTimerAuditFinding
+	}
+	return a
+}
+
+// check flags d if it is implausible for t
+func (a *timerAudit) check(t Timer, d time.Duration) {
+	var reason string
+	switch {
+	case d < a.min:
+		reason = TimerAuditTooShort
+	case d > a.max:
+		reason = TimerAuditTooLong
+	case belowUnit(t.Unit(), d):
+		reason = TimerAuditBelowUnit
+	default:
+		return
+	}
+
+	if a.meta != nil {
+		a.meta.timerAuditFindings[reason].Inc()
+	}
+
+	key := seriesKey(TypeTimer, t.Name(), t.Tags()) + "\x00" + reason
+	a.mu.Lock()
+	_, seen := a.reported[key]
+	a.reported[key] = struct{}{}
+	a.mu.Unlock()
+	if !seen {
+		a.handler(TimerAuditFinding{Name: t.Name(), Tags: t.Tags(), Unit: t.Unit(), Value: d, Reason: reason})
+	}
+}
+
+// belowUnit reports whether d is below a thousandth of the duration unit,
+// which no timer measuring in that unit is expected to record
+func belowUnit(unit string, d time.Duration) bool {
+	conversion, ok := LookupUnit(unit)
+	if !ok || conversion.Base != UnitSeconds {
+		return false
+	}
+	unitNanos := 1e9 / conversion.Divisor
+	return float64(d) < unitNanos/1000
+}
+
+// logTimerAuditFinding is the default TimerAudit.Handler
+func logTimerAuditFinding(f TimerAuditFinding) {
+	slog.Default().Warn("implausible timer duration; check the unit it was recorded in",
+		"metric", f.Name, "tags", f.Tags, "unit", f.Unit, "value", f.Value, "reason", f.Reason)
+}
@@ -0,0 +1,84 @@
+package metric
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimerAuditFlagsImplausibleDurations(t *testing.T) {
+	var mu sync.Mutex
+	var findings []TimerAuditFinding
+	registry := NewNoCleanupRegistry(WithTimerAudit(TimerAudit{Handler: func(f TimerAuditFinding) {
+		mu.Lock()
+		defer mu.Unlock()
+		findings = append(findings, f)
+	}}))
+	defer registry.Close()
+
+	timer := registry.Timer(Options{Name: "query_duration", Unit: "ms"})
+	timer.Record(150 * time.Millisecond)
+	timer.Record(150) // a count of milliseconds passed as a Duration
+	timer.Record(0)
+	timer.Record(2 * time.Hour)
+	timer.Record(3) // reported once per reason
+
+	if len(findings) != 3 {
+		t.Fatalf("Expected three findings, got %+v", findings)
+	}
+	want := []string{TimerAuditBelowUnit, TimerAuditTooShort, TimerAuditTooLong}
+	for i, f := range findings {
+		if f.Reason != want[i] || f.Name != "query_duration" || f.Unit != "ms" {
+			t.Errorf("Finding %d: expected %s for query_duration, got %+v", i, want[i], f)
+		}
+	}
+	if findings[0].Value != 150 {
+		t.Errorf("Expected the flagged value, got %v", findings[0].Value)
+	}
+	if s := timer.Snapshot(); s.Count != 5 {
+		t.Errorf("Expected flagged durations to still be recorded, got %d", s.Count)
+	}
+
+	// Derived series are audited and reported on their own
+	timer.With(Tags{"table": "users"}).Record(150)
+	if len(findings) != 4 || findings[3].Tags["table"] != "users" {
+		t.Errorf("Expected a finding for the derived series, got %+v", findings)
+	}
+}
+
+func TestTimerAuditBounds(t *testing.T) {
+	var findings []TimerAuditFinding
+	registry := NewNoCleanupRegistry(WithTimerAudit(TimerAudit{
+		Min:     time.Microsecond,
+		Max:     time.Second,
+		Handler: func(f TimerAuditFinding) { findings = append(findings, f) },
+	}))
+	defer registry.Close()
+
+	timer := registry.Timer(Options{Name: "op_duration"})
+	timer.Record(time.Millisecond)
+	timer.Record(500 * time.Nanosecond)
+	timer.Record(2 * time.Second)
+	if len(findings) != 2 || findings[0].Reason != TimerAuditTooShort || findings[1].Reason != TimerAuditTooLong {
+		t.Errorf("Expected the configured bounds to apply, got %+v", findings)
+	}
+}
+
+func TestTimerAuditMetaMetrics(t *testing.T) {
+	registry := NewNoCleanupRegistry(WithTimerAudit(TimerAudit{Handler: func(TimerAuditFinding) {}}), WithMetaMetrics())
+	defer registry.Close()
+
+	timer := registry.Timer(Options{Name: "op_duration", Unit: "seconds"})
+	timer.Record(time.Microsecond)
+	timer.Record(time.Microsecond)
+
+	var counted uint64
+	registry.Each(func(m Metric) {
+		if m.Name() == MetaMetricPrefix+"timer_audit_findings_total" && m.Tags()["reason"] == TimerAuditBelowUnit {
+			counted = m.(Counter).Value()
+		}
+	})
+	if counted != 2 {
+		t.Errorf("Expected every finding to be counted, got %v", counted)
+	}
+}
@@ -0,0 +1,228 @@
+package metric
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// TopKOther is the tag value the counts of values outside the top K are exported under
+const TopKOther = "other"
+
+// TopKConfig configures a TopK
+type TopKConfig struct {
+	// Key is the tag key the tracked values are exported under, e.g. "endpoint"
+	Key string
+	// K is the number of most frequent values exported per window
+	K int
+	// Capacity is the number of values the sketch tracks, at least K (4*K if
+	// zero). Counts are overestimated by at most the window's total divided by
+	// Capacity, so a larger capacity ranks values more accurately.
+	Capacity int
+	// Window is how often the top values are exported and the sketch reset
+	Window time.Duration
+}
+
+// TopKEntry is a value tracked by a TopK with its estimated count
+type TopKEntry struct {
+	Value string
+	// Count is the estimated number of times the value was seen, at most Error too high
+	Count uint64
+	// Error bounds the overestimate of Count
+	Error uint64
+}
+
+// TopK tracks the most frequent values of a tag, such as the endpoints with
+// the most requests, in a space-saving sketch of bounded size. At the end of
+// every window the counts of the top K values are added to counters tagged
+// with the value, and the counts of all other values to the counter tagged
+// TopKOther, so backends see a bounded set of series instead of one per value.
+type TopK struct {
+	registry Registry
+	opts     Options
+	config   TopKConfig
+
+	mu     sync.Mutex
+	sketch *spaceSaving
+}
+
+// NewTopK creates a TopK exporting to counters named opts.Name, with opts.Tags
+// plus the value under config.Key, and exports every config.Window until ctx is
+// done, flushing the last window then. Unless opts.TTL is set the counters
+// expire after two idle windows, so values that leave the top K are dropped.
+func NewTopK(ctx context.Context, registry Registry, opts Options, config TopKConfig) (*TopK, error) {
+	if config.Key == "" {
+		return nil, errors.New("top-k key must not be empty")
+	}
+	if config.K <= 0 || config.Window <= 0 {
+		return nil, fmt.Errorf("top-k needs a positive k and window, got %d and %s", config.K, config.Window)
+	}
+	if config.Capacity == 0 {
+		config.Capacity = 4 * config.K
+	}
+	if config.Capacity < config.K {
+		return nil, fmt.Errorf("top-k capacity %d is less than k %d", config.Capacity, config.K)
+	}
+	if opts.TTL == 0 {
+		opts.TTL = 2 * config.Window
+	}
+
+	t := &TopK{
+		registry: registry,
+		opts:     opts,
+		config:   config,
+		sketch:   newSpaceSaving(config.Capacity),
+	}
+	goroutines.start(&periodicTask{
+		ctx:      ctx,
+		interval: config.Window,
+		run:      func() bool { t.Flush(); return true },
+		done:     t.Flush,
+	})
+	return t, nil
+}
+
+// Observe counts one occurrence of value
+func (t *TopK) Observe(value string) {
+	t.Add(value, 1)
+}
+
+// Add counts n occurrences of value
+func (t *TopK) Add(value string, n uint64) {
+	if n == 0 {
+		return
+	}
+	t.mu.Lock()
+	t.sketch.add(value, n)
+	t.mu.Unlock()
+}
+
+// Top returns the most frequent values of the current window, most frequent first
+func (t *TopK) Top() []TopKEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sketch.top(t.config.K)
+}
+
+// Flush exports the current window now and starts a new one
+func (t *TopK) Flush() {
+	t.mu.Lock()
+	top := t.sketch.top(t.config.K)
+	other := t.sketch.total
+	t.sketch = newSpaceSaving(t.config.Capacity)
+	t.mu.Unlock()
+
+	for _, entry := range top {
+		t.counter(entry.Value).Add(float64(entry.Count))
+		// Counts are overestimates, so the remainder may undercount other values
+		other -= min64(other, entry.Count)
+	}
+	if other > 0 {
+		t.counter(TopKOther).Add(float64(other))
+	}
+}
+
+// counter returns the counter of value
+func (t *TopK) counter(value string) Counter {
+	opts := t.opts
+	opts.Tags = copyTags(opts.Tags, Tags{t.config.Key: value})
+	return t.registry.Counter(opts)
+}
+
+// min64 returns the smaller of a and b
+func min64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// spaceSaving is the Space-Saving sketch of Metwally et al.: it tracks at most
+// capacity values and, when full, replaces the least frequent one, which the
+// new value inherits the count of as its error
+type spaceSaving struct {
+	capacity int
+	entries  map[string]*sketchEntry
+	heap     sketchHeap // min-heap by count
+	total    uint64
+}
+
+type sketchEntry struct {
+	TopKEntry
+	index int
+}
+
+func newSpaceSaving(capacity int) *spaceSaving {
+	return &spaceSaving{capacity: capacity, entries: make(map[string]*sketchEntry, capacity)}
+}
+
+// add counts n occurrences of value
+func (s *spaceSaving) add(value string, n uint64) {
+	s.total += n
+	if entry, ok := s.entries[value]; ok {
+		entry.Count += n
+		heap.Fix(&s.heap, entry.index)
+		return
+	}
+	if len(s.heap) < s.capacity {
+		entry := &sketchEntry{TopKEntry: TopKEntry{Value: value, Count: n}}
+		s.entries[value] = entry
+		heap.Push(&s.heap, entry)
+		return
+	}
+
+	entry := s.heap[0]
+	delete(s.entries, entry.Value)
+	entry.Value, entry.Error = value, entry.Count
+	entry.Count += n
+	s.entries[value] = entry
+	heap.Fix(&s.heap, 0)
+}
+
+// top returns the k entries with the highest counts, highest first
+func (s *spaceSaving) top(k int) []TopKEntry {
+	entries := make([]TopKEntry, len(s.heap))
+	for i, entry := range s.heap {
+		entries[i] = entry.TopKEntry
+	}
+	slices.SortFunc(entries, func(a, b TopKEntry) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+	if len(entries) > k {
+		entries = entries[:k]
+	}
+	return entries
+}
+
+// sketchHeap implements heap.Interface over sketch entries by count
+type sketchHeap []*sketchEntry
+
+func (h sketchHeap) Len() int           { return len(h) }
+func (h sketchHeap) Less(i, j int) bool { return h[i].Count < h[j].Count }
+
+func (h sketchHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *sketchHeap) Push(x any) {
+	entry := x.(*sketchEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *sketchHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
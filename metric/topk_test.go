@@ -0,0 +1,69 @@
+package metric
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTopK(t *testing.T) {
+	registry := NewNoCleanupRegistry().(SeriesRegistry)
+	defer registry.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	topk, err := NewTopK(ctx, registry, Options{Name: "top_endpoints_total", Tags: Tags{"service": "api"}},
+		TopKConfig{Key: "endpoint", K: 2, Capacity: 8, Window: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	topk.Add("/checkout", 500)
+	topk.Add("/search", 300)
+	for i := 0; i < 100; i++ {
+		topk.Observe(fmt.Sprintf("/item/%d", i))
+	}
+
+	top := topk.Top()
+	if len(top) != 2 || top[0].Value != "/checkout" || top[1].Value != "/search" {
+		t.Fatalf("Expected /checkout and /search on top, got %+v", top)
+	}
+
+	topk.Flush()
+	metrics := metaValues(registry)
+	if got := metrics["top_endpoints_total{endpoint=/checkout,service=api}"]; got < 500 {
+		t.Errorf("Expected at least 500 checkouts, got %v", got)
+	}
+	if got := metrics["top_endpoints_total{endpoint=/search,service=api}"]; got < 300 {
+		t.Errorf("Expected at least 300 searches, got %v", got)
+	}
+	if got := len(registry.Series("top_endpoints_total")); got != 3 {
+		t.Errorf("Expected the top 2 and the other series, got %d", got)
+	}
+	total := 0.0
+	for _, s := range registry.Series("top_endpoints_total") {
+		total += s.Value
+	}
+	if total != 900 {
+		t.Errorf("Expected every observation to be exported once, got %v", total)
+	}
+
+	// The window is reset after a flush, and the last window is flushed on cancel
+	if top := topk.Top(); len(top) != 0 {
+		t.Errorf("Expected an empty window after the flush, got %+v", top)
+	}
+	topk.Observe("/search")
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for metaValues(registry)["top_endpoints_total{endpoint=/search,service=api}"] < 301 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the last window to be flushed when the context ends")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := NewTopK(ctx, registry, Options{Name: "bad"}, TopKConfig{Key: "k", K: 4, Capacity: 2, Window: time.Second}); err == nil {
+		t.Error("Expected a capacity below k to be rejected")
+	}
+}
@@ -0,0 +1,81 @@
+package metric
+
+import (
+	"maps"
+	"time"
+)
+
+// ConvertUnitAnnotation marks a gauge whose values reporters convert from its
+// unit to the unit's base unit, as they do for histograms. Gauges without it
+// are exported as recorded whatever their unit, so existing series keep their
+// names.
+const ConvertUnitAnnotation = "convert_unit"
+
+// BoolGauge is a gauge of a condition, such as whether a dependency is
+// reachable, exported as 1 when true and 0 when false
+type BoolGauge struct {
+	gauge Gauge
+}
+
+// NewBoolGauge creates or retrieves the bool gauge named opts.Name in registry, starting false
+func NewBoolGauge(registry Registry, opts Options) *BoolGauge {
+	return &BoolGauge{gauge: registry.Gauge(opts)}
+}
+
+// Set records whether the condition holds
+func (g *BoolGauge) Set(value bool) {
+	if value {
+		g.gauge.Set(1)
+	} else {
+		g.gauge.Set(0)
+	}
+}
+
+// Value returns whether the condition holds
+func (g *BoolGauge) Value() bool {
+	return g.gauge.Value() != 0
+}
+
+// With returns the BoolGauge with additional tags
+func (g *BoolGauge) With(tags Tags) *BoolGauge {
+	return &BoolGauge{gauge: g.gauge.With(tags)}
+}
+
+// DurationGauge is a gauge of a duration, such as the age of a cache or the
+// lag of a consumer. It records nanoseconds and reporters export it in
+// seconds, suffixed _seconds, like timers.
+type DurationGauge struct {
+	gauge Gauge
+}
+
+// NewDurationGauge creates or retrieves the duration gauge named opts.Name in
+// registry, starting at zero. Its unit is always nanoseconds.
+func NewDurationGauge(registry Registry, opts Options) *DurationGauge {
+	opts.Unit = "ns"
+	opts.Annotations = maps.Clone(opts.Annotations)
+	if opts.Annotations == nil {
+		opts.Annotations = make(map[string]string, 1)
+	}
+	opts.Annotations[ConvertUnitAnnotation] = "true"
+	return &DurationGauge{gauge: registry.Gauge(opts)}
+}
+
+// Set records d
+func (g *DurationGauge) Set(d time.Duration) {
+	g.gauge.Set(float64(d))
+}
+
+// SetSince records the time elapsed since t
+func (g *DurationGauge) SetSince(t time.Time) {
+	g.Set(time.Since(t))
+}
+
+// Value returns the recorded duration
+func (g *DurationGauge) Value() time.Duration {
+	return time.Duration(g.gauge.Value())
+}
+
+// With returns the DurationGauge with additional tags
+func (g *DurationGauge) With(tags Tags) *DurationGauge {
+	return &DurationGauge{gauge: g.gauge.With(tags)}
+}
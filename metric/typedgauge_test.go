@@ -0,0 +1,56 @@
+package metric
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBoolGauge(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	reachable := NewBoolGauge(registry, Options{Name: "upstream_reachable"})
+	if reachable.Value() {
+		t.Error("Expected a new bool gauge to be false")
+	}
+	reachable.Set(true)
+	if g := registry.Gauge(Options{Name: "upstream_reachable"}); !reachable.Value() || g.Value() != 1 {
+		t.Errorf("Expected true to be recorded as 1, got %d", g.Value())
+	}
+	reachable.Set(false)
+	if reachable.Value() {
+		t.Error("Expected the gauge to be false again")
+	}
+
+	billing := reachable.With(Tags{"upstream": "billing"})
+	billing.Set(true)
+	if reachable.Value() || !billing.Value() {
+		t.Error("Expected tagged series to be independent")
+	}
+}
+
+func TestDurationGauge(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	lag := NewDurationGauge(registry, Options{Name: "consumer_lag", Unit: "ms", Tags: Tags{"topic": "orders"}})
+	lag.Set(1500 * time.Millisecond)
+	if lag.Value() != 1500*time.Millisecond {
+		t.Errorf("Expected 1.5s, got %v", lag.Value())
+	}
+
+	g := registry.Gauge(Options{Name: "consumer_lag", Tags: Tags{"topic": "orders"}})
+	unit := ExportUnit(g)
+	if g.Unit() != "ns" || unit.Name(g.Name()) != "consumer_lag_seconds" || unit.Convert(float64(g.Value())) != 1.5 {
+		t.Errorf("Expected the gauge to be exported in seconds, got unit %q and %+v", g.Unit(), unit)
+	}
+
+	partition := lag.With(Tags{"partition": "3"})
+	partition.SetSince(time.Now().Add(-time.Minute))
+	if partition.Value() < time.Minute || lag.Value() != 1500*time.Millisecond {
+		t.Errorf("Expected tagged series to be independent, got %v and %v", partition.Value(), lag.Value())
+	}
+	if _, ok := AnnotationOf(registry.Gauge(Options{Name: "consumer_lag", Tags: Tags{"topic": "orders", "partition": "3"}}), ConvertUnitAnnotation); !ok {
+		t.Error("Expected tagged series to keep the conversion")
+	}
+}
@@ -20,6 +20,10 @@ const (
 	TypeHistogram Type = "histogram"
 	// TypeTimer is a specialized metric for duration measurements
 	TypeTimer Type = "timer"
+	// TypeUpDownCounter is for values that are concurrently incremented and decremented
+	TypeUpDownCounter Type = "updowncounter"
+	// TypeMeter is for event rates averaged over 1, 5 and 15 minutes
+	TypeMeter Type = "meter"
 )
 
 // Tags represents a map of key-value pairs associated with a metric
@@ -95,6 +99,12 @@ const (
 	BucketTypeCustom
 )
 
+// DefaultBuckets returns the bucket boundaries used when Options.Buckets is empty:
+// exponential buckets from 0.001 to 10000
+func DefaultBuckets() []float64 {
+	return []float64{0.001, 0.01, 0.1, 1, 10, 100, 1000, 10000}
+}
+
 // GenerateLinearBuckets creates linearly spaced bucket boundaries
 func GenerateLinearBuckets(start, width float64, count int) []float64 {
 	if count <= 0 {
@@ -160,9 +170,67 @@ type Options struct {
 	// Buckets defines custom histogram bucket boundaries (optional, for histograms only)
 	// If not specified, default buckets will be used
 	Buckets []float64
-	// TTL defines how long the metric should be kept in the registry (optional)
+	// TTL defines how long the metric may stay idle in the registry (optional).
+	// Every write refreshes it, so only metrics not written for TTL expire.
 	// If zero, the metric will not expire
 	TTL time.Duration
+	// Annotations are backend-specific hints for reporters, keyed by backend prefix
+	// (e.g. "datadog.distribution": "true"). They are not tags: they do not
+	// identify the series and are not exported as labels. Series derived with
+	// With() share them, and like Description the first registration's are kept.
+	Annotations map[string]string
+	// Shards spreads a counter over this many cache-line padded stripes, rounded
+	// up to a power of two, so heavily contended writers do not share one atomic.
+	// Value sums the stripes. runtime.GOMAXPROCS(0) is a good choice; 0 or 1
+	// means a plain counter. Counters only.
+	Shards int
+	// HighContention shards a histogram or timer over one set of cache-line
+	// padded buckets per CPU, merged by Snapshot, so many goroutines observing it
+	// at once do not contend on shared atomics. It costs memory per shard and a
+	// slower Snapshot. Histograms and timers only; not with Window.
+	HighContention bool
+	// Window makes a histogram report only the observations of its last complete
+	// window of this length, rather than every observation since it was created.
+	// 0 means no window. Histograms only.
+	Window time.Duration
+	// AlignWindow starts windows on wall-clock boundaries, at multiples of Window
+	// (e.g. every full minute), instead of when the histogram is created, so
+	// snapshots from many instances cover identical time ranges and can be combined
+	AlignWindow bool
+	// AdaptiveWarmUp is the number of observations a histogram warms up on
+	// before choosing its bucket boundaries from them, for workloads whose range
+	// is not known up front. Until then it observes into Buckets, or the
+	// defaults, and keeps every value; it then freezes exponential boundaries
+	// spanning half the smallest to twice the largest positive value, as many as
+	// it started with, and moves the warm-up observations into them. The warm-up
+	// is counted in observations, not time; AdaptiveHistogram.Freeze ends it
+	// early, e.g. for low-traffic series. Series derived with With() warm up on
+	// their own. 0 means fixed buckets. Histograms only; not with Window.
+	AdaptiveWarmUp int
+	// Durability stores a counter in a memory-mapped file so business-critical
+	// totals survive crashes exactly. The file is validated and its total restored
+	// when the counter is created; the registry panics if it is corrupt or belongs
+	// to another series, or ErrDurableLocked if the file is open elsewhere. Series
+	// derived with With() get their own file next to it. Unix only; counters only.
+	Durability *Durability
+}
+
+// Metadata is the descriptive information attached to a metric
+type Metadata struct {
+	Description string
+	Unit        string
+}
+
+// MetadataConflict describes a registry call whose Options carried metadata or
+// a type different from that of the metric already registered under the same name
+type MetadataConflict struct {
+	Name      string
+	Type      Type
+	Current   Metadata
+	Requested Metadata
+	// RequestedType is the type the call asked for when it differs from Type,
+	// which the registry's catalog reports; empty otherwise
+	RequestedType Type
 }
 
 // Metric is the base interface that all metric types implement
@@ -171,12 +239,30 @@ type Metric interface {
 	Name() string
 	// Description provides additional information about what the metric measures
 	Description() string
+	// Unit returns the unit of measurement (e.g. "seconds", "bytes"), or "" if unset
+	Unit() string
 	// Type returns the metric type (counter, gauge, etc.)
 	Type() Type
 	// Tags returns the key-value pairs associated with this metric
 	Tags() Tags
 }
 
+// Annotated is implemented by metrics that carry Options.Annotations
+type Annotated interface {
+	// Annotation returns the value of the annotation key, if set
+	Annotation(key string) (string, bool)
+	// Annotations returns a copy of all annotations
+	Annotations() map[string]string
+}
+
+// AnnotationOf returns the annotation key of m, if m is Annotated and has it set
+func AnnotationOf(m Metric, key string) (string, bool) {
+	if a, ok := m.(Annotated); ok {
+		return a.Annotation(key)
+	}
+	return "", false
+}
+
 // Counter represents a monotonically increasing value
 type Counter interface {
 	Metric
@@ -188,6 +274,97 @@ type Counter interface {
 	With(tags Tags) Counter
 	// Value returns the current counter value
 	Value() uint64
+	// Swap atomically returns the current value and resets the counter to zero,
+	// so push-based reporters can emit the increase since their previous report.
+	// Like Reset it starts a new generation.
+	Swap() uint64
+	// Reset sets the counter to zero and starts a new generation, so reporters
+	// tracking increases count everything after it as new
+	Reset()
+	// Snapshot returns the value with the generation it belongs to
+	Snapshot() CounterSnapshot
+}
+
+// CounterSnapshot is a counter's value together with its reset generation.
+// Every counter series starts with a generation of its own, unique in the
+// process, and takes a new one on each Reset or Swap. Two snapshots with the
+// same generation were read from the same series without a reset in between,
+// so the later value minus the earlier one is the increase; a series expired
+// by TTL and created again has a different generation even if its new value
+// has already passed the old one. Generation 0 means the counter does not
+// track generations.
+type CounterSnapshot struct {
+	Value      uint64
+	Generation uint64
+}
+
+// UpDownCounter represents a value adjusted by concurrent increments and decrements,
+// such as in-flight requests. Unlike Gauge it has no Set, so concurrent callers never
+// overwrite each other's changes.
+type UpDownCounter interface {
+	Metric
+	// Add adds the given value to the counter (can be negative)
+	Add(value float64)
+	// Inc increments the counter by 1
+	Inc()
+	// Dec decrements the counter by 1
+	Dec()
+	// With returns an UpDownCounter with additional tags
+	With(tags Tags) UpDownCounter
+	// Value returns the current counter value
+	Value() int64
+}
+
+// TimestampedAdder is implemented by counters that accept an explicit observation time
+type TimestampedAdder interface {
+	// AddAt adds value as observed at ts. The last timestamp only moves forward,
+	// so out-of-order adds are counted without rewinding it.
+	AddAt(value float64, ts time.Time)
+	// LastTimestamp returns the latest timestamp passed to AddAt, or the zero time
+	LastTimestamp() time.Time
+}
+
+// FloatGauge is implemented by gauges whose value need not be a whole number,
+// such as derived gauges and GaugeFuncs. Value rounds it; exporters report
+// FloatValue instead, see GaugeValue.
+type FloatGauge interface {
+	FloatValue() float64
+}
+
+// GaugeValue returns the value of a gauge as exporters report it: FloatValue
+// if it implements FloatGauge, otherwise Value
+func GaugeValue(gauge Gauge) float64 {
+	if f, ok := gauge.(FloatGauge); ok {
+		return f.FloatValue()
+	}
+	return float64(gauge.Value())
+}
+
+// CreatedTimestamper is implemented by metrics that know when they were created
+type CreatedTimestamper interface {
+	// Created returns when the series was created, or last reset by Counter.Swap,
+	// Counter.Reset or Histogram.SnapshotAndReset; the zero time if unknown
+	Created() time.Time
+}
+
+// ExemplarAdder is implemented by counters that keep an exemplar, linking the
+// count to a trace or request
+type ExemplarAdder interface {
+	// AddWithExemplar adds value and keeps it, with labels such as a trace ID,
+	// as the counter's exemplar
+	AddWithExemplar(value float64, labels Tags)
+	// Exemplars returns the latest exemplar, if any
+	Exemplars() []Exemplar
+}
+
+// ExemplarObserver is implemented by histograms that keep exemplars, linking
+// observations to a trace or request
+type ExemplarObserver interface {
+	// ObserveWithExemplar observes value and keeps it, with labels such as a
+	// trace ID, as the exemplar of its bucket
+	ObserveWithExemplar(value float64, labels Tags)
+	// Exemplars returns the latest exemplar of each bucket that has one, in bucket order
+	Exemplars() []Exemplar
 }
 
 // Gauge represents a current point-in-time measurement
@@ -210,10 +387,12 @@ type Gauge interface {
 // HistogramSnapshot represents the current state of a histogram
 type HistogramSnapshot struct {
 	Count   uint64
-	Sum     uint64
-	Min     uint64
-	Max     uint64
+	Sum     float64
+	Min     float64
+	Max     float64
 	Buckets []uint64
+	// Boundaries are the upper bounds of Buckets; the final bucket is +Inf
+	Boundaries []float64
 }
 
 // Histogram represents a statistical distribution of values
@@ -225,6 +404,71 @@ type Histogram interface {
 	With(tags Tags) Histogram
 	// Snapshot returns the current histogram statistics
 	Snapshot() HistogramSnapshot
+	// SnapshotAndReset returns the current statistics and resets the histogram,
+	// so push-based reporters can emit the observations since their previous report.
+	// Every observation lands in exactly one snapshot, though one made concurrently
+	// with the reset may have its count and bucket split across two.
+	SnapshotAndReset() HistogramSnapshot
+}
+
+// WindowedHistogram is a Histogram created with Options.Window. Its Snapshot
+// holds the observations of the last complete window only.
+type WindowedHistogram interface {
+	Histogram
+	// WindowSnapshot returns the statistics of the last complete window together
+	// with the time range it covers
+	WindowSnapshot() (snapshot HistogramSnapshot, start, end time.Time)
+}
+
+// AdaptiveHistogram is a Histogram created with Options.AdaptiveWarmUp. Its
+// Snapshot uses the warm-up boundaries until it is frozen and the final ones after.
+type AdaptiveHistogram interface {
+	Histogram
+	// Freeze chooses the final boundaries from the observations so far without
+	// waiting for the rest of the warm-up. It does nothing once frozen.
+	Freeze()
+	// Frozen reports whether the final boundaries have been chosen
+	Frozen() bool
+}
+
+// Meter measures the rate of events as exponentially weighted moving averages
+// over 1, 5 and 15 minutes, like the Unix load average. Reporters export the
+// rates as gauges, so dashboards need no rate() query on the backend.
+type Meter interface {
+	Metric
+	// Mark records n events; negative n is ignored
+	Mark(n int64)
+	// With returns a Meter with additional tags
+	With(tags Tags) Meter
+	// Count returns the total number of events marked
+	Count() int64
+	// Snapshot returns the event count and rates
+	Snapshot() MeterSnapshot
+}
+
+// MeterSnapshot is a point-in-time view of a Meter. Rates are in events per second.
+type MeterSnapshot struct {
+	Count    int64
+	Rate1    float64 // 1-minute moving average
+	Rate5    float64 // 5-minute moving average
+	Rate15   float64 // 15-minute moving average
+	MeanRate float64 // average since the meter was created
+}
+
+// Cardinality estimates the number of distinct values observed, such as unique
+// users, IPs or session IDs, with a HyperLogLog sketch of fixed size (about 16
+// KiB per series, with a standard error of about 1.6%), so uniqueness is
+// measured without a tag per value. Reporters export the estimate as a gauge.
+type Cardinality interface {
+	Metric
+	// Observe adds value to the set whose distinct members are counted
+	Observe(value string)
+	// With returns a Cardinality with additional tags
+	With(tags Tags) Cardinality
+	// Estimate returns the approximate number of distinct values observed
+	Estimate() uint64
+	// Reset forgets every value observed, e.g. to count daily unique users
+	Reset()
 }
 
 // Timer is a specialized metric for measuring durations
@@ -240,9 +484,15 @@ type Timer interface {
 	With(tags Tags) Timer
 	// Snapshot returns the underlying histogram statistics
 	Snapshot() HistogramSnapshot
+	// SnapshotAndReset returns the underlying histogram statistics and resets
+	// them, like Histogram.SnapshotAndReset
+	SnapshotAndReset() HistogramSnapshot
 }
 
-// Registry manages a collection of metrics
+// Registry manages a collection of metrics. Registries may implement the
+// optional interfaces InstrumentRegistry, SeriesRegistry, WatchRegistry,
+// ConfigurableRegistry and PausableRegistry for more; callers type-assert for
+// them. The registries of this package implement them all.
 type Registry interface {
 	// Counter creates or retrieves a Counter
 	Counter(opts Options) Counter
@@ -254,7 +504,9 @@ type Registry interface {
 	Timer(opts Options) Timer
 	// Unregister removes a metric from the registry
 	Unregister(name string)
-	// Each iterates over all registered metrics
+	// Each iterates over all registered metrics. fn runs on a snapshot taken
+	// before the first call, without registry locks held, so it may create,
+	// read and unregister metrics; metrics created meanwhile are not visited.
 	Each(fn func(Metric))
 	// ManualCleanup removes all expired metrics immediately
 	ManualCleanup()
@@ -262,6 +514,84 @@ type Registry interface {
 	Close() error
 }
 
+// InstrumentRegistry is implemented by registries that create instruments
+// besides counters, gauges, histograms and timers
+type InstrumentRegistry interface {
+	Registry
+	// GaugeFunc creates or retrieves a Gauge whose value is computed by fn each time it is read
+	GaugeFunc(opts Options, fn func() float64) Gauge
+	// Derived creates or retrieves a Gauge computed by fn from other series,
+	// such as an error rate or a utilization percentage. fn runs whenever the
+	// registry is iterated, so at every report; Value returns the latest
+	// result rounded to an integer, and the gauge implements FloatGauge, so
+	// exporters report it unrounded.
+	Derived(opts Options, fn func(SnapshotView) float64) Gauge
+	// UpDownCounter creates or retrieves an UpDownCounter
+	UpDownCounter(opts Options) UpDownCounter
+	// Meter creates or retrieves a Meter
+	Meter(opts Options) Meter
+	// Cardinality creates or retrieves a Cardinality
+	Cardinality(opts Options) Cardinality
+}
+
+// SeriesRegistry is implemented by registries that look up and remove single
+// series. CollectSeries, EachOfType and FindMetric read any Registry the same
+// way through Each.
+type SeriesRegistry interface {
+	Registry
+	// UnregisterMetric removes the metric of type t registered under name
+	UnregisterMetric(name string, t Type)
+	// UnregisterWhere removes every registered series for which match returns true
+	UnregisterWhere(match func(Metric) bool)
+	// UnregisterPrefix removes every metric whose name starts with prefix, as
+	// Unregister does for a single name
+	UnregisterPrefix(prefix string)
+	// EachByType iterates over the registered metrics of type t, as Each does
+	EachByType(t Type, fn func(Metric))
+	// Lookup returns the untagged series of name and type t without creating it
+	Lookup(name string, t Type) (Metric, bool)
+	// Series returns each registered series of name with its tags, type, last
+	// write time and current value
+	Series(name string) []SeriesInfo
+}
+
+// WatchRegistry is implemented by registries that stream changes to their series
+type WatchRegistry interface {
+	Registry
+	// Watch streams sampled value changes for the metrics selected by filter until ctx is done
+	Watch(ctx context.Context, filter WatchFilter) (<-chan MetricUpdate, error)
+	// WatchThreshold calls fn when a series of the named metric has met condition
+	// for condition.For and again when it stops meeting it, until ctx is done
+	WatchThreshold(ctx context.Context, name string, condition Condition, fn func(ThresholdEvent)) error
+}
+
+// ConfigurableRegistry is implemented by registries whose configuration can
+// change while they are in use
+type ConfigurableRegistry interface {
+	Registry
+	// UpdateTagValidation replaces the tag validation config for series created
+	// from now on, e.g. to raise MaxCardinality without a restart
+	UpdateTagValidation(config TagValidationConfig) error
+	// SetMetadata replaces the description and unit of every metric registered
+	// under name, and of metrics created under name later. Empty values are left unchanged.
+	SetMetadata(name, description, unit string)
+	// OnExpire registers fn to be called with each metric removed by TTL expiry,
+	// e.g. to log or persist its final value
+	OnExpire(fn func(Metric))
+}
+
+// PausableRegistry is implemented by registries whose writes can be paused
+type PausableRegistry interface {
+	Registry
+	// Pause makes every write through the registry's metrics a no-op until
+	// Resume is called. Handles stay valid and metrics can still be created and read.
+	Pause()
+	// Resume re-enables writes after Pause
+	Resume()
+	// Paused reports whether writes are currently paused
+	Paused() bool
+}
+
 // Reporter is the interface for reporting metrics to a backend system
 type Reporter interface {
 	// Report sends metrics to a backend system
@@ -272,6 +602,40 @@ type Reporter interface {
 	Close() error
 }
 
+// ObservationKind says how an Observation changed its metric
+type ObservationKind int
+
+const (
+	// ObservationAdd is a counter, up/down counter or gauge increment; Value is the delta
+	ObservationAdd ObservationKind = iota
+	// ObservationSet is a gauge Set; Value is the new value
+	ObservationSet
+	// ObservationSample is a histogram observation or timer recording; Value is the
+	// sample, in nanoseconds for timers
+	ObservationSample
+)
+
+// Observation is a single write to a metric
+type Observation struct {
+	// Metric is the series that was written
+	Metric Metric
+	// Kind says how Value applies to the metric
+	Kind ObservationKind
+	// Value is the delta, new value or sample, depending on Kind
+	Value float64
+	// Time is when the write happened
+	Time time.Time
+}
+
+// ObservationSink is optionally implemented by reporters that want every write as
+// it happens rather than periodic snapshots, such as StatsD or OTLP delta
+// exporters. Attach one to a registry with WithObservationSink.
+type ObservationSink interface {
+	// Observe is called synchronously on the writing goroutine, so it must be
+	// fast and safe for concurrent use
+	Observe(o Observation)
+}
+
 // ContextKey is a type for context keys
 type ContextKey string
 
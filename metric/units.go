@@ -0,0 +1,116 @@
+package metric
+
+import (
+	"strings"
+	"sync"
+)
+
+// Base units recorded values are normalized to on export
+const (
+	// UnitSeconds is the base unit of durations
+	UnitSeconds = "seconds"
+	// UnitBytes is the base unit of sizes
+	UnitBytes = "bytes"
+)
+
+// UnitConversion says how values recorded in a unit are exported in its base unit
+type UnitConversion struct {
+	// Base is the unit exported values are in, e.g. UnitSeconds
+	Base string
+	// Symbol is the UCUM symbol of Base used by OpenTelemetry, e.g. "s" or "By"
+	Symbol string
+	// Divisor divides a recorded value to express it in Base, e.g. 1e3 for milliseconds
+	Divisor float64
+}
+
+// Convert returns value, recorded in the converted unit, in the base unit
+func (c UnitConversion) Convert(value float64) float64 {
+	return value / c.Divisor
+}
+
+// Name returns name with the base unit as a suffix, as Prometheus naming
+// conventions ask, unless it already ends with it or there is no base unit
+func (c UnitConversion) Name(name string) string {
+	if c.Base == "" || strings.HasSuffix(name, "_"+c.Base) {
+		return name
+	}
+	return name + "_" + c.Base
+}
+
+var (
+	unitsMu sync.RWMutex
+	units   = map[string]UnitConversion{}
+)
+
+func init() {
+	for divisor, names := range map[float64][]string{
+		1e9:        {"ns", "nanoseconds"},
+		1e6:        {"us", "µs", "microseconds"},
+		1e3:        {"ms", "milliseconds"},
+		1:          {"s", "seconds"},
+		1.0 / 60:   {"min", "minutes"},
+		1.0 / 3600: {"h", "hours"},
+	} {
+		for _, name := range names {
+			units[name] = UnitConversion{Base: UnitSeconds, Symbol: "s", Divisor: divisor}
+		}
+	}
+	for divisor, names := range map[float64][]string{
+		1:               {"B", "By", "bytes"},
+		1e-3:            {"kB", "KB", "kilobytes"},
+		1.0 / 1024:      {"KiB", "kibibytes"},
+		1e-6:            {"MB", "megabytes"},
+		1.0 / (1 << 20): {"MiB", "mebibytes"},
+		1e-9:            {"GB", "gigabytes"},
+		1.0 / (1 << 30): {"GiB", "gibibytes"},
+	} {
+		for _, name := range names {
+			units[name] = UnitConversion{Base: UnitBytes, Symbol: "By", Divisor: divisor}
+		}
+	}
+}
+
+// RegisterUnit adds or replaces the conversion of values recorded in unit,
+// e.g. to export "deciseconds" in seconds. The built-in units cover
+// nanoseconds to hours and bytes to gibibytes, by name and symbol.
+func RegisterUnit(unit string, conversion UnitConversion) {
+	unitsMu.Lock()
+	defer unitsMu.Unlock()
+	units[unit] = conversion
+}
+
+// LookupUnit returns the conversion registered for unit
+func LookupUnit(unit string) (UnitConversion, bool) {
+	unitsMu.RLock()
+	defer unitsMu.RUnlock()
+	conversion, ok := units[unit]
+	return conversion, ok
+}
+
+// timerUnit is the conversion of every timer's values, which are recorded in
+// nanoseconds whatever Options.Unit says
+var timerUnit = UnitConversion{Base: UnitSeconds, Symbol: "s", Divisor: 1e9}
+
+// ExportUnit returns how reporters convert the values of a histogram or timer
+// to base units, so every backend exports them alike. Timers are always
+// converted from nanoseconds to seconds, since that is what they record
+// whatever their unit says. Histograms are converted from their unit if it is
+// registered and exported as recorded otherwise, as are gauges carrying
+// ConvertUnitAnnotation. Counters and other gauges are exported as recorded.
+func ExportUnit(m Metric) UnitConversion {
+	switch m.Type() {
+	case TypeTimer:
+		return timerUnit
+	case TypeHistogram:
+		if conversion, ok := LookupUnit(m.Unit()); ok {
+			return conversion
+		}
+	case TypeGauge:
+		if _, convert := AnnotationOf(m, ConvertUnitAnnotation); convert {
+			if conversion, ok := LookupUnit(m.Unit()); ok {
+				return conversion
+			}
+		}
+	}
+	return UnitConversion{Divisor: 1}
+}
@@ -0,0 +1,53 @@
+package metric
+
+import "testing"
+
+func TestExportUnit(t *testing.T) {
+	registry := NewNoCleanupRegistry()
+	defer registry.Close()
+
+	tests := []struct {
+		metric  Metric
+		name    string
+		divisor float64
+		symbol  string
+	}{
+		{registry.Timer(Options{Name: "rpc", Unit: "milliseconds"}), "rpc_seconds", 1e9, "s"},
+		{registry.Histogram(Options{Name: "query", Unit: "ms"}), "query_seconds", 1e3, "s"},
+		{registry.Histogram(Options{Name: "wait_seconds", Unit: "seconds"}), "wait_seconds", 1, "s"},
+		{registry.Histogram(Options{Name: "payload", Unit: "KiB"}), "payload_bytes", 1.0 / 1024, "By"},
+		{registry.Histogram(Options{Name: "batch", Unit: "items"}), "batch", 1, ""},
+		{registry.Gauge(Options{Name: "heap", Unit: "MB"}), "heap", 1, ""},
+		{registry.Gauge(Options{Name: "uptime", Unit: "ms", Annotations: map[string]string{ConvertUnitAnnotation: "true"}}), "uptime_seconds", 1e3, "s"},
+	}
+	for _, tt := range tests {
+		unit := ExportUnit(tt.metric)
+		if got := unit.Name(tt.metric.Name()); got != tt.name {
+			t.Errorf("%s: expected the name %q, got %q", tt.metric.Name(), tt.name, got)
+		}
+		if unit.Divisor != tt.divisor || unit.Symbol != tt.symbol {
+			t.Errorf("%s: expected divisor %v and symbol %q, got %+v", tt.metric.Name(), tt.divisor, tt.symbol, unit)
+		}
+	}
+
+	if got := ExportUnit(registry.Histogram(Options{Name: "payload", Unit: "KiB"})).Convert(2); got != 2048 {
+		t.Errorf("Expected 2KiB to convert to 2048 bytes, got %v", got)
+	}
+}
+
+func TestRegisterUnit(t *testing.T) {
+	RegisterUnit("deciseconds", UnitConversion{Base: UnitSeconds, Symbol: "s", Divisor: 10})
+	defer func() {
+		unitsMu.Lock()
+		delete(units, "deciseconds")
+		unitsMu.Unlock()
+	}()
+
+	conversion, ok := LookupUnit("deciseconds")
+	if !ok || conversion.Convert(25) != 2.5 {
+		t.Errorf("Expected 25ds to convert to 2.5s, got %+v, %v", conversion, ok)
+	}
+	if _, ok := LookupUnit("furlongs"); ok {
+		t.Error("Expected no conversion for an unregistered unit")
+	}
+}
@@ -0,0 +1,162 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultWatchInterval is the sampling interval used when WatchFilter.Interval is zero
+const DefaultWatchInterval = 100 * time.Millisecond
+
+// ErrRegistryClosed is returned when watching a registry that has been closed
+var ErrRegistryClosed = errors.New("registry is closed")
+
+// MetricUpdate describes a sampled change in a metric's value
+type MetricUpdate struct {
+	// Metric is the metric that changed
+	Metric Metric
+	// Name is the metric name
+	Name string
+	// Type is the metric type
+	Type Type
+	// Tags are the metric's tags at the time of sampling
+	Tags Tags
+	// Value is the counter or gauge value, or the observation count for histograms and timers
+	Value float64
+	// Snapshot holds the full statistics for histograms and timers, nil otherwise
+	Snapshot *HistogramSnapshot
+	// Time is when the change was sampled
+	Time time.Time
+}
+
+// WatchFilter selects which metrics a watch observes and how often they are sampled
+type WatchFilter struct {
+	// Names restricts the watch to metrics with these names (all metrics if empty)
+	Names []string
+	// Types restricts the watch to these metric types (all types if empty)
+	Types []Type
+	// Match is an optional predicate applied after Names and Types
+	Match func(Metric) bool
+	// Interval is how often metrics are sampled (DefaultWatchInterval if zero)
+	Interval time.Duration
+	// Buffer is the capacity of the returned channel
+	Buffer int
+}
+
+// matches reports whether the metric passes the filter
+func (f WatchFilter) matches(m Metric) bool {
+	if len(f.Names) > 0 && !slices.Contains(f.Names, m.Name()) {
+		return false
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, m.Type()) {
+		return false
+	}
+	return f.Match == nil || f.Match(m)
+}
+
+// SampleUpdates polls the registry at the filter's interval and streams an
+// update whenever a selected metric is first seen or its value changes.
+// The channel is closed when ctx is done. Registry implementations use it to
+// back Watch; it works with any Registry that supports Each.
+func SampleUpdates(ctx context.Context, registry Registry, filter WatchFilter) (<-chan MetricUpdate, error) {
+	if filter.Interval < 0 {
+		return nil, fmt.Errorf("watch interval must not be negative, got %s", filter.Interval)
+	}
+	if filter.Interval == 0 {
+		filter.Interval = DefaultWatchInterval
+	}
+	if filter.Buffer < 0 {
+		filter.Buffer = 0
+	}
+
+	updates := make(chan MetricUpdate, filter.Buffer)
+	last := make(map[Metric]float64)
+	goroutines.start(&periodicTask{
+		ctx:       ctx,
+		interval:  filter.Interval,
+		immediate: true,
+		run:       func() bool { return sampleOnce(ctx, registry, filter, last, updates) },
+		done:      func() { close(updates) },
+	})
+
+	return updates, nil
+}
+
+// sampleOnce emits updates for changed metrics, returning false if ctx ended while sending
+func sampleOnce(ctx context.Context, registry Registry, filter WatchFilter, last map[Metric]float64, updates chan<- MetricUpdate) bool {
+	// Collect first so user callbacks and channel sends happen outside Each
+	var selected []Metric
+	registry.Each(func(m Metric) {
+		if filter.matches(m) {
+			selected = append(selected, m)
+		}
+	})
+
+	now := time.Now()
+	seen := make(map[Metric]bool, len(selected))
+	for _, m := range selected {
+		seen[m] = true
+
+		update := MetricUpdate{
+			Metric: m,
+			Name:   m.Name(),
+			Type:   m.Type(),
+			Tags:   m.Tags(),
+			Time:   now,
+		}
+
+		value, snapshot, ok := readValue(m)
+		if !ok {
+			continue
+		}
+		update.Value = value
+		update.Snapshot = snapshot
+
+		if previous, ok := last[m]; ok && previous == update.Value {
+			continue
+		}
+		last[m] = update.Value
+
+		select {
+		case <-ctx.Done():
+			return false
+		case updates <- update:
+		}
+	}
+
+	// Forget metrics that disappeared so a re-registered metric is reported again
+	for m := range last {
+		if !seen[m] {
+			delete(last, m)
+		}
+	}
+
+	return true
+}
+
+// Watch implements the Registry interface. The watch ends when ctx is done or the registry is closed.
+func (r *defaultRegistry) Watch(ctx context.Context, filter WatchFilter) (<-chan MetricUpdate, error) {
+	if r.ctx.Err() != nil {
+		return nil, ErrRegistryClosed
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(r.ctx, cancel)
+
+	updates, err := SampleUpdates(watchCtx, r, filter)
+	if err != nil {
+		stop()
+		cancel()
+		return nil, err
+	}
+
+	// Release the registry hook once the caller's context ends
+	context.AfterFunc(watchCtx, func() {
+		stop()
+	})
+
+	return updates, nil
+}
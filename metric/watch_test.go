@@ -0,0 +1,90 @@
+package metric
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatchStreamsChanges(t *testing.T) {
+	registry := NewNoCleanupRegistry().(WatchRegistry)
+	defer registry.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	counter := registry.Counter(Options{Name: "watched_total"})
+	registry.Gauge(Options{Name: "ignored_gauge"}).Set(3)
+
+	updates, err := registry.Watch(ctx, WatchFilter{
+		Names:    []string{"watched_total"},
+		Interval: 5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Watch() returned error: %v", err)
+	}
+
+	// The first sample reports the current value
+	first := <-updates
+	if first.Name != "watched_total" || first.Value != 0 {
+		t.Fatalf("Unexpected first update: %+v", first)
+	}
+
+	counter.Add(5)
+	for update := range updates {
+		if update.Name != "watched_total" {
+			t.Fatalf("Received update for unselected metric %q", update.Name)
+		}
+		if update.Value == 5 {
+			cancel()
+			break
+		}
+	}
+
+	// The channel closes once the context is cancelled
+	for range updates {
+	}
+}
+
+func TestWatchHistogramIncludesSnapshot(t *testing.T) {
+	registry := NewNoCleanupRegistry().(WatchRegistry)
+	defer registry.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	registry.Timer(Options{Name: "watched_timer"}).Record(time.Millisecond)
+
+	updates, err := registry.Watch(ctx, WatchFilter{Types: []Type{TypeTimer}, Interval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Watch() returned error: %v", err)
+	}
+
+	update := <-updates
+	if update.Snapshot == nil || update.Snapshot.Count != 1 || update.Value != 1 {
+		t.Errorf("Expected timer snapshot with one observation, got %+v", update)
+	}
+}
+
+func TestWatchEndsWhenRegistryCloses(t *testing.T) {
+	registry := NewNoCleanupRegistry().(WatchRegistry)
+
+	updates, err := registry.Watch(context.Background(), WatchFilter{Interval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Watch() returned error: %v", err)
+	}
+	registry.Close()
+
+	select {
+	case _, ok := <-updates:
+		for ok {
+			_, ok = <-updates
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch channel was not closed after registry Close()")
+	}
+
+	if _, err := registry.Watch(context.Background(), WatchFilter{}); err != ErrRegistryClosed {
+		t.Errorf("Expected ErrRegistryClosed, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package metric
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// windowedHistogram implements WindowedHistogram by observing into a histogram
+// for the current window and keeping the snapshot of the last complete one
+type windowedHistogram struct {
+	baseMetric
+	opts Options
+	now  func() time.Time
+	end  atomic.Int64 // unix nanoseconds at which the current window ends
+
+	mu        sync.RWMutex
+	current   *histogramImpl
+	last      HistogramSnapshot
+	lastStart time.Time
+
+	derive func(tags Tags) Histogram // set by the registry to look up With() series
+}
+
+func newWindowedHistogram(opts Options) WindowedHistogram {
+	return newWindowedHistogramAt(opts, time.Now)
+}
+
+// newWindowedHistogramAt creates a windowed histogram reading the time from now
+func newWindowedHistogramAt(opts Options, now func() time.Time) *windowedHistogram {
+	current := newHistogram(opts).(*histogramImpl)
+	start := now()
+	if opts.AlignWindow {
+		start = start.Truncate(opts.Window)
+	}
+
+	w := &windowedHistogram{
+		baseMetric: baseMetric{
+			name:        opts.Name,
+			description: opts.Description,
+			unit:        opts.Unit,
+			metricType:  TypeHistogram,
+			tags:        opts.Tags,
+			annotations: maps.Clone(opts.Annotations),
+		},
+		opts:      opts,
+		now:       now,
+		current:   current,
+		last:      current.Snapshot(),
+		lastStart: start.Add(-opts.Window),
+	}
+	w.end.Store(start.Add(opts.Window).UnixNano())
+	return w
+}
+
+func (w *windowedHistogram) Observe(value float64) {
+	if w.isPaused() {
+		return
+	}
+	w.markWritten()
+	w.rotateIfNecessary()
+
+	w.mu.RLock()
+	w.current.Observe(value)
+	w.mu.RUnlock()
+	observe(w.sink, w, ObservationSample, value)
+}
+
+func (w *windowedHistogram) With(tags Tags) Histogram {
+	if w.derive != nil {
+		return w.derive(copyTags(w.tags, tags))
+	}
+	opts := w.opts
+	opts.Description = w.Description()
+	opts.Unit = w.Unit()
+	opts.Tags = copyTags(w.tags, tags)
+	return newWindowedHistogramAt(opts, w.now)
+}
+
+// Snapshot returns the statistics of the last complete window
+func (w *windowedHistogram) Snapshot() HistogramSnapshot {
+	snapshot, _, _ := w.WindowSnapshot()
+	return snapshot
+}
+
+func (w *windowedHistogram) WindowSnapshot() (HistogramSnapshot, time.Time, time.Time) {
+	w.rotateIfNecessary()
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	snapshot := w.last
+	snapshot.Buckets = slices.Clone(snapshot.Buckets)
+	snapshot.Boundaries = slices.Clone(snapshot.Boundaries)
+	return snapshot, w.lastStart, w.lastStart.Add(w.opts.Window)
+}
+
+// SnapshotAndReset returns the statistics of the last complete window and
+// clears them, so each window is returned once. The current window is untouched.
+func (w *windowedHistogram) SnapshotAndReset() HistogramSnapshot {
+	w.rotateIfNecessary()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	snapshot := w.last
+	w.last = newHistogram(w.opts).Snapshot()
+	return snapshot
+}
+
+// rotateIfNecessary starts a new window once the current one has ended. When
+// whole windows passed without a rotation, the last complete window was empty.
+func (w *windowedHistogram) rotateIfNecessary() {
+	now := w.now()
+	if now.UnixNano() < w.end.Load() {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	end := time.Unix(0, w.end.Load())
+	if now.Before(end) {
+		return
+	}
+	skipped := now.Sub(end) / w.opts.Window
+	start := end.Add(skipped * w.opts.Window)
+
+	if skipped == 0 {
+		w.last = w.current.Snapshot()
+	} else {
+		w.last = newHistogram(w.opts).Snapshot()
+	}
+	w.lastStart = start.Add(-w.opts.Window)
+	w.current = newHistogram(w.opts).(*histogramImpl)
+	w.end.Store(start.Add(w.opts.Window).UnixNano())
+}
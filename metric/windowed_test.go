@@ -0,0 +1,81 @@
+package metric
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowedHistogramAligned(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 40, 0, time.UTC)
+	h := newWindowedHistogramAt(Options{Name: "latency", Window: time.Minute, AlignWindow: true}, func() time.Time { return now })
+
+	h.Observe(1)
+	h.Observe(2)
+	if s := h.Snapshot(); s.Count != 0 {
+		t.Errorf("Expected no complete window yet, got %+v", s)
+	}
+
+	// The first window ends on the full minute, not a minute after creation
+	now = now.Add(20 * time.Second)
+	h.Observe(10)
+	snapshot, start, end := h.WindowSnapshot()
+	if snapshot.Count != 2 || snapshot.Sum != 3 {
+		t.Errorf("Expected the two observations of the first window, got %+v", snapshot)
+	}
+	if !start.Equal(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2026, 1, 1, 12, 1, 0, 0, time.UTC)) {
+		t.Errorf("Expected the window 12:00 to 12:01, got %v to %v", start, end)
+	}
+
+	// Windows without a rotation in between were empty
+	now = now.Add(3 * time.Minute)
+	snapshot, start, _ = h.WindowSnapshot()
+	if snapshot.Count != 0 || !start.Equal(time.Date(2026, 1, 1, 12, 3, 0, 0, time.UTC)) {
+		t.Errorf("Expected the empty window starting 12:03, got %+v from %v", snapshot, start)
+	}
+
+	// SnapshotAndReset returns each complete window once
+	h.Observe(5)
+	now = now.Add(time.Minute)
+	if s := h.SnapshotAndReset(); s.Count != 1 || s.Sum != 5 {
+		t.Errorf("Expected the window with one observation, got %+v", s)
+	}
+	if s := h.SnapshotAndReset(); s.Count != 0 {
+		t.Errorf("Expected the window to be returned once, got %+v", s)
+	}
+}
+
+func TestWindowedHistogramUnaligned(t *testing.T) {
+	created := time.Date(2026, 1, 1, 12, 0, 40, 0, time.UTC)
+	now := created
+	h := newWindowedHistogramAt(Options{Name: "latency", Window: time.Minute}, func() time.Time { return now })
+
+	h.Observe(1)
+	now = now.Add(30 * time.Second)
+	if s := h.Snapshot(); s.Count != 0 {
+		t.Errorf("Expected the window to run a full minute from creation, got %+v", s)
+	}
+	now = now.Add(30 * time.Second)
+	if _, start, _ := h.WindowSnapshot(); !start.Equal(created) {
+		t.Errorf("Expected the window to start at creation, got %v", start)
+	}
+}
+
+func TestRegistryWindowedHistogram(t *testing.T) {
+	registry := NewNoCleanupRegistry().(SeriesRegistry)
+	defer registry.Close()
+
+	opts := Options{Name: "latency", Window: time.Minute, AlignWindow: true}
+	h, ok := registry.Histogram(opts).(WindowedHistogram)
+	if !ok {
+		t.Fatal("Expected a WindowedHistogram")
+	}
+	if registry.Histogram(opts) != h {
+		t.Error("Expected lookups to return the registered histogram")
+	}
+	if _, ok := h.With(Tags{"route": "/"}).(WindowedHistogram); !ok {
+		t.Error("Expected derived series to be windowed")
+	}
+	if got := len(registry.Series("latency")); got != 2 {
+		t.Errorf("Expected the derived series to be registered, got %d series", got)
+	}
+}
@@ -0,0 +1,37 @@
+// Package chimetrics adapts the httpserver middleware to chi routers. Requests
+// are tagged with the route pattern chi matched, such as /users/{id}, rather
+// than the raw path, so IDs in paths do not create a series per URL:
+//
+//	router := chi.NewRouter()
+//	router.Use(chimetrics.Middleware(registry))
+//	router.Get("/users/{id}", getUser)
+package chimetrics
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/middleware/httpserver"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "chimetrics", Package: "github.com/MichaelAJay/go-metrics/middleware/chimetrics"})
+}
+
+// Middleware returns httpserver.Middleware tagging requests with their chi
+// route pattern. opts may override the route with httpserver.WithRoute.
+func Middleware(registry metric.Registry, opts ...httpserver.Option) func(http.Handler) http.Handler {
+	return httpserver.Middleware(registry, append([]httpserver.Option{httpserver.WithRoute(RoutePattern)}, opts...)...)
+}
+
+// RoutePattern returns the route pattern chi matched for r, including the
+// patterns of mounted subrouters, or "" if r was not routed by chi or matched
+// no route
+func RoutePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
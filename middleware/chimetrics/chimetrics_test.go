@@ -0,0 +1,39 @@
+package chimetrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestMiddlewareTagsRoutePattern(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	users := chi.NewRouter()
+	users.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	router := chi.NewRouter()
+	router.Use(Middleware(registry))
+	router.Mount("/users", users)
+
+	for _, path := range []string{"/users/1", "/users/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	tags := metric.Tags{"method": "GET", "route": "/users/{id}", "status": "202"}
+	if got := registry.Counter(metric.Options{Name: "http_server_requests_total", Tags: tags}).Value(); got != 2 {
+		t.Errorf("Expected both requests under the route pattern, got %d", got)
+	}
+}
+
+func TestRoutePatternOutsideChi(t *testing.T) {
+	if got := RoutePattern(httptest.NewRequest("GET", "/users/1", nil)); got != "" {
+		t.Errorf("Expected no pattern outside a chi router, got %q", got)
+	}
+}
@@ -0,0 +1,15 @@
+module github.com/MichaelAJay/go-metrics/middleware/chimetrics
+
+go 1.23.3
+
+require (
+	github.com/MichaelAJay/go-metrics v0.0.0
+	github.com/go-chi/chi/v5 v5.2.1
+)
+
+require (
+	golang.org/x/sys v0.30.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/MichaelAJay/go-metrics => ../..
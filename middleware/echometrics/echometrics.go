@@ -0,0 +1,66 @@
+// Package echometrics adapts the httpserver middleware to echo. Requests are
+// tagged with the route echo matched, such as /users/:id, rather than the raw
+// path, so IDs in paths do not create a series per URL:
+//
+//	e := echo.New()
+//	e.Use(echometrics.Middleware(registry))
+//	e.GET("/users/:id", getUser)
+//
+// Handlers add tags through metric.ScopeFromContext(c.Request().Context()).
+package echometrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/middleware/httpserver"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "echometrics", Package: "github.com/MichaelAJay/go-metrics/middleware/echometrics"})
+}
+
+// Middleware returns echo middleware recording every request into registry
+// with the metrics and options of httpserver.Middleware. httpserver.WithRoute
+// is only asked for requests echo matched no route for.
+func Middleware(registry metric.Registry, opts ...httpserver.Option) echo.MiddlewareFunc {
+	rec := httpserver.NewRecorder(registry, opts...)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			r, scope := rec.Start(c.Request())
+			c.SetRequest(r)
+
+			served := false
+			defer func() {
+				rec.Finish(c.Request(), scope, c.Path(), status(c, err, served))
+			}()
+			err = next(c)
+			served = true
+			return err
+		}
+	}
+}
+
+// status returns the status the request is answered with. Echo's error
+// handler writes the response of a returned error after the middleware chain,
+// so the status of an error not yet written is derived from it.
+func status(c echo.Context, err error, served bool) int {
+	if c.Response().Committed {
+		return c.Response().Status
+	}
+	if !served {
+		return http.StatusInternalServerError
+	}
+	if err != nil {
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			return httpErr.Code
+		}
+		return http.StatusInternalServerError
+	}
+	return c.Response().Status
+}
@@ -0,0 +1,51 @@
+package echometrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func requests(registry metric.Registry, tags metric.Tags) uint64 {
+	return registry.Counter(metric.Options{Name: "http_server_requests_total", Tags: tags}).Value()
+}
+
+func TestMiddleware(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	e := echo.New()
+	e.Use(Middleware(registry))
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.GET("/orders/:id", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusConflict)
+	})
+	e.GET("/fail", func(c echo.Context) error {
+		return errors.New("database down")
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/orders/7", "/fail"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	for _, tc := range []struct {
+		route, status string
+		want          uint64
+	}{
+		{"/users/:id", "204", 2},
+		{"/orders/:id", "409", 1},
+		{"/fail", "500", 1},
+	} {
+		tags := metric.Tags{"method": "GET", "route": tc.route, "status": tc.status}
+		if got := requests(registry, tags); got != tc.want {
+			t.Errorf("Expected %d requests with %v, got %d", tc.want, tags, got)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Package ginmetrics adapts the httpserver middleware to gin. Requests are
+// tagged with the route gin matched, such as /users/:id, rather than the raw
+// path, so IDs in paths do not create a series per URL:
+//
+//	router := gin.New()
+//	router.Use(gin.Recovery(), ginmetrics.Middleware(registry))
+//	router.GET("/users/:id", getUser)
+//
+// Handlers add tags through metric.ScopeFromContext(c.Request.Context()).
+package ginmetrics
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/middleware/httpserver"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "ginmetrics", Package: "github.com/MichaelAJay/go-metrics/middleware/ginmetrics"})
+}
+
+// Middleware returns gin middleware recording every request into registry
+// with the metrics and options of httpserver.Middleware. httpserver.WithRoute
+// is only asked for requests gin matched no route for.
+func Middleware(registry metric.Registry, opts ...httpserver.Option) gin.HandlerFunc {
+	rec := httpserver.NewRecorder(registry, opts...)
+
+	return func(c *gin.Context) {
+		r, scope := rec.Start(c.Request)
+		c.Request = r
+
+		served := false
+		defer func() {
+			status := c.Writer.Status()
+			// A panic is answered with 500 by gin.Recovery, after this middleware
+			if !served && !c.Writer.Written() {
+				status = http.StatusInternalServerError
+			}
+			rec.Finish(c.Request, scope, c.FullPath(), status)
+		}()
+		c.Next()
+		served = true
+	}
+}
@@ -0,0 +1,46 @@
+package ginmetrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/middleware/httpserver"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func requests(registry metric.Registry, name string, tags metric.Tags) uint64 {
+	return registry.Counter(metric.Options{Name: name + "_total", Tags: tags}).Value()
+}
+
+func TestMiddleware(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	router := gin.New()
+	router.Use(gin.Recovery(), Middleware(registry, httpserver.WithName("api_requests")))
+	router.GET("/users/:id", func(c *gin.Context) {
+		metric.ScopeFromContext(c.Request.Context()).SetTag("tenant", "acme")
+		c.Status(http.StatusNotFound)
+	})
+	router.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	for _, path := range []string{"/users/1", "/users/2", "/panic"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	tags := metric.Tags{"method": "GET", "route": "/users/:id", "status": "404", "tenant": "acme"}
+	if got := requests(registry, "api_requests", tags); got != 2 {
+		t.Errorf("Expected both requests under the route, got %d", got)
+	}
+	tags = metric.Tags{"method": "GET", "route": "/panic", "status": "500"}
+	if got := requests(registry, "api_requests", tags); got != 1 {
+		t.Errorf("Expected the panicking request as 500, got %d", got)
+	}
+}
@@ -0,0 +1,229 @@
+// Package httpclient instruments outbound HTTP requests. Its Transport wraps
+// an http.RoundTripper and records each request's latency and status class,
+// the DNS, connect and TLS phases of new connections, and retries, tagged with
+// the target host and, optionally, a route template.
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"maps"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "httpclient", Package: "github.com/MichaelAJay/go-metrics/middleware/httpclient"})
+}
+
+// DefaultName is the name prefix used when WithName is not given, recording
+// http_client_requests_duration, http_client_requests_total,
+// http_client_requests_phase_duration and http_client_requests_retries_total
+const DefaultName = "http_client_requests"
+
+// Phases of the {name}_phase_duration timer
+const (
+	PhaseDNS     = "dns"
+	PhaseConnect = "connect"
+	PhaseTLS     = "tls"
+)
+
+// StatusError is the status_class tag of requests that failed without a response
+const StatusError = "error"
+
+// Option configures the transport
+type Option func(*config)
+
+type config struct {
+	name  string
+	route func(*http.Request) string
+}
+
+// WithName sets the name prefix the request metrics are recorded under
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithRoute sets how the route tag is derived from a request. Return a route
+// template such as /users/{id}, never the raw path, to keep cardinality
+// bounded; an empty result omits the tag. Without it the route tag is omitted.
+func WithRoute(route func(*http.Request) string) Option {
+	return func(c *config) {
+		c.route = route
+	}
+}
+
+// Transport is an http.RoundTripper recording the requests it sends
+type Transport struct {
+	next     http.RoundTripper
+	registry metric.Registry
+	config
+}
+
+// NewTransport returns a transport sending requests with rt, or
+// http.DefaultTransport if rt is nil, and recording them into registry:
+//
+//	{name}_duration{host,method,route,status_class}  timer: time to the response headers
+//	{name}_total{host,method,route,status_class}     counter: requests sent
+//	{name}_phase_duration{host,phase}                timer: DNS lookup, connect and TLS handshake
+//	{name}_retries_total{host,method,route}          counter: attempts after the first; see TrackRetries
+//
+// status_class is the response status class, such as "2xx", or StatusError.
+func NewTransport(rt http.RoundTripper, registry metric.Registry, opts ...Option) *Transport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	t := &Transport{next: rt, registry: registry, config: config{name: DefaultName}}
+	for _, opt := range opts {
+		opt(&t.config)
+	}
+	return t
+}
+
+// RoundTrip sends req with the wrapped transport and records it
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tags := metric.Tags{"host": host(req), "method": req.Method}
+	if t.route != nil {
+		if route := t.route(req); route != "" {
+			tags["route"] = route
+		}
+	}
+	if attempts, ok := req.Context().Value(retriesKey{}).(*atomic.Int64); ok && attempts.Add(1) > 1 {
+		t.registry.Counter(metric.Options{
+			Name:        t.name + "_retries_total",
+			Description: "Retried outbound HTTP requests",
+			Unit:        "count",
+			Tags:        tags,
+		}).Inc()
+	}
+
+	phases := &phaseTracer{transport: t, host: tags["host"]}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), phases.trace()))
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	duration := time.Since(start)
+
+	tags = maps.Clone(tags)
+	tags["status_class"] = StatusError
+	if err == nil {
+		tags["status_class"] = strconv.Itoa(resp.StatusCode/100) + "xx"
+	}
+	t.registry.Timer(metric.Options{
+		Name:        t.name + "_duration",
+		Description: "Duration of outbound HTTP requests until the response headers",
+		Unit:        "nanoseconds",
+		Tags:        tags,
+	}).Record(duration)
+	t.registry.Counter(metric.Options{
+		Name:        t.name + "_total",
+		Description: "Outbound HTTP requests",
+		Unit:        "count",
+		Tags:        tags,
+	}).Inc()
+	return resp, err
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped transport,
+// so http.Client.CloseIdleConnections reaches it
+func (t *Transport) CloseIdleConnections() {
+	if closer, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// host returns the host and port a request is sent to
+func host(req *http.Request) string {
+	if req.URL != nil && req.URL.Host != "" {
+		return req.URL.Host
+	}
+	return req.Host
+}
+
+// retriesKey is the context key of the attempt counter set by TrackRetries
+type retriesKey struct{}
+
+// TrackRetries returns a context counting the requests sent with it, so the
+// transport records every attempt after the first as a retry. Derive it once
+// per logical request, before the retry loop, and send every attempt with it.
+func TrackRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retriesKey{}, new(atomic.Int64))
+}
+
+// phaseTracer times the connection phases of one request. Dialing may try
+// several addresses at once, so connect starts are kept per address.
+type phaseTracer struct {
+	transport *Transport
+	host      string
+
+	mu           sync.Mutex
+	dnsStart     time.Time
+	connectStart map[string]time.Time
+	tlsStart     time.Time
+}
+
+func (p *phaseTracer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			p.mu.Lock()
+			p.dnsStart = time.Now()
+			p.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			p.mu.Lock()
+			start := p.dnsStart
+			p.mu.Unlock()
+			p.record(PhaseDNS, start)
+		},
+		ConnectStart: func(network, addr string) {
+			p.mu.Lock()
+			if p.connectStart == nil {
+				p.connectStart = make(map[string]time.Time)
+			}
+			p.connectStart[network+addr] = time.Now()
+			p.mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			p.mu.Lock()
+			start := p.connectStart[network+addr]
+			p.mu.Unlock()
+			if err == nil {
+				p.record(PhaseConnect, start)
+			}
+		},
+		TLSHandshakeStart: func() {
+			p.mu.Lock()
+			p.tlsStart = time.Now()
+			p.mu.Unlock()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			p.mu.Lock()
+			start := p.tlsStart
+			p.mu.Unlock()
+			if err == nil {
+				p.record(PhaseTLS, start)
+			}
+		},
+	}
+}
+
+// record times phase from start, if it was seen to start
+func (p *phaseTracer) record(phase string, start time.Time) {
+	if start.IsZero() {
+		return
+	}
+	p.transport.registry.Timer(metric.Options{
+		Name:        p.transport.name + "_phase_duration",
+		Description: "Duration of the connection phases of outbound HTTP requests",
+		Unit:        "nanoseconds",
+		Tags:        metric.Tags{"host": p.host, "phase": phase},
+	}).RecordSince(start)
+}
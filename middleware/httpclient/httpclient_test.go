@@ -0,0 +1,82 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	client := &http.Client{Transport: NewTransport(server.Client().Transport, registry,
+		WithRoute(func(*http.Request) string { return "/users/{id}" }))}
+	resp, err := client.Get(server.URL + "/users/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	host := server.Listener.Addr().String()
+	tags := metric.Tags{"host": host, "method": "GET", "route": "/users/{id}", "status_class": "4xx"}
+	if got := registry.Counter(metric.Options{Name: DefaultName + "_total", Tags: tags}).Value(); got != 1 {
+		t.Errorf("Expected the request to be counted with %v, got %d", tags, got)
+	}
+	if got := registry.Timer(metric.Options{Name: DefaultName + "_duration", Tags: tags}).Snapshot().Count; got != 1 {
+		t.Errorf("Expected the request latency to be recorded, got %d observations", got)
+	}
+	for _, phase := range []string{PhaseConnect, PhaseTLS} {
+		phaseTags := metric.Tags{"host": host, "phase": phase}
+		if got := registry.Timer(metric.Options{Name: DefaultName + "_phase_duration", Tags: phaseTags}).Snapshot().Count; got != 1 {
+			t.Errorf("Expected the %s phase to be recorded, got %d observations", phase, got)
+		}
+	}
+}
+
+// failingTransport fails every request
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestTransportRetriesAndErrors(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	client := &http.Client{Transport: NewTransport(failingTransport{}, registry, WithName("api_calls"))}
+	ctx := TrackRetries(context.Background())
+	for range 3 {
+		req, _ := http.NewRequestWithContext(ctx, "POST", "http://payments.internal/charge", nil)
+		if _, err := client.Do(req); err == nil {
+			t.Fatal("Expected the transport's error")
+		}
+	}
+
+	tags := metric.Tags{"host": "payments.internal", "method": "POST"}
+	if got := registry.Counter(metric.Options{Name: "api_calls_retries_total", Tags: tags}).Value(); got != 2 {
+		t.Errorf("Expected two retries, got %d", got)
+	}
+	tags["status_class"] = StatusError
+	if got := registry.Counter(metric.Options{Name: "api_calls_total", Tags: tags}).Value(); got != 3 {
+		t.Errorf("Expected three failed requests, got %d", got)
+	}
+}
+
+func TestHost(t *testing.T) {
+	req := &http.Request{URL: &url.URL{Path: "/"}, Host: "example.com"}
+	if got := host(req); got != "example.com" {
+		t.Errorf("Expected the Host header without a URL host, got %q", got)
+	}
+}
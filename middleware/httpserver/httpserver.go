@@ -0,0 +1,140 @@
+// Package httpserver instruments net/http servers. Each request runs in a
+// metric.Scope tagged with its method, route and status, so handlers can add
+// tags such as the tenant with metric.ScopeFromContext and have them recorded
+// on the request's latency and count.
+package httpserver
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "httpserver", Package: "github.com/MichaelAJay/go-metrics/middleware/httpserver"})
+}
+
+// DefaultName is the scope name used when WithName is not given, recording
+// http_server_requests_duration and http_server_requests_total
+const DefaultName = "http_server_requests"
+
+// Option configures the middleware
+type Option func(*config)
+
+type config struct {
+	name  string
+	route func(*http.Request) string
+}
+
+// WithName sets the scope name the request metrics are recorded under
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithRoute sets how the route tag is derived from a request. It is called
+// after the handler has run, so routers that resolve their pattern while
+// serving can report it. Return a route template such as /users/{id}, never the
+// raw path, to keep cardinality bounded. Without it the route tag is omitted.
+func WithRoute(route func(*http.Request) string) Option {
+	return func(c *config) {
+		c.route = route
+	}
+}
+
+// Middleware returns middleware recording every request into registry
+func Middleware(registry metric.Registry, opts ...Option) func(http.Handler) http.Handler {
+	rec := NewRecorder(registry, opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, scope := rec.Start(r)
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			served := false
+			defer func() {
+				// A panicking handler is answered with 500 by net/http
+				if !served && !recorder.wroteHeader {
+					recorder.status = http.StatusInternalServerError
+				}
+				rec.Finish(r, scope, "", recorder.status)
+			}()
+			next.ServeHTTP(recorder, r)
+			served = true
+		})
+	}
+}
+
+// Recorder records requests for routers whose middleware is not net/http
+// middleware, such as gin and echo, with the same metrics and options as Middleware
+type Recorder struct {
+	registry metric.Registry
+	config
+}
+
+// NewRecorder creates a recorder of requests into registry
+func NewRecorder(registry metric.Registry, opts ...Option) *Recorder {
+	rec := &Recorder{registry: registry, config: config{name: DefaultName}}
+	for _, opt := range opts {
+		opt(&rec.config)
+	}
+	return rec
+}
+
+// Start starts the scope of r and returns r with a context carrying it, to
+// pass on to the handler
+func (rec *Recorder) Start(r *http.Request) (*http.Request, *metric.Scope) {
+	ctx, scope := metric.NewScope(r.Context(), rec.registry, rec.name, metric.Tags{"method": r.Method})
+	return r.WithContext(ctx), scope
+}
+
+// Finish records the request started with Start once the handler has
+// answered with status. route is the route template the router matched;
+// when empty, the WithRoute function is asked, and without a route the tag
+// is omitted.
+func (rec *Recorder) Finish(r *http.Request, scope *metric.Scope, route string, status int) {
+	if route == "" && rec.route != nil {
+		route = rec.route(r)
+	}
+	if route != "" {
+		scope.SetTag("route", route)
+	}
+	scope.SetTag("status", strconv.Itoa(status))
+	scope.End()
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	// Informational responses precede the final status
+	if !s.wroteHeader && code >= 200 {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher when the underlying writer does
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		s.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
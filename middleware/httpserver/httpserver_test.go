@@ -0,0 +1,47 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestMiddleware(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	handler := Middleware(registry, WithRoute(func(*http.Request) string { return "/users/{id}" }))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			metric.ScopeFromContext(r.Context()).SetTag("tenant", "acme")
+			w.WriteHeader(http.StatusNotFound)
+		}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	tags := metric.Tags{"method": "GET", "route": "/users/{id}", "status": "404", "tenant": "acme"}
+	if got := registry.Counter(metric.Options{Name: DefaultName + "_total", Tags: tags}).Value(); got != 1 {
+		t.Errorf("Expected the request to be counted with %v, got %d", tags, got)
+	}
+	if got := registry.Timer(metric.Options{Name: DefaultName + "_duration", Tags: tags}).Snapshot().Count; got != 1 {
+		t.Errorf("Expected the request latency to be recorded, got %d observations", got)
+	}
+}
+
+func TestMiddlewarePanic(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	handler := Middleware(registry, WithName("api_requests"))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	func() {
+		defer func() { recover() }()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil))
+	}()
+
+	tags := metric.Tags{"method": "POST", "status": "500"}
+	if got := registry.Counter(metric.Options{Name: "api_requests_total", Tags: tags}).Value(); got != 1 {
+		t.Errorf("Expected a panicking request to be counted as 500, got %d", got)
+	}
+}
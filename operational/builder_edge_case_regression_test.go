@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/testutil"
 )
 
 // TestPhase4EdgeCases implements comprehensive edge case testing for MetricsBuilder
@@ -139,11 +140,12 @@ func testVeryLongTagValues(t *testing.T) {
 
 			defer func() {
 				if r := recover(); r != nil {
-					// This is expected for values that exceed limits
-					if tt.length <= 200 {
-						t.Errorf("Unexpected panic with %d char value: %v", tt.length, r)
+					// This is expected for values that exceed limits, which
+					// are counted in bytes
+					if len(longValue) <= 200 {
+						t.Errorf("Unexpected panic with %d byte value: %v", len(longValue), r)
 					} else {
-						t.Logf("Expected panic with %d char value: %v", tt.length, r)
+						t.Logf("Expected panic with %d byte value: %v", len(longValue), r)
 					}
 				}
 			}()
@@ -478,6 +480,11 @@ func testConcurrentAccessDuringShutdown(t *testing.T) {
 	om := New(registry)
 	builder := NewMetricsBuilder(om)
 
+	// Workers report progress to a separate registry that outlives the one being closed
+	progress := metric.NewNoCleanupRegistry()
+	defer progress.Close()
+	operations := progress.Counter(metric.Options{Name: "shutdown_test_operations"})
+
 	const numWorkers = 50
 	var wg sync.WaitGroup
 	stopChan := make(chan struct{})
@@ -511,23 +518,21 @@ func testConcurrentAccessDuringShutdown(t *testing.T) {
 					}()
 
 					operationCount++
+					operations.Inc()
 					time.Sleep(1 * time.Millisecond)
 				}
 			}
 		}(i)
 	}
 
-	// Let operations run for a short time
-	time.Sleep(100 * time.Millisecond)
+	// Let every worker get some operations in
+	testutil.EventuallyCounter(t, progress, "shutdown_test_operations", numWorkers*5, 5*time.Second)
 
-	// Initiate shutdown while operations are still running
-	go func() {
-		time.Sleep(50 * time.Millisecond)
-		registry.Close()
-	}()
+	// Shut down while operations are still running
+	registry.Close()
 
-	// Stop all workers after a short delay
-	time.Sleep(200 * time.Millisecond)
+	// Stop all workers once they have kept operating on the closed registry
+	testutil.EventuallyCounter(t, progress, "shutdown_test_operations", operations.Value()+numWorkers*5, 5*time.Second)
 	close(stopChan)
 
 	// Wait for all workers to complete
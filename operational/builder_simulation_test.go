@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/metric/loadgen"
 )
 
 // TestRealWorldAuthServiceSimulation implements Phase 3: Real-World Simulation
@@ -49,61 +50,33 @@ func TestRealWorldAuthServiceSimulation(t *testing.T) {
 		"authentication_success_rate", "mfa_adoption"}
 	businessCategories := []string{"completed", "abandoned", "organic", "converted", "premium"}
 
-	ctx, cancel := context.WithTimeout(context.Background(), testDuration)
-	defer cancel()
-
 	var (
-		totalOps      int64
-		authOps       int64
-		securityOps   int64
-		businessOps   int64
-		wg            sync.WaitGroup
-		startTime     = time.Now()
+		authOps     int64
+		securityOps int64
+		businessOps int64
+		seed        atomic.Int64
 	)
+	seed.Store(time.Now().UnixNano())
+	rngs := sync.Pool{New: func() any { return rand.New(rand.NewSource(seed.Add(1))) }}
+
+	generator, err := loadgen.New(registry, loadgen.Config{
+		Name:     "auth_simulation",
+		RPS:      targetRPS,
+		Workers:  workerCount,
+		Duration: testDuration,
+	})
+	if err != nil {
+		t.Fatalf("loadgen.New: %v", err)
+	}
 
 	t.Logf("Starting auth service workload simulation: %d workers, %d req/sec target, %v duration",
 		workerCount, targetRPS, testDuration)
 
-	// Launch worker goroutines
-	for i := 0; i < workerCount; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-
-			rng := rand.New(rand.NewSource(int64(workerID) + time.Now().UnixNano()))
-			opsPerWorker := targetRPS / workerCount
-			ticker := time.NewTicker(time.Second / time.Duration(opsPerWorker))
-			defer ticker.Stop()
-
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case <-ticker.C:
-					// Simulate auth service operation mix
-					operationType := rng.Intn(10)
-
-					switch {
-					case operationType < 6: // 60% authentication operations
-						simulateAuthOperation(builder, rng, providers, statuses, userTypes, regions)
-						atomic.AddInt64(&authOps, 1)
-
-					case operationType < 8: // 20% security events
-						simulateSecurityEvent(builder, rng, securityEvents, securityActions)
-						atomic.AddInt64(&securityOps, 1)
-
-					default: // 20% business metrics
-						simulateBusinessMetric(builder, rng, businessMetrics, businessCategories, regions)
-						atomic.AddInt64(&businessOps, 1)
-					}
-
-					atomic.AddInt64(&totalOps, 1)
-				}
-			}
-		}(i)
-	}
-
 	// Progress reporting goroutine
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startTime := time.Now()
+	requests := registry.Counter(metric.Options{Name: "auth_simulation_requests_total"})
 	go func() {
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
@@ -114,27 +87,49 @@ func TestRealWorldAuthServiceSimulation(t *testing.T) {
 				return
 			case <-ticker.C:
 				elapsed := time.Since(startTime)
-				ops := atomic.LoadInt64(&totalOps)
+				ops := requests.Value()
 				rate := float64(ops) / elapsed.Seconds()
 				t.Logf("Progress: %v elapsed, %d ops, %.1f ops/sec", elapsed.Truncate(time.Second), ops, rate)
 			}
 		}
 	}()
 
-	// Wait for completion
-	wg.Wait()
-	elapsed := time.Since(startTime)
+	result, err := generator.Run(context.Background(), func(context.Context) error {
+		rng := rngs.Get().(*rand.Rand)
+		defer rngs.Put(rng)
+
+		// Simulate auth service operation mix
+		operationType := rng.Intn(10)
+
+		switch {
+		case operationType < 6: // 60% authentication operations
+			simulateAuthOperation(builder, rng, providers, statuses, userTypes, regions)
+			atomic.AddInt64(&authOps, 1)
+
+		case operationType < 8: // 20% security events
+			simulateSecurityEvent(builder, rng, securityEvents, securityActions)
+			atomic.AddInt64(&securityOps, 1)
+
+		default: // 20% business metrics
+			simulateBusinessMetric(builder, rng, businessMetrics, businessCategories, regions)
+			atomic.AddInt64(&businessOps, 1)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
 
 	// Report final statistics
-	totalOperations := atomic.LoadInt64(&totalOps)
+	totalOperations := int64(result.Requests)
 	authOperations := atomic.LoadInt64(&authOps)
 	securityOperations := atomic.LoadInt64(&securityOps)
 	businessOperations := atomic.LoadInt64(&businessOps)
-	actualRPS := float64(totalOperations) / elapsed.Seconds()
+	actualRPS := result.AchievedRPS
 
 	t.Logf("Auth Service Workload Simulation Complete:")
-	t.Logf("  Duration: %v", elapsed)
-	t.Logf("  Total Operations: %d", totalOperations)
+	t.Logf("  Duration: %v", result.Elapsed)
+	t.Logf("  Total Operations: %d (%d dropped)", totalOperations, result.Dropped)
 	t.Logf("  Authentication Ops: %d (%.1f%%)", authOperations, float64(authOperations)/float64(totalOperations)*100)
 	t.Logf("  Security Ops: %d (%.1f%%)", securityOperations, float64(securityOperations)/float64(totalOperations)*100)
 	t.Logf("  Business Ops: %d (%.1f%%)", businessOperations, float64(businessOperations)/float64(totalOperations)*100)
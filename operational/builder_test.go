@@ -1,6 +1,10 @@
 package operational
 
 import (
+	"context"
+	"errors"
+	"runtime/pprof"
+	"strings"
 	"testing"
 	"time"
 
@@ -113,4 +117,182 @@ func TestMetricsBuilder_ContextualMetrics(t *testing.T) {
 	if registry == nil {
 		t.Error("Registry should not be nil")
 	}
-}
\ No newline at end of file
+}
+func TestMetricsBuilder_RunWithLabels(t *testing.T) {
+	mock := NewMockOperationalMetrics()
+	builder := NewMetricsBuilder(mock)
+
+	labels := make(map[string]string)
+	failure := errors.New("payment declined")
+	err := builder.RunWithLabels(context.Background(), "checkout", func(ctx context.Context) error {
+		pprof.ForLabels(ctx, func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+		return failure
+	}, "route", "/cart")
+
+	if err != failure {
+		t.Errorf("Expected fn error to be returned, got %v", err)
+	}
+	if labels["operation"] != "checkout" || labels["route"] != "/cart" {
+		t.Errorf("Expected pprof labels for operation and route, got %v", labels)
+	}
+	if mock.GetOperationCallCount("checkout", "error") != 1 {
+		t.Error("Expected the operation to be recorded with error status")
+	}
+	if mock.GetOperationCallCount("checkout_route", "/cart") != 1 {
+		t.Error("Expected the route to be recorded as contextual metric")
+	}
+}
+
+func TestNewMetricsBuilderWithTags(t *testing.T) {
+	t.Run("base tags on every call", func(t *testing.T) {
+		mock := NewMockOperationalMetrics()
+		base := metric.Tags{"service": "auth", "region": "eu"}
+		builder := NewMetricsBuilderWithTags(mock, base)
+		base["region"] = "us" // the builder keeps its own copy
+
+		builder.RecordWithContext("login", "success", time.Millisecond, map[string]string{"provider": "password"})
+		builder.RecordSecurityEvent("brute_force", "blocked", nil)
+		builder.RecordBusinessMetric("conversion", "organic", 1, nil)
+
+		calls := mock.OperationCalls
+		if len(calls) != 4 {
+			t.Fatalf("Expected 4 operation calls, got %d", len(calls))
+		}
+		for _, call := range calls {
+			if call.Tags["service"] != "auth" || call.Tags["region"] != "eu" {
+				t.Errorf("Expected base tags on %s, got %v", call.Operation, call.Tags)
+			}
+		}
+	})
+
+	t.Run("builders with different tags record distinct series", func(t *testing.T) {
+		registry := metric.NewNoCleanupRegistry()
+		defer registry.Close()
+		om := New(registry)
+
+		NewMetricsBuilderWithTags(om, metric.Tags{"region": "eu"}).RecordWithContext("login", "success", time.Millisecond, nil)
+		NewMetricsBuilderWithTags(om, metric.Tags{"region": "us"}).RecordWithContext("login", "success", time.Millisecond, nil)
+		NewMetricsBuilderWithTags(om, metric.Tags{"region": "us"}).RecordWithContext("login", "success", time.Millisecond, nil)
+
+		counts := make(map[string]uint64)
+		registry.Each(func(m metric.Metric) {
+			if c, ok := m.(metric.Counter); ok && m.Name() == "login_total" {
+				counts[m.Tags()["region"]] += c.Value()
+			}
+		})
+		if counts["eu"] != 1 || counts["us"] != 2 {
+			t.Errorf("Expected 1 eu and 2 us operations, got %v", counts)
+		}
+	})
+}
+
+func TestMetricsBuilder_WithContextAsTags(t *testing.T) {
+	t.Run("context becomes tags", func(t *testing.T) {
+		registry := metric.NewNoCleanupRegistry()
+		defer registry.Close()
+		builder := NewMetricsBuilderWithTags(New(registry), metric.Tags{"service": "auth"}, WithContextAsTags())
+
+		builder.RecordWithContext("authentication", "success", time.Millisecond, map[string]string{"provider": "password"})
+		builder.RecordWithTags("authentication", "failure", time.Millisecond, "provider", "oauth")
+
+		names := make(map[string]int)
+		var counters []metric.Tags
+		registry.Each(func(m metric.Metric) {
+			names[m.Name()]++
+			if m.Name() == "authentication_total" && m.Tags()["status"] != "" {
+				counters = append(counters, m.Tags())
+			}
+		})
+		if names["authentication_provider_total"] != 0 {
+			t.Error("Expected no per-key operation metrics with context as tags")
+		}
+		if len(counters) != 2 {
+			t.Fatalf("Expected 2 tagged authentication counters, got %v", counters)
+		}
+		for _, tags := range counters {
+			if tags["service"] != "auth" || tags["provider"] == "" || tags["operation"] != "authentication" {
+				t.Errorf("Expected service, provider and operation tags, got %v", tags)
+			}
+		}
+	})
+
+	t.Run("reserved and invalid context", func(t *testing.T) {
+		mock := NewMockOperationalMetrics()
+		builder := NewMetricsBuilder(mock, WithContextAsTags())
+
+		builder.RecordSecurityEvent("login", "blocked", map[string]string{"status": "spoofed", "ip": "10.0.0.1"})
+		builder.RecordWithContext("upload", "success", 0, map[string]string{"file": strings.Repeat("x", 500)})
+
+		calls := mock.OperationCalls
+		if len(calls) != 2 {
+			t.Fatalf("Expected one call per record, got %d", len(calls))
+		}
+		if calls[0].Operation != "security_login" || calls[0].Status != "blocked" || calls[0].Tags["ip"] != "10.0.0.1" || calls[0].Tags["status"] != "" {
+			t.Errorf("Expected context tags without the reserved status key, got %+v", calls[0])
+		}
+		if calls[1].Operation != "upload" || calls[1].Tags != nil {
+			t.Errorf("Expected invalid context to be dropped, got %+v", calls[1])
+		}
+	})
+
+	t.Run("validated with the registry's config", func(t *testing.T) {
+		config := metric.DefaultTagValidationConfig()
+		config.MaxValueLength = 1000
+		registry := metric.NewRegistry(config, 0)
+		defer registry.Close()
+		builder := NewMetricsBuilder(New(registry), WithContextAsTags())
+
+		file := strings.Repeat("x", 500)
+		builder.RecordWithContext("upload", "success", 0, map[string]string{"file": file})
+		counter := registry.Counter(metric.Options{
+			Name: "upload_total",
+			Tags: metric.Tags{"operation": "upload", "status": "success", "file": file},
+		})
+		if counter.Value() != 1 {
+			t.Error("Expected context within the registry's limits to be kept as tags")
+		}
+	})
+}
+
+func TestMetricsBuilder_WithDefaultContext(t *testing.T) {
+	t.Run("merged into context as tags", func(t *testing.T) {
+		mock := NewMockOperationalMetrics()
+		defaults := map[string]string{"service": "auth", "region": "us-east"}
+		builder := NewMetricsBuilder(mock, WithContextAsTags(), WithDefaultContext(defaults))
+		defaults["region"] = "changed" // the builder keeps its own copy
+
+		builder.RecordWithContext("login", "success", time.Millisecond, nil)
+		builder.RecordWithContext("login", "success", time.Millisecond, map[string]string{"region": "eu-west", "provider": "password"})
+		builder.RecordSecurityEvent("login", "blocked", nil)
+
+		calls := mock.OperationCalls
+		if len(calls) != 3 {
+			t.Fatalf("Expected 3 calls, got %d", len(calls))
+		}
+		if calls[0].Tags["service"] != "auth" || calls[0].Tags["region"] != "us-east" {
+			t.Errorf("Expected the default context as tags, got %v", calls[0].Tags)
+		}
+		if calls[1].Tags["service"] != "auth" || calls[1].Tags["region"] != "eu-west" || calls[1].Tags["provider"] != "password" {
+			t.Errorf("Expected call context to win over defaults, got %v", calls[1].Tags)
+		}
+		if calls[2].Operation != "security_login" || calls[2].Tags["service"] != "auth" {
+			t.Errorf("Expected the default context on security events, got %+v", calls[2])
+		}
+	})
+
+	t.Run("merged into context as operations", func(t *testing.T) {
+		mock := NewMockOperationalMetrics()
+		builder := NewMetricsBuilder(mock, WithDefaultContext(map[string]string{"service": "auth"}))
+
+		builder.RecordWithContext("login", "success", time.Millisecond, map[string]string{"provider": "password"})
+
+		if mock.GetOperationCallCount("login", "success") != 1 ||
+			mock.GetOperationCallCount("login_service", "auth") != 1 ||
+			mock.GetOperationCallCount("login_provider", "password") != 1 {
+			t.Errorf("Expected an operation per default and call context key, got %+v", mock.OperationCalls)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package operational
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// BusinessMetrics records business values, such as order totals or active
+// subscriptions, as histograms and gauges in their own unit rather than as
+// operation timers
+type BusinessMetrics interface {
+	// RecordValue observes value in the distribution business_<metricType>,
+	// e.g. RecordValue("order_value", "premium", 49.90, "dollars", nil)
+	RecordValue(metricType, category string, value float64, unit string, tags metric.Tags)
+
+	// SetValue sets the current level business_<metricType>_current,
+	// e.g. SetValue("subscriptions", "trial", 1200, "subscriptions", nil).
+	// Gauges hold whole numbers, so record fractional levels in a smaller unit (e.g. cents).
+	SetValue(metricType, category string, value float64, unit string, tags metric.Tags)
+}
+
+// businessMetrics implements the BusinessMetrics interface
+type businessMetrics struct {
+	registry metric.Registry
+
+	// Cached metric instances for performance
+	histograms map[string]metric.Histogram
+	gauges     map[string]metric.Gauge
+
+	mu sync.RWMutex
+}
+
+// NewBusinessMetrics creates a BusinessMetrics recording into registry
+func NewBusinessMetrics(registry metric.Registry) BusinessMetrics {
+	return &businessMetrics{
+		registry:   registry,
+		histograms: make(map[string]metric.Histogram),
+		gauges:     make(map[string]metric.Gauge),
+	}
+}
+
+// RecordValue implements the BusinessMetrics interface
+func (bm *businessMetrics) RecordValue(metricType, category string, value float64, unit string, tags metric.Tags) {
+	var buf [cacheKeyBufferSize]byte
+	key := appendCacheKey(buf[:0], metricType, category, unit)
+	key = appendTagsKey(key, tags)
+
+	bm.mu.RLock()
+	histogram, exists := bm.histograms[string(key)]
+	bm.mu.RUnlock()
+
+	if !exists {
+		opts := businessOptions(metricType, category, unit, tags)
+		opts.Name = fmt.Sprintf("business_%s", metricType)
+		opts.Description = fmt.Sprintf("Distribution of %s", metricType)
+
+		bm.mu.Lock()
+		if histogram, exists = bm.histograms[string(key)]; !exists {
+			histogram = withinLimit(
+				func() metric.Histogram { return bm.registry.Histogram(opts) },
+				func() metric.Histogram { return overflow.Histogram(opts) },
+			)
+			bm.histograms[string(key)] = histogram
+		}
+		bm.mu.Unlock()
+	}
+
+	histogram.Observe(value)
+}
+
+// SetValue implements the BusinessMetrics interface
+func (bm *businessMetrics) SetValue(metricType, category string, value float64, unit string, tags metric.Tags) {
+	var buf [cacheKeyBufferSize]byte
+	key := appendCacheKey(buf[:0], metricType, category, unit)
+	key = appendTagsKey(key, tags)
+
+	bm.mu.RLock()
+	gauge, exists := bm.gauges[string(key)]
+	bm.mu.RUnlock()
+
+	if !exists {
+		opts := businessOptions(metricType, category, unit, tags)
+		opts.Name = fmt.Sprintf("business_%s_current", metricType)
+		opts.Description = fmt.Sprintf("Current %s", metricType)
+
+		bm.mu.Lock()
+		if gauge, exists = bm.gauges[string(key)]; !exists {
+			gauge = withinLimit(
+				func() metric.Gauge { return bm.registry.Gauge(opts) },
+				func() metric.Gauge { return overflow.Gauge(opts) },
+			)
+			bm.gauges[string(key)] = gauge
+		}
+		bm.mu.Unlock()
+	}
+
+	gauge.Set(value)
+}
+
+// businessOptions returns the unit and tags shared by business histograms and gauges
+func businessOptions(metricType, category, unit string, tags metric.Tags) metric.Options {
+	finalTags := make(metric.Tags, len(tags)+1)
+	maps.Copy(finalTags, tags)
+	finalTags["category"] = category
+
+	return metric.Options{
+		Unit: unit,
+		Tags: finalTags,
+	}
+}
@@ -0,0 +1,52 @@
+package operational
+
+import (
+	"testing"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestBusinessMetrics(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	bm := NewBusinessMetrics(registry)
+
+	bm.RecordValue("order_value", "premium", 49.5, "dollars", metric.Tags{"region": "eu"})
+	bm.RecordValue("order_value", "premium", 10.5, "dollars", metric.Tags{"region": "eu"})
+	bm.RecordValue("order_value", "basic", 5, "dollars", nil)
+	bm.SetValue("subscriptions", "trial", 1200, "subscriptions", nil)
+
+	series := metric.SeriesOf(registry, "business_order_value")
+	if len(series) != 2 {
+		t.Fatalf("Expected 2 order value series, got %d", len(series))
+	}
+	premium := series[1]
+	if premium.Tags["category"] != "premium" || premium.Tags["region"] != "eu" || premium.Snapshot == nil {
+		t.Fatalf("Expected the premium eu histogram, got %+v", premium)
+	}
+	if premium.Snapshot.Count != 2 || premium.Snapshot.Sum != 60 {
+		t.Errorf("Expected 2 orders summing to 60 dollars, got count %d sum %v", premium.Snapshot.Count, premium.Snapshot.Sum)
+	}
+	if unit := premium.Metric.Unit(); unit != "dollars" {
+		t.Errorf("Expected unit dollars, got %q", unit)
+	}
+
+	gauge := metric.SeriesOf(registry, "business_subscriptions_current")
+	if len(gauge) != 1 || gauge[0].Type != metric.TypeGauge || gauge[0].Value != 1200 {
+		t.Errorf("Expected a subscriptions gauge of 1200, got %+v", gauge)
+	}
+}
+
+func TestMockBusinessMetrics(t *testing.T) {
+	var bm BusinessMetrics = NewMockBusinessMetrics()
+	bm.RecordValue("order_value", "premium", 49.5, "dollars", metric.Tags{"region": "eu"})
+	bm.SetValue("subscriptions", "trial", 3, "subscriptions", nil)
+
+	mock := bm.(*MockBusinessMetrics)
+	if len(mock.RecordValueCalls) != 1 || mock.RecordValueCalls[0].Unit != "dollars" || mock.RecordValueCalls[0].Tags["region"] != "eu" {
+		t.Errorf("Expected the RecordValue call to be captured, got %+v", mock.RecordValueCalls)
+	}
+	if len(mock.SetValueCalls) != 1 || mock.SetValueCalls[0].Value != 3 {
+		t.Errorf("Expected the SetValue call to be captured, got %+v", mock.SetValueCalls)
+	}
+}
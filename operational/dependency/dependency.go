@@ -0,0 +1,246 @@
+// Package dependency records the health of outbound dependency calls: success
+// and error counts, latency, availability over a sliding window and the state
+// of any circuit breaker guarding the dependency.
+package dependency
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/operational/internal/window"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "dependency", Package: "github.com/MichaelAJay/go-metrics/operational/dependency"})
+}
+
+const (
+	// CallsMetric counts calls per dependency, tagged dependency and
+	// result=success|error|rejected
+	CallsMetric = "dependency_calls_total"
+	// DurationMetric is the timer of call latency per dependency
+	DurationMetric = "dependency_call_duration"
+	// AvailabilityMetric is the gauge of successful calls over the window, in
+	// parts per million of all calls; 1000000 without calls
+	AvailabilityMetric = "dependency_availability"
+	// CircuitStateMetric is the gauge of each dependency's CircuitState
+	CircuitStateMetric = "dependency_circuit_state"
+)
+
+// DefaultWindow is the sliding window availability is computed over by default
+const DefaultWindow = 5 * time.Minute
+
+// ppm scales the availability gauge, since gauges hold integers
+const ppm = 1e6
+
+// CircuitState is the state of a circuit breaker, reported as the value of CircuitStateMetric
+type CircuitState int
+
+const (
+	// CircuitClosed lets calls through
+	CircuitClosed CircuitState = iota
+	// CircuitHalfOpen lets trial calls through to test recovery
+	CircuitHalfOpen
+	// CircuitOpen rejects calls without making them
+	CircuitOpen
+)
+
+// String returns the lowercase name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half_open"
+	case CircuitOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// Option configures a Tracker
+type Option func(*Tracker)
+
+// WithWindow sets the sliding window availability is computed over
+func WithWindow(d time.Duration) Option {
+	return func(t *Tracker) {
+		if d > 0 {
+			t.window = d
+		}
+	}
+}
+
+// WithClock sets the time source used for the sliding window and call
+// durations, for tests
+func WithClock(now func() time.Time) Option {
+	return func(t *Tracker) {
+		t.now = now
+	}
+}
+
+// dependency holds the metrics and window of one dependency
+type dependency struct {
+	successes    metric.Counter
+	errors       metric.Counter
+	rejected     metric.Counter
+	duration     metric.Timer
+	availability metric.Gauge
+	circuit      metric.Gauge
+
+	mu     sync.Mutex
+	window *window.Window
+}
+
+// Tracker records outbound dependency calls
+type Tracker struct {
+	registry metric.Registry
+	window   time.Duration
+	now      func() time.Time
+
+	mu           sync.RWMutex
+	dependencies map[string]*dependency
+}
+
+// New creates a Tracker recording into registry
+func New(registry metric.Registry, opts ...Option) *Tracker {
+	t := &Tracker{
+		registry:     registry,
+		window:       DefaultWindow,
+		now:          time.Now,
+		dependencies: make(map[string]*dependency),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// get returns the state of the named dependency, creating its metrics on first use
+func (t *Tracker) get(name string) *dependency {
+	t.mu.RLock()
+	d, ok := t.dependencies[name]
+	t.mu.RUnlock()
+	if ok {
+		return d
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if d, ok := t.dependencies[name]; ok {
+		return d
+	}
+
+	tags := metric.Tags{"dependency": name}
+	calls := t.registry.Counter(metric.Options{
+		Name:        CallsMetric,
+		Description: "Outbound calls to dependencies",
+		Unit:        "count",
+		Tags:        tags,
+	})
+	d = &dependency{
+		successes: calls.With(metric.Tags{"result": "success"}),
+		errors:    calls.With(metric.Tags{"result": "error"}),
+		rejected:  calls.With(metric.Tags{"result": "rejected"}),
+		duration: t.registry.Timer(metric.Options{
+			Name:        DurationMetric,
+			Description: "Latency of outbound calls to dependencies",
+			Unit:        "nanoseconds",
+			Tags:        tags,
+		}),
+		availability: t.registry.Gauge(metric.Options{
+			Name:        AvailabilityMetric,
+			Description: "Share of successful dependency calls over the sliding window",
+			Unit:        "ppm",
+			Tags:        tags,
+		}),
+		circuit: t.registry.Gauge(metric.Options{
+			Name:        CircuitStateMetric,
+			Description: "Circuit breaker state: 0 closed, 1 half open, 2 open",
+			Tags:        tags,
+		}),
+		window: window.New(t.window),
+	}
+	d.availability.Set(ppm)
+	t.dependencies[name] = d
+	return d
+}
+
+// Record records a call to the named dependency that took duration and
+// returned err; a nil err is a success
+func (t *Tracker) Record(name string, duration time.Duration, err error) {
+	d := t.get(name)
+	if err == nil {
+		d.successes.Inc()
+	} else {
+		d.errors.Inc()
+	}
+	d.duration.Record(duration)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := t.now()
+	d.window.Add(now, err == nil)
+	d.availability.Set(math.Round(availability(d.window.Counts(now)) * ppm))
+}
+
+// WrapCall calls fn, records it as a call to the named dependency and returns
+// its error. A panic in fn is recorded as an error and then re-panicked.
+func (t *Tracker) WrapCall(name string, fn func() error) (err error) {
+	start := t.now()
+	panicked := true
+	defer func() {
+		if panicked {
+			t.Record(name, t.now().Sub(start), errPanic)
+		}
+	}()
+
+	err = fn()
+	panicked = false
+	t.Record(name, t.now().Sub(start), err)
+	return err
+}
+
+// errPanic stands in for the error of a call that panicked
+var errPanic = errors.New("dependency call panicked")
+
+// Availability returns the share of successful calls to the named dependency
+// over the sliding window ending now, from 0 to 1, and whether the dependency
+// has been seen. It also refreshes the availability gauge, so calls leaving the
+// window are reflected even when no new calls are made.
+func (t *Tracker) Availability(name string) (float64, bool) {
+	t.mu.RLock()
+	d, ok := t.dependencies[name]
+	t.mu.RUnlock()
+	if !ok {
+		return 0, false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	a := availability(d.window.Counts(t.now()))
+	d.availability.Set(math.Round(a * ppm))
+	return a, true
+}
+
+// RecordRejected counts a call to the named dependency that an open circuit
+// breaker rejected without making it. Rejected calls do not affect availability.
+func (t *Tracker) RecordRejected(name string) {
+	t.get(name).rejected.Inc()
+}
+
+// SetCircuitState reports the state of the circuit breaker guarding the named dependency
+func (t *Tracker) SetCircuitState(name string, state CircuitState) {
+	t.get(name).circuit.Set(float64(state))
+}
+
+// availability returns good/total, or 1 without calls
+func availability(good, total uint64) float64 {
+	if total == 0 {
+		return 1
+	}
+	return float64(good) / float64(total)
+}
@@ -0,0 +1,104 @@
+package dependency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// value returns the value of the series of name with the given tags
+func value(t *testing.T, registry metric.Registry, name string, tags metric.Tags) float64 {
+	t.Helper()
+	for _, series := range metric.SeriesOf(registry, name) {
+		match := true
+		for k, v := range tags {
+			if series.Tags[k] != v {
+				match = false
+			}
+		}
+		if match {
+			return series.Value
+		}
+	}
+	t.Fatalf("No %s series with tags %v", name, tags)
+	return 0
+}
+
+func TestTracker(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := New(registry, WithWindow(time.Minute), WithClock(func() time.Time { return now }))
+
+	failure := errors.New("connection refused")
+	for i := range 4 {
+		err := tracker.WrapCall("payments", func() error {
+			now = now.Add(10 * time.Millisecond)
+			if i == 0 {
+				return failure
+			}
+			return nil
+		})
+		if i == 0 && err != failure {
+			t.Errorf("Expected WrapCall to return the call's error, got %v", err)
+		}
+	}
+	tracker.RecordRejected("payments")
+
+	payments := metric.Tags{"dependency": "payments"}
+	if got := value(t, registry, CallsMetric, metric.Tags{"dependency": "payments", "result": "success"}); got != 3 {
+		t.Errorf("Expected 3 successes, got %v", got)
+	}
+	if got := value(t, registry, CallsMetric, metric.Tags{"dependency": "payments", "result": "error"}); got != 1 {
+		t.Errorf("Expected 1 error, got %v", got)
+	}
+	if got := value(t, registry, CallsMetric, metric.Tags{"dependency": "payments", "result": "rejected"}); got != 1 {
+		t.Errorf("Expected 1 rejected call, got %v", got)
+	}
+	series := metric.SeriesOf(registry, DurationMetric)
+	if len(series) != 1 || series[0].Snapshot.Sum != float64(40*time.Millisecond) {
+		t.Errorf("Expected 40ms of recorded latency, got %+v", series)
+	}
+	if got := value(t, registry, AvailabilityMetric, payments); got != 750000 {
+		t.Errorf("Expected availability of 750000 ppm, got %v", got)
+	}
+
+	// Calls leave the sliding window
+	now = now.Add(2 * time.Minute)
+	if a, ok := tracker.Availability("payments"); !ok || a != 1 {
+		t.Errorf("Expected full availability with no calls in the window, got %v", a)
+	}
+	if got := value(t, registry, AvailabilityMetric, payments); got != 1000000 {
+		t.Errorf("Expected the availability gauge to be refreshed, got %v", got)
+	}
+	if _, ok := tracker.Availability("unknown"); ok {
+		t.Error("Expected no availability for an unseen dependency")
+	}
+
+	tracker.SetCircuitState("payments", CircuitOpen)
+	if got := value(t, registry, CircuitStateMetric, payments); got != float64(CircuitOpen) {
+		t.Errorf("Expected circuit state %d, got %v", CircuitOpen, got)
+	}
+}
+
+func TestWrapCallPanic(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	tracker := New(registry)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected the panic to propagate, got %v", r)
+			}
+		}()
+		tracker.WrapCall("cache", func() error { panic("boom") })
+	}()
+
+	if got := value(t, registry, CallsMetric, metric.Tags{"dependency": "cache", "result": "error"}); got != 1 {
+		t.Errorf("Expected the panic to be recorded as an error, got %v", got)
+	}
+}
@@ -0,0 +1,74 @@
+// Package window counts good and total events over a rolling time window
+package window
+
+import "time"
+
+// Slots is how many slots a window is divided into; events expire a slot at a time
+const Slots = 60
+
+// slot counts the events of one slot
+type slot struct {
+	start       time.Time
+	good, total uint64
+}
+
+// Window counts good and total events over the last Width of time. It is not
+// safe for concurrent use.
+type Window struct {
+	width       time.Duration
+	slots       [Slots]slot
+	good, total uint64 // sums over the slots still in the window
+}
+
+// New creates a window covering width
+func New(width time.Duration) *Window {
+	return &Window{width: width}
+}
+
+// Width returns the time covered by the window
+func (w *Window) Width() time.Duration {
+	return w.width
+}
+
+// Add counts an event at now
+func (w *Window) Add(now time.Time, good bool) {
+	s := w.slotFor(now)
+	s.total++
+	w.total++
+	if good {
+		s.good++
+		w.good++
+	}
+}
+
+// Counts returns the good and total events in the window ending at now
+func (w *Window) Counts(now time.Time) (good, total uint64) {
+	w.expire(now)
+	return w.good, w.total
+}
+
+// slotFor returns the slot for now, clearing it if it last held an older period
+func (w *Window) slotFor(now time.Time) *slot {
+	slotWidth := max(w.width/Slots, time.Nanosecond)
+	start := now.Truncate(slotWidth)
+	s := &w.slots[(start.UnixNano()/int64(slotWidth))%Slots]
+	if !s.start.Equal(start) {
+		w.good -= s.good
+		w.total -= s.total
+		*s = slot{start: start}
+	}
+	return s
+}
+
+// expire drops the events of slots that are older than the window
+func (w *Window) expire(now time.Time) {
+	cutoff := now.Add(-w.width)
+	for i := range w.slots {
+		s := &w.slots[i]
+		if s.total > 0 && !s.start.After(cutoff) {
+			w.good -= s.good
+			w.total -= s.total
+			*s = slot{}
+		}
+	}
+}
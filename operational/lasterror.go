@@ -0,0 +1,88 @@
+package operational
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// lastError holds the metrics describing the most recent error of an operation
+type lastError struct {
+	mu            sync.Mutex
+	errorType     string
+	errorCategory string
+	info          metric.Gauge // nil until the first error
+	timestamp     metric.Gauge
+}
+
+// recordLastError points the operation's {operation}_last_error_info series at
+// errorType and errorCategory and sets {operation}_last_error_timestamp_seconds
+// to now. The info metric keeps a single series per operation: when the error
+// changes, the series of the previous error is unregistered.
+func (om *operationalMetrics) recordLastError(operation, errorType, errorCategory string) {
+	last := om.getOrCreateLastError(operation)
+
+	last.mu.Lock()
+	defer last.mu.Unlock()
+
+	if last.info == nil || last.errorType != errorType || last.errorCategory != errorCategory {
+		// Registries that cannot remove single series keep the previous one
+		if series, ok := om.registry.(metric.SeriesRegistry); ok && last.info != nil {
+			previous := last.info
+			series.UnregisterWhere(func(m metric.Metric) bool { return m == previous })
+		}
+
+		opts := metric.Options{
+			Name:        fmt.Sprintf("%s_last_error_info", operation),
+			Description: fmt.Sprintf("Type and category of the most recent error of %s operation", operation),
+			Tags: metric.Tags{
+				"operation":      operation,
+				"error_type":     errorType,
+				"error_category": errorCategory,
+			},
+		}
+		last.info = withinLimit(
+			func() metric.Gauge { return om.registry.Gauge(opts) },
+			func() metric.Gauge { return overflow.Gauge(opts) },
+		)
+		last.info.Set(1)
+		last.errorType = errorType
+		last.errorCategory = errorCategory
+	}
+	last.timestamp.Set(float64(time.Now().Unix()))
+}
+
+// getOrCreateLastError creates or retrieves the cached last error state of an operation
+func (om *operationalMetrics) getOrCreateLastError(operation string) *lastError {
+	om.mu.RLock()
+	if last, exists := om.lastErrors[operation]; exists {
+		om.mu.RUnlock()
+		return last
+	}
+	om.mu.RUnlock()
+
+	om.mu.Lock()
+	defer om.mu.Unlock()
+
+	if last, exists := om.lastErrors[operation]; exists {
+		return last
+	}
+
+	opts := metric.Options{
+		Name:        fmt.Sprintf("%s_last_error_timestamp_seconds", operation),
+		Description: fmt.Sprintf("Unix time of the most recent error of %s operation", operation),
+		Unit:        "seconds",
+		Tags:        metric.Tags{"operation": operation},
+	}
+	last := &lastError{
+		timestamp: withinLimit(
+			func() metric.Gauge { return om.registry.Gauge(opts) },
+			func() metric.Gauge { return overflow.Gauge(opts) },
+		),
+	}
+
+	om.lastErrors[operation] = last
+	return last
+}
@@ -0,0 +1,39 @@
+package operational
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestRecordErrorLastErrorInfo(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	om := New(registry)
+
+	before := time.Now().Unix()
+	om.RecordError("checkout", "timeout", "database")
+	om.RecordError("checkout", "timeout", "database")
+	om.RecordError("checkout", "validation_error", "input")
+
+	info := metric.SeriesOf(registry, "checkout_last_error_info")
+	if len(info) != 1 {
+		t.Fatalf("Expected a single last error series, got %+v", info)
+	}
+	if info[0].Tags["error_type"] != "validation_error" || info[0].Tags["error_category"] != "input" || info[0].Value != 1 {
+		t.Errorf("Expected the most recent error, got %+v", info[0])
+	}
+
+	timestamp := metric.SeriesOf(registry, "checkout_last_error_timestamp_seconds")
+	if len(timestamp) != 1 || int64(timestamp[0].Value) < before || int64(timestamp[0].Value) > time.Now().Unix() {
+		t.Errorf("Expected the time of the last error, got %+v", timestamp)
+	}
+
+	// Switching back re-registers the earlier error's series
+	om.RecordError("checkout", "timeout", "database")
+	info = metric.SeriesOf(registry, "checkout_last_error_info")
+	if len(info) != 1 || info[0].Tags["error_type"] != "timeout" {
+		t.Errorf("Expected the timeout error, got %+v", info)
+	}
+}
@@ -1,8 +1,11 @@
 package operational
 
 import (
+	"maps"
 	"sync"
 	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
 )
 
 // MockOperationalMetrics is a mock implementation of OperationalMetrics for testing
@@ -10,6 +13,8 @@ type MockOperationalMetrics struct {
 	// Call tracking
 	ErrorCalls     []ErrorCall
 	OperationCalls []OperationCall
+	PanicCalls     []PanicCall
+	SecurityCalls  []SecurityEventCall
 	
 	// Mutex for thread-safe access
 	mu sync.Mutex
@@ -23,11 +28,28 @@ type ErrorCall struct {
 	Timestamp     time.Time
 }
 
-// OperationCall represents a call to RecordOperation
+// OperationCall represents a call to RecordOperation or RecordOperationWithTags
 type OperationCall struct {
 	Operation string
 	Status    string
 	Duration  time.Duration
+	Tags      metric.Tags // nil for RecordOperation
+	Timestamp time.Time
+}
+
+// PanicCall represents a call to RecordPanic, or a panic caught by Recover
+type PanicCall struct {
+	Operation string
+	// Value is the recovered panic value, nil for RecordPanic
+	Value     any
+	Timestamp time.Time
+}
+
+// SecurityEventCall represents a call to CountSecurityEvent
+type SecurityEventCall struct {
+	EventType string
+	Severity  Severity
+	Tags      metric.Tags
 	Timestamp time.Time
 }
 
@@ -65,6 +87,86 @@ func (m *MockOperationalMetrics) RecordOperation(operation, status string, durat
 	})
 }
 
+// RecordOperationWithTags implements the TaggedOperationRecorder interface
+func (m *MockOperationalMetrics) RecordOperationWithTags(operation, status string, duration time.Duration, tags metric.Tags) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.OperationCalls = append(m.OperationCalls, OperationCall{
+		Operation: operation,
+		Status:    status,
+		Duration:  duration,
+		Tags:      maps.Clone(tags),
+		Timestamp: time.Now(),
+	})
+}
+
+// CountSecurityEvent implements the SecurityEventCounter interface
+func (m *MockOperationalMetrics) CountSecurityEvent(eventType string, severity Severity, tags metric.Tags) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.SecurityCalls = append(m.SecurityCalls, SecurityEventCall{
+		EventType: eventType,
+		Severity:  severity,
+		Tags:      maps.Clone(tags),
+		Timestamp: time.Now(),
+	})
+}
+
+// GetSecurityEventCount returns the number of security events counted for an event type and severity
+func (m *MockOperationalMetrics) GetSecurityEventCount(eventType string, severity Severity) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, call := range m.SecurityCalls {
+		if call.EventType == eventType && call.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// RecordPanic implements the OperationalMetrics interface
+func (m *MockOperationalMetrics) RecordPanic(operation string) {
+	m.recordPanic(operation, nil)
+}
+
+// Recover implements the OperationalMetrics interface, recording the panic as a
+// PanicCall and an ErrorCall like the real implementation
+func (m *MockOperationalMetrics) Recover(operation string) {
+	if r := recover(); r != nil {
+		m.recordPanic(operation, r)
+		m.RecordError(operation, PanicErrorType, PanicCategory(r))
+	}
+}
+
+func (m *MockOperationalMetrics) recordPanic(operation string, value any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.PanicCalls = append(m.PanicCalls, PanicCall{
+		Operation: operation,
+		Value:     value,
+		Timestamp: time.Now(),
+	})
+}
+
+// GetPanicCallCount returns the number of panics recorded for an operation
+func (m *MockOperationalMetrics) GetPanicCallCount(operation string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, call := range m.PanicCalls {
+		if call.Operation == operation {
+			count++
+		}
+	}
+	return count
+}
+
 // GetErrorCallCount returns the number of error calls for a specific operation/type/category
 func (m *MockOperationalMetrics) GetErrorCallCount(operation, errorType, errorCategory string) int {
 	m.mu.Lock()
@@ -144,6 +246,7 @@ func (m *MockOperationalMetrics) Reset() {
 	
 	m.ErrorCalls = make([]ErrorCall, 0)
 	m.OperationCalls = make([]OperationCall, 0)
+	m.PanicCalls = nil
 }
 
 // GetLastErrorCall returns the most recent error call, or nil if none
@@ -192,4 +295,64 @@ func (m *MockOperationalMetrics) GetAverageDuration(operation, status string) ti
 	}
 	
 	return total / time.Duration(count)
-}
\ No newline at end of file
+}
+
+// MockBusinessMetrics is a mock implementation of BusinessMetrics for testing
+type MockBusinessMetrics struct {
+	// RecordValueCalls and SetValueCalls hold the calls in order
+	RecordValueCalls []BusinessValueCall
+	SetValueCalls    []BusinessValueCall
+
+	mu sync.Mutex
+}
+
+// BusinessValueCall represents a call to RecordValue or SetValue
+type BusinessValueCall struct {
+	MetricType string
+	Category   string
+	Value      float64
+	Unit       string
+	Tags       metric.Tags
+	Timestamp  time.Time
+}
+
+// NewMockBusinessMetrics creates a new mock implementation
+func NewMockBusinessMetrics() *MockBusinessMetrics {
+	return &MockBusinessMetrics{}
+}
+
+// RecordValue implements the BusinessMetrics interface
+func (m *MockBusinessMetrics) RecordValue(metricType, category string, value float64, unit string, tags metric.Tags) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.RecordValueCalls = append(m.RecordValueCalls, newBusinessValueCall(metricType, category, value, unit, tags))
+}
+
+// SetValue implements the BusinessMetrics interface
+func (m *MockBusinessMetrics) SetValue(metricType, category string, value float64, unit string, tags metric.Tags) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.SetValueCalls = append(m.SetValueCalls, newBusinessValueCall(metricType, category, value, unit, tags))
+}
+
+// Reset clears all recorded calls
+func (m *MockBusinessMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.RecordValueCalls = nil
+	m.SetValueCalls = nil
+}
+
+func newBusinessValueCall(metricType, category string, value float64, unit string, tags metric.Tags) BusinessValueCall {
+	return BusinessValueCall{
+		MetricType: metricType,
+		Category:   category,
+		Value:      value,
+		Unit:       unit,
+		Tags:       maps.Clone(tags),
+		Timestamp:  time.Now(),
+	}
+}
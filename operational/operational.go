@@ -4,14 +4,23 @@
 package operational
 
 import (
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"maps"
+	"runtime/pprof"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/MichaelAJay/go-metrics/metric"
 )
 
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "operational", Package: "github.com/MichaelAJay/go-metrics/operational"})
+}
+
 // Internal tag map pool for operational metrics to reduce allocations
 var operationalTagPool = sync.Pool{
 	New: func() any {
@@ -19,6 +28,25 @@ var operationalTagPool = sync.Pool{
 	},
 }
 
+// overflow supplies metrics for series the registry refuses at its cardinality limit
+var overflow = metric.NewNoop()
+
+// withinLimit calls create, or fallback when the registry refuses the new series
+// because its metric name reached the cardinality limit. Each status and context
+// value is a distinct series, so unbounded values would otherwise panic in service
+// code; refused series are dropped and counted by the registry's meta-metrics.
+func withinLimit[M any](create func() M, fallback func() M) (m M) {
+	defer func() {
+		if r := recover(); r != nil {
+			if err, ok := r.(error); !ok || !errors.Is(err, metric.ErrCardinalityExceeded) {
+				panic(r)
+			}
+			m = fallback()
+		}
+	}()
+	return create()
+}
+
 // clearOperationalTags safely clears a tag map and returns it for reuse
 func clearOperationalTags(m map[string]string) map[string]string {
 	for k := range m {
@@ -33,6 +61,8 @@ type OperationalMetrics interface {
 	// operation: the operation that failed (e.g., "GenerateNonce", "ValidateRequest")
 	// errorType: the type of error (e.g., "crypto_error", "validation_error")
 	// errorCategory: additional categorization (e.g., "random_generation", "timeout")
+	// It also points {operation}_last_error_info at the error and sets
+	// {operation}_last_error_timestamp_seconds to the time it occurred.
 	RecordError(operation, errorType, errorCategory string)
 
 	// RecordOperation records an operation with its status and duration
@@ -40,6 +70,23 @@ type OperationalMetrics interface {
 	// status: the operation status (e.g., "success", "error", "timeout")
 	// duration: how long the operation took
 	RecordOperation(operation, status string, duration time.Duration)
+
+	// RecordPanic counts a panic in the operation as {operation}_panics_total
+	RecordPanic(operation string)
+
+	// Recover recovers a panic in the operation, counting it with RecordPanic and
+	// recording it with RecordError as error_type "panic" and an error_category of
+	// "runtime_error", "error", "message" or "value" for the kind of panic value.
+	// It must be deferred directly: defer om.Recover("ProcessJob")
+	Recover(operation string)
+}
+
+// TaggedOperationRecorder is implemented by OperationalMetrics that can record an
+// operation with extra tags on its timer and counter series
+type TaggedOperationRecorder interface {
+	// RecordOperationWithTags records an operation like RecordOperation, adding tags
+	// to its series. The operation and status tags cannot be overridden.
+	RecordOperationWithTags(operation, status string, duration time.Duration, tags metric.Tags)
 }
 
 // operationalMetrics implements the OperationalMetrics interface
@@ -50,6 +97,9 @@ type operationalMetrics struct {
 	errorCounters     map[string]metric.Counter
 	operationTimers   map[string]metric.Timer
 	operationCounters map[string]metric.Counter
+	panicCounters     map[string]metric.Counter
+	securityCounters  map[string]metric.Counter
+	lastErrors        map[string]*lastError
 
 	// Mutex for thread-safe metric caching
 	mu sync.RWMutex
@@ -62,6 +112,9 @@ func New(registry metric.Registry) OperationalMetrics {
 		errorCounters:     make(map[string]metric.Counter),
 		operationTimers:   make(map[string]metric.Timer),
 		operationCounters: make(map[string]metric.Counter),
+		panicCounters:     make(map[string]metric.Counter),
+		securityCounters:  make(map[string]metric.Counter),
+		lastErrors:        make(map[string]*lastError),
 	}
 }
 
@@ -77,6 +130,8 @@ func (om *operationalMetrics) RecordError(operation, errorType, errorCategory st
 	// Create error counter with tags for categorization
 	counter := om.getOrCreateErrorCounterWithTags(operation, tags)
 	counter.Inc()
+
+	om.recordLastError(operation, errorType, errorCategory)
 }
 
 // RecordOperation implements the OperationalMetrics interface
@@ -101,129 +156,77 @@ func (om *operationalMetrics) RecordOperation(operation, status string, duration
 	counter.Inc()
 }
 
-// getOrCreateErrorCounter creates or retrieves a cached error counter
-func (om *operationalMetrics) getOrCreateErrorCounter(operation, errorType, errorCategory string) metric.Counter {
-	// Create a unique key for this error counter
-	key := fmt.Sprintf("error:%s:%s:%s", operation, errorType, errorCategory)
-
-	// Try to get from cache first
-	om.mu.RLock()
-	if counter, exists := om.errorCounters[key]; exists {
-		om.mu.RUnlock()
-		return counter
-	}
-	om.mu.RUnlock()
-
-	// Create new counter if not in cache
-	om.mu.Lock()
-	defer om.mu.Unlock()
-
-	// Double-check after acquiring write lock
-	if counter, exists := om.errorCounters[key]; exists {
-		return counter
-	}
-
-	// Create the counter with appropriate name and tags
-	metricName := fmt.Sprintf("%s_errors_total", operation)
-	counter := om.registry.Counter(metric.Options{
-		Name:        metricName,
-		Description: fmt.Sprintf("Total number of errors for %s operation", operation),
-		Unit:        "count",
-		Tags: metric.Tags{
-			"operation":      operation,
-			"error_type":     errorType,
-			"error_category": errorCategory,
-		},
-	})
-
-	// Cache for future use
-	om.errorCounters[key] = counter
-	return counter
+// TagValidation implements metric.TagValidator with the config of the registry
+// the metrics are recorded in
+func (om *operationalMetrics) TagValidation() metric.TagValidationConfig {
+	return metric.TagValidationOf(om.registry)
 }
 
-// getOrCreateOperationTimer creates or retrieves a cached operation timer
-func (om *operationalMetrics) getOrCreateOperationTimer(operation string) metric.Timer {
-	key := fmt.Sprintf("timer:%s", operation)
+// RecordOperationWithTags implements the TaggedOperationRecorder interface
+func (om *operationalMetrics) RecordOperationWithTags(operation, status string, duration time.Duration, tags metric.Tags) {
+	timerTags := operationalTagPool.Get().(map[string]string)
+	defer operationalTagPool.Put(clearOperationalTags(timerTags))
 
-	// Try to get from cache first
-	om.mu.RLock()
-	if timer, exists := om.operationTimers[key]; exists {
-		om.mu.RUnlock()
-		return timer
-	}
-	om.mu.RUnlock()
+	maps.Copy(timerTags, tags)
+	timerTags["operation"] = operation
 
-	// Create new timer if not in cache
-	om.mu.Lock()
-	defer om.mu.Unlock()
+	timer := om.getOrCreateOperationTimerWithTags(operation, timerTags)
+	timer.Record(duration)
 
-	// Double-check after acquiring write lock
-	if timer, exists := om.operationTimers[key]; exists {
-		return timer
-	}
+	counterTags := operationalTagPool.Get().(map[string]string)
+	defer operationalTagPool.Put(clearOperationalTags(counterTags))
 
-	// Create the timer
-	metricName := fmt.Sprintf("%s_duration", operation)
-	timer := om.registry.Timer(metric.Options{
-		Name:        metricName,
-		Description: fmt.Sprintf("Duration of %s operation", operation),
-		Unit:        "nanoseconds",
-		Tags: metric.Tags{
-			"operation": operation,
-		},
-	})
+	maps.Copy(counterTags, tags)
+	counterTags["operation"] = operation
+	counterTags["status"] = status
 
-	// Cache for future use
-	om.operationTimers[key] = timer
-	return timer
+	counter := om.getOrCreateOperationCounterWithTags(operation, counterTags)
+	counter.Inc()
 }
 
-// getOrCreateOperationCounter creates or retrieves a cached operation counter
-func (om *operationalMetrics) getOrCreateOperationCounter(operation, status string) metric.Counter {
-	key := fmt.Sprintf("counter:%s:%s", operation, status)
-
-	// Try to get from cache first
-	om.mu.RLock()
-	if counter, exists := om.operationCounters[key]; exists {
-		om.mu.RUnlock()
-		return counter
+// cacheKeyBufferSize is the stack buffer used to build cache keys; longer keys
+// still work but spill to the heap
+const cacheKeyBufferSize = 128
+
+// appendCacheKey appends parts to dst, each prefixed with its length so parts
+// containing any separator cannot make two keys collide. Cache lookups index the
+// maps with string(key), which does not allocate, so a key only reaches the heap
+// when a new metric is cached.
+func appendCacheKey(dst []byte, parts ...string) []byte {
+	for _, part := range parts {
+		dst = binary.AppendUvarint(dst, uint64(len(part)))
+		dst = append(dst, part...)
 	}
-	om.mu.RUnlock()
-
-	// Create new counter if not in cache
-	om.mu.Lock()
-	defer om.mu.Unlock()
+	return dst
+}
 
-	// Double-check after acquiring write lock
-	if counter, exists := om.operationCounters[key]; exists {
-		return counter
+// appendTagsKey appends every tag to dst in sorted key order, so a cached metric
+// is keyed by the full tag set it was created with and each combination of
+// status, error and extra tags is its own series
+func appendTagsKey(dst []byte, tags map[string]string) []byte {
+	var small [8]string
+	keys := small[:0]
+	for k := range tags {
+		keys = append(keys, k)
 	}
+	slices.Sort(keys)
 
-	// Create the counter
-	metricName := fmt.Sprintf("%s_total", operation)
-	counter := om.registry.Counter(metric.Options{
-		Name:        metricName,
-		Description: fmt.Sprintf("Total number of %s operations", operation),
-		Unit:        "count",
-		Tags: metric.Tags{
-			"operation": operation,
-			"status":    status,
-		},
-	})
-
-	// Cache for future use
-	om.operationCounters[key] = counter
-	return counter
+	for _, k := range keys {
+		dst = appendCacheKey(dst, k, tags[k])
+	}
+	return dst
 }
 
 // getOrCreateErrorCounterWithTags creates or retrieves a cached error counter using pooled tags
 func (om *operationalMetrics) getOrCreateErrorCounterWithTags(operation string, tags map[string]string) metric.Counter {
 	// Create a unique key for this error counter
-	key := fmt.Sprintf("error:%s:%s:%s", operation, tags["error_type"], tags["error_category"])
+	var buf [cacheKeyBufferSize]byte
+	key := appendCacheKey(buf[:0], "error", operation)
+	key = appendTagsKey(key, tags)
 
 	// Try to get from cache first
 	om.mu.RLock()
-	if counter, exists := om.errorCounters[key]; exists {
+	if counter, exists := om.errorCounters[string(key)]; exists {
 		om.mu.RUnlock()
 		return counter
 	}
@@ -234,7 +237,7 @@ func (om *operationalMetrics) getOrCreateErrorCounterWithTags(operation string,
 	defer om.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if counter, exists := om.errorCounters[key]; exists {
+	if counter, exists := om.errorCounters[string(key)]; exists {
 		return counter
 	}
 
@@ -244,25 +247,31 @@ func (om *operationalMetrics) getOrCreateErrorCounterWithTags(operation string,
 
 	// Create the counter with appropriate name and tags
 	metricName := fmt.Sprintf("%s_errors_total", operation)
-	counter := om.registry.Counter(metric.Options{
+	opts := metric.Options{
 		Name:        metricName,
 		Description: fmt.Sprintf("Total number of errors for %s operation", operation),
 		Unit:        "count",
 		Tags:        finalTags,
-	})
+	}
+	counter := withinLimit(
+		func() metric.Counter { return om.registry.Counter(opts) },
+		func() metric.Counter { return overflow.Counter(opts) },
+	)
 
 	// Cache for future use
-	om.errorCounters[key] = counter
+	om.errorCounters[string(key)] = counter
 	return counter
 }
 
 // getOrCreateOperationTimerWithTags creates or retrieves a cached operation timer using pooled tags
 func (om *operationalMetrics) getOrCreateOperationTimerWithTags(operation string, tags map[string]string) metric.Timer {
-	key := fmt.Sprintf("timer:%s", operation)
+	var buf [cacheKeyBufferSize]byte
+	key := appendCacheKey(buf[:0], "timer", operation)
+	key = appendTagsKey(key, tags)
 
 	// Try to get from cache first
 	om.mu.RLock()
-	if timer, exists := om.operationTimers[key]; exists {
+	if timer, exists := om.operationTimers[string(key)]; exists {
 		om.mu.RUnlock()
 		return timer
 	}
@@ -273,7 +282,7 @@ func (om *operationalMetrics) getOrCreateOperationTimerWithTags(operation string
 	defer om.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if timer, exists := om.operationTimers[key]; exists {
+	if timer, exists := om.operationTimers[string(key)]; exists {
 		return timer
 	}
 
@@ -285,25 +294,31 @@ func (om *operationalMetrics) getOrCreateOperationTimerWithTags(operation string
 
 	// Create the timer
 	metricName := fmt.Sprintf("%s_duration", operation)
-	timer := om.registry.Timer(metric.Options{
+	opts := metric.Options{
 		Name:        metricName,
 		Description: fmt.Sprintf("Duration of %s operation", operation),
 		Unit:        "nanoseconds",
 		Tags:        finalTags,
-	})
+	}
+	timer := withinLimit(
+		func() metric.Timer { return om.registry.Timer(opts) },
+		func() metric.Timer { return overflow.Timer(opts) },
+	)
 
 	// Cache for future use
-	om.operationTimers[key] = timer
+	om.operationTimers[string(key)] = timer
 	return timer
 }
 
 // getOrCreateOperationCounterWithTags creates or retrieves a cached operation counter using pooled tags
 func (om *operationalMetrics) getOrCreateOperationCounterWithTags(operation string, tags map[string]string) metric.Counter {
-	key := fmt.Sprintf("counter:%s:%s", operation, tags["status"])
+	var buf [cacheKeyBufferSize]byte
+	key := appendCacheKey(buf[:0], "counter", operation)
+	key = appendTagsKey(key, tags)
 
 	// Try to get from cache first
 	om.mu.RLock()
-	if counter, exists := om.operationCounters[key]; exists {
+	if counter, exists := om.operationCounters[string(key)]; exists {
 		om.mu.RUnlock()
 		return counter
 	}
@@ -314,7 +329,7 @@ func (om *operationalMetrics) getOrCreateOperationCounterWithTags(operation stri
 	defer om.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if counter, exists := om.operationCounters[key]; exists {
+	if counter, exists := om.operationCounters[string(key)]; exists {
 		return counter
 	}
 
@@ -324,15 +339,19 @@ func (om *operationalMetrics) getOrCreateOperationCounterWithTags(operation stri
 
 	// Create the counter
 	metricName := fmt.Sprintf("%s_total", operation)
-	counter := om.registry.Counter(metric.Options{
+	opts := metric.Options{
 		Name:        metricName,
 		Description: fmt.Sprintf("Total number of %s operations", operation),
 		Unit:        "count",
 		Tags:        finalTags,
-	})
+	}
+	counter := withinLimit(
+		func() metric.Counter { return om.registry.Counter(opts) },
+		func() metric.Counter { return overflow.Counter(opts) },
+	)
 
 	// Cache for future use
-	om.operationCounters[key] = counter
+	om.operationCounters[string(key)] = counter
 	return counter
 }
 
@@ -340,14 +359,145 @@ func (om *operationalMetrics) getOrCreateOperationCounterWithTags(operation stri
 // that can be used by any service to record domain-specific metrics
 // while leveraging the pooled tag infrastructure for performance
 type MetricsBuilder struct {
-	om OperationalMetrics
+	om          OperationalMetrics
+	alertBudget *AlertBudget
+	baseTags    metric.Tags // added to every operation recorded by the builder
+	contextTags bool        // record context as tags instead of as separate operations
+
+	defaultContext map[string]string // merged under the context of every call
+}
+
+// BuilderOption is a functional option for configuring a MetricsBuilder
+type BuilderOption func(*MetricsBuilder)
+
+// WithAlertBudget counts security events recorded with a severity against the given budget
+func WithAlertBudget(budget *AlertBudget) BuilderOption {
+	return func(b *MetricsBuilder) {
+		b.alertBudget = budget
+	}
+}
+
+// WithContextAsTags makes the builder record context maps and key-value pairs as
+// tags on the operation's own timer and counter, instead of as a separate
+// <operation>_<key> operation per key whose status is the value. Each context
+// key then no longer creates a metric name, and the context stays correlated
+// with the operation's status and duration. Context that fails tag validation
+// is dropped and the operation is recorded without it. Without this option the
+// builder keeps the original behavior for compatibility with existing dashboards.
+func WithContextAsTags() BuilderOption {
+	return func(b *MetricsBuilder) {
+		b.contextTags = true
+	}
+}
+
+// WithDefaultContext merges context into the context of every RecordWithContext,
+// RecordSecurityEvent and RecordBusinessMetric call, so values shared across a
+// service (e.g. service and region) need not be built into a map per call. The
+// context passed to a call wins over the defaults for the same key. Defaults are
+// recorded exactly like per-call context, as tags with WithContextAsTags or as
+// separate operations without it; for tags on every series use
+// NewMetricsBuilderWithTags instead.
+func WithDefaultContext(context map[string]string) BuilderOption {
+	return func(b *MetricsBuilder) {
+		b.defaultContext = maps.Clone(context)
+	}
 }
 
 // NewMetricsBuilder creates a new MetricsBuilder instance
-func NewMetricsBuilder(om OperationalMetrics) *MetricsBuilder {
-	return &MetricsBuilder{
+func NewMetricsBuilder(om OperationalMetrics, opts ...BuilderOption) *MetricsBuilder {
+	b := &MetricsBuilder{
 		om: om,
 	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// NewMetricsBuilderWithTags creates a MetricsBuilder that adds baseTags (for example
+// service and region) to the series of every operation, security event and business
+// metric it records. Base tags require om to implement TaggedOperationRecorder, as
+// the OperationalMetrics returned by New does; otherwise they are ignored.
+func NewMetricsBuilderWithTags(om OperationalMetrics, baseTags metric.Tags, opts ...BuilderOption) *MetricsBuilder {
+	b := NewMetricsBuilder(om, opts...)
+	b.baseTags = maps.Clone(baseTags)
+	return b
+}
+
+// record records an operation with the builder's base tags
+func (b *MetricsBuilder) record(operation, status string, duration time.Duration) {
+	if len(b.baseTags) > 0 {
+		if tagged, ok := b.om.(TaggedOperationRecorder); ok {
+			tagged.RecordOperationWithTags(operation, status, duration, b.baseTags)
+			return
+		}
+	}
+	b.om.RecordOperation(operation, status, duration)
+}
+
+// mergeContext returns context merged over the builder's default context. The
+// merged map is taken from the tag pool, and pooled reports whether the caller
+// must put it back; when either side is empty the other is returned unchanged.
+func (b *MetricsBuilder) mergeContext(context map[string]string) (merged map[string]string, pooled bool) {
+	if len(b.defaultContext) == 0 {
+		return context, false
+	}
+	if len(context) == 0 {
+		return b.defaultContext, false
+	}
+
+	merged = clearOperationalTags(operationalTagPool.Get().(map[string]string))
+	maps.Copy(merged, b.defaultContext)
+	maps.Copy(merged, context)
+	return merged, true
+}
+
+// recordWithContextTags records an operation once, with the base tags and context as tags
+func (b *MetricsBuilder) recordWithContextTags(operation, status string, duration time.Duration, context map[string]string) {
+	tagged, ok := b.om.(TaggedOperationRecorder)
+	if !ok || len(context) == 0 {
+		b.record(operation, status, duration)
+		return
+	}
+
+	tags := operationalTagPool.Get().(map[string]string)
+	defer operationalTagPool.Put(clearOperationalTags(tags))
+
+	maps.Copy(tags, b.baseTags)
+	maps.Copy(tags, context)
+	delete(tags, "operation")
+	delete(tags, "status")
+
+	// Validate against the registry's config, leaving room for the operation
+	// and status tags added by the recorder
+	config := metric.DefaultTagValidationConfig()
+	if validator, ok := b.om.(metric.TagValidator); ok {
+		config = validator.TagValidation()
+	}
+	config.MaxKeys -= 2
+	if metric.ValidateTags(tags, config) != nil {
+		b.record(operation, status, duration)
+		return
+	}
+	tagged.RecordOperationWithTags(operation, status, duration, tags)
+}
+
+// recordPairsWithContextTags is recordWithContextTags for context given as key-value pairs
+func (b *MetricsBuilder) recordPairsWithContextTags(operation, status string, duration time.Duration, keyValuePairs []string) {
+	if len(keyValuePairs)%2 != 0 {
+		b.record(operation, status, duration)
+		return
+	}
+
+	context := operationalTagPool.Get().(map[string]string)
+	defer operationalTagPool.Put(clearOperationalTags(context))
+
+	for i := 0; i < len(keyValuePairs); i += 2 {
+		context[keyValuePairs[i]] = keyValuePairs[i+1]
+	}
+	b.recordWithContextTags(operation, status, duration, context)
 }
 
 // RecordWithContext records an operation with additional contextual information
@@ -356,8 +506,18 @@ func NewMetricsBuilder(om OperationalMetrics) *MetricsBuilder {
 // duration: how long the operation took
 // context: additional contextual tags (e.g., map[string]string{"provider": "password", "user_type": "premium"})
 func (b *MetricsBuilder) RecordWithContext(operation, status string, duration time.Duration, context map[string]string) {
+	context, pooled := b.mergeContext(context)
+	if pooled {
+		defer operationalTagPool.Put(context)
+	}
+
+	if b.contextTags {
+		b.recordWithContextTags(operation, status, duration, context)
+		return
+	}
+
 	// Record the primary operation using the existing pooled implementation
-	b.om.RecordOperation(operation, status, duration)
+	b.record(operation, status, duration)
 
 	// If no additional context, we're done
 	if len(context) == 0 {
@@ -368,7 +528,7 @@ func (b *MetricsBuilder) RecordWithContext(operation, status string, duration ti
 	// We'll create contextual operation metrics for each key-value pair
 	for key, value := range context {
 		contextualOperation := fmt.Sprintf("%s_%s", operation, key)
-		b.om.RecordOperation(contextualOperation, value, duration)
+		b.record(contextualOperation, value, duration)
 	}
 }
 
@@ -377,15 +537,25 @@ func (b *MetricsBuilder) RecordWithContext(operation, status string, duration ti
 // action: the action taken (e.g., "blocked", "allowed", "flagged")
 // context: additional contextual information (e.g., map[string]string{"ip": clientIP, "user_agent": userAgent})
 func (b *MetricsBuilder) RecordSecurityEvent(eventType, action string, context map[string]string) {
+	context, pooled := b.mergeContext(context)
+	if pooled {
+		defer operationalTagPool.Put(context)
+	}
+
 	operation := fmt.Sprintf("security_%s", eventType)
+	if b.contextTags {
+		b.recordWithContextTags(operation, action, 0, context)
+		return
+	}
+
 	// Security events are recorded with zero duration as they are typically point-in-time events
-	b.om.RecordOperation(operation, action, 0)
+	b.record(operation, action, 0)
 
 	// Record additional contextual metrics for security analysis
 	if len(context) > 0 {
 		for key, value := range context {
 			contextualOperation := fmt.Sprintf("security_%s_%s", eventType, key)
-			b.om.RecordOperation(contextualOperation, value, 0)
+			b.record(contextualOperation, value, 0)
 		}
 	}
 }
@@ -395,45 +565,94 @@ func (b *MetricsBuilder) RecordSecurityEvent(eventType, action string, context m
 // category: the category or status (e.g., "completed", "organic", "premium")
 // value: the numeric value associated with the metric (converted to duration for compatibility)
 // context: additional contextual information (e.g., map[string]string{"source": "organic", "tier": "premium"})
+//
+// Deprecated: the value is recorded as a timer duration, so it carries the wrong
+// unit. Use BusinessMetrics, which records values in their own unit.
 func (b *MetricsBuilder) RecordBusinessMetric(metricType, category string, value float64, context map[string]string) {
+	context, pooled := b.mergeContext(context)
+	if pooled {
+		defer operationalTagPool.Put(context)
+	}
+
 	operation := fmt.Sprintf("business_%s", metricType)
 	// Convert float64 value to duration (nanoseconds) for timer compatibility
 	duration := time.Duration(value * float64(time.Millisecond))
-	b.om.RecordOperation(operation, category, duration)
+	if b.contextTags {
+		b.recordWithContextTags(operation, category, duration, context)
+		return
+	}
+	b.record(operation, category, duration)
 
 	// Record additional contextual metrics for business analysis
 	if len(context) > 0 {
 		for key, contextValue := range context {
 			contextualOperation := fmt.Sprintf("business_%s_%s", metricType, key)
-			b.om.RecordOperation(contextualOperation, contextValue, duration)
+			b.record(contextualOperation, contextValue, duration)
 		}
 	}
 }
 
+// RunWithLabels runs fn as the named operation with pprof labels set for the
+// operation and the given key-value pairs (e.g. "route", "/checkout"), then
+// records it with RecordWithTags using the same pairs, so CPU profiles and
+// latency metrics can be sliced along the same dimensions. The status is
+// "success", or "error" when fn returns an error, which is passed through.
+func (b *MetricsBuilder) RunWithLabels(ctx context.Context, operation string, fn func(ctx context.Context) error, keyValuePairs ...string) error {
+	labels := []string{"operation", operation}
+	if len(keyValuePairs)%2 == 0 {
+		labels = append(labels, keyValuePairs...)
+	}
+
+	var err error
+	var duration time.Duration
+	pprof.Do(ctx, pprof.Labels(labels...), func(ctx context.Context) {
+		start := time.Now()
+		err = fn(ctx)
+		duration = time.Since(start)
+	})
+
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	b.RecordWithTags(operation, status, duration, keyValuePairs...)
+	return err
+}
+
 // Above should be deleted
 func (b *MetricsBuilder) RecordWithTags(operation, status string, duration time.Duration, keyValuePairs ...string) {
+	if b.contextTags {
+		b.recordPairsWithContextTags(operation, status, duration, keyValuePairs)
+		return
+	}
+
 	if len(keyValuePairs)%2 != 0 {
-		b.om.RecordOperation(operation, status, duration)
+		b.record(operation, status, duration)
 		return
 	}
 
 	// Record base operation
-	b.om.RecordOperation(operation, status, duration)
+	b.record(operation, status, duration)
 
 	// Record contextual metrics directly from variadic args - NO MAP NEEDED!
 	for i := 0; i < len(keyValuePairs); i += 2 {
 		key := keyValuePairs[i]
 		value := keyValuePairs[i+1]
 		contextualOperation := fmt.Sprintf("%s_%s", operation, key)
-		b.om.RecordOperation(contextualOperation, value, duration)
+		b.record(contextualOperation, value, duration)
 	}
 }
 
 func (b *MetricsBuilder) RecordSecurityEventWithTags(eventType, action string, keyValuePairs ...string) {
+	if b.contextTags {
+		b.recordPairsWithContextTags(fmt.Sprintf("security_%s", eventType), action, 0, keyValuePairs)
+		return
+	}
+
 	if len(keyValuePairs)%2 != 0 {
 		// Fallback to basic recording
 		operation := fmt.Sprintf("security_%s", eventType)
-		b.om.RecordOperation(operation, action, 0)
+		b.record(operation, action, 0)
 		return
 	}
 
@@ -442,7 +661,7 @@ func (b *MetricsBuilder) RecordSecurityEventWithTags(eventType, action string, k
 	defer operationalTagPool.Put(clearOperationalTags(tags))
 
 	operation := fmt.Sprintf("security_%s", eventType)
-	b.om.RecordOperation(operation, action, 0)
+	b.record(operation, action, 0)
 
 	// Populate from variadic args
 	for i := 0; i < len(keyValuePairs); i += 2 {
@@ -452,6 +671,6 @@ func (b *MetricsBuilder) RecordSecurityEventWithTags(eventType, action string, k
 	// Record contextual security metrics using pooled map
 	for key, value := range tags {
 		contextualOperation := fmt.Sprintf("security_%s_%s", eventType, key)
-		b.om.RecordOperation(contextualOperation, value, 0)
+		b.record(contextualOperation, value, 0)
 	}
 }
@@ -70,10 +70,11 @@ func TestRecordOperation(t *testing.T) {
 	})
 	
 	// Should have created:
-	// - 2 counters (GenerateNonce_total, ValidateRequest_total) with different tags
+	// - 3 counters, one series per operation and status (GenerateNonce_total{status=success},
+	//   GenerateNonce_total{status=error}, ValidateRequest_total{status=success})
 	// - 2 timers (GenerateNonce, ValidateRequest)
-	if counterCount != 2 {
-		t.Errorf("Expected 2 counters, got %d", counterCount)
+	if counterCount != 3 {
+		t.Errorf("Expected 3 counters, got %d", counterCount)
 	}
 	
 	if timerCount != 2 {
@@ -252,4 +253,62 @@ func BenchmarkConcurrentRecordError(b *testing.B) {
 			om.RecordError("ConcurrentBenchOp", "test_error", "benchmark")
 		}
 	})
-}
\ No newline at end of file
+}
+func TestRecordOperationBeyondCardinalityLimit(t *testing.T) {
+	config := metric.DefaultTagValidationConfig()
+	config.MaxCardinality = 2
+	registry := metric.NewRegistry(config, 0)
+	defer registry.Close()
+	om := New(registry)
+
+	// Each status is its own series; statuses beyond the limit are dropped instead of panicking
+	for _, status := range []string{"success", "error", "timeout", "cancelled"} {
+		om.RecordOperation("Sync", status, time.Millisecond)
+	}
+
+	statuses := make(map[string]uint64)
+	registry.Each(func(m metric.Metric) {
+		if counter, ok := m.(metric.Counter); ok && m.Name() == "Sync_total" {
+			statuses[m.Tags()["status"]] = counter.Value()
+		}
+	})
+	if len(statuses) != 2 || statuses["success"] != 1 || statuses["error"] != 1 {
+		t.Errorf("Expected series for the first two statuses only, got %v", statuses)
+	}
+}
+
+func TestCacheKeysUseFullTagSet(t *testing.T) {
+	registry := metric.NewDefaultRegistry()
+	om := New(registry)
+	tagged := om.(TaggedOperationRecorder)
+
+	// Each call has a distinct tag set, even where joining the parts with a
+	// separator would give the same key
+	om.RecordOperation("a:b", "c", time.Millisecond)
+	om.RecordOperation("a", "b:c", time.Millisecond)
+	om.RecordError("fetch", "a:b", "c")
+	om.RecordError("fetch", "a", "b:c")
+	tagged.RecordOperationWithTags("fetch", "ok", time.Millisecond, metric.Tags{"region": "x:y=z"})
+	tagged.RecordOperationWithTags("fetch", "ok", time.Millisecond, metric.Tags{"region": "x", "y": "z"})
+
+	counters := make(map[string]uint64)
+	registry.Each(func(m metric.Metric) {
+		if counter, ok := m.(metric.Counter); ok {
+			tags := m.Tags()
+			counters[m.Name()+"|"+tags["status"]+"|"+tags["error_type"]+"|"+tags["error_category"]+"|"+tags["region"]+"|"+tags["y"]] += counter.Value()
+		}
+	})
+
+	for _, key := range []string{
+		"a:b_total|c||||",
+		"a_total|b:c||||",
+		"fetch_errors_total||a:b|c||",
+		"fetch_errors_total||a|b:c||",
+		"fetch_total|ok|||x:y=z|",
+		"fetch_total|ok|||x|z",
+	} {
+		if counters[key] != 1 {
+			t.Errorf("Expected one count for %s, got %d (all: %v)", key, counters[key], counters)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package operational
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// PanicErrorType is the error_type under which Recover records recovered panics
+const PanicErrorType = "panic"
+
+// PanicCategory classifies a recovered panic value for the error_category tag:
+// "runtime_error", "error", "message" for strings, or "value"
+func PanicCategory(r any) string {
+	switch r.(type) {
+	case runtime.Error:
+		return "runtime_error"
+	case error:
+		return "error"
+	case string:
+		return "message"
+	default:
+		return "value"
+	}
+}
+
+// RecordPanic implements the OperationalMetrics interface
+func (om *operationalMetrics) RecordPanic(operation string) {
+	om.getOrCreatePanicCounter(operation).Inc()
+}
+
+// Recover implements the OperationalMetrics interface. It must be deferred directly
+// (defer om.Recover("op")) for the built-in recover to see the panic.
+func (om *operationalMetrics) Recover(operation string) {
+	if r := recover(); r != nil {
+		om.RecordPanic(operation)
+		om.RecordError(operation, PanicErrorType, PanicCategory(r))
+	}
+}
+
+// getOrCreatePanicCounter creates or retrieves a cached panic counter
+func (om *operationalMetrics) getOrCreatePanicCounter(operation string) metric.Counter {
+	var buf [cacheKeyBufferSize]byte
+	key := appendCacheKey(buf[:0], "panic", operation)
+
+	om.mu.RLock()
+	if counter, exists := om.panicCounters[string(key)]; exists {
+		om.mu.RUnlock()
+		return counter
+	}
+	om.mu.RUnlock()
+
+	om.mu.Lock()
+	defer om.mu.Unlock()
+
+	if counter, exists := om.panicCounters[string(key)]; exists {
+		return counter
+	}
+
+	opts := metric.Options{
+		Name:        fmt.Sprintf("%s_panics_total", operation),
+		Description: fmt.Sprintf("Total number of panics in %s operation", operation),
+		Unit:        "count",
+		Tags:        metric.Tags{"operation": operation},
+	}
+	counter := withinLimit(
+		func() metric.Counter { return om.registry.Counter(opts) },
+		func() metric.Counter { return overflow.Counter(opts) },
+	)
+
+	om.panicCounters[string(key)] = counter
+	return counter
+}
+
+// GoroutineSampler reports the number of goroutines as the goroutines gauge, and
+// how far it has grown beyond the count when the sampler was created as
+// goroutines_growth. A growth that keeps rising under steady load points to a
+// goroutine leak.
+type GoroutineSampler struct {
+	current  metric.Gauge
+	growth   metric.Gauge
+	baseline int
+
+	mu sync.Mutex
+}
+
+// NewGoroutineSampler creates a sampler recording into registry, taking the
+// current goroutine count as its baseline
+func NewGoroutineSampler(registry metric.Registry) *GoroutineSampler {
+	s := &GoroutineSampler{
+		current: registry.Gauge(metric.Options{
+			Name:        "goroutines",
+			Description: "Number of goroutines that currently exist",
+			Unit:        "count",
+		}),
+		growth: registry.Gauge(metric.Options{
+			Name:        "goroutines_growth",
+			Description: "Goroutines above the count when sampling started",
+			Unit:        "count",
+		}),
+	}
+	s.Reset()
+	return s
+}
+
+// Sample updates the gauges from the current goroutine count
+func (s *GoroutineSampler) Sample() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := runtime.NumGoroutine()
+	s.current.Set(float64(n))
+	s.growth.Set(float64(n - s.baseline))
+}
+
+// Reset takes the current goroutine count as the new baseline, e.g. once a
+// service has finished starting up
+func (s *GoroutineSampler) Reset() {
+	s.mu.Lock()
+	s.baseline = runtime.NumGoroutine()
+	s.mu.Unlock()
+
+	s.Sample()
+}
+
+// Run samples every interval until ctx is done
+func (s *GoroutineSampler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.Sample()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
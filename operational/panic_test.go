@@ -0,0 +1,106 @@
+package operational
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestRecover(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	om := New(registry)
+
+	run := func(value any) {
+		defer om.Recover("process_job")
+		panic(value)
+	}
+	run("boom")
+	run(errors.New("failed"))
+	run(42)
+	func() {
+		defer om.Recover("process_job")
+		var m map[string]int
+		m["x"] = 1 // a nil map write is a runtime error
+	}()
+	om.RecordPanic("process_job")
+
+	panics := metric.SeriesOf(registry, "process_job_panics_total")
+	if len(panics) != 1 || panics[0].Value != 5 {
+		t.Fatalf("Expected 5 panics counted, got %+v", panics)
+	}
+
+	categories := map[string]float64{}
+	for _, series := range metric.SeriesOf(registry, "process_job_errors_total") {
+		if series.Tags["error_type"] != PanicErrorType {
+			t.Errorf("Expected error_type %q, got %q", PanicErrorType, series.Tags["error_type"])
+		}
+		categories[series.Tags["error_category"]] = series.Value
+	}
+	for _, category := range []string{"message", "error", "value", "runtime_error"} {
+		if categories[category] != 1 {
+			t.Errorf("Expected 1 panic with category %s, got %v", category, categories)
+		}
+	}
+
+	// Without a panic Recover records nothing
+	func() { defer om.Recover("process_job") }()
+	if panics := metric.SeriesOf(registry, "process_job_panics_total"); panics[0].Value != 5 {
+		t.Errorf("Expected 5 panics after a clean run, got %v", panics[0].Value)
+	}
+}
+
+func TestMockRecover(t *testing.T) {
+	mock := NewMockOperationalMetrics()
+	var om OperationalMetrics = mock
+
+	func() {
+		defer om.Recover("process_job")
+		panic("boom")
+	}()
+	om.RecordPanic("other")
+
+	if mock.GetPanicCallCount("process_job") != 1 || mock.PanicCalls[0].Value != "boom" {
+		t.Errorf("Expected the recovered panic to be captured, got %+v", mock.PanicCalls)
+	}
+	if mock.GetErrorCallCount("process_job", PanicErrorType, "message") != 1 {
+		t.Errorf("Expected the panic to be recorded as an error, got %+v", mock.ErrorCalls)
+	}
+	if mock.GetPanicCallCount("other") != 1 {
+		t.Errorf("Expected RecordPanic to be captured")
+	}
+}
+
+func TestGoroutineSampler(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	sampler := NewGoroutineSampler(registry)
+
+	stop := make(chan struct{})
+	for range 3 {
+		go func() { <-stop }()
+	}
+	sampler.Sample()
+
+	growth := metric.SeriesOf(registry, "goroutines_growth")
+	if len(growth) != 1 || growth[0].Value < 3 {
+		t.Errorf("Expected growth of at least 3 goroutines, got %+v", growth)
+	}
+
+	close(stop)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		sampler.Run(ctx, time.Millisecond)
+		close(done)
+	}()
+	cancel()
+	<-done
+
+	if current := metric.SeriesOf(registry, "goroutines"); len(current) != 1 || current[0].Value < 1 {
+		t.Errorf("Expected the goroutines gauge to be set, got %+v", current)
+	}
+}
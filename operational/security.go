@@ -0,0 +1,258 @@
+package operational
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// Severity classifies how serious a security event is
+type Severity int
+
+const (
+	// SeverityUnspecified is the zero value, standing for no severity, so that
+	// configuration leaving a severity unset can be told apart from SeverityInfo
+	SeverityUnspecified Severity = iota
+	// SeverityInfo is for routine security-relevant activity (e.g., successful logins)
+	SeverityInfo
+	// SeverityLow is for suspicious activity that needs no immediate action
+	SeverityLow
+	// SeverityMedium is for activity that should be reviewed
+	SeverityMedium
+	// SeverityHigh is for activity that likely indicates an attack
+	SeverityHigh
+	// SeverityCritical is for confirmed attacks or compromises requiring immediate response
+	SeverityCritical
+)
+
+// String returns the lowercase name used as the severity tag value
+func (s Severity) String() string {
+	switch s {
+	case SeverityUnspecified:
+		return "unspecified"
+	case SeverityInfo:
+		return "info"
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("severity_%d", int(s))
+	}
+}
+
+// AlertBudgetConfig configures an AlertBudget
+type AlertBudgetConfig struct {
+	// Name identifies the budget and is used as the gauge's budget tag (default "security")
+	Name string
+	// MinSeverity is the lowest severity counted against the budget. Leaving it
+	// SeverityUnspecified selects SeverityCritical; set SeverityInfo to count every event.
+	MinSeverity Severity
+	// Threshold is the number of events allowed within Window before the budget is exhausted
+	Threshold int
+	// Window is the sliding window the threshold applies to (default one minute)
+	Window time.Duration
+}
+
+// AlertBudget tracks how many security events at or above a severity occurred
+// within a sliding window and flips a gauge to 1 while more than Threshold
+// events have been seen, so dashboards can alert on a single series.
+type AlertBudget struct {
+	config AlertBudgetConfig
+
+	exhaustedGauge metric.Gauge
+	eventsGauge    metric.Gauge
+
+	// recent holds the timestamps of the last Threshold+1 counted events as a ring
+	recent    []time.Time
+	next      int
+	exhausted bool
+	timer     *time.Timer
+	now       func() time.Time
+
+	mu sync.Mutex
+}
+
+// NewAlertBudget creates an AlertBudget that reports its state to the registry
+func NewAlertBudget(registry metric.Registry, config AlertBudgetConfig) *AlertBudget {
+	if config.Name == "" {
+		config.Name = "security"
+	}
+	if config.MinSeverity == SeverityUnspecified {
+		config.MinSeverity = SeverityCritical
+	}
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	if config.Threshold < 0 {
+		config.Threshold = 0
+	}
+
+	tags := metric.Tags{
+		"budget":       config.Name,
+		"min_severity": config.MinSeverity.String(),
+	}
+
+	return &AlertBudget{
+		config: config,
+		exhaustedGauge: registry.Gauge(metric.Options{
+			Name:        "security_alert_budget_exhausted",
+			Description: "Whether the security alert budget is exhausted (1) or not (0)",
+			Unit:        "bool",
+			Tags:        tags,
+		}),
+		eventsGauge: registry.Gauge(metric.Options{
+			Name:        "security_alert_budget_events",
+			Description: "Security events counted against the alert budget in the current window",
+			Unit:        "count",
+			Tags:        tags,
+		}),
+		recent: make([]time.Time, 0, config.Threshold+1),
+		now:    time.Now,
+	}
+}
+
+// Record counts an event against the budget if its severity qualifies
+func (a *AlertBudget) Record(severity Severity) {
+	if severity < a.config.MinSeverity {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if len(a.recent) < cap(a.recent) {
+		a.recent = append(a.recent, now)
+	} else {
+		a.recent[a.next] = now
+		a.next = (a.next + 1) % len(a.recent)
+	}
+
+	a.evaluateLocked(now)
+}
+
+// Exhausted reports whether more than Threshold events occurred within the window
+func (a *AlertBudget) Exhausted() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.evaluateLocked(a.now())
+	return a.exhausted
+}
+
+// Stop cancels any pending re-evaluation of the budget
+func (a *AlertBudget) Stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+}
+
+// evaluateLocked recomputes the window state and updates the gauges.
+// While events remain in the window it schedules another evaluation for when
+// the oldest one expires, so the gauge drops back to 0 once activity stops.
+func (a *AlertBudget) evaluateLocked(now time.Time) {
+	cutoff := now.Add(-a.config.Window)
+
+	inWindow := 0
+	var oldest time.Time
+	for _, ts := range a.recent {
+		if ts.After(cutoff) {
+			inWindow++
+			if oldest.IsZero() || ts.Before(oldest) {
+				oldest = ts
+			}
+		}
+	}
+
+	a.exhausted = inWindow > a.config.Threshold
+	a.eventsGauge.Set(float64(inWindow))
+	if a.exhausted {
+		a.exhaustedGauge.Set(1)
+	} else {
+		a.exhaustedGauge.Set(0)
+	}
+
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	if inWindow > 0 {
+		a.timer = time.AfterFunc(oldest.Sub(cutoff), func() {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			a.evaluateLocked(a.now())
+		})
+	}
+}
+
+// SecurityEventCounter is implemented by OperationalMetrics that can count
+// security events by severity
+type SecurityEventCounter interface {
+	// CountSecurityEvent increments security_events_total{event_type,severity},
+	// adding tags to the series. The event_type and severity tags cannot be overridden.
+	CountSecurityEvent(eventType string, severity Severity, tags metric.Tags)
+}
+
+// CountSecurityEvent implements the SecurityEventCounter interface
+func (om *operationalMetrics) CountSecurityEvent(eventType string, severity Severity, tags metric.Tags) {
+	counterTags := operationalTagPool.Get().(map[string]string)
+	defer operationalTagPool.Put(clearOperationalTags(counterTags))
+
+	maps.Copy(counterTags, tags)
+	counterTags["event_type"] = eventType
+	counterTags["severity"] = severity.String()
+
+	var buf [cacheKeyBufferSize]byte
+	key := appendTagsKey(buf[:0], counterTags)
+
+	om.mu.RLock()
+	counter, exists := om.securityCounters[string(key)]
+	om.mu.RUnlock()
+	if !exists {
+		om.mu.Lock()
+		if counter, exists = om.securityCounters[string(key)]; !exists {
+			opts := metric.Options{
+				Name:        "security_events_total",
+				Description: "Total number of security events by type and severity",
+				Unit:        "count",
+				Tags:        maps.Clone(counterTags),
+			}
+			counter = withinLimit(
+				func() metric.Counter { return om.registry.Counter(opts) },
+				func() metric.Counter { return overflow.Counter(opts) },
+			)
+			om.securityCounters[string(key)] = counter
+		}
+		om.mu.Unlock()
+	}
+	counter.Inc()
+}
+
+// RecordSecurityEventWithSeverity records a security event together with its
+// severity. In addition to the metrics produced by RecordSecurityEvent it counts
+// the event in security_events_total{event_type,severity}, when the builder's
+// OperationalMetrics implements SecurityEventCounter, and against the builder's
+// alert budget, if one is configured.
+func (b *MetricsBuilder) RecordSecurityEventWithSeverity(eventType, action string, severity Severity, context map[string]string) {
+	b.RecordSecurityEvent(eventType, action, context)
+
+	if counter, ok := b.om.(SecurityEventCounter); ok {
+		counter.CountSecurityEvent(eventType, severity, b.baseTags)
+	}
+
+	if b.alertBudget != nil {
+		b.alertBudget.Record(severity)
+	}
+}
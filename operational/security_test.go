@@ -0,0 +1,141 @@
+package operational
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestSeverityString(t *testing.T) {
+	tests := map[Severity]string{
+		SeverityUnspecified: "unspecified",
+		SeverityInfo:        "info",
+		SeverityLow:         "low",
+		SeverityMedium:      "medium",
+		SeverityHigh:        "high",
+		SeverityCritical:    "critical",
+		Severity(42):        "severity_42",
+	}
+	for severity, expected := range tests {
+		if actual := severity.String(); actual != expected {
+			t.Errorf("Severity(%d).String() = %q, expected %q", int(severity), actual, expected)
+		}
+	}
+}
+
+func TestRecordSecurityEventWithSeverity(t *testing.T) {
+	mock := NewMockOperationalMetrics()
+	builder := NewMetricsBuilder(mock)
+
+	builder.RecordSecurityEventWithSeverity("brute_force", "blocked", SeverityHigh, map[string]string{"source": "api"})
+
+	if count := mock.GetOperationCallCount("security_brute_force", "blocked"); count != 1 {
+		t.Errorf("Expected 1 security_brute_force call, got %d", count)
+	}
+	if count := mock.GetOperationCallCount("security_brute_force_source", "api"); count != 1 {
+		t.Errorf("Expected 1 contextual call, got %d", count)
+	}
+	if count := mock.GetSecurityEventCount("brute_force", SeverityHigh); count != 1 {
+		t.Errorf("Expected 1 counted security event, got %d", count)
+	}
+	if count := mock.GetOperationCallCount("security_brute_force_severity", "high"); count != 0 {
+		t.Errorf("Expected no severity operation, got %d", count)
+	}
+}
+
+func TestCountSecurityEvent(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	builder := NewMetricsBuilder(New(registry))
+
+	builder.RecordSecurityEventWithSeverity("brute_force", "blocked", SeverityHigh, nil)
+	builder.RecordSecurityEventWithSeverity("brute_force", "blocked", SeverityHigh, nil)
+	builder.RecordSecurityEventWithSeverity("brute_force", "allowed", SeverityLow, nil)
+
+	count := func(severity Severity) uint64 {
+		return registry.Counter(metric.Options{
+			Name: "security_events_total",
+			Tags: metric.Tags{"event_type": "brute_force", "severity": severity.String()},
+		}).Value()
+	}
+	if got := count(SeverityHigh); got != 2 {
+		t.Errorf("Expected 2 high severity events, got %d", got)
+	}
+	if got := count(SeverityLow); got != 1 {
+		t.Errorf("Expected 1 low severity event, got %d", got)
+	}
+	registry.Each(func(m metric.Metric) {
+		if m.Name() == "security_brute_force_severity_duration" {
+			t.Error("Expected no timer for the severity")
+		}
+	})
+}
+
+func TestAlertBudget(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	var mu sync.Mutex
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	budget := NewAlertBudget(registry, AlertBudgetConfig{Threshold: 2, Window: time.Minute})
+	budget.now = clock
+	defer budget.Stop()
+
+	builder := NewMetricsBuilder(NewMockOperationalMetrics(), WithAlertBudget(budget))
+	exhausted := registry.Gauge(metric.Options{
+		Name: "security_alert_budget_exhausted",
+		Tags: metric.Tags{"budget": "security", "min_severity": SeverityCritical.String()},
+	})
+
+	// Events below the minimum severity are not counted
+	builder.RecordSecurityEventWithSeverity("scan", "flagged", SeverityHigh, nil)
+	builder.RecordSecurityEventWithSeverity("intrusion", "blocked", SeverityCritical, nil)
+	builder.RecordSecurityEventWithSeverity("intrusion", "blocked", SeverityCritical, nil)
+	if budget.Exhausted() || exhausted.Value() != 0 {
+		t.Fatal("Budget should not be exhausted at the threshold")
+	}
+
+	builder.RecordSecurityEventWithSeverity("intrusion", "blocked", SeverityCritical, nil)
+	if !budget.Exhausted() || exhausted.Value() != 1 {
+		t.Fatal("Budget should be exhausted after exceeding the threshold")
+	}
+
+	advance(2 * time.Minute)
+	if budget.Exhausted() || exhausted.Value() != 0 {
+		t.Error("Budget should recover once events leave the window")
+	}
+}
+
+func TestAlertBudgetCountsInfo(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	budget := NewAlertBudget(registry, AlertBudgetConfig{Name: "logins", MinSeverity: SeverityInfo, Threshold: 1})
+	defer budget.Stop()
+
+	budget.Record(SeverityInfo)
+	budget.Record(SeverityInfo)
+	if !budget.Exhausted() {
+		t.Error("Expected Info events to count against a budget with MinSeverity SeverityInfo")
+	}
+	events := registry.Gauge(metric.Options{
+		Name: "security_alert_budget_events",
+		Tags: metric.Tags{"budget": "logins", "min_severity": "info"},
+	})
+	if events.Value() != 2 {
+		t.Errorf("Expected 2 events in the window, got %d", events.Value())
+	}
+}
@@ -0,0 +1,146 @@
+// Package slo tracks service level objectives for operations recorded through
+// operational.OperationalMetrics. A Tracker wraps an OperationalMetrics, passes
+// every call through, and classifies each recorded operation as a good or bad
+// event for the objectives defined on it, maintaining event counters, burn rate
+// and error budget gauges over a rolling window.
+package slo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/operational/internal/window"
+)
+
+func init() {
+	metric.RegisterCapability(metric.Capability{Kind: metric.CapabilityFeature, Name: "slo", Package: "github.com/MichaelAJay/go-metrics/operational/slo"})
+}
+
+const (
+	// EventsMetric counts the events of each objective, tagged slo and result=good|bad
+	EventsMetric = "slo_events_total"
+	// BurnRateMetric is the gauge of how fast each objective consumes its error
+	// budget over the window, in parts per million: 1000000 means the budget
+	// would be used up exactly at the end of the window
+	BurnRateMetric = "slo_burn_rate"
+	// BudgetRemainingMetric is the gauge of the error budget left in the window,
+	// in parts per million of the whole budget; negative once it is exhausted
+	BudgetRemainingMetric = "slo_error_budget_remaining"
+)
+
+// DefaultWindow is the rolling window used when an Objective does not set one
+const DefaultWindow = 30 * 24 * time.Hour
+
+// ppm scales the ratio gauges, since gauges hold integers
+const ppm = 1e6
+
+// Objective defines a service level objective over one operation
+type Objective struct {
+	// Name identifies the objective and is used as the slo tag
+	Name string
+	// Operation is the operation name passed to RecordOperation
+	Operation string
+	// Target is the percentage of events that must be good, e.g. 99.9
+	Target float64
+	// LatencyThreshold makes events slower than it bad, whatever their status; zero disables it
+	LatencyThreshold time.Duration
+	// Window is the rolling window the objective is evaluated over (default DefaultWindow)
+	Window time.Duration
+	// GoodStatuses are the operation statuses that count as good (default "success")
+	GoodStatuses []string
+}
+
+// validate checks the objective and fills in defaults
+func (o *Objective) validate() error {
+	if o.Name == "" {
+		return errors.New("slo: objective name must not be empty")
+	}
+	if o.Operation == "" {
+		return fmt.Errorf("slo: objective %s has no operation", o.Name)
+	}
+	if o.Target <= 0 || o.Target >= 100 {
+		return fmt.Errorf("slo: objective %s target must be between 0 and 100 exclusive, got %v", o.Name, o.Target)
+	}
+	if o.LatencyThreshold < 0 || o.Window < 0 {
+		return fmt.Errorf("slo: objective %s latency threshold and window must not be negative", o.Name)
+	}
+	if o.Window == 0 {
+		o.Window = DefaultWindow
+	}
+	if len(o.GoodStatuses) == 0 {
+		o.GoodStatuses = []string{"success"}
+	}
+	return nil
+}
+
+// good reports whether an operation with the given status and duration meets the objective
+func (o *Objective) good(status string, duration time.Duration) bool {
+	if o.LatencyThreshold > 0 && duration > o.LatencyThreshold {
+		return false
+	}
+	return slices.Contains(o.GoodStatuses, status)
+}
+
+// Status is the state of an objective over its rolling window
+type Status struct {
+	// Good and Total count the events in the window
+	Good, Total uint64
+	// SLI is the percentage of good events in the window; 100 without events
+	SLI float64
+	// BurnRate is the error rate divided by the error rate the target allows
+	BurnRate float64
+	// BudgetRemaining is the fraction of the error budget left, negative once exhausted
+	BudgetRemaining float64
+}
+
+// tracked is an objective with its rolling window and metrics
+type tracked struct {
+	Objective
+	goodEvents      metric.Counter
+	badEvents       metric.Counter
+	burnRate        metric.Gauge
+	budgetRemaining metric.Gauge
+
+	mu     sync.Mutex
+	window *window.Window
+}
+
+// record counts an event and refreshes the gauges
+func (t *tracked) record(good bool, now time.Time) {
+	if good {
+		t.goodEvents.Inc()
+	} else {
+		t.badEvents.Inc()
+	}
+
+	t.mu.Lock()
+	t.window.Add(now, good)
+	t.setGauges(t.statusLocked(now))
+	t.mu.Unlock()
+}
+
+// statusLocked computes the status at now; the caller must hold t.mu
+func (t *tracked) statusLocked(now time.Time) Status {
+	good, total := t.window.Counts(now)
+	status := Status{Good: good, Total: total, SLI: 100, BudgetRemaining: 1}
+	if total == 0 {
+		return status
+	}
+
+	errorRate := float64(total-good) / float64(total)
+	status.SLI = 100 * float64(good) / float64(total)
+	status.BurnRate = errorRate / (1 - t.Target/100)
+	status.BudgetRemaining = 1 - status.BurnRate
+	return status
+}
+
+// setGauges publishes status; the caller holds t.mu so updates are not reordered
+func (t *tracked) setGauges(status Status) {
+	t.burnRate.Set(math.Round(status.BurnRate * ppm))
+	t.budgetRemaining.Set(math.Round(status.BudgetRemaining * ppm))
+}
@@ -0,0 +1,142 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/operational"
+)
+
+// seriesValue returns the value of the series of name with the given slo and result tags
+func seriesValue(t *testing.T, registry metric.Registry, name string, tags metric.Tags) float64 {
+	t.Helper()
+	for _, series := range metric.SeriesOf(registry, name) {
+		match := true
+		for k, v := range tags {
+			if series.Tags[k] != v {
+				match = false
+			}
+		}
+		if match {
+			return series.Value
+		}
+	}
+	t.Fatalf("No %s series with tags %v", name, tags)
+	return 0
+}
+
+func TestTracker(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	mock := operational.NewMockOperationalMetrics()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker, err := New(mock, registry, []Objective{{
+		Name:             "checkout_availability",
+		Operation:        "checkout",
+		Target:           99,
+		LatencyThreshold: 100 * time.Millisecond,
+		Window:           time.Hour,
+	}}, WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	for range 97 {
+		tracker.RecordOperation("checkout", "success", 10*time.Millisecond)
+	}
+	tracker.RecordOperation("checkout", "error", 10*time.Millisecond)
+	tracker.RecordOperation("checkout", "success", time.Second) // too slow
+	func() {
+		defer tracker.Recover("checkout")
+		panic("boom")
+	}()
+	tracker.RecordOperation("search", "error", time.Second) // no objective
+
+	if got := mock.GetOperationCallCount("checkout", "success"); got != 98 {
+		t.Errorf("Expected calls to be passed through, got %d", got)
+	}
+	if mock.GetPanicCallCount("checkout") != 1 || mock.GetErrorCallCount("checkout", operational.PanicErrorType, "message") != 1 {
+		t.Errorf("Expected the panic to be passed through, got %+v %+v", mock.PanicCalls, mock.ErrorCalls)
+	}
+
+	status, ok := tracker.Status("checkout_availability")
+	if !ok || status.Total != 100 || status.Good != 97 || status.SLI != 97 {
+		t.Fatalf("Expected 97 of 100 good events, got %+v", status)
+	}
+	if status.BurnRate < 2.999 || status.BurnRate > 3.001 || status.BudgetRemaining > -1.999 {
+		t.Errorf("Expected a burn rate of 3 and budget of -2, got %+v", status)
+	}
+
+	tags := metric.Tags{"slo": "checkout_availability"}
+	if got := seriesValue(t, registry, EventsMetric, metric.Tags{"slo": "checkout_availability", "result": "bad"}); got != 3 {
+		t.Errorf("Expected 3 bad events, got %v", got)
+	}
+	if got := seriesValue(t, registry, BurnRateMetric, tags); got != 3000000 {
+		t.Errorf("Expected burn rate gauge 3000000, got %v", got)
+	}
+	if got := seriesValue(t, registry, BudgetRemainingMetric, tags); got != -2000000 {
+		t.Errorf("Expected budget gauge -2000000, got %v", got)
+	}
+
+	// Events leave the window, the counters keep them
+	now = now.Add(time.Hour + time.Minute)
+	if status, _ := tracker.Status("checkout_availability"); status.Total != 0 || status.BudgetRemaining != 1 {
+		t.Errorf("Expected an empty window with a full budget, got %+v", status)
+	}
+	if got := seriesValue(t, registry, BudgetRemainingMetric, tags); got != 1000000 {
+		t.Errorf("Expected the budget gauge to be refreshed, got %v", got)
+	}
+	if got := seriesValue(t, registry, EventsMetric, metric.Tags{"slo": "checkout_availability", "result": "good"}); got != 97 {
+		t.Errorf("Expected the good event counter to keep 97, got %v", got)
+	}
+
+	if _, ok := tracker.Status("missing"); ok {
+		t.Error("Expected no status for an unknown objective")
+	}
+}
+
+func TestTrackerRollingWindow(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker, err := New(operational.New(registry), registry, []Objective{
+		{Name: "api", Operation: "api", Target: 90, Window: time.Hour},
+	}, WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	tracker.RecordOperation("api", "error", 0)
+	now = now.Add(30 * time.Minute)
+	tracker.RecordOperation("api", "success", 0)
+	if status, _ := tracker.Status("api"); status.Total != 2 {
+		t.Errorf("Expected both events in the window, got %+v", status)
+	}
+
+	now = now.Add(31 * time.Minute)
+	if status, _ := tracker.Status("api"); status.Total != 1 || status.Good != 1 {
+		t.Errorf("Expected only the later good event in the window, got %+v", status)
+	}
+}
+
+func TestNewValidatesObjectives(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+	om := operational.NewMockOperationalMetrics()
+
+	invalid := [][]Objective{
+		{{Operation: "api", Target: 99}},
+		{{Name: "api", Target: 99}},
+		{{Name: "api", Operation: "api", Target: 100}},
+		{{Name: "api", Operation: "api", Target: 99, Window: -time.Hour}},
+		{{Name: "api", Operation: "api", Target: 99}, {Name: "api", Operation: "other", Target: 99}},
+	}
+	for i, objectives := range invalid {
+		if _, err := New(om, registry, objectives); err == nil {
+			t.Errorf("Case %d: expected an error for %+v", i, objectives)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package slo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/operational"
+	"github.com/MichaelAJay/go-metrics/operational/internal/window"
+)
+
+// Tracker is an operational.OperationalMetrics that also evaluates objectives.
+// Every call is passed through to the wrapped OperationalMetrics. Operations
+// recorded with RecordOperation are good or bad events for the objectives on
+// their operation, and panics recorded with RecordPanic or Recover are bad events.
+type Tracker struct {
+	om         operational.OperationalMetrics
+	objectives map[string][]*tracked // keyed by operation
+	byName     map[string]*tracked
+	now        func() time.Time
+}
+
+// Option configures a Tracker
+type Option func(*Tracker)
+
+// WithClock sets the time source used for the rolling windows, for tests
+func WithClock(now func() time.Time) Option {
+	return func(t *Tracker) {
+		t.now = now
+	}
+}
+
+// New creates a Tracker wrapping om that records the objectives' metrics in
+// registry. It returns an error if an objective is invalid or two share a name.
+func New(om operational.OperationalMetrics, registry metric.Registry, objectives []Objective, opts ...Option) (*Tracker, error) {
+	t := &Tracker{
+		om:         om,
+		objectives: make(map[string][]*tracked),
+		byName:     make(map[string]*tracked),
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	events := registry.Counter(metric.Options{
+		Name:        EventsMetric,
+		Description: "Events evaluated against service level objectives",
+		Unit:        "count",
+	})
+	burnRate := registry.Gauge(metric.Options{
+		Name:        BurnRateMetric,
+		Description: "Error budget burn rate over the objective window",
+		Unit:        "ppm",
+	})
+	budgetRemaining := registry.Gauge(metric.Options{
+		Name:        BudgetRemainingMetric,
+		Description: "Error budget remaining in the objective window",
+		Unit:        "ppm",
+	})
+
+	for _, objective := range objectives {
+		if err := objective.validate(); err != nil {
+			return nil, err
+		}
+		if _, exists := t.byName[objective.Name]; exists {
+			return nil, fmt.Errorf("slo: duplicate objective %s", objective.Name)
+		}
+
+		tags := metric.Tags{"slo": objective.Name}
+		o := &tracked{
+			Objective:       objective,
+			goodEvents:      events.With(metric.Tags{"slo": objective.Name, "result": "good"}),
+			badEvents:       events.With(metric.Tags{"slo": objective.Name, "result": "bad"}),
+			burnRate:        burnRate.With(tags),
+			budgetRemaining: budgetRemaining.With(tags),
+			window:          window.New(objective.Window),
+		}
+		o.setGauges(o.statusLocked(t.now()))
+
+		t.byName[objective.Name] = o
+		t.objectives[objective.Operation] = append(t.objectives[objective.Operation], o)
+	}
+	return t, nil
+}
+
+// RecordError implements operational.OperationalMetrics
+func (t *Tracker) RecordError(operation, errorType, errorCategory string) {
+	t.om.RecordError(operation, errorType, errorCategory)
+}
+
+// RecordOperation implements operational.OperationalMetrics, evaluating the
+// operation against its objectives
+func (t *Tracker) RecordOperation(operation, status string, duration time.Duration) {
+	t.om.RecordOperation(operation, status, duration)
+	t.evaluate(operation, status, duration)
+}
+
+// RecordOperationWithTags implements operational.TaggedOperationRecorder when the
+// wrapped OperationalMetrics does, so builders with base tags keep working
+func (t *Tracker) RecordOperationWithTags(operation, status string, duration time.Duration, tags metric.Tags) {
+	if tagged, ok := t.om.(operational.TaggedOperationRecorder); ok {
+		tagged.RecordOperationWithTags(operation, status, duration, tags)
+	} else {
+		t.om.RecordOperation(operation, status, duration)
+	}
+	t.evaluate(operation, status, duration)
+}
+
+// CountSecurityEvent implements operational.SecurityEventCounter when the
+// wrapped OperationalMetrics does
+func (t *Tracker) CountSecurityEvent(eventType string, severity operational.Severity, tags metric.Tags) {
+	if counter, ok := t.om.(operational.SecurityEventCounter); ok {
+		counter.CountSecurityEvent(eventType, severity, tags)
+	}
+}
+
+// TagValidation implements metric.TagValidator for the wrapped OperationalMetrics
+func (t *Tracker) TagValidation() metric.TagValidationConfig {
+	if validator, ok := t.om.(metric.TagValidator); ok {
+		return validator.TagValidation()
+	}
+	return metric.DefaultTagValidationConfig()
+}
+
+// RecordPanic implements operational.OperationalMetrics; a panic is a bad event
+func (t *Tracker) RecordPanic(operation string) {
+	t.om.RecordPanic(operation)
+	now := t.now()
+	for _, o := range t.objectives[operation] {
+		o.record(false, now)
+	}
+}
+
+// Recover implements operational.OperationalMetrics. It must be deferred directly.
+func (t *Tracker) Recover(operation string) {
+	if r := recover(); r != nil {
+		t.RecordPanic(operation)
+		t.om.RecordError(operation, operational.PanicErrorType, operational.PanicCategory(r))
+	}
+}
+
+// evaluate records an operation as a good or bad event for each of its objectives
+func (t *Tracker) evaluate(operation, status string, duration time.Duration) {
+	objectives := t.objectives[operation]
+	if len(objectives) == 0 {
+		return
+	}
+	now := t.now()
+	for _, o := range objectives {
+		o.record(o.Objective.good(status, duration), now)
+	}
+}
+
+// Status returns the current state of the named objective, refreshing its gauges
+// so they reflect events leaving the window even when no new events arrive
+func (t *Tracker) Status(name string) (Status, bool) {
+	o, ok := t.byName[name]
+	if !ok {
+		return Status{}, false
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	status := o.statusLocked(t.now())
+	o.setGauges(status)
+	return status, true
+}
@@ -77,14 +77,14 @@ func AssertHistogramObserveCalls(t *testing.T, histogram *MockHistogram, expecte
 }
 
 // AssertHistogramSnapshot verifies histogram statistics.
-func AssertHistogramSnapshot(t *testing.T, histogram *MockHistogram, expectedCount uint64, expectedSum uint64) {
+func AssertHistogramSnapshot(t *testing.T, histogram *MockHistogram, expectedCount uint64, expectedSum float64) {
 	t.Helper()
 	snapshot := histogram.Snapshot()
 	if snapshot.Count != expectedCount {
 		t.Errorf("Expected histogram count %d, got %d", expectedCount, snapshot.Count)
 	}
 	if snapshot.Sum != expectedSum {
-		t.Errorf("Expected histogram sum %d, got %d", expectedSum, snapshot.Sum)
+		t.Errorf("Expected histogram sum %g, got %g", expectedSum, snapshot.Sum)
 	}
 }
 
@@ -177,6 +177,10 @@ func MetricExists(t *testing.T, registry *MockRegistry, name string, metricType
 		if gauge := registry.GetGauge(name); gauge != nil {
 			return gauge
 		}
+	case metric.TypeUpDownCounter:
+		if counter := registry.GetUpDownCounter(name); counter != nil {
+			return counter
+		}
 	case metric.TypeHistogram:
 		if histogram := registry.GetHistogram(name); histogram != nil {
 			return histogram
@@ -185,6 +189,10 @@ func MetricExists(t *testing.T, registry *MockRegistry, name string, metricType
 		if timer := registry.GetTimer(name); timer != nil {
 			return timer
 		}
+	case metric.TypeMeter:
+		if meter := registry.GetMeter(name); meter != nil {
+			return meter
+		}
 	}
 	t.Errorf("Metric %s of type %s not found", name, metricType)
 	return nil
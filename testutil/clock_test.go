@@ -0,0 +1,45 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestFakeClockExpiresRegistryTTL(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1700000000, 0))
+	registry := metric.NewRegistry(metric.DefaultTagValidationConfig(), 0, metric.WithClock(clock))
+	defer registry.Close()
+
+	registry.Counter(metric.Options{Name: "fake_clock_ttl", TTL: time.Second}).Inc()
+
+	clock.Advance(500 * time.Millisecond)
+	registry.ManualCleanup()
+	if got := registry.Counter(metric.Options{Name: "fake_clock_ttl"}).Value(); got != 1 {
+		t.Fatalf("expected counter before TTL, got %d", got)
+	}
+
+	clock.Advance(time.Second)
+	registry.ManualCleanup()
+	if got := registry.Counter(metric.Options{Name: "fake_clock_ttl"}).Value(); got != 0 {
+		t.Errorf("expected fresh counter after TTL, got %d", got)
+	}
+}
+
+func TestMockTimerUsesClock(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1700000000, 0))
+	timer := NewMockTimer(metric.Options{Name: "fake_clock_timer"})
+	timer.Clock = clock
+
+	start := clock.Now()
+	clock.Advance(250 * time.Millisecond)
+	timer.RecordSince(start)
+
+	if d := timer.Time(func() { clock.Advance(time.Second) }); d != time.Second {
+		t.Errorf("expected Time to measure 1s, got %v", d)
+	}
+	if calls := timer.RecordCalls(); len(calls) != 2 || calls[0] != 250*time.Millisecond {
+		t.Errorf("expected RecordSince to record 250ms first, got %v", calls)
+	}
+}
@@ -0,0 +1,102 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// DefaultPollInterval is how often the wait helpers sample the registry.
+const DefaultPollInterval = 5 * time.Millisecond
+
+// WaitForCounter blocks until the counters named name in registry sum to at
+// least expected, or ctx is done. It returns the last observed total and an
+// error if the condition was not met.
+func WaitForCounter(ctx context.Context, registry metric.Registry, name string, expected uint64) (uint64, error) {
+	total, err := waitFor(ctx, registry, name, metric.TypeCounter, func(sum float64) bool {
+		return sum >= float64(expected)
+	})
+	if err != nil {
+		return uint64(total), fmt.Errorf("counter %s: expected at least %d, last saw %d: %w", name, expected, uint64(total), err)
+	}
+	return uint64(total), nil
+}
+
+// WaitForGauge blocks until the gauges named name in registry sum to a value
+// satisfying predicate, or ctx is done. It returns the last observed value
+// and an error if the condition was not met.
+func WaitForGauge(ctx context.Context, registry metric.Registry, name string, predicate func(int64) bool) (int64, error) {
+	value, err := waitFor(ctx, registry, name, metric.TypeGauge, func(sum float64) bool {
+		return predicate(int64(sum))
+	})
+	if err != nil {
+		return int64(value), fmt.Errorf("gauge %s: predicate not satisfied, last saw %d: %w", name, int64(value), err)
+	}
+	return int64(value), nil
+}
+
+// EventuallyCounter fails the test if the counter named name does not reach expected within timeout.
+func EventuallyCounter(t *testing.T, registry metric.Registry, name string, expected uint64, timeout time.Duration) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if _, err := WaitForCounter(ctx, registry, name, expected); err != nil {
+		t.Errorf("Eventually: %v", err)
+	}
+}
+
+// EventuallyGauge fails the test if the gauge named name does not satisfy predicate within timeout.
+func EventuallyGauge(t *testing.T, registry metric.Registry, name string, predicate func(int64) bool, timeout time.Duration) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if _, err := WaitForGauge(ctx, registry, name, predicate); err != nil {
+		t.Errorf("Eventually: %v", err)
+	}
+}
+
+// waitFor watches every series with the given name and type and returns once
+// the sum of their values satisfies done. The registry must implement
+// metric.WatchRegistry.
+func waitFor(ctx context.Context, registry metric.Registry, name string, metricType metric.Type, done func(float64) bool) (float64, error) {
+	watcher, ok := registry.(metric.WatchRegistry)
+	if !ok {
+		return 0, fmt.Errorf("%T does not implement metric.WatchRegistry: %w", registry, errors.ErrUnsupported)
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	updates, err := watcher.Watch(watchCtx, metric.WatchFilter{
+		Names:    []string{name},
+		Types:    []metric.Type{metricType},
+		Interval: DefaultPollInterval,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	values := make(map[metric.Metric]float64)
+	var sum float64
+	for update := range updates {
+		values[update.Metric] = update.Value
+
+		sum = 0
+		for _, v := range values {
+			sum += v
+		}
+		if done(sum) {
+			return sum, nil
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return sum, err
+	}
+	return sum, metric.ErrRegistryClosed
+}
@@ -0,0 +1,43 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestEventuallyCounterAndGauge(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	go func() {
+		counter := registry.Counter(metric.Options{Name: "async_jobs_total"})
+		gauge := registry.Gauge(metric.Options{Name: "async_queue_depth"})
+		for i := 0; i < 10; i++ {
+			counter.Inc()
+			gauge.Set(float64(10 - i))
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	EventuallyCounter(t, registry, "async_jobs_total", 10, 2*time.Second)
+	EventuallyGauge(t, registry, "async_queue_depth", func(v int64) bool { return v == 1 }, 2*time.Second)
+}
+
+func TestWaitForCounterTimesOut(t *testing.T) {
+	registry := NewMockRegistry()
+	registry.Counter(DefaultCounterOptions()).Inc()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	last, err := WaitForCounter(ctx, registry, "test_counter", 5)
+	if err == nil {
+		t.Fatal("Expected timeout error")
+	}
+	if last != 1 {
+		t.Errorf("Expected last observed value 1, got %d", last)
+	}
+}
@@ -0,0 +1,198 @@
+// Package golden compares the exposition of a registry against golden files,
+// so CI catches accidental metric renames, retyping and label changes. Output
+// is normalized before comparison: series are sorted and timestamps dropped.
+//
+// Run tests with UPDATE_GOLDEN=1 to rewrite the golden files from the current output.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+	"github.com/MichaelAJay/go-metrics/metric/prometheus"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// UpdateEnv is the environment variable that makes the Assert functions write
+// golden files instead of comparing against them
+const UpdateEnv = "UPDATE_GOLDEN"
+
+// PrometheusText renders registry in the Prometheus text exposition format, as
+// a prometheus.Collector serves it, with metric families and series sorted and
+// sample timestamps removed.
+func PrometheusText(registry metric.Registry) ([]byte, error) {
+	gatherer := prom.NewRegistry()
+	if err := gatherer.Register(prometheus.NewCollector(registry, nil)); err != nil {
+		return nil, err
+	}
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	encoder := expfmt.NewEncoder(&out, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, family := range families {
+		for _, m := range family.Metric {
+			m.TimestampMs = nil
+		}
+		if err := encoder.Encode(family); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// series is the canonical JSON form of one registered series
+type series struct {
+	Name        string      `json:"name"`
+	Type        metric.Type `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Unit        string      `json:"unit,omitempty"`
+	Tags        metric.Tags `json:"tags"`
+	Value       float64     `json:"value"`
+	Histogram   *histogram  `json:"histogram,omitempty"`
+}
+
+// histogram is the canonical JSON form of a histogram or timer snapshot.
+// Quantiles and meter rates are left out because they depend on timing.
+type histogram struct {
+	Count      uint64    `json:"count"`
+	Sum        float64   `json:"sum"`
+	Boundaries []float64 `json:"boundaries"`
+	Buckets    []uint64  `json:"buckets"`
+}
+
+// JSON renders every series of registry as indented JSON sorted by name, type
+// and tags. Values are as reported by Registry.Series: event counts for meters
+// and observation counts, sums and buckets for histograms and timers.
+func JSON(registry metric.Registry) ([]byte, error) {
+	names := make(map[string]struct{})
+	registry.Each(func(m metric.Metric) {
+		names[m.Name()] = struct{}{}
+	})
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	slices.Sort(sorted)
+
+	all := []series{}
+	for _, name := range sorted {
+		// SeriesOf sorts by type and tags within a name
+		for _, info := range metric.SeriesOf(registry, name) {
+			s := series{
+				Name:        name,
+				Type:        info.Type,
+				Description: info.Metric.Description(),
+				Unit:        info.Metric.Unit(),
+				Tags:        info.Tags,
+				Value:       info.Value,
+			}
+			if s.Tags == nil {
+				s.Tags = metric.Tags{}
+			}
+			if snapshot := info.Snapshot; snapshot != nil {
+				s.Histogram = &histogram{
+					Count:      snapshot.Count,
+					Sum:        snapshot.Sum,
+					Boundaries: snapshot.Boundaries,
+					Buckets:    snapshot.Buckets,
+				}
+			}
+			all = append(all, s)
+		}
+	}
+
+	out, err := json.MarshalIndent(struct {
+		Series []series `json:"series"`
+	}{all}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// AssertPrometheus compares the PrometheusText rendering of registry against the golden file at path
+func AssertPrometheus(t *testing.T, registry metric.Registry, path string) {
+	t.Helper()
+	got, err := PrometheusText(registry)
+	if err != nil {
+		t.Fatalf("golden: render Prometheus text: %v", err)
+	}
+	Assert(t, path, got)
+}
+
+// AssertJSON compares the JSON rendering of registry against the golden file at path
+func AssertJSON(t *testing.T, registry metric.Registry, path string) {
+	t.Helper()
+	got, err := JSON(registry)
+	if err != nil {
+		t.Fatalf("golden: render JSON: %v", err)
+	}
+	Assert(t, path, got)
+}
+
+// Assert fails the test if got differs from the contents of the golden file at
+// path, listing the lines missing from and added to the output. With
+// UPDATE_GOLDEN set it writes got to path instead, creating directories as needed.
+func Assert(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("golden: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("golden: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden: %v (run with %s=1 to create it)", err, UpdateEnv)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("golden: output differs from %s (run with %s=1 to update):\n%s", path, UpdateEnv, lineDiff(string(want), string(got)))
+	}
+}
+
+// lineDiff lists the lines of want missing from got with "-" and the lines of
+// got missing from want with "+". Both inputs are sorted renderings, so a
+// renamed or retyped metric shows up as its old and new lines.
+func lineDiff(want, got string) string {
+	counts := make(map[string]int)
+	for _, line := range strings.Split(got, "\n") {
+		counts[line]++
+	}
+
+	var b strings.Builder
+	for _, line := range strings.Split(want, "\n") {
+		if counts[line] > 0 {
+			counts[line]--
+			continue
+		}
+		fmt.Fprintf(&b, "- %s\n", line)
+	}
+
+	wanted := make(map[string]int)
+	for _, line := range strings.Split(want, "\n") {
+		wanted[line]++
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if wanted[line] > 0 {
+			wanted[line]--
+			continue
+		}
+		fmt.Fprintf(&b, "+ %s\n", line)
+	}
+	return b.String()
+}
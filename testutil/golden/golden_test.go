@@ -0,0 +1,61 @@
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func newRegistry() metric.Registry {
+	registry := metric.NewNoCleanupRegistry()
+	requests := registry.Counter(metric.Options{Name: "requests_total", Description: "Requests served"})
+	requests.With(metric.Tags{"method": "POST"}).Add(2)
+	requests.With(metric.Tags{"method": "GET"}).Inc()
+	registry.Gauge(metric.Options{Name: "queue_depth", Unit: "items"}).Set(4)
+	latency := registry.Timer(metric.Options{Name: "latency"})
+	latency.Record(5 * time.Millisecond)
+	latency.Record(20 * time.Millisecond)
+	return registry
+}
+
+func TestGoldenFiles(t *testing.T) {
+	registry := newRegistry()
+	AssertPrometheus(t, registry, filepath.Join("testdata", "registry.prom.golden"))
+	AssertJSON(t, registry, filepath.Join("testdata", "registry.json.golden"))
+}
+
+func TestRenderingIsStable(t *testing.T) {
+	first, err := PrometheusText(newRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := PrometheusText(newRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("Expected identical registries to render identically:\n%s", lineDiff(string(first), string(second)))
+	}
+}
+
+func TestAssertReportsRenames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "renamed.golden")
+	if err := os.WriteFile(path, []byte("a 1\nold_name 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	diff := lineDiff("a 1\nold_name 2\n", "a 1\nnew_name 2\n")
+	if !strings.Contains(diff, "- old_name 2") || !strings.Contains(diff, "+ new_name 2") || strings.Contains(diff, "a 1") {
+		t.Errorf("Expected diff to show only the renamed line, got:\n%s", diff)
+	}
+
+	t.Setenv(UpdateEnv, "1")
+	Assert(t, path, []byte("a 1\nnew_name 2\n"))
+	if data, _ := os.ReadFile(path); string(data) != "a 1\nnew_name 2\n" {
+		t.Errorf("Expected %s to rewrite the golden file, got %q", UpdateEnv, data)
+	}
+}
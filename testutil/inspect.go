@@ -0,0 +1,211 @@
+package testutil
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// Inspector reads the series recorded in a registry so integration tests can
+// assert on actual values rather than mock call counts. It works with any
+// metric.Registry, including the one returned by metric.NewRegistry.
+type Inspector struct {
+	registry metric.Registry
+}
+
+// Inspect creates an Inspector for registry
+func Inspect(registry metric.Registry) *Inspector {
+	return &Inspector{registry: registry}
+}
+
+// Find returns the series of name whose tags equal tags exactly. Nil and empty
+// tags both select the untagged series.
+func (i *Inspector) Find(name string, tags metric.Tags) (metric.SeriesInfo, bool) {
+	for _, series := range metric.SeriesOf(i.registry, name) {
+		if maps.Equal(series.Tags, tags) {
+			return series, true
+		}
+	}
+	return metric.SeriesInfo{}, false
+}
+
+// CounterValue returns the value of the counter series of name with tags
+func (i *Inspector) CounterValue(name string, tags metric.Tags) (uint64, bool) {
+	series, ok := i.find(name, tags, metric.TypeCounter)
+	if !ok {
+		return 0, false
+	}
+	return uint64(series.Value), true
+}
+
+// GaugeValue returns the value of the gauge series of name with tags
+func (i *Inspector) GaugeValue(name string, tags metric.Tags) (int64, bool) {
+	series, ok := i.find(name, tags, metric.TypeGauge)
+	if !ok {
+		return 0, false
+	}
+	return int64(series.Value), true
+}
+
+// Quantile estimates the q-quantile of the histogram or timer series of name
+// with tags, as HistogramQuantile does. Timer quantiles are in nanoseconds.
+func (i *Inspector) Quantile(name string, tags metric.Tags, q float64) (float64, bool) {
+	series, ok := i.Find(name, tags)
+	if !ok || series.Snapshot == nil {
+		return 0, false
+	}
+	return HistogramQuantile(*series.Snapshot, q), true
+}
+
+// Snapshot captures the current value of every series in the registry
+func (i *Inspector) Snapshot() RegistrySnapshot {
+	names := make(map[string]struct{})
+	i.registry.Each(func(m metric.Metric) {
+		names[m.Name()] = struct{}{}
+	})
+
+	snapshot := RegistrySnapshot{series: make(map[string]metric.SeriesInfo)}
+	for name := range names {
+		for _, series := range metric.SeriesOf(i.registry, name) {
+			snapshot.series[seriesKey(name, series.Type, series.Tags)] = series
+		}
+	}
+	return snapshot
+}
+
+// AssertCounter verifies that the counter series of name with tags exists and has the expected value.
+func (i *Inspector) AssertCounter(t *testing.T, name string, tags metric.Tags, expected uint64) {
+	t.Helper()
+	actual, ok := i.CounterValue(name, tags)
+	if !ok {
+		t.Errorf("Expected counter %s to be registered, found none", describeSeries(name, tags))
+		return
+	}
+	if actual != expected {
+		t.Errorf("Expected counter %s value %d, got %d", describeSeries(name, tags), expected, actual)
+	}
+}
+
+// AssertGauge verifies that the gauge series of name with tags exists and has the expected value.
+func (i *Inspector) AssertGauge(t *testing.T, name string, tags metric.Tags, expected int64) {
+	t.Helper()
+	actual, ok := i.GaugeValue(name, tags)
+	if !ok {
+		t.Errorf("Expected gauge %s to be registered, found none", describeSeries(name, tags))
+		return
+	}
+	if actual != expected {
+		t.Errorf("Expected gauge %s value %d, got %d", describeSeries(name, tags), expected, actual)
+	}
+}
+
+// AssertAbsent verifies that no series of name with tags is registered.
+func (i *Inspector) AssertAbsent(t *testing.T, name string, tags metric.Tags) {
+	t.Helper()
+	if series, ok := i.Find(name, tags); ok {
+		t.Errorf("Expected no series %s, found a %s", describeSeries(name, tags), series.Type)
+	}
+}
+
+func (i *Inspector) find(name string, tags metric.Tags, metricType metric.Type) (metric.SeriesInfo, bool) {
+	for _, series := range metric.SeriesOf(i.registry, name) {
+		if series.Type == metricType && maps.Equal(series.Tags, tags) {
+			return series, true
+		}
+	}
+	return metric.SeriesInfo{}, false
+}
+
+// RegistrySnapshot holds the series of a registry at one point in time, taken
+// by Inspector.Snapshot
+type RegistrySnapshot struct {
+	series map[string]metric.SeriesInfo
+}
+
+// Len returns the number of series in the snapshot
+func (s RegistrySnapshot) Len() int {
+	return len(s.series)
+}
+
+// SeriesChange describes how one series differs between two snapshots
+type SeriesChange struct {
+	Name string
+	Type metric.Type
+	Tags metric.Tags
+	// Before and After are the series values as reported in SeriesInfo.Value,
+	// i.e. observation counts for histograms and timers
+	Before float64
+	After  float64
+	// Added is set when the series only exists in the later snapshot
+	Added bool
+	// Removed is set when the series only exists in the earlier snapshot
+	Removed bool
+}
+
+// Delta returns After minus Before
+func (c SeriesChange) Delta() float64 {
+	return c.After - c.Before
+}
+
+// Diff returns the series whose values differ between before and after,
+// including series added or removed in between, sorted by name, type and tags.
+func Diff(before, after RegistrySnapshot) []SeriesChange {
+	var changes []SeriesChange
+	for key, a := range after.series {
+		b, ok := before.series[key]
+		if ok && b.Value == a.Value {
+			continue
+		}
+		change := SeriesChange{Name: a.Metric.Name(), Type: a.Type, Tags: a.Tags, After: a.Value, Added: !ok}
+		if ok {
+			change.Before = b.Value
+		}
+		changes = append(changes, change)
+	}
+	for key, b := range before.series {
+		if _, ok := after.series[key]; !ok {
+			changes = append(changes, SeriesChange{Name: b.Metric.Name(), Type: b.Type, Tags: b.Tags, Before: b.Value, Removed: true})
+		}
+	}
+
+	slices.SortFunc(changes, func(x, y SeriesChange) int {
+		return strings.Compare(seriesKey(x.Name, x.Type, x.Tags), seriesKey(y.Name, y.Type, y.Tags))
+	})
+	return changes
+}
+
+// seriesKey identifies a series by name, type and sorted tags
+func seriesKey(name string, metricType metric.Type, tags metric.Tags) string {
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte(0)
+	b.WriteString(string(metricType))
+	keys := slices.Collect(maps.Keys(tags))
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+	}
+	return b.String()
+}
+
+// describeSeries formats name and tags for assertion messages
+func describeSeries(name string, tags metric.Tags) string {
+	if len(tags) == 0 {
+		return name
+	}
+	keys := slices.Collect(maps.Keys(tags))
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%q", k, tags[k])
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,68 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestInspectRealRegistry(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	requests := registry.Counter(metric.Options{Name: "requests_total"})
+	requests.With(metric.Tags{"method": "GET"}).Add(3)
+	requests.With(metric.Tags{"method": "POST"}).Inc()
+	registry.Gauge(metric.Options{Name: "queue_depth"}).Set(7)
+	latency := registry.Timer(metric.Options{Name: "latency"})
+	for i := 1; i <= 100; i++ {
+		latency.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	inspector := Inspect(registry)
+	inspector.AssertCounter(t, "requests_total", metric.Tags{"method": "GET"}, 3)
+	inspector.AssertCounter(t, "requests_total", metric.Tags{"method": "POST"}, 1)
+	inspector.AssertGauge(t, "queue_depth", nil, 7)
+	inspector.AssertAbsent(t, "requests_total", metric.Tags{"method": "PUT"})
+
+	p50, ok := inspector.Quantile("latency", nil, 0.5)
+	if !ok {
+		t.Fatal("Expected latency timer to be found")
+	}
+	if p50 < float64(10*time.Millisecond) || p50 > float64(100*time.Millisecond) {
+		t.Errorf("Expected latency p50 between 10ms and 100ms, got %v", time.Duration(p50))
+	}
+}
+
+func TestInspectorDiff(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	registry.Counter(metric.Options{Name: "jobs_total"}).Inc()
+	registry.Gauge(metric.Options{Name: "workers"}).Set(4)
+	inspector := Inspect(registry)
+	before := inspector.Snapshot()
+
+	registry.Counter(metric.Options{Name: "jobs_total"}).Add(2)
+	registry.Counter(metric.Options{Name: "errors_total"}).Inc()
+	registry.Unregister("workers")
+	after := inspector.Snapshot()
+
+	changes := Diff(before, after)
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes, got %+v", changes)
+	}
+	if c := changes[0]; c.Name != "errors_total" || !c.Added || c.Delta() != 1 {
+		t.Errorf("Expected errors_total added with delta 1, got %+v", c)
+	}
+	if c := changes[1]; c.Name != "jobs_total" || c.Added || c.Delta() != 2 {
+		t.Errorf("Expected jobs_total to grow by 2, got %+v", c)
+	}
+	if c := changes[2]; c.Name != "workers" || !c.Removed || c.Before != 4 {
+		t.Errorf("Expected workers removed from 4, got %+v", c)
+	}
+	if len(Diff(after, inspector.Snapshot())) != 0 {
+		t.Error("Expected no changes between identical snapshots")
+	}
+}
@@ -1,6 +1,7 @@
 package testutil
 
 import (
+	"maps"
 	"sync"
 	"time"
 
@@ -11,8 +12,13 @@ import (
 type baseMetric struct {
 	name        string
 	description string
+	unit        string
 	metricType  metric.Type
 	tags        metric.Tags
+
+	// resolveWith returns the child series for With; set by a MockRegistry
+	// with KeyByTags so tagged children are registered and can be looked up
+	resolveWith func(tags metric.Tags) metric.Metric
 }
 
 func (b *baseMetric) Name() string {
@@ -23,6 +29,10 @@ func (b *baseMetric) Description() string {
 	return b.description
 }
 
+func (b *baseMetric) Unit() string {
+	return b.unit
+}
+
 func (b *baseMetric) Type() metric.Type {
 	return b.metricType
 }
@@ -31,10 +41,23 @@ func (b *baseMetric) Tags() metric.Tags {
 	return b.tags
 }
 
+func (b *baseMetric) base() *baseMetric {
+	return b
+}
+
+// childOptions returns the options of the series With(tags) resolves to
+func (b *baseMetric) childOptions(tags metric.Tags) metric.Options {
+	merged := make(metric.Tags, len(b.tags)+len(tags))
+	maps.Copy(merged, b.tags)
+	maps.Copy(merged, tags)
+	return metric.Options{Name: b.name, Description: b.description, Unit: b.unit, Tags: merged}
+}
+
 // MockCounter captures counter operations for inspection in tests.
 type MockCounter struct {
 	baseMetric
-	value     uint64
+	value      uint64
+	generation uint64
 	incCalls  int
 	addCalls  []float64
 	withCalls []metric.Tags
@@ -53,9 +76,11 @@ func NewMockCounter(opts metric.Options) *MockCounter {
 		baseMetric: baseMetric{
 			name:        opts.Name,
 			description: opts.Description,
+			unit:        opts.Unit,
 			metricType:  metric.TypeCounter,
 			tags:        opts.Tags,
 		},
+		generation: 1,
 	}
 }
 
@@ -85,14 +110,17 @@ func (m *MockCounter) Add(value float64) {
 
 func (m *MockCounter) With(tags metric.Tags) metric.Counter {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	
 	m.withCalls = append(m.withCalls, tags)
-	
-	if m.OnWithCallback != nil {
-		return m.OnWithCallback(tags)
+	callback := m.OnWithCallback
+	m.mu.Unlock()
+
+	if callback != nil {
+		return callback(tags)
 	}
-	
+	if m.resolveWith != nil {
+		return m.resolveWith(tags).(metric.Counter)
+	}
+
 	// For simplicity, return the same instance
 	return m
 }
@@ -103,6 +131,22 @@ func (m *MockCounter) Value() uint64 {
 	return m.value
 }
 
+func (m *MockCounter) Swap() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value := m.value
+	m.value = 0
+	m.generation++
+	return value
+}
+
+// Snapshot returns the value with the number of resets as its generation.
+func (m *MockCounter) Snapshot() metric.CounterSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return metric.CounterSnapshot{Value: m.value, Generation: m.generation}
+}
+
 // Test inspection methods
 func (m *MockCounter) IncCalls() int {
 	m.mu.RLock()
@@ -124,16 +168,333 @@ func (m *MockCounter) WithCalls() []metric.Tags {
 	return result
 }
 
+// Reset zeroes the counter, starting a new generation, and clears the recorded calls.
 func (m *MockCounter) Reset() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	
 	m.value = 0
+	m.generation++
 	m.incCalls = 0
 	m.addCalls = nil
 	m.withCalls = nil
 }
 
+// MockUpDownCounter captures up-down counter operations for inspection in tests.
+type MockUpDownCounter struct {
+	baseMetric
+	value     int64
+	addCalls  []float64
+	incCalls  int
+	decCalls  int
+	withCalls []metric.Tags
+
+	// Optional callbacks
+	OnAddCallback  func(value float64)
+	OnIncCallback  func()
+	OnDecCallback  func()
+	OnWithCallback func(tags metric.Tags) metric.UpDownCounter
+
+	mu sync.RWMutex
+}
+
+// NewMockUpDownCounter creates a new MockUpDownCounter instance.
+func NewMockUpDownCounter(opts metric.Options) *MockUpDownCounter {
+	return &MockUpDownCounter{
+		baseMetric: baseMetric{
+			name:        opts.Name,
+			description: opts.Description,
+			unit:        opts.Unit,
+			metricType:  metric.TypeUpDownCounter,
+			tags:        opts.Tags,
+		},
+	}
+}
+
+func (m *MockUpDownCounter) Add(value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.addCalls = append(m.addCalls, value)
+	m.value += int64(value)
+
+	if m.OnAddCallback != nil {
+		m.OnAddCallback(value)
+	}
+}
+
+func (m *MockUpDownCounter) Inc() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.incCalls++
+	m.value++
+
+	if m.OnIncCallback != nil {
+		m.OnIncCallback()
+	}
+}
+
+func (m *MockUpDownCounter) Dec() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.decCalls++
+	m.value--
+
+	if m.OnDecCallback != nil {
+		m.OnDecCallback()
+	}
+}
+
+func (m *MockUpDownCounter) With(tags metric.Tags) metric.UpDownCounter {
+	m.mu.Lock()
+	m.withCalls = append(m.withCalls, tags)
+	callback := m.OnWithCallback
+	m.mu.Unlock()
+
+	if callback != nil {
+		return callback(tags)
+	}
+	if m.resolveWith != nil {
+		return m.resolveWith(tags).(metric.UpDownCounter)
+	}
+
+	return m
+}
+
+func (m *MockUpDownCounter) Value() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.value
+}
+
+// Test inspection methods
+func (m *MockUpDownCounter) AddCalls() []float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]float64(nil), m.addCalls...)
+}
+
+func (m *MockUpDownCounter) IncCalls() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.incCalls
+}
+
+func (m *MockUpDownCounter) DecCalls() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.decCalls
+}
+
+func (m *MockUpDownCounter) WithCalls() []metric.Tags {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]metric.Tags, len(m.withCalls))
+	copy(result, m.withCalls)
+	return result
+}
+
+func (m *MockUpDownCounter) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.value = 0
+	m.addCalls = nil
+	m.incCalls = 0
+	m.decCalls = 0
+	m.withCalls = nil
+}
+
+// MockMeter captures meter operations for inspection in tests. Its snapshot
+// reports the marked count; rates are left to the tests that set them.
+type MockMeter struct {
+	baseMetric
+	count     int64
+	markCalls []int64
+	withCalls []metric.Tags
+
+	// Rates returned by Snapshot
+	Rates metric.MeterSnapshot
+
+	// Optional callbacks
+	OnMarkCallback func(n int64)
+	OnWithCallback func(tags metric.Tags) metric.Meter
+
+	mu sync.RWMutex
+}
+
+// NewMockMeter creates a new MockMeter instance.
+func NewMockMeter(opts metric.Options) *MockMeter {
+	return &MockMeter{
+		baseMetric: baseMetric{
+			name:        opts.Name,
+			description: opts.Description,
+			unit:        opts.Unit,
+			metricType:  metric.TypeMeter,
+			tags:        opts.Tags,
+		},
+	}
+}
+
+func (m *MockMeter) Mark(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.markCalls = append(m.markCalls, n)
+	if n > 0 {
+		m.count += n
+	}
+
+	if m.OnMarkCallback != nil {
+		m.OnMarkCallback(n)
+	}
+}
+
+func (m *MockMeter) With(tags metric.Tags) metric.Meter {
+	m.mu.Lock()
+	m.withCalls = append(m.withCalls, tags)
+	callback := m.OnWithCallback
+	m.mu.Unlock()
+
+	if callback != nil {
+		return callback(tags)
+	}
+	if m.resolveWith != nil {
+		return m.resolveWith(tags).(metric.Meter)
+	}
+
+	return m
+}
+
+func (m *MockMeter) Count() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.count
+}
+
+func (m *MockMeter) Snapshot() metric.MeterSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	snapshot := m.Rates
+	snapshot.Count = m.count
+	return snapshot
+}
+
+// Test inspection methods
+func (m *MockMeter) MarkCalls() []int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]int64(nil), m.markCalls...)
+}
+
+func (m *MockMeter) WithCalls() []metric.Tags {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]metric.Tags, len(m.withCalls))
+	copy(result, m.withCalls)
+	return result
+}
+
+func (m *MockMeter) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.count = 0
+	m.markCalls = nil
+	m.withCalls = nil
+	m.Rates = metric.MeterSnapshot{}
+}
+
+// MockCardinality captures cardinality operations for inspection in tests. Its
+// estimate is the exact number of distinct values observed.
+type MockCardinality struct {
+	baseMetric
+	values       map[string]struct{}
+	observeCalls []string
+	withCalls    []metric.Tags
+
+	// Optional callbacks
+	OnObserveCallback func(value string)
+	OnWithCallback    func(tags metric.Tags) metric.Cardinality
+
+	mu sync.RWMutex
+}
+
+// NewMockCardinality creates a new MockCardinality instance.
+func NewMockCardinality(opts metric.Options) *MockCardinality {
+	return &MockCardinality{
+		baseMetric: baseMetric{
+			name:        opts.Name,
+			description: opts.Description,
+			unit:        opts.Unit,
+			metricType:  metric.TypeGauge,
+			tags:        opts.Tags,
+		},
+		values: make(map[string]struct{}),
+	}
+}
+
+func (m *MockCardinality) Observe(value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.observeCalls = append(m.observeCalls, value)
+	m.values[value] = struct{}{}
+
+	if m.OnObserveCallback != nil {
+		m.OnObserveCallback(value)
+	}
+}
+
+func (m *MockCardinality) With(tags metric.Tags) metric.Cardinality {
+	m.mu.Lock()
+	m.withCalls = append(m.withCalls, tags)
+	callback := m.OnWithCallback
+	m.mu.Unlock()
+
+	if callback != nil {
+		return callback(tags)
+	}
+	if m.resolveWith != nil {
+		return m.resolveWith(tags).(metric.Cardinality)
+	}
+
+	return m
+}
+
+func (m *MockCardinality) Estimate() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return uint64(len(m.values))
+}
+
+// Test inspection methods
+func (m *MockCardinality) ObserveCalls() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.observeCalls...)
+}
+
+func (m *MockCardinality) WithCalls() []metric.Tags {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]metric.Tags, len(m.withCalls))
+	copy(result, m.withCalls)
+	return result
+}
+
+// Reset forgets the observed values and clears the call history.
+func (m *MockCardinality) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values = make(map[string]struct{})
+	m.observeCalls = nil
+	m.withCalls = nil
+}
+
 // MockGauge captures gauge operations for inspection in tests.
 type MockGauge struct {
 	baseMetric
@@ -143,6 +504,7 @@ type MockGauge struct {
 	incCalls  int
 	decCalls  int
 	withCalls []metric.Tags
+	valueFunc func() float64 // set for gauges created with GaugeFunc
 	
 	// Optional callbacks
 	OnSetCallback  func(value float64)
@@ -160,6 +522,7 @@ func NewMockGauge(opts metric.Options) *MockGauge {
 		baseMetric: baseMetric{
 			name:        opts.Name,
 			description: opts.Description,
+			unit:        opts.Unit,
 			metricType:  metric.TypeGauge,
 			tags:        opts.Tags,
 		},
@@ -216,20 +579,34 @@ func (m *MockGauge) Dec() {
 
 func (m *MockGauge) With(tags metric.Tags) metric.Gauge {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	
 	m.withCalls = append(m.withCalls, tags)
-	
-	if m.OnWithCallback != nil {
-		return m.OnWithCallback(tags)
+	callback := m.OnWithCallback
+	m.mu.Unlock()
+
+	if callback != nil {
+		return callback(tags)
 	}
-	
+	if m.resolveWith != nil {
+		return m.resolveWith(tags).(metric.Gauge)
+	}
+
 	return m
 }
 
+// NewMockGaugeFunc creates a MockGauge whose Value() evaluates fn.
+// Set, Add, Inc and Dec calls are still recorded but do not change the value.
+func NewMockGaugeFunc(opts metric.Options, fn func() float64) *MockGauge {
+	gauge := NewMockGauge(opts)
+	gauge.valueFunc = fn
+	return gauge
+}
+
 func (m *MockGauge) Value() int64 {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
+	if m.valueFunc != nil {
+		return int64(m.valueFunc())
+	}
 	return m.value
 }
 
@@ -299,6 +676,7 @@ func NewMockHistogram(opts metric.Options) *MockHistogram {
 		baseMetric: baseMetric{
 			name:        opts.Name,
 			description: opts.Description,
+			unit:        opts.Unit,
 			metricType:  metric.TypeHistogram,
 			tags:        opts.Tags,
 		},
@@ -316,12 +694,12 @@ func (m *MockHistogram) Observe(value float64) {
 	
 	// Update snapshot
 	m.snapshot.Count++
-	m.snapshot.Sum += uint64(value)
-	if m.snapshot.Min == 0 || uint64(value) < m.snapshot.Min {
-		m.snapshot.Min = uint64(value)
+	m.snapshot.Sum += value
+	if m.snapshot.Count == 1 || value < m.snapshot.Min {
+		m.snapshot.Min = value
 	}
-	if uint64(value) > m.snapshot.Max {
-		m.snapshot.Max = uint64(value)
+	if m.snapshot.Count == 1 || value > m.snapshot.Max {
+		m.snapshot.Max = value
 	}
 	
 	if m.OnObserveCallback != nil {
@@ -331,14 +709,17 @@ func (m *MockHistogram) Observe(value float64) {
 
 func (m *MockHistogram) With(tags metric.Tags) metric.Histogram {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	
 	m.withCalls = append(m.withCalls, tags)
-	
-	if m.OnWithCallback != nil {
-		return m.OnWithCallback(tags)
+	callback := m.OnWithCallback
+	m.mu.Unlock()
+
+	if callback != nil {
+		return callback(tags)
 	}
-	
+	if m.resolveWith != nil {
+		return m.resolveWith(tags).(metric.Histogram)
+	}
+
 	return m
 }
 
@@ -353,6 +734,23 @@ func (m *MockHistogram) Snapshot() metric.HistogramSnapshot {
 	return m.snapshot
 }
 
+// SnapshotAndReset returns the snapshot and clears it; recorded calls are kept.
+// OnSnapshotCallback, when set, is returned instead and nothing is cleared.
+func (m *MockHistogram) SnapshotAndReset() metric.HistogramSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.OnSnapshotCallback != nil {
+		return m.OnSnapshotCallback()
+	}
+
+	snapshot := m.snapshot
+	m.snapshot = metric.HistogramSnapshot{
+		Buckets: make([]uint64, 10),
+	}
+	return snapshot
+}
+
 // Test inspection methods
 func (m *MockHistogram) ObserveCalls() []float64 {
 	m.mu.RLock()
@@ -395,6 +793,9 @@ type MockTimer struct {
 	OnWithCallback        func(tags metric.Tags) metric.Timer
 	OnSnapshotCallback    func() metric.HistogramSnapshot
 	
+	// Clock measures RecordSince and Time; nil means metric.SystemClock
+	Clock metric.Clock
+	
 	mu sync.RWMutex
 }
 
@@ -404,6 +805,7 @@ func NewMockTimer(opts metric.Options) *MockTimer {
 		baseMetric: baseMetric{
 			name:        opts.Name,
 			description: opts.Description,
+			unit:        opts.Unit,
 			metricType:  metric.TypeTimer,
 			tags:        opts.Tags,
 		},
@@ -421,12 +823,12 @@ func (m *MockTimer) Record(d time.Duration) {
 	
 	// Update snapshot
 	m.snapshot.Count++
-	duration := uint64(d.Nanoseconds())
+	duration := float64(d.Nanoseconds())
 	m.snapshot.Sum += duration
-	if m.snapshot.Min == 0 || duration < m.snapshot.Min {
+	if m.snapshot.Count == 1 || duration < m.snapshot.Min {
 		m.snapshot.Min = duration
 	}
-	if duration > m.snapshot.Max {
+	if m.snapshot.Count == 1 || duration > m.snapshot.Max {
 		m.snapshot.Max = duration
 	}
 	
@@ -441,7 +843,7 @@ func (m *MockTimer) RecordSince(t time.Time) {
 	
 	m.recordSinceCalls = append(m.recordSinceCalls, t)
 	
-	duration := time.Since(t)
+	duration := m.now().Sub(t)
 	m.recordCalls = append(m.recordCalls, duration)
 	
 	if m.OnRecordSinceCallback != nil {
@@ -458,24 +860,34 @@ func (m *MockTimer) Time(fn func()) time.Duration {
 		return m.OnTimeCallback(fn)
 	}
 	
-	start := time.Now()
+	start := m.now()
 	fn()
-	duration := time.Since(start)
+	duration := m.now().Sub(start)
 	
 	m.Record(duration)
 	return duration
 }
 
+func (m *MockTimer) now() time.Time {
+	if m.Clock != nil {
+		return m.Clock.Now()
+	}
+	return metric.SystemClock.Now()
+}
+
 func (m *MockTimer) With(tags metric.Tags) metric.Timer {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	
 	m.withCalls = append(m.withCalls, tags)
-	
-	if m.OnWithCallback != nil {
-		return m.OnWithCallback(tags)
+	callback := m.OnWithCallback
+	m.mu.Unlock()
+
+	if callback != nil {
+		return callback(tags)
 	}
-	
+	if m.resolveWith != nil {
+		return m.resolveWith(tags).(metric.Timer)
+	}
+
 	return m
 }
 
@@ -490,6 +902,23 @@ func (m *MockTimer) Snapshot() metric.HistogramSnapshot {
 	return m.snapshot
 }
 
+// SnapshotAndReset returns the snapshot and clears it; recorded calls are kept.
+// OnSnapshotCallback, when set, is returned instead and nothing is cleared.
+func (m *MockTimer) SnapshotAndReset() metric.HistogramSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.OnSnapshotCallback != nil {
+		return m.OnSnapshotCallback()
+	}
+
+	snapshot := m.snapshot
+	m.snapshot = metric.HistogramSnapshot{
+		Buckets: make([]uint64, 10),
+	}
+	return snapshot
+}
+
 // Test inspection methods
 func (m *MockTimer) RecordCalls() []time.Duration {
 	m.mu.RLock()
@@ -1,44 +1,89 @@
 package testutil
 
 import (
+	"context"
+	"maps"
+	"strings"
 	"sync"
 
 	"github.com/MichaelAJay/go-metrics/metric"
 )
 
+// SetMetadataCall records the arguments of a SetMetadata call.
+type SetMetadataCall struct {
+	Name        string
+	Description string
+	Unit        string
+}
+
+// WatchThresholdCall records the arguments of a WatchThreshold call.
+type WatchThresholdCall struct {
+	Name      string
+	Condition metric.Condition
+}
+
 // MockRegistry captures metric operations for inspection in tests.
 type MockRegistry struct {
-	counters   map[string]*MockCounter
-	gauges     map[string]*MockGauge
-	histograms map[string]*MockHistogram
-	timers     map[string]*MockTimer
+	counters       map[string]*MockCounter
+	gauges         map[string]*MockGauge
+	upDownCounters map[string]*MockUpDownCounter
+	histograms     map[string]*MockHistogram
+	timers         map[string]*MockTimer
+	meters         map[string]*MockMeter
+	cardinalities  map[string]*MockCardinality
 	
 	// Call tracking
-	CounterCalls   []metric.Options
-	GaugeCalls     []metric.Options
-	HistogramCalls []metric.Options
-	TimerCalls     []metric.Options
-	UnregisterCalls []string
-	EachCalls      int
-	
+	CounterCalls        []metric.Options
+	GaugeCalls          []metric.Options
+	GaugeFuncCalls      []metric.Options
+	DerivedCalls        []metric.Options
+	UpDownCounterCalls  []metric.Options
+	HistogramCalls      []metric.Options
+	TimerCalls          []metric.Options
+	MeterCalls          []metric.Options
+	CardinalityCalls    []metric.Options
+	UnregisterCalls     []string
+	EachCalls           int
+	WatchCalls          []metric.WatchFilter
+	WatchThresholdCalls []WatchThresholdCall
+	ExpireHooks         []func(metric.Metric)
+	SetMetadataCalls    []SetMetadataCall
+	TagValidationCalls  []metric.TagValidationConfig
+	PauseCalls          int
+	ResumeCalls         int
+
+	// KeyByTags keys metrics by name and tags instead of name alone, so series
+	// created with different tag sets, including the children returned by
+	// With(), are distinct mocks. GetCounter and the other name lookups then
+	// return the untagged series. Set it before creating metrics.
+	KeyByTags bool
+
 	// Optional callbacks for custom test behavior
-	OnCounterCallback   func(opts metric.Options) metric.Counter
-	OnGaugeCallback     func(opts metric.Options) metric.Gauge
-	OnHistogramCallback func(opts metric.Options) metric.Histogram
-	OnTimerCallback     func(opts metric.Options) metric.Timer
-	OnUnregisterCallback func(name string)
-	OnEachCallback      func(fn func(metric.Metric))
-	
-	mu sync.RWMutex
+	OnCounterCallback       func(opts metric.Options) metric.Counter
+	OnGaugeCallback         func(opts metric.Options) metric.Gauge
+	OnGaugeFuncCallback     func(opts metric.Options, fn func() float64) metric.Gauge
+	OnUpDownCounterCallback func(opts metric.Options) metric.UpDownCounter
+	OnHistogramCallback     func(opts metric.Options) metric.Histogram
+	OnTimerCallback         func(opts metric.Options) metric.Timer
+	OnMeterCallback         func(opts metric.Options) metric.Meter
+	OnCardinalityCallback   func(opts metric.Options) metric.Cardinality
+	OnUnregisterCallback    func(name string)
+	OnEachCallback          func(fn func(metric.Metric))
+
+	paused bool
+	mu     sync.RWMutex
 }
 
 // NewMockRegistry creates a new MockRegistry instance.
 func NewMockRegistry() *MockRegistry {
 	return &MockRegistry{
-		counters:   make(map[string]*MockCounter),
-		gauges:     make(map[string]*MockGauge),
-		histograms: make(map[string]*MockHistogram),
-		timers:     make(map[string]*MockTimer),
+		counters:       make(map[string]*MockCounter),
+		gauges:         make(map[string]*MockGauge),
+		upDownCounters: make(map[string]*MockUpDownCounter),
+		histograms:     make(map[string]*MockHistogram),
+		timers:         make(map[string]*MockTimer),
+		meters:         make(map[string]*MockMeter),
+		cardinalities:  make(map[string]*MockCardinality),
 	}
 }
 
@@ -53,13 +98,7 @@ func (m *MockRegistry) Counter(opts metric.Options) metric.Counter {
 		return m.OnCounterCallback(opts)
 	}
 	
-	if counter, exists := m.counters[opts.Name]; exists {
-		return counter
-	}
-	
-	counter := NewMockCounter(opts)
-	m.counters[opts.Name] = counter
-	return counter
+	return seriesFor(m, m.counters, opts, NewMockCounter)
 }
 
 // Gauge creates or retrieves a MockGauge.
@@ -73,13 +112,52 @@ func (m *MockRegistry) Gauge(opts metric.Options) metric.Gauge {
 		return m.OnGaugeCallback(opts)
 	}
 	
-	if gauge, exists := m.gauges[opts.Name]; exists {
-		return gauge
+	return seriesFor(m, m.gauges, opts, NewMockGauge)
+}
+
+// GaugeFunc creates or retrieves a MockGauge whose value is computed by fn.
+func (m *MockRegistry) GaugeFunc(opts metric.Options, fn func() float64) metric.Gauge {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.GaugeFuncCalls = append(m.GaugeFuncCalls, opts)
+
+	if m.OnGaugeFuncCallback != nil {
+		return m.OnGaugeFuncCallback(opts, fn)
 	}
-	
-	gauge := NewMockGauge(opts)
-	m.gauges[opts.Name] = gauge
-	return gauge
+
+	return seriesFor(m, m.gauges, opts, func(opts metric.Options) *MockGauge {
+		return NewMockGaugeFunc(opts, fn)
+	})
+}
+
+// Derived creates or retrieves a MockGauge computed by fn from the mock's
+// metrics each time its value is read.
+func (m *MockRegistry) Derived(opts metric.Options, fn func(metric.SnapshotView) float64) metric.Gauge {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.DerivedCalls = append(m.DerivedCalls, opts)
+
+	return seriesFor(m, m.gauges, opts, func(opts metric.Options) *MockGauge {
+		return NewMockGaugeFunc(opts, func() float64 {
+			return fn(metric.NewSnapshotView(opts.Tags, m.all()))
+		})
+	})
+}
+
+// UpDownCounter creates or retrieves a MockUpDownCounter.
+func (m *MockRegistry) UpDownCounter(opts metric.Options) metric.UpDownCounter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.UpDownCounterCalls = append(m.UpDownCounterCalls, opts)
+
+	if m.OnUpDownCounterCallback != nil {
+		return m.OnUpDownCounterCallback(opts)
+	}
+
+	return seriesFor(m, m.upDownCounters, opts, NewMockUpDownCounter)
 }
 
 // Histogram creates or retrieves a MockHistogram.
@@ -93,13 +171,7 @@ func (m *MockRegistry) Histogram(opts metric.Options) metric.Histogram {
 		return m.OnHistogramCallback(opts)
 	}
 	
-	if histogram, exists := m.histograms[opts.Name]; exists {
-		return histogram
-	}
-	
-	histogram := NewMockHistogram(opts)
-	m.histograms[opts.Name] = histogram
-	return histogram
+	return seriesFor(m, m.histograms, opts, NewMockHistogram)
 }
 
 // Timer creates or retrieves a MockTimer.
@@ -113,13 +185,35 @@ func (m *MockRegistry) Timer(opts metric.Options) metric.Timer {
 		return m.OnTimerCallback(opts)
 	}
 	
-	if timer, exists := m.timers[opts.Name]; exists {
-		return timer
+	return seriesFor(m, m.timers, opts, NewMockTimer)
+}
+
+// Meter creates or retrieves a MockMeter.
+func (m *MockRegistry) Meter(opts metric.Options) metric.Meter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.MeterCalls = append(m.MeterCalls, opts)
+
+	if m.OnMeterCallback != nil {
+		return m.OnMeterCallback(opts)
 	}
-	
-	timer := NewMockTimer(opts)
-	m.timers[opts.Name] = timer
-	return timer
+
+	return seriesFor(m, m.meters, opts, NewMockMeter)
+}
+
+// Cardinality creates or retrieves a MockCardinality.
+func (m *MockRegistry) Cardinality(opts metric.Options) metric.Cardinality {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.CardinalityCalls = append(m.CardinalityCalls, opts)
+
+	if m.OnCardinalityCallback != nil {
+		return m.OnCardinalityCallback(opts)
+	}
+
+	return seriesFor(m, m.cardinalities, opts, NewMockCardinality)
 }
 
 // Unregister removes a metric from the registry.
@@ -133,36 +227,178 @@ func (m *MockRegistry) Unregister(name string) {
 		m.OnUnregisterCallback(name)
 	}
 	
-	delete(m.counters, name)
-	delete(m.gauges, name)
-	delete(m.histograms, name)
-	delete(m.timers, name)
+	named := func(mt metric.Metric) bool {
+		return mt.Name() == name
+	}
+	deleteMatching(m.counters, named)
+	deleteMatching(m.gauges, named)
+	deleteMatching(m.upDownCounters, named)
+	deleteMatching(m.histograms, named)
+	deleteMatching(m.timers, named)
+	deleteMatching(m.meters, named)
+	deleteMatching(m.cardinalities, named)
+}
+
+// UnregisterMetric removes the metric of type t registered under name.
+func (m *MockRegistry) UnregisterMetric(name string, t metric.Type) {
+	m.UnregisterWhere(func(mt metric.Metric) bool {
+		return mt.Name() == name && mt.Type() == t
+	})
+}
+
+// UnregisterWhere removes every metric for which match returns true.
+func (m *MockRegistry) UnregisterWhere(match func(metric.Metric) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleteMatching(m.counters, match)
+	deleteMatching(m.gauges, match)
+	deleteMatching(m.upDownCounters, match)
+	deleteMatching(m.histograms, match)
+	deleteMatching(m.timers, match)
+	deleteMatching(m.meters, match)
+	deleteMatching(m.cardinalities, match)
+}
+
+// UnregisterPrefix removes every metric whose name starts with prefix.
+func (m *MockRegistry) UnregisterPrefix(prefix string) {
+	m.UnregisterWhere(func(mt metric.Metric) bool {
+		return strings.HasPrefix(mt.Name(), prefix)
+	})
+}
+
+// mockMetric is implemented by the mocks, which all embed baseMetric
+type mockMetric interface {
+	metric.Metric
+	base() *baseMetric
+}
+
+// seriesFor returns the mock registered for opts in metrics, creating it with
+// create if there is none. With KeyByTags the mock's With() resolves to
+// children registered the same way. The caller must hold m.mu.
+func seriesFor[M mockMetric](m *MockRegistry, metrics map[string]M, opts metric.Options, create func(metric.Options) M) M {
+	key := m.key(opts.Name, opts.Tags)
+	if existing, exists := metrics[key]; exists {
+		return existing
+	}
+
+	created := create(opts)
+	if m.KeyByTags {
+		created.base().resolveWith = func(tags metric.Tags) metric.Metric {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			return seriesFor(m, metrics, created.base().childOptions(tags), create)
+		}
+	}
+	metrics[key] = created
+	return created
+}
+
+// seriesWithTags returns the mock of name whose tags equal tags, or nil
+func seriesWithTags[M mockMetric](m *MockRegistry, metrics map[string]M, name string, tags metric.Tags) M {
+	if mt, exists := metrics[m.key(name, tags)]; exists && maps.Equal(mt.Tags(), tags) {
+		return mt
+	}
+	var none M
+	return none
 }
 
-// Each iterates over all registered metrics.
+// key returns the map key of a series: its name, plus its tags with KeyByTags
+func (m *MockRegistry) key(name string, tags metric.Tags) string {
+	if !m.KeyByTags {
+		return name
+	}
+	return describeSeries(name, tags)
+}
+
+// deleteMatching removes the entries of metrics for which match returns true.
+func deleteMatching[M metric.Metric](metrics map[string]M, match func(metric.Metric) bool) {
+	for name, mt := range metrics {
+		if match(mt) {
+			delete(metrics, name)
+		}
+	}
+}
+
+// Each iterates over all registered metrics. Callbacks run without the mock's
+// lock held, so they may create metrics, as with the real registry.
 func (m *MockRegistry) Each(fn func(metric.Metric)) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	
+	m.mu.Lock()
 	m.EachCalls++
-	
-	if m.OnEachCallback != nil {
-		m.OnEachCallback(fn)
+	callback := m.OnEachCallback
+	m.mu.Unlock()
+
+	if callback != nil {
+		callback(fn)
 		return
 	}
-	
+
+	for _, registered := range m.all() {
+		fn(registered)
+	}
+}
+
+// EachByType iterates over the mock's metrics of type t using metric.EachOfType.
+func (m *MockRegistry) EachByType(t metric.Type, fn func(metric.Metric)) {
+	metric.EachOfType(m, t, fn)
+}
+
+// Lookup finds the mock's untagged series of name and type t using metric.FindMetric.
+func (m *MockRegistry) Lookup(name string, t metric.Type) (metric.Metric, bool) {
+	return metric.FindMetric(m, name, t, nil)
+}
+
+// all returns every registered metric without counting an Each call
+func (m *MockRegistry) all() []metric.Metric {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var metrics []metric.Metric
 	for _, counter := range m.counters {
-		fn(counter)
+		metrics = append(metrics, counter)
 	}
 	for _, gauge := range m.gauges {
-		fn(gauge)
+		metrics = append(metrics, gauge)
+	}
+	for _, counter := range m.upDownCounters {
+		metrics = append(metrics, counter)
 	}
 	for _, histogram := range m.histograms {
-		fn(histogram)
+		metrics = append(metrics, histogram)
 	}
 	for _, timer := range m.timers {
-		fn(timer)
+		metrics = append(metrics, timer)
+	}
+	for _, meter := range m.meters {
+		metrics = append(metrics, meter)
+	}
+	for _, cardinality := range m.cardinalities {
+		metrics = append(metrics, cardinality)
 	}
+	return metrics
+}
+
+// Watch streams sampled updates for the mock's metrics using metric.SampleUpdates.
+func (m *MockRegistry) Watch(ctx context.Context, filter metric.WatchFilter) (<-chan metric.MetricUpdate, error) {
+	m.mu.Lock()
+	m.WatchCalls = append(m.WatchCalls, filter)
+	m.mu.Unlock()
+
+	return metric.SampleUpdates(ctx, m, filter)
+}
+
+// WatchThreshold evaluates condition on the mock's metrics using metric.EvaluateThreshold.
+func (m *MockRegistry) WatchThreshold(ctx context.Context, name string, condition metric.Condition, fn func(metric.ThresholdEvent)) error {
+	m.mu.Lock()
+	m.WatchThresholdCalls = append(m.WatchThresholdCalls, WatchThresholdCall{Name: name, Condition: condition})
+	m.mu.Unlock()
+
+	return metric.EvaluateThreshold(ctx, m, name, condition, fn)
+}
+
+// Series returns the mock's series of name using metric.CollectSeries.
+func (m *MockRegistry) Series(name string) []metric.SeriesInfo {
+	return metric.CollectSeries(m, name)
 }
 
 // GetCounter retrieves a counter by name for test inspection.
@@ -172,6 +408,13 @@ func (m *MockRegistry) GetCounter(name string) *MockCounter {
 	return m.counters[name]
 }
 
+// GetCounterWithTags retrieves the counter of name whose tags equal tags, for test inspection.
+func (m *MockRegistry) GetCounterWithTags(name string, tags metric.Tags) *MockCounter {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return seriesWithTags(m, m.counters, name, tags)
+}
+
 // GetGauge retrieves a gauge by name for test inspection.
 func (m *MockRegistry) GetGauge(name string) *MockGauge {
 	m.mu.RLock()
@@ -179,6 +422,27 @@ func (m *MockRegistry) GetGauge(name string) *MockGauge {
 	return m.gauges[name]
 }
 
+// GetGaugeWithTags retrieves the gauge of name whose tags equal tags, for test inspection.
+func (m *MockRegistry) GetGaugeWithTags(name string, tags metric.Tags) *MockGauge {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return seriesWithTags(m, m.gauges, name, tags)
+}
+
+// GetUpDownCounter retrieves an up-down counter by name for test inspection.
+func (m *MockRegistry) GetUpDownCounter(name string) *MockUpDownCounter {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.upDownCounters[name]
+}
+
+// GetUpDownCounterWithTags retrieves the up-down counter of name whose tags equal tags, for test inspection.
+func (m *MockRegistry) GetUpDownCounterWithTags(name string, tags metric.Tags) *MockUpDownCounter {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return seriesWithTags(m, m.upDownCounters, name, tags)
+}
+
 // GetHistogram retrieves a histogram by name for test inspection.
 func (m *MockRegistry) GetHistogram(name string) *MockHistogram {
 	m.mu.RLock()
@@ -186,6 +450,13 @@ func (m *MockRegistry) GetHistogram(name string) *MockHistogram {
 	return m.histograms[name]
 }
 
+// GetHistogramWithTags retrieves the histogram of name whose tags equal tags, for test inspection.
+func (m *MockRegistry) GetHistogramWithTags(name string, tags metric.Tags) *MockHistogram {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return seriesWithTags(m, m.histograms, name, tags)
+}
+
 // GetTimer retrieves a timer by name for test inspection.
 func (m *MockRegistry) GetTimer(name string) *MockTimer {
 	m.mu.RLock()
@@ -193,22 +464,74 @@ func (m *MockRegistry) GetTimer(name string) *MockTimer {
 	return m.timers[name]
 }
 
+// GetTimerWithTags retrieves the timer of name whose tags equal tags, for test inspection.
+func (m *MockRegistry) GetTimerWithTags(name string, tags metric.Tags) *MockTimer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return seriesWithTags(m, m.timers, name, tags)
+}
+
+// GetMeter retrieves a meter by name for test inspection.
+func (m *MockRegistry) GetMeter(name string) *MockMeter {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.meters[name]
+}
+
+// GetMeterWithTags retrieves the meter of name whose tags equal tags, for test inspection.
+func (m *MockRegistry) GetMeterWithTags(name string, tags metric.Tags) *MockMeter {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return seriesWithTags(m, m.meters, name, tags)
+}
+
+// GetCardinality retrieves a cardinality by name for test inspection.
+func (m *MockRegistry) GetCardinality(name string) *MockCardinality {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cardinalities[name]
+}
+
+// GetCardinalityWithTags retrieves the cardinality of name whose tags equal tags, for test inspection.
+func (m *MockRegistry) GetCardinalityWithTags(name string, tags metric.Tags) *MockCardinality {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return seriesWithTags(m, m.cardinalities, name, tags)
+}
+
 // Reset clears all metrics and call history.
 func (m *MockRegistry) Reset() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	
-	m.counters = make(map[string]*MockCounter)
-	m.gauges = make(map[string]*MockGauge)
-	m.histograms = make(map[string]*MockHistogram)
-	m.timers = make(map[string]*MockTimer)
+	// Cleared rather than replaced: With() on tag-keyed mocks resolves into these maps
+	clear(m.counters)
+	clear(m.gauges)
+	clear(m.upDownCounters)
+	clear(m.histograms)
+	clear(m.timers)
+	clear(m.meters)
+	clear(m.cardinalities)
 	
 	m.CounterCalls = nil
 	m.GaugeCalls = nil
+	m.GaugeFuncCalls = nil
+	m.DerivedCalls = nil
+	m.UpDownCounterCalls = nil
 	m.HistogramCalls = nil
 	m.TimerCalls = nil
+	m.MeterCalls = nil
+	m.CardinalityCalls = nil
 	m.UnregisterCalls = nil
 	m.EachCalls = 0
+	m.WatchCalls = nil
+	m.WatchThresholdCalls = nil
+	m.ExpireHooks = nil
+	m.SetMetadataCalls = nil
+	m.TagValidationCalls = nil
+	m.PauseCalls = 0
+	m.ResumeCalls = 0
+	m.paused = false
 }
 
 // ManualCleanup performs manual cleanup (no-op for mock)
@@ -216,6 +539,63 @@ func (m *MockRegistry) ManualCleanup() {
 	// No-op for mock registry
 }
 
+// SetMetadata records the call; mock metrics keep the metadata they were created with.
+func (m *MockRegistry) SetMetadata(name, description, unit string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.SetMetadataCalls = append(m.SetMetadataCalls, SetMetadataCall{Name: name, Description: description, Unit: unit})
+}
+
+// UpdateTagValidation records the call; mock metrics are not validated.
+func (m *MockRegistry) UpdateTagValidation(config metric.TagValidationConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.TagValidationCalls = append(m.TagValidationCalls, config)
+	return nil
+}
+
+// OnExpire records fn; Expire runs the recorded hooks.
+func (m *MockRegistry) OnExpire(fn func(metric.Metric)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ExpireHooks = append(m.ExpireHooks, fn)
+}
+
+// Expire simulates TTL expiry of a metric by running the registered expire hooks.
+func (m *MockRegistry) Expire(expired metric.Metric) {
+	m.mu.RLock()
+	hooks := append(([]func(metric.Metric))(nil), m.ExpireHooks...)
+	m.mu.RUnlock()
+
+	for _, hook := range hooks {
+		hook(expired)
+	}
+}
+
+// Pause records the call and marks the registry paused. Mock metrics keep
+// recording writes, so tests can check what code under test did while paused.
+func (m *MockRegistry) Pause() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PauseCalls++
+	m.paused = true
+}
+
+// Resume records the call and clears the paused mark.
+func (m *MockRegistry) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ResumeCalls++
+	m.paused = false
+}
+
+// Paused reports whether Pause was called without a later Resume.
+func (m *MockRegistry) Paused() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.paused
+}
+
 // Close closes the registry (no-op for mock)
 func (m *MockRegistry) Close() error {
 	return nil
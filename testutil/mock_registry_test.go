@@ -0,0 +1,81 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestMockRegistryKeyByTags(t *testing.T) {
+	registry := NewMockRegistry()
+	registry.KeyByTags = true
+
+	registry.Counter(metric.Options{Name: "requests", Tags: metric.Tags{"route": "/a"}}).Inc()
+	registry.Counter(metric.Options{Name: "requests", Tags: metric.Tags{"route": "/b"}}).Add(2)
+
+	AssertCounterValue(t, registry.GetCounterWithTags("requests", metric.Tags{"route": "/a"}), 1)
+	AssertCounterValue(t, registry.GetCounterWithTags("requests", metric.Tags{"route": "/b"}), 2)
+	if registry.GetCounter("requests") != nil {
+		t.Error("Expected no untagged requests counter")
+	}
+
+	// Children returned by With() are registered under their merged tags
+	base := registry.Counter(metric.Options{Name: "requests", Tags: metric.Tags{"route": "/a"}})
+	base.With(metric.Tags{"status": "200"}).Inc()
+	base.With(metric.Tags{"status": "200"}).Inc()
+	base.With(metric.Tags{"status": "500"}).With(metric.Tags{"retry": "true"}).Inc()
+
+	AssertCounterValue(t, registry.GetCounterWithTags("requests", metric.Tags{"route": "/a", "status": "200"}), 2)
+	AssertCounterValue(t, registry.GetCounterWithTags("requests", metric.Tags{"route": "/a", "status": "500", "retry": "true"}), 1)
+	AssertCounterValue(t, registry.GetCounterWithTags("requests", metric.Tags{"route": "/a"}), 1)
+	AssertWithCalls(t, base.(*MockCounter).WithCalls(), []metric.Tags{{"status": "200"}, {"status": "200"}, {"status": "500"}})
+
+	timer := registry.Timer(metric.Options{Name: "latency"})
+	timer.With(metric.Tags{"route": "/a"}).Record(time.Millisecond)
+	if calls := registry.GetTimerWithTags("latency", metric.Tags{"route": "/a"}).RecordCalls(); len(calls) != 1 {
+		t.Errorf("Expected tagged timer child to record once, got %d", len(calls))
+	}
+	if calls := registry.GetTimer("latency").RecordCalls(); len(calls) != 0 {
+		t.Errorf("Expected untagged timer to record nothing, got %d", len(calls))
+	}
+
+	registry.Unregister("requests")
+	if registry.GetCounterWithTags("requests", metric.Tags{"route": "/b"}) != nil {
+		t.Error("Expected Unregister to remove every tagged series of the name")
+	}
+}
+
+func TestMockRegistryGetWithTagsByName(t *testing.T) {
+	registry := NewMockRegistry()
+	counter := registry.Counter(metric.Options{Name: "jobs", Tags: metric.Tags{"queue": "email"}})
+
+	// Without KeyByTags, With() still returns the same mock
+	if counter.With(metric.Tags{"priority": "high"}) != counter {
+		t.Error("Expected With() to return the same mock without KeyByTags")
+	}
+	if registry.GetCounterWithTags("jobs", metric.Tags{"queue": "email"}) != counter {
+		t.Error("Expected lookup by the counter's tags to find it")
+	}
+	if registry.GetCounterWithTags("jobs", metric.Tags{"queue": "sms"}) != nil {
+		t.Error("Expected lookup by other tags to find nothing")
+	}
+}
+
+func TestMockRegistryDerived(t *testing.T) {
+	registry := NewMockRegistry()
+	registry.Counter(metric.Options{Name: "requests_total"}).Add(8)
+	errors := registry.Counter(metric.Options{Name: "errors_total"})
+
+	errorRate := registry.Derived(metric.Options{Name: "error_rate"}, func(s metric.SnapshotView) float64 {
+		return 100 * s.Value("errors_total", nil) / s.Value("requests_total", nil)
+	})
+	errors.Add(2)
+
+	if errorRate.Value() != 25 {
+		t.Errorf("Expected an error rate of 25, got %d", errorRate.Value())
+	}
+	if len(registry.DerivedCalls) != 1 || registry.EachCalls != 0 {
+		t.Errorf("Expected one Derived call and no Each calls, got %d and %d", len(registry.DerivedCalls), registry.EachCalls)
+	}
+}
@@ -0,0 +1,103 @@
+package testutil
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+// HistogramQuantile estimates the q-quantile (0 <= q <= 1) of a histogram snapshot.
+// The estimate interpolates linearly within the bucket holding the target rank,
+// bounded by the snapshot's Min and Max. A snapshot without boundaries yields Max,
+// the most conservative answer available. An empty snapshot yields 0.
+func HistogramQuantile(snapshot metric.HistogramSnapshot, q float64) float64 {
+	if snapshot.Count == 0 {
+		return 0
+	}
+	if len(snapshot.Boundaries) == 0 {
+		return snapshot.Max
+	}
+
+	return snapshot.Quantile(q)
+}
+
+// TimerQuantile returns the q-quantile of the durations recorded by timer.
+// For a MockTimer the exact nearest-rank value of its recorded durations is
+// returned; for any other timer it is estimated from the snapshot buckets.
+func TimerQuantile(timer metric.Timer, q float64) time.Duration {
+	if mock, ok := timer.(*MockTimer); ok {
+		return exactQuantile(mock.RecordCalls(), q)
+	}
+	return time.Duration(HistogramQuantile(timer.Snapshot(), q))
+}
+
+// AssertTimerQuantileBelow verifies that the q-quantile of a timer's recorded
+// durations is below limit. A timer with no recordings fails the assertion.
+func AssertTimerQuantileBelow(t *testing.T, timer metric.Timer, q float64, limit time.Duration) {
+	t.Helper()
+	if timerCount(timer) == 0 {
+		t.Errorf("Expected timer %s to have recordings for p%g assertion, got none", timer.Name(), q*100)
+		return
+	}
+	if actual := TimerQuantile(timer, q); actual >= limit {
+		t.Errorf("Expected timer %s p%g below %v, got %v", timer.Name(), q*100, limit, actual)
+	}
+}
+
+// AssertTimerP50Below verifies that a timer's median duration is below limit.
+func AssertTimerP50Below(t *testing.T, timer metric.Timer, limit time.Duration) {
+	t.Helper()
+	AssertTimerQuantileBelow(t, timer, 0.50, limit)
+}
+
+// AssertTimerP95Below verifies that a timer's 95th percentile duration is below limit.
+func AssertTimerP95Below(t *testing.T, timer metric.Timer, limit time.Duration) {
+	t.Helper()
+	AssertTimerQuantileBelow(t, timer, 0.95, limit)
+}
+
+// AssertTimerP99Below verifies that a timer's 99th percentile duration is below limit.
+func AssertTimerP99Below(t *testing.T, timer metric.Timer, limit time.Duration) {
+	t.Helper()
+	AssertTimerQuantileBelow(t, timer, 0.99, limit)
+}
+
+// AssertHistogramQuantileBelow verifies that the estimated q-quantile of a histogram is below limit.
+func AssertHistogramQuantileBelow(t *testing.T, histogram metric.Histogram, q float64, limit float64) {
+	t.Helper()
+	snapshot := histogram.Snapshot()
+	if snapshot.Count == 0 {
+		t.Errorf("Expected histogram %s to have observations for p%g assertion, got none", histogram.Name(), q*100)
+		return
+	}
+	if actual := HistogramQuantile(snapshot, q); actual >= limit {
+		t.Errorf("Expected histogram %s p%g below %g, got %g", histogram.Name(), q*100, limit, actual)
+	}
+}
+
+// timerCount returns how many durations a timer has recorded.
+func timerCount(timer metric.Timer) int {
+	if mock, ok := timer.(*MockTimer); ok {
+		return len(mock.RecordCalls())
+	}
+	return int(timer.Snapshot().Count)
+}
+
+// exactQuantile returns the nearest-rank q-quantile of durations.
+func exactQuantile(durations []time.Duration, q float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+
+	q = math.Max(0, math.Min(1, q))
+	index := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
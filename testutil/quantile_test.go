@@ -0,0 +1,86 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MichaelAJay/go-metrics/metric"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	snapshot := metric.HistogramSnapshot{
+		Count:      100,
+		Min:        1,
+		Max:        50,
+		Buckets:    []uint64{50, 40, 10, 0},
+		Boundaries: []float64{10, 20, 40},
+	}
+
+	tests := []struct {
+		q        float64
+		expected float64
+	}{
+		{q: 0.25, expected: 5.5},
+		{q: 0.5, expected: 10},
+		{q: 0.7, expected: 15},
+		{q: 0.95, expected: 30},
+		{q: 1, expected: 40},
+	}
+
+	for _, tt := range tests {
+		if actual := HistogramQuantile(snapshot, tt.q); actual != tt.expected {
+			t.Errorf("q=%g: expected %g, got %g", tt.q, tt.expected, actual)
+		}
+	}
+
+	if actual := HistogramQuantile(metric.HistogramSnapshot{}, 0.99); actual != 0 {
+		t.Errorf("Expected 0 for empty snapshot, got %g", actual)
+	}
+}
+
+func TestAssertTimerP99BelowWithRegistryTimer(t *testing.T) {
+	registry := metric.NewNoCleanupRegistry()
+	defer registry.Close()
+
+	timer := registry.Timer(metric.Options{
+		Name:    "request_duration",
+		Buckets: metric.GenerateLinearBuckets(float64(time.Millisecond), float64(time.Millisecond), 20),
+	})
+	for i := 0; i < 99; i++ {
+		timer.Record(2 * time.Millisecond)
+	}
+	timer.Record(15 * time.Millisecond)
+
+	AssertTimerP50Below(t, timer, 3*time.Millisecond)
+	AssertTimerP99Below(t, timer, 3*time.Millisecond)
+
+	if p100 := TimerQuantile(timer, 1); p100 != 15*time.Millisecond {
+		t.Errorf("Expected p100 of 15ms, got %v", p100)
+	}
+
+	// A regression above the limit must fail the assertion
+	mockT := &testing.T{}
+	AssertTimerP99Below(mockT, timer, time.Millisecond)
+	if !mockT.Failed() {
+		t.Error("Expected AssertTimerP99Below to fail for a limit below the observed p99")
+	}
+}
+
+func TestTimerQuantileWithMockTimer(t *testing.T) {
+	timer := NewMockTimer(DefaultTimerOptions())
+	for i := 1; i <= 100; i++ {
+		timer.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	if p99 := TimerQuantile(timer, 0.99); p99 != 99*time.Millisecond {
+		t.Errorf("Expected exact p99 of 99ms, got %v", p99)
+	}
+	AssertTimerP95Below(t, timer, 96*time.Millisecond)
+
+	empty := NewMockTimer(DefaultTimerOptions())
+	mockT := &testing.T{}
+	AssertTimerP99Below(mockT, empty, time.Second)
+	if !mockT.Failed() {
+		t.Error("Expected AssertTimerP99Below to fail for a timer with no recordings")
+	}
+}